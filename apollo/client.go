@@ -1,46 +1,230 @@
 package apollo
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/apolloconfig/agollo/v4"
 	"github.com/apolloconfig/agollo/v4/env/config"
 	"github.com/apolloconfig/agollo/v4/storage"
+	"github.com/zeromicro/go-zero/core/logx"
 	"gopkg.in/yaml.v3"
 )
 
+// defaultRetryInterval 不存在的命名空间再次向服务端查询的最小间隔
+const defaultRetryInterval = 30 * time.Second
+
 type Config struct {
 	AppID        string
 	Cluster      string
 	Addr         string
 	PrivateSpace string
+	// RequirePrivate 为 true 时私有命名空间不存在则 NewClient 返回错误，
+	// 否则只记录日志，命名空间创建后会被自动加载
+	RequirePrivate bool `json:",optional"`
+	// RetryInterval 不存在的命名空间重新查询的间隔，默认 30s
+	RetryInterval time.Duration `json:",optional"`
+}
+
+var (
+	// ErrNamespaceNotFound 命名空间不存在或未加载
+	ErrNamespaceNotFound = errors.New("apollo: namespace not found")
+	// ErrEmptyContent 命名空间内容为空
+	ErrEmptyContent = errors.New("apollo: namespace content is empty")
+)
+
+// NamespaceError 命名空间访问失败，errors.Is 可匹配 ErrNamespaceNotFound / ErrEmptyContent
+type NamespaceError struct {
+	Namespace string
+	Err       error
+}
+
+func (e *NamespaceError) Error() string {
+	return fmt.Sprintf("%v: %s", e.Err, e.Namespace)
+}
+
+func (e *NamespaceError) Unwrap() error {
+	return e.Err
 }
 
 // Client Apollo 客户端封装
 type Client struct {
-	client  *agollo.Client
+	client       *agollo.Client
+	privateSpace string
+	// Default / Private 为创建时加载的命名空间，不存在时为 nil；
+	// 之后创建的命名空间请通过 Namespace / GetContent 访问
 	Default *storage.Config // application namespace
 	Private *storage.Config // private namespace
+
+	getConfig     func(namespace string) *storage.Config
+	retryInterval time.Duration
+	mu            sync.Mutex
+	missing       map[string]time.Time // 命名空间 -> 上次查询不到的时间
 }
 
+// GetPrivateJson 返回私有命名空间的 JSON 内容，出错时返回空
 func (c *Client) GetPrivateJson() []byte {
-	content := strings.TrimPrefix(c.Private.GetContent(), "content=")
+	content, _ := c.GetPrivateJsonE()
 	return []byte(content)
 }
 
-// GetPrivateYamlFromProperties 将 properties 风格内容还原为 YAML（功能等价）
+// GetPrivateJsonE 返回私有命名空间的 JSON 内容
+func (c *Client) GetPrivateJsonE() (string, error) {
+	return c.privateContent()
+}
+
+// GetPrivateYamlFromProperties 将 properties 风格内容还原为 YAML（功能等价），
+// 内容为空或命名空间不存在时返回 "{}"
 func (c *Client) GetPrivateYaml() []byte {
-	content := strings.TrimPrefix(c.Private.GetContent(), "content=")
-	props := parsePropertiesInline(content)
-	data := buildNestedMap(props)
-	out, err := yaml.Marshal(data)
+	content, _ := c.privateContent()
+	out, err := propertiesToYaml(content)
 	if err != nil {
 		return []byte(content)
 	}
 	return out
 }
 
+// GetPrivateYamlE 将 properties 风格内容还原为 YAML
+func (c *Client) GetPrivateYamlE() (string, error) {
+	content, err := c.privateContent()
+	if err != nil {
+		return "", err
+	}
+	out, err := propertiesToYaml(content)
+	if err != nil {
+		return "", fmt.Errorf("apollo: marshal yaml: %w", err)
+	}
+	return string(out), nil
+}
+
+func propertiesToYaml(content string) ([]byte, error) {
+	return yaml.Marshal(buildNestedMap(parsePropertiesInline(content)))
+}
+
+// GetContent 返回指定命名空间的原始内容
+func (c *Client) GetContent(namespace string) (string, error) {
+	return namespaceContent(c.namespace(namespace), namespace)
+}
+
+// GetValue 返回命名空间中 key 的值，key 不存在时返回空字符串
+func (c *Client) GetValue(namespace, key string) (string, error) {
+	cfg, err := c.Namespace(namespace)
+	if err != nil {
+		return "", err
+	}
+	return cfg.GetValue(key), nil
+}
+
+// ContentHash 返回指定命名空间内容的 sha256，用于判断配置是否变化
+func (c *Client) ContentHash(namespace string) (string, error) {
+	content, err := c.GetContent(namespace)
+	if err != nil {
+		return "", err
+	}
+	return hashContent(content), nil
+}
+
+// Changed 比较命名空间当前内容与 lastHash，返回当前 hash 以及是否变化，
+// 调用方可在未变化时跳过重新解析
+func (c *Client) Changed(namespace, lastHash string) (string, bool, error) {
+	hash, err := c.ContentHash(namespace)
+	if err != nil {
+		return "", false, err
+	}
+	return hash, hash != lastHash, nil
+}
+
+// Exists 判断命名空间是否存在，不存在的命名空间按 RetryInterval 间隔重新查询
+func (c *Client) Exists(namespace string) bool {
+	return c.namespace(namespace) != nil
+}
+
+// Namespace 返回命名空间配置，不存在时返回 *NamespaceError
+func (c *Client) Namespace(namespace string) (*storage.Config, error) {
+	if cfg := c.namespace(namespace); cfg != nil {
+		return cfg, nil
+	}
+	return nil, &NamespaceError{Namespace: namespace, Err: ErrNamespaceNotFound}
+}
+
+// PrivateNamespace 返回私有命名空间配置，不存在时返回 *NamespaceError
+func (c *Client) PrivateNamespace() (*storage.Config, error) {
+	return c.Namespace(c.privateSpace)
+}
+
+func (c *Client) privateContent() (string, error) {
+	return c.GetContent(c.privateSpace)
+}
+
+func (c *Client) namespace(namespace string) *storage.Config {
+	switch namespace {
+	case "":
+		return nil
+	case ApplicationNamespace:
+		if c.Default != nil {
+			return c.Default
+		}
+	case c.privateSpace:
+		if c.Private != nil {
+			return c.Private
+		}
+	}
+	return c.lookup(namespace)
+}
+
+// lookup 查询命名空间，查询不到时在 retryInterval 内直接返回 nil，
+// 避免每次访问都同步请求服务端
+func (c *Client) lookup(namespace string) *storage.Config {
+	if c.getConfig == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	last, missing := c.missing[namespace]
+	c.mu.Unlock()
+	if missing && time.Since(last) < c.retryInterval {
+		return nil
+	}
+
+	cfg := c.getConfig(namespace)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cfg == nil {
+		if c.missing == nil {
+			c.missing = make(map[string]time.Time)
+		}
+		c.missing[namespace] = time.Now()
+		return nil
+	}
+	if missing {
+		delete(c.missing, namespace)
+		logx.Infof("apollo: namespace %s is available now", namespace)
+	}
+	return cfg
+}
+
+func namespaceContent(cfg *storage.Config, namespace string) (string, error) {
+	if cfg == nil {
+		return "", &NamespaceError{Namespace: namespace, Err: ErrNamespaceNotFound}
+	}
+	content := strings.TrimPrefix(cfg.GetContent(), "content=")
+	if content == "" {
+		return "", &NamespaceError{Namespace: namespace, Err: ErrEmptyContent}
+	}
+	return content, nil
+}
+
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
 // AddChangeListener 向已存在的客户端添加新的配置变更监听器
 func (c *Client) AddChangeListener(listener storage.ChangeListener) {
 	if c.client != nil {
@@ -67,15 +251,33 @@ func NewClient(conf *Config) (*Client, error) {
 		return nil, fmt.Errorf("create apollo client error: %w", err)
 	}
 
-	c := &Client{
-		client:  &client,
-		Default: client.GetConfig(ApplicationNamespace),
-		Private: client.GetConfig(conf.PrivateSpace),
+	c := newClient(conf, client.GetConfig)
+	c.client = &client
+	if conf.PrivateSpace != "" && c.Private == nil {
+		if conf.RequirePrivate {
+			client.Close()
+			return nil, fmt.Errorf("create apollo client error: %w", &NamespaceError{Namespace: conf.PrivateSpace, Err: ErrNamespaceNotFound})
+		}
+		logx.Errorf("apollo: private namespace %s not found, retry every %s", conf.PrivateSpace, c.retryInterval)
 	}
 
 	return c, nil
 }
 
+func newClient(conf *Config, getConfig func(namespace string) *storage.Config) *Client {
+	c := &Client{
+		privateSpace:  conf.PrivateSpace,
+		getConfig:     getConfig,
+		retryInterval: conf.RetryInterval,
+	}
+	if c.retryInterval <= 0 {
+		c.retryInterval = defaultRetryInterval
+	}
+	c.Default = c.namespace(ApplicationNamespace)
+	c.Private = c.namespace(conf.PrivateSpace)
+	return c
+}
+
 // CustomChangeListener 默认的配置变更监听器
 type CustomChangeListener struct{}
 
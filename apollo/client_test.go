@@ -0,0 +1,193 @@
+package apollo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apolloconfig/agollo/v4/storage"
+	"github.com/zeromicro/go-zero/core/conf"
+)
+
+type fakeServer struct {
+	namespaces map[string]*storage.Config
+	calls      map[string]int
+}
+
+func (f *fakeServer) GetConfig(namespace string) *storage.Config {
+	f.calls[namespace]++
+	return f.namespaces[namespace]
+}
+
+func TestClient_MissingNamespace(t *testing.T) {
+	server := &fakeServer{
+		namespaces: map[string]*storage.Config{ApplicationNamespace: {}},
+		calls:      map[string]int{},
+	}
+	c := newClient(&Config{PrivateSpace: "private", RetryInterval: time.Hour}, server.GetConfig)
+
+	if c.Default == nil || c.Private != nil {
+		t.Fatalf("Default = %v, Private = %v", c.Default, c.Private)
+	}
+	if c.Exists("private") {
+		t.Error("Exists(private) = true before the namespace is created")
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{name: "GetContent", call: func() error { _, err := c.GetContent("private"); return err }},
+		{name: "GetPrivateJsonE", call: func() error { _, err := c.GetPrivateJsonE(); return err }},
+		{name: "GetPrivateYamlE", call: func() error { _, err := c.GetPrivateYamlE(); return err }},
+		{name: "PrivateNamespace", call: func() error { _, err := c.PrivateNamespace(); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			var nsErr *NamespaceError
+			if !errors.Is(err, ErrNamespaceNotFound) || !errors.As(err, &nsErr) || nsErr.Namespace != "private" {
+				t.Errorf("error = %v, want NamespaceError for private", err)
+			}
+		})
+	}
+	if got := c.GetPrivateJson(); len(got) != 0 {
+		t.Errorf("GetPrivateJson() = %q, want empty", got)
+	}
+	if server.calls["private"] != 1 {
+		t.Errorf("server queried %d times within the retry interval, want 1", server.calls["private"])
+	}
+
+	// the namespace is created later and picked up after the retry interval
+	server.namespaces["private"] = &storage.Config{}
+	c.missing["private"] = time.Now().Add(-2 * time.Hour)
+	if !c.Exists("private") {
+		t.Error("Exists(private) = false after the retry interval")
+	}
+	if _, ok := c.missing["private"]; ok {
+		t.Error("namespace still marked missing")
+	}
+}
+
+func TestClient_EmptyNamespace(t *testing.T) {
+	server := &fakeServer{namespaces: map[string]*storage.Config{}, calls: map[string]int{}}
+	c := newClient(&Config{}, server.GetConfig)
+
+	if c.Exists("") {
+		t.Error("Exists(\"\") = true")
+	}
+	if server.calls[""] != 0 {
+		t.Errorf("server queried for the empty namespace %d times", server.calls[""])
+	}
+	if _, err := c.GetPrivateJsonE(); !errors.Is(err, ErrNamespaceNotFound) {
+		t.Errorf("GetPrivateJsonE() error = %v, want %v", err, ErrNamespaceNotFound)
+	}
+}
+
+// namespaceWith returns a loaded namespace holding kv
+func namespaceWith(namespace string, kv map[string]any) *storage.Config {
+	cache := storage.CreateNamespaceConfig(namespace)
+	cache.UpdateApolloConfigCache(kv, 0, namespace)
+	return cache.GetConfig(namespace)
+}
+
+func TestClient_ContentHash(t *testing.T) {
+	server := &fakeServer{
+		namespaces: map[string]*storage.Config{
+			"limits": namespaceWith("limits", map[string]any{"content": `{"daily":100}`}),
+			"empty":  namespaceWith("empty", map[string]any{}),
+		},
+		calls: map[string]int{},
+	}
+	c := newClient(&Config{RetryInterval: time.Hour}, server.GetConfig)
+
+	hash, err := c.ContentHash("limits")
+	if err != nil || len(hash) != 64 {
+		t.Fatalf("ContentHash() = %q, %v", hash, err)
+	}
+	if again, _ := c.ContentHash("limits"); again != hash {
+		t.Errorf("ContentHash() not stable: %s != %s", again, hash)
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		lastHash    string
+		wantChanged bool
+		wantErr     error
+	}{
+		{name: "first load", namespace: "limits", lastHash: "", wantChanged: true},
+		{name: "unchanged", namespace: "limits", lastHash: hash, wantChanged: false},
+		{name: "stale hash", namespace: "limits", lastHash: hashContent(`{"daily":50}`), wantChanged: true},
+		{name: "empty namespace", namespace: "empty", lastHash: hash, wantErr: ErrEmptyContent},
+		{name: "missing namespace", namespace: "missing", lastHash: hash, wantErr: ErrNamespaceNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := c.Changed(tt.namespace, tt.lastHash)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || changed {
+					t.Fatalf("Changed() = %v, %v, want error %v", changed, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != hash || changed != tt.wantChanged {
+				t.Errorf("Changed() = %q, %v, %v, want %q, %v", got, changed, err, hash, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestClient_GetPrivateYaml(t *testing.T) {
+	tests := []struct {
+		name    string
+		private *storage.Config
+		want    string
+		wantErr error
+	}{
+		{
+			name:    "properties",
+			private: namespaceWith("private", map[string]any{"content": "db.host=127.0.0.1\ndb.port=3306"}),
+			want:    "db:\n    host: 127.0.0.1\n    port: 3306\n",
+		},
+		{name: "empty content keeps {}", private: namespaceWith("private", map[string]any{}), want: "{}\n", wantErr: ErrEmptyContent},
+		{name: "missing namespace keeps {}", want: "{}\n", wantErr: ErrNamespaceNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespaces := map[string]*storage.Config{}
+			if tt.private != nil {
+				namespaces["private"] = tt.private
+			}
+			server := &fakeServer{namespaces: namespaces, calls: map[string]int{}}
+			c := newClient(&Config{PrivateSpace: "private"}, server.GetConfig)
+
+			if got := string(c.GetPrivateYaml()); got != tt.want {
+				t.Errorf("GetPrivateYaml() = %q, want %q", got, tt.want)
+			}
+			got, err := c.GetPrivateYamlE()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("GetPrivateYamlE() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("GetPrivateYamlE() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Load(t *testing.T) {
+	var c struct {
+		Apollo Config
+	}
+	content := `{"Apollo": {"AppID": "order", "Cluster": "default", "Addr": "http://apollo:8080", "PrivateSpace": "order.yaml"}}`
+	if err := conf.LoadFromJsonBytes([]byte(content), &c); err != nil {
+		t.Fatalf("LoadFromJsonBytes() error = %v", err)
+	}
+	if c.Apollo.RequirePrivate || c.Apollo.RetryInterval != 0 {
+		t.Errorf("Apollo = %+v, want optional fields unset", c.Apollo)
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // Constants definition
@@ -27,6 +29,9 @@ type PortalClient struct {
 	Namespace  string
 	Operator   string
 	HTTPClient *http.Client
+
+	limiter        *rate.Limiter
+	maxConcurrency int
 }
 
 // NewPortalClient creates a new Portal client instance
@@ -42,6 +47,8 @@ func NewPortalClient(config ApolloConfig) *PortalClient {
 		HTTPClient: &http.Client{
 			Timeout: DefaultTimeout,
 		},
+		limiter:        newLimiter(config.RateLimit, config.Burst),
+		maxConcurrency: config.MaxConcurrency,
 	}
 
 	// Set default values
@@ -54,6 +61,9 @@ func NewPortalClient(config ApolloConfig) *PortalClient {
 	if client.Operator == "" {
 		client.Operator = "apollo"
 	}
+	if client.maxConcurrency <= 0 {
+		client.maxConcurrency = DefaultMaxConcurrency
+	}
 
 	return client
 }
@@ -67,6 +77,13 @@ type ApolloConfig struct {
 	Cluster   string
 	Namespace string
 	Operator  string
+
+	// OpenAPI call budget shared by all methods of the client.
+	// RateLimit is requests per second (0 = DefaultRateLimit, negative disables),
+	// MaxConcurrency bounds in-flight calls of batch operations like SyncItems
+	RateLimit      float64 `json:",optional"`
+	Burst          int     `json:",optional"`
+	MaxConcurrency int     `json:",optional"`
 }
 
 // Item configuration item structure
@@ -151,7 +168,7 @@ func (c *PortalClient) GetItem(ctx context.Context, key string) (*Item, error) {
 	}
 	c.setHeaders(req)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -183,7 +200,7 @@ func (c *PortalClient) ListItems(ctx context.Context) ([]Item, error) {
 	}
 	c.setHeaders(req)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -260,7 +277,7 @@ func (c *PortalClient) doRequest(ctx context.Context, method, url string, payloa
 	}
 	c.setHeaders(req)
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.send(req)
 	if err != nil {
 		return fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -279,6 +296,9 @@ func (c *PortalClient) doRequest(ctx context.Context, method, url string, payloa
 		return nil
 	}
 
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %s (method=%s, url=%s)", ErrThrottled, string(respBody), method, url)
+	}
 	return fmt.Errorf("request failed: %s (status=%d, method=%s, url=%s)",
 		string(respBody), resp.StatusCode, method, url)
 }
@@ -0,0 +1,132 @@
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestApplyPatch(t *testing.T) {
+	const doc = `{"name":"svc","limits":{"qps":100,"burst":10},"hosts":["a","b"],"id":9007199254740993}`
+
+	tests := []struct {
+		name    string
+		patch   string
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "merge patch",
+			patch: `{"limits":{"qps":200,"burst":null},"debug":true}`,
+			want:  `{"debug":true,"hosts":["a","b"],"id":9007199254740993,"limits":{"qps":200},"name":"svc"}`,
+		},
+		{
+			name:  "add replace remove",
+			patch: `[{"op":"add","path":"/hosts/1","value":"x"},{"op":"replace","path":"/name","value":"svc2"},{"op":"remove","path":"/limits/burst"}]`,
+			want:  `{"hosts":["a","x","b"],"id":9007199254740993,"limits":{"qps":100},"name":"svc2"}`,
+		},
+		{
+			name:  "append move copy",
+			patch: `[{"op":"add","path":"/hosts/-","value":"c"},{"op":"move","from":"/name","path":"/app"},{"op":"copy","from":"/limits","path":"/defaults"}]`,
+			want:  `{"app":"svc","defaults":{"burst":10,"qps":100},"hosts":["a","b","c"],"id":9007199254740993,"limits":{"burst":10,"qps":100}}`,
+		},
+		{
+			name:  "escaped pointer",
+			patch: `[{"op":"add","path":"/a~1b~0c","value":1},{"op":"test","path":"/a~1b~0c","value":1}]`,
+			want:  `{"a/b~c":1,"hosts":["a","b"],"id":9007199254740993,"limits":{"burst":10,"qps":100},"name":"svc"}`,
+		},
+		{
+			name:    "test mismatch",
+			patch:   `[{"op":"test","path":"/limits/qps","value":1},{"op":"remove","path":"/name"}]`,
+			wantErr: ErrPatchTest,
+		},
+		{name: "replace missing", patch: `[{"op":"replace","path":"/nope","value":1}]`, wantErr: errAny},
+		{name: "index out of range", patch: `[{"op":"add","path":"/hosts/5","value":1}]`, wantErr: errAny},
+		{name: "move into child", patch: `[{"op":"move","from":"/limits","path":"/limits/x"}]`, wantErr: errAny},
+		{name: "not a patch", patch: `"x"`, wantErr: errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := decodeJSON([]byte(doc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := applyPatch(d, []byte(tt.patch))
+			if tt.wantErr != nil {
+				if err == nil || (tt.wantErr != errAny && !errors.Is(err, tt.wantErr)) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			out, _ := json.Marshal(got)
+			if string(out) != tt.want {
+				t.Errorf("got  %s\nwant %s", out, tt.want)
+			}
+		})
+	}
+}
+
+var errAny = errors.New("any error")
+
+func TestPatchJSONNamespace(t *testing.T) {
+	content := "{\n  \"feature\": {\n    \"enabled\": false\n  },\n  \"url\": \"a?b=1&c=2\"\n}"
+	var (
+		updated   string
+		published int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/items/content"):
+			_ = json.NewEncoder(w).Encode(Item{Key: JSONContentKey, Value: content, Comment: "doc"})
+		case r.Method == http.MethodPut && strings.HasSuffix(r.URL.Path, "/items/content"):
+			body, _ := io.ReadAll(r.Body)
+			var item Item
+			_ = json.Unmarshal(body, &item)
+			updated = item.Value
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/releases"):
+			published++
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewPortalClient(ApolloConfig{PortalURL: srv.URL, AppID: "app", Env: "DEV", Namespace: "biz.json", RateLimit: -1})
+	ctx := context.Background()
+
+	doc, err := c.GetJSONNamespace(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc["url"] != "a?b=1&c=2" {
+		t.Fatalf("unexpected document: %v", doc)
+	}
+
+	// a patch that changes nothing is neither written nor published
+	if err := c.PatchJSONNamespace(ctx, []byte(`{"feature":{"enabled":false}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if updated != "" || published != 0 {
+		t.Fatalf("no-op patch wrote %q, published %d", updated, published)
+	}
+
+	if err := c.PatchJSONNamespace(ctx, []byte(`[{"op":"replace","path":"/feature/enabled","value":true}]`)); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"feature\": {\n    \"enabled\": true\n  },\n  \"url\": \"a?b=1&c=2\"\n}"
+	if updated != want {
+		t.Errorf("updated content:\n%s\nwant:\n%s", updated, want)
+	}
+	if published != 1 {
+		t.Errorf("published %d times, want 1", published)
+	}
+}
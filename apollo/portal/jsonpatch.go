@@ -0,0 +1,312 @@
+package portal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrPatchTest is returned when a "test" operation of a JSON Patch does not match
+var ErrPatchTest = errors.New("json patch test failed")
+
+// patchOp is one RFC 6902 operation
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// applyPatch applies patch to doc: a JSON array is an RFC 6902 JSON Patch,
+// a JSON object is an RFC 7396 merge patch
+func applyPatch(doc any, patch []byte) (any, error) {
+	patch = bytes.TrimSpace(patch)
+	if len(patch) == 0 {
+		return nil, errors.New("empty patch")
+	}
+	switch patch[0] {
+	case '[':
+		var ops []patchOp
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return nil, fmt.Errorf("invalid json patch: %w", err)
+		}
+		return applyJSONPatch(doc, ops)
+	case '{':
+		merge, err := decodeJSON(patch)
+		if err != nil {
+			return nil, fmt.Errorf("invalid merge patch: %w", err)
+		}
+		return applyMergePatch(doc, merge), nil
+	default:
+		return nil, errors.New("patch must be a JSON array (RFC 6902) or object (RFC 7396)")
+	}
+}
+
+// applyMergePatch implements RFC 7396: objects merge recursively, null deletes
+// a member and any other value replaces the target
+func applyMergePatch(doc, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	target, ok := doc.(map[string]any)
+	if !ok {
+		target = map[string]any{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(target, k)
+			continue
+		}
+		target[k] = applyMergePatch(target[k], v)
+	}
+	return target
+}
+
+func applyJSONPatch(doc any, ops []patchOp) (any, error) {
+	var err error
+	for i, op := range ops {
+		if doc, err = applyOp(doc, op); err != nil {
+			return nil, fmt.Errorf("op %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOp(doc any, op patchOp) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, errors.New("missing value")
+		}
+		value, err := decodeJSON(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value: %w", err)
+		}
+		if op.Op == "test" {
+			current, err := getPointer(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrPatchTest
+			}
+			return doc, nil
+		}
+		return setPointer(doc, path, value, op.Op == "replace")
+	case "remove":
+		doc, _, err = removePointer(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		var value any
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, errors.New("cannot move a value into its own child")
+			}
+			doc, value, err = removePointer(doc, from)
+		} else {
+			value, err = getPointer(doc, from)
+			if err == nil {
+				value, err = deepCopy(value)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		return setPointer(doc, path, value, false)
+	default:
+		return nil, fmt.Errorf("unsupported op %q", op.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("invalid json pointer %q", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func getPointer(doc any, path []string) (any, error) {
+	node := doc
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			v, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found: %q", token)
+			}
+			node = v
+		case []any:
+			i, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot index into %T with %q", node, token)
+		}
+	}
+	return node, nil
+}
+
+// setPointer adds value at path, or replaces the existing value when replace is
+// set, and returns the updated document
+func setPointer(doc any, path []string, value any, replace bool) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent any, token string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			if _, ok := p[token]; replace && !ok {
+				return nil, fmt.Errorf("path not found: %q", token)
+			}
+			p[token] = value
+			return p, nil
+		case []any:
+			if replace {
+				i, err := arrayIndex(token, len(p)-1)
+				if err != nil {
+					return nil, err
+				}
+				p[i] = value
+				return p, nil
+			}
+			if token == "-" {
+				return append(p, value), nil
+			}
+			i, err := arrayIndex(token, len(p))
+			if err != nil {
+				return nil, err
+			}
+			p = append(p, nil)
+			copy(p[i+1:], p[i:])
+			p[i] = value
+			return p, nil
+		default:
+			return nil, fmt.Errorf("cannot set %q on %T", token, parent)
+		}
+	})
+}
+
+// removePointer removes the value at path and returns the updated document and
+// the removed value
+func removePointer(doc any, path []string) (any, any, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("cannot remove the whole document")
+	}
+	var removed any
+	doc, err := updateParent(doc, path, func(parent any, token string) (any, error) {
+		switch p := parent.(type) {
+		case map[string]any:
+			v, ok := p[token]
+			if !ok {
+				return nil, fmt.Errorf("path not found: %q", token)
+			}
+			removed = v
+			delete(p, token)
+			return p, nil
+		case []any:
+			i, err := arrayIndex(token, len(p)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = p[i]
+			return append(p[:i], p[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove %q from %T", token, parent)
+		}
+	})
+	return doc, removed, err
+}
+
+// updateParent resolves the parent of path, applies fn to it and stores the
+// result back, since inserting into an array changes the slice
+func updateParent(doc any, path []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	child, err := getPointer(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = updateParent(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+	switch n := doc.(type) {
+	case map[string]any:
+		n[path[0]] = child
+	case []any:
+		i, _ := arrayIndex(path[0], len(n)-1)
+		n[i] = child
+	}
+	return doc, nil
+}
+
+// arrayIndex parses token as an array index in [0, max]
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max {
+		return 0, fmt.Errorf("array index %q out of range", token)
+	}
+	return i, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func deepCopy(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJSON(data)
+}
+
+// decodeJSON decodes with json.Number so large integers survive a round trip
+func decodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return v, nil
+}
@@ -0,0 +1,101 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/zeromicro/go-zero/core/metric"
+	"golang.org/x/time/rate"
+)
+
+// Default OpenAPI call budget, well below what the portal throttles
+const (
+	DefaultRateLimit      = 10
+	DefaultBurst          = 10
+	DefaultMaxConcurrency = 4
+)
+
+var throttledTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "apollo",
+	Subsystem: "portal",
+	Name:      "throttled_total",
+	Help:      "How many OpenAPI calls were throttled, partitioned by app and source (local limiter or server 429).",
+	Labels:    []string{"app", "source"},
+})
+
+// ErrThrottled is wrapped by errors of calls rejected by the portal with 429
+var ErrThrottled = errors.New("apollo portal throttled the request")
+
+// send waits for the rate limiter and executes req
+func (c *PortalClient) send(req *http.Request) (*http.Response, error) {
+	if c.limiter != nil && !c.limiter.Allow() {
+		throttledTotal.Inc(c.AppID, "local")
+		if err := c.limiter.Wait(req.Context()); err != nil {
+			return nil, fmt.Errorf("wait for rate limiter: %w", err)
+		}
+	}
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		throttledTotal.Inc(c.AppID, "server")
+	}
+	return resp, nil
+}
+
+func newLimiter(ratePerSec float64, burst int) *rate.Limiter {
+	if ratePerSec < 0 {
+		// negative rate disables limiting
+		return nil
+	}
+	if ratePerSec == 0 {
+		ratePerSec = DefaultRateLimit
+	}
+	if burst <= 0 {
+		burst = DefaultBurst
+	}
+	return rate.NewLimiter(rate.Limit(ratePerSec), burst)
+}
+
+// forEach runs fn for every item with at most c.maxConcurrency calls in
+// flight, DefaultMaxConcurrency for clients not built by NewPortalClient,
+// and returns the joined errors
+func (c *PortalClient) forEach(ctx context.Context, n int, fn func(ctx context.Context, i int) error) error {
+	limit := c.maxConcurrency
+	if limit <= 0 {
+		limit = DefaultMaxConcurrency
+	}
+	sem := make(chan struct{}, limit)
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = fn(ctx, i)
+		}(i)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,45 @@
+package portal
+
+import (
+	"context"
+	"fmt"
+)
+
+// SyncItems creates or updates items so the namespace holds the given values.
+// Items already up to date are skipped and keys missing from items are kept.
+// Calls run with bounded concurrency under the client rate limit; the changes
+// still need PublishConfig to take effect
+func (c *PortalClient) SyncItems(ctx context.Context, items []Item) error {
+	existing, err := c.ListItems(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list items for sync: %w", err)
+	}
+
+	current := make(map[string]Item, len(existing))
+	for _, it := range existing {
+		current[it.Key] = it
+	}
+
+	var pending []Item
+	for _, it := range items {
+		if old, ok := current[it.Key]; ok && old.Value == it.Value && old.Comment == it.Comment {
+			continue
+		}
+		pending = append(pending, it)
+	}
+
+	return c.forEach(ctx, len(pending), func(ctx context.Context, i int) error {
+		it := pending[i]
+		if _, ok := current[it.Key]; ok {
+			return c.UpdateItem(ctx, it.Key, it.Value, it.Comment)
+		}
+		return c.CreateItem(ctx, it.Key, it.Value, it.Comment)
+	})
+}
+
+// DeleteItems deletes keys with bounded concurrency under the client rate limit
+func (c *PortalClient) DeleteItems(ctx context.Context, keys []string) error {
+	return c.forEach(ctx, len(keys), func(ctx context.Context, i int) error {
+		return c.DeleteItem(ctx, keys[i])
+	})
+}
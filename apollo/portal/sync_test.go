@@ -0,0 +1,132 @@
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/conf"
+)
+
+func TestSyncItems(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		calls    = map[string]int{}
+		inFlight atomic.Int32
+		peak     atomic.Int32
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			_ = json.NewEncoder(w).Encode([]Item{{Key: "same", Value: "1"}, {Key: "changed", Value: "old"}})
+			return
+		}
+
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		calls[r.Method]++
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	c := NewPortalClient(ApolloConfig{PortalURL: srv.URL, AppID: "app", Env: "DEV", RateLimit: -1, MaxConcurrency: 2})
+
+	items := []Item{
+		{Key: "same", Value: "1"},
+		{Key: "changed", Value: "new"},
+		{Key: "a", Value: "1"},
+		{Key: "b", Value: "1"},
+		{Key: "c", Value: "1"},
+	}
+	if err := c.SyncItems(context.Background(), items); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls[http.MethodPut] != 1 || calls[http.MethodPost] != 3 {
+		t.Fatalf("unexpected calls: %v", calls)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Fatalf("concurrency %d exceeds limit", p)
+	}
+}
+
+func TestDeleteItemsLiteralClient(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+	}))
+	defer srv.Close()
+
+	// a struct literal has no limiter, concurrency limit or HTTP client
+	c := &PortalClient{PortalURL: srv.URL, AppID: "app", Env: "DEV", Cluster: "default", Namespace: "application"}
+
+	done := make(chan error, 1)
+	go func() { done <- c.DeleteItems(context.Background(), []string{"a", "b", "c"}) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("DeleteItems deadlocked")
+	}
+	if hits.Load() != 3 {
+		t.Fatalf("hits = %d, want 3", hits.Load())
+	}
+}
+
+func TestRateLimitAndThrottled(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) > 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewPortalClient(ApolloConfig{PortalURL: srv.URL, AppID: "app", Env: "DEV", RateLimit: 20, Burst: 1})
+
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if err := c.DeleteItem(context.Background(), "k"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("limiter did not delay second call: %v", elapsed)
+	}
+
+	err := c.DeleteItem(context.Background(), "k")
+	if !errors.Is(err, ErrThrottled) {
+		t.Fatalf("expected ErrThrottled, got %v", err)
+	}
+}
+
+func TestApolloConfig_Load(t *testing.T) {
+	var c struct {
+		Portal ApolloConfig
+	}
+	content := `{"Portal": {"PortalURL": "http://portal:8070", "Token": "t", "AppID": "app", "Env": "DEV",
+		"Cluster": "default", "Namespace": "application", "Operator": "apollo"}}`
+	if err := conf.LoadFromJsonBytes([]byte(content), &c); err != nil {
+		t.Fatalf("LoadFromJsonBytes() error = %v", err)
+	}
+	if client := NewPortalClient(c.Portal); client.maxConcurrency != DefaultMaxConcurrency {
+		t.Errorf("maxConcurrency = %d, want %d", client.maxConcurrency, DefaultMaxConcurrency)
+	}
+}
@@ -4,10 +4,26 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// Handler priorities, any int is accepted
+const (
+	PriorityCritical   = 100  // e.g. state persistence
+	PriorityNormal     = 0    // default for Subscribe
+	PriorityBestEffort = -100 // e.g. notifications
+)
+
+// Subscriber registers handlers for a topic.
+//
+// Ordering: Publish calls the handlers of a topic synchronously, in descending
+// priority; handlers with equal priority run in subscription order. Publish stops
+// at the first handler returning an error, so lower priority handlers are
+// skipped when a higher priority one fails.
 type Subscriber interface {
 	Subscribe(topic EventTopic, fn interface{}) error
+	SubscribeWithPriority(topic EventTopic, fn interface{}, priority int) error
 	SubscribeOnce(topic EventTopic, fn interface{}) error
 	Unsubscribe(topic EventTopic, handler interface{}) error
 }
@@ -19,16 +35,20 @@ type Publisher interface {
 type Bus interface {
 	Subscriber
 	Publisher
+	DelayedPublisher
 }
 
 type eventHandler struct {
 	callback reflect.Value
 	once     bool
+	priority int
 }
 
 type EventBus struct {
-	handlers map[EventTopic][]*eventHandler
-	mu       sync.RWMutex
+	handlers  map[EventTopic][]*eventHandler
+	mu        sync.RWMutex
+	scheduler *Scheduler
+	traceMode atomic.Int32 // TraceMode, see WithTracing
 }
 
 func (e *EventBus) doSubscribe(topic EventTopic, fn interface{}, handler *eventHandler) error {
@@ -38,7 +58,20 @@ func (e *EventBus) doSubscribe(topic EventTopic, fn interface{}, handler *eventH
 	if reflect.TypeOf(fn).Kind() != reflect.Func {
 		return fmt.Errorf("%s is not of type reflect.Func", reflect.TypeOf(fn).Kind())
 	}
-	e.handlers[topic] = append(e.handlers[topic], handler)
+
+	// keep handlers sorted by descending priority, stable for equal priorities
+	handlers := e.handlers[topic]
+	idx := len(handlers)
+	for i, h := range handlers {
+		if h.priority < handler.priority {
+			idx = i
+			break
+		}
+	}
+	handlers = append(handlers, nil)
+	copy(handlers[idx+1:], handlers[idx:])
+	handlers[idx] = handler
+	e.handlers[topic] = handlers
 	return nil
 }
 
@@ -93,11 +126,16 @@ func (e *EventBus) parseArgs(callback *eventHandler, args ...interface{}) []refl
 }
 
 func (e *EventBus) Subscribe(topic EventTopic, fn interface{}) error {
-	return e.doSubscribe(topic, fn, &eventHandler{reflect.ValueOf(fn), false})
+	return e.SubscribeWithPriority(topic, fn, PriorityNormal)
+}
+
+// SubscribeWithPriority subscribes fn to topic, higher priority handlers run first
+func (e *EventBus) SubscribeWithPriority(topic EventTopic, fn interface{}, priority int) error {
+	return e.doSubscribe(topic, fn, &eventHandler{callback: reflect.ValueOf(fn), priority: priority})
 }
 
 func (e *EventBus) SubscribeOnce(topic EventTopic, fn interface{}) error {
-	return e.doSubscribe(topic, fn, &eventHandler{reflect.ValueOf(fn), true})
+	return e.doSubscribe(topic, fn, &eventHandler{callback: reflect.ValueOf(fn), once: true})
 }
 
 func (e *EventBus) Unsubscribe(topic EventTopic, handler interface{}) error {
@@ -115,6 +153,9 @@ func (e *EventBus) Publish(topic EventTopic, args ...interface{}) error {
 	e.mu.RLock()
 	defer e.mu.RUnlock()
 
+	t := e.startPublish(topic, args)
+	called := 0
+	var err error
 	if handlers, ok := e.handlers[topic]; ok && len(handlers) > 0 {
 		copyHandlers := make([]*eventHandler, len(handlers))
 		copy(copyHandlers, handlers)
@@ -123,19 +164,40 @@ func (e *EventBus) Publish(topic EventTopic, args ...interface{}) error {
 			// if handler.once {
 			// e.removeHandler(topic, i)
 			// }
-			err := e.doPublish(handler, args...)
+			called++
+			if t != nil {
+				err = t.handle(e, handler, args)
+			} else {
+				err = e.doPublish(handler, args...)
+			}
 			if err != nil {
-				return err
+				break
 			}
 		}
 	}
-	return nil
+	if t != nil {
+		t.end(called, err)
+	}
+	return err
+}
+
+// PublishAfter publishes args to topic once delay has elapsed, see Scheduler
+func (e *EventBus) PublishAfter(topic EventTopic, delay time.Duration, args ...interface{}) *Scheduled {
+	return e.scheduler.PublishAfter(topic, delay, args...)
 }
 
-func New() Bus {
+// PublishAt publishes args to topic at t, see Scheduler
+func (e *EventBus) PublishAt(topic EventTopic, at time.Time, args ...interface{}) *Scheduled {
+	return e.scheduler.PublishAt(topic, at, args...)
+}
+
+func New(opts ...Option) Bus {
 	b := &EventBus{
-		make(map[EventTopic][]*eventHandler),
-		sync.RWMutex{},
+		handlers: make(map[EventTopic][]*eventHandler),
+	}
+	for _, opt := range opts {
+		opt(b)
 	}
+	b.scheduler = NewScheduler(b)
 	return b
 }
@@ -0,0 +1,65 @@
+package bus
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEventBus_PriorityOrder(t *testing.T) {
+	const topic EventTopic = "order.paid"
+
+	tests := []struct {
+		name       string
+		priorities []int
+		want       []int // handler indexes in call order
+	}{
+		{name: "default keeps subscription order", priorities: []int{0, 0, 0}, want: []int{0, 1, 2}},
+		{name: "critical before best effort", priorities: []int{PriorityBestEffort, PriorityNormal, PriorityCritical}, want: []int{2, 1, 0}},
+		{name: "stable within priority", priorities: []int{PriorityCritical, PriorityBestEffort, PriorityCritical}, want: []int{0, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New()
+			var calls []int
+			for i, p := range tt.priorities {
+				i := i
+				if err := b.SubscribeWithPriority(topic, func(id string) error {
+					calls = append(calls, i)
+					return nil
+				}, p); err != nil {
+					t.Fatalf("SubscribeWithPriority() error = %v", err)
+				}
+			}
+
+			if err := b.Publish(topic, "1001"); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+			if !reflect.DeepEqual(calls, tt.want) {
+				t.Fatalf("call order = %v, want %v", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventBus_CriticalFailureStopsPublish(t *testing.T) {
+	const topic EventTopic = "order.paid"
+	b := New()
+
+	notified := false
+	_ = b.SubscribeWithPriority(topic, func(id string) error {
+		notified = true
+		return nil
+	}, PriorityBestEffort)
+	_ = b.SubscribeWithPriority(topic, func(id string) error {
+		return errors.New("persist failed")
+	}, PriorityCritical)
+
+	if err := b.Publish(topic, "1001"); err == nil {
+		t.Fatal("Publish() expected error from critical handler")
+	}
+	if notified {
+		t.Fatal("best effort handler ran after critical handler failed")
+	}
+}
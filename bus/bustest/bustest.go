@@ -0,0 +1,186 @@
+// Package bustest provides a recording bus.Bus for unit tests of event publishers.
+package bustest
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"gomod.pri/golib/bus"
+)
+
+// Event is a single recorded Publish call
+type Event struct {
+	Topic bus.EventTopic
+	Args  []interface{}
+	Err   error
+}
+
+// Matcher reports whether the published args are the expected ones
+type Matcher func(args ...interface{}) bool
+
+// Any matches every publish
+func Any() Matcher {
+	return func(args ...interface{}) bool { return true }
+}
+
+// Args matches publishes whose args deep-equal want
+func Args(want ...interface{}) Matcher {
+	return func(args ...interface{}) bool { return reflect.DeepEqual(args, want) }
+}
+
+// Recorder is a bus.Bus recording every Publish. Subscribed handlers still run
+// on an internal bus.New(), so it can replace the real bus without further mocks.
+type Recorder struct {
+	bus.Bus
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	events   []Event
+	failures map[bus.EventTopic]error
+	inflight int
+	delayed  *bus.Scheduler
+}
+
+var _ bus.Bus = (*Recorder)(nil)
+
+func NewRecorder() *Recorder {
+	r := &Recorder{Bus: bus.New(), failures: make(map[bus.EventTopic]error)}
+	r.cond = sync.NewCond(&r.mu)
+	r.delayed = bus.NewScheduler(r)
+	return r
+}
+
+// FailWith makes Publish on topic return err without calling handlers, nil resets it
+func (r *Recorder) FailWith(topic bus.EventTopic, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		delete(r.failures, topic)
+		return
+	}
+	r.failures[topic] = err
+}
+
+func (r *Recorder) Publish(topic bus.EventTopic, args ...interface{}) error {
+	r.mu.Lock()
+	err, failed := r.failures[topic]
+	r.inflight++
+	r.mu.Unlock()
+
+	if !failed {
+		err = r.Bus.Publish(topic, args...)
+	}
+
+	r.mu.Lock()
+	r.inflight--
+	r.events = append(r.events, Event{Topic: topic, Args: append([]interface{}(nil), args...), Err: err})
+	r.cond.Broadcast()
+	r.mu.Unlock()
+	return err
+}
+
+// PublishAfter records the event when it is published, use DrainAndWait to wait for it
+func (r *Recorder) PublishAfter(topic bus.EventTopic, delay time.Duration, args ...interface{}) *bus.Scheduled {
+	return r.delayed.PublishAfter(topic, delay, args...)
+}
+
+// PublishAt records the event when it is published, use DrainAndWait to wait for it
+func (r *Recorder) PublishAt(topic bus.EventTopic, at time.Time, args ...interface{}) *bus.Scheduled {
+	return r.delayed.PublishAt(topic, at, args...)
+}
+
+// Events returns a copy of the recorded events
+func (r *Recorder) Events() []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+// Published returns the recorded events of topic
+func (r *Recorder) Published(topic bus.EventTopic) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Event
+	for _, e := range r.events {
+		if e.Topic == topic {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Reset drops the recorded events
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = nil
+}
+
+// AssertPublished fails t unless an event on topic matched
+func (r *Recorder) AssertPublished(t testing.TB, topic bus.EventTopic, match Matcher) {
+	t.Helper()
+	if match == nil {
+		match = Any()
+	}
+	events := r.Published(topic)
+	for _, e := range events {
+		if match(e.Args...) {
+			return
+		}
+	}
+	t.Errorf("bustest: no matching publish on %q, got %s", topic, describe(events))
+}
+
+// AssertNotPublished fails t if anything was published on topic
+func (r *Recorder) AssertNotPublished(t testing.TB, topic bus.EventTopic) {
+	t.Helper()
+	if events := r.Published(topic); len(events) > 0 {
+		t.Errorf("bustest: unexpected publish on %q: %s", topic, describe(events))
+	}
+}
+
+// DrainAndWait waits until at least n events were recorded and no Publish is in
+// flight, then returns and clears them. It fails t after timeout, which makes it
+// suitable for components publishing from their own goroutines.
+func (r *Recorder) DrainAndWait(t testing.TB, n int, timeout time.Duration) []Event {
+	t.Helper()
+
+	timedOut := false
+	timer := time.AfterFunc(timeout, func() {
+		r.mu.Lock()
+		timedOut = true
+		r.cond.Broadcast()
+		r.mu.Unlock()
+	})
+	defer timer.Stop()
+
+	r.mu.Lock()
+	for (len(r.events) < n || r.inflight > 0) && !timedOut {
+		r.cond.Wait()
+	}
+	events := r.events
+	r.events = nil
+	r.mu.Unlock()
+
+	if len(events) < n {
+		t.Errorf("bustest: got %d events after %s, want at least %d: %s", len(events), timeout, n, describe(events))
+	}
+	return events
+}
+
+func describe(events []Event) string {
+	if len(events) == 0 {
+		return "none"
+	}
+	s := ""
+	for i, e := range events {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s%v", e.Topic, e.Args)
+	}
+	return s
+}
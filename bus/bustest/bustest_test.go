@@ -0,0 +1,108 @@
+package bustest
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gomod.pri/golib/bus"
+)
+
+const topic bus.EventTopic = "order.paid"
+
+func TestRecorder_AssertPublished(t *testing.T) {
+	r := NewRecorder()
+
+	var handled string
+	if err := r.Subscribe(topic, func(id string, amount int) error {
+		handled = id
+		return nil
+	}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	if err := r.Publish(topic, "1001", 30); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if handled != "1001" {
+		t.Fatalf("handler not called, got %q", handled)
+	}
+
+	r.AssertPublished(t, topic, Args("1001", 30))
+	r.AssertPublished(t, topic, nil)
+	r.AssertNotPublished(t, "order.refunded")
+
+	ft := &fakeT{}
+	r.AssertPublished(ft, topic, Args("1002", 30))
+	if !ft.failed {
+		t.Fatal("AssertPublished should fail for unmatched args")
+	}
+}
+
+func TestRecorder_FailWith(t *testing.T) {
+	r := NewRecorder()
+	called := false
+	_ = r.Subscribe(topic, func(id string) error {
+		called = true
+		return nil
+	})
+
+	want := errors.New("persist failed")
+	r.FailWith(topic, want)
+	if err := r.Publish(topic, "1001"); !errors.Is(err, want) {
+		t.Fatalf("Publish() error = %v, want %v", err, want)
+	}
+	if called {
+		t.Fatal("handlers should be skipped on injected failure")
+	}
+	if events := r.Published(topic); len(events) != 1 || events[0].Err != want {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	r.FailWith(topic, nil)
+	if err := r.Publish(topic, "1001"); err != nil || !called {
+		t.Fatalf("Publish() error = %v, called = %v", err, called)
+	}
+}
+
+func TestRecorder_DrainAndWait(t *testing.T) {
+	r := NewRecorder()
+	for i := 0; i < 3; i++ {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			_ = r.Publish(topic, "1001")
+		}()
+	}
+
+	if events := r.DrainAndWait(t, 3, time.Second); len(events) != 3 {
+		t.Fatalf("DrainAndWait() = %d events, want 3", len(events))
+	}
+	if len(r.Events()) != 0 {
+		t.Fatal("DrainAndWait should clear recorded events")
+	}
+
+	ft := &fakeT{}
+	r.DrainAndWait(ft, 1, 20*time.Millisecond)
+	if !ft.failed {
+		t.Fatal("DrainAndWait should fail on timeout")
+	}
+}
+
+func TestRecorder_PublishAfter(t *testing.T) {
+	r := NewRecorder()
+	r.PublishAfter(topic, 60*time.Millisecond, "1001")
+	r.AssertNotPublished(t, topic)
+
+	events := r.DrainAndWait(t, 1, time.Second)
+	if len(events) != 1 || events[0].Args[0] != "1001" {
+		t.Fatalf("recorded %v, want the delayed event", events)
+	}
+}
+
+type fakeT struct {
+	testing.TB
+	failed bool
+}
+
+func (f *fakeT) Helper()                       {}
+func (f *fakeT) Errorf(string, ...interface{}) { f.failed = true }
@@ -1,5 +1,7 @@
 package bus
 
+import "time"
+
 var globalEventBus Bus
 
 func init() {
@@ -10,6 +12,10 @@ func Subscribe(topic EventTopic, fn interface{}) error {
 	return globalEventBus.Subscribe(topic, fn)
 }
 
+func SubscribeWithPriority(topic EventTopic, fn interface{}, priority int) error {
+	return globalEventBus.SubscribeWithPriority(topic, fn, priority)
+}
+
 func Unsubscribe(topic EventTopic, fn interface{}) error {
 	return globalEventBus.Unsubscribe(topic, fn)
 }
@@ -17,3 +23,11 @@ func Unsubscribe(topic EventTopic, fn interface{}) error {
 func Publish(topic EventTopic, args ...interface{}) error {
 	return globalEventBus.Publish(topic, args...)
 }
+
+func PublishAfter(topic EventTopic, delay time.Duration, args ...interface{}) *Scheduled {
+	return globalEventBus.PublishAfter(topic, delay, args...)
+}
+
+func PublishAt(topic EventTopic, at time.Time, args ...interface{}) *Scheduled {
+	return globalEventBus.PublishAt(topic, at, args...)
+}
@@ -0,0 +1,129 @@
+package bus
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/collection"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/threading"
+)
+
+// timing wheel resolution, scheduled events fire up to one tick late
+const (
+	scheduleTick  = 50 * time.Millisecond
+	scheduleSlots = 1200 // one minute per turn, longer delays take several turns
+)
+
+// DelayedPublisher publishes events later. Scheduled events live in memory only
+// and are lost on restart, use a message queue for anything that must survive it
+type DelayedPublisher interface {
+	PublishAfter(topic EventTopic, delay time.Duration, args ...interface{}) *Scheduled
+	PublishAt(topic EventTopic, at time.Time, args ...interface{}) *Scheduled
+}
+
+const (
+	scheduledPending int32 = iota
+	scheduledFired
+	scheduledCancelled
+)
+
+// Scheduled is the handle of a delayed publish
+type Scheduled struct {
+	Topic EventTopic
+	At    time.Time
+
+	args      []interface{}
+	state     atomic.Int32
+	scheduler *Scheduler
+}
+
+// Cancel prevents the publish, it returns false when the event was already
+// published or cancelled
+func (s *Scheduled) Cancel() bool {
+	if !s.state.CompareAndSwap(scheduledPending, scheduledCancelled) {
+		return false
+	}
+	if s.scheduler.wheel != nil {
+		_ = s.scheduler.wheel.RemoveTimer(s)
+	}
+	return true
+}
+
+// Scheduler publishes events to a Publisher after a delay. The handlers run on a
+// background goroutine, their errors are logged
+type Scheduler struct {
+	pub     Publisher
+	once    sync.Once
+	wheel   *collection.TimingWheel
+	stopped atomic.Bool
+}
+
+func NewScheduler(pub Publisher) *Scheduler {
+	return &Scheduler{pub: pub}
+}
+
+// PublishAfter publishes args to topic once delay has elapsed
+func (s *Scheduler) PublishAfter(topic EventTopic, delay time.Duration, args ...interface{}) *Scheduled {
+	return s.schedule(topic, time.Now().Add(delay), delay, args)
+}
+
+// PublishAt publishes args to topic at t, right away when t is in the past
+func (s *Scheduler) PublishAt(topic EventTopic, at time.Time, args ...interface{}) *Scheduled {
+	return s.schedule(topic, at, time.Until(at), args)
+}
+
+// Stop drops the pending events, events scheduled afterwards are never published
+func (s *Scheduler) Stop() {
+	if s.stopped.Swap(true) {
+		return
+	}
+	s.once.Do(func() {})
+	if s.wheel != nil {
+		s.wheel.Stop()
+	}
+}
+
+func (s *Scheduler) schedule(topic EventTopic, at time.Time, delay time.Duration, args []interface{}) *Scheduled {
+	sc := &Scheduled{Topic: topic, At: at, args: args, scheduler: s}
+	if s.stopped.Load() {
+		sc.state.Store(scheduledCancelled)
+		return sc
+	}
+	s.once.Do(func() {
+		wheel, err := collection.NewTimingWheel(scheduleTick, scheduleSlots, func(key, _ any) {
+			s.fire(key.(*Scheduled))
+		})
+		if err != nil {
+			logx.Errorf("bus: create timing wheel failed: %v", err)
+			return
+		}
+		s.wheel = wheel
+	})
+
+	if delay < scheduleTick || s.wheel == nil {
+		threading.GoSafe(func() {
+			s.fire(sc)
+		})
+		return sc
+	}
+	if err := s.wheel.SetTimer(sc, nil, delay); err != nil {
+		// only fails after Stop
+		sc.state.Store(scheduledCancelled)
+	}
+	return sc
+}
+
+func (s *Scheduler) fire(sc *Scheduled) {
+	// the wheel rounds to ticks and may fire up to one tick early
+	if early := time.Until(sc.At); early > 0 {
+		time.Sleep(early)
+	}
+	if !sc.state.CompareAndSwap(scheduledPending, scheduledFired) {
+		return
+	}
+	if err := s.pub.Publish(sc.Topic, sc.args...); err != nil {
+		logx.Errorf("bus: scheduled publish to %s failed: %v", sc.Topic, err)
+	}
+}
@@ -0,0 +1,106 @@
+package bus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventBus_PublishAfter(t *testing.T) {
+	const topic EventTopic = "order.timeout"
+
+	tests := []struct {
+		name     string
+		schedule func(b Bus) *Scheduled
+	}{
+		{name: "delay", schedule: func(b Bus) *Scheduled { return b.PublishAfter(topic, 120*time.Millisecond, "1001") }},
+		{name: "at", schedule: func(b Bus) *Scheduled { return b.PublishAt(topic, time.Now().Add(120*time.Millisecond), "1001") }},
+		{name: "past runs right away", schedule: func(b Bus) *Scheduled { return b.PublishAt(topic, time.Now().Add(-time.Hour), "1001") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New()
+			got := make(chan string, 1)
+			_ = b.Subscribe(topic, func(id string) error {
+				got <- id
+				return nil
+			})
+
+			start := time.Now()
+			sc := tt.schedule(b)
+			select {
+			case id := <-got:
+				if id != "1001" {
+					t.Fatalf("published %q, want 1001", id)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("event was not published")
+			}
+			if early := sc.At.Sub(time.Now()); early > 0 {
+				t.Errorf("published %s early", early)
+			}
+			if time.Since(start) > time.Second {
+				t.Errorf("published after %s", time.Since(start))
+			}
+			if sc.Cancel() {
+				t.Error("Cancel after publish returned true")
+			}
+		})
+	}
+}
+
+func TestScheduled_Cancel(t *testing.T) {
+	const topic EventTopic = "order.timeout"
+	b := New()
+	got := make(chan string, 2)
+	_ = b.Subscribe(topic, func(id string) error {
+		got <- id
+		return nil
+	})
+
+	cancelled := b.PublishAfter(topic, 100*time.Millisecond, "cancelled")
+	_ = b.PublishAfter(topic, 200*time.Millisecond, "kept")
+	if !cancelled.Cancel() {
+		t.Fatal("Cancel of a pending event returned false")
+	}
+	if cancelled.Cancel() {
+		t.Error("second Cancel returned true")
+	}
+
+	select {
+	case id := <-got:
+		if id != "kept" {
+			t.Fatalf("published %q, want kept", id)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("event was not published")
+	}
+	select {
+	case id := <-got:
+		t.Fatalf("cancelled event %q was published", id)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestScheduler_Stop(t *testing.T) {
+	const topic EventTopic = "order.timeout"
+	b := New()
+	got := make(chan string, 2)
+	_ = b.Subscribe(topic, func(id string) error {
+		got <- id
+		return nil
+	})
+
+	s := NewScheduler(b)
+	_ = s.PublishAfter(topic, 100*time.Millisecond, "pending")
+	s.Stop()
+	if s.PublishAfter(topic, time.Millisecond, "late").Cancel() {
+		t.Error("event scheduled after Stop is pending")
+	}
+
+	select {
+	case id := <-got:
+		t.Fatalf("%q was published after Stop", id)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
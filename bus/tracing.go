@@ -0,0 +1,160 @@
+package bus
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "gomod.pri/golib/bus"
+
+// TraceMode selects how a traced bus records publishes, see WithTracing
+type TraceMode int
+
+const (
+	// TraceOff records nothing (default)
+	TraceOff TraceMode = iota
+	// TraceEvents adds a span event per handler and one per publish to the
+	// span of the publishing context, cheap enough for hot paths
+	TraceEvents
+	// TraceSpans starts a child span per publish and per handler. Handlers
+	// receive the context of their span, so their own calls nest below it
+	TraceSpans
+)
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// Option configures New
+type Option func(*EventBus)
+
+// WithTracing records every publish and handler call with topic, handler name
+// and duration on the trace of the first context.Context argument, so the
+// in-process event flow shows up in the trace of the triggering request.
+// Publishes without a context argument or without a recording span are not traced
+func WithTracing(mode TraceMode) Option {
+	return func(e *EventBus) {
+		e.traceMode.Store(int32(mode))
+	}
+}
+
+// EnableTracing sets the trace mode of the package level bus
+func EnableTracing(mode TraceMode) {
+	if e, ok := globalEventBus.(*EventBus); ok {
+		e.traceMode.Store(int32(mode))
+	}
+}
+
+// publishTrace records one traced Publish
+type publishTrace struct {
+	mode   TraceMode
+	topic  EventTopic
+	ctx    context.Context
+	ctxArg int // index of the context argument
+	span   trace.Span
+	start  time.Time
+}
+
+// startPublish returns nil when the publish is not traced
+func (e *EventBus) startPublish(topic EventTopic, args []interface{}) *publishTrace {
+	mode := TraceMode(e.traceMode.Load())
+	if mode == TraceOff {
+		return nil
+	}
+	idx := -1
+	var ctx context.Context
+	for i, arg := range args {
+		if c, ok := arg.(context.Context); ok && c != nil {
+			idx, ctx = i, c
+			break
+		}
+	}
+	if ctx == nil || !trace.SpanFromContext(ctx).IsRecording() {
+		return nil
+	}
+
+	t := &publishTrace{mode: mode, topic: topic, ctx: ctx, ctxArg: idx, start: time.Now()}
+	if mode == TraceSpans {
+		t.ctx, t.span = otel.Tracer(tracerName).Start(ctx, "bus.publish "+string(topic),
+			trace.WithSpanKind(trace.SpanKindInternal),
+			trace.WithAttributes(attribute.String("bus.topic", string(topic))))
+	}
+	return t
+}
+
+// handle calls handler, recording it as a span event or a child span
+func (t *publishTrace) handle(e *EventBus, handler *eventHandler, args []interface{}) error {
+	name := handlerName(handler)
+	attrs := []attribute.KeyValue{
+		attribute.String("bus.topic", string(t.topic)),
+		attribute.String("bus.handler", name),
+	}
+
+	if t.mode == TraceEvents {
+		start := time.Now()
+		err := e.doPublish(handler, args...)
+		attrs = append(attrs, durationAttr(time.Since(start)))
+		if err != nil {
+			attrs = append(attrs, attribute.String("error", err.Error()))
+		}
+		trace.SpanFromContext(t.ctx).AddEvent("bus.handle", trace.WithAttributes(attrs...))
+		return err
+	}
+
+	ctx, span := otel.Tracer(tracerName).Start(t.ctx, "bus.handle "+name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...))
+	defer span.End()
+
+	// hand the handler span to the handler instead of the publisher context
+	traced := append([]interface{}(nil), args...)
+	if in := handler.callback.Type(); t.ctxArg < in.NumIn() && in.In(t.ctxArg) == contextType {
+		traced[t.ctxArg] = ctx
+	}
+	err := e.doPublish(handler, traced...)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// end records the publish with the number of handlers called
+func (t *publishTrace) end(handlers int, err error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("bus.topic", string(t.topic)),
+		attribute.Int("bus.handlers", handlers),
+		durationAttr(time.Since(t.start)),
+	}
+	if t.mode == TraceEvents {
+		if err != nil {
+			attrs = append(attrs, attribute.String("error", err.Error()))
+		}
+		trace.SpanFromContext(t.ctx).AddEvent("bus.publish", trace.WithAttributes(attrs...))
+		return
+	}
+
+	t.span.SetAttributes(attribute.Int("bus.handlers", handlers))
+	if err != nil {
+		t.span.RecordError(err)
+		t.span.SetStatus(codes.Error, err.Error())
+	}
+	t.span.End()
+}
+
+func durationAttr(d time.Duration) attribute.KeyValue {
+	return attribute.Float64("bus.duration_ms", float64(d.Microseconds())/1000)
+}
+
+// handlerName returns the function name of the handler, e.g. main.onOrderPaid
+func handlerName(handler *eventHandler) string {
+	if fn := runtime.FuncForPC(handler.callback.Pointer()); fn != nil {
+		return fn.Name()
+	}
+	return handler.callback.Type().String()
+}
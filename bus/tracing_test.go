@@ -0,0 +1,121 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func onOrderPaid(ctx context.Context, id string) error { return nil }
+
+func attr(attrs []attribute.KeyValue, key string) attribute.Value {
+	for _, kv := range attrs {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingEvents(t *testing.T) {
+	const topic EventTopic = "order.paid"
+	recorder := recordSpans(t)
+	b := New(WithTracing(TraceEvents))
+	boom := errors.New("boom")
+	_ = b.Subscribe(topic, onOrderPaid)
+	_ = b.Subscribe(topic, func(ctx context.Context, id string) error { return boom })
+
+	ctx, root := otel.Tracer("test").Start(context.Background(), "POST /orders")
+	if err := b.Publish(topic, ctx, "o-1"); !errors.Is(err, boom) {
+		t.Fatalf("Publish() error = %v, want boom", err)
+	}
+	root.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want only the request span", len(spans))
+	}
+	events := spans[0].Events()
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 2 handlers and the publish", len(events))
+	}
+	if events[0].Name != "bus.handle" || !strings.HasSuffix(attr(events[0].Attributes, "bus.handler").AsString(), "bus.onOrderPaid") {
+		t.Errorf("first event = %s %v", events[0].Name, events[0].Attributes)
+	}
+	if got := attr(events[1].Attributes, "error").AsString(); got != "boom" {
+		t.Errorf("failed handler error = %q", got)
+	}
+	if events[2].Name != "bus.publish" || attr(events[2].Attributes, "bus.handlers").AsInt64() != 2 ||
+		attr(events[2].Attributes, "bus.topic").AsString() != string(topic) {
+		t.Errorf("publish event = %s %v", events[2].Name, events[2].Attributes)
+	}
+}
+
+func TestTracingSpans(t *testing.T) {
+	const topic EventTopic = "order.paid"
+	recorder := recordSpans(t)
+	b := New(WithTracing(TraceSpans))
+
+	var handlerSpan trace.SpanContext
+	_ = b.Subscribe(topic, func(ctx context.Context, id string) error {
+		handlerSpan = trace.SpanContextFromContext(ctx)
+		return errors.New("boom")
+	})
+
+	ctx, root := otel.Tracer("test").Start(context.Background(), "POST /orders")
+	_ = b.Publish(topic, ctx, "o-1")
+	root.End()
+
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		byName[strings.SplitN(s.Name(), " ", 2)[0]] = s
+	}
+	publish, handle := byName["bus.publish"], byName["bus.handle"]
+	if publish == nil || handle == nil {
+		t.Fatalf("spans = %v", byName)
+	}
+	if publish.Parent().SpanID() != root.SpanContext().SpanID() || handle.Parent().SpanID() != publish.SpanContext().SpanID() {
+		t.Error("spans are not nested below the request span")
+	}
+	if handlerSpan.SpanID() != handle.SpanContext().SpanID() {
+		t.Error("handler did not receive the context of its span")
+	}
+	if handle.Status().Code != codes.Error || publish.Status().Code != codes.Error {
+		t.Errorf("status = %v / %v, want error", handle.Status(), publish.Status())
+	}
+}
+
+func TestTracingWithoutContext(t *testing.T) {
+	recorder := recordSpans(t)
+	b := New(WithTracing(TraceSpans))
+	called := false
+	_ = b.Subscribe("topic", func(id string) error { called = true; return nil })
+
+	// no context argument and a context without span are both left alone
+	_ = b.Publish("topic", "o-1")
+	if !called || len(recorder.Ended()) != 0 {
+		t.Fatalf("called = %v, spans = %d", called, len(recorder.Ended()))
+	}
+	_ = b.Subscribe("ctx", func(ctx context.Context) error { return nil })
+	_ = b.Publish("ctx", context.Background())
+	if len(recorder.Ended()) != 0 {
+		t.Fatalf("traced a publish without span")
+	}
+}
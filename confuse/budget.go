@@ -0,0 +1,83 @@
+package confuse
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ============================================================================
+// Length Budget - cap obfuscated identifiers, e.g. MySQL's 64 char limit
+// ============================================================================
+
+// MySQLIdentifierMaxLen is the maximum length of a MySQL table or column name
+const MySQLIdentifierMaxLen = 64
+
+// budgetHashLen is the number of hex chars of the hash suffix
+const budgetHashLen = 8
+
+// WithMaxLength caps every output of ObfuscateFields at max characters.
+// Overlong outputs are truncated and suffixed with a seed derived hash of the
+// original field, so they stay deterministic and unique. Shortened fields can
+// only be reversed via the returned Mapping (persist it with a MappingStore)
+func WithMaxLength(max int) FieldsOption {
+	return func(o *fieldsOptions) {
+		o.maxLength = max
+	}
+}
+
+// ShortenField returns ObfuscateField(field) if it fits in max characters,
+// otherwise its truncated, hash suffixed form. The result is the same one
+// ObfuscateFields produces with WithMaxLength(max) when there is no collision
+func (sdk *ObfuscatorSDK) ShortenField(field string, max int) (string, error) {
+	if err := checkMaxLength(max); err != nil {
+		return "", err
+	}
+	return sdk.shorten(sdk.ObfuscateField(field), field, max, 0), nil
+}
+
+func checkMaxLength(max int) error {
+	if max <= budgetHashLen {
+		return fmt.Errorf("confuse: max length %d must be greater than %d", max, budgetHashLen)
+	}
+	return nil
+}
+
+// fitLength shortens out if needed, retrying the hash until it is unused
+func (sdk *ObfuscatorSDK) fitLength(out, field string, max int, used map[string][]string) string {
+	if utf8.RuneCountInString(out) <= max {
+		return out
+	}
+	for k := 0; ; k++ {
+		candidate := sdk.shorten(out, field, max, k)
+		if _, ok := used[candidate]; !ok {
+			used[candidate] = nil
+			return candidate
+		}
+	}
+}
+
+// shorten keeps the first max-budgetHashLen characters of out and appends a
+// hash of the original field, seed and attempt k
+func (sdk *ObfuscatorSDK) shorten(out, field string, max, k int) string {
+	if utf8.RuneCountInString(out) <= max {
+		return out
+	}
+
+	prefix := []rune(out)[:max-budgetHashLen]
+	sum := sha256.Sum256([]byte(strconv.Itoa(sdk.Seed()) + "\x00" + field + "\x00" + strconv.Itoa(k)))
+	suffix := hex.EncodeToString(sum[:])[:budgetHashLen]
+	if isUpperIdentifier(out) {
+		suffix = strings.ToUpper(suffix)
+	}
+	return string(prefix) + suffix
+}
+
+// isUpperIdentifier reports whether out has no lowercase ASCII letters,
+// e.g. "ORDER_ID", so the hash suffix keeps its case
+func isUpperIdentifier(out string) bool {
+	return strings.ToUpper(out) == out && strings.ToLower(out) != out
+}
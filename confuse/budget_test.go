@@ -0,0 +1,67 @@
+package confuse
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWithMaxLength(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	long := strings.Repeat("user_name_", 8) + "id"
+	fields := []string{"user_name", long, long + "_x", strings.ToUpper(long)}
+
+	m, err := sdk.ObfuscateFields(fields, WithMaxLength(MySQLIdentifierMaxLen))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	for _, f := range fields {
+		out, ok := m.Obfuscate(f)
+		if !ok {
+			t.Fatalf("%s: missing from mapping", f)
+		}
+		if n := utf8.RuneCountInString(out); n > MySQLIdentifierMaxLen {
+			t.Fatalf("%s: output %q has %d chars", f, out, n)
+		}
+		if seen[out] {
+			t.Fatalf("%s: duplicate output %q", f, out)
+		}
+		seen[out] = true
+
+		if back, _ := m.Deobfuscate(out); back != f {
+			t.Fatalf("%s: mapping reversed to %q", f, back)
+		}
+	}
+
+	// short fields are untouched and still reversible without the mapping
+	if out, _ := m.Obfuscate("user_name"); out != sdk.ObfuscateField("user_name") {
+		t.Fatalf("short field changed: %q", out)
+	}
+
+	// deterministic across runs
+	again, err := sdk.ObfuscateFields(fields, WithMaxLength(MySQLIdentifierMaxLen))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := m.Obfuscate(long)
+	b, _ := again.Obfuscate(long)
+	if a != b {
+		t.Fatalf("not deterministic: %q vs %q", a, b)
+	}
+	if s, _ := sdk.ShortenField(long, MySQLIdentifierMaxLen); s != a {
+		t.Fatalf("ShortenField %q differs from mapping %q", s, a)
+	}
+}
+
+func TestWithMaxLengthTooSmall(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+	if _, err := sdk.ObfuscateFields([]string{"user_name"}, WithMaxLength(budgetHashLen)); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := sdk.ShortenField("user_name", 4); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,94 @@
+package confuse
+
+import (
+	"container/list"
+	"sync"
+)
+
+// ============================================================================
+// Char Cache - LRU for character-encrypted (out-of-dictionary) words
+// ============================================================================
+
+// DefaultCharCacheSize is the number of char-encrypted words cached per SDK
+const DefaultCharCacheSize = 4096
+
+// SetCharCacheSize sets how many char-encrypted words are cached, 0 disables
+// the cache. The cache is rebuilt whenever a setting changes
+func (sdk *ObfuscatorSDK) SetCharCacheSize(size int) *ObfuscatorSDK {
+	return sdk.update(func(st *sdkState) {
+		st.charCacheSize = size
+	})
+}
+
+// charCache is a fixed size LRU keyed by direction and word
+type charCache struct {
+	mu    sync.Mutex
+	size  int
+	ll    *list.List
+	items map[charCacheKey]*list.Element
+}
+
+type charCacheKey struct {
+	word    string
+	reverse bool
+}
+
+type charCacheEntry struct {
+	key   charCacheKey
+	value string
+}
+
+// newCharCache returns nil (no caching) when size <= 0
+func newCharCache(size int) *charCache {
+	if size <= 0 {
+		return nil
+	}
+	return &charCache{
+		size:  size,
+		ll:    list.New(),
+		items: make(map[charCacheKey]*list.Element, size),
+	}
+}
+
+func (c *charCache) get(key charCacheKey) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		return e.Value.(*charCacheEntry).value, true
+	}
+	return "", false
+}
+
+func (c *charCache) put(key charCacheKey, value string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*charCacheEntry).value = value
+		return
+	}
+	c.items[key] = c.ll.PushFront(&charCacheEntry{key: key, value: value})
+	if c.ll.Len() > c.size {
+		oldest := c.ll.Back()
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*charCacheEntry).key)
+	}
+}
+
+func (c *charCache) len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
@@ -0,0 +1,96 @@
+package confuse
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestCharCache(t *testing.T) {
+	c := newCharCache(2)
+	c.put(charCacheKey{word: "a"}, "x")
+	c.put(charCacheKey{word: "b"}, "y")
+	if _, ok := c.get(charCacheKey{word: "a"}); !ok {
+		t.Fatal("a evicted too early")
+	}
+	c.put(charCacheKey{word: "c"}, "z")
+
+	if _, ok := c.get(charCacheKey{word: "b"}); ok {
+		t.Fatal("least recently used entry not evicted")
+	}
+	if v, _ := c.get(charCacheKey{word: "a"}); v != "x" {
+		t.Fatalf("got %q", v)
+	}
+	if _, ok := c.get(charCacheKey{word: "a", reverse: true}); ok {
+		t.Fatal("directions must not share entries")
+	}
+	if c.len() != 2 {
+		t.Fatalf("len %d, want 2", c.len())
+	}
+
+	disabled := newCharCache(0)
+	disabled.put(charCacheKey{word: "a"}, "x")
+	if _, ok := disabled.get(charCacheKey{word: "a"}); ok {
+		t.Fatal("disabled cache returned a value")
+	}
+}
+
+func TestCharCacheResetOnSetting(t *testing.T) {
+	sdk := NewObfuscatorSDK(20241).WithSeed(20241)
+	enc := sdk.ObfuscateWord("xyzzy")
+	if sdk.load().charCache.len() == 0 {
+		t.Fatal("char-encrypted word not cached")
+	}
+
+	sdk.SetCharsets(CharsetUpper)
+	if got := sdk.ObfuscateWord("xyzzy"); got != "xyzzy" {
+		t.Fatalf("stale cache after SetCharsets: %q (was %q)", got, enc)
+	}
+
+	sdk.SetCharCacheSize(0)
+	if sdk.load().charCache != nil {
+		t.Fatal("cache not disabled")
+	}
+}
+
+func TestDictionaryIndexMatchesSearch(t *testing.T) {
+	d, _ := LookupDictionary(DefaultDictionary)
+	words := d.snapshot()
+	view := d.view()
+	for i, w := range words {
+		idx := view.indexOf(w)
+		if words[idx] != w || idx > i {
+			t.Fatalf("%s: index %d, position %d", w, idx, i)
+		}
+	}
+	if view.indexOf("notaword123") != -1 {
+		t.Fatal("unknown word found")
+	}
+}
+
+func BenchmarkObfuscateWord(b *testing.B) {
+	sdk := NewObfuscatorSDK(20240).WithSeed(20240)
+	words := sdk.Dictionary().Words()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sdk.ObfuscateWord(words[i%len(words)])
+	}
+}
+
+func BenchmarkObfuscateWordOutOfDict(b *testing.B) {
+	sdk := NewObfuscatorSDK(20240).WithSeed(20240)
+	words := make([]string, 512)
+	for i := range words {
+		words[i] = "zq" + strconv.Itoa(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sdk.ObfuscateWord(words[i%len(words)])
+	}
+}
+
+func BenchmarkObfuscateField(b *testing.B) {
+	sdk := NewObfuscatorSDK(20240).WithSeed(20240)
+	for i := 0; i < b.N; i++ {
+		sdk.ObfuscateField("user_order_createTime")
+	}
+}
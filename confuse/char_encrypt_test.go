@@ -1,7 +1,9 @@
 package confuse
 
 import (
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 // TestCharacterEncryption tests character-level encryption for out-of-dictionary words
@@ -308,3 +310,72 @@ func TestCharacterEncryption1(t *testing.T) {
 	decrypted := sdk.DeobfuscateWord(encrypted)
 	t.Logf("Decrypted: %s", decrypted)
 }
+
+func TestEncryptByChar_Unicode(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	tests := []struct {
+		name string
+		word string
+	}{
+		{name: "chinese", word: "用户名称"},
+		{name: "cyrillic", word: "Привет"},
+		{name: "mixed", word: "订单ID_заказ2"},
+		{name: "emoji kept", word: "ok👍"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc := sdk.load().encryptByChar(tt.word)
+			if utf8.RuneCountInString(enc) != utf8.RuneCountInString(tt.word) {
+				t.Fatalf("encryptByChar(%q) = %q changed rune count", tt.word, enc)
+			}
+			if enc == tt.word {
+				t.Fatalf("encryptByChar(%q) left text unchanged", tt.word)
+			}
+			if dec := sdk.load().decryptByChar(enc); dec != tt.word {
+				t.Fatalf("decryptByChar(%q) = %q, want %q", enc, dec, tt.word)
+			}
+		})
+	}
+
+	field := "用户_name"
+	obf := sdk.ObfuscateField(field)
+	if strings.Contains(obf, "用户") {
+		t.Fatalf("ObfuscateField(%q) = %q kept chinese text", field, obf)
+	}
+	if back := sdk.DeobfuscateField(obf); back != field {
+		t.Fatalf("DeobfuscateField(%q) = %q, want %q", obf, back, field)
+	}
+}
+
+func TestSetCharsets_FewSymbols(t *testing.T) {
+	tests := []struct {
+		name    string
+		charset Charset
+		word    string
+	}{
+		{name: "one symbol", charset: Charset{Name: "a", Lo: 'a', Hi: 'a'}, word: "aaaa"},
+		{name: "two symbols", charset: Charset{Name: "ab", Lo: 'a', Hi: 'b'}, word: "abba"},
+		{name: "three symbols", charset: Charset{Name: "abc", Lo: 'a', Hi: 'c'}, word: "cabbage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, seed := range []int{0, 1, 2, 3, 20240} {
+				sdk := NewObfuscatorSDK(0).WithSeed(seed).SetCharsets(tt.charset)
+				enc := sdk.load().encryptByChar(tt.word)
+				for _, r := range enc {
+					if !tt.charset.contains(r) && !strings.ContainsRune(tt.word, r) {
+						t.Fatalf("seed %d: encryptByChar(%q) = %q left the charset", seed, tt.word, enc)
+					}
+				}
+				if dec := sdk.load().decryptByChar(enc); dec != tt.word {
+					t.Fatalf("seed %d: decryptByChar(%q) = %q, want %q", seed, enc, dec, tt.word)
+				}
+				if back := sdk.DeobfuscateWord(sdk.ObfuscateWord(tt.word)); back != tt.word {
+					t.Fatalf("seed %d: round trip of %q = %q", seed, tt.word, back)
+				}
+			}
+		})
+	}
+}
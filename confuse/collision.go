@@ -0,0 +1,136 @@
+package confuse
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// Batch Field Obfuscation - collision detection and disambiguation
+// ============================================================================
+
+// Collision lists distinct input fields that obfuscate to the same output
+type Collision struct {
+	Output string
+	Inputs []string
+}
+
+// CollisionError is returned by ObfuscateFields when collisions are detected
+type CollisionError struct {
+	Collisions []Collision
+}
+
+func (e *CollisionError) Error() string {
+	parts := make([]string, 0, len(e.Collisions))
+	for _, c := range e.Collisions {
+		parts = append(parts, fmt.Sprintf("%s <- [%s]", c.Output, strings.Join(c.Inputs, ", ")))
+	}
+	return fmt.Sprintf("confuse: %d field collision(s): %s", len(e.Collisions), strings.Join(parts, "; "))
+}
+
+type fieldsOptions struct {
+	disambiguate bool
+	maxLength    int
+}
+
+// FieldsOption configures ObfuscateFields
+type FieldsOption func(*fieldsOptions)
+
+// WithDisambiguate resolves collisions instead of failing: the first input in
+// sorted order keeps the plain output, later ones get a seed derived suffix
+func WithDisambiguate() FieldsOption {
+	return func(o *fieldsOptions) {
+		o.disambiguate = true
+	}
+}
+
+// ObfuscateFields obfuscates a batch of fields with ObfuscateField and
+// guarantees the result is bijective. Colliding inputs (e.g. an out-of-dictionary
+// word whose character encryption lands on a dictionary word) are reported as a
+// *CollisionError, or disambiguated with WithDisambiguate. The result does not
+// depend on the order of fields. Disambiguated fields can only be reversed via
+// the returned Mapping, not DeobfuscateField.
+func (sdk *ObfuscatorSDK) ObfuscateFields(fields []string, opts ...FieldsOption) (*Mapping, error) {
+	o := &fieldsOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.maxLength > 0 {
+		if err := checkMaxLength(o.maxLength); err != nil {
+			return nil, err
+		}
+	}
+
+	inputs := dedupeSorted(fields)
+	outputs := make(map[string][]string, len(inputs))
+	for _, field := range inputs {
+		out := sdk.ObfuscateField(field)
+		outputs[out] = append(outputs[out], field)
+	}
+
+	var collisions []Collision
+	for out, ins := range outputs {
+		if len(ins) > 1 {
+			collisions = append(collisions, Collision{Output: out, Inputs: ins})
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].Output < collisions[j].Output
+	})
+
+	if len(collisions) > 0 && !o.disambiguate {
+		return nil, &CollisionError{Collisions: collisions}
+	}
+
+	m := NewMapping()
+	for _, field := range inputs {
+		out := sdk.ObfuscateField(field)
+		if ins := outputs[out]; len(ins) > 1 && ins[0] != field {
+			out = sdk.disambiguate(out, outputs)
+		}
+		if o.maxLength > 0 {
+			out = sdk.fitLength(out, field, o.maxLength, outputs)
+		}
+		if err := m.Add(field, out); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// disambiguate appends the first seed derived suffix that yields an unused output
+func (sdk *ObfuscatorSDK) disambiguate(out string, used map[string][]string) string {
+	for k := 0; ; k++ {
+		candidate := out + sdk.collisionSuffix(k)
+		if _, ok := used[candidate]; !ok {
+			used[candidate] = nil
+			return candidate
+		}
+	}
+}
+
+// collisionSuffix returns the k-th suffix: a digit run that keeps the field a
+// valid identifier, derived from the seed so it differs between partners
+func (sdk *ObfuscatorSDK) collisionSuffix(k int) string {
+	seed := sdk.Seed()
+	if seed < 0 {
+		seed = -seed
+	}
+	return strconv.Itoa(seed%97 + k + 1)
+}
+
+func dedupeSorted(fields []string) []string {
+	seen := make(map[string]struct{}, len(fields))
+	out := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if _, ok := seen[f]; ok {
+			continue
+		}
+		seen[f] = struct{}{}
+		out = append(out, f)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,87 @@
+package confuse
+
+import (
+	"errors"
+	"testing"
+)
+
+// findCollision returns an out-of-dictionary word and a dictionary word that
+// obfuscate to the same output under sdk
+func findCollision(t *testing.T, sdk *ObfuscatorSDK) (string, string) {
+	t.Helper()
+
+	reverse := make(map[string]string, GetWordCount())
+	for _, w := range GetWords() {
+		reverse[sdk.ObfuscateWord(w)] = w
+	}
+
+	for a := 'a'; a <= 'z'; a++ {
+		for b := 'a'; b <= 'z'; b++ {
+			for c := 'a'; c <= 'z'; c++ {
+				word := string([]rune{a, b, c})
+				if HasWord(word) {
+					continue
+				}
+				if dictWord, ok := reverse[sdk.ObfuscateWord(word)]; ok {
+					return word, dictWord
+				}
+			}
+		}
+	}
+	t.Skip("no collision found for seed")
+	return "", ""
+}
+
+func TestObfuscateFields_Collision(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+	outOfDict, dictWord := findCollision(t, sdk)
+	fields := []string{dictWord, outOfDict, "userName"}
+
+	_, err := sdk.ObfuscateFields(fields)
+	var ce *CollisionError
+	if !errors.As(err, &ce) || len(ce.Collisions) != 1 {
+		t.Fatalf("ObfuscateFields() error = %v, want one collision", err)
+	}
+
+	m, err := sdk.ObfuscateFields(fields, WithDisambiguate())
+	if err != nil {
+		t.Fatalf("ObfuscateFields(WithDisambiguate) error = %v", err)
+	}
+	if m.Len() != len(fields) {
+		t.Fatalf("Len() = %d, want %d", m.Len(), len(fields))
+	}
+	for _, f := range fields {
+		out, _ := m.Obfuscate(f)
+		if back, ok := m.Deobfuscate(out); !ok || back != f {
+			t.Fatalf("Deobfuscate(%q) = %q, %v, want %q", out, back, ok, f)
+		}
+	}
+
+	// the result must not depend on input order
+	again, err := sdk.ObfuscateFields([]string{"userName", outOfDict, dictWord}, WithDisambiguate())
+	if err != nil {
+		t.Fatalf("ObfuscateFields() error = %v", err)
+	}
+	for _, f := range fields {
+		a, _ := m.Obfuscate(f)
+		b, _ := again.Obfuscate(f)
+		if a != b {
+			t.Fatalf("non-deterministic output for %q: %q vs %q", f, a, b)
+		}
+	}
+}
+
+func TestObfuscateFields_NoCollision(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	m, err := sdk.ObfuscateFields([]string{"userName", "order_id", "userName"})
+	if err != nil {
+		t.Fatalf("ObfuscateFields() error = %v", err)
+	}
+	if m.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", m.Len())
+	}
+	if out, _ := m.Obfuscate("userName"); out != sdk.ObfuscateField("userName") {
+		t.Fatalf("Obfuscate(userName) = %q, want %q", out, sdk.ObfuscateField("userName"))
+	}
+}
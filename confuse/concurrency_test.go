@@ -0,0 +1,57 @@
+package confuse
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestWithSeed(t *testing.T) {
+	base := NewObfuscatorSDK(20240)
+	tenant := base.WithSeed(20241)
+
+	if tenant == NewObfuscatorSDK(20241) {
+		t.Fatal("WithSeed must not return the cached instance")
+	}
+	if tenant.Seed() != 20241 || base.Seed() != 20240 {
+		t.Fatalf("unexpected seeds: %d, %d", base.Seed(), tenant.Seed())
+	}
+
+	for _, field := range []string{"userName", "order_id", "createTime"} {
+		want := NewObfuscatorSDK(20241).ObfuscateField(field)
+		if got := tenant.ObfuscateField(field); got != want {
+			t.Fatalf("%s: got %q, want %q", field, got, want)
+		}
+		if back := tenant.DeobfuscateField(tenant.ObfuscateField(field)); back != field {
+			t.Fatalf("%s: round trip got %q", field, back)
+		}
+	}
+}
+
+func TestConcurrentSettersAndCalls(t *testing.T) {
+	sdk := NewObfuscatorSDK(20242).WithSeed(20242)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				sdk.SetEncryptOutOfDict(j%2 == 0)
+				sdk.SetPreserveStopWords(j%3 == 0)
+				sdk.SetCharsets(DefaultCharsets...)
+			}
+		}()
+		go func(seed int) {
+			defer wg.Done()
+			tenant := sdk.WithSeed(seed)
+			for j := 0; j < 200; j++ {
+				sdk.ObfuscateField("user_name")
+				if back := tenant.DeobfuscateField(tenant.ObfuscateField("orderId")); back != "orderId" {
+					t.Errorf("round trip got %q", back)
+					return
+				}
+			}
+		}(20240 + i)
+	}
+	wg.Wait()
+}
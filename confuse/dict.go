@@ -22,6 +22,8 @@ func init() {
 	for _, w := range Words {
 		WordSet[w] = struct{}{}
 	}
+
+	registerEmbeddedDictionary()
 }
 
 // GetWords returns all words from the embedded dictionary
@@ -0,0 +1,257 @@
+package confuse
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ============================================================================
+// Dictionary Management - named dictionaries editable at runtime
+// ============================================================================
+
+// DefaultDictionary is the name of the embedded dictionary
+const DefaultDictionary = "default"
+
+var dictionaries sync.Map // map[string]*Dictionary
+
+// Dictionary is a named, sorted word list. Reads use an immutable snapshot,
+// writes replace it (copy-on-write), so it is safe for concurrent use.
+//
+// Note: the word mapping depends on the whole dictionary, so adding or removing
+// words changes the output for existing words. Export a Mapping first if
+// previously obfuscated data must stay readable.
+//
+// Duplicate words (the embedded list has a few) keep their slot so the mapping
+// of other words doesn't change, but are never produced as an output: a word
+// whose image would be a repeated slot maps to the next free slot instead.
+type Dictionary struct {
+	name  string
+	mu    sync.Mutex // serializes writers
+	words atomic.Pointer[dictSnapshot]
+}
+
+// dictSnapshot is an immutable word list with a precomputed index, so word
+// lookups are O(1) instead of a binary search per call
+type dictSnapshot struct {
+	words []string
+	index map[string]int // word -> first index in words
+	hash  string         // fingerprint of words, see Header
+}
+
+func newDictSnapshot(words []string) *dictSnapshot {
+	index := make(map[string]int, len(words))
+	for i, w := range words {
+		// keep the first index of duplicates, as sort.SearchStrings would
+		if _, ok := index[w]; !ok {
+			index[w] = i
+		}
+	}
+	return &dictSnapshot{words: words, index: index, hash: hashWords(words)}
+}
+
+// indexOf returns the index of word, or -1 if not found
+func (s *dictSnapshot) indexOf(word string) int {
+	if s == nil {
+		return -1
+	}
+	if i, ok := s.index[word]; ok {
+		return i
+	}
+	return -1
+}
+
+// isDuplicate reports whether words[i] repeats an earlier word. The word
+// mapping walks past such slots, so a dictionary with duplicates still maps
+// distinct words to distinct words
+func (s *dictSnapshot) isDuplicate(i int) bool {
+	return s.index[s.words[i]] != i
+}
+
+// NewDictionary creates a dictionary from words, lowercased, sorted and deduplicated
+func NewDictionary(name string, words []string) *Dictionary {
+	d := &Dictionary{name: name}
+	d.store(normalizeWords(words))
+	return d
+}
+
+// Name returns the dictionary name
+func (d *Dictionary) Name() string {
+	return d.name
+}
+
+// Len returns the number of words
+func (d *Dictionary) Len() int {
+	return len(d.snapshot())
+}
+
+// Words returns a copy of the sorted words
+func (d *Dictionary) Words() []string {
+	return append([]string(nil), d.snapshot()...)
+}
+
+// Has reports whether word is in the dictionary
+func (d *Dictionary) Has(word string) bool {
+	return d.view().indexOf(word) >= 0
+}
+
+// AddWords adds words to the dictionary
+func (d *Dictionary) AddWords(words ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	merged := append(d.Words(), words...)
+	d.store(normalizeWords(merged))
+}
+
+// RemoveWords removes words from the dictionary
+func (d *Dictionary) RemoveWords(words ...string) {
+	remove := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		remove[strings.ToLower(strings.TrimSpace(w))] = struct{}{}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current := d.snapshot()
+	kept := make([]string, 0, len(current))
+	for _, w := range current {
+		if _, ok := remove[w]; !ok {
+			kept = append(kept, w)
+		}
+	}
+	d.store(kept)
+}
+
+func (d *Dictionary) snapshot() []string {
+	if v := d.view(); v != nil {
+		return v.words
+	}
+	return nil
+}
+
+// view returns the current snapshot, or nil for a nil or empty dictionary
+func (d *Dictionary) view() *dictSnapshot {
+	if d == nil {
+		return nil
+	}
+	return d.words.Load()
+}
+
+func (d *Dictionary) store(words []string) {
+	d.words.Store(newDictSnapshot(words))
+}
+
+// RegisterDictionary makes d selectable by name, replacing any dictionary of the same name
+func RegisterDictionary(d *Dictionary) {
+	dictionaries.Store(d.name, d)
+}
+
+// LookupDictionary returns the dictionary registered under name
+func LookupDictionary(name string) (*Dictionary, bool) {
+	v, ok := dictionaries.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return v.(*Dictionary), true
+}
+
+// LoadDictionaryFromFile reads one word per line (blank lines and lines starting
+// with # are ignored) and registers the dictionary under name
+func LoadDictionaryFromFile(name, path string) (*Dictionary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("confuse: open dictionary: %w", err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("confuse: read dictionary: %w", err)
+	}
+
+	d := NewDictionary(name, words)
+	RegisterDictionary(d)
+	return d, nil
+}
+
+// AddWords adds words to the dictionary registered under name
+func AddWords(name string, words ...string) error {
+	d, ok := LookupDictionary(name)
+	if !ok {
+		return fmt.Errorf("confuse: dictionary %q not found", name)
+	}
+	d.AddWords(words...)
+	return nil
+}
+
+// RemoveWords removes words from the dictionary registered under name
+func RemoveWords(name string, words ...string) error {
+	d, ok := LookupDictionary(name)
+	if !ok {
+		return fmt.Errorf("confuse: dictionary %q not found", name)
+	}
+	d.RemoveWords(words...)
+	return nil
+}
+
+// WithDictionary returns a copy of the SDK that maps words with the dictionary
+// registered under name, e.g. sdk.WithDictionary("finance").ObfuscateField(f).
+// Seed and settings are shared with sdk at the time of the call
+func (sdk *ObfuscatorSDK) WithDictionary(name string) (*ObfuscatorSDK, error) {
+	d, ok := LookupDictionary(name)
+	if !ok {
+		return nil, fmt.Errorf("confuse: dictionary %q not found", name)
+	}
+	return sdk.derive(func(st *sdkState) {
+		st.dict = d
+	}), nil
+}
+
+// Dictionary returns the dictionary used by the SDK
+func (sdk *ObfuscatorSDK) Dictionary() *Dictionary {
+	return sdk.load().dict
+}
+
+// registerEmbeddedDictionary registers the embedded words as DefaultDictionary.
+// The list is kept as-is (only sorted) so existing mappings don't change
+func registerEmbeddedDictionary() {
+	words := make([]string, len(Words))
+	copy(words, Words)
+	sort.Strings(words)
+
+	d := &Dictionary{name: DefaultDictionary}
+	d.store(words)
+	RegisterDictionary(d)
+}
+
+func normalizeWords(words []string) []string {
+	out := make([]string, 0, len(words))
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			out = append(out, w)
+		}
+	}
+	sort.Strings(out)
+
+	// compact duplicates in place
+	n := 0
+	for i, w := range out {
+		if i == 0 || w != out[n-1] {
+			out[n] = w
+			n++
+		}
+	}
+	return out[:n]
+}
@@ -0,0 +1,101 @@
+package confuse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDictionary_AddRemove(t *testing.T) {
+	d := NewDictionary("test-add-remove", []string{"Loan", "rate", "rate", " credit "})
+	if d.Len() != 3 || !d.Has("loan") || !d.Has("credit") {
+		t.Fatalf("Words() = %v, want normalized and deduplicated", d.Words())
+	}
+
+	d.AddWords("Invoice", "loan")
+	if d.Len() != 4 || !d.Has("invoice") {
+		t.Fatalf("AddWords() words = %v", d.Words())
+	}
+
+	d.RemoveWords("RATE")
+	if d.Len() != 3 || d.Has("rate") {
+		t.Fatalf("RemoveWords() words = %v", d.Words())
+	}
+}
+
+func TestWithDictionary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "finance.txt")
+	content := "# finance terms\nloan\ninterest\ncredit\ndebit\ninvoice\nledger\n\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write dictionary: %v", err)
+	}
+
+	d, err := LoadDictionaryFromFile("test-finance", path)
+	if err != nil {
+		t.Fatalf("LoadDictionaryFromFile() error = %v", err)
+	}
+	if d.Len() != 6 {
+		t.Fatalf("Len() = %d, want 6", d.Len())
+	}
+
+	base := NewObfuscatorSDK(20240)
+	sdk, err := base.WithDictionary("test-finance")
+	if err != nil {
+		t.Fatalf("WithDictionary() error = %v", err)
+	}
+
+	for _, w := range d.Words() {
+		obf := sdk.ObfuscateWord(w)
+		if !d.Has(obf) {
+			t.Fatalf("ObfuscateWord(%q) = %q is outside the finance dictionary", w, obf)
+		}
+		if back := sdk.DeobfuscateWord(obf); back != w {
+			t.Fatalf("DeobfuscateWord(%q) = %q, want %q", obf, back, w)
+		}
+	}
+
+	if base.Dictionary().Name() != DefaultDictionary {
+		t.Fatalf("WithDictionary() changed the base SDK dictionary to %q", base.Dictionary().Name())
+	}
+	if _, err := base.WithDictionary("missing"); err == nil {
+		t.Fatal("WithDictionary(missing) expected error")
+	}
+}
+
+func TestDictionary_FewWords(t *testing.T) {
+	tests := []struct {
+		name   string
+		words  []string
+		remove []string
+	}{
+		{name: "one word", words: []string{"loan"}},
+		{name: "two words", words: []string{"loan", "rate"}},
+		{name: "three words", words: []string{"loan", "rate", "credit"}},
+		{name: "removed down to two", words: []string{"loan", "rate", "credit", "debit"}, remove: []string{"credit", "debit"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			RegisterDictionary(NewDictionary("test-few-words", tt.words))
+			if err := RemoveWords("test-few-words", tt.remove...); err != nil {
+				t.Fatalf("RemoveWords() error = %v", err)
+			}
+			for _, seed := range []int{0, 1, 2, 3, 20240} {
+				sdk, err := NewObfuscatorSDK(0).WithSeed(seed).WithDictionary("test-few-words")
+				if err != nil {
+					t.Fatalf("WithDictionary() error = %v", err)
+				}
+				seen := make(map[string]bool)
+				for _, w := range sdk.Dictionary().Words() {
+					obf := sdk.ObfuscateWord(w)
+					if seen[obf] || !sdk.Dictionary().Has(obf) {
+						t.Fatalf("seed %d: ObfuscateWord(%q) = %q is not a distinct dictionary word", seed, w, obf)
+					}
+					seen[obf] = true
+					if back := sdk.DeobfuscateWord(obf); back != w {
+						t.Fatalf("seed %d: DeobfuscateWord(%q) = %q, want %q", seed, obf, back, w)
+					}
+				}
+			}
+		})
+	}
+}
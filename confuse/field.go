@@ -0,0 +1,188 @@
+package confuse
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ============================================================================
+// Field-level Obfuscation (camelCase / snake_case / kebab-case identifiers)
+// ============================================================================
+
+// fieldToken is a segment of a field, either a word or a separator run
+type fieldToken struct {
+	text   string
+	isWord bool
+}
+
+// ObfuscateField obfuscates every word of an identifier such as "userName" or
+// "order_id" while keeping separators and the case pattern of each word,
+// so the result remains a valid identifier of the same style.
+// Non-ASCII text (e.g. "用户_name") is character-encrypted within its charset
+func (sdk *ObfuscatorSDK) ObfuscateField(field string) string {
+	return sdk.transformField(field, false)
+}
+
+// DeobfuscateField reverses ObfuscateField
+func (sdk *ObfuscatorSDK) DeobfuscateField(field string) string {
+	return sdk.transformField(field, true)
+}
+
+func (sdk *ObfuscatorSDK) transformField(field string, reverse bool) string {
+	if field == "" {
+		return field
+	}
+
+	// one snapshot per field, so concurrent setters can't split a field
+	st := sdk.load()
+
+	var sb strings.Builder
+	sb.Grow(len(field))
+	for _, t := range splitField(field) {
+		if !t.isWord {
+			// separators are never in a charset, non-ASCII letters are
+			sb.WriteString(st.transformChars(t.text, reverse))
+			continue
+		}
+		sb.WriteString(st.transformToken(t.text, reverse))
+	}
+	return sb.String()
+}
+
+// transformToken maps a single word according to its shape:
+//   - lowercase words go through the dictionary as-is
+//   - Titlecase words go through the dictionary lowercased, skipping
+//     single-letter results so camelCase boundaries survive the round trip
+//   - UPPERCASE words are not dictionary words and fall back to
+//     case-preserving character encryption
+//   - preserved words (see SetPreserveWords) are kept in any case
+func (st *sdkState) transformToken(token string, reverse bool) string {
+	fn := st.obfuscateWord
+	if reverse {
+		fn = st.deobfuscateWord
+	}
+
+	switch {
+	case st.isPreserved(token):
+		// preserved in any case, e.g. "ID" in "userID"
+		return token
+	case isLowerToken(token):
+		return fn(token)
+	case isTitleToken(token):
+		if len(token) == 1 {
+			// skip preserved letters, e.g. "A" with stop words, which the
+			// reverse mapping would keep as they are
+			if !st.encryptOutOfDict {
+				return token
+			}
+			return st.walkChars(token, reverse, st.isPreserved)
+		}
+		return toTitle(walkWord(strings.ToLower(token), fn))
+	default:
+		return fn(token)
+	}
+}
+
+// walkWord applies fn until the result has at least two characters.
+// Because fn is a permutation of the dictionary this "cycle walking" is still
+// a bijection on words of length >= 2, and the reverse walk undoes it
+func walkWord(word string, fn func(string) string) string {
+	out := fn(word)
+	for len(out) < 2 && out != word {
+		out = fn(out)
+	}
+	return out
+}
+
+// transformChars applies character-level encryption, honoring encryptOutOfDict
+func (st *sdkState) transformChars(token string, reverse bool) string {
+	if !st.encryptOutOfDict {
+		return token
+	}
+	if reverse {
+		return st.decryptByChar(token)
+	}
+	return st.encryptByChar(token)
+}
+
+// splitField splits a field into word and separator tokens.
+// A word is a run of ASCII letters and digits, split on camelCase boundaries:
+// "userName" -> user|Name, "HTTPServer" -> HTTP|Server, "v2Item" -> v2|Item.
+// Tokens are cut at byte offsets, so invalid UTF-8 is kept as is
+func splitField(field string) []fieldToken {
+	runes := make([]rune, 0, len(field))
+	offsets := make([]int, 0, len(field)+1)
+	for i, r := range field {
+		runes = append(runes, r)
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(field))
+	tokens := make([]fieldToken, 0, 4)
+
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i < len(runes) && !isBoundary(runes, i) {
+			continue
+		}
+		tokens = append(tokens, fieldToken{
+			text:   field[offsets[start]:offsets[i]],
+			isWord: isWordRune(runes[start]),
+		})
+		start = i
+	}
+	return tokens
+}
+
+// isBoundary reports whether a new token starts at runes[i]
+func isBoundary(runes []rune, i int) bool {
+	prev, curr := runes[i-1], runes[i]
+	if isWordRune(prev) != isWordRune(curr) {
+		return true
+	}
+	if !isWordRune(curr) || !isASCIIUpper(curr) {
+		return false
+	}
+	if isASCIILower(prev) || isASCIIDigit(prev) {
+		return true
+	}
+	// acronym followed by a Titlecase word: "HTTPServer" splits before "S"
+	return i+1 < len(runes) && (isASCIILower(runes[i+1]) || isASCIIDigit(runes[i+1]))
+}
+
+func isWordRune(r rune) bool {
+	return isASCIILower(r) || isASCIIUpper(r) || isASCIIDigit(r)
+}
+
+func isASCIILower(r rune) bool { return r >= 'a' && r <= 'z' }
+func isASCIIUpper(r rune) bool { return r >= 'A' && r <= 'Z' }
+func isASCIIDigit(r rune) bool { return r >= '0' && r <= '9' }
+
+func isLowerToken(token string) bool {
+	for _, r := range token {
+		if isASCIIUpper(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isTitleToken(token string) bool {
+	for i, r := range token {
+		if i == 0 && !isASCIIUpper(r) {
+			return false
+		}
+		if i > 0 && isASCIIUpper(r) {
+			return false
+		}
+	}
+	return token != ""
+}
+
+func toTitle(word string) string {
+	if word == "" {
+		return word
+	}
+	runes := []rune(word)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
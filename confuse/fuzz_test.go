@@ -0,0 +1,149 @@
+package confuse
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+// reversibility configs cover every setting that changes the mapping, built
+// through the public API only. The default config also covers dictionaries
+// with duplicates, the embedded list has a few
+var reversibilityConfigs = []struct {
+	name      string
+	configure func(sdk *ObfuscatorSDK) *ObfuscatorSDK
+}{
+	{name: "default", configure: func(sdk *ObfuscatorSDK) *ObfuscatorSDK { return sdk }},
+	{name: "keep out of dict", configure: func(sdk *ObfuscatorSDK) *ObfuscatorSDK { return sdk.SetEncryptOutOfDict(false) }},
+	{name: "preserve", configure: func(sdk *ObfuscatorSDK) *ObfuscatorSDK {
+		return sdk.SetPreserveWords(DefaultPreserveWords...).SetPreserveStopWords(true)
+	}},
+	{name: "charsets", configure: func(sdk *ObfuscatorSDK) *ObfuscatorSDK {
+		return sdk.SetCharsets(CharsetLower, CharsetUpper, CharsetDigit, CharsetHiragana, CharsetKatakana, CharsetHangul)
+	}},
+	{name: "charsets of 1-3 symbols", configure: func(sdk *ObfuscatorSDK) *ObfuscatorSDK {
+		return sdk.SetCharsets(Charset{Lo: 'a', Hi: 'a'}, Charset{Lo: 'b', Hi: 'c'}, Charset{Lo: 'd', Hi: 'f'}, Charset{Lo: '0', Hi: '1'})
+	}},
+	{name: "no cache", configure: func(sdk *ObfuscatorSDK) *ObfuscatorSDK { return sdk.SetCharCacheSize(0) }},
+	{name: "custom dictionary", configure: func(sdk *ObfuscatorSDK) *ObfuscatorSDK {
+		// NewDictionary drops duplicates and normalizes case
+		return withFuzzDictionary(sdk, "fuzz", "order", "Order", "user", "name", "id", "a", "b", "v1", "user")
+	}},
+	{name: "one word dictionary", configure: func(sdk *ObfuscatorSDK) *ObfuscatorSDK {
+		return withFuzzDictionary(sdk, "fuzz-1", "user")
+	}},
+	{name: "two word dictionary", configure: func(sdk *ObfuscatorSDK) *ObfuscatorSDK {
+		return withFuzzDictionary(sdk, "fuzz-2", "order", "user")
+	}},
+	{name: "three word dictionary", configure: func(sdk *ObfuscatorSDK) *ObfuscatorSDK {
+		return withFuzzDictionary(sdk, "fuzz-3", "id", "order", "user")
+	}},
+}
+
+// withFuzzDictionary registers a dictionary of words and returns sdk using it
+func withFuzzDictionary(sdk *ObfuscatorSDK, name string, words ...string) *ObfuscatorSDK {
+	RegisterDictionary(NewDictionary(name, words))
+	derived, err := sdk.WithDictionary(name)
+	if err != nil {
+		panic(err)
+	}
+	return derived
+}
+
+// newFuzzSDK returns an uncached SDK, so configs don't leak into other tests
+func newFuzzSDK(seed int, configure func(sdk *ObfuscatorSDK) *ObfuscatorSDK) *ObfuscatorSDK {
+	return configure(NewObfuscatorSDK(0).WithSeed(seed))
+}
+
+var fuzzCorpus = []string{
+	"", "a", "A", "B", "id", "ID", "file", "list", "req", "ticket", "user", "User",
+	"userName", "order_id", "HTTPServer", "v2Item", "2FA", "createdAt", "xyz123",
+	"user123@test.com", "你好world123", "用户_name", "Привет", "こんにちは", "한국어",
+	"\xff\xfe", "a\x80b", "emoji😀", "tab\tnew\nline", "kebab-case-field", "UPPER_SNAKE",
+}
+
+func FuzzObfuscateWord(f *testing.F) {
+	for i, s := range fuzzCorpus {
+		f.Add(s, i*7919-20240)
+	}
+	f.Fuzz(func(t *testing.T, word string, seed int) {
+		for _, cfg := range reversibilityConfigs {
+			sdk := newFuzzSDK(seed, cfg.configure)
+			out := sdk.ObfuscateWord(word)
+			if back := sdk.DeobfuscateWord(out); back != word {
+				t.Fatalf("%s seed %d: %q -> %q -> %q", cfg.name, seed, word, out, back)
+			}
+			if utf8.ValidString(word) && utf8.RuneCountInString(out) != utf8.RuneCountInString(word) && !sdk.Dictionary().Has(word) {
+				t.Fatalf("%s seed %d: %q -> %q changed length", cfg.name, seed, word, out)
+			}
+		}
+	})
+}
+
+func FuzzObfuscateField(f *testing.F) {
+	for i, s := range fuzzCorpus {
+		f.Add(s, i*104729+1)
+	}
+	f.Fuzz(func(t *testing.T, field string, seed int) {
+		for _, cfg := range reversibilityConfigs {
+			sdk := newFuzzSDK(seed, cfg.configure)
+			out := sdk.ObfuscateField(field)
+			if back := sdk.DeobfuscateField(out); back != field {
+				t.Fatalf("%s seed %d: %q -> %q -> %q", cfg.name, seed, field, out, back)
+			}
+		}
+	})
+}
+
+// TestDictionaryBijection checks every dictionary word for a spread of seeds:
+// distinct words never share an output and every output reverses
+func TestDictionaryBijection(t *testing.T) {
+	seeds := []int{0, 1, 2, -1, 7, 12345, 20240, -987654321, 1 << 40}
+	for _, cfg := range reversibilityConfigs {
+		for _, seed := range seeds {
+			sdk := newFuzzSDK(seed, cfg.configure)
+			seen := make(map[string]string)
+			for _, word := range sdk.Dictionary().Words() {
+				out := sdk.ObfuscateWord(word)
+				if prev, ok := seen[out]; ok && prev != word {
+					t.Fatalf("%s seed %d: %q and %q both map to %q", cfg.name, seed, prev, word, out)
+				}
+				seen[out] = word
+				if back := sdk.DeobfuscateWord(out); back != word {
+					t.Fatalf("%s seed %d: %q -> %q -> %q", cfg.name, seed, word, out, back)
+				}
+			}
+		}
+	}
+}
+
+// TestShortWordsReversible exhausts all words of up to 3 lowercase letters or
+// digits, which collide with dictionary words after character encryption most often
+func TestShortWordsReversible(t *testing.T) {
+	if testing.Short() {
+		t.Skip("exhaustive")
+	}
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+	var words []string
+	for _, a := range alphabet {
+		words = append(words, string(a))
+		for _, b := range alphabet {
+			words = append(words, string([]rune{a, b}))
+			for _, c := range alphabet {
+				words = append(words, string([]rune{a, b, c}))
+			}
+		}
+	}
+
+	for _, cfg := range reversibilityConfigs {
+		const seed = 20240
+		sdk := newFuzzSDK(seed, cfg.configure)
+		for _, w := range words {
+			for _, word := range []string{w, toTitle(w)} {
+				out := sdk.ObfuscateField(word)
+				if back := sdk.DeobfuscateField(out); back != word {
+					t.Fatalf("%s seed %d: %q -> %q -> %q", cfg.name, seed, word, out, back)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package confuse
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ============================================================================
+// Document Header - records which mapping produced an obfuscated document
+// ============================================================================
+
+// headerVersion is the current header format
+const headerVersion = 1
+
+const headerPrefix = "confuse/"
+
+var (
+	// ErrMissingHeader is returned when a header is required but the document has none
+	ErrMissingHeader = errors.New("confuse: document header missing")
+	// ErrHeaderMismatch is returned when a document was produced with another
+	// seed, mapping settings or header version than the SDK decoding it
+	ErrHeaderMismatch = errors.New("confuse: document header mismatch")
+)
+
+// Header identifies the mapping a document was obfuscated with.
+// Dict is a short SHA-256 fingerprint of every setting besides the seed that
+// changes the mapping: the dictionary words, the preserve list, whether
+// out-of-dictionary words are encrypted and the charsets used to encrypt them.
+// Seed is only set with WithHeaderKey: an HMAC of the seed under that key, an
+// unkeyed hash of a 31-bit seed could be brute-forced
+type Header struct {
+	Version int
+	Seed    string
+	Dict    string
+}
+
+// Header returns the header written by the WithJSONHeader / WithCSVHeader options
+func (sdk *ObfuscatorSDK) Header() Header {
+	return sdk.load().header()
+}
+
+// WithHeaderKey returns a copy of the SDK that writes and checks a seed
+// fingerprint keyed with key in document headers. Both sides need the same
+// key; without one a document obfuscated with another seed is not detected
+func (sdk *ObfuscatorSDK) WithHeaderKey(key []byte) *ObfuscatorSDK {
+	key = append([]byte(nil), key...)
+	return sdk.derive(func(st *sdkState) {
+		st.headerKey = key
+	})
+}
+
+func (st *sdkState) header() Header {
+	var seed string
+	if len(st.headerKey) > 0 {
+		mac := hmac.New(sha256.New, st.headerKey)
+		mac.Write([]byte("confuse/seed-fingerprint\x00" + strconv.Itoa(st.seed)))
+		seed = hex.EncodeToString(mac.Sum(nil)[:4])
+	}
+
+	var dict string
+	if view := st.dict.view(); view != nil {
+		dict = view.hash
+	}
+	parts := []string{"dict=" + dict, "encrypt=" + strconv.FormatBool(st.encryptOutOfDict)}
+	if st.encryptOutOfDict {
+		// charsets don't overlap, so their order doesn't change the mapping
+		ranges := make([]string, 0, len(st.charsets))
+		for _, c := range st.charsets {
+			ranges = append(ranges, fmt.Sprintf("%x-%x", c.Lo, c.Hi))
+		}
+		sort.Strings(ranges)
+		parts = append(parts, "charsets="+strings.Join(ranges, ","))
+	}
+	preserve := make([]string, 0, len(st.preserve))
+	for w := range st.preserve {
+		preserve = append(preserve, w)
+	}
+	sort.Strings(preserve)
+	parts = append(parts, "preserve="+strings.Join(preserve, ","))
+
+	return Header{
+		Version: headerVersion,
+		Seed:    seed,
+		Dict:    hashWords(parts),
+	}
+}
+
+// String formats the header as "confuse/1 seed=1a2b3c4d dict=0123456789ab",
+// seed is left out when empty
+func (h Header) String() string {
+	if h.Seed == "" {
+		return fmt.Sprintf("%s%d dict=%s", headerPrefix, h.Version, h.Dict)
+	}
+	return fmt.Sprintf("%s%d seed=%s dict=%s", headerPrefix, h.Version, h.Seed, h.Dict)
+}
+
+// ParseHeader parses the output of Header.String
+func ParseHeader(s string) (Header, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], headerPrefix) {
+		return Header{}, fmt.Errorf("confuse: invalid header %q", s)
+	}
+	version, err := strconv.Atoi(strings.TrimPrefix(fields[0], headerPrefix))
+	if err != nil {
+		return Header{}, fmt.Errorf("confuse: invalid header version %q", fields[0])
+	}
+
+	h := Header{Version: version}
+	for _, f := range fields[1:] {
+		key, value, _ := strings.Cut(f, "=")
+		switch key {
+		case "seed":
+			h.Seed = value
+		case "dict":
+			h.Dict = value
+		}
+	}
+	return h, nil
+}
+
+// checkHeader verifies that a document header matches the SDK settings
+func (st *sdkState) checkHeader(raw string) error {
+	got, err := ParseHeader(raw)
+	if err != nil {
+		return err
+	}
+	want := st.header()
+	switch {
+	case got.Version != want.Version:
+		return fmt.Errorf("%w: version %d, want %d", ErrHeaderMismatch, got.Version, want.Version)
+	case got.Seed != want.Seed:
+		return fmt.Errorf("%w: seed %s, want %s", ErrHeaderMismatch, got.Seed, want.Seed)
+	case got.Dict != want.Dict:
+		return fmt.Errorf("%w: mapping settings %s, want %s", ErrHeaderMismatch, got.Dict, want.Dict)
+	}
+	return nil
+}
+
+// hashWords returns a 12 hex digit fingerprint of an ordered word list
+func hashWords(words []string) string {
+	h := sha256.New()
+	for _, w := range words {
+		h.Write([]byte(w))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:6])
+}
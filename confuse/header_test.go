@@ -0,0 +1,147 @@
+package confuse
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+var testHeaderKey = []byte("partner-a header key")
+
+func TestHeader_String(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+	for _, h := range []Header{sdk.Header(), sdk.WithHeaderKey(testHeaderKey).Header()} {
+		if h.Version != headerVersion || len(h.Dict) != 12 {
+			t.Fatalf("Header() = %+v", h)
+		}
+		parsed, err := ParseHeader(h.String())
+		if err != nil {
+			t.Fatalf("ParseHeader() error = %v", err)
+		}
+		if parsed != h {
+			t.Errorf("ParseHeader() = %+v, want %+v", parsed, h)
+		}
+	}
+
+	if h := sdk.Header(); h.Seed != "" || strings.Contains(h.String(), "seed=") {
+		t.Errorf("Header() without key = %s, want no seed fingerprint", h)
+	}
+	keyed := sdk.WithHeaderKey(testHeaderKey).Header()
+	if len(keyed.Seed) != 8 || keyed.Seed == sdk.WithHeaderKey([]byte("other key")).Header().Seed {
+		t.Errorf("keyed seed fingerprint = %q, want 8 hex digits depending on the key", keyed.Seed)
+	}
+
+	if _, err := ParseHeader("seed=1 dict=2"); err == nil {
+		t.Error("ParseHeader() accepted a header without version")
+	}
+}
+
+func TestHeader_MappingSettings(t *testing.T) {
+	base := NewObfuscatorSDK(0).WithSeed(20240)
+	want := base.Header().Dict
+	reversed := slices.Clone(DefaultCharsets)
+	slices.Reverse(reversed)
+
+	tests := []struct {
+		name string
+		sdk  *ObfuscatorSDK
+		same bool
+	}{
+		{name: "same settings", sdk: NewObfuscatorSDK(0).WithSeed(20240), same: true},
+		{name: "other seed", sdk: base.WithSeed(1), same: true},
+		{name: "charset order", sdk: NewObfuscatorSDK(0).WithSeed(20240).SetCharsets(reversed...), same: true},
+		{name: "other charsets", sdk: NewObfuscatorSDK(0).WithSeed(20240).SetCharsets(CharsetLower, CharsetUpper)},
+		{name: "keep out of dict", sdk: NewObfuscatorSDK(0).WithSeed(20240).SetEncryptOutOfDict(false)},
+		{name: "preserve list", sdk: NewObfuscatorSDK(0).WithSeed(20240).SetPreserveWords("user")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.sdk.Header().Dict; (got == want) != tt.same {
+				t.Errorf("Dict = %s, base %s, want equal = %v", got, want, tt.same)
+			}
+		})
+	}
+}
+
+func TestJSONHeader(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240).WithHeaderKey(testHeaderKey)
+	data := []byte(`{"userName":"alice","orderList":[{"skuId":"a1"}]}`)
+
+	obf, err := sdk.ObfuscateJSON(data, WithJSONHeader())
+	if err != nil {
+		t.Fatalf("ObfuscateJSON() error = %v", err)
+	}
+	if !strings.HasPrefix(string(obf), `{"_confuse":"confuse/1 seed=`) {
+		t.Fatalf("ObfuscateJSON() = %s, want header envelope", obf)
+	}
+
+	back, err := sdk.DeobfuscateJSON(obf, WithJSONHeader())
+	if err != nil {
+		t.Fatalf("DeobfuscateJSON() error = %v", err)
+	}
+	if string(back) != string(data) {
+		t.Errorf("DeobfuscateJSON() = %s, want %s", back, data)
+	}
+
+	tests := []struct {
+		name    string
+		sdk     *ObfuscatorSDK
+		doc     []byte
+		wantErr error
+	}{
+		{name: "other seed", sdk: sdk.WithSeed(1), doc: obf, wantErr: ErrHeaderMismatch},
+		{name: "other preserve list", sdk: sdk.WithSeed(20240).SetPreserveWords("user"), doc: obf, wantErr: ErrHeaderMismatch},
+		{name: "other charsets", sdk: sdk.WithSeed(20240).SetCharsets(CharsetLower), doc: obf, wantErr: ErrHeaderMismatch},
+		{name: "no header key", sdk: NewObfuscatorSDK(20240), doc: obf, wantErr: ErrHeaderMismatch},
+		{name: "no header", sdk: sdk, doc: data, wantErr: ErrMissingHeader},
+		{name: "not an object", sdk: sdk, doc: []byte(`[1,2]`), wantErr: ErrMissingHeader},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.sdk.DeobfuscateJSON(tt.doc, WithJSONHeader()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("DeobfuscateJSON() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCSVHeader(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240).WithHeaderKey(testHeaderKey)
+	data := "user_name,order_total\nalice,12.50\n\"bob, jr\",3\n"
+
+	tests := []struct {
+		name string
+		opts []CSVOption
+	}{
+		{name: "columns"},
+		{name: "values", opts: []CSVOption{WithCSVValues()}},
+		{name: "header", opts: []CSVOption{WithCSVHeader()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obf, err := sdk.ObfuscateCSV([]byte(data), tt.opts...)
+			if err != nil {
+				t.Fatalf("ObfuscateCSV() error = %v", err)
+			}
+			if strings.Contains(string(obf), "user_name") {
+				t.Fatalf("ObfuscateCSV() kept column names: %s", obf)
+			}
+			back, err := sdk.DeobfuscateCSV(obf, tt.opts...)
+			if err != nil {
+				t.Fatalf("DeobfuscateCSV() error = %v", err)
+			}
+			if string(back) != data {
+				t.Errorf("DeobfuscateCSV() = %q, want %q", back, data)
+			}
+		})
+	}
+
+	obf, _ := sdk.ObfuscateCSV([]byte(data), WithCSVHeader())
+	if _, err := sdk.WithSeed(7).DeobfuscateCSV(obf, WithCSVHeader()); !errors.Is(err, ErrHeaderMismatch) {
+		t.Errorf("DeobfuscateCSV() with other seed error = %v, want %v", err, ErrHeaderMismatch)
+	}
+	if _, err := sdk.DeobfuscateCSV([]byte(data), WithCSVHeader()); !errors.Is(err, ErrMissingHeader) {
+		t.Errorf("DeobfuscateCSV() without header error = %v, want %v", err, ErrMissingHeader)
+	}
+}
@@ -0,0 +1,200 @@
+package confuse
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ============================================================================
+// JSON Obfuscation - rewrite keys (and optionally string values) in place
+// ============================================================================
+
+type jsonOptions struct {
+	values bool
+	header bool
+}
+
+// JSONOption configures ObfuscateJSON / DeobfuscateJSON
+type JSONOption func(*jsonOptions)
+
+// WithJSONValues also obfuscates string values, not only object keys
+func WithJSONValues() JSONOption {
+	return func(o *jsonOptions) {
+		o.values = true
+	}
+}
+
+// WithJSONHeader wraps the output in an envelope carrying the SDK Header,
+// {"_confuse":"confuse/1 dict=...","data":<document>}, and makes
+// DeobfuscateJSON reject documents without it or produced with other mapping
+// settings (or another seed, see WithHeaderKey), instead of returning wrong words
+func WithJSONHeader() JSONOption {
+	return func(o *jsonOptions) {
+		o.header = true
+	}
+}
+
+// jsonEnvelope is the document written by WithJSONHeader
+type jsonEnvelope struct {
+	Header *string         `json:"_confuse"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// ObfuscateJSON rewrites every object key of an arbitrary JSON document with
+// ObfuscateField, keeping key order, nesting, numbers and literals unchanged.
+// The output is compact JSON.
+func (sdk *ObfuscatorSDK) ObfuscateJSON(data []byte, opts ...JSONOption) ([]byte, error) {
+	o := newJSONOptions(opts)
+	out, err := sdk.transformJSON(data, sdk.ObfuscateField, o)
+	if err != nil || !o.header {
+		return out, err
+	}
+
+	h, _ := json.Marshal(sdk.Header().String())
+	env := make([]byte, 0, len(out)+len(h)+24)
+	env = append(env, `{"_confuse":`...)
+	env = append(env, h...)
+	env = append(env, `,"data":`...)
+	env = append(env, out...)
+	return append(env, '}'), nil
+}
+
+// DeobfuscateJSON reverses ObfuscateJSON, using the same options
+func (sdk *ObfuscatorSDK) DeobfuscateJSON(data []byte, opts ...JSONOption) ([]byte, error) {
+	o := newJSONOptions(opts)
+	if o.header {
+		var env jsonEnvelope
+		if err := json.Unmarshal(data, &env); err != nil || env.Header == nil {
+			return nil, ErrMissingHeader
+		}
+		if err := sdk.load().checkHeader(*env.Header); err != nil {
+			return nil, err
+		}
+		data = env.Data
+	}
+	return sdk.transformJSON(data, sdk.DeobfuscateField, o)
+}
+
+func newJSONOptions(opts []JSONOption) *jsonOptions {
+	o := &jsonOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// jsonFrame tracks the container being written
+type jsonFrame struct {
+	object    bool
+	count     int  // elements written so far
+	expectKey bool // next string token in an object is a key
+}
+
+func (sdk *ObfuscatorSDK) transformJSON(data []byte, fn func(string) string, o *jsonOptions) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var (
+		out   bytes.Buffer
+		stack []*jsonFrame
+		root  bool
+	)
+	out.Grow(len(data))
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("confuse: invalid json: %w", err)
+		}
+
+		if len(stack) == 0 {
+			if root {
+				return nil, errors.New("confuse: invalid json: multiple top-level values")
+			}
+			root = true
+		}
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			out.WriteByte(byte(d))
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				stack[len(stack)-1].afterValue()
+			}
+			continue
+		}
+
+		// separators before keys / array elements, colon before object values
+		isKey := top != nil && top.object && top.expectKey
+		if top != nil {
+			switch {
+			case isKey && top.count > 0, !top.object && top.count > 0:
+				out.WriteByte(',')
+			case top.object && !top.expectKey:
+				out.WriteByte(':')
+			}
+		}
+
+		switch v := tok.(type) {
+		case json.Delim:
+			out.WriteByte(byte(v))
+			stack = append(stack, &jsonFrame{object: v == '{', expectKey: v == '{'})
+			continue
+		case string:
+			if isKey || o.values {
+				v = fn(v)
+			}
+			if err := writeJSONValue(&out, v); err != nil {
+				return nil, err
+			}
+		default:
+			if err := writeJSONValue(&out, v); err != nil {
+				return nil, err
+			}
+		}
+
+		if isKey {
+			top.expectKey = false
+			continue
+		}
+		if top != nil {
+			top.afterValue()
+		}
+	}
+
+	if !root {
+		return nil, errors.New("confuse: invalid json: empty document")
+	}
+	if len(stack) > 0 {
+		return nil, errors.New("confuse: invalid json: unexpected end of document")
+	}
+	return out.Bytes(), nil
+}
+
+func (f *jsonFrame) afterValue() {
+	f.count++
+	if f.object {
+		f.expectKey = true
+	}
+}
+
+func writeJSONValue(out *bytes.Buffer, v any) error {
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("confuse: encode json: %w", err)
+	}
+	// Encoder appends a newline after every value
+	out.Truncate(out.Len() - 1)
+	return nil
+}
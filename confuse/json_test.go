@@ -0,0 +1,79 @@
+package confuse
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestObfuscateJSON(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	tests := []struct {
+		name string
+		data string
+		opts []JSONOption
+	}{
+		{name: "flat object", data: `{"userName":"alice","age":18,"vip":true,"note":null}`},
+		{name: "nested", data: `{"order":{"itemList":[{"skuId":"a1"},{"sku_id":"b2"}],"total":12.50}}`},
+		{name: "top level array", data: `[{"user_name":"bob"},1,"x",[]]`},
+		{name: "values", data: `{"status":"order paid","tags":["first order","vip"]}`, opts: []JSONOption{WithJSONValues()}},
+		{name: "empty containers", data: `{"a":{},"b":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obf, err := sdk.ObfuscateJSON([]byte(tt.data), tt.opts...)
+			if err != nil {
+				t.Fatalf("ObfuscateJSON() error = %v", err)
+			}
+			if !json.Valid(obf) {
+				t.Fatalf("ObfuscateJSON() produced invalid json: %s", obf)
+			}
+
+			back, err := sdk.DeobfuscateJSON(obf, tt.opts...)
+			if err != nil {
+				t.Fatalf("DeobfuscateJSON() error = %v", err)
+			}
+
+			var want, got any
+			_ = json.Unmarshal([]byte(tt.data), &want)
+			_ = json.Unmarshal(back, &got)
+			if !reflect.DeepEqual(want, got) {
+				t.Fatalf("round trip mismatch:\n want %s\n got  %s\n obf  %s", tt.data, back, obf)
+			}
+		})
+	}
+}
+
+func TestObfuscateJSON_KeepsValuesByDefault(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	obf, err := sdk.ObfuscateJSON([]byte(`{"userName":"alice"}`))
+	if err != nil {
+		t.Fatalf("ObfuscateJSON() error = %v", err)
+	}
+
+	var m map[string]string
+	if err := json.Unmarshal(obf, &m); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if _, ok := m["userName"]; ok {
+		t.Fatalf("key was not obfuscated: %s", obf)
+	}
+	for _, v := range m {
+		if v != "alice" {
+			t.Fatalf("value changed without WithJSONValues: %s", obf)
+		}
+	}
+}
+
+func TestObfuscateJSON_Invalid(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	for _, data := range []string{``, `{"a":`, `{"a":1}{"b":2}`} {
+		if _, err := sdk.ObfuscateJSON([]byte(data)); err == nil {
+			t.Errorf("ObfuscateJSON(%q) expected error", data)
+		}
+	}
+}
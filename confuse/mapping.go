@@ -0,0 +1,266 @@
+package confuse
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// Mapping - original <-> obfuscated pairs that can be shared without the seed
+// ============================================================================
+
+const mappingVersion = 1
+
+// Mapping is a concurrency safe, bijective set of original -> obfuscated pairs
+type Mapping struct {
+	mu      sync.RWMutex
+	forward map[string]string // original -> obfuscated
+	reverse map[string]string // obfuscated -> original
+}
+
+// mappingFile is the on-disk / exported representation
+type mappingFile struct {
+	Version int               `json:"version"`
+	Entries map[string]string `json:"entries"` // original -> obfuscated
+}
+
+// NewMapping creates an empty mapping
+func NewMapping() *Mapping {
+	return &Mapping{
+		forward: make(map[string]string),
+		reverse: make(map[string]string),
+	}
+}
+
+// BuildMapping obfuscates every field with ObfuscateField and records the pairs
+func (sdk *ObfuscatorSDK) BuildMapping(fields ...string) *Mapping {
+	m := NewMapping()
+	for _, field := range fields {
+		// ObfuscateField is a bijection, so Add cannot conflict here
+		_ = m.Add(field, sdk.ObfuscateField(field))
+	}
+	return m
+}
+
+// Add records a pair. It fails if either side is already mapped to something else
+func (m *Mapping) Add(original, obfuscated string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.add(original, obfuscated)
+}
+
+func (m *Mapping) add(original, obfuscated string) error {
+	if v, ok := m.forward[original]; ok && v != obfuscated {
+		return fmt.Errorf("confuse: %q already mapped to %q", original, v)
+	}
+	if v, ok := m.reverse[obfuscated]; ok && v != original {
+		return fmt.Errorf("confuse: %q already mapped from %q", obfuscated, v)
+	}
+	m.forward[original] = obfuscated
+	m.reverse[obfuscated] = original
+	return nil
+}
+
+// Obfuscate looks up the obfuscated form of original
+func (m *Mapping) Obfuscate(original string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.forward[original]
+	return v, ok
+}
+
+// Deobfuscate looks up the original form of obfuscated
+func (m *Mapping) Deobfuscate(obfuscated string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.reverse[obfuscated]
+	return v, ok
+}
+
+// Len returns the number of pairs
+func (m *Mapping) Len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.forward)
+}
+
+// Entries returns a copy of all original -> obfuscated pairs
+func (m *Mapping) Entries() map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entries := make(map[string]string, len(m.forward))
+	for k, v := range m.forward {
+		entries[k] = v
+	}
+	return entries
+}
+
+// Merge adds all pairs of other, failing on the first conflict
+func (m *Mapping) Merge(other *Mapping) error {
+	if other == m {
+		return nil
+	}
+	entries := other.Entries()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, v := range entries {
+		if err := m.add(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportMapping writes the mapping as versioned JSON with sorted keys
+func (m *Mapping) ExportMapping(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(mappingFile{Version: mappingVersion, Entries: m.Entries()}); err != nil {
+		return fmt.Errorf("confuse: export mapping: %w", err)
+	}
+	return nil
+}
+
+// ImportMapping merges pairs written by ExportMapping into m
+func (m *Mapping) ImportMapping(r io.Reader) error {
+	var f mappingFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return fmt.Errorf("confuse: import mapping: %w", err)
+	}
+	if f.Version != mappingVersion {
+		return fmt.Errorf("confuse: import mapping: unsupported version %d", f.Version)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, v := range f.Entries {
+		if err := m.add(k, v); err != nil {
+			return fmt.Errorf("confuse: import mapping: %w", err)
+		}
+	}
+	return nil
+}
+
+// ============================================================================
+// Mapping stores
+// ============================================================================
+
+// ErrMappingNotFound is returned by stores when no mapping exists under a name
+var ErrMappingNotFound = errors.New("confuse: mapping not found")
+
+// MappingStore persists named mappings so other services can reuse them
+type MappingStore interface {
+	Save(ctx context.Context, name string, m *Mapping) error
+	Load(ctx context.Context, name string) (*Mapping, error)
+}
+
+// FileMappingStore keeps each mapping as <dir>/<name>.json
+type FileMappingStore struct {
+	dir string
+}
+
+// NewFileMappingStore creates a file store rooted at dir
+func NewFileMappingStore(dir string) *FileMappingStore {
+	return &FileMappingStore{dir: dir}
+}
+
+// Save writes the mapping atomically (temp file + rename)
+func (s *FileMappingStore) Save(_ context.Context, name string, m *Mapping) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("confuse: create mapping dir: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("confuse: create mapping file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := m.ExportMapping(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("confuse: write mapping file: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.path(name))
+}
+
+// Load reads the mapping saved under name
+func (s *FileMappingStore) Load(_ context.Context, name string) (*Mapping, error) {
+	f, err := os.Open(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrMappingNotFound, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("confuse: open mapping file: %w", err)
+	}
+	defer f.Close()
+
+	m := NewMapping()
+	if err := m.ImportMapping(f); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *FileMappingStore) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+// RedisMappingStore keeps each mapping in a hash <prefix><name> of original -> obfuscated
+type RedisMappingStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisMappingStore creates a Redis store, keys are prefix+name
+func NewRedisMappingStore(client redis.UniversalClient, prefix string) *RedisMappingStore {
+	return &RedisMappingStore{client: client, prefix: prefix}
+}
+
+// Save replaces the stored mapping in a single transaction
+func (s *RedisMappingStore) Save(ctx context.Context, name string, m *Mapping) error {
+	key := s.prefix + name
+	entries := m.Entries()
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(entries) > 0 {
+			pipe.HSet(ctx, key, entries)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("confuse: save mapping %s: %w", name, err)
+	}
+	return nil
+}
+
+// Load reads the mapping stored under name
+func (s *RedisMappingStore) Load(ctx context.Context, name string) (*Mapping, error) {
+	entries, err := s.client.HGetAll(ctx, s.prefix+name).Result()
+	if err != nil {
+		return nil, fmt.Errorf("confuse: load mapping %s: %w", name, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrMappingNotFound, name)
+	}
+
+	m := NewMapping()
+	for k, v := range entries {
+		if err := m.add(k, v); err != nil {
+			return nil, fmt.Errorf("confuse: load mapping %s: %w", name, err)
+		}
+	}
+	return m, nil
+}
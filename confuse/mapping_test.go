@@ -0,0 +1,85 @@
+package confuse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMapping_ExportImport(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+	m := sdk.BuildMapping("userName", "order_id", "createdAt")
+
+	var buf bytes.Buffer
+	if err := m.ExportMapping(&buf); err != nil {
+		t.Fatalf("ExportMapping() error = %v", err)
+	}
+
+	imported := NewMapping()
+	if err := imported.ImportMapping(&buf); err != nil {
+		t.Fatalf("ImportMapping() error = %v", err)
+	}
+	if imported.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", imported.Len())
+	}
+
+	for _, field := range []string{"userName", "order_id", "createdAt"} {
+		obf, ok := imported.Obfuscate(field)
+		if !ok || obf != sdk.ObfuscateField(field) {
+			t.Fatalf("Obfuscate(%q) = %q, %v", field, obf, ok)
+		}
+		orig, ok := imported.Deobfuscate(obf)
+		if !ok || orig != field {
+			t.Fatalf("Deobfuscate(%q) = %q, %v", obf, orig, ok)
+		}
+	}
+}
+
+func TestMapping_Conflicts(t *testing.T) {
+	tests := []struct {
+		name       string
+		original   string
+		obfuscated string
+		wantErr    bool
+	}{
+		{name: "same pair", original: "a", obfuscated: "x"},
+		{name: "original remapped", original: "a", obfuscated: "y", wantErr: true},
+		{name: "obfuscated reused", original: "b", obfuscated: "x", wantErr: true},
+		{name: "new pair", original: "b", obfuscated: "y"},
+	}
+
+	m := NewMapping()
+	if err := m.Add("a", "x"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.Add(tt.original, tt.obfuscated); (err != nil) != tt.wantErr {
+				t.Fatalf("Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileMappingStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileMappingStore(t.TempDir())
+
+	if _, err := store.Load(ctx, "partner"); !errors.Is(err, ErrMappingNotFound) {
+		t.Fatalf("Load() missing error = %v, want ErrMappingNotFound", err)
+	}
+
+	m := NewObfuscatorSDK(20240).BuildMapping("userName", "orderId")
+	if err := store.Save(ctx, "partner", m); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := store.Load(ctx, "partner")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Len() != m.Len() {
+		t.Fatalf("Len() = %d, want %d", loaded.Len(), m.Len())
+	}
+}
@@ -0,0 +1,152 @@
+package confuse
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// ============================================================================
+// HTTP Middleware - obfuscate JSON field names for external partners
+// ============================================================================
+
+// DefaultPartnerHeader is the request header used to identify the partner
+const DefaultPartnerHeader = "X-Partner-Id"
+
+type middlewareOptions struct {
+	partnerFunc func(r *http.Request) string
+	jsonOpts    []JSONOption
+}
+
+// MiddlewareOption configures Middleware
+type MiddlewareOption func(*middlewareOptions)
+
+// WithPartnerFunc sets how the partner id is resolved from a request.
+// Defaults to the X-Partner-Id header
+func WithPartnerFunc(fn func(r *http.Request) string) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.partnerFunc = fn
+	}
+}
+
+// WithMiddlewareJSONOptions passes options to ObfuscateJSON / DeobfuscateJSON
+func WithMiddlewareJSONOptions(opts ...JSONOption) MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.jsonOpts = append(o.jsonOpts, opts...)
+	}
+}
+
+// Middleware returns a go-zero rest middleware that, for partners listed in
+// seeds (partner id -> seed), deobfuscates JSON request bodies before the
+// handler and obfuscates JSON response keys after it. Requests from other
+// callers pass through untouched. A JSON response that can't be obfuscated
+// is replaced by an empty 500.
+func Middleware(seeds map[string]int, opts ...MiddlewareOption) func(next http.HandlerFunc) http.HandlerFunc {
+	o := &middlewareOptions{
+		partnerFunc: func(r *http.Request) string {
+			return r.Header.Get(DefaultPartnerHeader)
+		},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	sdks := make(map[string]*ObfuscatorSDK, len(seeds))
+	for partner, seed := range seeds {
+		sdks[partner] = NewObfuscatorSDK(seed)
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			sdk, ok := sdks[o.partnerFunc(r)]
+			if !ok {
+				next(w, r)
+				return
+			}
+
+			if isJSONContent(r.Header.Get("Content-Type")) && r.Body != nil && r.Body != http.NoBody {
+				body, err := io.ReadAll(r.Body)
+				r.Body.Close()
+				if err != nil {
+					http.Error(w, "read request body failed", http.StatusBadRequest)
+					return
+				}
+				if len(body) > 0 {
+					if body, err = sdk.DeobfuscateJSON(body, o.jsonOpts...); err != nil {
+						http.Error(w, "invalid json body", http.StatusBadRequest)
+						return
+					}
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				r.ContentLength = int64(len(body))
+				r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+			}
+
+			bw := &bufferedWriter{header: make(http.Header), code: http.StatusOK}
+			next(bw, r)
+			err := bw.flushTo(w, func(body []byte) ([]byte, error) {
+				return sdk.ObfuscateJSON(body, o.jsonOpts...)
+			})
+			if err != nil {
+				logx.WithContext(r.Context()).Errorf("confuse: obfuscate response failed: %v", err)
+			}
+		}
+	}
+}
+
+// bufferedWriter holds the response until the handler returns so the body
+// can be rewritten and Content-Length fixed
+type bufferedWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.code = code
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// flushTo writes the response with JSON bodies rewritten. If the rewrite
+// fails it sends an empty 500 instead, the original body would expose the
+// real field names
+func (w *bufferedWriter) flushTo(dst http.ResponseWriter, rewrite func([]byte) ([]byte, error)) error {
+	body := w.body.Bytes()
+	if len(body) > 0 && isJSONContent(w.header.Get("Content-Type")) {
+		out, err := rewrite(body)
+		if err != nil {
+			dst.Header().Set("Content-Length", "0")
+			dst.WriteHeader(http.StatusInternalServerError)
+			return err
+		}
+		body = out
+		w.header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+
+	h := dst.Header()
+	for k, v := range w.header {
+		h[k] = v
+	}
+	dst.WriteHeader(w.code)
+	_, _ = dst.Write(body)
+	return nil
+}
+
+func isJSONContent(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json"
+}
@@ -0,0 +1,85 @@
+package confuse
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	const seed = 20240
+	sdk := NewObfuscatorSDK(seed)
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		body, _ := io.ReadAll(r.Body)
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &req); err != nil || req["userName"] != "alice" {
+				http.Error(w, "unexpected request: "+string(body), http.StatusTeapot)
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		_, _ = w.Write([]byte(`{"orderId":"1001","userName":"alice"}`))
+	}
+	mw := Middleware(map[string]int{"partner-a": seed})(handler)
+
+	t.Run("partner", func(t *testing.T) {
+		reqBody, _ := sdk.ObfuscateJSON([]byte(`{"userName":"alice"}`))
+		r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(string(reqBody)))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set(DefaultPartnerHeader, "partner-a")
+		w := httptest.NewRecorder()
+
+		mw(w, r)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+		}
+		if strings.Contains(w.Body.String(), "orderId") {
+			t.Fatalf("response keys not obfuscated: %s", w.Body.String())
+		}
+		back, err := sdk.DeobfuscateJSON(w.Body.Bytes())
+		if err != nil || string(back) != `{"orderId":"1001","userName":"alice"}` {
+			t.Fatalf("DeobfuscateJSON() = %s, %v", back, err)
+		}
+		if w.Header().Get("Content-Length") != "" && w.Header().Get("Content-Length") != strconv.Itoa(w.Body.Len()) {
+			t.Fatalf("Content-Length = %s, body length %d", w.Header().Get("Content-Length"), w.Body.Len())
+		}
+	})
+
+	t.Run("invalid response fails closed", func(t *testing.T) {
+		broken := Middleware(map[string]int{"partner-a": seed})(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Trace", "t1")
+			_, _ = w.Write([]byte(`{"orderId":"1001","userName":`))
+		})
+		r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		r.Header.Set(DefaultPartnerHeader, "partner-a")
+		w := httptest.NewRecorder()
+
+		broken(w, r)
+
+		if w.Code != http.StatusInternalServerError || w.Body.Len() != 0 {
+			t.Fatalf("status = %d, body = %s, want empty 500", w.Code, w.Body.String())
+		}
+		if w.Header().Get("Content-Type") != "" || w.Header().Get("X-Trace") != "" {
+			t.Fatalf("handler headers leaked: %v", w.Header())
+		}
+	})
+
+	t.Run("other caller", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		w := httptest.NewRecorder()
+
+		mw(w, r)
+
+		if w.Body.String() != `{"orderId":"1001","userName":"alice"}` {
+			t.Fatalf("response modified for unknown caller: %s", w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package confuse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ============================================================================
+// Model Mapping - reuse sqlx models against an obfuscated replica database
+// ============================================================================
+
+const dbTagName = "db"
+
+// Model maps a sqlx model (columns from `db` tags, as go-zero's builder does)
+// to the obfuscated table and columns of a replica database. Identifiers are
+// obfuscated exactly like ObfuscateSQL does, and string values tagged
+// `confuse:"true"` are mapped by Encode / Decode
+type Model struct {
+	sdk      *ObfuscatorSDK
+	table    string
+	fields   []string          // original column names, in struct order
+	columns  []string          // obfuscated column names, in struct order
+	byColumn map[string]string // original -> obfuscated
+}
+
+// Model builds the mapping for table and model, a struct or pointer to struct
+func (sdk *ObfuscatorSDK) Model(table string, model any) (*Model, error) {
+	t := reflect.TypeOf(model)
+	if t == nil {
+		return nil, errors.New("confuse: model is nil")
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("confuse: model %s is not a struct", t)
+	}
+
+	m := &Model{
+		sdk:      sdk,
+		table:    sdk.sqlIdent(table),
+		byColumn: make(map[string]string),
+	}
+	for _, name := range dbFieldNames(t) {
+		obf := sdk.sqlIdent(name)
+		m.fields = append(m.fields, name)
+		m.columns = append(m.columns, obf)
+		m.byColumn[name] = obf
+	}
+	if len(m.fields) == 0 {
+		return nil, fmt.Errorf("confuse: model %s has no columns", t)
+	}
+	return m, nil
+}
+
+// sqlIdent obfuscates an identifier the same way ObfuscateSQL does
+func (sdk *ObfuscatorSDK) sqlIdent(name string) string {
+	return walkSQLIdent(name, sdk.ObfuscateField)
+}
+
+// dbFieldNames returns column names from `db` tags, skipping `db:"-"`.
+// Untagged exported fields use the field name
+func dbFieldNames(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get(dbTagName)
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// Table returns the obfuscated table name
+func (m *Model) Table() string {
+	return m.table
+}
+
+// Column returns the obfuscated name of a model column, or the name itself
+// if the model has no such column
+func (m *Model) Column(name string) string {
+	if obf, ok := m.byColumn[name]; ok {
+		return obf
+	}
+	return name
+}
+
+// Columns returns the obfuscated columns in struct order
+func (m *Model) Columns() []string {
+	return append([]string(nil), m.columns...)
+}
+
+// ColumnList returns the quoted obfuscated columns for INSERT statements,
+// e.g. "`a`,`b`", in the order of the model fields
+func (m *Model) ColumnList() string {
+	quoted := make([]string, len(m.columns))
+	for i, c := range m.columns {
+		quoted[i] = "`" + c + "`"
+	}
+	return strings.Join(quoted, ",")
+}
+
+// SelectList returns the obfuscated columns aliased back to the original
+// names, e.g. "`a` AS `user_name`", so rows scan into the unchanged model
+func (m *Model) SelectList() string {
+	quoted := make([]string, len(m.columns))
+	for i, c := range m.columns {
+		quoted[i] = "`" + c + "` AS `" + m.fields[i] + "`"
+	}
+	return strings.Join(quoted, ",")
+}
+
+// Query rewrites a statement written against the original schema.
+// Select lists are rewritten too, so prefer SelectList for reads that scan
+// into the model
+func (m *Model) Query(stmt string) (string, error) {
+	out, _, err := m.sdk.ObfuscateSQL(stmt)
+	return out, err
+}
+
+// Encode returns a copy of v with values tagged `confuse:"true"` obfuscated,
+// ready to be written to the replica
+func (m *Model) Encode(v any) (any, error) {
+	return m.sdk.ObfuscateStruct(v)
+}
+
+// Decode deobfuscates tagged values in place after a read. ptr is a pointer to
+// a model or to a slice of models (or model pointers)
+func (m *Model) Decode(ptr any) error {
+	rv := reflect.ValueOf(ptr)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("confuse: decode target must be a non-nil pointer")
+	}
+
+	elem := rv.Elem()
+	w := &structWalker{fn: m.sdk.DeobfuscateField, opts: &structOptions{}}
+	elem.Set(w.copy(elem, false))
+	return nil
+}
@@ -0,0 +1,96 @@
+package confuse
+
+import (
+	"strings"
+	"testing"
+)
+
+type modelUser struct {
+	Id       int64  `db:"id"`
+	UserName string `db:"user_name" confuse:"true"`
+	Email    string `db:"email"`
+	Ignored  string `db:"-"`
+	internal string
+}
+
+func TestModel(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	m, err := sdk.Model("user_info", &modelUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stmt, _, err := sdk.ObfuscateSQL("SELECT user_name, email FROM user_info WHERE id = ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, col := range []string{"user_name", "email", "id"} {
+		if !strings.Contains(stmt, m.Column(col)) {
+			t.Fatalf("%s: column %q not in %q", col, m.Column(col), stmt)
+		}
+	}
+	if !strings.Contains(stmt, m.Table()) {
+		t.Fatalf("table %q not in %q", m.Table(), stmt)
+	}
+
+	if n := len(m.Columns()); n != 3 {
+		t.Fatalf("got %d columns, want 3", n)
+	}
+	if got := m.SelectList(); !strings.Contains(got, "`"+m.Column("email")+"` AS `email`") {
+		t.Fatalf("unexpected select list %q", got)
+	}
+	if got := strings.Count(m.ColumnList(), ","); got != 2 {
+		t.Fatalf("unexpected column list %q", m.ColumnList())
+	}
+}
+
+func TestModelEncodeDecode(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+	m, err := sdk.Model("user_info", modelUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orig := modelUser{Id: 1, UserName: "order_name", Email: "a@b.c"}
+	enc, err := m.Encode(orig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := enc.(modelUser)
+	if row.UserName == orig.UserName || row.Email != orig.Email {
+		t.Fatalf("unexpected encoded row %+v", row)
+	}
+
+	if err := m.Decode(&row); err != nil {
+		t.Fatal(err)
+	}
+	if row != orig {
+		t.Fatalf("decoded %+v, want %+v", row, orig)
+	}
+
+	rows := []*modelUser{enc.(modelUser).ptr()}
+	if err := m.Decode(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if *rows[0] != orig {
+		t.Fatalf("decoded slice %+v, want %+v", *rows[0], orig)
+	}
+
+	if err := m.Decode(row); err == nil {
+		t.Fatal("expected error for non-pointer")
+	}
+}
+
+func (u modelUser) ptr() *modelUser {
+	return &u
+}
+
+func TestModelErrors(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+	for _, v := range []any{nil, 1, struct{ a int }{}} {
+		if _, err := sdk.Model("t", v); err == nil {
+			t.Fatalf("expected error for %T", v)
+		}
+	}
+}
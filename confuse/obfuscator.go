@@ -1,31 +1,40 @@
 package confuse
 
 import (
-	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"unicode/utf8"
 )
 
 // ============================================================================
 // Obfuscator SDK - With Reversible Linear Congruential Mapping
 // ============================================================================
 
-// Character sets for position-dependent encryption
-const (
-	charsetLower = "abcdefghijklmnopqrstuvwxyz"
-	charsetUpper = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
-	charsetDigit = "0123456789"
-)
-
 var (
 	// sdkCache caches ObfuscatorSDK instances by seed to avoid reloading dictionary
 	sdkCache sync.Map // map[int]*ObfuscatorSDK
 )
 
+// ObfuscatorSDK is safe for concurrent use. Settings live in an immutable
+// snapshot that setters replace atomically, so a call in flight keeps using
+// the settings it started with. Use WithSeed to serve several tenants from
+// one configured instance
 type ObfuscatorSDK struct {
-	dictionary       []string
+	mu    sync.Mutex // serializes setters
+	state atomic.Pointer[sdkState]
+}
+
+// sdkState is an immutable snapshot of the SDK settings
+type sdkState struct {
+	dict             *Dictionary
 	seed             int
-	encryptOutOfDict bool // if true, encrypt out-of-dictionary words; if false, keep them unchanged
+	encryptOutOfDict bool                // if true, encrypt out-of-dictionary words; if false, keep them unchanged
+	charsets         []Charset           // rune ranges used by character-level encryption
+	preserve         map[string]struct{} // lowercase words kept readable, see SetPreserveWords
+	charCacheSize    int                 // see SetCharCacheSize
+	charCache        *charCache          // rebuilt with every snapshot
+	headerKey        []byte              // keys the seed fingerprint of document headers, see WithHeaderKey
 }
 
 // NewObfuscatorSDK creates a new obfuscator SDK instance with embedded dictionary
@@ -38,67 +47,131 @@ func NewObfuscatorSDK(seed int) *ObfuscatorSDK {
 	}
 
 	// Create new SDK instance
-	sdk := &ObfuscatorSDK{
+	st := &sdkState{
 		seed:             seed,
 		encryptOutOfDict: true, // default: encrypt out-of-dictionary words
+		charsets:         DefaultCharsets,
+		charCacheSize:    DefaultCharCacheSize,
 	}
-	sdk.loadEmbeddedDictionary()
+	st.dict, _ = LookupDictionary(DefaultDictionary)
+	sdk := newSDK(st)
 
 	// Store in cache, or return existing if another goroutine stored it first
 	actual, _ := sdkCache.LoadOrStore(seed, sdk)
 	return actual.(*ObfuscatorSDK)
 }
 
+func newSDK(st *sdkState) *ObfuscatorSDK {
+	st.charCache = newCharCache(st.charCacheSize)
+	sdk := &ObfuscatorSDK{}
+	sdk.state.Store(st)
+	return sdk
+}
+
+// load returns the current settings snapshot
+func (sdk *ObfuscatorSDK) load() *sdkState {
+	return sdk.state.Load()
+}
+
+// update applies fn to a copy of the current settings and publishes it
+func (sdk *ObfuscatorSDK) update(fn func(st *sdkState)) *ObfuscatorSDK {
+	sdk.mu.Lock()
+	defer sdk.mu.Unlock()
+
+	st := *sdk.load()
+	fn(&st)
+	st.charCache = newCharCache(st.charCacheSize)
+	sdk.state.Store(&st)
+	return sdk
+}
+
+// derive returns a new, uncached SDK with a modified copy of the current settings.
+// Later setter calls on sdk don't affect the derived instance and vice versa
+func (sdk *ObfuscatorSDK) derive(fn func(st *sdkState)) *ObfuscatorSDK {
+	st := *sdk.load()
+	fn(&st)
+	return newSDK(&st)
+}
+
+// WithSeed returns a copy of the SDK that uses seed and shares every other
+// setting, e.g. sdk.WithSeed(tenantSeed).ObfuscateField(f)
+func (sdk *ObfuscatorSDK) WithSeed(seed int) *ObfuscatorSDK {
+	return sdk.derive(func(st *sdkState) {
+		st.seed = seed
+	})
+}
+
+// Seed returns the seed used by the SDK
+func (sdk *ObfuscatorSDK) Seed() int {
+	return sdk.load().seed
+}
+
 // SetEncryptOutOfDict sets whether to encrypt out-of-dictionary words
 // If set to false, out-of-dictionary words will be kept unchanged
 func (sdk *ObfuscatorSDK) SetEncryptOutOfDict(encrypt bool) *ObfuscatorSDK {
-	sdk.encryptOutOfDict = encrypt
-	return sdk
+	return sdk.update(func(st *sdkState) {
+		st.encryptOutOfDict = encrypt
+	})
 }
 
 // ObfuscateWord maps a word from the dictionary to another dictionary word (reversible)
 // If word is not in dictionary and encryptOutOfDict is true, use character-level encryption
 // If word is not in dictionary and encryptOutOfDict is false, return word unchanged
 func (sdk *ObfuscatorSDK) ObfuscateWord(word string) string {
-	if len(word) == 0 {
+	return sdk.load().obfuscateWord(word)
+}
+
+func (st *sdkState) obfuscateWord(word string) string {
+	if len(word) == 0 || st.isPreserved(word) {
 		return word
 	}
 
-	if len(sdk.dictionary) == 0 {
-		if sdk.encryptOutOfDict {
-			return sdk.encryptByChar(word)
+	view := st.dict.view()
+	var dictionary []string
+	if view != nil {
+		dictionary = view.words
+	}
+	if len(dictionary) == 0 {
+		if st.encryptOutOfDict {
+			return st.walkChars(word, false, st.isPreserved)
 		}
 		return word
 	}
 
-	m := len(sdk.dictionary)
+	m := len(dictionary)
 
 	// 确保种子为正数
-	seed := sdk.seed
+	seed := st.seed
 	if seed < 0 {
 		seed = -seed
 	}
 
 	// 生成与m互质的乘法因子a
-	a := sdk.generateCoprime(seed, m)
+	a := st.generateCoprime(seed, m)
 	b := seed % m
 
 	// map word to dictionary index
-	idx := sdk.wordToIndex(word)
+	idx := view.indexOf(word)
 	if idx < 0 {
 		// not found in dictionary
-		if sdk.encryptOutOfDict {
-			return sdk.encryptByChar(word)
+		if st.encryptOutOfDict {
+			return st.walkChars(word, false, st.inDictOrPreserved(view))
 		}
 		return word // keep unchanged
 	}
 
-	// apply linear congruential mapping
-	newIdx := (a*idx + b) % m
-	if newIdx < 0 {
-		newIdx += m
+	// apply linear congruential mapping, walking past preserved words and
+	// repeated slots so the mapping stays a bijection on the remaining dictionary
+	newIdx := idx
+	for {
+		newIdx = (a*newIdx + b) % m
+		if newIdx < 0 {
+			newIdx += m
+		}
+		if !st.isPreserved(dictionary[newIdx]) && !view.isDuplicate(newIdx) {
+			return dictionary[newIdx]
+		}
 	}
-	return sdk.dictionary[newIdx]
 }
 
 func (sdk *ObfuscatorSDK) ObfuscateWords(words []string) map[string]string {
@@ -122,35 +195,44 @@ func (sdk *ObfuscatorSDK) DeobfuscateWords(obfWords []string) map[string]string
 // If word is not in dictionary and encryptOutOfDict is true, use character-level decryption
 // If word is not in dictionary and encryptOutOfDict is false, return word unchanged
 func (sdk *ObfuscatorSDK) DeobfuscateWord(obfWord string) string {
-	if len(obfWord) == 0 {
+	return sdk.load().deobfuscateWord(obfWord)
+}
+
+func (st *sdkState) deobfuscateWord(obfWord string) string {
+	if len(obfWord) == 0 || st.isPreserved(obfWord) {
 		return obfWord
 	}
 
-	if len(sdk.dictionary) == 0 {
-		if sdk.encryptOutOfDict {
-			return sdk.decryptByChar(obfWord)
+	view := st.dict.view()
+	var dictionary []string
+	if view != nil {
+		dictionary = view.words
+	}
+	if len(dictionary) == 0 {
+		if st.encryptOutOfDict {
+			return st.walkChars(obfWord, true, st.isPreserved)
 		}
 		return obfWord
 	}
 
-	m := len(sdk.dictionary)
+	m := len(dictionary)
 
 	// 确保种子为正数
-	seed := sdk.seed
+	seed := st.seed
 	if seed < 0 {
 		seed = -seed
 	}
 
 	// 生成与m互质的乘法因子a
-	a := sdk.generateCoprime(seed, m)
+	a := st.generateCoprime(seed, m)
 	b := seed % m
 
 	// find index of obfuscated word
-	idx := sdk.wordToIndex(obfWord)
+	idx := view.indexOf(obfWord)
 	if idx < 0 {
 		// not found in dictionary
-		if sdk.encryptOutOfDict {
-			return sdk.decryptByChar(obfWord)
+		if st.encryptOutOfDict {
+			return st.walkChars(obfWord, true, st.inDictOrPreserved(view))
 		}
 		return obfWord // keep unchanged
 	}
@@ -161,12 +243,18 @@ func (sdk *ObfuscatorSDK) DeobfuscateWord(obfWord string) string {
 		return obfWord // cannot reverse
 	}
 
-	// reverse mapping: x = (y-b)*a^(-1) mod m
-	origIdx := (ainv * ((idx - b + m) % m)) % m
-	if origIdx < 0 {
-		origIdx += m
+	// reverse mapping: x = (y-b)*a^(-1) mod m, walking back past preserved
+	// words and repeated slots
+	origIdx := idx
+	for {
+		origIdx = (ainv * ((origIdx - b + m) % m)) % m
+		if origIdx < 0 {
+			origIdx += m
+		}
+		if !st.isPreserved(dictionary[origIdx]) && !view.isDuplicate(origIdx) {
+			return dictionary[origIdx]
+		}
 	}
-	return sdk.dictionary[origIdx]
 }
 
 // ============================================================================
@@ -174,7 +262,12 @@ func (sdk *ObfuscatorSDK) DeobfuscateWord(obfWord string) string {
 // ============================================================================
 
 // generateCoprime generates a number coprime to m using the seed
-func (sdk *ObfuscatorSDK) generateCoprime(seed, m int) int {
+func (st *sdkState) generateCoprime(seed, m int) int {
+	// 1 is the only multiplier below 3, the search below would never end for m == 2
+	if m <= 2 {
+		return 1
+	}
+
 	// 使用种子生成基础数
 	base := seed % m
 	if base <= 1 {
@@ -202,11 +295,7 @@ func gcd(a, b int) int {
 
 // wordToIndex returns the dictionary index of a word, or -1 if not found
 func (sdk *ObfuscatorSDK) wordToIndex(word string) int {
-	idx := sort.SearchStrings(sdk.dictionary, word)
-	if idx >= len(sdk.dictionary) || sdk.dictionary[idx] != word {
-		return -1
-	}
-	return idx
+	return sdk.load().dict.view().indexOf(word)
 }
 
 // modularInverse computes modular inverse of a under modulo m
@@ -237,65 +326,147 @@ func modularInverse(a, m int) int {
 	return t
 }
 
-// loadEmbeddedDictionary loads the built-in word dictionary
-func (sdk *ObfuscatorSDK) loadEmbeddedDictionary() {
-	sdk.dictionary = make([]string, len(Words))
-	copy(sdk.dictionary, Words)
-	sort.Strings(sdk.dictionary)
-}
-
 // ============================================================================
 // Character-level Encryption (for out-of-dictionary words)
 // ============================================================================
 
-// encryptByChar encrypts a word using position-dependent character mapping
-func (sdk *ObfuscatorSDK) encryptByChar(word string) string {
-	result := make([]byte, len(word))
-	for i := 0; i < len(word); i++ {
-		result[i] = sdk.encryptChar(word[i], i)
+// Charset is a contiguous rune range [Lo, Hi]. Character encryption maps a rune
+// to another rune of the same charset, so the script and case of text are kept
+type Charset struct {
+	Name string
+	Lo   rune
+	Hi   rune
+}
+
+// Built-in charsets
+var (
+	CharsetLower         = Charset{Name: "lower", Lo: 'a', Hi: 'z'}
+	CharsetUpper         = Charset{Name: "upper", Lo: 'A', Hi: 'Z'}
+	CharsetDigit         = Charset{Name: "digit", Lo: '0', Hi: '9'}
+	CharsetCJK           = Charset{Name: "cjk", Lo: 0x4E00, Hi: 0x9FFF}            // CJK Unified Ideographs
+	CharsetCyrillicUpper = Charset{Name: "cyrillic-upper", Lo: 0x0410, Hi: 0x042F} // А-Я
+	CharsetCyrillicLower = Charset{Name: "cyrillic-lower", Lo: 0x0430, Hi: 0x044F} // а-я
+	CharsetHiragana      = Charset{Name: "hiragana", Lo: 0x3041, Hi: 0x3096}
+	CharsetKatakana      = Charset{Name: "katakana", Lo: 0x30A1, Hi: 0x30FA}
+	CharsetHangul        = Charset{Name: "hangul", Lo: 0xAC00, Hi: 0xD7A3}
+)
+
+// DefaultCharsets are used unless SetCharsets is called
+var DefaultCharsets = []Charset{
+	CharsetLower, CharsetUpper, CharsetDigit,
+	CharsetCJK, CharsetCyrillicUpper, CharsetCyrillicLower,
+}
+
+func (c Charset) size() int {
+	return int(c.Hi-c.Lo) + 1
+}
+
+func (c Charset) contains(r rune) bool {
+	return r >= c.Lo && r <= c.Hi
+}
+
+// SetCharsets replaces the charsets used by character encryption.
+// Charsets must not overlap; runes outside every charset are kept unchanged.
+// A charset of two symbols is only shifted by position, one of a single symbol never changes
+func (sdk *ObfuscatorSDK) SetCharsets(charsets ...Charset) *ObfuscatorSDK {
+	charsets = append([]Charset(nil), charsets...)
+	return sdk.update(func(st *sdkState) {
+		st.charsets = charsets
+	})
+}
+
+func (st *sdkState) charsetOf(r rune) (Charset, bool) {
+	for _, c := range st.charsets {
+		if c.contains(r) {
+			return c, true
+		}
 	}
-	return string(result)
+	return Charset{}, false
+}
+
+// walkChars character-encrypts (or decrypts) word, repeating while the result
+// is in skip. Character encryption is a permutation of the strings of a given
+// shape, so this "cycle walking" keeps it a bijection on the words outside skip:
+// an out-of-dictionary word never turns into a dictionary or preserved word,
+// which the reverse mapping would resolve differently
+func (st *sdkState) walkChars(word string, reverse bool, skip func(string) bool) string {
+	fn := st.encryptByChar
+	if reverse {
+		fn = st.decryptByChar
+	}
+	out := fn(word)
+	for out != word && skip(out) {
+		out = fn(out)
+	}
+	return out
 }
 
-// decryptByChar decrypts a word using position-dependent character mapping
-func (sdk *ObfuscatorSDK) decryptByChar(word string) string {
-	result := make([]byte, len(word))
-	for i := 0; i < len(word); i++ {
-		result[i] = sdk.decryptChar(word[i], i)
+// inDictOrPreserved reports whether a word is resolved by the dictionary or the preserve list
+func (st *sdkState) inDictOrPreserved(view *dictSnapshot) func(string) bool {
+	return func(word string) bool {
+		return view.indexOf(word) >= 0 || st.isPreserved(word)
 	}
-	return string(result)
 }
 
-// encryptChar encrypts a single character at given position using LCG
-func (sdk *ObfuscatorSDK) encryptChar(ch byte, pos int) byte {
-	var charset string
+// encryptByChar encrypts a word rune by rune using position-dependent mapping
+func (st *sdkState) encryptByChar(word string) string {
+	key := charCacheKey{word: word}
+	if out, ok := st.charCache.get(key); ok {
+		return out
+	}
+	out := mapRunes(word, st.encryptRune)
+	st.charCache.put(key, out)
+	return out
+}
 
-	// determine character set based on character type
-	if ch >= 'a' && ch <= 'z' {
-		charset = charsetLower
-	} else if ch >= 'A' && ch <= 'Z' {
-		charset = charsetUpper
-	} else if ch >= '0' && ch <= '9' {
-		charset = charsetDigit
-	} else {
-		// non-alphanumeric characters remain unchanged
-		return ch
+// decryptByChar decrypts a word rune by rune using position-dependent mapping
+func (st *sdkState) decryptByChar(word string) string {
+	key := charCacheKey{word: word, reverse: true}
+	if out, ok := st.charCache.get(key); ok {
+		return out
 	}
+	out := mapRunes(word, st.decryptRune)
+	st.charCache.put(key, out)
+	return out
+}
 
-	m := len(charset)
-	idx := strings.IndexByte(charset, ch)
-	if idx < 0 {
-		return ch
+// mapRunes applies fn to every rune of word with its position. Bytes that are
+// not valid UTF-8 are copied unchanged (and count as a position), instead of
+// becoming U+FFFD, so arbitrary bytes round trip
+func mapRunes(word string, fn func(r rune, pos int) rune) string {
+	var sb strings.Builder
+	sb.Grow(len(word))
+	for pos, i := 0, 0; i < len(word); pos++ {
+		r, size := utf8.DecodeRuneInString(word[i:])
+		if r == utf8.RuneError && size == 1 {
+			sb.WriteByte(word[i])
+		} else {
+			sb.WriteRune(fn(r, pos))
+		}
+		i += size
+	}
+	return sb.String()
+}
+
+// encryptRune encrypts a single rune at given position using LCG
+func (st *sdkState) encryptRune(r rune, pos int) rune {
+	charset, ok := st.charsetOf(r)
+	if !ok {
+		// runes outside every charset remain unchanged
+		return r
 	}
 
+	m := charset.size()
+	idx := int(r - charset.Lo)
+
 	// 确保种子为正数
-	seed := sdk.seed
+	seed := st.seed
 	if seed < 0 {
 		seed = -seed
 	}
 
 	// position-dependent LCG mapping
-	a := sdk.generateCoprime(seed, m)
+	a := st.generateCoprime(seed, m)
 	b := (seed + pos) % m // each position has different offset
 
 	newIdx := (a*idx + b) % m
@@ -303,44 +474,32 @@ func (sdk *ObfuscatorSDK) encryptChar(ch byte, pos int) byte {
 		newIdx += m
 	}
 
-	return charset[newIdx]
+	return charset.Lo + rune(newIdx)
 }
 
-// decryptChar decrypts a single character at given position using modular inverse
-func (sdk *ObfuscatorSDK) decryptChar(ch byte, pos int) byte {
-	var charset string
-
-	// determine character set based on character type
-	if ch >= 'a' && ch <= 'z' {
-		charset = charsetLower
-	} else if ch >= 'A' && ch <= 'Z' {
-		charset = charsetUpper
-	} else if ch >= '0' && ch <= '9' {
-		charset = charsetDigit
-	} else {
-		// non-alphanumeric characters remain unchanged
-		return ch
+// decryptRune decrypts a single rune at given position using modular inverse
+func (st *sdkState) decryptRune(r rune, pos int) rune {
+	charset, ok := st.charsetOf(r)
+	if !ok {
+		return r
 	}
 
-	m := len(charset)
-	idx := strings.IndexByte(charset, ch)
-	if idx < 0 {
-		return ch
-	}
+	m := charset.size()
+	idx := int(r - charset.Lo)
 
 	// 确保种子为正数
-	seed := sdk.seed
+	seed := st.seed
 	if seed < 0 {
 		seed = -seed
 	}
 
 	// position-dependent LCG mapping
-	a := sdk.generateCoprime(seed, m)
+	a := st.generateCoprime(seed, m)
 	b := (seed + pos) % m
 	ainv := modularInverse(a, m)
 
 	if ainv == -1 {
-		return ch // cannot reverse
+		return r // cannot reverse
 	}
 
 	// reverse mapping: x = (y-b)*a^(-1) mod m
@@ -349,5 +508,5 @@ func (sdk *ObfuscatorSDK) decryptChar(ch byte, pos int) byte {
 		origIdx += m
 	}
 
-	return charset[origIdx]
+	return charset.Lo + rune(origIdx)
 }
@@ -0,0 +1,69 @@
+package confuse
+
+import "strings"
+
+// ============================================================================
+// Preserve List - well known tokens that stay readable
+// ============================================================================
+
+// DefaultPreserveWords are abbreviations universally known in schemas
+var DefaultPreserveWords = []string{
+	"id", "ids", "uid", "uuid", "url", "uri", "http", "https", "api", "utc",
+	"json", "xml", "sql", "ip", "md5", "sha1", "sha256", "ts", "at",
+}
+
+// StopWords are English function words kept readable by SetPreserveStopWords
+var StopWords = []string{
+	"a", "an", "the", "and", "or", "not", "no", "of", "to", "in", "on",
+	"by", "for", "with", "from", "as", "is", "if", "per",
+}
+
+// SetPreserveWords sets the words (case-insensitive) that are never obfuscated,
+// e.g. SetPreserveWords(DefaultPreserveWords...). Other dictionary words never
+// map onto a preserved word, so obfuscation stays reversible.
+// It replaces any previous preserve list, including stop words
+func (sdk *ObfuscatorSDK) SetPreserveWords(words ...string) *ObfuscatorSDK {
+	preserve := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		preserve[strings.ToLower(w)] = struct{}{}
+	}
+	return sdk.update(func(st *sdkState) {
+		st.preserve = preserve
+	})
+}
+
+// SetPreserveStopWords adds (or removes) StopWords to the preserve list
+func (sdk *ObfuscatorSDK) SetPreserveStopWords(preserve bool) *ObfuscatorSDK {
+	return sdk.update(func(st *sdkState) {
+		words := make(map[string]struct{}, len(st.preserve)+len(StopWords))
+		for w := range st.preserve {
+			words[w] = struct{}{}
+		}
+		for _, w := range StopWords {
+			if preserve {
+				words[w] = struct{}{}
+			} else {
+				delete(words, w)
+			}
+		}
+		st.preserve = words
+	})
+}
+
+// PreserveWords returns the current preserve list
+func (sdk *ObfuscatorSDK) PreserveWords() []string {
+	st := sdk.load()
+	words := make([]string, 0, len(st.preserve))
+	for w := range st.preserve {
+		words = append(words, w)
+	}
+	return words
+}
+
+func (st *sdkState) isPreserved(word string) bool {
+	if len(st.preserve) == 0 {
+		return false
+	}
+	_, ok := st.preserve[strings.ToLower(word)]
+	return ok
+}
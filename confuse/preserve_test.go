@@ -0,0 +1,64 @@
+package confuse
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPreserveWords(t *testing.T) {
+	// use a dedicated seed, the preserve list is stored on the cached instance
+	sdk := NewObfuscatorSDK(20241).SetPreserveWords(DefaultPreserveWords...).SetPreserveStopWords(true)
+	defer sdk.SetPreserveWords()
+
+	tests := []struct {
+		field string
+		keep  []string
+	}{
+		{field: "userId", keep: []string{"Id"}},
+		{field: "userID", keep: []string{"ID"}},
+		{field: "callback_url", keep: []string{"_url"}},
+		{field: "createdAtUtc", keep: []string{"At", "Utc"}},
+		{field: "price_of_item", keep: []string{"_of_"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.field, func(t *testing.T) {
+			obf := sdk.ObfuscateField(tt.field)
+			for _, k := range tt.keep {
+				if !strings.Contains(obf, k) {
+					t.Fatalf("ObfuscateField(%q) = %q, want %q preserved", tt.field, obf, k)
+				}
+			}
+			if obf == tt.field {
+				t.Fatalf("ObfuscateField(%q) left business terms readable", tt.field)
+			}
+			if back := sdk.DeobfuscateField(obf); back != tt.field {
+				t.Fatalf("DeobfuscateField(%q) = %q, want %q", obf, back, tt.field)
+			}
+		})
+	}
+}
+
+func TestPreserveWords_Bijective(t *testing.T) {
+	sdk := NewObfuscatorSDK(20242).SetPreserveWords(DefaultPreserveWords...)
+	defer sdk.SetPreserveWords()
+
+	// the embedded dictionary contains a few duplicate entries which can't round trip
+	count := make(map[string]int, GetWordCount())
+	for _, w := range GetWords() {
+		count[w]++
+	}
+
+	for _, w := range GetWords() {
+		obf := sdk.ObfuscateWord(w)
+		if sdk.load().isPreserved(obf) && obf != w {
+			t.Fatalf("ObfuscateWord(%q) = %q is a preserved word", w, obf)
+		}
+		if count[w] > 1 || count[obf] > 1 {
+			continue
+		}
+		if back := sdk.DeobfuscateWord(obf); back != w {
+			t.Fatalf("DeobfuscateWord(%q) = %q, want %q", obf, back, w)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package confuse
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// ============================================================================
+// Secret Seeding & Rotation
+// ============================================================================
+
+// SeedFromSecret derives a non-negative seed from a secret with SHA-256, so
+// partners can share a passphrase instead of a guessable small integer
+func SeedFromSecret(secret string) int {
+	sum := sha256.Sum256([]byte("confuse/seed\x00" + secret))
+	// 31 bits keep seed + position arithmetic far from overflow
+	return int(binary.BigEndian.Uint64(sum[:8]) & math.MaxInt32)
+}
+
+// NewObfuscatorSDKFromSecret creates (or reuses) the SDK for SeedFromSecret(secret)
+func NewObfuscatorSDKFromSecret(secret string) (*ObfuscatorSDK, error) {
+	if secret == "" {
+		return nil, errors.New("confuse: secret is empty")
+	}
+	return NewObfuscatorSDK(SeedFromSecret(secret)), nil
+}
+
+// Rotation migrates data obfuscated with an old seed to a new one
+type Rotation struct {
+	Old *ObfuscatorSDK
+	New *ObfuscatorSDK
+}
+
+// RotateSeed returns a rotation from sdk to a copy seeded from newSecret
+// with the same settings
+func (sdk *ObfuscatorSDK) RotateSeed(newSecret string) (*Rotation, error) {
+	if newSecret == "" {
+		return nil, errors.New("confuse: secret is empty")
+	}
+	return &Rotation{Old: sdk, New: sdk.WithSeed(SeedFromSecret(newSecret))}, nil
+}
+
+// Remap converts a field obfuscated with the old seed to the new seed
+func (r *Rotation) Remap(oldObfuscated string) string {
+	return r.New.ObfuscateField(r.Old.DeobfuscateField(oldObfuscated))
+}
+
+// Table builds the old obfuscated -> new obfuscated table for fields given in
+// their original form, e.g. to rename columns of an obfuscated replica
+func (r *Rotation) Table(fields ...string) (*Mapping, error) {
+	m := NewMapping()
+	for _, f := range fields {
+		if err := m.Add(r.Old.ObfuscateField(f), r.New.ObfuscateField(f)); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,57 @@
+package confuse
+
+import "testing"
+
+func TestSeedFromSecret(t *testing.T) {
+	a, b := SeedFromSecret("partner-a"), SeedFromSecret("partner-b")
+	if a == b || a < 0 || b < 0 {
+		t.Fatalf("unexpected seeds %d, %d", a, b)
+	}
+	if SeedFromSecret("partner-a") != a {
+		t.Fatal("seed is not deterministic")
+	}
+
+	sdk, err := NewObfuscatorSDKFromSecret("partner-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sdk.Seed() != a {
+		t.Fatalf("seed %d, want %d", sdk.Seed(), a)
+	}
+	if _, err := NewObfuscatorSDKFromSecret(""); err == nil {
+		t.Fatal("expected error for empty secret")
+	}
+}
+
+func TestRotateSeed(t *testing.T) {
+	old, err := NewObfuscatorSDKFromSecret("old-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := old.RotateSeed("new-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.New.Seed() != SeedFromSecret("new-secret") {
+		t.Fatal("new sdk not seeded from new secret")
+	}
+
+	fields := []string{"user_name", "order_id", "createTime"}
+	table, err := r.Table(fields...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fields {
+		oldObf := old.ObfuscateField(f)
+		newObf, ok := table.Obfuscate(oldObf)
+		if !ok || newObf != r.New.ObfuscateField(f) {
+			t.Fatalf("%s: table maps %q to %q", f, oldObf, newObf)
+		}
+		if got := r.Remap(oldObf); got != newObf {
+			t.Fatalf("%s: Remap %q, table %q", f, got, newObf)
+		}
+		if back := r.New.DeobfuscateField(newObf); back != f {
+			t.Fatalf("%s: new seed reverses to %q", f, back)
+		}
+	}
+}
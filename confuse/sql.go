@@ -0,0 +1,320 @@
+package confuse
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ============================================================================
+// SQL Obfuscation - rewrite table / column identifiers of a statement
+// ============================================================================
+
+type sqlTokenKind int
+
+const (
+	sqlSpace sqlTokenKind = iota
+	sqlComment
+	sqlString      // 'literal'
+	sqlQuotedIdent // "ident" or `ident`
+	sqlNumber
+	sqlWord // keyword, function or identifier
+	sqlParam
+	sqlPunct
+)
+
+type sqlToken struct {
+	kind sqlTokenKind
+	text string
+}
+
+// SQLMapping maps every obfuscated identifier in a statement to its original name
+type SQLMapping map[string]string
+
+// WriteTo writes the mapping as JSON
+func (m SQLMapping) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// ReadSQLMapping reads a mapping written by SQLMapping.WriteTo
+func ReadSQLMapping(r io.Reader) (SQLMapping, error) {
+	m := SQLMapping{}
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("confuse: read sql mapping: %w", err)
+	}
+	return m, nil
+}
+
+// ObfuscateSQL rewrites table and column identifiers of a statement with
+// ObfuscateField. Keywords, data types, function names, literals, comments and
+// parameters are left intact. The returned mapping restores the statement with
+// RestoreSQL without needing the seed.
+func (sdk *ObfuscatorSDK) ObfuscateSQL(stmt string) (string, SQLMapping, error) {
+	mapping := SQLMapping{}
+	out, err := rewriteSQL(stmt, func(ident string) string {
+		obf := walkSQLIdent(ident, sdk.ObfuscateField)
+		mapping[obf] = ident
+		return obf
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return out, mapping, nil
+}
+
+// DeobfuscateSQL reverses ObfuscateSQL with the same seed
+func (sdk *ObfuscatorSDK) DeobfuscateSQL(stmt string) (string, error) {
+	return rewriteSQL(stmt, func(ident string) string {
+		return walkSQLIdent(ident, sdk.DeobfuscateField)
+	})
+}
+
+// RestoreSQL reverses ObfuscateSQL using a mapping; unknown identifiers are kept
+func RestoreSQL(stmt string, mapping SQLMapping) (string, error) {
+	return rewriteSQL(stmt, func(ident string) string {
+		if orig, ok := mapping[ident]; ok {
+			return orig
+		}
+		return ident
+	})
+}
+
+// walkSQLIdent applies fn until the result is not a SQL keyword, so an
+// obfuscated identifier never turns into a keyword. Identifiers are never
+// keywords on input, which keeps the walk reversible
+func walkSQLIdent(ident string, fn func(string) string) string {
+	out := fn(ident)
+	for isSQLKeyword(out) && out != ident {
+		out = fn(out)
+	}
+	return out
+}
+
+func rewriteSQL(stmt string, fn func(string) string) (string, error) {
+	tokens, err := lexSQL(stmt)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(stmt))
+
+	prevWord := ""
+	for i, t := range tokens {
+		switch t.kind {
+		case sqlWord:
+			if isSQLIdentifier(tokens, i, prevWord) {
+				sb.WriteString(fn(t.text))
+			} else {
+				sb.WriteString(t.text)
+			}
+			prevWord = strings.ToUpper(t.text)
+		case sqlQuotedIdent:
+			// quoted keywords such as `order` are kept as is: walkSQLIdent only
+			// reverses identifiers that are not keywords
+			ident := t.text[1 : len(t.text)-1]
+			if isSQLKeyword(ident) {
+				sb.WriteString(t.text)
+			} else {
+				quote := t.text[:1]
+				sb.WriteString(quote)
+				sb.WriteString(fn(ident))
+				sb.WriteString(quote)
+			}
+			prevWord = ""
+		case sqlSpace, sqlComment:
+			sb.WriteString(t.text)
+		default:
+			sb.WriteString(t.text)
+			prevWord = ""
+		}
+	}
+	return sb.String(), nil
+}
+
+// isSQLIdentifier decides whether the word at tokens[i] names a table or column
+func isSQLIdentifier(tokens []sqlToken, i int, prevWord string) bool {
+	word := tokens[i].text
+	if isSQLKeyword(word) {
+		return false
+	}
+
+	// a word directly followed by "(" is a function call, except after
+	// keywords that introduce a table name: CREATE TABLE t(...), INSERT INTO t(...)
+	next := nextSignificant(tokens, i)
+	if next != nil && next.kind == sqlPunct && next.text == "(" {
+		_, ok := sqlTableIntroducers[prevWord]
+		return ok
+	}
+	return true
+}
+
+func nextSignificant(tokens []sqlToken, i int) *sqlToken {
+	for j := i + 1; j < len(tokens); j++ {
+		if tokens[j].kind != sqlSpace && tokens[j].kind != sqlComment {
+			return &tokens[j]
+		}
+	}
+	return nil
+}
+
+// lexSQL splits a statement into tokens; concatenating them yields the input
+func lexSQL(stmt string) ([]sqlToken, error) {
+	tokens := make([]sqlToken, 0, 32)
+	n := len(stmt)
+
+	for i := 0; i < n; {
+		ch := stmt[i]
+		start := i
+		kind := sqlPunct
+
+		switch {
+		case isSQLSpace(ch):
+			for i < n && isSQLSpace(stmt[i]) {
+				i++
+			}
+			kind = sqlSpace
+
+		case ch == '-' && i+1 < n && stmt[i+1] == '-', ch == '#':
+			for i < n && stmt[i] != '\n' {
+				i++
+			}
+			kind = sqlComment
+
+		case ch == '/' && i+1 < n && stmt[i+1] == '*':
+			end := strings.Index(stmt[i+2:], "*/")
+			if end < 0 {
+				return nil, errors.New("confuse: unterminated sql comment")
+			}
+			i += end + 4
+			kind = sqlComment
+
+		case ch == '\'' || ch == '"' || ch == '`':
+			end, err := scanSQLQuoted(stmt, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			kind = sqlQuotedIdent
+			if ch == '\'' {
+				kind = sqlString
+			}
+
+		case isASCIIDigit(rune(ch)):
+			for i < n && (isSQLWordByte(stmt[i]) || stmt[i] == '.') {
+				i++
+			}
+			kind = sqlNumber
+
+		case isSQLWordByte(ch):
+			for i < n && isSQLWordByte(stmt[i]) {
+				i++
+			}
+			kind = sqlWord
+
+		case ch == '?':
+			i++
+			kind = sqlParam
+
+		case (ch == ':' || ch == '@' || ch == '$') && i+1 < n && isSQLWordByte(stmt[i+1]) &&
+			!(ch == ':' && i > 0 && stmt[i-1] == ':'):
+			i++
+			for i < n && isSQLWordByte(stmt[i]) {
+				i++
+			}
+			kind = sqlParam
+
+		default:
+			i++
+		}
+
+		tokens = append(tokens, sqlToken{kind: kind, text: stmt[start:i]})
+	}
+	return tokens, nil
+}
+
+// scanSQLQuoted returns the index right after the quoted token starting at i.
+// A doubled quote character inside is an escaped quote
+func scanSQLQuoted(stmt string, i int) (int, error) {
+	quote := stmt[i]
+	for j := i + 1; j < len(stmt); j++ {
+		switch stmt[j] {
+		case '\\':
+			if quote == '\'' {
+				j++
+			}
+		case quote:
+			if j+1 < len(stmt) && stmt[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("confuse: unterminated sql quote %q", quote)
+}
+
+func isSQLSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r' || ch == '\f'
+}
+
+func isSQLWordByte(ch byte) bool {
+	return ch == '_' || isWordRune(rune(ch))
+}
+
+func isSQLKeyword(word string) bool {
+	_, ok := sqlKeywords[strings.ToUpper(word)]
+	return ok
+}
+
+// sqlTableIntroducers are keywords after which "name(" is a table, not a function
+var sqlTableIntroducers = toSet(
+	"TABLE", "INTO", "REFERENCES", "EXISTS", "INDEX", "KEY", "UNIQUE", "CONSTRAINT", "ON",
+)
+
+// sqlKeywords covers common MySQL / PostgreSQL keywords, data types and
+// niladic functions. Identifiers colliding with them are left unchanged
+var sqlKeywords = toSet(
+	// statements and clauses
+	"SELECT", "FROM", "WHERE", "AND", "OR", "NOT", "IN", "IS", "NULL", "AS", "ON", "JOIN",
+	"INNER", "LEFT", "RIGHT", "FULL", "OUTER", "CROSS", "NATURAL", "USING", "GROUP", "BY",
+	"ORDER", "HAVING", "LIMIT", "OFFSET", "UNION", "ALL", "DISTINCT", "ASC", "DESC", "CASE",
+	"WHEN", "THEN", "ELSE", "END", "BETWEEN", "LIKE", "ILIKE", "REGEXP", "EXISTS", "ANY",
+	"SOME", "INSERT", "INTO", "VALUES", "UPDATE", "SET", "DELETE", "REPLACE", "DUPLICATE",
+	"CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "TABLE", "VIEW", "INDEX", "DATABASE",
+	"SCHEMA", "IF", "ADD", "COLUMN", "MODIFY", "CHANGE", "PRIMARY", "KEY", "FOREIGN",
+	"REFERENCES", "UNIQUE", "CONSTRAINT", "CHECK", "DEFAULT", "AUTO_INCREMENT", "COMMENT",
+	"ENGINE", "CHARSET", "CHARACTER", "COLLATE", "TEMPORARY", "CASCADE", "RESTRICT", "NO",
+	"ACTION", "WITH", "RECURSIVE", "OVER", "PARTITION", "WINDOW", "ROWS", "RANGE",
+	"PRECEDING", "FOLLOWING", "UNBOUNDED", "CURRENT", "ROW", "FOR", "SHARE", "LOCK", "MODE",
+	"EXPLAIN", "ANALYZE", "FORMAT", "RETURNING", "CONFLICT", "DO", "NOTHING", "TRUE",
+	"FALSE", "UNSIGNED", "SIGNED", "ZEROFILL", "BINARY", "INTERVAL", "FORCE", "IGNORE",
+	"USE", "STRAIGHT_JOIN", "ESCAPE", "COLLATION", "GENERATED", "ALWAYS", "STORED",
+	"VIRTUAL", "FULLTEXT", "SPATIAL", "BTREE", "HASH", "ROW_FORMAT", "NULLS", "FIRST",
+	"LAST", "ONLY", "LATERAL", "EXCEPT", "INTERSECT", "MINUS", "TOP", "FETCH", "NEXT",
+	"INNODB", "MYISAM", "UTF8", "UTF8MB4", "UTF8MB4_BIN", "UTF8MB4_GENERAL_CI", "UTF8MB4_UNICODE_CI",
+	// data types
+	"INT", "INTEGER", "TINYINT", "SMALLINT", "MEDIUMINT", "BIGINT", "SERIAL", "BIGSERIAL",
+	"DECIMAL", "NUMERIC", "FLOAT", "DOUBLE", "REAL", "PRECISION", "BIT", "BOOL", "BOOLEAN",
+	"CHAR", "VARCHAR", "TEXT", "TINYTEXT", "MEDIUMTEXT", "LONGTEXT", "BLOB", "TINYBLOB",
+	"MEDIUMBLOB", "LONGBLOB", "VARBINARY", "JSON", "JSONB", "ENUM", "DATE", "TIME",
+	"DATETIME", "TIMESTAMP", "TIMESTAMPTZ", "YEAR", "UUID", "BYTEA", "ZONE", "VARYING",
+	// niladic functions
+	"CURRENT_TIMESTAMP", "CURRENT_DATE", "CURRENT_TIME", "CURRENT_USER", "LOCALTIME",
+	"LOCALTIMESTAMP",
+)
+
+func toSet(items ...string) map[string]struct{} {
+	set := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		set[item] = struct{}{}
+	}
+	return set
+}
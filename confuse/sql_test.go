@@ -0,0 +1,90 @@
+package confuse
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestObfuscateSQL(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	tests := []struct {
+		name     string
+		stmt     string
+		keep     []string // fragments that must survive obfuscation
+		identify []string // identifiers that must be rewritten
+	}{
+		{
+			name:     "create table",
+			stmt:     "CREATE TABLE `user_order` (\n  id BIGINT NOT NULL AUTO_INCREMENT,\n  user_name VARCHAR(64) DEFAULT '' COMMENT 'user name',\n  created_at DATETIME DEFAULT CURRENT_TIMESTAMP,\n  PRIMARY KEY (id)\n) ENGINE=InnoDB",
+			keep:     []string{"CREATE TABLE", "BIGINT NOT NULL AUTO_INCREMENT", "VARCHAR(64)", "'user name'", "CURRENT_TIMESTAMP", "PRIMARY KEY", "ENGINE=InnoDB"},
+			identify: []string{"user_order", "user_name", "created_at"},
+		},
+		{
+			name:     "select with functions",
+			stmt:     "SELECT o.order_id, COUNT(*) AS total, max(o.amount) FROM orders o /* hot path */ WHERE o.status = 'paid' AND o.user_id IN (?, ?) GROUP BY o.order_id",
+			keep:     []string{"COUNT(*)", "max(", "/* hot path */", "'paid'", "IN (?, ?)", "GROUP BY"},
+			identify: []string{"order_id", "orders", "user_id", "amount"},
+		},
+		{
+			name:     "insert",
+			stmt:     `INSERT INTO "payment"(pay_id, price) VALUES ($1, 'it''s') -- trailing`,
+			keep:     []string{"INSERT INTO", "VALUES ($1, 'it''s')", "-- trailing"},
+			identify: []string{"payment", "pay_id", "price"},
+		},
+		{
+			name:     "quoted keywords",
+			stmt:     "SELECT `order`, `key`, `desc`, \"group\", amount FROM `index` WHERE `date` > ? ORDER BY `order` DESC",
+			keep:     []string{"`order`", "`key`", "`desc`", `"group"`, "`index`", "`date`", "ORDER BY", "DESC"},
+			identify: []string{"amount"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obf, mapping, err := sdk.ObfuscateSQL(tt.stmt)
+			if err != nil {
+				t.Fatalf("ObfuscateSQL() error = %v", err)
+			}
+			for _, frag := range tt.keep {
+				if !strings.Contains(obf, frag) {
+					t.Errorf("fragment %q lost in %s", frag, obf)
+				}
+			}
+			for _, ident := range tt.identify {
+				if strings.Contains(obf, ident) {
+					t.Errorf("identifier %q not obfuscated in %s", ident, obf)
+				}
+			}
+
+			back, err := sdk.DeobfuscateSQL(obf)
+			if err != nil || back != tt.stmt {
+				t.Fatalf("DeobfuscateSQL() = %q, %v, want %q", back, err, tt.stmt)
+			}
+
+			var buf bytes.Buffer
+			if _, err := mapping.WriteTo(&buf); err != nil {
+				t.Fatalf("WriteTo() error = %v", err)
+			}
+			loaded, err := ReadSQLMapping(&buf)
+			if err != nil {
+				t.Fatalf("ReadSQLMapping() error = %v", err)
+			}
+			restored, err := RestoreSQL(obf, loaded)
+			if err != nil || restored != tt.stmt {
+				t.Fatalf("RestoreSQL() = %q, %v, want %q", restored, err, tt.stmt)
+			}
+		})
+	}
+}
+
+func TestObfuscateSQL_Invalid(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	for _, stmt := range []string{"SELECT 'abc", "SELECT /* x", "SELECT `a"} {
+		if _, _, err := sdk.ObfuscateSQL(stmt); err == nil {
+			t.Errorf("ObfuscateSQL(%q) expected error", stmt)
+		}
+	}
+}
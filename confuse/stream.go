@@ -0,0 +1,85 @@
+package confuse
+
+import (
+	"context"
+	"fmt"
+)
+
+// ============================================================================
+// Streaming Batch API - long running anonymization jobs
+// ============================================================================
+
+// MappingEntry is one original / obfuscated pair produced by ObfuscateFieldsStream
+type MappingEntry struct {
+	Original   string
+	Obfuscated string
+}
+
+type streamOptions struct {
+	total    int
+	progress func(done, total int)
+}
+
+// StreamOption configures ObfuscateFieldsStream
+type StreamOption func(*streamOptions)
+
+// WithStreamProgress calls fn after every processed field. total is passed
+// through to fn (use 0 when unknown)
+func WithStreamProgress(total int, fn func(done, total int)) StreamOption {
+	return func(o *streamOptions) {
+		o.total = total
+		o.progress = fn
+	}
+}
+
+// ObfuscateFieldsStream obfuscates fields as they arrive and sends one entry
+// per distinct field to results, until fields is closed or ctx is done.
+// results is closed on return. Like ObfuscateFields it fails with a
+// *CollisionError when two fields map to the same output. The returned
+// Mapping holds every entry sent, ready to be saved with a MappingStore
+func (sdk *ObfuscatorSDK) ObfuscateFieldsStream(ctx context.Context, fields <-chan string, results chan<- MappingEntry, opts ...StreamOption) (*Mapping, error) {
+	defer close(results)
+
+	o := &streamOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	m := NewMapping()
+	done := 0
+	for {
+		var (
+			field string
+			ok    bool
+		)
+		select {
+		case <-ctx.Done():
+			return m, fmt.Errorf("confuse: stream stopped after %d fields: %w", done, ctx.Err())
+		case field, ok = <-fields:
+			if !ok {
+				return m, nil
+			}
+		}
+
+		done++
+		if _, seen := m.Obfuscate(field); !seen {
+			out := sdk.ObfuscateField(field)
+			if prev, taken := m.Deobfuscate(out); taken {
+				return m, &CollisionError{Collisions: []Collision{{Output: out, Inputs: []string{prev, field}}}}
+			}
+			if err := m.Add(field, out); err != nil {
+				return m, err
+			}
+
+			select {
+			case results <- MappingEntry{Original: field, Obfuscated: out}:
+			case <-ctx.Done():
+				return m, fmt.Errorf("confuse: stream stopped after %d fields: %w", done, ctx.Err())
+			}
+		}
+
+		if o.progress != nil {
+			o.progress(done, o.total)
+		}
+	}
+}
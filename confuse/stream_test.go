@@ -0,0 +1,76 @@
+package confuse
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestObfuscateFieldsStream(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+	input := []string{"user_name", "order_id", "user_name", "createTime"}
+
+	fields := make(chan string)
+	results := make(chan MappingEntry)
+	go func() {
+		for _, f := range input {
+			fields <- f
+		}
+		close(fields)
+	}()
+
+	var progress []int
+	var got []MappingEntry
+	errc := make(chan error, 1)
+	var m *Mapping
+	go func() {
+		var err error
+		m, err = sdk.ObfuscateFieldsStream(context.Background(), fields, results,
+			WithStreamProgress(len(input), func(done, total int) {
+				if total != len(input) {
+					t.Errorf("total %d", total)
+				}
+				progress = append(progress, done)
+			}))
+		errc <- err
+	}()
+	for e := range results {
+		got = append(got, e)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3 distinct", len(got))
+	}
+	for _, e := range got {
+		if e.Obfuscated != sdk.ObfuscateField(e.Original) {
+			t.Fatalf("%s: got %q", e.Original, e.Obfuscated)
+		}
+	}
+	if len(progress) != len(input) || progress[len(progress)-1] != len(input) {
+		t.Fatalf("unexpected progress %v", progress)
+	}
+	if m.Len() != 3 {
+		t.Fatalf("mapping has %d entries", m.Len())
+	}
+}
+
+func TestObfuscateFieldsStreamCancel(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	fields := make(chan string, 1)
+	results := make(chan MappingEntry) // never read
+	fields <- "user_name"
+	cancel()
+
+	_, err := sdk.ObfuscateFieldsStream(ctx, fields, results)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, ok := <-results; ok {
+		t.Fatal("results not closed")
+	}
+}
@@ -0,0 +1,164 @@
+package confuse
+
+import (
+	"errors"
+	"reflect"
+)
+
+// ============================================================================
+// Struct Obfuscation - tag driven deep copy
+// ============================================================================
+
+const (
+	structTagName    = "confuse"
+	structTagEnable  = "true"
+	structTagExclude = "-"
+)
+
+type structOptions struct {
+	allStrings bool
+}
+
+// StructOption configures ObfuscateStruct / DeobfuscateStruct
+type StructOption func(*structOptions)
+
+// WithAllStrings obfuscates every exported string field, not only the ones
+// tagged `confuse:"true"`. Fields tagged `confuse:"-"` are still skipped
+func WithAllStrings() StructOption {
+	return func(o *structOptions) {
+		o.allStrings = true
+	}
+}
+
+// ObfuscateStruct returns a deep copy of v (a struct or pointer to struct)
+// where string fields tagged `confuse:"true"` are obfuscated with ObfuscateField.
+// A tag on a struct, slice, map or pointer field applies to every string nested in it.
+// The input is never modified; unexported fields are copied as-is.
+func (sdk *ObfuscatorSDK) ObfuscateStruct(v any, opts ...StructOption) (any, error) {
+	return sdk.walkStruct(v, sdk.ObfuscateField, opts...)
+}
+
+// DeobfuscateStruct reverses ObfuscateStruct, using the same tags and options
+func (sdk *ObfuscatorSDK) DeobfuscateStruct(v any, opts ...StructOption) (any, error) {
+	return sdk.walkStruct(v, sdk.DeobfuscateField, opts...)
+}
+
+func (sdk *ObfuscatorSDK) walkStruct(v any, fn func(string) string, opts ...StructOption) (any, error) {
+	if v == nil {
+		return nil, errors.New("confuse: struct is nil")
+	}
+
+	o := &structOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	rv := reflect.ValueOf(v)
+	kind := rv.Kind()
+	if kind == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, errors.New("confuse: struct pointer is nil")
+		}
+		kind = rv.Elem().Kind()
+	}
+	if kind != reflect.Struct {
+		return nil, errors.New("confuse: value is not a struct or pointer to struct")
+	}
+
+	w := &structWalker{fn: fn, opts: o}
+	return w.copy(rv, false).Interface(), nil
+}
+
+type structWalker struct {
+	fn   func(string) string
+	opts *structOptions
+}
+
+// copy deep-copies v, transforming strings when apply is true
+func (w *structWalker) copy(v reflect.Value, apply bool) reflect.Value {
+	switch v.Kind() {
+	case reflect.String:
+		if !apply {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.SetString(w.fn(v.String()))
+		return out
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(w.copy(v.Elem(), apply))
+		return out
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(w.copy(v.Elem(), apply))
+		return out
+
+	case reflect.Struct:
+		return w.copyStruct(v, apply)
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(w.copy(v.Index(i), apply))
+		}
+		return out
+
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(w.copy(v.Index(i), apply))
+		}
+		return out
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), w.copy(iter.Value(), apply))
+		}
+		return out
+
+	default:
+		return v
+	}
+}
+
+func (w *structWalker) copyStruct(v reflect.Value, apply bool) reflect.Value {
+	out := reflect.New(v.Type()).Elem()
+	// copy everything first so unexported fields are preserved
+	out.Set(v)
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get(structTagName)
+		if tag == structTagExclude {
+			continue
+		}
+
+		fieldApply := apply || tag == structTagEnable
+		if !fieldApply && w.opts.allStrings {
+			fieldApply = field.Type.Kind() == reflect.String
+		}
+		out.Field(i).Set(w.copy(v.Field(i), fieldApply))
+	}
+	return out
+}
@@ -0,0 +1,134 @@
+package confuse
+
+import (
+	"testing"
+)
+
+type testAddress struct {
+	City   string
+	Street string `confuse:"true"`
+}
+
+type testPayload struct {
+	UserName string `confuse:"true"`
+	Email    string
+	Skip     string `confuse:"-"`
+	Age      int
+	Address  *testAddress
+	Tags     []string          `confuse:"true"`
+	Extra    map[string]string `confuse:"true"`
+	secret   string
+}
+
+func TestObfuscateStruct(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	in := &testPayload{
+		UserName: "algorithm",
+		Email:    "user@test.com",
+		Skip:     "computer",
+		Age:      18,
+		Address:  &testAddress{City: "science", Street: "mathematics"},
+		Tags:     []string{"computer", "science"},
+		Extra:    map[string]string{"k": "algorithm"},
+		secret:   "hidden",
+	}
+
+	got, err := sdk.ObfuscateStruct(in)
+	if err != nil {
+		t.Fatalf("ObfuscateStruct() error = %v", err)
+	}
+
+	out, ok := got.(*testPayload)
+	if !ok {
+		t.Fatalf("ObfuscateStruct() returned %T, want *testPayload", got)
+	}
+	if out == in || out.Address == in.Address {
+		t.Fatal("expected a deep copy")
+	}
+	if in.UserName != "algorithm" || in.Address.Street != "mathematics" || in.Tags[0] != "computer" {
+		t.Fatal("input must not be modified")
+	}
+
+	if out.UserName == in.UserName {
+		t.Errorf("tagged field not obfuscated: %s", out.UserName)
+	}
+	if out.Email != in.Email || out.Skip != in.Skip || out.Age != in.Age || out.secret != in.secret {
+		t.Errorf("untagged fields changed: %+v", out)
+	}
+	if out.Address.City != in.Address.City {
+		t.Errorf("untagged nested field changed: %s", out.Address.City)
+	}
+	if out.Address.Street == in.Address.Street {
+		t.Errorf("tagged nested field not obfuscated: %s", out.Address.Street)
+	}
+	if out.Tags[0] == in.Tags[0] || out.Extra["k"] == in.Extra["k"] {
+		t.Errorf("tagged containers not obfuscated: %v %v", out.Tags, out.Extra)
+	}
+
+	back, err := sdk.DeobfuscateStruct(out)
+	if err != nil {
+		t.Fatalf("DeobfuscateStruct() error = %v", err)
+	}
+	restored := back.(*testPayload)
+	if restored.UserName != in.UserName || restored.Address.Street != in.Address.Street ||
+		restored.Tags[1] != in.Tags[1] || restored.Extra["k"] != in.Extra["k"] {
+		t.Errorf("reversibility failed: %+v", restored)
+	}
+}
+
+func TestObfuscateStruct_AllStrings(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	in := testAddress{City: "science", Street: "mathematics"}
+	got, err := sdk.ObfuscateStruct(in, WithAllStrings())
+	if err != nil {
+		t.Fatalf("ObfuscateStruct() error = %v", err)
+	}
+
+	out := got.(testAddress)
+	if out.City == in.City || out.Street == in.Street {
+		t.Errorf("expected all string fields obfuscated, got %+v", out)
+	}
+}
+
+func TestObfuscateStruct_InvalidInput(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	var nilPtr *testAddress
+	for _, v := range []any{nil, nilPtr, "text", 1} {
+		if _, err := sdk.ObfuscateStruct(v); err == nil {
+			t.Errorf("expected error for %#v", v)
+		}
+	}
+}
+
+func TestObfuscateField(t *testing.T) {
+	sdk := NewObfuscatorSDK(20240)
+
+	tests := []string{
+		"userName",
+		"user_name",
+		"UserName",
+		"user-name-v2",
+		"AUserBName",
+		"ABCd",
+		"HTTPServer",
+		"userID",
+		"order2Item",
+		"",
+		"__",
+	}
+
+	for _, field := range tests {
+		t.Run(field, func(t *testing.T) {
+			obf := sdk.ObfuscateField(field)
+			if got := sdk.DeobfuscateField(obf); got != field {
+				t.Errorf("reversibility failed: %s -> %s -> %s", field, obf, got)
+			}
+			if len(splitField(obf)) != len(splitField(field)) {
+				t.Errorf("token count changed: %s -> %s", field, obf)
+			}
+		})
+	}
+}
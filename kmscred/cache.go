@@ -0,0 +1,155 @@
+package kmscred
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/zeromicro/go-zero/core/metric"
+	"github.com/zeromicro/go-zero/core/syncx"
+)
+
+// DefaultMaxConcurrency bounds the KMS calls in flight per CachedClient
+const DefaultMaxConcurrency = 8
+
+var fetchTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "kmscred",
+	Name:      "fetch_total",
+	Help:      "Uncached secret lookups, partitioned by result: fetched from KMS, collapsed into a concurrent fetch, or failed.",
+	Labels:    []string{"result"},
+})
+
+// CacheOption configures a CachedClient
+type CacheOption func(*CachedClient)
+
+// WithMaxConcurrency bounds the concurrent KMS calls, n <= 0 removes the limit
+func WithMaxConcurrency(n int) CacheOption {
+	return func(c *CachedClient) {
+		if n <= 0 {
+			c.slots = nil
+			return
+		}
+		c.slots = make(chan struct{}, n)
+	}
+}
+
+// CachedClient wraps a Client and keeps fetched secret values in memory.
+// Concurrent lookups of the same uncached secret share a single KMS call
+type CachedClient struct {
+	client Client
+	mu     sync.RWMutex
+	values map[string]string
+	ready  atomic.Bool
+	flight syncx.SingleFlight
+	slots  chan struct{} // bounds KMS calls in flight, nil for no limit
+}
+
+// NewCachedClient creates a caching wrapper around client
+func NewCachedClient(client Client, opts ...CacheOption) *CachedClient {
+	c := &CachedClient{
+		client: client,
+		values: make(map[string]string),
+		flight: syncx.NewSingleFlight(),
+		slots:  make(chan struct{}, DefaultMaxConcurrency),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// GetSecretValue returns the cached value of secretName, fetching it on first use
+func (c *CachedClient) GetSecretValue(secretName string) (string, error) {
+	if val, ok := c.cached(secretName); ok {
+		return val, nil
+	}
+
+	val, fresh, err := c.flight.DoEx(secretName, func() (any, error) {
+		return c.fetch(secretName)
+	})
+	switch {
+	case !fresh:
+		fetchTotal.Inc("collapsed")
+	case err != nil:
+		fetchTotal.Inc("failed")
+	default:
+		fetchTotal.Inc("fetched")
+	}
+	if err != nil {
+		return "", err
+	}
+	return val.(string), nil
+}
+
+func (c *CachedClient) cached(secretName string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	val, ok := c.values[secretName]
+	return val, ok
+}
+
+// fetch calls KMS within the concurrency limit and caches the value
+func (c *CachedClient) fetch(secretName string) (string, error) {
+	if c.slots != nil {
+		c.slots <- struct{}{}
+		defer func() { <-c.slots }()
+	}
+	// another call may have filled the cache while we waited for a slot
+	if val, ok := c.cached(secretName); ok {
+		return val, nil
+	}
+
+	val, err := c.client.GetSecretValue(secretName)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.values[secretName] = val
+	c.mu.Unlock()
+	return val, nil
+}
+
+// Preload fetches all names concurrently and caches them.
+// The client becomes Ready only after every secret was fetched successfully.
+func (c *CachedClient) Preload(ctx context.Context, names []string) error {
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+			if _, err := c.GetSecretValue(name); err != nil {
+				errs[i] = fmt.Errorf("kmscred: preload secret %q: %w", name, err)
+			}
+		}(i, name)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("kmscred: preload aborted: %w", ctx.Err())
+	case <-done:
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	c.ready.Store(true)
+	return nil
+}
+
+// Ready reports whether Preload has completed successfully,
+// intended to be wired into readiness probes
+func (c *CachedClient) Ready() bool {
+	return c.ready.Load()
+}
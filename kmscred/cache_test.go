@@ -0,0 +1,172 @@
+package kmscred
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeClient struct {
+	calls    atomic.Int32
+	inflight atomic.Int32
+	peak     atomic.Int32
+	values   map[string]string
+	delay    time.Duration
+}
+
+func (f *fakeClient) GetSecretValue(secretName string) (string, error) {
+	f.calls.Add(1)
+	n := f.inflight.Add(1)
+	defer f.inflight.Add(-1)
+	for {
+		p := f.peak.Load()
+		if n <= p || f.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+	if f.delay > 0 {
+		time.Sleep(f.delay)
+	}
+	v, ok := f.values[secretName]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return v, nil
+}
+
+func TestCachedClient_Preload(t *testing.T) {
+	tests := []struct {
+		name      string
+		names     []string
+		wantErr   bool
+		wantReady bool
+	}{
+		{
+			name:      "all secrets available",
+			names:     []string{"db", "redis"},
+			wantReady: true,
+		},
+		{
+			name:      "missing secret",
+			names:     []string{"db", "missing"},
+			wantErr:   true,
+			wantReady: false,
+		},
+		{
+			name:      "empty list",
+			names:     nil,
+			wantReady: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeClient{values: map[string]string{"db": "pwd", "redis": "secret"}}
+			c := NewCachedClient(fake)
+
+			if c.Ready() {
+				t.Fatal("client should not be ready before Preload")
+			}
+
+			err := c.Preload(context.Background(), tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Preload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if c.Ready() != tt.wantReady {
+				t.Fatalf("Ready() = %v, want %v", c.Ready(), tt.wantReady)
+			}
+		})
+	}
+}
+
+func TestCachedClient_GetSecretValueUsesCache(t *testing.T) {
+	fake := &fakeClient{values: map[string]string{"db": "pwd"}}
+	c := NewCachedClient(fake)
+
+	if err := c.Preload(context.Background(), []string{"db"}); err != nil {
+		t.Fatalf("Preload() error = %v", err)
+	}
+
+	val, err := c.GetSecretValue("db")
+	if err != nil || val != "pwd" {
+		t.Fatalf("GetSecretValue() = %q, %v", val, err)
+	}
+	if got := fake.calls.Load(); got != 1 {
+		t.Fatalf("expected 1 backend call, got %d", got)
+	}
+}
+
+func TestCachedClient_PreloadContextCanceled(t *testing.T) {
+	fake := &fakeClient{values: map[string]string{"db": "pwd"}, delay: time.Second}
+	c := NewCachedClient(fake)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := c.Preload(ctx, []string{"db"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if c.Ready() {
+		t.Fatal("client should not be ready after aborted Preload")
+	}
+}
+
+func TestCachedClient_CollapsesConcurrentFetches(t *testing.T) {
+	fake := &fakeClient{values: map[string]string{"db": "pwd"}, delay: 50 * time.Millisecond}
+	c := NewCachedClient(fake)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if val, err := c.GetSecretValue("db"); err != nil || val != "pwd" {
+				t.Errorf("GetSecretValue() = %q, %v", val, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := fake.calls.Load(); got != 1 {
+		t.Fatalf("expected 1 backend call, got %d", got)
+	}
+}
+
+func TestCachedClient_MaxConcurrency(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     []CacheOption
+		wantPeak int32
+	}{
+		{name: "default", wantPeak: DefaultMaxConcurrency},
+		{name: "limited", opts: []CacheOption{WithMaxConcurrency(2)}, wantPeak: 2},
+		{name: "unlimited", opts: []CacheOption{WithMaxConcurrency(0)}, wantPeak: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := make([]string, 20)
+			values := make(map[string]string, len(names))
+			for i := range names {
+				names[i] = fmt.Sprintf("secret-%d", i)
+				values[names[i]] = "v"
+			}
+			fake := &fakeClient{values: values, delay: 20 * time.Millisecond}
+			c := NewCachedClient(fake, tt.opts...)
+
+			if err := c.Preload(context.Background(), names); err != nil {
+				t.Fatalf("Preload() error = %v", err)
+			}
+			if got := fake.peak.Load(); got > tt.wantPeak {
+				t.Fatalf("peak concurrent calls = %d, want at most %d", got, tt.wantPeak)
+			}
+			if got := fake.calls.Load(); got != int32(len(names)) {
+				t.Fatalf("expected %d backend calls, got %d", len(names), got)
+			}
+		})
+	}
+}
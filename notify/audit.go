@@ -0,0 +1,228 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/sqlx"
+)
+
+// 消息类型
+const (
+	MessageText = "text"
+	MessageCard = "card"
+)
+
+// AuditRecord 一次通知发送的审计记录
+type AuditRecord struct {
+	Channel     NotificationType `json:"channel"`      // 通知渠道
+	MessageType string           `json:"message_type"` // text / card
+	Title       string           `json:"title,omitempty"`
+	ContentHash string           `json:"content_hash"` // 内容 sha256，不落明文
+	Success     bool             `json:"success"`
+	Error       string           `json:"error,omitempty"`
+	LatencyMs   int64            `json:"latency_ms"`
+	SentAt      time.Time        `json:"sent_at"`
+}
+
+// AuditSink 审计记录持久化接口
+type AuditSink interface {
+	Record(ctx context.Context, record AuditRecord) error
+}
+
+// AuditSinkFunc 函数形式的 AuditSink
+type AuditSinkFunc func(ctx context.Context, record AuditRecord) error
+
+// Record 实现 AuditSink
+func (f AuditSinkFunc) Record(ctx context.Context, record AuditRecord) error {
+	return f(ctx, record)
+}
+
+// auditedNotification 记录每次发送结果的通知装饰器
+type auditedNotification struct {
+	next    Notification
+	channel NotificationType
+	sink    AuditSink
+}
+
+// NewAuditedNotification 包装通知实例，每次发送后将结果写入 sink。
+// sink 写入失败只记录日志，不影响发送结果
+func NewAuditedNotification(next Notification, channel NotificationType, sink AuditSink) Notification {
+	if sink == nil {
+		return next
+	}
+	return &auditedNotification{
+		next:    next,
+		channel: channel,
+		sink:    sink,
+	}
+}
+
+// SendText 发送文本消息
+func (a *auditedNotification) SendText(ctx context.Context, content string, opts ...Option) error {
+	start := time.Now()
+	err := a.next.SendText(ctx, content, opts...)
+	a.record(ctx, MessageText, "", content, start, err)
+	return err
+}
+
+// SendCard 发送卡片消息
+func (a *auditedNotification) SendCard(ctx context.Context, title, content string, opts ...Option) error {
+	start := time.Now()
+	err := a.next.SendCard(ctx, title, content, opts...)
+	a.record(ctx, MessageCard, title, title+"\n"+content, start, err)
+	return err
+}
+
+// VerifyConfig 校验被包装渠道的配置，自检不写审计
+func (a *auditedNotification) VerifyConfig(ctx context.Context) error {
+	return VerifyConfig(ctx, a.next)
+}
+
+func (a *auditedNotification) record(ctx context.Context, msgType, title, content string, start time.Time, err error) {
+	record := AuditRecord{
+		Channel:     a.channel,
+		MessageType: msgType,
+		Title:       title,
+		ContentHash: ContentHash(content),
+		Success:     err == nil,
+		LatencyMs:   time.Since(start).Milliseconds(),
+		SentAt:      start,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if sinkErr := a.sink.Record(ctx, record); sinkErr != nil {
+		logx.WithContext(ctx).Errorf("notify audit record failed: %v, record: %+v", sinkErr, record)
+	}
+}
+
+// ContentHash 计算通知内容的 sha256
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// FileAuditSink 以 JSON Lines 格式追加写入本地文件
+type FileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileAuditSink 打开（或创建）审计文件
+func NewFileAuditSink(filename string) (*FileAuditSink, error) {
+	f, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open audit file failed: %w", err)
+	}
+	return &FileAuditSink{file: f}, nil
+}
+
+// Record 实现 AuditSink
+func (s *FileAuditSink) Record(_ context.Context, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.file.Write(data)
+	return err
+}
+
+// Close 关闭审计文件
+func (s *FileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// SQLAuditSink 将审计记录写入数据库表，连接可通过 xutils/db.GetDB 获取。
+// 表结构参考:
+//
+//	CREATE TABLE notify_audit (
+//	  id           BIGINT AUTO_INCREMENT PRIMARY KEY,
+//	  channel      VARCHAR(32)  NOT NULL,
+//	  message_type VARCHAR(16)  NOT NULL,
+//	  title        VARCHAR(255) NOT NULL DEFAULT '',
+//	  content_hash CHAR(64)     NOT NULL,
+//	  success      TINYINT(1)   NOT NULL,
+//	  error        TEXT,
+//	  latency_ms   BIGINT       NOT NULL,
+//	  sent_at      DATETIME(3)  NOT NULL
+//	);
+type SQLAuditSink struct {
+	conn  sqlx.SqlConn
+	query string
+}
+
+// NewSQLAuditSink 创建数据库审计 sink
+func NewSQLAuditSink(conn sqlx.SqlConn, table string) *SQLAuditSink {
+	return &SQLAuditSink{
+		conn: conn,
+		query: fmt.Sprintf("INSERT INTO `%s` (channel, message_type, title, content_hash, success, error, latency_ms, sent_at) "+
+			"VALUES (?, ?, ?, ?, ?, ?, ?, ?)", table),
+	}
+}
+
+// Record 实现 AuditSink
+func (s *SQLAuditSink) Record(ctx context.Context, record AuditRecord) error {
+	_, err := s.conn.ExecCtx(ctx, s.query,
+		string(record.Channel),
+		record.MessageType,
+		record.Title,
+		record.ContentHash,
+		record.Success,
+		record.Error,
+		record.LatencyMs,
+		record.SentAt,
+	)
+	return err
+}
+
+// StreamUploader 对象存储上传接口，storage.Storage 满足该接口
+type StreamUploader interface {
+	UploadStream(ctx context.Context, remote string, stream io.Reader) error
+}
+
+// StorageAuditSink 每条审计记录作为一个 JSON 对象上传到对象存储
+type StorageAuditSink struct {
+	uploader StreamUploader
+	prefix   string
+}
+
+// NewStorageAuditSink 创建对象存储审计 sink，对象路径为 prefix/yyyy-mm-dd/<时间戳>-<hash>.json
+func NewStorageAuditSink(uploader StreamUploader, prefix string) *StorageAuditSink {
+	return &StorageAuditSink{
+		uploader: uploader,
+		prefix:   prefix,
+	}
+}
+
+// Record 实现 AuditSink
+func (s *StorageAuditSink) Record(ctx context.Context, record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	remote := path.Join(
+		s.prefix,
+		record.SentAt.Format("2006-01-02"),
+		fmt.Sprintf("%d-%.12s.json", record.SentAt.UnixNano(), record.ContentHash),
+	)
+	return s.uploader.UploadStream(ctx, remote, bytes.NewReader(data))
+}
@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zeromicro/go-zero/core/conf"
+)
+
+type stubNotification struct {
+	err error
+}
+
+func (s *stubNotification) SendText(ctx context.Context, content string, opts ...Option) error {
+	return s.err
+}
+
+func (s *stubNotification) SendCard(ctx context.Context, title, content string, opts ...Option) error {
+	return s.err
+}
+
+func TestAuditedNotification(t *testing.T) {
+	tests := []struct {
+		name    string
+		sendErr error
+		card    bool
+	}{
+		{name: "text success"},
+		{name: "text failure", sendErr: errors.New("webhook down")},
+		{name: "card success", card: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []AuditRecord
+			sink := AuditSinkFunc(func(_ context.Context, r AuditRecord) error {
+				got = append(got, r)
+				return nil
+			})
+			n := NewAuditedNotification(&stubNotification{err: tt.sendErr}, DingTalk, sink)
+
+			var err error
+			if tt.card {
+				err = n.SendCard(context.Background(), "title", "content")
+			} else {
+				err = n.SendText(context.Background(), "content")
+			}
+			if !errors.Is(err, tt.sendErr) {
+				t.Fatalf("send error = %v, want %v", err, tt.sendErr)
+			}
+
+			if len(got) != 1 {
+				t.Fatalf("expected 1 audit record, got %d", len(got))
+			}
+			r := got[0]
+			if r.Channel != DingTalk || r.Success != (tt.sendErr == nil) || r.ContentHash == "" {
+				t.Fatalf("unexpected record: %+v", r)
+			}
+			if tt.sendErr != nil && r.Error != tt.sendErr.Error() {
+				t.Fatalf("record error = %q, want %q", r.Error, tt.sendErr.Error())
+			}
+		})
+	}
+}
+
+func TestFileAuditSink(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileAuditSink(filename)
+	if err != nil {
+		t.Fatalf("NewFileAuditSink() error = %v", err)
+	}
+
+	n := NewAuditedNotification(&stubNotification{}, Feishu, sink)
+	for i := 0; i < 3; i++ {
+		if err := n.SendText(context.Background(), "hello"); err != nil {
+			t.Fatalf("SendText() error = %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("open audit file: %v", err)
+	}
+	defer f.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("invalid audit line %q: %v", scanner.Text(), err)
+		}
+		if r.ContentHash != ContentHash("hello") {
+			t.Fatalf("content hash = %s", r.ContentHash)
+		}
+		lines++
+	}
+	if lines != 3 {
+		t.Fatalf("expected 3 audit lines, got %d", lines)
+	}
+}
+
+func TestNotificationConfig_Load(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    NotificationType
+	}{
+		{name: "dingtalk", content: `{"Type": "dingtalk", "Config": {"Webhook": "https://oapi.dingtalk.com/robot/send", "Secret": "s"}}`, want: DingTalk},
+		{name: "feishu", content: `{"Type": "feishu", "Config": {"Webhook": "https://open.feishu.cn/hook", "Secret": "s"}, "Locale": "en"}`, want: Feishu},
+		{name: "escalation", content: `{"Type": "escalation", "Config": {"Webhook": "", "Secret": ""}, "Escalation": {"Provider": "twilio_sms", "AccessKey": "k", "AccessSecret": "s", "Phones": ["+8613800000000"]}}`, want: Escalation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg NotificationConfig
+			if err := conf.LoadFromJsonBytes([]byte(tt.content), &cfg); err != nil {
+				t.Fatalf("LoadFromJsonBytes() error = %v", err)
+			}
+			if cfg.Type != tt.want || cfg.Audit != nil {
+				t.Errorf("loaded config = %+v", cfg)
+			}
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -17,8 +18,9 @@ import (
 
 // DingTalkNotification 钉钉通知实现
 type DingTalkNotification struct {
-	webhook string
-	secret  string
+	webhook    string
+	secret     string
+	verifyText string
 }
 
 // NewDingTalkNotification 创建钉钉通知实例
@@ -27,8 +29,9 @@ func NewDingTalkNotification(cfg Config) (Notification, error) {
 		return nil, fmt.Errorf("webhook is empty")
 	}
 	return &DingTalkNotification{
-		webhook: cfg.Webhook,
-		secret:  cfg.Secret,
+		webhook:    cfg.Webhook,
+		secret:     cfg.Secret,
+		verifyText: cfg.VerifyText,
 	}, nil
 }
 
@@ -125,15 +128,7 @@ func (d *DingTalkNotification) sendDingTalkMsg(ctx context.Context, reqBody stri
 		return
 	}
 
-	// 构建请求URL
-	robotUrl := d.webhook
-
-	// 如果设置了签名密钥，则添加签名参数
-	if d.secret != "" {
-		sign, timestamp := d.GenDingTalkSign()
-		robotUrl = fmt.Sprintf("%s&timestamp=%d&sign=%s", d.webhook, timestamp, sign)
-	}
-
+	robotUrl := d.robotURL()
 	reqHeaders := map[string]string{
 		"Content-Type": "application/json",
 	}
@@ -156,6 +151,43 @@ func (d *DingTalkNotification) sendDingTalkMsg(ctx context.Context, reqBody stri
 	return
 }
 
+// robotURL 构建请求URL，设置了签名密钥时添加签名参数
+func (d *DingTalkNotification) robotURL() string {
+	if d.secret == "" {
+		return d.webhook
+	}
+	sign, timestamp := d.GenDingTalkSign()
+	return fmt.Sprintf("%s&timestamp=%d&sign=%s", d.webhook, timestamp, sign)
+}
+
+// VerifyConfig 发送一条自检文本消息，校验 token、签名（或关键词、IP 白名单）和本机时间
+func (d *DingTalkNotification) VerifyConfig(ctx context.Context) error {
+	msg := &Dtext{Msgtype: "text"}
+	msg.Text.Content = withDefault(d.verifyText, DefaultVerifyText)
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	resp, err := xhttp.NewClient().Post(ctx, d.robotURL(), map[string]string{
+		"Content-Type": "application/json",
+	}, data)
+	if err != nil {
+		return fmt.Errorf("dingtalk verify: %w", err)
+	}
+	defer resp.Body.Close()
+
+	skewErr := checkClockSkew(resp, time.Now())
+	var res TalkResponse
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("dingtalk verify: decode response: %w", err)
+	}
+	if res.Code != 0 {
+		return errors.Join(fmt.Errorf("dingtalk verify: errcode %d: %s", res.Code, res.Msg), skewErr)
+	}
+	return skewErr
+}
+
 // 钉钉消息结构体
 // text类型
 type Dtext struct {
@@ -0,0 +1,260 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// Severity 告警级别
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityCritical
+)
+
+// 升级通道服务商
+const (
+	ProviderAliyunSMS   = "aliyun_sms"
+	ProviderAliyunVoice = "aliyun_vms"
+	ProviderTwilioSMS   = "twilio_sms"
+	ProviderTwilioCall  = "twilio_call"
+)
+
+const (
+	defaultRateLimit  = 5
+	defaultRateWindow = time.Hour
+	// 短信/语音内容长度上限（按字符）
+	maxEscalationContent = 200
+)
+
+// EscalationConfig 电话/短信升级通道配置
+type EscalationConfig struct {
+	Provider     string   // aliyun_sms / aliyun_vms / twilio_sms / twilio_call
+	AccessKey    string   // 阿里云 AccessKeyId 或 Twilio Account SID
+	AccessSecret string   // 阿里云 AccessKeySecret 或 Twilio Auth Token
+	Phones       []string // 值班手机号
+	Endpoint     string   `json:",optional"` // 可选，覆盖服务商地址
+
+	SignName     string `json:",optional"` // 阿里云短信签名
+	TemplateCode string `json:",optional"` // 阿里云短信模板 / 语音 TTS 模板
+	ShowNumber   string `json:",optional"` // 阿里云语音主叫显号
+	From         string `json:",optional"` // Twilio 发送号码
+
+	MinSeverity Severity      `json:",optional"` // 最低发送级别，默认 SeverityCritical
+	RateLimit   int           `json:",optional"` // 每个号码在 RateWindow 内最多发送次数，默认 5
+	RateWindow  time.Duration `json:",optional"` // 默认 1h
+	QuietHours  QuietHours    `json:",optional"`
+}
+
+// QuietHours 免打扰时段，时段内只发送级别不低于 MinSeverity 的告警
+type QuietHours struct {
+	Start       string   `json:",optional"` // "22:00"
+	End         string   `json:",optional"` // "08:00"，可跨天
+	Timezone    string   `json:",optional"` // 默认本地时区，如 "Asia/Shanghai"
+	MinSeverity Severity `json:",optional"` // 默认 SeverityCritical
+}
+
+// escalationSender 服务商发送实现
+type escalationSender interface {
+	send(ctx context.Context, phone, content string) error
+}
+
+// EscalationNotification 电话/短信升级通知实现
+type EscalationNotification struct {
+	cfg     EscalationConfig
+	sender  escalationSender
+	limiter *phoneLimiter
+	quiet   *quietWindow
+	now     func() time.Time
+}
+
+// NewEscalationNotification 创建升级通知实例
+func NewEscalationNotification(cfg EscalationConfig) (Notification, error) {
+	return newEscalationNotification(cfg, nil)
+}
+
+func newEscalationNotification(cfg EscalationConfig, sender escalationSender) (*EscalationNotification, error) {
+	if len(cfg.Phones) == 0 {
+		return nil, fmt.Errorf("escalation phones is empty")
+	}
+	if cfg.MinSeverity == SeverityInfo {
+		cfg.MinSeverity = SeverityCritical
+	}
+	if cfg.RateLimit <= 0 {
+		cfg.RateLimit = defaultRateLimit
+	}
+	if cfg.RateWindow <= 0 {
+		cfg.RateWindow = defaultRateWindow
+	}
+
+	quiet, err := newQuietWindow(cfg.QuietHours)
+	if err != nil {
+		return nil, err
+	}
+
+	if sender == nil {
+		if sender, err = newEscalationSender(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return &EscalationNotification{
+		cfg:     cfg,
+		sender:  sender,
+		limiter: newPhoneLimiter(cfg.RateLimit, cfg.RateWindow),
+		quiet:   quiet,
+		now:     time.Now,
+	}, nil
+}
+
+// SendText 发送文本告警
+func (e *EscalationNotification) SendText(ctx context.Context, content string, opts ...Option) error {
+	return e.escalate(ctx, content, opts...)
+}
+
+// SendCard 发送卡片告警，短信/语音只保留标题和正文文本
+func (e *EscalationNotification) SendCard(ctx context.Context, title, content string, opts ...Option) error {
+	return e.escalate(ctx, title+": "+content, opts...)
+}
+
+func (e *EscalationNotification) escalate(ctx context.Context, content string, opts ...Option) error {
+	o := &Options{Severity: SeverityCritical}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	now := e.now()
+	if o.Severity < e.cfg.MinSeverity {
+		return nil
+	}
+	if e.quiet != nil && e.quiet.contains(now) && o.Severity < e.quiet.minSeverity {
+		logx.WithContext(ctx).Infof("escalation suppressed by quiet hours, severity: %d", o.Severity)
+		return nil
+	}
+
+	content = truncateRunes(content, maxEscalationContent)
+
+	var errs []error
+	for _, phone := range e.cfg.Phones {
+		if !e.limiter.allow(phone, now) {
+			logx.WithContext(ctx).Infof("escalation rate limited, phone: %s", maskPhone(phone))
+			continue
+		}
+		if err := e.sender.send(ctx, phone, content); err != nil {
+			errs = append(errs, fmt.Errorf("escalate to %s: %w", maskPhone(phone), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// phoneLimiter 按号码的滑动窗口限流
+type phoneLimiter struct {
+	mu     sync.Mutex
+	limit  int
+	window time.Duration
+	sent   map[string][]time.Time
+}
+
+func newPhoneLimiter(limit int, window time.Duration) *phoneLimiter {
+	return &phoneLimiter{
+		limit:  limit,
+		window: window,
+		sent:   make(map[string][]time.Time),
+	}
+}
+
+func (l *phoneLimiter) allow(phone string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cutoff := now.Add(-l.window)
+	history := l.sent[phone]
+	kept := history[:0]
+	for _, ts := range history {
+		if ts.After(cutoff) {
+			kept = append(kept, ts)
+		}
+	}
+	if len(kept) >= l.limit {
+		l.sent[phone] = kept
+		return false
+	}
+	l.sent[phone] = append(kept, now)
+	return true
+}
+
+// quietWindow 每日免打扰时段
+type quietWindow struct {
+	start, end  time.Duration // 距当天零点的偏移
+	loc         *time.Location
+	minSeverity Severity
+}
+
+func newQuietWindow(q QuietHours) (*quietWindow, error) {
+	if q.Start == "" || q.End == "" {
+		return nil, nil
+	}
+
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := time.Local
+	if q.Timezone != "" {
+		if loc, err = time.LoadLocation(q.Timezone); err != nil {
+			return nil, fmt.Errorf("invalid quiet hours timezone: %w", err)
+		}
+	}
+
+	minSeverity := q.MinSeverity
+	if minSeverity == SeverityInfo {
+		minSeverity = SeverityCritical
+	}
+	return &quietWindow{start: start, end: end, loc: loc, minSeverity: minSeverity}, nil
+}
+
+func (q *quietWindow) contains(t time.Time) bool {
+	t = t.In(q.loc)
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if q.start <= q.end {
+		return offset >= q.start && offset < q.end
+	}
+	// 跨天，如 22:00 - 08:00
+	return offset >= q.start || offset < q.end
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quiet hours time %q: %w", s, err)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+func maskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return phone
+	}
+	return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+}
@@ -0,0 +1,198 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"gomod.pri/golib/xhttp"
+)
+
+const (
+	aliyunSMSEndpoint   = "https://dysmsapi.aliyuncs.com/"
+	aliyunVoiceEndpoint = "https://dyvmsapi.aliyuncs.com/"
+	twilioEndpoint      = "https://api.twilio.com"
+)
+
+func newEscalationSender(cfg EscalationConfig) (escalationSender, error) {
+	if cfg.AccessKey == "" || cfg.AccessSecret == "" {
+		return nil, fmt.Errorf("escalation access key or secret is empty")
+	}
+
+	switch cfg.Provider {
+	case ProviderAliyunSMS:
+		if cfg.SignName == "" || cfg.TemplateCode == "" {
+			return nil, fmt.Errorf("aliyun sms requires sign name and template code")
+		}
+		return &aliyunSender{cfg: cfg, endpoint: withDefault(cfg.Endpoint, aliyunSMSEndpoint), voice: false}, nil
+	case ProviderAliyunVoice:
+		if cfg.ShowNumber == "" || cfg.TemplateCode == "" {
+			return nil, fmt.Errorf("aliyun voice requires show number and template code")
+		}
+		return &aliyunSender{cfg: cfg, endpoint: withDefault(cfg.Endpoint, aliyunVoiceEndpoint), voice: true}, nil
+	case ProviderTwilioSMS, ProviderTwilioCall:
+		if cfg.From == "" {
+			return nil, fmt.Errorf("twilio requires from number")
+		}
+		return &twilioSender{cfg: cfg, endpoint: withDefault(cfg.Endpoint, twilioEndpoint), call: cfg.Provider == ProviderTwilioCall}, nil
+	default:
+		return nil, fmt.Errorf("unsupported escalation provider: %s", cfg.Provider)
+	}
+}
+
+// aliyunSender 阿里云短信（SendSms）/语音（SingleCallByTts）
+type aliyunSender struct {
+	cfg      EscalationConfig
+	endpoint string
+	voice    bool
+}
+
+func (a *aliyunSender) send(ctx context.Context, phone, content string) error {
+	param, err := json.Marshal(map[string]string{"content": content})
+	if err != nil {
+		return err
+	}
+
+	params := map[string]string{}
+	if a.voice {
+		params["Action"] = "SingleCallByTts"
+		params["CalledNumber"] = phone
+		params["CalledShowNumber"] = a.cfg.ShowNumber
+		params["TtsCode"] = a.cfg.TemplateCode
+		params["TtsParam"] = string(param)
+	} else {
+		params["Action"] = "SendSms"
+		params["PhoneNumbers"] = phone
+		params["SignName"] = a.cfg.SignName
+		params["TemplateCode"] = a.cfg.TemplateCode
+		params["TemplateParam"] = string(param)
+	}
+
+	query, err := signAliyunRPC(a.cfg.AccessKey, a.cfg.AccessSecret, params, time.Now())
+	if err != nil {
+		return err
+	}
+
+	resp, err := xhttp.NewClient().Get(ctx, a.endpoint+"?"+query, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var res struct {
+		Code    string `json:"Code"`
+		Message string `json:"Message"`
+	}
+	if err = json.Unmarshal(body, &res); err != nil {
+		return err
+	}
+	if res.Code != "OK" {
+		return fmt.Errorf("aliyun %s failed: %s %s", params["Action"], res.Code, res.Message)
+	}
+	return nil
+}
+
+// signAliyunRPC 按阿里云 RPC 签名规则生成请求参数
+func signAliyunRPC(accessKey, secret string, params map[string]string, now time.Time) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	all := map[string]string{
+		"AccessKeyId":      accessKey,
+		"Format":           "JSON",
+		"RegionId":         "cn-hangzhou",
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureNonce":   hex.EncodeToString(nonce),
+		"SignatureVersion": "1.0",
+		"Timestamp":        now.UTC().Format("2006-01-02T15:04:05Z"),
+		"Version":          "2017-05-25",
+	}
+	for k, v := range params {
+		all[k] = v
+	}
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, aliyunEncode(k)+"="+aliyunEncode(all[k]))
+	}
+	canonical := strings.Join(pairs, "&")
+
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte("GET&%2F&" + aliyunEncode(canonical)))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return "Signature=" + aliyunEncode(signature) + "&" + canonical, nil
+}
+
+func aliyunEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	return strings.ReplaceAll(s, "%7E", "~")
+}
+
+// twilioSender Twilio 短信（Messages）/电话（Calls）
+type twilioSender struct {
+	cfg      EscalationConfig
+	endpoint string
+	call     bool
+}
+
+func (t *twilioSender) send(ctx context.Context, phone, content string) error {
+	form := url.Values{}
+	form.Set("To", phone)
+	form.Set("From", t.cfg.From)
+
+	resource := "Messages.json"
+	if t.call {
+		resource = "Calls.json"
+		form.Set("Twiml", "<Response><Say>"+html.EscapeString(content)+"</Say></Response>")
+	} else {
+		form.Set("Body", content)
+	}
+
+	api := fmt.Sprintf("%s/2010-04-01/Accounts/%s/%s", t.endpoint, url.PathEscape(t.cfg.AccessKey), resource)
+	auth := base64.StdEncoding.EncodeToString([]byte(t.cfg.AccessKey + ":" + t.cfg.AccessSecret))
+	headers := map[string]string{
+		"Authorization": "Basic " + auth,
+		"Content-Type":  "application/x-www-form-urlencoded",
+	}
+
+	// 非 2xx 由 xhttp 转为 *xhttp.HTTPError
+	resp, err := xhttp.NewClient().Post(ctx, api, headers, []byte(form.Encode()))
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func withDefault(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
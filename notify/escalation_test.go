@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeSender struct {
+	sent []string
+}
+
+func (f *fakeSender) send(_ context.Context, phone, content string) error {
+	f.sent = append(f.sent, phone+":"+content)
+	return nil
+}
+
+func TestEscalationSeverityAndQuietHours(t *testing.T) {
+	cfg := EscalationConfig{
+		Phones:      []string{"13800000000"},
+		MinSeverity: SeverityWarning,
+		QuietHours:  QuietHours{Start: "22:00", End: "08:00", Timezone: "UTC"},
+	}
+
+	cases := []struct {
+		name     string
+		at       time.Time
+		severity Severity
+		want     int
+	}{
+		{"info dropped", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), SeverityInfo, 0},
+		{"warning daytime", time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), SeverityWarning, 1},
+		{"warning quiet", time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC), SeverityWarning, 0},
+		{"warning quiet after midnight", time.Date(2024, 1, 1, 7, 59, 0, 0, time.UTC), SeverityWarning, 0},
+		{"critical quiet", time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC), SeverityCritical, 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := &fakeSender{}
+			n, err := newEscalationNotification(cfg, sender)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n.now = func() time.Time { return tc.at }
+
+			if err := n.SendText(context.Background(), "db down", WithSeverity(tc.severity)); err != nil {
+				t.Fatal(err)
+			}
+			if len(sender.sent) != tc.want {
+				t.Fatalf("sent %d, want %d", len(sender.sent), tc.want)
+			}
+		})
+	}
+}
+
+func TestEscalationRateLimit(t *testing.T) {
+	sender := &fakeSender{}
+	n, err := newEscalationNotification(EscalationConfig{
+		Phones:     []string{"13800000000", "13900000000"},
+		RateLimit:  2,
+		RateWindow: time.Minute,
+	}, sender)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if err := n.SendCard(context.Background(), "P0", "db down"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(sender.sent) != 4 {
+		t.Fatalf("sent %d, want 4", len(sender.sent))
+	}
+	if sender.sent[0] != "13800000000:P0: db down" {
+		t.Fatalf("unexpected content %q", sender.sent[0])
+	}
+
+	now = now.Add(time.Minute)
+	if err := n.SendText(context.Background(), "again"); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 6 {
+		t.Fatalf("sent %d after window, want 6", len(sender.sent))
+	}
+}
+
+func TestSignAliyunRPC(t *testing.T) {
+	query, err := signAliyunRPC("ak", "secret", map[string]string{"Action": "SendSms"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(query, "Signature=") {
+		t.Fatalf("missing signature: %s", query)
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values.Get("Action") != "SendSms" || values.Get("AccessKeyId") != "ak" {
+		t.Fatalf("unexpected params: %v", values)
+	}
+}
+
+func TestNewEscalationConfigErrors(t *testing.T) {
+	cases := []EscalationConfig{
+		{},
+		{Phones: []string{"1"}, Provider: "unknown", AccessKey: "a", AccessSecret: "b"},
+		{Phones: []string{"1"}, Provider: ProviderAliyunSMS, AccessKey: "a", AccessSecret: "b"},
+		{Phones: []string{"1"}, QuietHours: QuietHours{Start: "25:00", End: "08:00"}},
+	}
+	for _, cfg := range cases {
+		if _, err := NewEscalationNotification(cfg); err == nil {
+			t.Fatalf("expected error for %+v", cfg)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -18,8 +19,9 @@ import (
 
 // FeishuNotification 飞书通知实现
 type FeishuNotification struct {
-	webhook string
-	secret  string
+	webhook    string
+	secret     string
+	verifyText string
 }
 
 // NewFeishuNotification 创建飞书通知实例
@@ -28,8 +30,9 @@ func NewFeishuNotification(cfg Config) (Notification, error) {
 		return nil, fmt.Errorf("feishu webhook or secret is empty")
 	}
 	return &FeishuNotification{
-		webhook: cfg.Webhook,
-		secret:  cfg.Secret,
+		webhook:    cfg.Webhook,
+		secret:     cfg.Secret,
+		verifyText: cfg.VerifyText,
 	}, nil
 }
 
@@ -153,6 +156,47 @@ func SendFeishuCardMsg(ctx context.Context, webhook, secret, title, content stri
 	return err
 }
 
+// VerifyConfig 发送一条自检文本消息，校验 webhook、签名和本机时间
+func (f *FeishuNotification) VerifyConfig(ctx context.Context) error {
+	tt := time.Now().Unix()
+	sign, err := GenFeishuSign(ctx, f.secret, tt)
+	if err != nil {
+		return err
+	}
+	info := reqStruct{MsgType: "text", Timestamp: strconv.FormatInt(tt, 10), Sign: sign}
+	info.Content.Text = withDefault(f.verifyText, DefaultVerifyText)
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	resp, err := xhttp.NewClient().Post(ctx, f.webhook, map[string]string{
+		"Content-Type": "application/json",
+	}, data)
+	if err != nil {
+		return fmt.Errorf("feishu verify: %w", err)
+	}
+	defer resp.Body.Close()
+
+	skewErr := checkClockSkew(resp, time.Now())
+	var res feishuResponse
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("feishu verify: decode response: %w", err)
+	}
+	if res.Code != 0 || res.StatusCode != 0 {
+		return errors.Join(fmt.Errorf("feishu verify: code %d: %s", max(res.Code, res.StatusCode), res.Msg+res.StatusMessage), skewErr)
+	}
+	return skewErr
+}
+
+// feishuResponse 飞书机器人响应，旧版本返回 StatusCode/StatusMessage
+type feishuResponse struct {
+	Code          int    `json:"code"`
+	Msg           string `json:"msg"`
+	StatusCode    int    `json:"StatusCode"`
+	StatusMessage string `json:"StatusMessage"`
+}
+
 // 飞书消息结构体
 type reqStruct struct {
 	MsgType string `json:"msg_type"`
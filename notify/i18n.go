@@ -0,0 +1,229 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// Locale 渠道语言，BCP 47 格式，如 zh-CN、en-US
+type Locale string
+
+const (
+	LocaleZH Locale = "zh-CN"
+	LocaleEN Locale = "en-US"
+	// DefaultLocale 渠道未配置语言，或消息缺少对应语言时使用
+	DefaultLocale = LocaleZH
+)
+
+// language 返回语言部分，如 en-US 返回 en
+func (l Locale) language() string {
+	lang, _, _ := strings.Cut(strings.ToLower(string(l)), "-")
+	return lang
+}
+
+// MessageTemplate 一条消息在某个语言下的标题和内容，text/template 语法，
+// 数据来自 WithMessage；SendText 只使用 Content
+type MessageTemplate struct {
+	Title   string `json:"title,optional"`
+	Content string `json:"content"`
+}
+
+type parsedMessage struct {
+	title   *template.Template
+	content *template.Template
+}
+
+// Catalog 多语言消息目录，按消息 key 和语言保存模板，并发安全
+type Catalog struct {
+	mu       sync.RWMutex
+	messages map[string]map[Locale]parsedMessage
+}
+
+// DefaultCatalog 未单独指定目录的渠道使用的全局目录
+var DefaultCatalog = NewCatalog()
+
+func NewCatalog() *Catalog {
+	return &Catalog{messages: make(map[string]map[Locale]parsedMessage)}
+}
+
+// LoadCatalog 从 JSON 加载目录，格式为 语言 -> key -> 模板，例如：
+//
+//	{
+//	  "zh-CN": {"payment.failed": {"title": "支付失败", "content": "订单 {{.OrderID}} 支付失败"}},
+//	  "en-US": {"payment.failed": {"title": "Payment failed", "content": "Order {{.OrderID}} failed to pay"}}
+//	}
+func LoadCatalog(data []byte) (*Catalog, error) {
+	var messages map[string]map[string]MessageTemplate
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("notify: parse catalog failed: %w", err)
+	}
+	return newCatalogFrom(messages)
+}
+
+func newCatalogFrom(messages map[string]map[string]MessageTemplate) (*Catalog, error) {
+	c := NewCatalog()
+	for locale, msgs := range messages {
+		if err := c.Add(Locale(locale), msgs); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Add 添加或覆盖 locale 下的消息，任一模板解析失败时不做任何修改
+func (c *Catalog) Add(locale Locale, messages map[string]MessageTemplate) error {
+	parsed := make(map[string]parsedMessage, len(messages))
+	for key, msg := range messages {
+		var (
+			pm  parsedMessage
+			err error
+		)
+		if pm.title, err = template.New(key).Option("missingkey=zero").Parse(msg.Title); err != nil {
+			return fmt.Errorf("notify: message %s (%s) title: %w", key, locale, err)
+		}
+		if pm.content, err = template.New(key).Option("missingkey=zero").Parse(msg.Content); err != nil {
+			return fmt.Errorf("notify: message %s (%s) content: %w", key, locale, err)
+		}
+		parsed[key] = pm
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, pm := range parsed {
+		if c.messages[key] == nil {
+			c.messages[key] = make(map[Locale]parsedMessage)
+		}
+		c.messages[key][locale] = pm
+	}
+	return nil
+}
+
+// Render 按 locale 渲染消息，依次尝试完全匹配、同语言的其他地区和 DefaultLocale，
+// 消息不存在时 ok 为 false
+func (c *Catalog) Render(locale Locale, key string, data any) (title, content string, ok bool, err error) {
+	pm, ok := c.lookup(locale, key)
+	if !ok {
+		return "", "", false, nil
+	}
+	if title, err = execute(pm.title, data); err != nil {
+		return "", "", true, fmt.Errorf("notify: render message %s title: %w", key, err)
+	}
+	if content, err = execute(pm.content, data); err != nil {
+		return "", "", true, fmt.Errorf("notify: render message %s content: %w", key, err)
+	}
+	return title, content, true, nil
+}
+
+func (c *Catalog) lookup(locale Locale, key string) (parsedMessage, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	byLocale := c.messages[key]
+	if pm, ok := byLocale[locale]; ok {
+		return pm, true
+	}
+	// 同语言的其他地区，如 en-US 缺失时用 en-GB；多个匹配时取字典序最小的，保证结果稳定
+	var (
+		match Locale
+		found bool
+	)
+	for l := range byLocale {
+		if l.language() == locale.language() && (!found || l < match) {
+			match, found = l, true
+		}
+	}
+	if found {
+		return byLocale[match], true
+	}
+	pm, ok := byLocale[DefaultLocale]
+	return pm, ok
+}
+
+func execute(t *template.Template, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// WithMessage 按渠道语言从目录渲染消息 key，替换 SendText/SendCard 传入的标题和内容；
+// 目录中没有该 key 时仍发送传入的标题和内容
+func WithMessage(key string, data any) Option {
+	return func(o *Options) {
+		o.MessageKey = key
+		o.MessageData = data
+	}
+}
+
+// localizedNotification 按渠道语言渲染 WithMessage 指定的消息
+type localizedNotification struct {
+	next    Notification
+	locale  Locale
+	catalog *Catalog
+}
+
+// NewLocalizedNotification 包装 n，使用 catalog 按 locale 渲染消息，
+// catalog 为 nil 时使用 DefaultCatalog，locale 为空时使用 DefaultLocale
+func NewLocalizedNotification(n Notification, locale Locale, catalog *Catalog) Notification {
+	if locale == "" {
+		locale = DefaultLocale
+	}
+	if catalog == nil {
+		catalog = DefaultCatalog
+	}
+	return &localizedNotification{next: n, locale: locale, catalog: catalog}
+}
+
+// SendText 发送文本消息
+func (l *localizedNotification) SendText(ctx context.Context, content string, opts ...Option) error {
+	_, content, err := l.render("", content, opts)
+	if err != nil {
+		return err
+	}
+	return l.next.SendText(ctx, content, opts...)
+}
+
+// SendCard 发送卡片消息
+func (l *localizedNotification) SendCard(ctx context.Context, title, content string, opts ...Option) error {
+	title, content, err := l.render(title, content, opts)
+	if err != nil {
+		return err
+	}
+	return l.next.SendCard(ctx, title, content, opts...)
+}
+
+// VerifyConfig 校验被包装渠道的配置
+func (l *localizedNotification) VerifyConfig(ctx context.Context) error {
+	return VerifyConfig(ctx, l.next)
+}
+
+func (l *localizedNotification) render(title, content string, opts []Option) (string, string, error) {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.MessageKey == "" {
+		return title, content, nil
+	}
+
+	t, c, ok, err := l.catalog.Render(l.locale, o.MessageKey, o.MessageData)
+	if err != nil {
+		return "", "", err
+	}
+	if !ok {
+		logx.Errorf("notify: message %s not found in catalog for %s, send as is", o.MessageKey, l.locale)
+		return title, content, nil
+	}
+	if t == "" {
+		t = title
+	}
+	return t, c, nil
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"testing"
+)
+
+const testCatalog = `{
+	"zh-CN": {"payment.failed": {"title": "支付失败", "content": "订单 {{.OrderID}} 支付失败"}},
+	"en-US": {"payment.failed": {"title": "Payment failed", "content": "Order {{.OrderID}} failed to pay"}},
+	"en-GB": {"refund.failed": {"content": "Refund {{.OrderID}} failed"}}
+}`
+
+func TestCatalog_Render(t *testing.T) {
+	catalog, err := LoadCatalog([]byte(testCatalog))
+	if err != nil {
+		t.Fatalf("LoadCatalog() error = %v", err)
+	}
+	data := map[string]string{"OrderID": "1001"}
+
+	tests := []struct {
+		name        string
+		locale      Locale
+		key         string
+		wantTitle   string
+		wantContent string
+		wantOK      bool
+	}{
+		{name: "exact", locale: LocaleEN, key: "payment.failed", wantTitle: "Payment failed", wantContent: "Order 1001 failed to pay", wantOK: true},
+		{name: "chinese", locale: LocaleZH, key: "payment.failed", wantTitle: "支付失败", wantContent: "订单 1001 支付失败", wantOK: true},
+		{name: "same language", locale: "en-AU", key: "payment.failed", wantTitle: "Payment failed", wantContent: "Order 1001 failed to pay", wantOK: true},
+		{name: "other region", locale: LocaleEN, key: "refund.failed", wantContent: "Refund 1001 failed", wantOK: true},
+		{name: "default locale", locale: "ja-JP", key: "payment.failed", wantTitle: "支付失败", wantContent: "订单 1001 支付失败", wantOK: true},
+		{name: "missing key", locale: LocaleEN, key: "order.created"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, content, ok, err := catalog.Render(tt.locale, tt.key, data)
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			if ok != tt.wantOK || title != tt.wantTitle || content != tt.wantContent {
+				t.Errorf("Render() = %q, %q, %v, want %q, %q, %v", title, content, ok, tt.wantTitle, tt.wantContent, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCatalog_AddInvalid(t *testing.T) {
+	catalog := NewCatalog()
+	err := catalog.Add(LocaleEN, map[string]MessageTemplate{
+		"ok":     {Content: "fine"},
+		"broken": {Content: "{{.OrderID"},
+	})
+	if err == nil {
+		t.Fatal("Add() accepted an invalid template")
+	}
+	if _, _, ok, _ := catalog.Render(LocaleEN, "ok", nil); ok {
+		t.Error("valid messages were added despite the error")
+	}
+}
+
+func TestLocalizedNotification(t *testing.T) {
+	catalog, err := LoadCatalog([]byte(testCatalog))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sent []string
+	intl := NewLocalizedNotification(&recordingNotification{webhook: "intl", sent: &sent}, LocaleEN, catalog)
+	domestic := NewLocalizedNotification(&recordingNotification{webhook: "cn", sent: &sent}, "", catalog)
+
+	ctx := context.Background()
+	opt := WithMessage("payment.failed", map[string]string{"OrderID": "1001"})
+	for _, n := range []Notification{intl, domestic} {
+		if err := n.SendCard(ctx, "fallback", "fallback", opt); err != nil {
+			t.Fatalf("SendCard() error = %v", err)
+		}
+	}
+	// 没有 WithMessage 或目录中没有 key 时原样发送
+	_ = intl.SendText(ctx, "plain")
+	_ = intl.SendText(ctx, "unknown", WithMessage("order.created", nil))
+
+	want := []string{
+		"intl:Payment failed/Order 1001 failed to pay",
+		"cn:支付失败/订单 1001 支付失败",
+		"intl:plain",
+		"intl:unknown",
+	}
+	if len(sent) != len(want) {
+		t.Fatalf("sent %q, want %q", sent, want)
+	}
+	for i := range want {
+		if sent[i] != want[i] {
+			t.Errorf("sent[%d] = %q, want %q", i, sent[i], want[i])
+		}
+	}
+}
+
+func TestRegistry_Locale(t *testing.T) {
+	src := &fakeSource{content: `{
+		"channels": {
+			"intl": {"type": "feishu", "webhook": "intl", "locale": "en-US"},
+			"cn":   {"type": "feishu", "webhook": "cn"}
+		},
+		"routes": {"payment": ["intl", "cn"]},
+		"messages": {
+			"zh-CN": {"payment.failed": {"title": "支付失败", "content": "订单 {{.OrderID}}"}},
+			"en-US": {"payment.failed": {"title": "Payment failed", "content": "Order {{.OrderID}}"}}
+		}
+	}`}
+	var sent []string
+	r := newRegistry(src, "notify.json")
+	r.newFn = func(cfg NotificationConfig) (Notification, error) {
+		n := &recordingNotification{webhook: cfg.Config.Webhook, sent: &sent}
+		return NewLocalizedNotification(n, cfg.Locale, cfg.Catalog), nil
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	err := r.Route("payment").SendCard(context.Background(), "", "", WithMessage("payment.failed", map[string]string{"OrderID": "1001"}))
+	if err != nil {
+		t.Fatalf("SendCard() error = %v", err)
+	}
+	if len(sent) != 2 || sent[0] != "intl:Payment failed/Order 1001" || sent[1] != "cn:支付失败/订单 1001" {
+		t.Errorf("sent %q", sent)
+	}
+}
@@ -13,17 +13,26 @@ const (
 	DingTalk NotificationType = "dingtalk"
 	// Feishu 飞书通知
 	Feishu NotificationType = "feishu"
+	// Escalation 电话/短信升级通知
+	Escalation NotificationType = "escalation"
 )
 
 // NotificationConfig 通知配置
 type NotificationConfig struct {
-	Type   NotificationType // 通知类型
-	Config Config           // 通知配置
+	Type       NotificationType // 通知类型
+	Config     Config           // 通知配置
+	Escalation EscalationConfig `json:",optional"` // Type 为 Escalation 时的配置
+	Audit      AuditSink        `json:",optional"` // 可选，审计记录持久化，只能在代码中设置（conf 不允许两个 "-" 字段）
+	Spill      SpillConfig      `json:",optional"` // 可选，发送失败时落盘并在恢复后重发
+	Locale     Locale           `json:",optional"` // 可选，渲染 WithMessage 消息的语言，默认 DefaultLocale
+	Catalog    *Catalog         `json:"-"`         // 可选，消息目录，默认 DefaultCatalog
 }
 
 type Config struct {
 	Webhook string // 机器人 webhook
 	Secret  string // 机器人加签密钥
+	// VerifyText 自检消息内容，默认 DefaultVerifyText，机器人设置了关键词时需包含关键词
+	VerifyText string `json:",optional"`
 }
 
 // Notification 通知接口
@@ -39,7 +48,13 @@ type Option func(*Options)
 
 // Options 选项结构
 type Options struct {
-	AtUsers []string // 空数组表示不@任何人，["all"]表示@所有人，["user1", "user2"]表示@特定用户
+	AtUsers  []string // 空数组表示不@任何人，["all"]表示@所有人，["user1", "user2"]表示@特定用户
+	Severity Severity // 告警级别，升级通道据此决定是否发送
+
+	severitySet bool // 是否调用了 WithSeverity，未设置时升级通道按 SeverityCritical 处理
+
+	MessageKey  string // 消息目录中的 key，见 WithMessage
+	MessageData any    // 渲染消息模板的数据
 }
 
 // AtAll 设置@所有人
@@ -49,6 +64,14 @@ func AtAll() Option {
 	}
 }
 
+// WithSeverity 设置告警级别
+func WithSeverity(severity Severity) Option {
+	return func(o *Options) {
+		o.Severity = severity
+		o.severitySet = true
+	}
+}
+
 // AtMobiles 设置@指定手机号
 func AtMobiles(atMobiles []string) Option {
 	return func(o *Options) {
@@ -58,12 +81,32 @@ func AtMobiles(atMobiles []string) Option {
 
 // NewNotification 创建通知实例
 func NewNotification(cfg NotificationConfig) (Notification, error) {
+	var (
+		n   Notification
+		err error
+	)
+
 	switch cfg.Type {
 	case DingTalk:
-		return NewDingTalkNotification(cfg.Config)
+		n, err = NewDingTalkNotification(cfg.Config)
 	case Feishu:
-		return NewFeishuNotification(cfg.Config)
+		n, err = NewFeishuNotification(cfg.Config)
+	case Escalation:
+		n, err = NewEscalationNotification(cfg.Escalation)
 	default:
 		return nil, fmt.Errorf("unsupported notification type: %s", cfg.Type)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	n = NewAuditedNotification(n, cfg.Type, cfg.Audit)
+	if cfg.Spill.Path != "" {
+		// 落盘包在审计外层，重发的消息也会被审计
+		if n, err = NewSpillNotification(n, cfg.Spill); err != nil {
+			return nil, err
+		}
+	}
+	// 多语言渲染在最外层，审计和落盘记录的都是渲染后的内容
+	return NewLocalizedNotification(n, cfg.Locale, cfg.Catalog), nil
 }
@@ -0,0 +1,303 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/apolloconfig/agollo/v4/storage"
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+
+	"gomod.pri/golib/apollo"
+)
+
+// ErrChannelNotFound 渠道或路由不存在
+var ErrChannelNotFound = errors.New("notify: channel not found")
+
+// ConfigSource 配置来源，*apollo.Client 已实现
+type ConfigSource interface {
+	GetContent(namespace string) (string, error)
+	AddChangeListener(listener storage.ChangeListener)
+}
+
+var _ ConfigSource = (*apollo.Client)(nil)
+
+// RegistryConfig Apollo 命名空间中的 JSON 配置，例如：
+//
+//	{
+//	  "channels": {
+//	    "ops":    {"type": "dingtalk", "webhook": "https://...", "secret": "SEC..."},
+//	    "oncall": {"type": "escalation", "escalation": {"Provider": "aliyun_sms", ...}}
+//	  },
+//	  "routes": {"payment": ["ops", "oncall"]},
+//	  "messages": {"en-US": {"payment.failed": {"title": "Payment failed", "content": "Order {{.OrderID}}"}}}
+//	}
+type RegistryConfig struct {
+	Channels map[string]ChannelConfig `json:"channels"`
+	Routes   map[string][]string      `json:"routes,optional"` // 路由名 -> 渠道名列表
+	// 消息目录，格式同 LoadCatalog，为空时使用 WithRegistryCatalog 设置的目录
+	Messages map[string]map[string]MessageTemplate `json:"messages,optional"`
+}
+
+// ChannelConfig 单个命名渠道的配置
+type ChannelConfig struct {
+	Type       NotificationType `json:"type"`
+	Webhook    string           `json:"webhook,optional"`
+	Secret     string           `json:"secret,optional"`
+	Escalation EscalationConfig `json:"escalation,optional"`
+	Locale     Locale           `json:"locale,optional"`     // 渲染 WithMessage 消息的语言
+	VerifyText string           `json:"verifyText,optional"` // 自检消息内容，见 Config.VerifyText
+}
+
+// RegistryOption 注册表选项
+type RegistryOption func(*Registry)
+
+// WithRegistryAudit 为注册表创建的所有渠道设置审计
+func WithRegistryAudit(sink AuditSink) RegistryOption {
+	return func(r *Registry) {
+		r.audit = sink
+	}
+}
+
+// WithRegistryCatalog 设置配置中没有 messages 时使用的消息目录
+func WithRegistryCatalog(catalog *Catalog) RegistryOption {
+	return func(r *Registry) {
+		r.catalog = catalog
+	}
+}
+
+// registryState 一次加载的不可变快照
+type registryState struct {
+	hash     string
+	channels map[string]Notification
+	routes   map[string][]string
+}
+
+// Registry 从 Apollo 加载的命名渠道注册表，配置变更时热更新，
+// 更新后的 webhook/密钥对之后的发送立即生效，无需重新部署
+type Registry struct {
+	source    ConfigSource
+	namespace string
+	audit     AuditSink
+	catalog   *Catalog
+	newFn     func(cfg NotificationConfig) (Notification, error)
+
+	mu    sync.Mutex // 串行化 reload
+	state atomic.Pointer[registryState]
+}
+
+// FromApollo 从 namespace 加载渠道配置并监听变更，初次加载失败时返回错误；
+// 之后的变更如果配置无效，保留上一份可用配置并记录日志
+func FromApollo(client ConfigSource, namespace string, opts ...RegistryOption) (*Registry, error) {
+	r := newRegistry(client, namespace, opts...)
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	client.AddChangeListener(r)
+	return r, nil
+}
+
+func newRegistry(source ConfigSource, namespace string, opts ...RegistryOption) *Registry {
+	r := &Registry{
+		source:    source,
+		namespace: namespace,
+		newFn:     NewNotification,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Reload 重新读取命名空间，内容未变化时跳过
+func (r *Registry) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	content, err := r.source.GetContent(r.namespace)
+	if err != nil {
+		return fmt.Errorf("notify: load registry %s failed: %w", r.namespace, err)
+	}
+	hash := ContentHash(content)
+	if cur := r.state.Load(); cur != nil && cur.hash == hash {
+		return nil
+	}
+
+	var cfg RegistryConfig
+	if err := conf.LoadFromJsonBytes([]byte(content), &cfg); err != nil {
+		return fmt.Errorf("notify: parse registry %s failed: %w", r.namespace, err)
+	}
+
+	st, err := r.build(cfg)
+	if err != nil {
+		return fmt.Errorf("notify: registry %s: %w", r.namespace, err)
+	}
+	st.hash = hash
+	r.state.Store(st)
+	return nil
+}
+
+func (r *Registry) build(cfg RegistryConfig) (*registryState, error) {
+	st := &registryState{
+		channels: make(map[string]Notification, len(cfg.Channels)),
+		routes:   cfg.Routes,
+	}
+	catalog := r.catalog
+	if len(cfg.Messages) > 0 {
+		var err error
+		if catalog, err = newCatalogFrom(cfg.Messages); err != nil {
+			return nil, err
+		}
+	}
+	for name, ch := range cfg.Channels {
+		n, err := r.newFn(NotificationConfig{
+			Type:       ch.Type,
+			Config:     Config{Webhook: ch.Webhook, Secret: ch.Secret, VerifyText: ch.VerifyText},
+			Escalation: ch.Escalation,
+			Audit:      r.audit,
+			Locale:     ch.Locale,
+			Catalog:    catalog,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("channel %s: %w", name, err)
+		}
+		st.channels[name] = n
+	}
+	for route, names := range cfg.Routes {
+		for _, name := range names {
+			if _, ok := st.channels[name]; !ok {
+				return nil, fmt.Errorf("route %s: %w: %s", route, ErrChannelNotFound, name)
+			}
+		}
+	}
+	return st, nil
+}
+
+// OnChange 实现 storage.ChangeListener
+func (r *Registry) OnChange(event *storage.ChangeEvent) {
+	if event == nil || event.Namespace != r.namespace {
+		return
+	}
+	if err := r.Reload(); err != nil {
+		logx.Errorf("notify registry reload failed, keep previous config: %v", err)
+	}
+}
+
+// OnNewestChange 实现 storage.ChangeListener
+func (r *Registry) OnNewestChange(*storage.FullChangeEvent) {}
+
+// Channels 返回当前已加载的渠道名（已排序）
+func (r *Registry) Channels() []string {
+	st := r.state.Load()
+	names := make([]string, 0, len(st.channels))
+	for name := range st.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Channel 返回命名渠道，每次发送时按最新配置解析，可长期持有
+func (r *Registry) Channel(name string) Notification {
+	return &registryChannel{r: r, name: name}
+}
+
+// Route 返回路由，发送到路由下的所有渠道，渠道错误合并返回
+func (r *Registry) Route(name string) Notification {
+	return &registryRoute{r: r, name: name}
+}
+
+func (r *Registry) lookup(name string) (Notification, error) {
+	if n, ok := r.state.Load().channels[name]; ok {
+		return n, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrChannelNotFound, name)
+}
+
+type registryChannel struct {
+	r    *Registry
+	name string
+}
+
+// SendText 发送文本消息
+func (c *registryChannel) SendText(ctx context.Context, content string, opts ...Option) error {
+	n, err := c.r.lookup(c.name)
+	if err != nil {
+		return err
+	}
+	return n.SendText(ctx, content, opts...)
+}
+
+// SendCard 发送卡片消息
+func (c *registryChannel) SendCard(ctx context.Context, title, content string, opts ...Option) error {
+	n, err := c.r.lookup(c.name)
+	if err != nil {
+		return err
+	}
+	return n.SendCard(ctx, title, content, opts...)
+}
+
+// VerifyConfig 按最新配置校验渠道
+func (c *registryChannel) VerifyConfig(ctx context.Context) error {
+	n, err := c.r.lookup(c.name)
+	if err != nil {
+		return err
+	}
+	return VerifyConfig(ctx, n)
+}
+
+type registryRoute struct {
+	r    *Registry
+	name string
+}
+
+// SendText 发送文本消息
+func (rt *registryRoute) SendText(ctx context.Context, content string, opts ...Option) error {
+	return rt.each(func(n Notification) error {
+		return n.SendText(ctx, content, opts...)
+	})
+}
+
+// SendCard 发送卡片消息
+func (rt *registryRoute) SendCard(ctx context.Context, title, content string, opts ...Option) error {
+	return rt.each(func(n Notification) error {
+		return n.SendCard(ctx, title, content, opts...)
+	})
+}
+
+// VerifyConfig 校验路由下支持自检的渠道，都不支持时返回 ErrVerifyNotSupported
+func (rt *registryRoute) VerifyConfig(ctx context.Context) error {
+	supported := false
+	err := rt.each(func(n Notification) error {
+		err := VerifyConfig(ctx, n)
+		if errors.Is(err, ErrVerifyNotSupported) {
+			return nil
+		}
+		supported = true
+		return err
+	})
+	if err == nil && !supported {
+		return ErrVerifyNotSupported
+	}
+	return err
+}
+
+func (rt *registryRoute) each(fn func(n Notification) error) error {
+	st := rt.r.state.Load()
+	names, ok := st.routes[rt.name]
+	if !ok {
+		return fmt.Errorf("%w: route %s", ErrChannelNotFound, rt.name)
+	}
+
+	var errs []error
+	for _, name := range names {
+		if err := fn(st.channels[name]); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/apolloconfig/agollo/v4/storage"
+)
+
+type fakeSource struct {
+	mu        sync.Mutex
+	content   string
+	listeners []storage.ChangeListener
+}
+
+func (f *fakeSource) GetContent(string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.content, nil
+}
+
+func (f *fakeSource) AddChangeListener(l storage.ChangeListener) {
+	f.listeners = append(f.listeners, l)
+}
+
+func (f *fakeSource) publish(namespace, content string) {
+	f.mu.Lock()
+	f.content = content
+	f.mu.Unlock()
+
+	event := &storage.ChangeEvent{}
+	event.Namespace = namespace
+	for _, l := range f.listeners {
+		l.OnChange(event)
+	}
+}
+
+// recordingNotification 记录发送时使用的 webhook
+type recordingNotification struct {
+	webhook string
+	sent    *[]string
+	err     error
+}
+
+func (n *recordingNotification) SendText(_ context.Context, content string, _ ...Option) error {
+	*n.sent = append(*n.sent, n.webhook+":"+content)
+	return n.err
+}
+
+func (n *recordingNotification) SendCard(ctx context.Context, title, content string, opts ...Option) error {
+	return n.SendText(ctx, title+"/"+content, opts...)
+}
+
+func newTestRegistry(t *testing.T, src *fakeSource, sent *[]string) *Registry {
+	t.Helper()
+	r := newRegistry(src, "notify.json")
+	r.newFn = func(cfg NotificationConfig) (Notification, error) {
+		if cfg.Config.Webhook == "" {
+			return nil, errors.New("webhook is empty")
+		}
+		var err error
+		if strings.Contains(cfg.Config.Webhook, "broken") {
+			err = errors.New("send failed")
+		}
+		return &recordingNotification{webhook: cfg.Config.Webhook, sent: sent, err: err}, nil
+	}
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	src.AddChangeListener(r)
+	return r
+}
+
+func TestRegistry_HotReload(t *testing.T) {
+	src := &fakeSource{content: `{
+		"channels": {
+			"ops": {"type": "dingtalk", "webhook": "hook-v1", "secret": "s1"},
+			"dev": {"type": "feishu", "webhook": "hook-dev"}
+		},
+		"routes": {"payment": ["ops", "dev"]}
+	}`}
+	var sent []string
+	r := newTestRegistry(t, src, &sent)
+
+	if got := strings.Join(r.Channels(), ","); got != "dev,ops" {
+		t.Fatalf("Channels() = %s", got)
+	}
+
+	ops := r.Channel("ops")
+	_ = ops.SendText(context.Background(), "a")
+
+	// 轮换 token 后，已持有的渠道立即使用新配置
+	src.publish("notify.json", `{"channels": {"ops": {"type": "dingtalk", "webhook": "hook-v2"}}}`)
+	_ = ops.SendText(context.Background(), "b")
+
+	// 无效配置不覆盖当前配置
+	src.publish("notify.json", `{"channels": {"ops": {"type": "dingtalk"}}}`)
+	_ = ops.SendText(context.Background(), "c")
+
+	// 其他命名空间的变更被忽略
+	src.publish("other", `{"channels": {}}`)
+	_ = ops.SendText(context.Background(), "d")
+
+	want := []string{"hook-v1:a", "hook-v2:b", "hook-v2:c", "hook-v2:d"}
+	if strings.Join(sent, ",") != strings.Join(want, ",") {
+		t.Fatalf("sent = %v, want %v", sent, want)
+	}
+
+	if err := r.Channel("dev").SendText(context.Background(), "x"); !errors.Is(err, ErrChannelNotFound) {
+		t.Fatalf("removed channel error = %v, want ErrChannelNotFound", err)
+	}
+}
+
+func TestRegistry_Route(t *testing.T) {
+	src := &fakeSource{content: `{
+		"channels": {
+			"ops": {"type": "dingtalk", "webhook": "hook-ops"},
+			"bad": {"type": "feishu", "webhook": "hook-broken"}
+		},
+		"routes": {"payment": ["bad", "ops"]}
+	}`}
+	var sent []string
+	r := newTestRegistry(t, src, &sent)
+
+	err := r.Route("payment").SendCard(context.Background(), "t", "c")
+	if err == nil || !strings.Contains(err.Error(), "bad: send failed") {
+		t.Fatalf("Route().SendCard() error = %v", err)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("a failing channel must not stop the route, sent = %v", sent)
+	}
+
+	if err := r.Route("missing").SendText(context.Background(), "x"); !errors.Is(err, ErrChannelNotFound) {
+		t.Fatalf("missing route error = %v", err)
+	}
+}
+
+func TestRegistry_InvalidConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not json", `channels`},
+		{"route to unknown channel", `{"channels": {"ops": {"type": "dingtalk", "webhook": "h"}}, "routes": {"p": ["nope"]}}`},
+		{"unsupported type", `{"channels": {"ops": {"type": "pager"}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromApollo(&fakeSource{content: tt.content}, "notify.json"); err == nil {
+				t.Fatal("FromApollo() expected error")
+			}
+		})
+	}
+}
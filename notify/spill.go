@@ -0,0 +1,296 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const (
+	defaultSpillMaxBytes = 10 << 20
+	defaultSpillAttempts = 3
+	defaultSpillBackoff  = time.Second
+	// 单次回放的超时
+	spillReplayTimeout = 2 * time.Minute
+)
+
+// ErrSpilled 多次发送失败，消息已写入本地溢出文件，恢复后会自动重发
+var ErrSpilled = errors.New("notify: delivery failed, message spilled to disk")
+
+// SpillConfig 发送失败时落盘重发的配置
+type SpillConfig struct {
+	Path     string        // 溢出文件路径（JSON Lines），为空时不启用
+	MaxBytes int64         `json:",optional"` // 文件大小上限，超出时丢弃最旧的消息，默认 10MB
+	Attempts int           `json:",optional"` // 落盘前的发送次数，默认 3
+	Backoff  time.Duration `json:",optional"` // 重试间隔，按次数递增，默认 1s
+}
+
+// spillEntry 溢出文件中的一条消息
+type spillEntry struct {
+	Seq       int64     `json:"seq"`
+	Type      string    `json:"type"` // text / card
+	Title     string    `json:"title,omitempty"`
+	Content   string    `json:"content"`
+	AtUsers   []string  `json:"at_users,omitempty"`
+	Severity  *Severity `json:"severity,omitempty"` // 未调用 WithSeverity 时为空，回放时也不设置
+	SpilledAt time.Time `json:"spilled_at"`
+}
+
+// SpillNotification 发送失败的消息落盘，网络恢复后按顺序重发，
+// 避免网络分区期间的告警永久丢失
+type SpillNotification struct {
+	next Notification
+	cfg  SpillConfig
+
+	mu        sync.Mutex // 保护溢出文件
+	replaying atomic.Bool
+}
+
+// NewSpillNotification 包装通知实例：每条消息最多尝试 Attempts 次，仍失败时追加到溢出文件并返回 ErrSpilled。
+// 之后任意一次发送成功（说明网络已恢复）或调用 Replay 时重发溢出的消息；创建时文件中已有消息也会尝试重发
+func NewSpillNotification(next Notification, cfg SpillConfig) (*SpillNotification, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("notify: spill path is empty")
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultSpillMaxBytes
+	}
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = defaultSpillAttempts
+	}
+	if cfg.Backoff <= 0 {
+		cfg.Backoff = defaultSpillBackoff
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("notify: create spill dir failed: %w", err)
+	}
+
+	s := &SpillNotification{next: next, cfg: cfg}
+	if n, err := s.Pending(); err == nil && n > 0 {
+		s.replayAsync()
+	}
+	return s, nil
+}
+
+// SendText 发送文本消息
+func (s *SpillNotification) SendText(ctx context.Context, content string, opts ...Option) error {
+	return s.send(ctx, spillEntry{Type: MessageText, Content: content}, opts)
+}
+
+// SendCard 发送卡片消息
+func (s *SpillNotification) SendCard(ctx context.Context, title, content string, opts ...Option) error {
+	return s.send(ctx, spillEntry{Type: MessageCard, Title: title, Content: content}, opts)
+}
+
+func (s *SpillNotification) send(ctx context.Context, entry spillEntry, opts []Option) error {
+	var err error
+	for attempt := 1; attempt <= s.cfg.Attempts; attempt++ {
+		if err = s.deliver(ctx, entry, opts...); err == nil {
+			s.replayAsync()
+			return nil
+		}
+		if attempt == s.cfg.Attempts {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			attempt = s.cfg.Attempts
+		case <-time.After(s.cfg.Backoff * time.Duration(attempt)):
+		}
+	}
+
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	entry.AtUsers = o.AtUsers
+	if o.severitySet {
+		entry.Severity = &o.Severity
+	}
+	entry.SpilledAt = time.Now()
+	if spillErr := s.append(entry); spillErr != nil {
+		return errors.Join(err, fmt.Errorf("notify: spill failed: %w", spillErr))
+	}
+	return fmt.Errorf("%w: %w", ErrSpilled, err)
+}
+
+func (s *SpillNotification) deliver(ctx context.Context, entry spillEntry, opts ...Option) error {
+	if entry.Type == MessageCard {
+		return s.next.SendCard(ctx, entry.Title, entry.Content, opts...)
+	}
+	return s.next.SendText(ctx, entry.Content, opts...)
+}
+
+// VerifyConfig 校验被包装渠道的配置，自检消息失败不落盘
+func (s *SpillNotification) VerifyConfig(ctx context.Context) error {
+	return VerifyConfig(ctx, s.next)
+}
+
+// Pending 返回溢出文件中待重发的消息数
+func (s *SpillNotification) Pending() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries, err := s.load()
+	return len(entries), err
+}
+
+// Replay 按写入顺序重发溢出的消息，遇到第一次失败即停止（网络仍未恢复），
+// 返回成功重发的条数。可由定时任务调用，发送成功时也会自动触发
+func (s *SpillNotification) Replay(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	entries, err := s.load()
+	s.mu.Unlock()
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+
+	var (
+		sent    int
+		lastSeq int64
+		sendErr error
+	)
+	for _, entry := range entries {
+		opts := []Option{AtMobiles(entry.AtUsers)}
+		if entry.Severity != nil {
+			opts = append(opts, WithSeverity(*entry.Severity))
+		}
+		if sendErr = s.deliver(ctx, entry, opts...); sendErr != nil {
+			break
+		}
+		sent++
+		lastSeq = entry.Seq
+	}
+
+	if sent > 0 {
+		// 回放期间可能有新消息写入，只删除已重发的部分
+		s.mu.Lock()
+		err = s.rewrite(func(e spillEntry) bool { return e.Seq > lastSeq })
+		s.mu.Unlock()
+		if err != nil {
+			return sent, fmt.Errorf("notify: update spill file failed: %w", err)
+		}
+	}
+	if sendErr != nil {
+		return sent, fmt.Errorf("notify: replay stopped after %d messages: %w", sent, sendErr)
+	}
+	return sent, nil
+}
+
+// replayAsync 后台回放，同一时间只有一个回放
+func (s *SpillNotification) replayAsync() {
+	if !s.replaying.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer s.replaying.Store(false)
+		ctx, cancel := context.WithTimeout(context.Background(), spillReplayTimeout)
+		defer cancel()
+		if n, err := s.Replay(ctx); err != nil {
+			logx.Errorf("notify spill replay failed: %v", err)
+		} else if n > 0 {
+			logx.Infof("notify spill replayed %d messages", n)
+		}
+	}()
+}
+
+// append 追加一条消息，超出 MaxBytes 时丢弃最旧的消息
+func (s *SpillNotification) append(entry spillEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	entry.Seq = 1
+	if len(entries) > 0 {
+		entry.Seq = entries[len(entries)-1].Seq + 1
+	}
+	return s.write(append(entries, entry))
+}
+
+// rewrite 保留 keep 返回 true 的消息
+func (s *SpillNotification) rewrite(keep func(spillEntry) bool) error {
+	entries, err := s.load()
+	if err != nil {
+		return err
+	}
+	kept := entries[:0]
+	for _, e := range entries {
+		if keep(e) {
+			kept = append(kept, e)
+		}
+	}
+	return s.write(kept)
+}
+
+// load 读取溢出文件，无法解析的行跳过
+func (s *SpillNotification) load() ([]spillEntry, error) {
+	data, err := os.ReadFile(s.cfg.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []spillEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64<<10), int(s.cfg.MaxBytes))
+	for scanner.Scan() {
+		var e spillEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			logx.Errorf("notify spill: skip corrupt line: %v", err)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// write 原子替换溢出文件，从最旧的消息开始丢弃直到不超过 MaxBytes
+func (s *SpillNotification) write(entries []spillEntry) error {
+	lines := make([][]byte, 0, len(entries))
+	var size int64
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		line = append(line, '\n')
+		lines = append(lines, line)
+		size += int64(len(line))
+	}
+	dropped := 0
+	for size > s.cfg.MaxBytes && len(lines) > 0 {
+		size -= int64(len(lines[0]))
+		lines = lines[1:]
+		dropped++
+	}
+	if dropped > 0 {
+		logx.Errorf("notify spill file %s is full, dropped %d oldest messages", s.cfg.Path, dropped)
+	}
+
+	if len(lines) == 0 {
+		if err := os.Remove(s.cfg.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	tmp := s.cfg.Path + ".tmp"
+	if err := os.WriteFile(tmp, bytes.Join(lines, nil), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.cfg.Path)
+}
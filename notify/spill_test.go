@@ -0,0 +1,254 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyNotification 在 down 为 true 时发送失败，记录成功发送的内容
+type flakyNotification struct {
+	mu   sync.Mutex
+	down bool
+	sent []string
+}
+
+func (f *flakyNotification) setDown(down bool) {
+	f.mu.Lock()
+	f.down = down
+	f.mu.Unlock()
+}
+
+func (f *flakyNotification) messages() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent...)
+}
+
+func (f *flakyNotification) SendText(ctx context.Context, content string, opts ...Option) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.down {
+		return errors.New("webhook unreachable")
+	}
+	f.sent = append(f.sent, content)
+	return nil
+}
+
+func (f *flakyNotification) SendCard(ctx context.Context, title, content string, opts ...Option) error {
+	return f.SendText(ctx, title+":"+content, opts...)
+}
+
+func newTestSpill(t *testing.T, next Notification, maxBytes int64) *SpillNotification {
+	t.Helper()
+	s, err := NewSpillNotification(next, SpillConfig{
+		Path:     filepath.Join(t.TempDir(), "spill", "notify.jsonl"),
+		MaxBytes: maxBytes,
+		Attempts: 2,
+		Backoff:  time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func waitPending(t *testing.T, s *SpillNotification, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		n, err := s.Pending()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n == want && !s.replaying.Load() {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pending = %d, want %d", n, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSpillNotification(t *testing.T) {
+	next := &flakyNotification{down: true}
+	s := newTestSpill(t, next, 0)
+	ctx := context.Background()
+
+	if err := s.SendText(ctx, "a"); !errors.Is(err, ErrSpilled) {
+		t.Fatalf("SendText err = %v, want ErrSpilled", err)
+	}
+	if err := s.SendCard(ctx, "t", "b", WithSeverity(SeverityCritical)); !errors.Is(err, ErrSpilled) {
+		t.Fatalf("SendCard err = %v, want ErrSpilled", err)
+	}
+	waitPending(t, s, 2)
+
+	// 恢复后第一次成功发送触发回放
+	next.setDown(false)
+	if err := s.SendText(ctx, "c"); err != nil {
+		t.Fatal(err)
+	}
+	waitPending(t, s, 0)
+
+	got := strings.Join(next.messages(), ",")
+	if got != "c,a,t:b" {
+		t.Errorf("sent = %s, want c,a,t:b", got)
+	}
+	if _, err := os.Stat(s.cfg.Path); !os.IsNotExist(err) {
+		t.Errorf("spill file still exists after replay: %v", err)
+	}
+}
+
+func TestSpillNotificationBound(t *testing.T) {
+	next := &flakyNotification{down: true}
+	s := newTestSpill(t, next, 400)
+	ctx := context.Background()
+
+	for i := 0; i < 10; i++ {
+		_ = s.SendText(ctx, strings.Repeat("x", 50)+string(rune('0'+i)))
+	}
+	info, err := os.Stat(s.cfg.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() > 400 {
+		t.Errorf("spill file size = %d, want <= 400", info.Size())
+	}
+
+	next.setDown(false)
+	if _, err := s.Replay(ctx); err != nil {
+		t.Fatal(err)
+	}
+	sent := next.messages()
+	if len(sent) == 0 || len(sent) == 10 {
+		t.Fatalf("replayed %d messages, want the newest few", len(sent))
+	}
+	if last := sent[len(sent)-1]; !strings.HasSuffix(last, "9") {
+		t.Errorf("newest message dropped, last replayed = %s", last)
+	}
+}
+
+func TestSpillReplayStopsOnFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		failAt   int
+		wantSent int
+		wantLeft int
+	}{
+		{name: "all sent", failAt: -1, wantSent: 3, wantLeft: 0},
+		{name: "first fails", failAt: 0, wantSent: 0, wantLeft: 3},
+		{name: "middle fails", failAt: 2, wantSent: 2, wantLeft: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &flakyNotification{down: true}
+			s := newTestSpill(t, next, 0)
+			ctx := context.Background()
+			for _, msg := range []string{"a", "b", "c"} {
+				_ = s.SendText(ctx, msg)
+			}
+
+			calls := 0
+			s.next = &countingNotification{next: next, before: func() {
+				next.setDown(calls == tt.failAt)
+				calls++
+			}}
+			sent, err := s.Replay(ctx)
+			if sent != tt.wantSent {
+				t.Errorf("sent = %d, want %d", sent, tt.wantSent)
+			}
+			if (err != nil) != (tt.wantLeft > 0) {
+				t.Errorf("err = %v", err)
+			}
+			waitPending(t, s, tt.wantLeft)
+		})
+	}
+}
+
+func TestSpillKeepsEntriesAddedDuringReplay(t *testing.T) {
+	next := &flakyNotification{down: true}
+	s := newTestSpill(t, next, 0)
+	ctx := context.Background()
+	_ = s.SendText(ctx, "a")
+
+	// 回放第一条时写入一条新的溢出消息，回放结束后应保留
+	s.next = &countingNotification{next: next, before: func() {
+		next.setDown(false)
+		if err := s.append(spillEntry{Type: MessageText, Content: "late"}); err != nil {
+			t.Error(err)
+		}
+	}}
+	if sent, err := s.Replay(ctx); err != nil || sent != 1 {
+		t.Fatalf("Replay = %d, %v", sent, err)
+	}
+	waitPending(t, s, 1)
+}
+
+type countingNotification struct {
+	next   Notification
+	before func()
+}
+
+func (c *countingNotification) SendText(ctx context.Context, content string, opts ...Option) error {
+	c.before()
+	return c.next.SendText(ctx, content, opts...)
+}
+
+func (c *countingNotification) SendCard(ctx context.Context, title, content string, opts ...Option) error {
+	c.before()
+	return c.next.SendCard(ctx, title, content, opts...)
+}
+
+// outageSender 在 down 为 true 时发送失败
+type outageSender struct {
+	fakeSender
+	down bool
+}
+
+func (s *outageSender) send(ctx context.Context, phone, content string) error {
+	if s.down {
+		return errors.New("provider unreachable")
+	}
+	return s.fakeSender.send(ctx, phone, content)
+}
+
+func TestSpillReplayKeepsMissingSeverity(t *testing.T) {
+	cases := []struct {
+		name string
+		opts []Option
+		want int
+	}{
+		{name: "no severity escalates", want: 1},
+		{name: "explicit critical", opts: []Option{WithSeverity(SeverityCritical)}, want: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			sender := &outageSender{down: true}
+			esc, err := newEscalationNotification(EscalationConfig{Phones: []string{"13800000000"}}, sender)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := newTestSpill(t, esc, 0)
+
+			if err := s.SendText(context.Background(), "db down", tc.opts...); !errors.Is(err, ErrSpilled) {
+				t.Fatalf("SendText() error = %v, want ErrSpilled", err)
+			}
+
+			sender.down = false
+			n, err := s.Replay(context.Background())
+			if err != nil || n != 1 {
+				t.Fatalf("Replay() = %d, %v", n, err)
+			}
+			if len(sender.sent) != tc.want {
+				t.Fatalf("escalated %d times after replay, want %d", len(sender.sent), tc.want)
+			}
+		})
+	}
+}
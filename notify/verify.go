@@ -0,0 +1,191 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const (
+	// DefaultVerifyText 自检消息内容
+	DefaultVerifyText = "通知配置自检，请忽略"
+	// maxClockSkew 本机与机器人服务端的最大时间差，签名允许 1 小时误差，超过 5 分钟说明时间同步已异常
+	maxClockSkew = 5 * time.Minute
+
+	defaultMonitorInterval = time.Hour
+	defaultMonitorTimeout  = 10 * time.Second
+)
+
+var (
+	// ErrVerifyNotSupported 渠道不支持配置自检
+	ErrVerifyNotSupported = errors.New("notify: channel does not support config verification")
+	// ErrClockSkew 本机时间与机器人服务端相差过大，签名会失效
+	ErrClockSkew = errors.New("notify: clock skew with webhook server")
+)
+
+// Verifier 可自检配置的通知渠道
+type Verifier interface {
+	// VerifyConfig 发送一条自检消息，校验 webhook、签名和本机时间
+	VerifyConfig(ctx context.Context) error
+}
+
+// VerifyConfig 校验 n 的配置，n 未实现 Verifier 时返回 ErrVerifyNotSupported
+func VerifyConfig(ctx context.Context, n Notification) error {
+	v, ok := n.(Verifier)
+	if !ok {
+		return ErrVerifyNotSupported
+	}
+	return v.VerifyConfig(ctx)
+}
+
+// checkClockSkew 对比响应的 Date 头与本机时间
+func checkClockSkew(resp *http.Response, now time.Time) error {
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return nil
+	}
+	if skew := now.Sub(date); skew > maxClockSkew || skew < -maxClockSkew {
+		return fmt.Errorf("%w: local %s, server %s", ErrClockSkew, now.UTC().Format(time.RFC3339), date.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// MonitorConfig 渠道健康检查配置
+type MonitorConfig struct {
+	Interval time.Duration `json:",optional"` // 检查间隔，默认 1 小时，每次检查每个渠道会收到一条自检消息
+	Timeout  time.Duration `json:",optional"` // 单个渠道的检查超时，默认 10 秒
+	// Alert 备用通道，渠道失效和恢复时通知，不能是被检查的渠道
+	Alert Notification `json:"-"`
+}
+
+// Monitor 定期自检通知渠道，及时发现被撤销的机器人 token、失效的签名等静默故障
+type Monitor struct {
+	channels map[string]Notification
+	cfg      MonitorConfig
+
+	mu     sync.Mutex
+	broken map[string]error
+
+	started  atomic.Bool // Start 或 Stop 已调用，done 由 Start 的 goroutine 或 Stop 关闭
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewMonitor 创建健康检查，channels 为渠道名到渠道的映射，不支持自检的渠道会被跳过
+func NewMonitor(channels map[string]Notification, cfg MonitorConfig) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultMonitorInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultMonitorTimeout
+	}
+	return &Monitor{
+		channels: channels,
+		cfg:      cfg,
+		broken:   make(map[string]error),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start 立即检查一次，之后每隔 Interval 检查，重复调用或 Stop 之后调用不生效
+func (m *Monitor) Start() {
+	if !m.started.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			m.Check(context.Background())
+			select {
+			case <-ticker.C:
+			case <-m.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止检查并等待进行中的检查结束，未调用 Start 时直接返回
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+	if m.started.CompareAndSwap(false, true) {
+		close(m.done)
+	}
+	<-m.done
+}
+
+// Check 检查所有渠道，返回失效的渠道及原因。
+// 渠道失效或恢复时通过 Alert 通知，持续失效不重复通知
+func (m *Monitor) Check(ctx context.Context) map[string]error {
+	names := make([]string, 0, len(m.channels))
+	for name := range m.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		checkCtx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+		err := VerifyConfig(checkCtx, m.channels[name])
+		cancel()
+		if errors.Is(err, ErrVerifyNotSupported) {
+			continue
+		}
+		m.update(ctx, name, err)
+	}
+	return m.Broken()
+}
+
+// Broken 返回最近一次检查失效的渠道及原因
+func (m *Monitor) Broken() map[string]error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	broken := make(map[string]error, len(m.broken))
+	for name, err := range m.broken {
+		broken[name] = err
+	}
+	return broken
+}
+
+func (m *Monitor) update(ctx context.Context, name string, err error) {
+	m.mu.Lock()
+	_, wasBroken := m.broken[name]
+	if err != nil {
+		m.broken[name] = err
+	} else {
+		delete(m.broken, name)
+	}
+	m.mu.Unlock()
+
+	switch {
+	case err != nil && !wasBroken:
+		logx.Errorf("notify channel %s verify failed: %v", name, err)
+		m.alert(ctx, "通知渠道失效", fmt.Sprintf("渠道 %s 自检失败，告警可能无法送达\n\n%v", name, err))
+	case err == nil && wasBroken:
+		logx.Infof("notify channel %s recovered", name)
+		m.alert(ctx, "通知渠道恢复", fmt.Sprintf("渠道 %s 自检恢复正常", name))
+	}
+}
+
+func (m *Monitor) alert(ctx context.Context, title, content string) {
+	if m.cfg.Alert == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+	if err := m.cfg.Alert.SendCard(ctx, title, content); err != nil {
+		logx.Errorf("notify monitor alert failed: %v", err)
+	}
+}
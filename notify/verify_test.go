@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// robotServer 模拟机器人 webhook，返回 body，date 非零时设置 Date 头
+func robotServer(t *testing.T, body string, date time.Time, got *string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		data, _ := json.Marshal(payload)
+		*got = string(data)
+		if !date.IsZero() {
+			w.Header().Set("Date", date.UTC().Format(http.TimeFormat))
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestVerifyConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		typ     NotificationType
+		body    string
+		date    time.Time
+		wantErr error
+		errText string
+	}{
+		{name: "dingtalk ok", typ: DingTalk, body: `{"errcode":0,"errmsg":"ok"}`, date: time.Now()},
+		{name: "dingtalk revoked token", typ: DingTalk, body: `{"errcode":300001,"errmsg":"token is not exist"}`, errText: "300001"},
+		{name: "dingtalk clock skew", typ: DingTalk, body: `{"errcode":0,"errmsg":"ok"}`, date: time.Now().Add(-time.Hour), wantErr: ErrClockSkew},
+		{name: "feishu ok", typ: Feishu, body: `{"code":0,"msg":"success"}`},
+		{name: "feishu bad sign", typ: Feishu, body: `{"code":19021,"msg":"sign match fail"}`, date: time.Now().Add(2 * time.Hour), wantErr: ErrClockSkew, errText: "19021"},
+		{name: "feishu legacy response", typ: Feishu, body: `{"StatusCode":19001,"StatusMessage":"param invalid"}`, errText: "param invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payload string
+			srv := robotServer(t, tt.body, tt.date, &payload)
+			n, err := NewNotification(NotificationConfig{
+				Type:   tt.typ,
+				Config: Config{Webhook: srv.URL + "/send?access_token=t", Secret: "s", VerifyText: "告警 自检"},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = VerifyConfig(context.Background(), n)
+			if tt.wantErr == nil && tt.errText == "" {
+				if err != nil {
+					t.Fatalf("VerifyConfig() error = %v", err)
+				}
+			} else {
+				if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+					t.Errorf("VerifyConfig() error = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil || !strings.Contains(err.Error(), tt.errText) {
+					t.Errorf("VerifyConfig() error = %v, want %q", err, tt.errText)
+				}
+			}
+			if !strings.Contains(payload, "告警 自检") {
+				t.Errorf("payload = %s, want verify text", payload)
+			}
+		})
+	}
+}
+
+func TestVerifyConfigNotSupported(t *testing.T) {
+	if err := VerifyConfig(context.Background(), &flakyNotification{}); !errors.Is(err, ErrVerifyNotSupported) {
+		t.Fatalf("VerifyConfig() error = %v, want ErrVerifyNotSupported", err)
+	}
+}
+
+// verifyingNotification 自检结果由 err 决定
+type verifyingNotification struct {
+	flakyNotification
+	mu       sync.Mutex
+	err      error
+	verified int
+}
+
+func (v *verifyingNotification) setErr(err error) {
+	v.mu.Lock()
+	v.err = err
+	v.mu.Unlock()
+}
+
+func (v *verifyingNotification) VerifyConfig(context.Context) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.verified++
+	return v.err
+}
+
+func TestMonitor(t *testing.T) {
+	ops := &verifyingNotification{}
+	alert := &flakyNotification{}
+	m := NewMonitor(map[string]Notification{
+		"ops":    NewAuditedNotification(ops, DingTalk, AuditSinkFunc(func(context.Context, AuditRecord) error { return nil })),
+		"oncall": &flakyNotification{}, // 不支持自检，跳过
+	}, MonitorConfig{Alert: alert})
+	ctx := context.Background()
+
+	if broken := m.Check(ctx); len(broken) != 0 {
+		t.Fatalf("Check() = %v, want none broken", broken)
+	}
+
+	revoked := errors.New("token is not exist")
+	ops.setErr(revoked)
+	m.Check(ctx)
+	broken := m.Check(ctx)
+	if !errors.Is(broken["ops"], revoked) || len(broken) != 1 {
+		t.Fatalf("Check() = %v, want ops broken", broken)
+	}
+
+	ops.setErr(nil)
+	if broken := m.Check(ctx); len(broken) != 0 {
+		t.Fatalf("Check() after recovery = %v", broken)
+	}
+
+	// 失效和恢复各通知一次，持续失效不重复通知
+	msgs := alert.messages()
+	if len(msgs) != 2 || !strings.Contains(msgs[0], "ops") || !strings.Contains(msgs[0], revoked.Error()) || !strings.HasPrefix(msgs[1], "通知渠道恢复") {
+		t.Fatalf("alerts = %q", msgs)
+	}
+	if ops.verified != 4 {
+		t.Errorf("verified %d times, want 4", ops.verified)
+	}
+}
+
+func TestMonitorStartStop(t *testing.T) {
+	ops := &verifyingNotification{err: errors.New("down")}
+	m := NewMonitor(map[string]Notification{"ops": ops}, MonitorConfig{Interval: time.Hour})
+	m.Start()
+	deadline := time.Now().Add(time.Second)
+	for len(m.Broken()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	m.Stop()
+	if _, ok := m.Broken()["ops"]; !ok {
+		t.Fatal("Start() did not check immediately")
+	}
+}
+
+func TestMonitorStopWithoutStart(t *testing.T) {
+	ops := &verifyingNotification{}
+	m := NewMonitor(map[string]Notification{"ops": ops}, MonitorConfig{Interval: time.Hour})
+
+	stopped := make(chan struct{})
+	go func() {
+		m.Stop()
+		m.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Stop() without Start() blocked")
+	}
+
+	// Start after Stop does nothing
+	m.Start()
+	m.Stop()
+}
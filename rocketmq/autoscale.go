@@ -0,0 +1,139 @@
+package rocketmq
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const (
+	defaultScaleInterval = 30 * time.Second
+	// a window with at least this share of full receives means there is a backlog
+	scaleUpFullness = 0.8
+	// a window with at most this share of full receives means the workers idle
+	scaleDownFullness = 0.2
+)
+
+// scaler collects receive fullness and processing latency of the workers and
+// decides the worker count for the next window
+type scaler struct {
+	min        int
+	max        int
+	maxLatency time.Duration
+
+	receives  atomic.Int64
+	full      atomic.Int64
+	processed atomic.Int64
+	latency   atomic.Int64 // total processing nanoseconds
+}
+
+func newScaler(conf *ConsumerConfig) *scaler {
+	if conf.MaxWorkers <= conf.Workers {
+		return nil
+	}
+	return &scaler{
+		min:        conf.Workers,
+		max:        conf.MaxWorkers,
+		maxLatency: conf.MaxProcessLatency,
+	}
+}
+
+// observeReceive records a Receive of up to batch messages that returned n
+// messages, 0 for MESSAGE_NOT_FOUND
+func (s *scaler) observeReceive(n int, batch int32) {
+	if s == nil {
+		return
+	}
+	s.receives.Add(1)
+	if n >= int(batch) {
+		s.full.Add(1)
+	}
+}
+
+func (s *scaler) observeProcess(d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.processed.Add(1)
+	s.latency.Add(int64(d))
+}
+
+// next returns the worker count for the next window and resets the counters.
+// It grows by half while the receives come back full, shrinks by one when
+// they come back mostly empty or the processing is slower than maxLatency,
+// since more workers won't help a saturated downstream
+func (s *scaler) next(current int) int {
+	receives, full := s.receives.Swap(0), s.full.Swap(0)
+	processed, latency := s.processed.Swap(0), s.latency.Swap(0)
+
+	target := current
+	switch {
+	case s.maxLatency > 0 && processed > 0 && time.Duration(latency/processed) > s.maxLatency:
+		target = current - 1
+	case receives == 0:
+		// all workers are busy with long batches, no signal
+	case float64(full)/float64(receives) >= scaleUpFullness:
+		target = current + max(1, current/2)
+	case float64(full)/float64(receives) <= scaleDownFullness:
+		target = current - 1
+	}
+	return min(max(target, s.min), s.max)
+}
+
+// autoscale adjusts the workers every ScaleInterval until the consumer stops
+func (c *Consumer[T]) autoscale() {
+	interval := c.conf.ScaleInterval
+	if interval <= 0 {
+		interval = defaultScaleInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			current := c.Workers()
+			target := c.scaler.next(current)
+			if target == current {
+				continue
+			}
+			logx.Infof("rocketmq consumer %s/%s scales workers from %d to %d",
+				c.conf.Topic, c.conf.ConsumerGroup, current, target)
+			c.scaleTo(target)
+		}
+	}
+}
+
+// scaleTo starts or stops workers until target are running. A stopped worker
+// finishes the messages it already received
+func (c *Consumer[T]) scaleTo(target int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.workers) < target {
+		quit := make(chan struct{})
+		c.workers = append(c.workers, quit)
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+			// 这个 sleep 是必要的，5.x 版本的 proxy 有 bug，导致第一次接收消息失败
+			time.Sleep(time.Millisecond * 100)
+			c.consume(quit)
+		}()
+	}
+	for len(c.workers) > target {
+		last := len(c.workers) - 1
+		close(c.workers[last])
+		c.workers = c.workers[:last]
+	}
+}
+
+// Workers returns the number of running workers
+func (c *Consumer[T]) Workers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.workers)
+}
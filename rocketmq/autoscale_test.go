@@ -0,0 +1,58 @@
+package rocketmq
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScalerNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		full     int
+		empty    int
+		latency  time.Duration
+		expected int
+	}{
+		{name: "backlog grows by half", current: 4, full: 9, empty: 1, expected: 6},
+		{name: "small pool grows by one", current: 3, full: 5, expected: 4},
+		{name: "capped at max", current: 7, full: 10, expected: 8},
+		{name: "idle shrinks", current: 4, full: 1, empty: 9, expected: 3},
+		{name: "floor at min", current: 2, empty: 10, expected: 2},
+		{name: "mixed keeps", current: 4, full: 5, empty: 5, expected: 4},
+		{name: "no receives keeps", current: 5, expected: 5},
+		{name: "slow downstream shrinks", current: 5, full: 10, latency: 2 * time.Second, expected: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScaler(&ConsumerConfig{Workers: 2, MaxWorkers: 8, MaxProcessLatency: time.Second})
+			for i := 0; i < tt.full; i++ {
+				s.observeReceive(int(maxMessageNum), maxMessageNum)
+				s.observeProcess(tt.latency)
+			}
+			for i := 0; i < tt.empty; i++ {
+				s.observeReceive(0, maxMessageNum)
+			}
+			if got := s.next(tt.current); got != tt.expected {
+				t.Errorf("next(%d) = %d, want %d", tt.current, got, tt.expected)
+			}
+			// the window is reset
+			if got := s.next(tt.current); got != tt.current {
+				t.Errorf("next(%d) after reset = %d, want %d", tt.current, got, tt.current)
+			}
+		})
+	}
+}
+
+func TestNewScalerDisabled(t *testing.T) {
+	if s := newScaler(&ConsumerConfig{Workers: 4}); s != nil {
+		t.Error("autoscaling enabled without MaxWorkers")
+	}
+	if s := newScaler(&ConsumerConfig{Workers: 4, MaxWorkers: 4}); s != nil {
+		t.Error("autoscaling enabled with MaxWorkers == Workers")
+	}
+	// a nil scaler ignores observations
+	var s *scaler
+	s.observeReceive(4, maxMessageNum)
+	s.observeProcess(time.Second)
+}
@@ -0,0 +1,161 @@
+package rocketmq
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// CodecKey is the message property naming the codec the body was encoded with
+const CodecKey = "CODEC"
+
+// Codec encodes message bodies. Register custom codecs, e.g. avro, with RegisterCodec
+type Codec interface {
+	// Name identifies the codec in ConsumerConfig.Codec and the CodecKey property
+	Name() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var (
+	JSONCodec     Codec = jsonCodec{}
+	ProtobufCodec Codec = protobufCodec{}
+	// RawCodec passes []byte and string bodies through unchanged
+	RawCodec Codec = rawCodec{}
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		JSONCodec.Name():     JSONCodec,
+		ProtobufCodec.Name(): ProtobufCodec,
+		RawCodec.Name():      RawCodec,
+	}
+)
+
+// RegisterCodec makes c available to consumers by name, replacing a codec of the same name
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[c.Name()] = c
+}
+
+func lookupCodec(name string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	c, ok := codecs[name]
+	return c, ok
+}
+
+// messageCodec picks the codec of a received message: the one named by its CodecKey
+// property, else fallback or JSON. Messages sent with the []byte Publish methods
+// carry no property and are decoded with fallback
+func messageCodec(props map[string]string, fallback Codec) (Codec, error) {
+	if fallback == nil {
+		fallback = JSONCodec
+	}
+	name, ok := props[CodecKey]
+	if !ok || name == "" {
+		return fallback, nil
+	}
+	c, ok := lookupCodec(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown codec %q", name)
+	}
+	return c, nil
+}
+
+// Publish encodes v with the codec set by WithCodec, JSON by default, and sends it
+// to topic as is. Use GetTopicName for the app prefixed topic
+func Publish[T any](ctx context.Context, p *Producer, topic Topic, v T, opts ...PublishOptionFunc) error {
+	codec := JSONCodec
+	opt := &PublishOption{}
+	for _, o := range opts {
+		o(opt)
+	}
+	if opt.codec != nil {
+		codec = opt.codec
+	}
+	body, err := codec.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encode message with %s codec: %w", codec.Name(), err)
+	}
+	return p.publish(ctx, topic, body, append(opts[:len(opts):len(opts)], WithCodec(codec))...)
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return "json" }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal keeps numbers as json.Number so large ids don't lose precision
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return "protobuf" }
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal accepts a proto.Message or, as Consumer[*pb.Msg] passes it, a pointer
+// to a possibly nil message pointer
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	if m, ok := v.(proto.Message); ok {
+		return proto.Unmarshal(data, m)
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Pointer {
+		elem := rv.Elem()
+		if elem.IsNil() {
+			elem.Set(reflect.New(elem.Type().Elem()))
+		}
+		if m, ok := elem.Interface().(proto.Message); ok {
+			return proto.Unmarshal(data, m)
+		}
+	}
+	return fmt.Errorf("%T is not a proto.Message", v)
+}
+
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return "raw" }
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	switch b := v.(type) {
+	case []byte:
+		return b, nil
+	case string:
+		return []byte(b), nil
+	}
+	return nil, fmt.Errorf("raw codec cannot encode %T", v)
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	switch b := v.(type) {
+	case *[]byte:
+		*b = bytes.Clone(data)
+	case *string:
+		*b = string(data)
+	default:
+		return fmt.Errorf("raw codec cannot decode into %T", v)
+	}
+	return nil
+}
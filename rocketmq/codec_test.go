@@ -0,0 +1,133 @@
+package rocketmq
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type fakeSendProducer struct {
+	rmq.Producer
+	sent *rmq.Message
+	err  error
+}
+
+func (f *fakeSendProducer) Send(ctx context.Context, msg *rmq.Message) ([]*rmq.SendReceipt, error) {
+	f.sent = msg
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []*rmq.SendReceipt{{MessageID: "m1"}}, nil
+}
+
+type order struct {
+	ID     json.Number `json:"id"`
+	Amount int         `json:"amount"`
+}
+
+func TestPublishCodecs(t *testing.T) {
+	tests := []struct {
+		name      string
+		publish   func(ctx context.Context, p *Producer) error
+		wantCodec string
+		decode    func(c *Consumer[any], props map[string]string, body []byte) (any, error)
+		want      any
+	}{
+		{
+			name: "json by default",
+			publish: func(ctx context.Context, p *Producer) error {
+				return Publish(ctx, p, "orders", order{ID: "9007199254740993", Amount: 3})
+			},
+			wantCodec: "json",
+			want:      order{ID: "9007199254740993", Amount: 3},
+		},
+		{
+			name: "protobuf",
+			publish: func(ctx context.Context, p *Producer) error {
+				return Publish(ctx, p, "orders", wrapperspb.String("o1"), WithCodec(ProtobufCodec))
+			},
+			wantCodec: "protobuf",
+			want:      "o1",
+		},
+		{
+			name: "raw",
+			publish: func(ctx context.Context, p *Producer) error {
+				return Publish(ctx, p, "orders", []byte("\x00\x01"), WithCodec(RawCodec))
+			},
+			wantCodec: "raw",
+			want:      "\x00\x01",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSendProducer{}
+			if err := tt.publish(context.Background(), &Producer{Producer: fake}); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			props := fake.sent.GetProperties()
+			if props[CodecKey] != tt.wantCodec {
+				t.Fatalf("codec property = %q, want %q", props[CodecKey], tt.wantCodec)
+			}
+
+			var got any
+			switch tt.wantCodec {
+			case "json":
+				got = decodeWith[order](t, props, fake.sent.Body)
+			case "protobuf":
+				got = decodeWith[*wrapperspb.StringValue](t, props, fake.sent.Body).GetValue()
+			case "raw":
+				got = string(decodeWith[[]byte](t, props, fake.sent.Body))
+			}
+			if got != tt.want {
+				t.Errorf("decoded %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// decodeWith decodes like a JSON configured Consumer[T] would
+func decodeWith[T any](t *testing.T, props map[string]string, body []byte) T {
+	t.Helper()
+	c := &Consumer[T]{codec: JSONCodec}
+	var data T
+	if err := c.decode(props, body, &data); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return data
+}
+
+func TestConsumerCodecFallback(t *testing.T) {
+	body, err := proto.Marshal(wrapperspb.Int64(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Consumer[wrapperspb.Int64Value]{codec: ProtobufCodec}
+	var data wrapperspb.Int64Value
+	if err := c.decode(nil, body, &data); err != nil {
+		t.Fatalf("decode without codec property: %v", err)
+	}
+	if data.GetValue() != 42 {
+		t.Errorf("value = %d, want 42", data.GetValue())
+	}
+
+	if err := c.decode(map[string]string{CodecKey: "avro"}, body, &data); err == nil {
+		t.Error("unknown codec property was accepted")
+	}
+}
+
+func TestPublishEncodeError(t *testing.T) {
+	fake := &fakeSendProducer{}
+	err := Publish(context.Background(), &Producer{Producer: fake}, "orders", order{}, WithCodec(ProtobufCodec))
+	if err == nil {
+		t.Fatal("non proto message was encoded")
+	}
+	if fake.sent != nil {
+		t.Error("message was sent despite the encode error")
+	}
+}
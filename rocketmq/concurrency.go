@@ -0,0 +1,91 @@
+package rocketmq
+
+import (
+	"sync"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+// maxReceiveBatch is the largest batch a single Receive may ask for
+const maxReceiveBatch int32 = 32
+
+// batchSize asks for enough messages per Receive to keep Concurrency busy
+func (c *Consumer[T]) batchSize() int32 {
+	return max(maxMessageNum, min(int32(c.conf.Concurrency), maxReceiveBatch))
+}
+
+// dispatch processes a received batch and returns once every message is done.
+// With Concurrency the messages run in parallel on the shared slots, messages
+// of one sharding key run in order on a single slot when OrderByKey is set,
+// messages of one message group when FIFO is set
+func (c *Consumer[T]) dispatch(msgs []*rmq.MessageView) {
+	ordered, key := c.conf.OrderByKey, shardingKey
+	if c.conf.FIFO {
+		ordered, key = true, messageGroup
+	}
+	groups := partition(msgs, ordered, key)
+	if c.slots == nil || len(groups) == 1 {
+		for _, group := range groups {
+			c.run(group)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, group := range groups {
+		c.slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-c.slots
+				wg.Done()
+			}()
+			c.run(group)
+		}()
+	}
+	wg.Wait()
+}
+
+// run processes the messages of one partition in order
+func (c *Consumer[T]) run(group []*rmq.MessageView) {
+	if c.conf.FIFO {
+		c.runFIFO(group)
+		return
+	}
+	for _, msg := range group {
+		c.process(msg)
+	}
+}
+
+// partition splits msgs into groups processed independently: one group per
+// message, or per sharding key in receive order when ordered is set.
+// Messages without a key never wait for each other
+func partition(msgs []*rmq.MessageView, ordered bool, key func(*rmq.MessageView) string) [][]*rmq.MessageView {
+	groups := make([][]*rmq.MessageView, 0, len(msgs))
+	index := map[string]int{}
+	for _, msg := range msgs {
+		k := ""
+		if ordered {
+			k = key(msg)
+		}
+		if k == "" {
+			groups = append(groups, []*rmq.MessageView{msg})
+			continue
+		}
+		if i, ok := index[k]; ok {
+			groups[i] = append(groups[i], msg)
+			continue
+		}
+		index[k] = len(groups)
+		groups = append(groups, []*rmq.MessageView{msg})
+	}
+	return groups
+}
+
+// shardingKey returns the key set by WithShardingKey
+func shardingKey(msg *rmq.MessageView) string {
+	if keys := msg.GetKeys(); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
@@ -0,0 +1,111 @@
+package rocketmq
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+func TestPartition(t *testing.T) {
+	msgs := make([]*rmq.MessageView, 6)
+	for i := range msgs {
+		msgs[i] = &rmq.MessageView{}
+	}
+	keys := map[*rmq.MessageView]string{msgs[0]: "a", msgs[1]: "b", msgs[2]: "a", msgs[3]: "", msgs[4]: "b", msgs[5]: ""}
+	key := func(m *rmq.MessageView) string { return keys[m] }
+
+	tests := []struct {
+		name    string
+		ordered bool
+		want    [][]int
+	}{
+		{name: "unordered", want: [][]int{{0}, {1}, {2}, {3}, {4}, {5}}},
+		{name: "ordered by key", ordered: true, want: [][]int{{0, 2}, {1, 4}, {3}, {5}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			groups := partition(msgs, tt.ordered, key)
+			if len(groups) != len(tt.want) {
+				t.Fatalf("got %d groups, want %d", len(groups), len(tt.want))
+			}
+			for i, group := range groups {
+				if len(group) != len(tt.want[i]) {
+					t.Fatalf("group %d has %d messages, want %d", i, len(group), len(tt.want[i]))
+				}
+				for j, msg := range group {
+					if msg != msgs[tt.want[i][j]] {
+						t.Errorf("group %d[%d] is not message %d", i, j, tt.want[i][j])
+					}
+				}
+			}
+		})
+	}
+}
+
+// slowHandler fails every message (the test messages have no body) and records
+// how many run at once
+type slowHandler struct {
+	inFlight, peak, done atomic.Int32
+}
+
+func (h *slowHandler) Consume(context.Context, string) error { return nil }
+
+func (h *slowHandler) ErrorHandler(context.Context, string, error) {
+	n := h.inFlight.Add(1)
+	defer h.inFlight.Add(-1)
+	for p := h.peak.Load(); n > p && !h.peak.CompareAndSwap(p, n); p = h.peak.Load() {
+	}
+	time.Sleep(20 * time.Millisecond)
+	h.done.Add(1)
+}
+
+func TestDispatchConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		wantPeak    int32
+	}{
+		{name: "sequential", concurrency: 0, wantPeak: 1},
+		{name: "bounded", concurrency: 3, wantPeak: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &slowHandler{}
+			c := &Consumer[string]{
+				conf:     &ConsumerConfig{Concurrency: tt.concurrency},
+				consumer: &fakeSimpleConsumer{},
+				handler:  h,
+				retry:    RetryPolicy{AckOnError: true}.withDefaults(),
+			}
+			if tt.concurrency > 1 {
+				c.slots = make(chan struct{}, tt.concurrency)
+			}
+
+			msgs := make([]*rmq.MessageView, 8)
+			for i := range msgs {
+				msgs[i] = &rmq.MessageView{}
+			}
+			c.dispatch(msgs)
+
+			if got := h.done.Load(); got != 8 {
+				t.Fatalf("processed %d messages, want 8", got)
+			}
+			if got := h.peak.Load(); got != tt.wantPeak {
+				t.Errorf("peak concurrency = %d, want %d", got, tt.wantPeak)
+			}
+		})
+	}
+}
+
+func TestBatchSize(t *testing.T) {
+	for concurrency, want := range map[int]int32{0: maxMessageNum, 8: 8, 100: maxReceiveBatch} {
+		c := &Consumer[string]{conf: &ConsumerConfig{Concurrency: concurrency}}
+		if got := c.batchSize(); got != want {
+			t.Errorf("batchSize(concurrency=%d) = %d, want %d", concurrency, got, want)
+		}
+	}
+}
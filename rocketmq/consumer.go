@@ -1,12 +1,10 @@
 package rocketmq
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"runtime/debug"
-	"strings"
 	"sync"
 	"time"
 
@@ -15,6 +13,7 @@ import (
 	v2 "github.com/apache/rocketmq-clients/golang/v5/protocol/v2"
 	"github.com/zeromicro/go-zero/core/logc"
 	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/service"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
@@ -33,11 +32,42 @@ var (
 
 type ConsumerConfig struct {
 	Endpoint      string              `json:"endpoint"`
-	Topic         string              `json:"topic"`
+	Topic         string              `json:"topic,optional"`
 	ConsumerGroup string              `json:"consumerGroup"`
 	Tags          []string            `json:"tags,optional"`
 	Credentials   *SessionCredentials `json:"credentials,optional"`
 	Workers       int                 `json:"workers,optional"`
+	// UnhealthyAfter marks the consumer unhealthy when no Receive succeeded for this long
+	UnhealthyAfter time.Duration `json:"unhealthyAfter,optional"`
+	// MaxConsecutiveErrors marks the consumer unhealthy after this many Receive errors in a row
+	MaxConsecutiveErrors int `json:"maxConsecutiveErrors,optional"`
+	// MaxWorkers enables autoscaling when greater than Workers: the workers grow up to
+	// MaxWorkers while receives come back full and shrink back to Workers when idle
+	MaxWorkers int `json:"maxWorkers,optional"`
+	// ScaleInterval is how often the worker count is adjusted, default 30s
+	ScaleInterval time.Duration `json:"scaleInterval,optional"`
+	// MaxProcessLatency shrinks the workers while the average processing time of a
+	// message exceeds it, e.g. when the downstream is saturated
+	MaxProcessLatency time.Duration `json:"maxProcessLatency,optional"`
+	// Concurrency processes up to this many messages in parallel across all workers,
+	// default 1 processes each received batch sequentially
+	Concurrency int `json:"concurrency,optional"`
+	// OrderByKey keeps messages with the same sharding key (see WithShardingKey)
+	// in receive order when Concurrency is greater than 1
+	OrderByKey bool `json:"orderByKey,optional"`
+	// FIFO must be set when the consumer group is FIFO (ordered): the messages of one
+	// message group (see WithMessageGroup) are processed strictly in order, one at a
+	// time across all workers, and a message that is not acked holds back the rest
+	// of its group until it is redelivered. Different groups still use Concurrency
+	FIFO bool `json:"fifo,optional"`
+	// Retry decides what happens to messages that fail to be consumed
+	Retry RetryPolicy `json:"retry,optional"`
+	// Codec decodes messages without a CodecKey property: json (default), protobuf,
+	// raw or the name of a codec added with RegisterCodec
+	Codec string `json:"codec,optional"`
+	// Subscriptions are further topics consumed over the same connection, route
+	// them to their own handler with Handle
+	Subscriptions []Subscription `json:"subscriptions,optional"`
 }
 type SessionCredentials struct {
 	AccessKey    string `json:"accessKey"`
@@ -49,21 +79,22 @@ type ConsumeHandler[T any] interface {
 	ErrorHandler(ctx context.Context, message T, err error)
 }
 
+// NewConsumer creates a consumer of the configured topics, handler receives the
+// messages of topics without their own handler (see Handle) and may be nil
 func NewConsumer[T any](conf *ConsumerConfig, handler ConsumeHandler[T]) (*Consumer[T], error) {
 	if conf == nil {
 		return nil, errors.New("NewRocketMqConsumer config is nil")
 	}
+	subs := conf.subscriptions()
+	if len(subs) == 0 {
+		return nil, errors.New("NewRocketMqConsumer config has no topic")
+	}
 	SetLogger()
-	opts := []rmq.SimpleConsumerOption{rmq.WithAwaitDuration(awaitDuration)}
-	tagsExp := rmq.SUB_ALL
-	if len(conf.Tags) > 0 {
-		tagsExp = rmq.NewFilterExpression(strings.Join(conf.Tags, "||"))
+	opts := []rmq.SimpleConsumerOption{
+		rmq.WithAwaitDuration(awaitDuration),
+		rmq.WithSubscriptionExpressions(subs),
 	}
 
-	opts = append(opts, rmq.WithSubscriptionExpressions(map[string]*rmq.FilterExpression{
-		conf.Topic: tagsExp,
-	}))
-
 	cfg := &rmq.Config{
 		Endpoint:      conf.Endpoint,
 		ConsumerGroup: conf.ConsumerGroup,
@@ -87,189 +118,342 @@ func NewConsumer[T any](conf *ConsumerConfig, handler ConsumeHandler[T]) (*Consu
 		return nil, errors.New("NewRocketMqConsumer simpleConsumer is nil")
 	}
 
-	return &Consumer[T]{consumer: simpleConsumer,
-		handler: handler,
-		conf:    conf,
-		done:    make(chan struct{}),
-	}, nil
+	codec := JSONCodec
+	if conf.Codec != "" {
+		var ok bool
+		if codec, ok = lookupCodec(conf.Codec); !ok {
+			return nil, fmt.Errorf("unknown codec %q", conf.Codec)
+		}
+	}
+
+	c := &Consumer[T]{consumer: simpleConsumer,
+		handler:    handler,
+		conf:       conf,
+		codec:      codec,
+		retry:      conf.Retry.withDefaults(),
+		done:       make(chan struct{}),
+		subscribed: make(map[string]struct{}, len(subs)),
+	}
+	for topic := range subs {
+		c.subscribed[topic] = struct{}{}
+	}
+	c.receiveCtx, c.cancelReceive = context.WithCancel(context.Background())
+	if conf.Concurrency > 1 {
+		c.slots = make(chan struct{}, conf.Concurrency)
+	}
+	if c.retry.deadLettering() {
+		if c.dlq, err = newDeadLetterProducer(conf); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
 }
 
 type Consumer[T any] struct {
-	conf     *ConsumerConfig
-	consumer rmq.SimpleConsumer
-	handler  ConsumeHandler[T]
-	done     chan struct{}
-	wg       sync.WaitGroup
+	conf          *ConsumerConfig
+	consumer      rmq.SimpleConsumer
+	handler       ConsumeHandler[T]
+	codec         Codec
+	retry         RetryPolicy
+	slots         chan struct{} // bounds concurrent processing, nil when sequential
+	dlq           rmq.Producer
+	idempotency   *idempotency // set by EnableIdempotency
+	done          chan struct{}
+	stopOnce      sync.Once
+	receiveCtx    context.Context // cancelled by Stop to interrupt the long polling Receive
+	cancelReceive context.CancelFunc
+	wg            sync.WaitGroup
+	fifo          groupLocks // serializes FIFO message groups across workers
+	pause         pauseGate
+	health        consumerHealth
+	stats         consumerStats
+	scaler        *scaler
+	mu            sync.Mutex
+	workers       []chan struct{} // quit channel per running worker
+
+	routesMu   sync.RWMutex
+	routes     map[string]ConsumeHandler[T] // set by Handle, other topics use handler
+	subscribed map[string]struct{}
+	pending    map[string]*rmq.FilterExpression // added by Handle before Start
+	started    bool
 }
 
-func (c *Consumer[T]) Start() {
+// Start starts receiving with the configured workers and returns right away
+func (c *Consumer[T]) Start() error {
 	if err := c.consumer.Start(); err != nil {
-		logx.Errorf("start consumer failed: %v", err)
-		return
+		return fmt.Errorf("start consumer %s/%s: %w", c.conf.Topic, c.conf.ConsumerGroup, err)
+	}
+	if err := c.subscribePending(); err != nil {
+		_ = c.consumer.GracefulStop()
+		return fmt.Errorf("start consumer %s/%s: %w", c.conf.Topic, c.conf.ConsumerGroup, err)
+	}
+	c.health.start()
+
+	if c.dlq != nil {
+		if err := c.dlq.Start(); err != nil {
+			// 死信转发失败时消息会重投，不影响消费
+			logx.Errorf("start dlq producer failed: %v", err)
+			c.dlq = nil
+		}
 	}
 
 	if c.conf.Workers == 0 {
 		c.conf.Workers = 1
 	}
 
-	for i := 0; i < c.conf.Workers; i++ {
+	c.scaler = newScaler(c.conf)
+	c.scaleTo(c.conf.Workers)
+
+	if c.scaler != nil {
 		c.wg.Add(1)
 		go func() {
 			defer c.wg.Done()
-			// 这个 sleep 是必要的，5.x 版本的 proxy 有 bug，导致第一次接收消息失败
-			time.Sleep(time.Millisecond * 100)
-			c.consume()
+			c.autoscale()
 		}()
 	}
+	return nil
+}
 
-	// c.wg.Wait()
+// Stop stops receiving, then waits for the messages in flight to be processed
+// and acked until ctx is done. Messages still running after that are not acked
+// and get redelivered once their invisible duration expires
+func (c *Consumer[T]) Stop(ctx context.Context) error {
+	var err error
+	c.stopOnce.Do(func() {
+		c.health.stop()
+		close(c.done)
+		// 中断正在等待的 Receive 长轮询
+		c.cancelReceive()
+
+		drained := make(chan struct{})
+		go func() {
+			c.wg.Wait()
+			close(drained)
+		}()
+		select {
+		case <-drained:
+		case <-ctx.Done():
+			err = fmt.Errorf("stop consumer %s/%s before in-flight messages finished: %w",
+				c.conf.Topic, c.conf.ConsumerGroup, ctx.Err())
+		}
+
+		_ = c.consumer.GracefulStop()
+		if c.dlq != nil {
+			_ = c.dlq.GracefulStop()
+		}
+	})
+	return err
 }
 
-func (c *Consumer[T]) Stop() {
-	close(c.done)
-	_ = c.consumer.GracefulStop()
-	c.wg.Wait()
+// Service adapts the consumer to go-zero's service.Service for a ServiceGroup:
+// Start blocks until the group stops it, Stop waits up to timeout for in-flight messages
+func (c *Consumer[T]) Service(timeout time.Duration) service.Service {
+	return consumerService[T]{c: c, timeout: timeout}
 }
 
-func (c *Consumer[T]) consume() {
-	tracer := otel.Tracer("rocket-consumer")
-	prop := propagation.TraceContext{}
+type consumerService[T any] struct {
+	c       *Consumer[T]
+	timeout time.Duration
+}
+
+func (s consumerService[T]) Start() {
+	if err := s.c.Start(); err != nil {
+		logx.Errorf("%v", err)
+		return
+	}
+	<-s.c.done
+}
+
+func (s consumerService[T]) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	if err := s.c.Stop(ctx); err != nil {
+		logx.Errorf("%v", err)
+	}
+}
 
+func (c *Consumer[T]) consume(quit <-chan struct{}) {
 	for {
 		select {
 		case <-c.done:
 			return
+		case <-quit:
+			return
 		default:
-			msgs, err := c.consumer.Receive(context.Background(), maxMessageNum, invisibleDuration)
+			if !c.awaitResume(quit) {
+				return
+			}
+			batch := c.batchSize()
+			ctx := c.receiveContext()
+			msgs, err := c.consumer.Receive(ctx, batch, invisibleDuration)
 			if err != nil {
+				if c.receiveCtx.Err() != nil {
+					// Stop 中断了 Receive
+					return
+				}
+				if ctx.Err() != nil {
+					// Pause 中断了 Receive
+					continue
+				}
 				if rpcErr, ok := err.(*rmq.ErrRpcStatus); ok && v2.Code(rpcErr.Code) == v2.Code_MESSAGE_NOT_FOUND {
 					// 消息未找到是正常情况，静默处理并等待
-					time.Sleep(awaitDuration)
+					c.health.receiveSucceeded()
+					c.scaler.observeReceive(0, batch)
+					select {
+					case <-time.After(awaitDuration):
+					case <-c.done:
+					case <-quit:
+					}
 					continue
 				}
 				// 只有在非 MESSAGE_NOT_FOUND 的错误情况下才打印日志
+				c.health.receiveFailed(err)
+				c.observeReceiveError()
 				logx.Errorf("receive message failed: %v", err)
 				continue
 			}
+			c.health.receiveSucceeded()
+			c.scaler.observeReceive(len(msgs), batch)
+			c.observeReceived(msgs, time.Now())
 
-			for _, msg := range msgs {
-				receiveAt := time.Now()
-				func() {
-					defer func() {
-						if r := recover(); r != nil {
-							stack := string(debug.Stack())
-							logx.Errorf("panic in message processing: %v\nstack: %s", r, stack)
-							// 确保消息被确认，避免重复消费
-							if ackErr := c.consumer.Ack(context.Background(), msg); ackErr != nil {
-								logx.Errorf("failed to ack message after panic: %v", ackErr)
-							}
-						}
-					}()
-
-					props := msg.GetProperties()
-					carrier := propagation.MapCarrier{}
-					for k, v := range props {
-						carrier[k] = v
-					}
+			c.dispatch(msgs)
+		}
+	}
+}
 
-					ctx, cancel := context.WithTimeout(context.Background(), invisibleDuration-time.Second*2)
-					defer cancel()
+// process decodes and consumes one message, then acks it or applies the retry policy.
+// It returns the result recorded in the metrics
+func (c *Consumer[T]) process(msg *rmq.MessageView) (result string) {
+	tracer := otel.Tracer("rocket-consumer")
+	prop := propagation.TraceContext{}
+	receiveAt := time.Now()
+	result = resultFailed
+	obsCtx := context.Background()
+	defer func() {
+		c.scaler.observeProcess(time.Since(receiveAt))
+		c.observeResult(obsCtx, msg, result, receiveAt)
+	}()
+
+	defer func() {
+		if r := recover(); r != nil {
+			stack := string(debug.Stack())
+			logx.Errorf("panic in message processing: %v\nstack: %s", r, stack)
+			c.onFailure(context.Background(), msg, fmt.Errorf("panic: %v", r), false)
+		}
+	}()
 
-					ctx = prop.Extract(ctx, carrier)
+	var err error
+	props := msg.GetProperties()
+	carrier := propagation.MapCarrier{}
+	for k, v := range props {
+		carrier[k] = v
+	}
 
-					reconsumeTimes := ""
-					for _, key := range []string{"RECONSUME_TIMES", "reconsumeTimes", "x-rocketmq-reconsume-times"} {
-						if v, ok := props[key]; ok {
-							reconsumeTimes = v
-							break
-						}
-					}
+	ctx, cancel := context.WithTimeout(context.Background(), invisibleDuration-time.Second*2)
+	defer cancel()
 
-					attrs := []attribute.KeyValue{
-						attribute.String("message.topic", msg.GetTopic()),
-						attribute.String("message.id", msg.GetMessageId()),
-					}
-					if reconsumeTimes != "" {
-						attrs = append(attrs, attribute.String("message.reconsume_times", reconsumeTimes))
-					}
+	ctx = prop.Extract(ctx, carrier)
 
-					msgCtx, msgSpan := tracer.Start(ctx, "rocket.Consumer.ProcessMessage",
-						trace.WithAttributes(attrs...),
-						trace.WithSpanKind(trace.SpanKindConsumer),
-					)
-					defer msgSpan.End()
-
-					logc.Infof(msgCtx, "receive message, topic: %s, msgId: %s", msg.GetTopic(), msg.GetMessageId())
-					var data T
-					decoder := json.NewDecoder(bytes.NewReader(msg.GetBody()))
-					decoder.UseNumber()
-					if err = decoder.Decode(&data); err != nil {
-						c.handler.ErrorHandler(msgCtx, data, err)
-						msgSpan.RecordError(err)
-						msgSpan.SetStatus(codes.Error, err.Error())
-						if ackErr := c.consumer.Ack(msgCtx, msg); ackErr != nil {
-							msgSpan.RecordError(ackErr)
-						}
-						return
-					}
+	reconsumeTimes := ""
+	for _, key := range []string{"RECONSUME_TIMES", "reconsumeTimes", "x-rocketmq-reconsume-times"} {
+		if v, ok := props[key]; ok {
+			reconsumeTimes = v
+			break
+		}
+	}
 
-					consumeStart := time.Now()
-					msgSpan.SetAttributes(attribute.Int64("consumer.receive_to_consume_ms", time.Since(receiveAt).Milliseconds()))
+	attrs := []attribute.KeyValue{
+		attribute.String("message.topic", msg.GetTopic()),
+		attribute.String("message.id", msg.GetMessageId()),
+	}
+	if reconsumeTimes != "" {
+		attrs = append(attrs, attribute.String("message.reconsume_times", reconsumeTimes))
+	}
 
-					if appID, ok := props[string(APP_ID_KEY)]; ok {
-						msgCtx = context.WithValue(msgCtx, APP_ID_KEY, appID)
-					}
+	msgCtx, msgSpan := tracer.Start(ctx, "rocket.Consumer.ProcessMessage",
+		trace.WithAttributes(attrs...),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(producerLinks(ctx, props)...),
+	)
+	defer msgSpan.End()
+	obsCtx = msgCtx
+
+	logc.Infof(msgCtx, "receive message, topic: %s, msgId: %s", msg.GetTopic(), msg.GetMessageId())
+	handler := c.handlerFor(msg.GetTopic())
+	if handler == nil {
+		err = fmt.Errorf("%w %s", ErrNoHandler, msg.GetTopic())
+		logc.Errorf(msgCtx, "%v, msgId: %s", err, msg.GetMessageId())
+		msgSpan.RecordError(err)
+		c.onFailure(msgCtx, msg, err, true)
+		return
+	}
 
-					if err = c.handler.Consume(msgCtx, data); err != nil {
-						msgSpan.SetAttributes(attribute.Int64("consumer.consume_ms", time.Since(consumeStart).Milliseconds()))
-						c.handler.ErrorHandler(msgCtx, data, err)
-						msgSpan.RecordError(err)
-						// 业务函数返回了，我们按预期 Ack 掉，所以这里不把 Span 状态设为永久 Error
-						// 除非后续 Ack 也失败了
-
-						ackCtx, ackCancel := context.WithTimeout(context.WithoutCancel(msgCtx), time.Second*30)
-						ackStart := time.Now()
-						ackErr := c.consumer.Ack(ackCtx, msg)
-						ackCancel()
-
-						msgSpan.SetAttributes(attribute.Int64("consumer.ack_ms", time.Since(ackStart).Milliseconds()))
-						if ackErr != nil {
-							msgSpan.RecordError(ackErr)
-							msgSpan.SetStatus(codes.Error, "biz_err_and_ack_failed: "+ackErr.Error())
-							msgSpan.SetAttributes(attribute.String("ack.error", ackErr.Error()))
-						} else {
-							msgSpan.SetStatus(codes.Ok, "biz_err_but_ack_success")
-							msgSpan.SetAttributes(attribute.Bool("ack.success", true))
-						}
-						return
-					}
+	var data T
+	if err = c.decode(props, msg.GetBody(), &data); err != nil {
+		handler.ErrorHandler(msgCtx, data, err)
+		msgSpan.RecordError(err)
+		// 解码失败重试也无法成功，直接进入死信或丢弃
+		c.onFailure(msgCtx, msg, err, true)
+		return
+	}
 
-					msgSpan.SetAttributes(attribute.Int64("consumer.consume_ms", time.Since(consumeStart).Milliseconds()))
+	claim, claimResult, handled := c.claim(msgCtx, msg)
+	if handled {
+		result = claimResult
+		return
+	}
+	defer claim.release(msgCtx)
 
-					// Record deadline and ack metrics
-					if deadline, ok := msgCtx.Deadline(); ok {
-						msgSpan.SetAttributes(attribute.Int64("consumer.msg_ctx_deadline_left_ms", time.Until(deadline).Milliseconds()))
-					}
+	consumeStart := time.Now()
+	msgSpan.SetAttributes(attribute.Int64("consumer.receive_to_consume_ms", time.Since(receiveAt).Milliseconds()))
 
-					// 正常处理完成后的 ack
-					ackCtx, ackCancel := context.WithTimeout(context.WithoutCancel(msgCtx), time.Second*30)
-					ackStart := time.Now()
-					err = c.consumer.Ack(ackCtx, msg)
-					ackCancel()
-
-					msgSpan.SetAttributes(attribute.Int64("consumer.ack_ms", time.Since(ackStart).Milliseconds()))
-					if err != nil {
-						msgSpan.RecordError(err)
-						msgSpan.SetStatus(codes.Error, "biz_succss_but_ack_failed: "+err.Error())
-						msgSpan.SetAttributes(attribute.String("ack.error", err.Error()))
-					} else {
-						msgSpan.SetStatus(codes.Ok, "")
-						msgSpan.SetAttributes(attribute.Bool("ack.success", true))
-					}
-				}()
-			}
-		}
+	if appID, ok := props[string(APP_ID_KEY)]; ok {
+		msgCtx = context.WithValue(msgCtx, APP_ID_KEY, appID)
+	}
+
+	if err = handler.Consume(msgCtx, data); err != nil {
+		msgSpan.SetAttributes(attribute.Int64("consumer.consume_ms", time.Since(consumeStart).Milliseconds()))
+		handler.ErrorHandler(msgCtx, data, err)
+		msgSpan.RecordError(err)
+		c.onFailure(msgCtx, msg, err, false)
+		return
+	}
+
+	msgSpan.SetAttributes(attribute.Int64("consumer.consume_ms", time.Since(consumeStart).Milliseconds()))
+	claim.complete(msgCtx)
+
+	// Record deadline and ack metrics
+	if deadline, ok := msgCtx.Deadline(); ok {
+		msgSpan.SetAttributes(attribute.Int64("consumer.msg_ctx_deadline_left_ms", time.Until(deadline).Milliseconds()))
+	}
+
+	// 正常处理完成后的 ack
+	ackCtx, ackCancel := context.WithTimeout(context.WithoutCancel(msgCtx), time.Second*30)
+	ackStart := time.Now()
+	err = c.consumer.Ack(ackCtx, msg)
+	ackCancel()
+
+	msgSpan.SetAttributes(attribute.Int64("consumer.ack_ms", time.Since(ackStart).Milliseconds()))
+	if err != nil {
+		result = resultAckError
+		msgSpan.RecordError(err)
+		msgSpan.SetStatus(codes.Error, "biz_succss_but_ack_failed: "+err.Error())
+		msgSpan.SetAttributes(attribute.String("ack.error", err.Error()))
+	} else {
+		result = resultAcked
+		msgSpan.SetStatus(codes.Ok, "")
+		msgSpan.SetAttributes(attribute.Bool("ack.success", true))
+	}
+	return
+}
+
+// decode unmarshals body with the codec of the message, falling back to the configured one
+func (c *Consumer[T]) decode(props map[string]string, body []byte, data *T) error {
+	codec, err := messageCodec(props, c.codec)
+	if err != nil {
+		return err
 	}
+	return codec.Unmarshal(body, data)
 }
 
 func RegisterConsumer[T any](conf *ConsumerConfig, handler ConsumeHandler[T]) *Consumer[T] {
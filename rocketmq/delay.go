@@ -0,0 +1,86 @@
+package rocketmq
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	v2 "github.com/apache/rocketmq-clients/golang/v5/protocol/v2"
+)
+
+// DefaultMaxDelay matches the broker's default timerMaxDelaySec of one day on
+// most managed instances, raise ProducerConfig.MaxDelay when the broker allows more
+const DefaultMaxDelay = 24 * time.Hour
+
+// ErrInvalidDelay is returned for delays the broker would not honor, either
+// checked before sending or reported by the broker as a *ScheduleError
+var ErrInvalidDelay = errors.New("rocketmq: invalid delivery time")
+
+// ScheduleError is returned when the broker rejects the delivery time of a message
+type ScheduleError struct {
+	DeliverAt time.Time
+	Err       error
+}
+
+func (e *ScheduleError) Error() string {
+	return fmt.Sprintf("rocketmq: broker rejected delivery at %s: %v", e.DeliverAt.Format(time.RFC3339), e.Err)
+}
+
+func (e *ScheduleError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ScheduleError) Is(target error) bool {
+	return target == ErrInvalidDelay
+}
+
+// WithDeliverAt delivers the message at t instead of right away, t must be in
+// the future and within the producer's MaxDelay
+func WithDeliverAt(t time.Time) PublishOptionFunc {
+	return func(opt *PublishOption) {
+		opt.deliverAt = t
+	}
+}
+
+// deliveryTime returns when the message should be delivered, zero for right away
+func (opt *PublishOption) deliveryTime(now time.Time, maxDelay time.Duration) (time.Time, error) {
+	if opt.delay == 0 && opt.deliverAt.IsZero() {
+		return time.Time{}, nil
+	}
+	if opt.messageGroup != "" {
+		return time.Time{}, fmt.Errorf("%w: message group %s is FIFO", ErrInvalidDelay, opt.messageGroup)
+	}
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxDelay
+	}
+
+	var at time.Time
+	switch {
+	case opt.delay != 0 && !opt.deliverAt.IsZero():
+		return time.Time{}, fmt.Errorf("%w: both delay and deliver time are set", ErrInvalidDelay)
+	case opt.delay < 0:
+		return time.Time{}, fmt.Errorf("%w: negative delay %s", ErrInvalidDelay, opt.delay)
+	case opt.delay > 0:
+		at = now.Add(opt.delay)
+	default:
+		at = opt.deliverAt
+		if !at.After(now) {
+			return time.Time{}, fmt.Errorf("%w: %s is in the past", ErrInvalidDelay, at.Format(time.RFC3339))
+		}
+	}
+
+	if d := at.Sub(now); d > maxDelay {
+		return time.Time{}, fmt.Errorf("%w: delay %s exceeds the maximum %s", ErrInvalidDelay, d.Round(time.Second), maxDelay)
+	}
+	return at, nil
+}
+
+// scheduleError wraps the broker's rejection of the delivery time
+func scheduleError(err error, at time.Time) error {
+	var rpcErr *rmq.ErrRpcStatus
+	if !at.IsZero() && errors.As(err, &rpcErr) && v2.Code(rpcErr.GetCode()) == v2.Code_ILLEGAL_DELIVERY_TIME {
+		return &ScheduleError{DeliverAt: at, Err: err}
+	}
+	return err
+}
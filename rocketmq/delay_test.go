@@ -0,0 +1,96 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	v2 "github.com/apache/rocketmq-clients/golang/v5/protocol/v2"
+)
+
+func TestDeliveryTime(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		opts     []PublishOptionFunc
+		maxDelay time.Duration
+		want     time.Time
+		wantErr  bool
+	}{
+		{name: "immediate"},
+		{name: "delay", opts: []PublishOptionFunc{WithDelay(time.Hour)}, want: now.Add(time.Hour)},
+		{name: "deliver at", opts: []PublishOptionFunc{WithDeliverAt(now.Add(2 * time.Hour))}, want: now.Add(2 * time.Hour)},
+		{name: "default max", opts: []PublishOptionFunc{WithDelay(DefaultMaxDelay + time.Second)}, wantErr: true},
+		{name: "raised max", opts: []PublishOptionFunc{WithDelay(48 * time.Hour)}, maxDelay: 72 * time.Hour, want: now.Add(48 * time.Hour)},
+		{name: "negative", opts: []PublishOptionFunc{WithDelay(-time.Second)}, wantErr: true},
+		{name: "past", opts: []PublishOptionFunc{WithDeliverAt(now.Add(-time.Minute))}, wantErr: true},
+		{name: "fifo", opts: []PublishOptionFunc{WithMessageGroup("order-1"), WithDelay(time.Minute)}, wantErr: true},
+		{name: "both", opts: []PublishOptionFunc{WithDelay(time.Minute), WithDeliverAt(now.Add(time.Hour))}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := &PublishOption{}
+			for _, o := range tt.opts {
+				o(opt)
+			}
+			got, err := opt.deliveryTime(now, tt.maxDelay)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidDelay) {
+					t.Fatalf("err = %v, want ErrInvalidDelay", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("delivery time = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishDelayErrors(t *testing.T) {
+	t.Run("invalid delay is not sent", func(t *testing.T) {
+		fake := &fakeSendProducer{}
+		p := &Producer{Producer: fake}
+		err := p.PublishWithoutPrefix(context.Background(), "orders", []byte("o1"), WithDelay(30*24*time.Hour))
+		if !errors.Is(err, ErrInvalidDelay) {
+			t.Fatalf("err = %v, want ErrInvalidDelay", err)
+		}
+		if fake.sent != nil {
+			t.Error("message with an invalid delay was sent")
+		}
+	})
+
+	t.Run("broker rejection", func(t *testing.T) {
+		fake := &fakeSendProducer{err: &rmq.ErrRpcStatus{Code: int32(v2.Code_ILLEGAL_DELIVERY_TIME), Message: "delay too long"}}
+		p := &Producer{Producer: fake, maxDelay: 40 * 24 * time.Hour}
+		at := time.Now().Add(30 * 24 * time.Hour)
+		err := p.PublishWithoutPrefix(context.Background(), "orders", []byte("o1"), WithDeliverAt(at))
+
+		var schedErr *ScheduleError
+		if !errors.As(err, &schedErr) || !errors.Is(err, ErrInvalidDelay) {
+			t.Fatalf("err = %v, want *ScheduleError", err)
+		}
+		if !schedErr.DeliverAt.Equal(at) {
+			t.Errorf("DeliverAt = %s, want %s", schedErr.DeliverAt, at)
+		}
+		if got := fake.sent.GetDeliveryTimestamp(); got == nil || !got.Equal(at) {
+			t.Errorf("delivery timestamp = %v, want %s", got, at)
+		}
+	})
+
+	t.Run("other send errors pass through", func(t *testing.T) {
+		errDown := errors.New("broker unavailable")
+		p := &Producer{Producer: &fakeSendProducer{err: errDown}}
+		err := p.PublishWithoutPrefix(context.Background(), "orders", []byte("o1"), WithDelay(time.Minute))
+		if !errors.Is(err, errDown) || errors.Is(err, ErrInvalidDelay) {
+			t.Fatalf("err = %v, want the send error", err)
+		}
+	})
+}
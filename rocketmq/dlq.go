@@ -0,0 +1,196 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/apache/rocketmq-clients/golang/v5/credentials"
+	v2 "github.com/apache/rocketmq-clients/golang/v5/protocol/v2"
+)
+
+// DeadLetterReplayedKey is added to replayed messages with the id of the dead letter
+const DeadLetterReplayedKey = "DLQ_REPLAYED_FROM"
+
+// defaultInspectDuration keeps fetched dead letters invisible to other readers
+const defaultInspectDuration = 5 * time.Minute
+
+// DeadLetter is a message read from a dead-letter topic with the failure
+// metadata recorded by the consumer that gave up on it
+type DeadLetter struct {
+	MessageID       string
+	OriginTopic     string
+	OriginMessageID string
+	ConsumerGroup   string
+	Error           string
+	Attempts        int
+	TraceID         string
+	FailedAt        time.Time
+	Tag             string
+	Keys            []string
+	Body            []byte
+	// Properties are the properties of the original message
+	Properties map[string]string
+
+	view *rmq.MessageView
+}
+
+// ParseDeadLetter reads the failure metadata of a message from a dead-letter topic
+func ParseDeadLetter(msg *rmq.MessageView) *DeadLetter {
+	var tag string
+	if t := msg.GetTag(); t != nil {
+		tag = *t
+	}
+	d := newDeadLetter(msg.GetMessageId(), msg.GetProperties(), msg.GetBody(), tag, msg.GetKeys())
+	d.view = msg
+	return d
+}
+
+func newDeadLetter(id string, props map[string]string, body []byte, tag string, keys []string) *DeadLetter {
+	d := &DeadLetter{
+		MessageID:       id,
+		OriginTopic:     props[DeadLetterOriginTopicKey],
+		OriginMessageID: props[DeadLetterOriginIDKey],
+		ConsumerGroup:   props[DeadLetterGroupKey],
+		Error:           props[DeadLetterErrorKey],
+		TraceID:         props[DeadLetterTraceIDKey],
+		Tag:             tag,
+		Keys:            keys,
+		Body:            body,
+		Properties:      make(map[string]string, len(props)),
+	}
+	d.Attempts, _ = strconv.Atoi(props[DeadLetterAttemptsKey])
+	d.FailedAt, _ = time.Parse(time.RFC3339Nano, props[DeadLetterFailedAtKey])
+	for k, v := range props {
+		if !strings.HasPrefix(k, "DLQ_") {
+			d.Properties[k] = v
+		}
+	}
+	return d
+}
+
+// Message returns the original message for its origin topic, marked as replayed
+func (d *DeadLetter) Message() *rmq.Message {
+	msg := &rmq.Message{
+		Topic: d.OriginTopic,
+		Body:  d.Body,
+	}
+	for k, v := range d.Properties {
+		msg.AddProperty(k, v)
+	}
+	if d.Tag != "" {
+		msg.SetTag(d.Tag)
+	}
+	if len(d.Keys) > 0 {
+		msg.SetKeys(d.Keys...)
+	}
+	if d.MessageID != "" {
+		msg.AddProperty(DeadLetterReplayedKey, d.MessageID)
+	}
+	return msg
+}
+
+// DeadLetterReader inspects a dead-letter topic and replays or discards its
+// messages, e.g. from an ops command after the cause of the failures was fixed.
+// Fetched messages are only removed by Replay or Discard, the others become
+// visible again once their inspect duration expires
+type DeadLetterReader struct {
+	consumer rmq.SimpleConsumer
+	producer rmq.Producer
+}
+
+// NewDeadLetterReader reads conf.Topic, the dead-letter topic such as
+// DeadLetterTopic("orders"), with its own conf.ConsumerGroup. Replays are sent
+// with the same endpoint and credentials
+func NewDeadLetterReader(conf *ConsumerConfig) (*DeadLetterReader, error) {
+	if conf == nil || conf.Topic == "" {
+		return nil, errors.New("dead-letter reader requires a topic")
+	}
+	SetLogger()
+	cfg := &rmq.Config{
+		Endpoint:      conf.Endpoint,
+		ConsumerGroup: conf.ConsumerGroup,
+		Credentials:   &credentials.SessionCredentials{},
+	}
+	if conf.Credentials != nil {
+		cfg.Credentials = &credentials.SessionCredentials{
+			AccessKey:    conf.Credentials.AccessKey,
+			AccessSecret: conf.Credentials.AccessSecret,
+		}
+	}
+
+	consumer, err := rmq.NewSimpleConsumer(cfg,
+		rmq.WithAwaitDuration(awaitDuration),
+		rmq.WithSubscriptionExpressions(map[string]*rmq.FilterExpression{conf.Topic: rmq.SUB_ALL}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create dead-letter consumer %s: %w", conf.Topic, err)
+	}
+	producer, err := rmq.NewProducer(&rmq.Config{Endpoint: cfg.Endpoint, Credentials: cfg.Credentials})
+	if err != nil {
+		return nil, fmt.Errorf("create replay producer: %w", err)
+	}
+	return &DeadLetterReader{consumer: consumer, producer: producer}, nil
+}
+
+func (r *DeadLetterReader) Start() error {
+	if err := r.consumer.Start(); err != nil {
+		return fmt.Errorf("start dead-letter consumer: %w", err)
+	}
+	if err := r.producer.Start(); err != nil {
+		_ = r.consumer.GracefulStop()
+		return fmt.Errorf("start replay producer: %w", err)
+	}
+	return nil
+}
+
+func (r *DeadLetterReader) Stop() error {
+	return errors.Join(r.consumer.GracefulStop(), r.producer.GracefulStop())
+}
+
+// Fetch receives up to maxMessages dead letters and hides them from other readers for
+// inspect, default 5m. It returns nil when the topic is empty
+func (r *DeadLetterReader) Fetch(ctx context.Context, maxMessages int32, inspect time.Duration) ([]*DeadLetter, error) {
+	if inspect <= 0 {
+		inspect = defaultInspectDuration
+	}
+	msgs, err := r.consumer.Receive(ctx, maxMessages, inspect)
+	if err != nil {
+		var rpcErr *rmq.ErrRpcStatus
+		if errors.As(err, &rpcErr) && v2.Code(rpcErr.GetCode()) == v2.Code_MESSAGE_NOT_FOUND {
+			return nil, nil
+		}
+		return nil, err
+	}
+	letters := make([]*DeadLetter, 0, len(msgs))
+	for _, msg := range msgs {
+		letters = append(letters, ParseDeadLetter(msg))
+	}
+	return letters, nil
+}
+
+// Replay publishes the original message to its origin topic, then removes the dead letter
+func (r *DeadLetterReader) Replay(ctx context.Context, d *DeadLetter) error {
+	if d.OriginTopic == "" {
+		return fmt.Errorf("dead letter %s has no origin topic", d.MessageID)
+	}
+	if _, err := r.producer.Send(ctx, d.Message()); err != nil {
+		return fmt.Errorf("replay dead letter %s to %s: %w", d.MessageID, d.OriginTopic, err)
+	}
+	return r.Discard(ctx, d)
+}
+
+// Discard removes the dead letter without replaying it
+func (r *DeadLetterReader) Discard(ctx context.Context, d *DeadLetter) error {
+	if d.view == nil {
+		return fmt.Errorf("dead letter %s was not fetched", d.MessageID)
+	}
+	if err := r.consumer.Ack(ctx, d.view); err != nil {
+		return fmt.Errorf("ack dead letter %s: %w", d.MessageID, err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRetryPolicyDeadLetterTopic(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   string
+	}{
+		{name: "fixed topic", policy: RetryPolicy{DLQTopic: "all_DLQ", DeadLetter: true}, want: "all_DLQ"},
+		{name: "per topic", policy: RetryPolicy{DeadLetter: true}, want: "orders_DLQ"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.deadLetterTopic("orders"); got != tt.want {
+				t.Errorf("deadLetterTopic = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOriginTraceID(t *testing.T) {
+	const (
+		producerTrace = "4bf92f3577b34da6a3ce929d0e0e4736"
+		consumerTrace = "0af7651916cd43dd8448eb211c80319c"
+	)
+	consumerCtx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: mustTraceID(t, consumerTrace),
+		SpanID:  trace.SpanID{1},
+	}))
+
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		props map[string]string
+		want  string
+	}{
+		{name: "traceparent", ctx: consumerCtx, props: map[string]string{"traceparent": "00-" + producerTrace + "-00f067aa0ba902b7-01"}, want: producerTrace},
+		{name: "legacy", ctx: consumerCtx, props: map[string]string{legacyTraceIDKey: producerTrace, legacySpanIDKey: "00f067aa0ba902b7"}, want: producerTrace},
+		{name: "consumer trace", ctx: consumerCtx, want: consumerTrace},
+		{name: "none", ctx: context.Background(), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := originTraceID(tt.ctx, tt.props); got != tt.want {
+				t.Errorf("originTraceID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func mustTraceID(t *testing.T, s string) trace.TraceID {
+	t.Helper()
+	id, err := trace.TraceIDFromHex(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestDeadLetterRoundTrip(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: mustTraceID(t, traceID),
+		SpanID:  trace.SpanID{1},
+	}))
+
+	sent := deadLetterMessage(ctx, "orders_DLQ", "billing", &rmq.MessageView{}, 3, errors.New("downstream timeout"))
+	sent.AddProperty(CodecKey, "json")
+	d := newDeadLetter("dlq-1", sent.GetProperties(), []byte(`{"id":1}`), "paid", []string{"o1"})
+
+	if d.Attempts != 3 || d.Error != "downstream timeout" || d.ConsumerGroup != "billing" || d.TraceID != traceID {
+		t.Errorf("dead letter = %+v", d)
+	}
+	if time.Since(d.FailedAt) > time.Minute {
+		t.Errorf("FailedAt = %s", d.FailedAt)
+	}
+
+	d.OriginTopic = "orders"
+	replay := d.Message()
+	props := replay.GetProperties()
+	if replay.Topic != "orders" || string(replay.Body) != `{"id":1}` || *replay.GetTag() != "paid" {
+		t.Errorf("replay message = %s %s %v", replay.Topic, replay.Body, replay.GetTag())
+	}
+	if props[CodecKey] != "json" || props[DeadLetterReplayedKey] != "dlq-1" {
+		t.Errorf("replay properties = %v", props)
+	}
+	for k := range props {
+		if strings.HasPrefix(k, "DLQ_") && k != DeadLetterReplayedKey {
+			t.Errorf("replay kept dead-letter property %s", k)
+		}
+	}
+}
+
+func TestDeadLetterReader_Replay(t *testing.T) {
+	tests := []struct {
+		name      string
+		origin    string
+		sendErr   error
+		wantErr   bool
+		wantSent  bool
+		wantAcked int
+	}{
+		{name: "replay", origin: "orders", wantSent: true, wantAcked: 1},
+		{name: "no origin", wantErr: true},
+		{name: "send failure keeps the dead letter", origin: "orders", sendErr: errors.New("down"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &fakeSimpleConsumer{}
+			producer := &fakeDLQProducer{err: tt.sendErr}
+			r := &DeadLetterReader{consumer: sc, producer: producer}
+
+			d := newDeadLetter("dlq-1", map[string]string{DeadLetterOriginTopicKey: tt.origin}, []byte("body"), "", nil)
+			d.view = &rmq.MessageView{}
+			err := r.Replay(context.Background(), d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Replay error = %v, want error %v", err, tt.wantErr)
+			}
+			if (len(producer.sent) == 1) != tt.wantSent || sc.acked != tt.wantAcked {
+				t.Errorf("sent %d, acked %d", len(producer.sent), sc.acked)
+			}
+			if tt.wantSent && producer.sent[0].Topic != tt.origin {
+				t.Errorf("replayed to %s, want %s", producer.sent[0].Topic, tt.origin)
+			}
+		})
+	}
+}
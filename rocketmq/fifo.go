@@ -0,0 +1,97 @@
+package rocketmq
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// messageGroup returns the group set by WithMessageGroup
+func messageGroup(msg *rmq.MessageView) string {
+	if group := msg.GetMessageGroup(); group != nil {
+		return *group
+	}
+	return ""
+}
+
+// runFIFO processes the messages of one message group in order while holding
+// the group's lock, so a group never has more than one message in flight even
+// when another worker received its next batch. The first message that is not
+// acked stops the group: the rest is made visible again together with it
+func (c *Consumer[T]) runFIFO(group []*rmq.MessageView) {
+	unlock := c.fifo.lock(messageGroup(group[0]))
+	defer unlock()
+
+	for i, msg := range group {
+		switch c.process(msg) {
+		case resultAcked, resultDuplicate:
+		default:
+			c.holdBack(msg, group[i+1:])
+			return
+		}
+	}
+}
+
+// holdBack redelivers the messages queued behind head after head's backoff,
+// processing them now would overtake head
+func (c *Consumer[T]) holdBack(head *rmq.MessageView, rest []*rmq.MessageView) {
+	if len(rest) == 0 {
+		return
+	}
+	delay := c.retry.backoff(max(int(head.GetDeliveryAttempt()), 1))
+	now := time.Now()
+	for _, msg := range rest {
+		if err := c.consumer.ChangeInvisibleDuration(msg, delay); err != nil {
+			// 修改失败时消息在 invisibleDuration 后自动重投
+			logx.Errorf("change invisible duration failed: %v, msgId: %s", err, msg.GetMessageId())
+		}
+		c.observeResult(context.Background(), msg, resultDeferred, now)
+	}
+	logx.Infof("message group %s held back %d messages behind msgId: %s",
+		messageGroup(head), len(rest), head.GetMessageId())
+}
+
+// groupLocks is a lock per message group, entries are dropped once unused.
+// The zero value is ready to use
+type groupLocks struct {
+	mu    sync.Mutex
+	locks map[string]*groupLock
+}
+
+type groupLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock blocks until group is free and returns its unlock, messages without a
+// group are not serialized
+func (g *groupLocks) lock(group string) (unlock func()) {
+	if group == "" {
+		return func() {}
+	}
+
+	g.mu.Lock()
+	if g.locks == nil {
+		g.locks = make(map[string]*groupLock)
+	}
+	l, ok := g.locks[group]
+	if !ok {
+		l = &groupLock{}
+		g.locks[group] = l
+	}
+	l.refs++
+	g.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		g.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(g.locks, group)
+		}
+		g.mu.Unlock()
+	}
+}
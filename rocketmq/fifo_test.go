@@ -0,0 +1,151 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+// fifoSimpleConsumer records the acks of every message group in order, fails
+// the acks of the keys in fail and tracks overlapping messages of one group
+type fifoSimpleConsumer struct {
+	rmq.SimpleConsumer
+	fail map[string]bool
+
+	mu      sync.Mutex
+	acked   map[string][]string
+	held    []string
+	active  map[string]int
+	overlap bool
+}
+
+func (f *fifoSimpleConsumer) Ack(_ context.Context, msg *rmq.MessageView) error {
+	group, key := messageGroup(msg), msg.GetKeys()[0]
+	f.mu.Lock()
+	f.acked[group] = append(f.acked[group], key)
+	if f.active[group]++; f.active[group] > 1 {
+		f.overlap = true
+	}
+	f.mu.Unlock()
+
+	time.Sleep(2 * time.Millisecond)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.active[group]--
+	if f.fail[key] {
+		return errors.New("ack timeout")
+	}
+	return nil
+}
+
+func (f *fifoSimpleConsumer) ChangeInvisibleDuration(msg *rmq.MessageView, _ time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.held = append(f.held, msg.GetKeys()[0])
+	return nil
+}
+
+func newFIFOConsumer(concurrency int, fail map[string]bool) (*Consumer[string], *fifoSimpleConsumer) {
+	sc := &fifoSimpleConsumer{fail: fail, acked: map[string][]string{}, active: map[string]int{}}
+	c := &Consumer[string]{
+		conf:     &ConsumerConfig{FIFO: true, Concurrency: concurrency},
+		consumer: sc,
+		handler:  &nopHandler{},
+		codec:    RawCodec,
+		retry:    RetryPolicy{}.withDefaults(),
+	}
+	if concurrency > 1 {
+		c.slots = make(chan struct{}, concurrency)
+	}
+	return c, sc
+}
+
+// fifoBatch builds messages from "group/key" pairs
+func fifoBatch(ids ...string) []*rmq.MessageView {
+	msgs := make([]*rmq.MessageView, len(ids))
+	for i, id := range ids {
+		group, key, _ := strings.Cut(id, "/")
+		msgs[i] = &rmq.MessageView{}
+		msgs[i].SetMessageGroup(group)
+		msgs[i].SetKeys(key)
+	}
+	return msgs
+}
+
+func TestConsumerFIFO(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		fail        map[string]bool
+		wantAcked   map[string]string
+		wantHeld    string
+	}{
+		{
+			name:        "groups in order",
+			concurrency: 4,
+			wantAcked:   map[string]string{"a": "a1,a2,a3", "b": "b1,b2"},
+		},
+		{
+			name:        "unacked message holds back its group",
+			concurrency: 4,
+			fail:        map[string]bool{"a2": true},
+			wantAcked:   map[string]string{"a": "a1,a2", "b": "b1,b2"},
+			wantHeld:    "a3",
+		},
+		{
+			name:      "sequential",
+			fail:      map[string]bool{"b1": true},
+			wantAcked: map[string]string{"a": "a1,a2,a3", "b": "b1"},
+			wantHeld:  "b2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, sc := newFIFOConsumer(tt.concurrency, tt.fail)
+			c.dispatch(fifoBatch("a/a1", "b/b1", "a/a2", "b/b2", "a/a3"))
+
+			for group, want := range tt.wantAcked {
+				if got := strings.Join(sc.acked[group], ","); got != want {
+					t.Errorf("group %s acked %s, want %s", group, got, want)
+				}
+			}
+			if got := strings.Join(sc.held, ","); got != tt.wantHeld {
+				t.Errorf("held back %s, want %s", got, tt.wantHeld)
+			}
+			if stats := c.Stats(); stats.InFlight != 0 {
+				t.Errorf("in flight = %d after dispatch", stats.InFlight)
+			}
+		})
+	}
+}
+
+func TestConsumerFIFOAcrossWorkers(t *testing.T) {
+	c, sc := newFIFOConsumer(4, nil)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.dispatch(fifoBatch("a/1", "a/2", "b/1", "a/3"))
+		}()
+	}
+	wg.Wait()
+
+	if sc.overlap {
+		t.Error("a message group had more than one message in flight")
+	}
+	if n := len(sc.acked["a"]); n != 12 {
+		t.Errorf("group a acked %d messages, want 12", n)
+	}
+	if len(c.fifo.locks) != 0 {
+		t.Errorf("group locks left behind: %v", c.fifo.locks)
+	}
+}
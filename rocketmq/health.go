@@ -0,0 +1,132 @@
+package rocketmq
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultUnhealthyAfter       = 5 * time.Minute
+	defaultMaxConsecutiveErrors = 10
+)
+
+// ConsumerHealth is a snapshot of the receive loop liveness
+type ConsumerHealth struct {
+	Running           bool      `json:"running"`
+	Paused            bool      `json:"paused"`
+	LastReceive       time.Time `json:"lastReceive"`
+	ConsecutiveErrors int64     `json:"consecutiveErrors"`
+	LastError         string    `json:"lastError,omitempty"`
+	Healthy           bool      `json:"healthy"`
+	Reason            string    `json:"reason,omitempty"`
+}
+
+// consumerHealth tracks receive results, shared by all workers of a consumer
+type consumerHealth struct {
+	running           atomic.Bool
+	paused            atomic.Bool
+	lastReceive       atomic.Int64 // unix nano of the last successful Receive
+	consecutiveErrors atomic.Int64
+	mu                sync.Mutex
+	lastErr           error
+}
+
+func (h *consumerHealth) start() {
+	// the grace period before the first Receive counts from Start
+	h.lastReceive.Store(time.Now().UnixNano())
+	h.running.Store(true)
+}
+
+func (h *consumerHealth) stop() {
+	h.running.Store(false)
+}
+
+func (h *consumerHealth) pause() {
+	h.paused.Store(true)
+}
+
+func (h *consumerHealth) resume() {
+	// the grace period before the next Receive counts from Resume
+	h.lastReceive.Store(time.Now().UnixNano())
+	h.consecutiveErrors.Store(0)
+	h.paused.Store(false)
+}
+
+func (h *consumerHealth) receiveSucceeded() {
+	h.lastReceive.Store(time.Now().UnixNano())
+	h.consecutiveErrors.Store(0)
+}
+
+func (h *consumerHealth) receiveFailed(err error) {
+	h.consecutiveErrors.Add(1)
+	h.mu.Lock()
+	h.lastErr = err
+	h.mu.Unlock()
+}
+
+func (h *consumerHealth) snapshot(unhealthyAfter time.Duration, maxErrors int) ConsumerHealth {
+	s := ConsumerHealth{
+		Running:           h.running.Load(),
+		Paused:            h.paused.Load(),
+		ConsecutiveErrors: h.consecutiveErrors.Load(),
+	}
+	if ts := h.lastReceive.Load(); ts > 0 {
+		s.LastReceive = time.Unix(0, ts)
+	}
+	h.mu.Lock()
+	if h.lastErr != nil {
+		s.LastError = h.lastErr.Error()
+	}
+	h.mu.Unlock()
+
+	switch {
+	case !s.Running:
+		s.Reason = "consumer not running"
+	case s.Paused:
+		// paused on purpose, receiving is not expected
+		s.Healthy = true
+	case time.Since(s.LastReceive) > unhealthyAfter:
+		s.Reason = fmt.Sprintf("no successful receive for %s", time.Since(s.LastReceive).Truncate(time.Second))
+	case s.ConsecutiveErrors >= int64(maxErrors):
+		s.Reason = fmt.Sprintf("%d consecutive receive errors", s.ConsecutiveErrors)
+	default:
+		s.Healthy = true
+	}
+	return s
+}
+
+// Health returns the current liveness snapshot of the receive loop
+func (c *Consumer[T]) Health() ConsumerHealth {
+	unhealthyAfter := c.conf.UnhealthyAfter
+	if unhealthyAfter <= 0 {
+		unhealthyAfter = defaultUnhealthyAfter
+	}
+	maxErrors := c.conf.MaxConsecutiveErrors
+	if maxErrors <= 0 {
+		maxErrors = defaultMaxConsecutiveErrors
+	}
+	return c.health.snapshot(unhealthyAfter, maxErrors)
+}
+
+// Healthy reports whether the consumer is running and its receive loop is
+// making progress, see ConsumerConfig.UnhealthyAfter and MaxConsecutiveErrors
+func (c *Consumer[T]) Healthy() bool {
+	return c.Health().Healthy
+}
+
+// ReadinessHandler returns an HTTP handler for readiness probes that responds
+// 503 with the health snapshot while the consumer is unhealthy
+func (c *Consumer[T]) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := c.Health()
+		w.Header().Set("Content-Type", "application/json")
+		if !h.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(h)
+	}
+}
@@ -0,0 +1,74 @@
+package rocketmq
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConsumerHealth(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(h *consumerHealth)
+		expect bool
+	}{
+		{
+			name:   "not started",
+			setup:  func(h *consumerHealth) {},
+			expect: false,
+		},
+		{
+			name:   "started",
+			setup:  func(h *consumerHealth) { h.start() },
+			expect: true,
+		},
+		{
+			name: "stale receive",
+			setup: func(h *consumerHealth) {
+				h.start()
+				h.lastReceive.Store(time.Now().Add(-time.Hour).UnixNano())
+			},
+			expect: false,
+		},
+		{
+			name: "too many errors",
+			setup: func(h *consumerHealth) {
+				h.start()
+				for i := 0; i < 3; i++ {
+					h.receiveFailed(errors.New("rpc unavailable"))
+				}
+			},
+			expect: false,
+		},
+		{
+			name: "recovered after errors",
+			setup: func(h *consumerHealth) {
+				h.start()
+				for i := 0; i < 3; i++ {
+					h.receiveFailed(errors.New("rpc unavailable"))
+				}
+				h.receiveSucceeded()
+			},
+			expect: true,
+		},
+		{
+			name: "stopped",
+			setup: func(h *consumerHealth) {
+				h.start()
+				h.stop()
+			},
+			expect: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &consumerHealth{}
+			tt.setup(h)
+			s := h.snapshot(time.Minute, 3)
+			if s.Healthy != tt.expect {
+				t.Fatalf("Healthy = %v, want %v (reason: %s)", s.Healthy, tt.expect, s.Reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package rocketmq
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/zeromicro/go-zero/core/logc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// DefaultIdempotencyTTL is how long processed messages are remembered
+const DefaultIdempotencyTTL = 24 * time.Hour
+
+// IdempotencyState is the state of a message key in an IdempotencyStore
+type IdempotencyState int
+
+const (
+	// IdempotencyClaimed means the caller now owns the key and must process the message
+	IdempotencyClaimed IdempotencyState = iota
+	// IdempotencyProcessing means another delivery is processing the message
+	IdempotencyProcessing
+	// IdempotencyDone means the message was processed already
+	IdempotencyDone
+)
+
+// IdempotencyStore records which messages are being or have been processed
+type IdempotencyStore interface {
+	// Claim marks key as processing by owner for ttl unless it is recorded already
+	Claim(ctx context.Context, key, owner string, ttl time.Duration) (IdempotencyState, error)
+	// Complete marks key as processed for ttl
+	Complete(ctx context.Context, key string, ttl time.Duration) error
+	// Release drops the claim of owner so a redelivery can process the message
+	Release(ctx context.Context, key, owner string) error
+}
+
+// IdempotencyKeyFunc returns the deduplication key of a message
+type IdempotencyKeyFunc func(msg *rmq.MessageView) string
+
+// MessageIDKey deduplicates by message id, which only catches broker redeliveries
+func MessageIDKey(msg *rmq.MessageView) string {
+	return msg.GetMessageId()
+}
+
+// MessageKeysKey deduplicates by the first message key (see WithKeys), which also
+// catches a producer sending the same business message twice, e.g. an order id.
+// Messages without keys fall back to the message id
+func MessageKeysKey(msg *rmq.MessageView) string {
+	if keys := msg.GetKeys(); len(keys) > 0 && keys[0] != "" {
+		return keys[0]
+	}
+	return msg.GetMessageId()
+}
+
+type idempotency struct {
+	store IdempotencyStore
+	key   IdempotencyKeyFunc
+	ttl   time.Duration
+}
+
+// IdempotencyOption configures EnableIdempotency
+type IdempotencyOption func(*idempotency)
+
+// WithIdempotencyKey sets the key messages are deduplicated by, default MessageIDKey
+func WithIdempotencyKey(fn IdempotencyKeyFunc) IdempotencyOption {
+	return func(i *idempotency) {
+		i.key = fn
+	}
+}
+
+// WithIdempotencyTTL sets how long processed messages are remembered, default 24h
+func WithIdempotencyTTL(ttl time.Duration) IdempotencyOption {
+	return func(i *idempotency) {
+		i.ttl = ttl
+	}
+}
+
+// EnableIdempotency skips messages that were processed already, e.g. redelivered
+// after the invisible duration expired or an ack was lost. Call it before Start.
+// A message being processed by another delivery is retried later, so a slow
+// handler is not run twice concurrently
+func (c *Consumer[T]) EnableIdempotency(store IdempotencyStore, opts ...IdempotencyOption) {
+	i := &idempotency{store: store, key: MessageIDKey, ttl: DefaultIdempotencyTTL}
+	for _, opt := range opts {
+		opt(i)
+	}
+	c.idempotency = i
+}
+
+// idempotencyClaim is a claimed delivery, completed after the handler succeeded
+// and released otherwise. A nil claim does nothing
+type idempotencyClaim struct {
+	i         *idempotency
+	key       string
+	owner     string
+	completed bool
+}
+
+// claim checks msg against the store, handled is true when msg needs no
+// processing and was acked or deferred, with result for the metrics
+func (c *Consumer[T]) claim(ctx context.Context, msg *rmq.MessageView) (cl *idempotencyClaim, result string, handled bool) {
+	i := c.idempotency
+	if i == nil {
+		return nil, "", false
+	}
+	span := trace.SpanFromContext(ctx)
+
+	key := i.key(msg)
+	if key == "" {
+		return nil, "", false
+	}
+	key = c.group() + ":" + msg.GetTopic() + ":" + key
+	owner := newOwner()
+
+	// 认领到处理超时为止，和消息不可见时间一致
+	state, err := i.store.Claim(ctx, key, owner, invisibleDuration)
+	if err != nil {
+		err = fmt.Errorf("claim idempotency key %s: %w", key, err)
+		logc.Errorf(ctx, "%v, msgId: %s", err, msg.GetMessageId())
+		span.RecordError(err)
+		c.onFailure(ctx, msg, err, false)
+		return nil, resultFailed, true
+	}
+
+	switch state {
+	case IdempotencyDone:
+		span.SetAttributes(attribute.Bool("message.duplicate", true))
+		logc.Infof(ctx, "skip processed message, key: %s, msgId: %s", key, msg.GetMessageId())
+		ackCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second*30)
+		defer cancel()
+		if err := c.consumer.Ack(ackCtx, msg); err != nil {
+			span.RecordError(err)
+			return nil, resultAckError, true
+		}
+		return nil, resultDuplicate, true
+	case IdempotencyProcessing:
+		// 另一次投递正在处理，稍后再看结果
+		delay := c.retry.Backoff
+		if delay <= 0 {
+			delay = defaultBackoff
+		}
+		span.SetAttributes(attribute.Int64("consumer.retry_delay_ms", delay.Milliseconds()))
+		if err := c.consumer.ChangeInvisibleDuration(msg, delay); err != nil {
+			logc.Errorf(ctx, "change invisible duration failed: %v, msgId: %s", err, msg.GetMessageId())
+			span.RecordError(err)
+		}
+		return nil, resultDeferred, true
+	}
+	return &idempotencyClaim{i: i, key: key, owner: owner}, "", false
+}
+
+// complete records the message as processed, a failure only risks a duplicate
+func (cl *idempotencyClaim) complete(ctx context.Context) {
+	if cl == nil {
+		return
+	}
+	cl.completed = true
+	if err := cl.i.store.Complete(context.WithoutCancel(ctx), cl.key, cl.i.ttl); err != nil {
+		logc.Errorf(ctx, "complete idempotency key %s failed: %v", cl.key, err)
+	}
+}
+
+// release drops the claim unless the message was completed
+func (cl *idempotencyClaim) release(ctx context.Context) {
+	if cl == nil || cl.completed {
+		return
+	}
+	if err := cl.i.store.Release(context.WithoutCancel(ctx), cl.key, cl.owner); err != nil {
+		// 认领在 invisibleDuration 后过期
+		logc.Errorf(ctx, "release idempotency key %s failed: %v", cl.key, err)
+	}
+}
+
+func newOwner() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+const (
+	idempotencyDoneValue      = "done"
+	idempotencyProcessingMark = "processing:"
+)
+
+// releaseScript deletes the key only while owner still holds the claim
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// RedisIdempotencyStore keeps the idempotency keys in redis, e.g. a client from xredis.Init
+type RedisIdempotencyStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisIdempotencyStore stores keys under prefix, default "rocketmq:idempotency:"
+func NewRedisIdempotencyStore(client redis.UniversalClient, prefix string) *RedisIdempotencyStore {
+	if prefix == "" {
+		prefix = "rocketmq:idempotency:"
+	}
+	return &RedisIdempotencyStore{client: client, prefix: prefix}
+}
+
+func (s *RedisIdempotencyStore) Claim(ctx context.Context, key, owner string, ttl time.Duration) (IdempotencyState, error) {
+	key = s.prefix + key
+	ok, err := s.client.SetNX(ctx, key, idempotencyProcessingMark+owner, ttl).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ok {
+		return IdempotencyClaimed, nil
+	}
+
+	val, err := s.client.Get(ctx, key).Result()
+	switch {
+	case errors.Is(err, redis.Nil):
+		// 刚好过期，按处理中稍后重试
+		return IdempotencyProcessing, nil
+	case err != nil:
+		return 0, err
+	case val == idempotencyDoneValue:
+		return IdempotencyDone, nil
+	default:
+		return IdempotencyProcessing, nil
+	}
+}
+
+func (s *RedisIdempotencyStore) Complete(ctx context.Context, key string, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+key, idempotencyDoneValue, ttl).Err()
+}
+
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key, owner string) error {
+	return releaseScript.Run(ctx, s.client, []string{s.prefix + key}, idempotencyProcessingMark+owner).Err()
+}
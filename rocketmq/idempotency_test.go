@@ -0,0 +1,118 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+// memIdempotencyStore mimics RedisIdempotencyStore without expiry
+type memIdempotencyStore struct {
+	mu       sync.Mutex
+	values   map[string]string
+	claimErr error
+}
+
+func (s *memIdempotencyStore) Claim(_ context.Context, key, owner string, _ time.Duration) (IdempotencyState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.claimErr != nil {
+		return 0, s.claimErr
+	}
+	switch v, ok := s.values[key]; {
+	case !ok:
+		s.values[key] = owner
+		return IdempotencyClaimed, nil
+	case v == idempotencyDoneValue:
+		return IdempotencyDone, nil
+	default:
+		return IdempotencyProcessing, nil
+	}
+}
+
+func (s *memIdempotencyStore) Complete(_ context.Context, key string, _ time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = idempotencyDoneValue
+	return nil
+}
+
+func (s *memIdempotencyStore) Release(_ context.Context, key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.values[key] == owner {
+		delete(s.values, key)
+	}
+	return nil
+}
+
+type countingHandler struct {
+	calls int
+	err   error
+}
+
+func (h *countingHandler) Consume(context.Context, []byte) error {
+	h.calls++
+	return h.err
+}
+
+func (h *countingHandler) ErrorHandler(context.Context, []byte, error) {}
+
+func TestConsumerIdempotency(t *testing.T) {
+	// group:topic:key, the message view in tests has no topic
+	const key = "billing::order-1"
+	tests := []struct {
+		name          string
+		stored        map[string]string
+		claimErr      error
+		handlerErr    error
+		wantCalls     int
+		wantAcked     int
+		wantRedeliver bool
+		wantStored    string
+	}{
+		{name: "first delivery", wantCalls: 1, wantAcked: 1, wantStored: idempotencyDoneValue},
+		{name: "processed before", stored: map[string]string{key: idempotencyDoneValue}, wantAcked: 1, wantStored: idempotencyDoneValue},
+		{name: "processing elsewhere", stored: map[string]string{key: "other"}, wantRedeliver: true, wantStored: "other"},
+		{name: "handler error releases", handlerErr: errors.New("declined"), wantCalls: 1, wantRedeliver: true},
+		{name: "store error retries", claimErr: errors.New("redis down"), wantRedeliver: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memIdempotencyStore{values: map[string]string{}, claimErr: tt.claimErr}
+			for k, v := range tt.stored {
+				store.values[k] = v
+			}
+			sc := &fakeSimpleConsumer{}
+			h := &countingHandler{err: tt.handlerErr}
+			c := &Consumer[[]byte]{
+				conf:     &ConsumerConfig{ConsumerGroup: "billing"},
+				consumer: sc,
+				handler:  h,
+				codec:    RawCodec,
+				retry:    RetryPolicy{Backoff: time.Second}.withDefaults(),
+			}
+			c.EnableIdempotency(store, WithIdempotencyKey(func(*rmq.MessageView) string { return "order-1" }))
+
+			c.process(&rmq.MessageView{})
+
+			if h.calls != tt.wantCalls {
+				t.Errorf("handler calls = %d, want %d", h.calls, tt.wantCalls)
+			}
+			if sc.acked != tt.wantAcked {
+				t.Errorf("acked = %d, want %d", sc.acked, tt.wantAcked)
+			}
+			if (len(sc.invisible) > 0) != tt.wantRedeliver {
+				t.Errorf("invisible changes = %v, want redeliver %v", sc.invisible, tt.wantRedeliver)
+			}
+			if got := store.values[key]; got != tt.wantStored {
+				t.Errorf("stored %q, want %q", got, tt.wantStored)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+// lifecycleConsumer hands out one message, then long polls until the receive
+// context is cancelled
+type lifecycleConsumer struct {
+	fakeSimpleConsumer
+	startErr    error
+	once        sync.Once
+	ackedAtStop int
+	stopped     bool
+}
+
+func (f *lifecycleConsumer) Start() error { return f.startErr }
+
+func (f *lifecycleConsumer) Receive(ctx context.Context, _ int32, _ time.Duration) ([]*rmq.MessageView, error) {
+	var msgs []*rmq.MessageView
+	f.once.Do(func() { msgs = []*rmq.MessageView{{}} })
+	if msgs != nil {
+		return msgs, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *lifecycleConsumer) GracefulStop() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+	f.ackedAtStop = f.acked
+	return nil
+}
+
+// blockingHandler fails every message (the test message has no body) and
+// blocks in ErrorHandler until released
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingHandler) Consume(context.Context, string) error { return nil }
+
+func (h *blockingHandler) ErrorHandler(context.Context, string, error) {
+	close(h.started)
+	<-h.release
+}
+
+func newLifecycleConsumer(sc rmq.SimpleConsumer, h ConsumeHandler[string]) *Consumer[string] {
+	c := &Consumer[string]{
+		consumer: sc,
+		handler:  h,
+		conf:     &ConsumerConfig{Topic: "orders", ConsumerGroup: "billing"},
+		retry:    RetryPolicy{}.withDefaults(),
+		done:     make(chan struct{}),
+	}
+	c.receiveCtx, c.cancelReceive = context.WithCancel(context.Background())
+	return c
+}
+
+func TestConsumerStop(t *testing.T) {
+	tests := []struct {
+		name      string
+		releaseIn time.Duration
+		timeout   time.Duration
+		wantErr   error
+		wantAcked int
+	}{
+		{name: "drains in-flight messages", releaseIn: 50 * time.Millisecond, timeout: 2 * time.Second, wantAcked: 1},
+		{name: "deadline", releaseIn: 300 * time.Millisecond, timeout: 50 * time.Millisecond, wantErr: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &lifecycleConsumer{}
+			h := &blockingHandler{started: make(chan struct{}), release: make(chan struct{})}
+			c := newLifecycleConsumer(sc, h)
+
+			if err := c.Start(); err != nil {
+				t.Fatalf("Start() error = %v", err)
+			}
+			select {
+			case <-h.started:
+			case <-time.After(2 * time.Second):
+				t.Fatal("message was not processed")
+			}
+			time.AfterFunc(tt.releaseIn, func() { close(h.release) })
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+			if err := c.Stop(ctx); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Stop() error = %v, want %v", err, tt.wantErr)
+			}
+			if err := c.Stop(ctx); err != nil {
+				t.Errorf("second Stop() error = %v", err)
+			}
+
+			sc.mu.Lock()
+			defer sc.mu.Unlock()
+			if !sc.stopped {
+				t.Fatal("simple consumer was not stopped")
+			}
+			if sc.ackedAtStop != tt.wantAcked {
+				t.Errorf("acked before GracefulStop = %d, want %d", sc.ackedAtStop, tt.wantAcked)
+			}
+		})
+	}
+}
+
+func TestConsumerStartError(t *testing.T) {
+	errDown := errors.New("proxy unavailable")
+	c := newLifecycleConsumer(&lifecycleConsumer{startErr: errDown}, &blockingHandler{})
+	if err := c.Start(); !errors.Is(err, errDown) {
+		t.Fatalf("Start() error = %v, want %v", err, errDown)
+	}
+	if c.Workers() != 0 {
+		t.Errorf("started %d workers after a failed Start", c.Workers())
+	}
+}
@@ -0,0 +1,129 @@
+package rocketmq
+
+import (
+	"context"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/zeromicro/go-zero/core/metric"
+
+	"gomod.pri/golib/xtrace"
+)
+
+// results of the messages counter
+const (
+	resultReceived  = "received"
+	resultAcked     = "acked"
+	resultFailed    = "failed"    // handler error, panic or decode error, the retry policy applies
+	resultAckError  = "ack_error" // processed but the ack failed, the message is redelivered
+	resultDuplicate = "duplicate" // processed before, acked without calling the handler
+	resultDeferred  = "deferred"  // being processed by another delivery, retried later
+)
+
+// allTopicsLabel is the topic label of receive errors of a consumer of several topics
+const allTopicsLabel = "*"
+
+var (
+	consumerMessages = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: "rocketmq",
+		Subsystem: "consumer",
+		Name:      "messages_total",
+		Help:      "Consumed messages, partitioned by topic, consumer group and result.",
+		Labels:    []string{"topic", "group", "result"},
+	})
+
+	consumerReceiveErrors = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: "rocketmq",
+		Subsystem: "consumer",
+		Name:      "receive_errors_total",
+		Help:      "Failed Receive calls, not counting empty long polls, partitioned by topic (\"*\" for a consumer of several topics) and consumer group.",
+		Labels:    []string{"topic", "group"},
+	})
+
+	consumerProcessDuration = xtrace.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: "rocketmq",
+		Subsystem: "consumer",
+		Name:      "process_duration_ms",
+		Help:      "Time from receive to ack or retry decision in milliseconds, partitioned by topic, consumer group and result.",
+		Labels:    []string{"topic", "group", "result"},
+		Buckets:   []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000},
+	})
+
+	// the simple consumer has no access to broker offsets, the age of the
+	// received messages grows with the backlog and is what alerts should use
+	consumerLag = metric.NewGaugeVec(&metric.GaugeVecOpts{
+		Namespace: "rocketmq",
+		Subsystem: "consumer",
+		Name:      "lag_seconds",
+		Help:      "Estimated consumer lag: age of the oldest message of the last received batch, since it was stored or became deliverable.",
+		Labels:    []string{"topic", "group"},
+	})
+)
+
+// group returns the consumer group label
+func (c *Consumer[T]) group() string {
+	if c.conf == nil {
+		return ""
+	}
+	return c.conf.ConsumerGroup
+}
+
+func (c *Consumer[T]) observeReceived(msgs []*rmq.MessageView, now time.Time) {
+	if len(msgs) == 0 {
+		return
+	}
+	c.stats.receive(msgs, now)
+	topic, group := msgs[0].GetTopic(), c.group()
+	consumerMessages.Add(float64(len(msgs)), topic, group, resultReceived)
+	consumerLag.Set(messageLag(msgs, now).Seconds(), topic, group)
+}
+
+func (c *Consumer[T]) observeReceiveError() {
+	c.stats.receiveErrors.Add(1)
+	consumerReceiveErrors.Inc(c.receiveTopic(), c.group())
+}
+
+// receiveTopic returns the topic label of a Receive call, which polls every
+// subscribed topic at once: the topic if there is only one, otherwise
+// allTopicsLabel
+func (c *Consumer[T]) receiveTopic() string {
+	c.routesMu.RLock()
+	defer c.routesMu.RUnlock()
+	if len(c.subscribed) == 1 {
+		for topic := range c.subscribed {
+			return topic
+		}
+	}
+	return allTopicsLabel
+}
+
+func (c *Consumer[T]) observeResult(ctx context.Context, msg *rmq.MessageView, result string, receiveAt time.Time) {
+	c.stats.finish(msg, result)
+	topic, group := msg.GetTopic(), c.group()
+	consumerMessages.Inc(topic, group, result)
+	consumerProcessDuration.Observe(ctx, float64(time.Since(receiveAt).Microseconds())/1000, topic, group, result)
+}
+
+// messageLag returns the age of the oldest message
+func messageLag(msgs []*rmq.MessageView, now time.Time) time.Duration {
+	var lag time.Duration
+	for _, msg := range msgs {
+		if since := deliverableSince(msg.GetBornTimestamp(), msg.GetDeliveryTimestamp()); !since.IsZero() {
+			lag = max(lag, now.Sub(since))
+		}
+	}
+	return lag
+}
+
+// deliverableSince returns when a message became consumable: its delivery time
+// for delayed messages, so a scheduled delay is not mistaken for backlog
+func deliverableSince(born, delivery *time.Time) time.Time {
+	var since time.Time
+	if born != nil {
+		since = *born
+	}
+	if delivery != nil && delivery.After(since) {
+		since = *delivery
+	}
+	return since
+}
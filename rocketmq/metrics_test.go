@@ -0,0 +1,64 @@
+package rocketmq
+
+import (
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+func TestDeliverableSince(t *testing.T) {
+	born := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	later := born.Add(time.Hour)
+	earlier := born.Add(-time.Hour)
+
+	tests := []struct {
+		name     string
+		born     *time.Time
+		delivery *time.Time
+		want     time.Time
+	}{
+		{name: "born", born: &born, want: born},
+		{name: "delayed", born: &born, delivery: &later, want: later},
+		{name: "delivery before born", born: &born, delivery: &earlier, want: born},
+		{name: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := deliverableSince(tt.born, tt.delivery); !got.Equal(tt.want) {
+				t.Errorf("deliverableSince = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessageLagWithoutTimestamps(t *testing.T) {
+	if lag := messageLag([]*rmq.MessageView{{}, {}}, time.Now()); lag != 0 {
+		t.Errorf("lag = %s, want 0 for messages without timestamps", lag)
+	}
+}
+
+func TestReceiveTopic(t *testing.T) {
+	tests := []struct {
+		name       string
+		subscribed []string
+		want       string
+	}{
+		{name: "single topic", subscribed: []string{"orders"}, want: "orders"},
+		{name: "subscriptions", subscribed: []string{"orders", "payments"}, want: allTopicsLabel},
+		{name: "none", want: allTopicsLabel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Consumer[string]{conf: &ConsumerConfig{}, subscribed: make(map[string]struct{})}
+			for _, topic := range tt.subscribed {
+				c.subscribed[topic] = struct{}{}
+			}
+			if got := c.receiveTopic(); got != tt.want {
+				t.Errorf("receiveTopic() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package rocketmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/sqlx"
+)
+
+// OutboxIDKey is the property carrying the outbox row id of a relayed message.
+// A crash between sending and marking the row sent relays it again, consumers
+// that must not see duplicates key their idempotency on it (see WithIdempotencyKey)
+const OutboxIDKey = "OUTBOX_ID"
+
+// DefaultOutboxTable is the table used unless WithOutboxTable is given
+const DefaultOutboxTable = "rocketmq_outbox"
+
+const (
+	outboxPending = 0
+	outboxSent    = 1
+	outboxFailed  = 2 // gave up after MaxAttempts, see last_error
+
+	defaultOutboxInterval    = time.Second
+	defaultOutboxBatch       = 100
+	defaultOutboxMaxAttempts = 16
+	defaultOutboxRetention   = 7 * 24 * time.Hour
+	outboxPurgeBatch         = 1000
+	maxOutboxErrorLen        = 1024
+)
+
+// OutboxSchema creates the outbox table, %s is the table name. Times are unix
+// milliseconds so the DSN needs no parseTime
+const OutboxSchema = `CREATE TABLE IF NOT EXISTS %s (
+  id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+  topic VARCHAR(255) NOT NULL,
+  tag VARCHAR(255) NOT NULL DEFAULT '',
+  msg_keys VARCHAR(1024) NOT NULL DEFAULT '',
+  message_group VARCHAR(255) NOT NULL DEFAULT '',
+  properties TEXT NOT NULL,
+  body MEDIUMBLOB NOT NULL,
+  deliver_at BIGINT NOT NULL DEFAULT 0,
+  status TINYINT NOT NULL DEFAULT 0,
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at BIGINT NOT NULL,
+  last_error VARCHAR(1024) NOT NULL DEFAULT '',
+  created_at BIGINT NOT NULL,
+  sent_at BIGINT NOT NULL DEFAULT 0,
+  PRIMARY KEY (id),
+  KEY idx_status_next_attempt (status, next_attempt_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`
+
+var outboxTableName = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// OutboxOption configures an Outbox
+type OutboxOption func(*Outbox)
+
+// WithOutboxTable stores the messages in table instead of DefaultOutboxTable
+func WithOutboxTable(table string) OutboxOption {
+	return func(o *Outbox) {
+		o.table = table
+	}
+}
+
+// WithOutboxInterval sets how often the relay polls for pending messages,
+// default 1s. A full batch is followed by the next one right away
+func WithOutboxInterval(interval time.Duration) OutboxOption {
+	return func(o *Outbox) {
+		o.interval = interval
+	}
+}
+
+// WithOutboxBatch sets how many messages the relay sends per transaction, default 100
+func WithOutboxBatch(n int) OutboxOption {
+	return func(o *Outbox) {
+		o.batch = n
+	}
+}
+
+// WithOutboxRetry sets how often a message is sent before it is marked failed
+// and the backoff between attempts, default 16 attempts from 1s up to 10m
+func WithOutboxRetry(maxAttempts int, backoff, maxBackoff time.Duration) OutboxOption {
+	return func(o *Outbox) {
+		o.maxAttempts = maxAttempts
+		o.backoff = RetryPolicy{Backoff: backoff, MaxBackoff: maxBackoff}
+	}
+}
+
+// WithOutboxRetention sets how long sent messages are kept, default 7 days
+func WithOutboxRetention(retention time.Duration) OutboxOption {
+	return func(o *Outbox) {
+		o.retention = retention
+	}
+}
+
+// Outbox implements the transactional outbox: Add stores a message in the
+// business transaction, so it is published if and only if the transaction
+// commits, and the relay started by Start publishes the stored messages.
+// Messages are published at least once and in id order per relay batch; run
+// the relay on any number of instances, rows are claimed with SKIP LOCKED
+// (MySQL 8). conn usually comes from xutils/db.GetDB, producer's retry and
+// breaker apply to every send
+type Outbox struct {
+	conn        sqlx.SqlConn
+	producer    *Producer
+	table       string
+	interval    time.Duration
+	batch       int
+	maxAttempts int
+	backoff     RetryPolicy
+	retention   time.Duration
+	now         func() time.Time
+
+	done     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewOutbox creates an outbox in conn that relays through producer
+func NewOutbox(conn sqlx.SqlConn, producer *Producer, opts ...OutboxOption) (*Outbox, error) {
+	if conn == nil || producer == nil {
+		return nil, errors.New("outbox needs a connection and a producer")
+	}
+	o := &Outbox{
+		conn:        conn,
+		producer:    producer,
+		table:       DefaultOutboxTable,
+		interval:    defaultOutboxInterval,
+		batch:       defaultOutboxBatch,
+		maxAttempts: defaultOutboxMaxAttempts,
+		backoff:     RetryPolicy{Backoff: time.Second},
+		retention:   defaultOutboxRetention,
+		now:         time.Now,
+		done:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if !outboxTableName.MatchString(o.table) {
+		return nil, fmt.Errorf("invalid outbox table name %q", o.table)
+	}
+	o.backoff = o.backoff.withDefaults()
+	o.batch = max(o.batch, 1)
+	o.maxAttempts = max(o.maxAttempts, 1)
+	return o, nil
+}
+
+// CreateTable creates the outbox table if it does not exist
+func (o *Outbox) CreateTable(ctx context.Context) error {
+	if _, err := o.conn.ExecCtx(ctx, fmt.Sprintf(OutboxSchema, o.table)); err != nil {
+		return fmt.Errorf("create outbox table %s: %w", o.table, err)
+	}
+	return nil
+}
+
+// Add stores a message for topic in session, the business transaction, with
+// the trace context of ctx. Publish options apply as for Publish, a delay
+// counts from now, not from when the relay sends the message
+func (o *Outbox) Add(ctx context.Context, session sqlx.Session, topic Topic, body []byte, opts ...PublishOptionFunc) error {
+	opt := &PublishOption{}
+	for _, fn := range opts {
+		fn(opt)
+	}
+	now := o.now()
+	deliverAt, err := opt.deliveryTime(now, o.producer.maxDelay)
+	if err != nil {
+		return err
+	}
+
+	msg := newMessage(ctx, string(topic), body, opt)
+	row, err := newOutboxRow(msg, deliverAt, now)
+	if err != nil {
+		return err
+	}
+	_, err = session.ExecCtx(ctx, fmt.Sprintf("INSERT INTO %s (topic, tag, msg_keys, message_group, properties, body, deliver_at, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)", o.table),
+		row.Topic, row.Tag, row.Keys, row.MessageGroup, row.Properties, row.Body, row.DeliverAt, row.NextAttemptAt, row.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("add outbox message for %s: %w", topic, err)
+	}
+	return nil
+}
+
+// Start starts the relay and returns right away
+func (o *Outbox) Start() {
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		o.relay()
+	}()
+}
+
+// Stop stops the relay after the batch being sent
+func (o *Outbox) Stop() {
+	o.stopOnce.Do(func() {
+		close(o.done)
+	})
+	o.wg.Wait()
+}
+
+func (o *Outbox) relay() {
+	ticker := time.NewTicker(o.interval)
+	defer ticker.Stop()
+	lastPurge := time.Time{}
+
+	for {
+		sent, err := o.RelayOnce(context.Background())
+		if err != nil {
+			logx.Errorf("relay outbox %s failed: %v", o.table, err)
+		}
+		if now := o.now(); now.Sub(lastPurge) >= time.Hour {
+			lastPurge = now
+			if err := o.purge(context.Background()); err != nil {
+				logx.Errorf("purge outbox %s failed: %v", o.table, err)
+			}
+		}
+		if err == nil && sent == o.batch {
+			// 还有积压，立即处理下一批
+			select {
+			case <-o.done:
+				return
+			default:
+				continue
+			}
+		}
+		select {
+		case <-o.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce sends one batch of due messages and returns how many it handled,
+// sent or rescheduled. Start calls it in a loop, call it directly to drain the
+// outbox from a job instead
+func (o *Outbox) RelayOnce(ctx context.Context) (int, error) {
+	var handled int
+	err := o.conn.TransactCtx(ctx, func(ctx context.Context, session sqlx.Session) error {
+		var rows []*outboxRow
+		query := fmt.Sprintf("SELECT id, topic, tag, msg_keys, message_group, properties, body, deliver_at, attempts FROM %s WHERE status = ? AND next_attempt_at <= ? ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED", o.table)
+		if err := session.QueryRowsPartialCtx(ctx, &rows, query, outboxPending, o.now().UnixMilli(), o.batch); err != nil {
+			return fmt.Errorf("select outbox messages: %w", err)
+		}
+		for _, row := range rows {
+			err := o.send(ctx, session, row)
+			if errors.Is(err, ErrBreakerOpen) {
+				// broker 不可用，不计入重试次数，等待熔断恢复
+				break
+			}
+			if err != nil {
+				return err
+			}
+			handled++
+		}
+		return nil
+	})
+	return handled, err
+}
+
+// send publishes row and records the outcome. An open breaker and a failed
+// update stop the batch
+func (o *Outbox) send(ctx context.Context, session sqlx.Session, row *outboxRow) error {
+	msg, err := row.message(o.now())
+	if err == nil {
+		_, err = o.producer.send(ctx, msg, o.producer.timeout())
+	}
+
+	if errors.Is(err, ErrBreakerOpen) {
+		return err
+	}
+
+	now := o.now()
+	if err == nil {
+		_, err = session.ExecCtx(ctx, fmt.Sprintf("UPDATE %s SET status = ?, attempts = attempts + 1, sent_at = ? WHERE id = ?", o.table),
+			outboxSent, now.UnixMilli(), row.ID)
+		if err != nil {
+			return fmt.Errorf("mark outbox message %d sent: %w", row.ID, err)
+		}
+		return nil
+	}
+
+	attempts := row.Attempts + 1
+	status, next := outboxPending, now.Add(o.backoff.backoff(attempts))
+	if attempts >= o.maxAttempts || errors.Is(err, ErrInvalidDelay) {
+		status = outboxFailed
+		logx.Errorf("give up outbox message %d to %s after %d attempts: %v", row.ID, row.Topic, attempts, err)
+	}
+	cause := err.Error()
+	if len(cause) > maxOutboxErrorLen {
+		cause = cause[:maxOutboxErrorLen]
+	}
+	_, err = session.ExecCtx(ctx, fmt.Sprintf("UPDATE %s SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ? WHERE id = ?", o.table),
+		status, attempts, next.UnixMilli(), cause, row.ID)
+	if err != nil {
+		return fmt.Errorf("reschedule outbox message %d: %w", row.ID, err)
+	}
+	return nil
+}
+
+// purge deletes the sent messages older than the retention
+func (o *Outbox) purge(ctx context.Context) error {
+	before := o.now().Add(-o.retention).UnixMilli()
+	_, err := o.conn.ExecCtx(ctx, fmt.Sprintf("DELETE FROM %s WHERE status = ? AND sent_at < ? LIMIT %d", o.table, outboxPurgeBatch),
+		outboxSent, before)
+	return err
+}
+
+// outboxRow is a message stored in the outbox
+type outboxRow struct {
+	ID            int64  `db:"id"`
+	Topic         string `db:"topic"`
+	Tag           string `db:"tag"`
+	Keys          string `db:"msg_keys"` // JSON array
+	MessageGroup  string `db:"message_group"`
+	Properties    string `db:"properties"` // JSON object
+	Body          []byte `db:"body"`
+	DeliverAt     int64  `db:"deliver_at"` // unix milliseconds, 0 for right away
+	Attempts      int    `db:"attempts"`
+	NextAttemptAt int64  `db:"next_attempt_at"`
+	CreatedAt     int64  `db:"created_at"`
+}
+
+func newOutboxRow(msg *rmq.Message, deliverAt, now time.Time) (*outboxRow, error) {
+	row := &outboxRow{
+		Topic:         msg.Topic,
+		Body:          msg.Body,
+		NextAttemptAt: now.UnixMilli(),
+		CreatedAt:     now.UnixMilli(),
+	}
+	if row.Body == nil {
+		row.Body = []byte{}
+	}
+	if tag := msg.GetTag(); tag != nil {
+		row.Tag = *tag
+	}
+	if group := msg.GetMessageGroup(); group != nil {
+		row.MessageGroup = *group
+	}
+	if keys := msg.GetKeys(); len(keys) > 0 {
+		b, err := json.Marshal(keys)
+		if err != nil {
+			return nil, err
+		}
+		row.Keys = string(b)
+	}
+	props, err := json.Marshal(msg.GetProperties())
+	if err != nil {
+		return nil, err
+	}
+	row.Properties = string(props)
+	if !deliverAt.IsZero() {
+		row.DeliverAt = deliverAt.UnixMilli()
+	}
+	return row, nil
+}
+
+// message rebuilds the stored message, a delivery time already passed is sent right away
+func (r *outboxRow) message(now time.Time) (*rmq.Message, error) {
+	msg := &rmq.Message{Topic: r.Topic, Body: r.Body}
+	if r.Tag != "" {
+		msg.SetTag(r.Tag)
+	}
+	if r.MessageGroup != "" {
+		msg.SetMessageGroup(r.MessageGroup)
+	}
+	if r.Keys != "" {
+		var keys []string
+		if err := json.Unmarshal([]byte(r.Keys), &keys); err != nil {
+			return nil, fmt.Errorf("outbox message %d keys: %w", r.ID, err)
+		}
+		msg.SetKeys(keys...)
+	}
+	props := map[string]string{}
+	if r.Properties != "" {
+		if err := json.Unmarshal([]byte(r.Properties), &props); err != nil {
+			return nil, fmt.Errorf("outbox message %d properties: %w", r.ID, err)
+		}
+	}
+	for k, v := range props {
+		msg.AddProperty(k, v)
+	}
+	msg.AddProperty(OutboxIDKey, strconv.FormatInt(r.ID, 10))
+	if at := time.UnixMilli(r.DeliverAt); r.DeliverAt > 0 && at.After(now) {
+		msg.SetDelayTimestamp(at)
+	}
+	return msg, nil
+}
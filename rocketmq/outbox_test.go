@@ -0,0 +1,184 @@
+package rocketmq
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/zeromicro/go-zero/core/stores/sqlx"
+)
+
+// memOutboxRow is a row of memOutboxDB
+type memOutboxRow struct {
+	outboxRow
+	status    int
+	lastError string
+	sentAt    int64
+}
+
+// memOutboxDB understands the statements of Outbox
+type memOutboxDB struct {
+	sqlx.SqlConn
+	mu     sync.Mutex
+	rows   map[int64]*memOutboxRow
+	nextID int64
+}
+
+func newMemOutboxDB() *memOutboxDB {
+	return &memOutboxDB{rows: map[int64]*memOutboxRow{}}
+}
+
+func (db *memOutboxDB) TransactCtx(ctx context.Context, fn func(context.Context, sqlx.Session) error) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return fn(ctx, db)
+}
+
+func (db *memOutboxDB) ExecCtx(_ context.Context, query string, args ...any) (sql.Result, error) {
+	switch {
+	case strings.HasPrefix(query, "INSERT"):
+		db.nextID++
+		db.rows[db.nextID] = &memOutboxRow{outboxRow: outboxRow{
+			ID: db.nextID, Topic: args[0].(string), Tag: args[1].(string), Keys: args[2].(string),
+			MessageGroup: args[3].(string), Properties: args[4].(string), Body: args[5].([]byte),
+			DeliverAt: args[6].(int64), NextAttemptAt: args[7].(int64), CreatedAt: args[8].(int64),
+		}}
+	case strings.Contains(query, "attempts = attempts + 1"):
+		row := db.rows[args[2].(int64)]
+		row.status, row.sentAt = args[0].(int), args[1].(int64)
+		row.Attempts++
+	case strings.HasPrefix(query, "UPDATE"):
+		row := db.rows[args[4].(int64)]
+		row.status, row.Attempts, row.NextAttemptAt, row.lastError = args[0].(int), args[1].(int), args[2].(int64), args[3].(string)
+	default:
+		return nil, errors.New("unexpected statement: " + query)
+	}
+	return nil, nil
+}
+
+func (db *memOutboxDB) QueryRowsPartialCtx(_ context.Context, v any, _ string, args ...any) error {
+	var ids []int64
+	for id, row := range db.rows {
+		if row.status == args[0].(int) && row.NextAttemptAt <= args[1].(int64) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	rows := v.(*[]*outboxRow)
+	for _, id := range ids[:min(len(ids), args[2].(int))] {
+		row := db.rows[id].outboxRow
+		*rows = append(*rows, &row)
+	}
+	return nil
+}
+
+// recordingSendProducer records the sent messages and fails with the queued errors
+type recordingSendProducer struct {
+	rmq.Producer
+	errs []error
+	sent []*rmq.Message
+}
+
+func (f *recordingSendProducer) Send(_ context.Context, msg *rmq.Message) ([]*rmq.SendReceipt, error) {
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	f.sent = append(f.sent, msg)
+	return []*rmq.SendReceipt{{MessageID: "m1"}}, nil
+}
+
+func newTestOutbox(t *testing.T, p *Producer, opts ...OutboxOption) (*Outbox, *memOutboxDB, *time.Time) {
+	t.Helper()
+	db := newMemOutboxDB()
+	o, err := NewOutbox(db, p, opts...)
+	if err != nil {
+		t.Fatalf("NewOutbox() error = %v", err)
+	}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	o.now = func() time.Time { return now }
+	return o, db, &now
+}
+
+func TestOutboxRelay(t *testing.T) {
+	fake := &recordingSendProducer{errs: []error{errors.New("connection refused")}}
+	o, db, now := newTestOutbox(t, &Producer{Producer: fake})
+	ctx := context.Background()
+
+	err := db.TransactCtx(ctx, func(ctx context.Context, session sqlx.Session) error {
+		return o.Add(ctx, session, "orders", []byte(`{"id":1}`), WithShardingKey("order-1"), WithMessageGroup("user-7"))
+	})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	// 第一次发送失败，按退避重新排期
+	if n, err := o.RelayOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("RelayOnce() = %d, %v", n, err)
+	}
+	row := db.rows[1]
+	if row.status != outboxPending || row.Attempts != 1 || row.lastError != "connection refused" || row.NextAttemptAt != now.Add(time.Second).UnixMilli() {
+		t.Fatalf("rescheduled row = %+v", row)
+	}
+	if n, _ := o.RelayOnce(ctx); n != 0 {
+		t.Fatalf("relayed %d messages before the backoff", n)
+	}
+
+	*now = now.Add(time.Second)
+	if n, err := o.RelayOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("RelayOnce() = %d, %v", n, err)
+	}
+	if row.status != outboxSent || row.sentAt != now.UnixMilli() || len(fake.sent) != 1 {
+		t.Fatalf("sent row = %+v, sent %d", row, len(fake.sent))
+	}
+	msg := fake.sent[0]
+	if msg.Topic != "orders" || string(msg.Body) != `{"id":1}` || msg.GetKeys()[0] != "order-1" ||
+		*msg.GetMessageGroup() != "user-7" || msg.GetProperties()[OutboxIDKey] != "1" {
+		t.Errorf("relayed message = %s %s %v %v", msg.Topic, msg.Body, msg.GetKeys(), msg.GetProperties())
+	}
+}
+
+func TestOutboxGiveUp(t *testing.T) {
+	tests := []struct {
+		name       string
+		sendErr    error
+		wantStatus int
+		wantTries  int
+	}{
+		{name: "max attempts", sendErr: errors.New("connection refused"), wantStatus: outboxFailed, wantTries: 2},
+		{name: "breaker open is not an attempt", sendErr: ErrBreakerOpen, wantStatus: outboxPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &recordingSendProducer{errs: []error{tt.sendErr, tt.sendErr}}
+			o, db, now := newTestOutbox(t, &Producer{Producer: fake}, WithOutboxRetry(2, time.Second, time.Second))
+			ctx := context.Background()
+			if err := o.Add(ctx, db, "orders", []byte(`{}`)); err != nil {
+				t.Fatal(err)
+			}
+			for range 2 {
+				if _, err := o.RelayOnce(ctx); err != nil {
+					t.Fatalf("RelayOnce() error = %v", err)
+				}
+				*now = now.Add(time.Second)
+			}
+			if row := db.rows[1]; row.status != tt.wantStatus || row.Attempts != tt.wantTries {
+				t.Errorf("row status = %d, attempts = %d", row.status, row.Attempts)
+			}
+		})
+	}
+}
+
+func TestNewOutboxInvalidTable(t *testing.T) {
+	if _, err := NewOutbox(newMemOutboxDB(), &Producer{}, WithOutboxTable("outbox; DROP TABLE users")); err == nil {
+		t.Fatal("NewOutbox() accepted an invalid table name")
+	}
+}
@@ -0,0 +1,90 @@
+package rocketmq
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// pauseGate holds the workers before Receive while paused, the zero value is running
+type pauseGate struct {
+	mu       sync.Mutex
+	paused   bool
+	pausedAt time.Time
+	resumed  chan struct{} // closed by Resume
+	ctx      context.Context
+	cancel   context.CancelFunc // interrupts the long polling Receive on Pause
+}
+
+// Pause stops issuing Receive without stopping the client, e.g. while a
+// downstream dependency is down. Messages already received are still processed
+// and acked, a Receive waiting for messages is interrupted. Health reports a
+// paused consumer as healthy so probes do not restart it
+func (c *Consumer[T]) Pause() {
+	g := &c.pause
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		return
+	}
+	g.paused, g.pausedAt = true, time.Now()
+	g.resumed = make(chan struct{})
+	if g.cancel != nil {
+		g.cancel()
+		g.ctx, g.cancel = nil, nil
+	}
+	c.health.pause()
+	logx.Infof("rocketmq consumer %s paused", c.group())
+}
+
+// Resume starts receiving again after Pause
+func (c *Consumer[T]) Resume() {
+	g := &c.pause
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		return
+	}
+	g.paused = false
+	close(g.resumed)
+	c.health.resume()
+	logx.Infof("rocketmq consumer %s resumed after %s", c.group(), time.Since(g.pausedAt).Truncate(time.Second))
+}
+
+// Paused reports whether the consumer is paused
+func (c *Consumer[T]) Paused() bool {
+	c.pause.mu.Lock()
+	defer c.pause.mu.Unlock()
+	return c.pause.paused
+}
+
+// awaitResume blocks while paused, it returns false when the worker should exit
+func (c *Consumer[T]) awaitResume(quit <-chan struct{}) bool {
+	g := &c.pause
+	g.mu.Lock()
+	paused, resumed := g.paused, g.resumed
+	g.mu.Unlock()
+	if !paused {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-c.done:
+	case <-quit:
+	}
+	return false
+}
+
+// receiveContext returns the context of the next Receive, cancelled by Stop and Pause
+func (c *Consumer[T]) receiveContext() context.Context {
+	g := &c.pause
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.ctx == nil {
+		g.ctx, g.cancel = context.WithCancel(c.receiveCtx)
+	}
+	return g.ctx
+}
@@ -0,0 +1,96 @@
+package rocketmq
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+// pollingConsumer counts Receive calls, each long polls until its context is cancelled
+type pollingConsumer struct {
+	fakeSimpleConsumer
+	receives    atomic.Int32
+	interrupted atomic.Int32
+}
+
+func (f *pollingConsumer) Start() error        { return nil }
+func (f *pollingConsumer) GracefulStop() error { return nil }
+
+func (f *pollingConsumer) Receive(ctx context.Context, _ int32, _ time.Duration) ([]*rmq.MessageView, error) {
+	f.receives.Add(1)
+	<-ctx.Done()
+	f.interrupted.Add(1)
+	return nil, ctx.Err()
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestConsumerPauseResume(t *testing.T) {
+	sc := &pollingConsumer{}
+	c := newLifecycleConsumer(sc, &blockingHandler{})
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "first receive", func() bool { return sc.receives.Load() == 1 })
+
+	c.Pause()
+	c.Pause()
+	waitFor(t, "receive interrupted", func() bool { return sc.interrupted.Load() == 1 })
+	time.Sleep(20 * time.Millisecond)
+	if n := sc.receives.Load(); n != 1 {
+		t.Fatalf("Receive called %d times while paused, want 1", n)
+	}
+	if !c.Paused() {
+		t.Error("Paused() = false after Pause")
+	}
+	// a paused consumer stays healthy even though nothing is received
+	c.health.lastReceive.Store(time.Now().Add(-time.Hour).UnixNano())
+	if h := c.Health(); !h.Healthy || !h.Paused {
+		t.Errorf("Health() while paused = %+v", h)
+	}
+	if s := c.Stats(); !s.Paused {
+		t.Error("Stats().Paused = false")
+	}
+
+	c.Resume()
+	waitFor(t, "receive after resume", func() bool { return sc.receives.Load() == 2 })
+	if h := c.Health(); !h.Healthy || h.Paused {
+		t.Errorf("Health() after resume = %+v", h)
+	}
+
+	// Stop does not wait for Resume
+	c.Pause()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.Stop(ctx); err != nil {
+		t.Fatalf("Stop() while paused error = %v", err)
+	}
+}
+
+func TestConsumerPauseBeforeStart(t *testing.T) {
+	sc := &pollingConsumer{}
+	c := newLifecycleConsumer(sc, &blockingHandler{})
+	c.Pause()
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := sc.receives.Load(); n != 0 {
+		t.Fatalf("Receive called %d times before Resume", n)
+	}
+	c.Resume()
+	waitFor(t, "receive after resume", func() bool { return sc.receives.Load() == 1 })
+	_ = c.Stop(context.Background())
+}
@@ -0,0 +1,122 @@
+package rocketmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultPingTimeout bounds a Ping whose context has no deadline
+const defaultPingTimeout = 3 * time.Second
+
+// pingEndpoint dials the addresses of endpoint ("host:port;host:port") and
+// succeeds once one of them accepts a connection
+func pingEndpoint(ctx context.Context, endpoint string) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultPingTimeout)
+		defer cancel()
+	}
+
+	var (
+		dialer net.Dialer
+		errs   []error
+	)
+	for _, addr := range strings.Split(endpoint, ";") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			_ = conn.Close()
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return fmt.Errorf("ping rocketmq: no endpoint in %q", endpoint)
+	}
+	return fmt.Errorf("ping rocketmq %s: %w", endpoint, errors.Join(errs...))
+}
+
+// Ping checks that the broker endpoint accepts connections
+func (p *Producer) Ping(ctx context.Context) error {
+	return pingEndpoint(ctx, p.endpoint)
+}
+
+// Ping checks that the broker endpoint accepts connections, see Health for
+// whether the receive loop is making progress
+func (c *Consumer[T]) Ping(ctx context.Context) error {
+	return pingEndpoint(ctx, c.conf.Endpoint)
+}
+
+// ProducerHealth is a snapshot of the producer's broker connectivity
+type ProducerHealth struct {
+	Healthy     bool      `json:"healthy"`
+	LastSend    time.Time `json:"lastSend"` // last successful send, zero before the first
+	LastError   string    `json:"lastError,omitempty"`
+	BreakerOpen bool      `json:"breakerOpen"`
+	Reason      string    `json:"reason,omitempty"`
+}
+
+// producerHealth tracks send results, the zero value is ready to use
+type producerHealth struct {
+	lastSend atomic.Int64 // unix nano
+	mu       sync.Mutex
+	lastErr  error
+}
+
+func (h *producerHealth) record(err error) {
+	if err == nil {
+		h.lastSend.Store(time.Now().UnixNano())
+		return
+	}
+	h.mu.Lock()
+	h.lastErr = err
+	h.mu.Unlock()
+}
+
+// Health pings the broker and reports the producer unhealthy while the ping
+// fails or the breaker is open
+func (p *Producer) Health(ctx context.Context) ProducerHealth {
+	var s ProducerHealth
+	if ts := p.health.lastSend.Load(); ts > 0 {
+		s.LastSend = time.Unix(0, ts)
+	}
+	p.health.mu.Lock()
+	if p.health.lastErr != nil {
+		s.LastError = p.health.lastErr.Error()
+	}
+	p.health.mu.Unlock()
+	s.BreakerOpen = p.breaker.isOpen()
+
+	switch err := p.Ping(ctx); {
+	case err != nil:
+		s.Reason = err.Error()
+	case s.BreakerOpen:
+		s.Reason = "circuit breaker open"
+	default:
+		s.Healthy = true
+	}
+	return s
+}
+
+// ReadinessHandler returns an HTTP handler for readiness probes that responds
+// 503 with the health snapshot while the broker is unreachable
+func (p *Producer) ReadinessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		h := p.Health(r.Context())
+		w.Header().Set("Content-Type", "application/json")
+		if !h.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(h)
+	}
+}
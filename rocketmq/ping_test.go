@@ -0,0 +1,104 @@
+package rocketmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func listen(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	return ln.Addr().String()
+}
+
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestPingEndpoint(t *testing.T) {
+	up, down := listen(t), closedAddr(t)
+
+	tests := []struct {
+		name     string
+		endpoint string
+		wantErr  bool
+	}{
+		{name: "reachable", endpoint: up},
+		{name: "any of several", endpoint: down + ";" + up},
+		{name: "unreachable", endpoint: down, wantErr: true},
+		{name: "empty", endpoint: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := pingEndpoint(context.Background(), tt.endpoint); (err != nil) != tt.wantErr {
+				t.Errorf("pingEndpoint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestProducerHealth(t *testing.T) {
+	up, down := listen(t), closedAddr(t)
+	openBreaker := newBreaker(up, BreakerConfig{FailureThreshold: 1})
+	openBreaker.record(false, errors.New("connection refused"))
+
+	tests := []struct {
+		name       string
+		producer   *Producer
+		wantStatus int
+	}{
+		{name: "healthy", producer: &Producer{endpoint: up}, wantStatus: http.StatusOK},
+		{name: "unreachable", producer: &Producer{endpoint: down}, wantStatus: http.StatusServiceUnavailable},
+		{name: "breaker open", producer: &Producer{endpoint: up, breaker: openBreaker}, wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.producer.ReadinessHandler()(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			var h ProducerHealth
+			if err := json.NewDecoder(rec.Body).Decode(&h); err != nil {
+				t.Fatal(err)
+			}
+			if h.Healthy != (tt.wantStatus == http.StatusOK) || (!h.Healthy && h.Reason == "") {
+				t.Errorf("health = %+v", h)
+			}
+		})
+	}
+
+	// 健康检查不占用熔断器的探测机会
+	if openBreaker.allow() == nil {
+		t.Error("breaker probe taken before the cooldown")
+	}
+}
+
+func TestProducerHealthLastSend(t *testing.T) {
+	fake := &flakySendProducer{errs: []error{errors.New("connection refused")}}
+	p := &Producer{Producer: fake, endpoint: listen(t)}
+	_ = p.PublishWithoutPrefix(context.Background(), "orders", []byte(`{}`))
+	if h := p.Health(context.Background()); !h.LastSend.IsZero() || h.LastError != "connection refused" {
+		t.Fatalf("after failure: %+v", h)
+	}
+	_ = p.PublishWithoutPrefix(context.Background(), "orders", []byte(`{}`))
+	if h := p.Health(context.Background()); h.LastSend.IsZero() {
+		t.Fatalf("after send: %+v", h)
+	}
+}
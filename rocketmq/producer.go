@@ -19,17 +19,34 @@ type ProducerConfig struct {
 	Endpoint    string              `json:"endpoint"`
 	AppId       string              `json:"appId"`
 	Credentials *SessionCredentials `json:"credentials"`
+	// 预先声明要发送的 topic，事务消息回查需要
+	Topics []string `json:"topics,optional"`
+	// MaxDelay 延迟消息的最大延迟，与 broker 的 timerMaxDelaySec 一致，默认 24h
+	MaxDelay time.Duration `json:"maxDelay,optional"`
+	// SendTimeout bounds each send attempt unless WithTimeout is given, default 5s
+	SendTimeout time.Duration `json:"sendTimeout,optional"`
+	// Retry 发送失败（broker 不可达、超时、限流）时的重试
+	Retry SendRetryPolicy `json:"retry,optional"`
+	// Breaker broker 不可用时快速失败，避免每次 Publish 都等到超时
+	Breaker BreakerConfig `json:"breaker,optional"`
 }
 
 func NewProducer(conf *ProducerConfig) *Producer {
+	return newProducer(conf)
+}
+
+func newProducer(conf *ProducerConfig, opts ...rmq.ProducerOption) *Producer {
 	SetLogger()
+	if len(conf.Topics) > 0 {
+		opts = append(opts, rmq.WithTopics(conf.Topics...))
+	}
 	producer, err := rmq.NewProducer(&rmq.Config{
 		Endpoint: conf.Endpoint,
 		Credentials: &credentials.SessionCredentials{
 			AccessKey:    conf.Credentials.AccessKey,
 			AccessSecret: conf.Credentials.AccessSecret,
 		},
-	})
+	}, opts...)
 	if err != nil {
 		logx.Errorf("init producer failed: %v", err)
 		panic(err)
@@ -42,14 +59,25 @@ func NewProducer(conf *ProducerConfig) *Producer {
 	}
 
 	return &Producer{
-		Producer: producer,
-		app:      conf.AppId,
+		Producer:    producer,
+		endpoint:    conf.Endpoint,
+		app:         conf.AppId,
+		maxDelay:    conf.MaxDelay,
+		sendTimeout: conf.SendTimeout,
+		retry:       conf.Retry,
+		breaker:     newBreaker(conf.Endpoint, conf.Breaker),
 	}
 }
 
 type Producer struct {
 	rmq.Producer
-	app string
+	endpoint    string
+	app         string
+	maxDelay    time.Duration
+	sendTimeout time.Duration
+	retry       SendRetryPolicy
+	breaker     *breaker // nil when disabled
+	health      producerHealth
 }
 
 func (p *Producer) Stop() {
@@ -58,18 +86,24 @@ func (p *Producer) Stop() {
 
 type PublishOption struct {
 	delay       time.Duration
+	deliverAt   time.Time
 	timeout     time.Duration
 	ShardingKey string
+	codec       Codec
+	// messageGroup is set by WithMessageGroup
+	messageGroup string
 }
 
 type PublishOptionFunc func(*PublishOption)
 
+// WithDelay delivers the message after delay, at most the producer's MaxDelay
 func WithDelay(delay time.Duration) PublishOptionFunc {
 	return func(opt *PublishOption) {
 		opt.delay = delay
 	}
 }
 
+// WithTimeout bounds each send attempt, see ProducerConfig.SendTimeout
 func WithTimeout(timeout time.Duration) PublishOptionFunc {
 	return func(opt *PublishOption) {
 		opt.timeout = timeout
@@ -83,6 +117,22 @@ func WithShardingKey(shardingKey string) PublishOptionFunc {
 	}
 }
 
+// WithMessageGroup publishes to a FIFO topic: consumers with FIFO set process
+// the messages of one group strictly in publish order. FIFO messages can not be delayed
+func WithMessageGroup(group string) PublishOptionFunc {
+	return func(opt *PublishOption) {
+		opt.messageGroup = group
+	}
+}
+
+// WithCodec sets the codec Publish encodes with and records it in the CodecKey
+// property, so consumers decode the message with the same codec
+func WithCodec(codec Codec) PublishOptionFunc {
+	return func(opt *PublishOption) {
+		opt.codec = codec
+	}
+}
+
 func (p *Producer) PublishWithoutPrefix(ctx context.Context, topic Topic, msg []byte, opts ...PublishOptionFunc) error {
 	return p.publish(ctx, topic, msg, opts...)
 }
@@ -94,7 +144,7 @@ func (p *Producer) PublishWithPrefix(ctx context.Context, topic Topic, msg []byt
 
 func (p *Producer) publish(ctx context.Context, topic Topic, msg []byte, opts ...PublishOptionFunc) error {
 	opt := &PublishOption{
-		timeout: 5 * time.Second,
+		timeout: p.timeout(),
 	}
 
 	for _, o := range opts {
@@ -103,41 +153,78 @@ func (p *Producer) publish(ctx context.Context, topic Topic, msg []byte, opts ..
 
 	actualTopic := string(topic)
 
+	deliverAt, err := opt.deliveryTime(time.Now(), p.maxDelay)
+	if err != nil {
+		logc.Errorf(ctx, "invalid delivery time: %v, topic: %s", err, actualTopic)
+		return err
+	}
+
 	// 检查输入的 context 中是否已有 trace
 	// if spanCtx := trace.SpanContextFromContext(ctx); spanCtx.IsValid() {
 	// 	logx.Infof("Input context trace_id: %s", spanCtx.TraceID().String())
 	// }
 
-	ctx, span := otel.Tracer("rocket-producer").Start(ctx, "rocket.Producer.Publish",
+	ctx, span := startPublishSpan(ctx, "rocket.Producer.Publish", actualTopic, msg)
+	defer span.End()
+
+	message := newMessage(ctx, actualTopic, msg, opt)
+
+	// 如果设置了延迟时间，设置延迟投递
+	if !deliverAt.IsZero() {
+		message.SetDelayTimestamp(deliverAt)
+		span.SetAttributes(attribute.Int64("delay.ms", time.Until(deliverAt).Milliseconds()))
+	}
+
+	// 每次尝试使用独立的超时，按重试策略和熔断器发送
+	result, err := p.send(ctx, message, opt.timeout)
+	if err != nil {
+		err = scheduleError(err, deliverAt)
+		logc.Errorf(ctx, "send message failed: %v, topic: %s, msg: %s", err, actualTopic, string(msg))
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logx.WithContext(ctx).Errorf("send message failed: %v, topic: %s, msg: %s", err, actualTopic, string(msg))
+		return err
+	}
+
+	// 设置成功状态和消息ID
+	span.SetStatus(codes.Ok, "")
+	span.SetAttributes(attribute.String("message.id", result[0].MessageID))
+	logc.Infof(ctx, "send message success, topic: %s, msgId: %s", actualTopic, result[0].MessageID)
+
+	return nil
+}
+
+func startPublishSpan(ctx context.Context, name, topic string, msg []byte) (context.Context, trace.Span) {
+	return otel.Tracer("rocket-producer").Start(ctx, name,
 		trace.WithAttributes(
-			attribute.String("topic", actualTopic),
+			attribute.String("topic", topic),
 			attribute.Int("message.size", len(msg)),
 		),
 		trace.WithSpanKind(trace.SpanKindProducer),
 	)
-	defer span.End()
+}
 
+// newMessage 构造消息，写入 trace context、APP-ID 和 sharding key
+func newMessage(ctx context.Context, topic string, msg []byte, opt *PublishOption) *rmq.Message {
 	// 使用 W3C trace context 格式
 	prop := propagation.TraceContext{}
 	carrier := propagation.MapCarrier{}
 	prop.Inject(ctx, carrier)
 
 	message := &rmq.Message{
-		Topic: actualTopic,
+		Topic: topic,
 		Body:  msg,
 	}
 
-	// 打印要传递的 trace context
-	// logx.Infof("Injecting trace context: %+v", carrier)
-
 	// 将 trace context 添加到消息属性
 	for k, v := range carrier {
 		message.AddProperty(k, v)
 	}
 
 	// 为了兼容性，同时保留原有的 trace_id 和 span_id
-	message.AddProperty("trace_id", span.SpanContext().TraceID().String())
-	message.AddProperty("span_id", span.SpanContext().SpanID().String())
+	spanCtx := trace.SpanContextFromContext(ctx)
+	message.AddProperty("trace_id", spanCtx.TraceID().String())
+	message.AddProperty("span_id", spanCtx.SpanID().String())
 
 	if appID, ok := ctx.Value(APP_ID_KEY).(string); ok {
 		message.AddProperty(string(APP_ID_KEY), appID)
@@ -146,31 +233,11 @@ func (p *Producer) publish(ctx context.Context, topic Topic, msg []byte, opts ..
 	if opt.ShardingKey != "" {
 		message.SetKeys(opt.ShardingKey)
 	}
-
-	// 如果设置了延迟时间，设置延迟投递
-	if opt.delay > 0 {
-		deliveryTime := time.Now().Add(opt.delay)
-		message.SetDelayTimestamp(deliveryTime)
-		span.SetAttributes(attribute.Int64("delay.ms", opt.delay.Milliseconds()))
+	if opt.messageGroup != "" {
+		message.SetMessageGroup(opt.messageGroup)
 	}
-
-	// 使用超时上下文发送消息
-	sendCtx, cancel := context.WithTimeout(ctx, opt.timeout)
-	defer cancel()
-
-	result, err := p.Send(sendCtx, message)
-	if err != nil {
-		logc.Errorf(ctx, "send message failed: %v, topic: %s, msg: %s", err, actualTopic, string(msg))
-		span.RecordError(err)
-		span.SetStatus(codes.Error, err.Error())
-		logx.WithContext(ctx).Errorf("send message failed: %v, topic: %s, msg: %s", err, actualTopic, string(msg))
-		return err
+	if opt.codec != nil {
+		message.AddProperty(CodecKey, opt.codec.Name())
 	}
-
-	// 设置成功状态和消息ID
-	span.SetStatus(codes.Ok, "")
-	span.SetAttributes(attribute.String("message.id", result[0].MessageID))
-	logc.Infof(ctx, "send message success, topic: %s, msgId: %s", actualTopic, result[0].MessageID)
-
-	return nil
+	return message
 }
@@ -0,0 +1,248 @@
+package rocketmq
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/apache/rocketmq-clients/golang/v5/credentials"
+	"github.com/zeromicro/go-zero/core/logc"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultMaxAttempts = 16
+	defaultBackoff     = 10 * time.Second
+	defaultMaxBackoff  = 10 * time.Minute
+	deadLetterTimeout  = 5 * time.Second
+)
+
+// properties added to messages forwarded to the dead-letter topic
+const (
+	DeadLetterOriginTopicKey = "DLQ_ORIGIN_TOPIC"
+	DeadLetterOriginIDKey    = "DLQ_ORIGIN_MESSAGE_ID"
+	DeadLetterErrorKey       = "DLQ_ERROR"
+	DeadLetterAttemptsKey    = "DLQ_ATTEMPTS"
+	DeadLetterGroupKey       = "DLQ_CONSUMER_GROUP"
+	DeadLetterFailedAtKey    = "DLQ_FAILED_AT"
+	// DeadLetterTraceIDKey is the trace id of the producer, so the failure can be
+	// found in the original trace
+	DeadLetterTraceIDKey = "DLQ_TRACE_ID"
+)
+
+// DeadLetterSuffix is appended to a topic to name its dead-letter topic
+const DeadLetterSuffix = "_DLQ"
+
+// DeadLetterTopic returns the default dead-letter topic of topic
+func DeadLetterTopic(topic string) string {
+	return topic + DeadLetterSuffix
+}
+
+// RetryPolicy decides what happens to a message whose handler returned an error,
+// panicked or whose body could not be decoded
+type RetryPolicy struct {
+	// MaxAttempts is the number of deliveries including the first one, default 16
+	MaxAttempts int `json:"maxAttempts,optional"`
+	// Backoff is the delay before the first redelivery, doubled on every attempt, default 10s
+	Backoff time.Duration `json:"backoff,optional"`
+	// MaxBackoff caps the redelivery delay, default 10m
+	MaxBackoff time.Duration `json:"maxBackoff,optional"`
+	// DLQTopic receives messages that exhausted MaxAttempts or cannot be decoded.
+	// Without it or DeadLetter such messages are acked and only logged
+	DLQTopic string `json:"dlqTopic,optional"`
+	// DeadLetter forwards such messages to the <topic>_DLQ of their own topic
+	// when DLQTopic is empty, see DeadLetterTopic
+	DeadLetter bool `json:"deadLetter,optional"`
+	// AckOnError acks failed messages right away, dropping them like the consumer
+	// did before retries were supported
+	AckOnError bool `json:"ackOnError,optional"`
+}
+
+type retryAction int
+
+const (
+	retryAck        retryAction = iota // ack and drop the message
+	retryRedeliver                     // make the message visible again after a backoff
+	retryDeadLetter                    // forward to DLQTopic, then ack
+)
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaultMaxAttempts
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = defaultBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultMaxBackoff
+	}
+	if p.MaxBackoff < p.Backoff {
+		p.MaxBackoff = p.Backoff
+	}
+	return p
+}
+
+// decide picks the action for a failed delivery. attempt is 1-based; permanent
+// failures like decode errors skip the retries
+func (p RetryPolicy) decide(attempt int, permanent bool) retryAction {
+	switch {
+	case p.AckOnError:
+		return retryAck
+	case !permanent && attempt < p.MaxAttempts:
+		return retryRedeliver
+	case p.deadLettering():
+		return retryDeadLetter
+	default:
+		return retryAck
+	}
+}
+
+// deadLettering reports whether failed messages are forwarded to a dead-letter topic
+func (p RetryPolicy) deadLettering() bool {
+	return !p.AckOnError && (p.DLQTopic != "" || p.DeadLetter)
+}
+
+// deadLetterTopic returns where failed messages of topic are forwarded
+func (p RetryPolicy) deadLetterTopic(topic string) string {
+	if p.DLQTopic != "" {
+		return p.DLQTopic
+	}
+	return DeadLetterTopic(topic)
+}
+
+// backoff returns the redelivery delay after the given attempt
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, p.MaxBackoff)
+}
+
+// newDeadLetterProducer creates a producer for the DLQ topics with the consumer's credentials
+func newDeadLetterProducer(conf *ConsumerConfig) (rmq.Producer, error) {
+	cfg := &rmq.Config{
+		Endpoint:    conf.Endpoint,
+		Credentials: &credentials.SessionCredentials{},
+	}
+	if conf.Credentials != nil {
+		cfg.Credentials = &credentials.SessionCredentials{
+			AccessKey:    conf.Credentials.AccessKey,
+			AccessSecret: conf.Credentials.AccessSecret,
+		}
+	}
+	// topics added later with Handle are routed on their first send
+	var topics []string
+	if conf.Retry.DLQTopic != "" {
+		topics = append(topics, conf.Retry.DLQTopic)
+	} else {
+		for topic := range conf.subscriptions() {
+			topics = append(topics, DeadLetterTopic(topic))
+		}
+	}
+	return rmq.NewProducer(cfg, rmq.WithTopics(topics...))
+}
+
+// deadLetterMessage copies msg to topic and records why, when and where it failed
+func deadLetterMessage(ctx context.Context, topic, group string, msg *rmq.MessageView, attempt int, cause error) *rmq.Message {
+	dl := &rmq.Message{
+		Topic: topic,
+		Body:  msg.GetBody(),
+	}
+	for k, v := range msg.GetProperties() {
+		dl.AddProperty(k, v)
+	}
+	if tag := msg.GetTag(); tag != nil {
+		dl.SetTag(*tag)
+	}
+	if keys := msg.GetKeys(); len(keys) > 0 {
+		dl.SetKeys(keys...)
+	}
+	dl.AddProperty(DeadLetterOriginTopicKey, msg.GetTopic())
+	dl.AddProperty(DeadLetterOriginIDKey, msg.GetMessageId())
+	dl.AddProperty(DeadLetterAttemptsKey, strconv.Itoa(attempt))
+	dl.AddProperty(DeadLetterFailedAtKey, time.Now().UTC().Format(time.RFC3339Nano))
+	if group != "" {
+		dl.AddProperty(DeadLetterGroupKey, group)
+	}
+	if traceID := originTraceID(ctx, msg.GetProperties()); traceID != "" {
+		dl.AddProperty(DeadLetterTraceIDKey, traceID)
+	}
+	if cause != nil {
+		dl.AddProperty(DeadLetterErrorKey, cause.Error())
+	}
+	return dl
+}
+
+// onFailure applies the retry policy to a message that failed with cause
+func (c *Consumer[T]) onFailure(ctx context.Context, msg *rmq.MessageView, cause error, permanent bool) {
+	span := trace.SpanFromContext(ctx)
+	attempt := max(int(msg.GetDeliveryAttempt()), 1)
+	span.SetAttributes(attribute.Int("message.delivery_attempt", attempt))
+
+	switch c.retry.decide(attempt, permanent) {
+	case retryRedeliver:
+		c.redeliver(ctx, msg, attempt)
+		return
+	case retryDeadLetter:
+		if err := c.deadLetter(ctx, msg, attempt, cause); err != nil {
+			// 转发失败时稍后重投，不丢消息
+			logc.Errorf(ctx, "forward message to dlq failed: %v, msgId: %s", err, msg.GetMessageId())
+			span.RecordError(err)
+			c.redeliver(ctx, msg, attempt)
+			return
+		}
+		span.SetAttributes(attribute.String("message.dlq_topic", c.retry.deadLetterTopic(msg.GetTopic())))
+	default:
+		if !c.retry.AckOnError {
+			logc.Errorf(ctx, "drop message after %d attempts: %v, topic: %s, msgId: %s",
+				attempt, cause, msg.GetTopic(), msg.GetMessageId())
+		}
+	}
+
+	// 业务函数返回了，我们按策略 Ack 掉，所以这里不把 Span 状态设为永久 Error
+	// 除非后续 Ack 也失败了
+	ackCtx, ackCancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second*30)
+	ackStart := time.Now()
+	ackErr := c.consumer.Ack(ackCtx, msg)
+	ackCancel()
+
+	span.SetAttributes(attribute.Int64("consumer.ack_ms", time.Since(ackStart).Milliseconds()))
+	if ackErr != nil {
+		c.stats.ackFailures.Add(1)
+		span.RecordError(ackErr)
+		span.SetStatus(codes.Error, "biz_err_and_ack_failed: "+ackErr.Error())
+		span.SetAttributes(attribute.String("ack.error", ackErr.Error()))
+	} else {
+		span.SetStatus(codes.Ok, "biz_err_but_ack_success")
+		span.SetAttributes(attribute.Bool("ack.success", true))
+	}
+}
+
+// redeliver makes msg visible again after the backoff of attempt
+func (c *Consumer[T]) redeliver(ctx context.Context, msg *rmq.MessageView, attempt int) {
+	span := trace.SpanFromContext(ctx)
+	delay := c.retry.backoff(attempt)
+	span.SetAttributes(attribute.Int64("consumer.retry_delay_ms", delay.Milliseconds()))
+	if err := c.consumer.ChangeInvisibleDuration(msg, delay); err != nil {
+		// 修改失败时消息在 invisibleDuration 后自动重投
+		logc.Errorf(ctx, "change invisible duration failed: %v, msgId: %s", err, msg.GetMessageId())
+		span.RecordError(err)
+	}
+	span.SetStatus(codes.Error, fmt.Sprintf("biz_err_retry_in_%s", delay))
+}
+
+func (c *Consumer[T]) deadLetter(ctx context.Context, msg *rmq.MessageView, attempt int, cause error) error {
+	topic := c.retry.deadLetterTopic(msg.GetTopic())
+	if c.dlq == nil {
+		return fmt.Errorf("dlq producer for %s is not running", topic)
+	}
+	sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), deadLetterTimeout)
+	defer cancel()
+	_, err := c.dlq.Send(sendCtx, deadLetterMessage(ctx, topic, c.group(), msg, attempt, cause))
+	return err
+}
@@ -0,0 +1,126 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+func TestRetryPolicyDecide(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    RetryPolicy
+		attempt   int
+		permanent bool
+		want      retryAction
+	}{
+		{name: "retry below max", policy: RetryPolicy{MaxAttempts: 3}, attempt: 2, want: retryRedeliver},
+		{name: "drop at max without dlq", policy: RetryPolicy{MaxAttempts: 3}, attempt: 3, want: retryAck},
+		{name: "dead letter at max", policy: RetryPolicy{MaxAttempts: 3, DLQTopic: "dlq"}, attempt: 3, want: retryDeadLetter},
+		{name: "permanent skips retries", policy: RetryPolicy{MaxAttempts: 3, DLQTopic: "dlq"}, attempt: 1, permanent: true, want: retryDeadLetter},
+		{name: "ack on error", policy: RetryPolicy{MaxAttempts: 3, DLQTopic: "dlq", AckOnError: true}, attempt: 1, want: retryAck},
+		{name: "per topic dead letter", policy: RetryPolicy{MaxAttempts: 3, DeadLetter: true}, attempt: 3, want: retryDeadLetter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.withDefaults().decide(tt.attempt, tt.permanent); got != tt.want {
+				t.Errorf("decide = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}.withDefaults()
+	for attempt, want := range map[int]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second, 4: 5 * time.Second, 40: 5 * time.Second} {
+		if got := p.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+type fakeSimpleConsumer struct {
+	rmq.SimpleConsumer
+	mu        sync.Mutex
+	acked     int
+	invisible []time.Duration
+}
+
+func (f *fakeSimpleConsumer) Ack(context.Context, *rmq.MessageView) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.acked++
+	return nil
+}
+
+func (f *fakeSimpleConsumer) ChangeInvisibleDuration(_ *rmq.MessageView, d time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invisible = append(f.invisible, d)
+	return nil
+}
+
+type fakeDLQProducer struct {
+	rmq.Producer
+	err  error
+	sent []*rmq.Message
+}
+
+func (f *fakeDLQProducer) Send(_ context.Context, msg *rmq.Message) ([]*rmq.SendReceipt, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.sent = append(f.sent, msg)
+	return []*rmq.SendReceipt{{MessageID: "dlq-1"}}, nil
+}
+
+func TestConsumerOnFailure(t *testing.T) {
+	cause := errors.New("downstream timeout")
+
+	tests := []struct {
+		name          string
+		policy        RetryPolicy
+		dlqErr        error
+		permanent     bool
+		wantAcked     int
+		wantRedeliver bool
+		wantDLQ       int
+	}{
+		{name: "redeliver", policy: RetryPolicy{MaxAttempts: 3, Backoff: time.Second}, wantRedeliver: true},
+		{name: "ack on error", policy: RetryPolicy{AckOnError: true}, wantAcked: 1},
+		{name: "dead letter", policy: RetryPolicy{DLQTopic: "orders_DLQ"}, permanent: true, wantAcked: 1, wantDLQ: 1},
+		{name: "dlq failure redelivers", policy: RetryPolicy{DLQTopic: "orders_DLQ"}, dlqErr: errors.New("down"), permanent: true, wantRedeliver: true},
+		{name: "drop without dlq", policy: RetryPolicy{MaxAttempts: 1}, wantAcked: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &fakeSimpleConsumer{}
+			dlq := &fakeDLQProducer{err: tt.dlqErr}
+			c := &Consumer[string]{consumer: sc, retry: tt.policy.withDefaults(), dlq: dlq}
+
+			c.onFailure(context.Background(), &rmq.MessageView{}, cause, tt.permanent)
+
+			if sc.acked != tt.wantAcked {
+				t.Errorf("acked = %d, want %d", sc.acked, tt.wantAcked)
+			}
+			if (len(sc.invisible) > 0) != tt.wantRedeliver {
+				t.Errorf("invisible changes = %v, want redeliver %v", sc.invisible, tt.wantRedeliver)
+			}
+			if len(dlq.sent) != tt.wantDLQ {
+				t.Fatalf("dlq sent = %d, want %d", len(dlq.sent), tt.wantDLQ)
+			}
+			if tt.wantDLQ > 0 {
+				props := dlq.sent[0].GetProperties()
+				if dlq.sent[0].Topic != "orders_DLQ" || props[DeadLetterErrorKey] != cause.Error() || props[DeadLetterAttemptsKey] != "1" {
+					t.Errorf("dlq message = %s %v", dlq.sent[0].Topic, props)
+				}
+			}
+		})
+	}
+}
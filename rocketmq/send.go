@@ -0,0 +1,269 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	v2 "github.com/apache/rocketmq-clients/golang/v5/protocol/v2"
+	"github.com/zeromicro/go-zero/core/logx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gomod.pri/golib/notify"
+)
+
+const (
+	defaultSendTimeout     = 5 * time.Second
+	defaultSendBackoff     = 100 * time.Millisecond
+	defaultSendMaxBackoff  = 2 * time.Second
+	defaultBreakerCooldown = 10 * time.Second
+	breakerAlertTimeout    = 10 * time.Second
+)
+
+// ErrBreakerOpen is returned by Publish without sending while the broker is
+// considered down, see BreakerConfig
+var ErrBreakerOpen = errors.New("rocketmq: producer circuit breaker is open")
+
+// SendRetryPolicy retries sends that failed because the broker was unreachable
+// or overloaded, on top of the client's own retries across brokers. Messages
+// the broker rejected, e.g. an unknown topic, are never retried
+type SendRetryPolicy struct {
+	// MaxAttempts includes the first send, default 1 sends once
+	MaxAttempts int `json:"maxAttempts,optional"`
+	// Backoff is the wait before the second attempt, doubled for each further one, default 100ms
+	Backoff time.Duration `json:"backoff,optional"`
+	// MaxBackoff caps the wait between attempts, default 2s
+	MaxBackoff time.Duration `json:"maxBackoff,optional"`
+}
+
+func (p SendRetryPolicy) withDefaults() SendRetryPolicy {
+	p.MaxAttempts = max(p.MaxAttempts, 1)
+	if p.Backoff <= 0 {
+		p.Backoff = defaultSendBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultSendMaxBackoff
+	}
+	return p
+}
+
+// backoff returns the wait after the given failed attempt
+func (p SendRetryPolicy) backoff(attempt int) time.Duration {
+	return RetryPolicy{Backoff: p.Backoff, MaxBackoff: max(p.MaxBackoff, p.Backoff)}.backoff(attempt)
+}
+
+// BreakerConfig makes Publish fail fast with ErrBreakerOpen once the broker is
+// down, instead of every call waiting out its timeout
+type BreakerConfig struct {
+	// FailureThreshold opens the breaker after this many send attempts in a row
+	// failed to reach the broker, 0 disables the breaker
+	FailureThreshold int `json:"failureThreshold,optional"`
+	// Cooldown is how long the breaker stays open before a single send probes
+	// the broker again, default 10s
+	Cooldown time.Duration `json:"cooldown,optional"`
+	// Notify is alerted when the breaker opens and when it closes again
+	Notify notify.Notification `json:"-"`
+}
+
+// send sends msg with the retry policy and the breaker, each attempt
+// bounded by timeout
+func (p *Producer) send(ctx context.Context, msg *rmq.Message, timeout time.Duration) ([]*rmq.SendReceipt, error) {
+	retry := p.retry.withDefaults()
+	for attempt := 1; ; attempt++ {
+		if err := p.breaker.allow(); err != nil {
+			return nil, err
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, timeout)
+		receipts, err := p.Send(sendCtx, msg)
+		cancel()
+		p.health.record(err)
+
+		if ctx.Err() != nil {
+			// 调用方取消或超时，说明不了 broker 是否可用
+			p.breaker.abandon()
+			return receipts, err
+		}
+		// 单次发送超时计入熔断：broker 不可达时发送通常一直挂起
+		unavailable := err != nil && (brokerUnavailable(err) || errors.Is(err, context.DeadlineExceeded))
+		p.breaker.record(!unavailable, err)
+		if !unavailable || attempt >= retry.MaxAttempts {
+			return receipts, err
+		}
+
+		delay := retry.backoff(attempt)
+		trace.SpanFromContext(ctx).AddEvent("rocketmq.send.retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.Int64("backoff_ms", delay.Milliseconds()),
+			attribute.String("error", err.Error()),
+		))
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, err
+		}
+	}
+}
+
+// timeout returns the timeout of a send attempt
+func (p *Producer) timeout() time.Duration {
+	if p.sendTimeout > 0 {
+		return p.sendTimeout
+	}
+	return defaultSendTimeout
+}
+
+// brokerUnavailable reports whether err means the broker could not take the
+// message now: transport errors, timeouts, throttling and server errors.
+// A canceled or expired context is the caller giving up, and a broker that
+// does not implement or support the request will not take it on retry either
+func brokerUnavailable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rpcErr *rmq.ErrRpcStatus
+	if !errors.As(err, &rpcErr) {
+		return true
+	}
+	switch code := v2.Code(rpcErr.GetCode()); code {
+	case v2.Code_REQUEST_TIMEOUT, v2.Code_TOO_MANY_REQUESTS:
+		return true
+	case v2.Code_NOT_IMPLEMENTED, v2.Code_UNSUPPORTED:
+		return false
+	default:
+		return code >= v2.Code_INTERNAL_ERROR && code < v2.Code_FAILED_TO_CONSUME_MESSAGE
+	}
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// breaker counts consecutive failed sends, a nil breaker always allows
+type breaker struct {
+	endpoint  string
+	threshold int
+	cooldown  time.Duration
+	notify    notify.Notification
+	now       func() time.Time
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	lastErr  error
+}
+
+func newBreaker(endpoint string, conf BreakerConfig) *breaker {
+	if conf.FailureThreshold <= 0 {
+		return nil
+	}
+	if conf.Cooldown <= 0 {
+		conf.Cooldown = defaultBreakerCooldown
+	}
+	return &breaker{
+		endpoint:  endpoint,
+		threshold: conf.FailureThreshold,
+		cooldown:  conf.Cooldown,
+		notify:    conf.Notify,
+		now:       time.Now,
+	}
+}
+
+// allow returns ErrBreakerOpen while open, after the cooldown it lets a single
+// probe through and keeps failing the others until the probe is recorded
+func (b *breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return fmt.Errorf("%w: %v", ErrBreakerOpen, b.lastErr)
+		}
+		b.state = breakerHalfOpen
+		return nil
+	case breakerHalfOpen:
+		return fmt.Errorf("%w: %v", ErrBreakerOpen, b.lastErr)
+	}
+	return nil
+}
+
+// isOpen reports whether sends fail fast, without taking the probe
+func (b *breaker) isOpen() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state != breakerClosed
+}
+
+// record counts the result of an allowed send, ok is false when the broker
+// was unreachable
+func (b *breaker) record(ok bool, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case ok && b.state == breakerHalfOpen:
+		b.state, b.failures = breakerClosed, 0
+		logx.Infof("rocketmq producer breaker closed, broker %s is back", b.endpoint)
+		b.alert("RocketMQ producer recovered", fmt.Sprintf("broker %s accepts messages again", b.endpoint))
+	case ok:
+		b.failures = 0
+	case b.state == breakerHalfOpen:
+		// 探测失败，继续熔断
+		b.state, b.openedAt, b.lastErr = breakerOpen, b.now(), err
+	case b.state == breakerClosed:
+		b.lastErr = err
+		if b.failures++; b.failures >= b.threshold {
+			b.state, b.openedAt = breakerOpen, b.now()
+			logx.Errorf("rocketmq producer breaker opened after %d failed sends to %s: %v", b.failures, b.endpoint, err)
+			b.alert("RocketMQ producer circuit open", fmt.Sprintf(
+				"broker %s unreachable after %d failed sends, Publish fails fast and probes again every %s\n\nlast error: %v",
+				b.endpoint, b.failures, b.cooldown, err))
+		}
+	}
+}
+
+// abandon gives up an allowed send without a result, a half-open breaker
+// lets the next send probe instead
+func (b *breaker) abandon() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == breakerHalfOpen {
+		b.state = breakerOpen
+	}
+}
+
+// alert notifies in the background so Publish never waits on the webhook
+func (b *breaker) alert(title, content string) {
+	if b.notify == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), breakerAlertTimeout)
+		defer cancel()
+		if err := b.notify.SendCard(ctx, title, content); err != nil {
+			logx.Errorf("send rocketmq breaker alert failed: %v", err)
+		}
+	}()
+}
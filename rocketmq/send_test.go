@@ -0,0 +1,235 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	v2 "github.com/apache/rocketmq-clients/golang/v5/protocol/v2"
+
+	"gomod.pri/golib/notify"
+)
+
+// flakySendProducer fails the sends with the queued errors, then succeeds
+type flakySendProducer struct {
+	rmq.Producer
+	errs  []error
+	sends int
+}
+
+func (f *flakySendProducer) Send(context.Context, *rmq.Message) ([]*rmq.SendReceipt, error) {
+	f.sends++
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return nil, err
+	}
+	return []*rmq.SendReceipt{{MessageID: "m1"}}, nil
+}
+
+func TestPublishRetry(t *testing.T) {
+	down := errors.New("connection refused")
+	rejected := &rmq.ErrRpcStatus{Code: int32(v2.Code_TOPIC_NOT_FOUND), Message: "no topic"}
+	throttled := &rmq.ErrRpcStatus{Code: int32(v2.Code_TOO_MANY_REQUESTS), Message: "slow down"}
+
+	tests := []struct {
+		name      string
+		retry     SendRetryPolicy
+		errs      []error
+		wantSends int
+		wantErr   error
+	}{
+		{name: "no retry by default", errs: []error{down}, wantSends: 1, wantErr: down},
+		{name: "retry until sent", retry: SendRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}, errs: []error{down, throttled}, wantSends: 3},
+		{name: "give up after max attempts", retry: SendRetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}, errs: []error{down, down, down}, wantSends: 2, wantErr: down},
+		{name: "rejected is not retried", retry: SendRetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}, errs: []error{rejected}, wantSends: 1, wantErr: rejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &flakySendProducer{errs: tt.errs}
+			p := &Producer{Producer: fake, retry: tt.retry}
+			err := p.PublishWithoutPrefix(context.Background(), "orders", []byte(`{}`))
+			if !errors.Is(err, tt.wantErr) || (tt.wantErr == nil && err != nil) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if fake.sends != tt.wantSends {
+				t.Errorf("sends = %d, want %d", fake.sends, tt.wantSends)
+			}
+		})
+	}
+}
+
+func TestSendRetryBackoff(t *testing.T) {
+	p := SendRetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}.withDefaults()
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 300 * time.Millisecond} {
+		if got := p.backoff(attempt); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempt, got, want)
+		}
+	}
+}
+
+// alertRecorder records breaker alerts
+type alertRecorder struct {
+	mu     sync.Mutex
+	titles []string
+	sent   chan struct{}
+}
+
+func (a *alertRecorder) SendText(ctx context.Context, content string, opts ...notify.Option) error {
+	return a.SendCard(ctx, "", content, opts...)
+}
+
+func (a *alertRecorder) SendCard(_ context.Context, title, _ string, _ ...notify.Option) error {
+	a.mu.Lock()
+	a.titles = append(a.titles, title)
+	a.mu.Unlock()
+	a.sent <- struct{}{}
+	return nil
+}
+
+func (a *alertRecorder) wait(t *testing.T) {
+	t.Helper()
+	select {
+	case <-a.sent:
+	case <-time.After(time.Second):
+		t.Fatal("no breaker alert sent")
+	}
+}
+
+func TestPublishBreaker(t *testing.T) {
+	down := errors.New("connection refused")
+	alerts := &alertRecorder{sent: make(chan struct{}, 2)}
+	now := time.Now()
+	b := newBreaker("127.0.0.1:8081", BreakerConfig{FailureThreshold: 2, Cooldown: time.Minute, Notify: alerts})
+	b.now = func() time.Time { return now }
+
+	fake := &flakySendProducer{errs: []error{down, down, down}}
+	p := &Producer{Producer: fake, breaker: b}
+	publish := func() error {
+		return p.PublishWithoutPrefix(context.Background(), "orders", []byte(`{}`))
+	}
+
+	for range 2 {
+		if err := publish(); !errors.Is(err, down) {
+			t.Fatalf("err = %v, want the send error", err)
+		}
+	}
+	alerts.wait(t)
+
+	// 熔断期间不再发送
+	if err := publish(); !errors.Is(err, ErrBreakerOpen) || fake.sends != 2 {
+		t.Fatalf("open breaker: err = %v, sends = %d", err, fake.sends)
+	}
+
+	// 冷却后探测失败，重新熔断
+	now = now.Add(time.Minute)
+	if err := publish(); !errors.Is(err, down) || fake.sends != 3 {
+		t.Fatalf("failed probe: err = %v, sends = %d", err, fake.sends)
+	}
+	if err := publish(); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("after failed probe: err = %v", err)
+	}
+
+	// 探测成功后恢复
+	now = now.Add(time.Minute)
+	for range 2 {
+		if err := publish(); err != nil {
+			t.Fatalf("recovered: err = %v", err)
+		}
+	}
+	alerts.wait(t)
+
+	alerts.mu.Lock()
+	defer alerts.mu.Unlock()
+	if got := strings.Join(alerts.titles, ","); got != "RocketMQ producer circuit open,RocketMQ producer recovered" {
+		t.Errorf("alerts = %s", got)
+	}
+}
+
+func TestBreakerHalfOpenSingleProbe(t *testing.T) {
+	now := time.Now()
+	b := newBreaker("broker", BreakerConfig{FailureThreshold: 1})
+	b.now = func() time.Time { return now }
+	b.record(false, errors.New("down"))
+
+	now = now.Add(defaultBreakerCooldown)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe not allowed: %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrBreakerOpen) {
+		t.Fatalf("second send during the probe: err = %v", err)
+	}
+	if newBreaker("broker", BreakerConfig{}) != nil {
+		t.Error("breaker enabled without a threshold")
+	}
+}
+
+func TestBrokerUnavailable(t *testing.T) {
+	status := func(code v2.Code) error {
+		return &rmq.ErrRpcStatus{Code: int32(code), Message: code.String()}
+	}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"transport", errors.New("connection refused"), true},
+		{"throttled", status(v2.Code_TOO_MANY_REQUESTS), true},
+		{"request timeout", status(v2.Code_REQUEST_TIMEOUT), true},
+		{"internal error", status(v2.Code_INTERNAL_ERROR), true},
+		{"topic not found", status(v2.Code_TOPIC_NOT_FOUND), false},
+		{"not implemented", status(v2.Code_NOT_IMPLEMENTED), false},
+		{"unsupported", status(v2.Code_UNSUPPORTED), false},
+		{"canceled", fmt.Errorf("send: %w", context.Canceled), false},
+		{"deadline exceeded", context.DeadlineExceeded, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := brokerUnavailable(tt.err); got != tt.want {
+				t.Errorf("brokerUnavailable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishCallerCanceled(t *testing.T) {
+	now := time.Now()
+	b := newBreaker("broker", BreakerConfig{FailureThreshold: 1})
+	b.now = func() time.Time { return now }
+	b.record(false, errors.New("down"))
+	now = now.Add(defaultBreakerCooldown)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fake := &flakySendProducer{errs: []error{context.Canceled}}
+	p := &Producer{Producer: fake, breaker: b, retry: SendRetryPolicy{MaxAttempts: 3}}
+	if err := p.PublishWithoutPrefix(ctx, "orders", []byte(`{}`)); !errors.Is(err, context.Canceled) || fake.sends != 1 {
+		t.Fatalf("err = %v, sends = %d, want a single canceled send", err, fake.sends)
+	}
+
+	// 被放弃的探测既不恢复也不延长熔断，下一次发送继续探测
+	if !b.isOpen() {
+		t.Fatal("canceled probe closed the breaker")
+	}
+	if err := b.allow(); err != nil {
+		t.Fatalf("next probe not allowed: %v", err)
+	}
+}
+
+func TestSendAttemptTimeoutTripsBreaker(t *testing.T) {
+	b := newBreaker("broker", BreakerConfig{FailureThreshold: 1})
+	fake := &flakySendProducer{errs: []error{context.DeadlineExceeded}}
+	p := &Producer{Producer: fake, breaker: b}
+	if err := p.PublishWithoutPrefix(context.Background(), "orders", []byte(`{}`)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v", err)
+	}
+	if !b.isOpen() {
+		t.Error("hanging send did not open the breaker")
+	}
+}
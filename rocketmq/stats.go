@@ -0,0 +1,121 @@
+package rocketmq
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+// ConsumerStats is a debug snapshot of a running consumer, see Stats
+type ConsumerStats struct {
+	Group   string   `json:"group"`
+	Topics  []string `json:"topics"`
+	Workers int      `json:"workers"`
+	Paused  bool     `json:"paused"`
+	// InFlight counts received messages that are not acked or retried yet
+	InFlight int `json:"inFlight"`
+	// OldestInFlightMs is how long the oldest in-flight message has been held,
+	// close to the invisible duration it is about to be redelivered
+	OldestInFlightMs int64     `json:"oldestInFlightMs"`
+	Received         uint64    `json:"received"`
+	Acked            uint64    `json:"acked"`
+	Failed           uint64    `json:"failed"`
+	AckFailures      uint64    `json:"ackFailures"`
+	ReceiveErrors    uint64    `json:"receiveErrors"`
+	LastReceive      time.Time `json:"lastReceive"`
+	LastError        string    `json:"lastError,omitempty"`
+}
+
+// consumerStats counts messages for Stats, the zero value is ready to use
+type consumerStats struct {
+	received      atomic.Uint64
+	acked         atomic.Uint64
+	failed        atomic.Uint64
+	ackFailures   atomic.Uint64
+	receiveErrors atomic.Uint64
+
+	mu       sync.Mutex
+	inFlight map[*rmq.MessageView]time.Time // message -> receive time
+}
+
+func (s *consumerStats) receive(msgs []*rmq.MessageView, now time.Time) {
+	s.received.Add(uint64(len(msgs)))
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.inFlight == nil {
+		s.inFlight = make(map[*rmq.MessageView]time.Time)
+	}
+	for _, msg := range msgs {
+		s.inFlight[msg] = now
+	}
+}
+
+func (s *consumerStats) finish(msg *rmq.MessageView, result string) {
+	switch result {
+	case resultAcked, resultDuplicate:
+		s.acked.Add(1)
+	case resultFailed:
+		s.failed.Add(1)
+	case resultAckError:
+		s.ackFailures.Add(1)
+	}
+	s.mu.Lock()
+	delete(s.inFlight, msg)
+	s.mu.Unlock()
+}
+
+// oldest returns the number of in-flight messages and the age of the oldest
+func (s *consumerStats) oldest(now time.Time) (int, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var age time.Duration
+	for _, at := range s.inFlight {
+		age = max(age, now.Sub(at))
+	}
+	return len(s.inFlight), age
+}
+
+// Stats returns a snapshot of the in-flight messages, message counters and the
+// last receive error, e.g. to inspect a live consumer without the broker console
+func (c *Consumer[T]) Stats() ConsumerStats {
+	s := ConsumerStats{
+		Group:         c.group(),
+		Received:      c.stats.received.Load(),
+		Acked:         c.stats.acked.Load(),
+		Failed:        c.stats.failed.Load(),
+		AckFailures:   c.stats.ackFailures.Load(),
+		ReceiveErrors: c.stats.receiveErrors.Load(),
+	}
+	var oldest time.Duration
+	s.InFlight, oldest = c.stats.oldest(time.Now())
+	s.OldestInFlightMs = oldest.Milliseconds()
+
+	c.routesMu.RLock()
+	for topic := range c.subscribed {
+		s.Topics = append(s.Topics, topic)
+	}
+	c.routesMu.RUnlock()
+	sort.Strings(s.Topics)
+
+	c.mu.Lock()
+	s.Workers = len(c.workers)
+	c.mu.Unlock()
+
+	// only the receive fields of the health snapshot are used
+	h := c.health.snapshot(0, 0)
+	s.LastReceive, s.LastError, s.Paused = h.LastReceive, h.LastError, h.Paused
+	return s
+}
+
+// StatsHandler returns an HTTP handler serving Stats as JSON, for a debug or admin port
+func (c *Consumer[T]) StatsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(c.Stats())
+	}
+}
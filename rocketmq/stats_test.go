@@ -0,0 +1,51 @@
+package rocketmq
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+func TestConsumerStats(t *testing.T) {
+	c := &Consumer[string]{
+		conf:       &ConsumerConfig{ConsumerGroup: "billing"},
+		subscribed: map[string]struct{}{"payments": {}, "orders": {}},
+	}
+	acked, failed, ackErr, held := &rmq.MessageView{}, &rmq.MessageView{}, &rmq.MessageView{}, &rmq.MessageView{}
+
+	c.observeReceived([]*rmq.MessageView{held}, time.Now().Add(-time.Minute))
+	c.observeReceived([]*rmq.MessageView{acked, failed, ackErr}, time.Now())
+	c.observeResult(t.Context(), acked, resultAcked, time.Now())
+	c.observeResult(t.Context(), failed, resultFailed, time.Now())
+	c.observeResult(t.Context(), ackErr, resultAckError, time.Now())
+	c.health.receiveFailed(errors.New("connection reset"))
+	c.observeReceiveError()
+
+	s := c.Stats()
+	if s.Group != "billing" || len(s.Topics) != 2 || s.Topics[0] != "orders" {
+		t.Errorf("group and topics = %s %v", s.Group, s.Topics)
+	}
+	if s.Received != 4 || s.Acked != 1 || s.Failed != 1 || s.AckFailures != 1 || s.ReceiveErrors != 1 {
+		t.Errorf("counters = %+v", s)
+	}
+	if s.InFlight != 1 || s.OldestInFlightMs < time.Minute.Milliseconds() {
+		t.Errorf("in flight = %d, oldest %dms", s.InFlight, s.OldestInFlightMs)
+	}
+	if s.LastError != "connection reset" {
+		t.Errorf("last error = %q", s.LastError)
+	}
+
+	rec := httptest.NewRecorder()
+	c.StatsHandler()(rec, httptest.NewRequest("GET", "/debug/rocketmq", nil))
+	var served ConsumerStats
+	if err := json.NewDecoder(rec.Body).Decode(&served); err != nil {
+		t.Fatalf("decode stats: %v", err)
+	}
+	if served.InFlight != 1 || served.Received != 4 {
+		t.Errorf("served stats = %+v", served)
+	}
+}
@@ -0,0 +1,97 @@
+package rocketmq
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+// ErrNoHandler is the failure cause of messages of a topic without handler,
+// they skip the retries and go to the dead-letter topic if there is one
+var ErrNoHandler = errors.New("rocketmq: no handler for topic")
+
+// Subscription is a topic consumed in addition to ConsumerConfig.Topic
+type Subscription struct {
+	Topic string   `json:"topic"`
+	Tags  []string `json:"tags,optional"`
+}
+
+func tagsExpression(tags []string) *rmq.FilterExpression {
+	if len(tags) == 0 {
+		return rmq.SUB_ALL
+	}
+	return rmq.NewFilterExpression(strings.Join(tags, "||"))
+}
+
+// subscriptions returns the tag filter of every configured topic
+func (conf *ConsumerConfig) subscriptions() map[string]*rmq.FilterExpression {
+	subs := make(map[string]*rmq.FilterExpression, len(conf.Subscriptions)+1)
+	if conf.Topic != "" {
+		subs[conf.Topic] = tagsExpression(conf.Tags)
+	}
+	for _, sub := range conf.Subscriptions {
+		subs[sub.Topic] = tagsExpression(sub.Tags)
+	}
+	return subs
+}
+
+// Handle routes the messages of topic to handler instead of the handler given to
+// NewConsumer. A topic missing from the config is subscribed with tags, right
+// away when the consumer is running, otherwise on Start. All topics share the
+// connection, workers and retry policy of the consumer
+func (c *Consumer[T]) Handle(topic string, handler ConsumeHandler[T], tags ...string) error {
+	if topic == "" || handler == nil {
+		return errors.New("rocketmq: Handle needs a topic and a handler")
+	}
+
+	c.routesMu.Lock()
+	defer c.routesMu.Unlock()
+	if c.routes == nil {
+		c.routes = make(map[string]ConsumeHandler[T])
+	}
+	if _, ok := c.subscribed[topic]; !ok {
+		exp := tagsExpression(tags)
+		if c.started {
+			if err := c.consumer.Subscribe(topic, exp); err != nil {
+				return fmt.Errorf("subscribe %s: %w", topic, err)
+			}
+		} else {
+			if c.pending == nil {
+				c.pending = make(map[string]*rmq.FilterExpression)
+			}
+			c.pending[topic] = exp
+		}
+		if c.subscribed == nil {
+			c.subscribed = make(map[string]struct{})
+		}
+		c.subscribed[topic] = struct{}{}
+	}
+	c.routes[topic] = handler
+	return nil
+}
+
+// subscribePending subscribes the topics added by Handle before Start
+func (c *Consumer[T]) subscribePending() error {
+	c.routesMu.Lock()
+	defer c.routesMu.Unlock()
+	for topic, exp := range c.pending {
+		if err := c.consumer.Subscribe(topic, exp); err != nil {
+			return fmt.Errorf("subscribe %s: %w", topic, err)
+		}
+		delete(c.pending, topic)
+	}
+	c.started = true
+	return nil
+}
+
+// handlerFor returns the handler of topic, nil when there is none
+func (c *Consumer[T]) handlerFor(topic string) ConsumeHandler[T] {
+	c.routesMu.RLock()
+	defer c.routesMu.RUnlock()
+	if h, ok := c.routes[topic]; ok {
+		return h
+	}
+	return c.handler
+}
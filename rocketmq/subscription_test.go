@@ -0,0 +1,121 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+type subscribeConsumer struct {
+	lifecycleConsumer
+	subscribed map[string]*rmq.FilterExpression
+	subErr     error
+}
+
+func (f *subscribeConsumer) Subscribe(topic string, exp *rmq.FilterExpression) error {
+	if f.subErr != nil {
+		return f.subErr
+	}
+	if f.subscribed == nil {
+		f.subscribed = map[string]*rmq.FilterExpression{}
+	}
+	f.subscribed[topic] = exp
+	return nil
+}
+
+type nopHandler struct{ name string }
+
+func (h *nopHandler) Consume(context.Context, string) error       { return nil }
+func (h *nopHandler) ErrorHandler(context.Context, string, error) {}
+
+func TestConsumerConfigSubscriptions(t *testing.T) {
+	conf := &ConsumerConfig{
+		Topic: "orders",
+		Tags:  []string{"paid", "refunded"},
+		Subscriptions: []Subscription{
+			{Topic: "payments"},
+			{Topic: "refunds", Tags: []string{"full"}},
+		},
+	}
+	want := map[string]*rmq.FilterExpression{
+		"orders":   rmq.NewFilterExpression("paid||refunded"),
+		"payments": rmq.SUB_ALL,
+		"refunds":  rmq.NewFilterExpression("full"),
+	}
+	if got := conf.subscriptions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("subscriptions() = %v, want %v", got, want)
+	}
+	if got := (&ConsumerConfig{}).subscriptions(); len(got) != 0 {
+		t.Fatalf("subscriptions() without topics = %v", got)
+	}
+}
+
+func TestConsumerHandle(t *testing.T) {
+	sc := &subscribeConsumer{}
+	fallback, payments, refunds := &nopHandler{"fallback"}, &nopHandler{"payments"}, &nopHandler{"refunds"}
+	c := newLifecycleConsumer(sc, fallback)
+	c.subscribed = map[string]struct{}{"orders": {}, "payments": {}}
+
+	if err := c.Handle("payments", payments); err != nil {
+		t.Fatalf("Handle(payments) error = %v", err)
+	}
+	if err := c.Handle("refunds", refunds, "full"); err != nil {
+		t.Fatalf("Handle(refunds) error = %v", err)
+	}
+	if len(sc.subscribed) != 0 {
+		t.Fatalf("subscribed %v before Start", sc.subscribed)
+	}
+
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = c.Stop(context.Background()) }()
+	want := map[string]*rmq.FilterExpression{"refunds": rmq.NewFilterExpression("full")}
+	if !reflect.DeepEqual(sc.subscribed, want) {
+		t.Fatalf("subscribed on Start = %v, want %v", sc.subscribed, want)
+	}
+
+	if err := c.Handle("audits", refunds); err != nil {
+		t.Fatalf("Handle(audits) after Start error = %v", err)
+	}
+	if _, ok := sc.subscribed["audits"]; !ok {
+		t.Fatal("topic handled after Start was not subscribed")
+	}
+
+	routes := map[string]*nopHandler{"orders": fallback, "payments": payments, "refunds": refunds, "audits": refunds, "unknown": fallback}
+	for topic, want := range routes {
+		if got := c.handlerFor(topic); got != want {
+			t.Errorf("handlerFor(%s) = %v, want %s", topic, got, want.name)
+		}
+	}
+}
+
+func TestConsumerHandleErrors(t *testing.T) {
+	errDenied := errors.New("topic not found")
+	sc := &subscribeConsumer{subErr: errDenied}
+	c := newLifecycleConsumer(sc, nil)
+
+	if err := c.Handle("", &nopHandler{}); err == nil {
+		t.Error("Handle accepted an empty topic")
+	}
+	if err := c.Handle("refunds", &nopHandler{}); err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if err := c.Start(); !errors.Is(err, errDenied) {
+		t.Fatalf("Start() error = %v, want %v", err, errDenied)
+	}
+}
+
+func TestProcessWithoutHandler(t *testing.T) {
+	sc := &fakeSimpleConsumer{}
+	c := &Consumer[string]{consumer: sc, retry: RetryPolicy{}.withDefaults()}
+
+	c.process(&rmq.MessageView{})
+
+	if sc.acked != 1 || len(sc.invisible) != 0 {
+		t.Fatalf("acked = %d, redelivered = %d, want the message dropped", sc.acked, len(sc.invisible))
+	}
+}
@@ -0,0 +1,86 @@
+package rocketmq
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// legacy trace properties written by producers that don't speak W3C traceparent
+const (
+	legacyTraceIDKey = "trace_id"
+	legacySpanIDKey  = "span_id"
+)
+
+// legacySpanContext builds a remote span context from the trace_id/span_id properties.
+// 64-bit trace ids (common in Java tracers) are left-padded to 128 bits.
+func legacySpanContext(props map[string]string) (trace.SpanContext, bool) {
+	rawTrace := strings.TrimSpace(props[legacyTraceIDKey])
+	rawSpan := strings.TrimSpace(props[legacySpanIDKey])
+	if rawTrace == "" || rawSpan == "" {
+		return trace.SpanContext{}, false
+	}
+
+	var traceID trace.TraceID
+	var spanID trace.SpanID
+	if !decodeHexID(traceID[:], rawTrace) || !decodeHexID(spanID[:], rawSpan) {
+		return trace.SpanContext{}, false
+	}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	})
+	return sc, sc.IsValid()
+}
+
+// decodeHexID decodes s into dst, left-padding shorter ids with zeros.
+func decodeHexID(dst []byte, s string) bool {
+	s = strings.ToLower(strings.ReplaceAll(s, "-", ""))
+	if len(s) > len(dst)*2 {
+		return false
+	}
+	if len(s)%2 == 1 {
+		s = "0" + s
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return false
+	}
+	copy(dst[len(dst)-len(b):], b)
+	return true
+}
+
+// producerLinks returns a link to the producer span when the W3C headers carried
+// no parent, so cross-language traces stay connected instead of starting fresh roots.
+func producerLinks(ctx context.Context, props map[string]string) []trace.Link {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+	sc, ok := legacySpanContext(props)
+	if !ok {
+		return nil
+	}
+	return []trace.Link{{SpanContext: sc}}
+}
+
+// originTraceID returns the trace id of the producer from the W3C or legacy
+// properties, falling back to the consumer's own trace in ctx
+func originTraceID(ctx context.Context, props map[string]string) string {
+	producerCtx := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier(props))
+	if sc := trace.SpanContextFromContext(producerCtx); sc.IsValid() {
+		return sc.TraceID().String()
+	}
+	if sc, ok := legacySpanContext(props); ok {
+		return sc.TraceID().String()
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
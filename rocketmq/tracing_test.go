@@ -0,0 +1,77 @@
+package rocketmq
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestLegacySpanContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		props   map[string]string
+		ok      bool
+		traceID string
+		spanID  string
+	}{
+		{
+			name:    "128-bit trace id",
+			props:   map[string]string{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "span_id": "00f067aa0ba902b7"},
+			ok:      true,
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			spanID:  "00f067aa0ba902b7",
+		},
+		{
+			name:    "64-bit trace id is padded",
+			props:   map[string]string{"trace_id": "A3CE929D0E0E4736", "span_id": "f067aa0ba902b7"},
+			ok:      true,
+			traceID: "0000000000000000a3ce929d0e0e4736",
+			spanID:  "00f067aa0ba902b7",
+		},
+		{name: "missing span id", props: map[string]string{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736"}},
+		{name: "not hex", props: map[string]string{"trace_id": "xyz", "span_id": "00f067aa0ba902b7"}},
+		{name: "too long", props: map[string]string{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736ff", "span_id": "00f067aa0ba902b7"}},
+		{name: "all zeros", props: map[string]string{"trace_id": "0", "span_id": "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := legacySpanContext(tt.props)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if got := sc.TraceID().String(); got != tt.traceID {
+				t.Errorf("trace id = %s, want %s", got, tt.traceID)
+			}
+			if got := sc.SpanID().String(); got != tt.spanID {
+				t.Errorf("span id = %s, want %s", got, tt.spanID)
+			}
+			if !sc.IsRemote() {
+				t.Error("span context should be remote")
+			}
+		})
+	}
+}
+
+func TestProducerLinks(t *testing.T) {
+	props := map[string]string{"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736", "span_id": "00f067aa0ba902b7"}
+
+	links := producerLinks(context.Background(), props)
+	if len(links) != 1 || links[0].SpanContext.SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("unexpected links: %+v", links)
+	}
+
+	// a W3C parent takes precedence, no link needed
+	carrier := propagation.MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	ctx := propagation.TraceContext{}.Extract(context.Background(), carrier)
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		t.Fatal("traceparent not extracted")
+	}
+	if links := producerLinks(ctx, props); len(links) != 0 {
+		t.Fatalf("expected no links, got %d", len(links))
+	}
+}
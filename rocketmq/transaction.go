@@ -0,0 +1,148 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+	"github.com/zeromicro/go-zero/core/logc"
+	"github.com/zeromicro/go-zero/core/logx"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// ErrTransactionDelay 事务消息不支持延迟投递
+var ErrTransactionDelay = errors.New("rocketmq: delay is not supported for transaction messages")
+
+// TransactionState 本地事务状态
+type TransactionState int
+
+const (
+	// TransactionUnknown 状态未知，broker 稍后会再次回查
+	TransactionUnknown TransactionState = iota
+	// TransactionCommit 本地事务已提交，投递消息
+	TransactionCommit
+	// TransactionRollback 本地事务已回滚，丢弃消息
+	TransactionRollback
+)
+
+// TransactionChecker 回查本地事务状态。生产者在本地事务结束后崩溃或提交/回滚请求丢失时，
+// broker 会调用 Check 确认半消息的最终状态，实现通常按消息中的业务 ID 查询数据库
+type TransactionChecker interface {
+	Check(ctx context.Context, msg *rmq.MessageView) TransactionState
+}
+
+// TransactionCheckerFunc 函数形式的 TransactionChecker
+type TransactionCheckerFunc func(ctx context.Context, msg *rmq.MessageView) TransactionState
+
+// Check 调用 f
+func (f TransactionCheckerFunc) Check(ctx context.Context, msg *rmq.MessageView) TransactionState {
+	return f(ctx, msg)
+}
+
+// TransactionProducer 事务消息生产者
+type TransactionProducer struct {
+	*Producer
+}
+
+// NewTransactionProducer 创建事务消息生产者，checker 用于回查本地事务状态。
+// conf.Topics 需要包含发送事务消息的 topic，否则收不到回查
+func NewTransactionProducer(conf *ProducerConfig, checker TransactionChecker) *TransactionProducer {
+	if checker == nil {
+		panic("rocketmq: transaction checker is nil")
+	}
+	return &TransactionProducer{
+		Producer: newProducer(conf, rmq.WithTransactionChecker(&rmq.TransactionChecker{
+			Check: func(msg *rmq.MessageView) rmq.TransactionResolution {
+				return resolve(checker, msg)
+			},
+		})),
+	}
+}
+
+// resolve 调用 checker，回查上下文沿用消息中的 trace，checker panic 时返回 UNKNOWN 等待下次回查
+func resolve(checker TransactionChecker, msg *rmq.MessageView) (resolution rmq.TransactionResolution) {
+	ctx := propagation.TraceContext{}.Extract(context.Background(), propagation.MapCarrier(msg.GetProperties()))
+	defer func() {
+		if r := recover(); r != nil {
+			logc.Errorf(ctx, "transaction check panic: %v, msgId: %s", r, msg.GetMessageId())
+			resolution = rmq.UNKNOWN
+		}
+	}()
+
+	switch checker.Check(ctx, msg) {
+	case TransactionCommit:
+		return rmq.COMMIT
+	case TransactionRollback:
+		return rmq.ROLLBACK
+	default:
+		return rmq.UNKNOWN
+	}
+}
+
+// PublishInTransaction 发送事务消息：先发送对消费者不可见的半消息，成功后执行 localTx，
+// localTx 返回 nil 时提交消息，返回错误或 panic 时回滚。半消息发送失败时不执行 localTx；
+// 提交请求失败只记录日志，由 checker 回查确定。
+// topic 不加 app 前缀，需要时使用 GetTopicName
+func (p *TransactionProducer) PublishInTransaction(ctx context.Context, topic Topic, msg []byte, localTx func() error, opts ...PublishOptionFunc) (err error) {
+	opt := &PublishOption{
+		timeout: p.timeout(),
+	}
+	for _, o := range opts {
+		o(opt)
+	}
+	if opt.delay != 0 || !opt.deliverAt.IsZero() {
+		return ErrTransactionDelay
+	}
+
+	actualTopic := string(topic)
+	ctx, span := startPublishSpan(ctx, "rocket.Producer.PublishInTransaction", actualTopic, msg)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else {
+			span.SetStatus(codes.Ok, "")
+		}
+		span.End()
+	}()
+
+	message := newMessage(ctx, actualTopic, msg, opt)
+	tx := p.BeginTransaction()
+
+	sendCtx, cancel := context.WithTimeout(ctx, opt.timeout)
+	result, err := p.SendWithTransaction(sendCtx, message, tx)
+	cancel()
+	if err != nil {
+		logx.WithContext(ctx).Errorf("send half message failed: %v, topic: %s, msg: %s", err, actualTopic, string(msg))
+		return fmt.Errorf("send half message: %w", err)
+	}
+	span.SetAttributes(attribute.String("message.id", result[0].MessageID))
+
+	defer func() {
+		if r := recover(); r != nil {
+			rollback(ctx, tx, result[0].MessageID)
+			panic(r)
+		}
+	}()
+	if err = localTx(); err != nil {
+		rollback(ctx, tx, result[0].MessageID)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		// 本地事务已提交，不返回错误，消息状态由 broker 回查 checker 决定
+		logc.Errorf(ctx, "commit transaction message failed: %v, msgId: %s", err, result[0].MessageID)
+		return nil
+	}
+	logc.Infof(ctx, "transaction message committed, topic: %s, msgId: %s", actualTopic, result[0].MessageID)
+	return nil
+}
+
+func rollback(ctx context.Context, tx rmq.Transaction, msgID string) {
+	if err := tx.RollBack(); err != nil {
+		logc.Errorf(ctx, "rollback transaction message failed: %v, msgId: %s", err, msgID)
+	}
+}
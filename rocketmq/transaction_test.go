@@ -0,0 +1,155 @@
+package rocketmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	rmq "github.com/apache/rocketmq-clients/golang/v5"
+)
+
+type fakeTransaction struct {
+	committed, rolledBack bool
+}
+
+func (f *fakeTransaction) Commit() error {
+	f.committed = true
+	return nil
+}
+
+func (f *fakeTransaction) RollBack() error {
+	f.rolledBack = true
+	return nil
+}
+
+type fakeTxProducer struct {
+	rmq.Producer
+	tx       *fakeTransaction
+	sendErr  error
+	sent     *rmq.Message
+	deadline time.Time
+}
+
+func (f *fakeTxProducer) BeginTransaction() rmq.Transaction {
+	return f.tx
+}
+
+func (f *fakeTxProducer) SendWithTransaction(ctx context.Context, msg *rmq.Message, tx rmq.Transaction) ([]*rmq.SendReceipt, error) {
+	if f.sendErr != nil {
+		return nil, f.sendErr
+	}
+	f.sent = msg
+	f.deadline, _ = ctx.Deadline()
+	return []*rmq.SendReceipt{{MessageID: "m1"}}, nil
+}
+
+func TestPublishInTransaction(t *testing.T) {
+	errLocal := errors.New("insert order failed")
+	errSend := errors.New("broker unavailable")
+
+	tests := []struct {
+		name         string
+		sendErr      error
+		localErr     error
+		opts         []PublishOptionFunc
+		wantErr      error
+		wantLocalRun bool
+		wantCommit   bool
+		wantRollback bool
+	}{
+		{name: "commit", wantLocalRun: true, wantCommit: true},
+		{name: "local failure rolls back", localErr: errLocal, wantErr: errLocal, wantLocalRun: true, wantRollback: true},
+		{name: "half message failure skips local tx", sendErr: errSend, wantErr: errSend},
+		{name: "delay rejected", opts: []PublishOptionFunc{WithDelay(time.Minute)}, wantErr: ErrTransactionDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeTxProducer{tx: &fakeTransaction{}, sendErr: tt.sendErr}
+			p := &TransactionProducer{Producer: &Producer{Producer: fake}}
+
+			ran := false
+			err := p.PublishInTransaction(context.Background(), "orders", []byte("o1"), func() error {
+				ran = true
+				return tt.localErr
+			}, tt.opts...)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if ran != tt.wantLocalRun {
+				t.Errorf("local tx ran = %v, want %v", ran, tt.wantLocalRun)
+			}
+			if fake.tx.committed != tt.wantCommit || fake.tx.rolledBack != tt.wantRollback {
+				t.Errorf("committed = %v, rolled back = %v", fake.tx.committed, fake.tx.rolledBack)
+			}
+		})
+	}
+}
+
+func TestPublishInTransactionPanic(t *testing.T) {
+	fake := &fakeTxProducer{tx: &fakeTransaction{}}
+	p := &TransactionProducer{Producer: &Producer{Producer: fake}}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("panic was swallowed")
+		}
+		if !fake.tx.rolledBack || fake.tx.committed {
+			t.Errorf("committed = %v, rolled back = %v", fake.tx.committed, fake.tx.rolledBack)
+		}
+	}()
+	_ = p.PublishInTransaction(context.Background(), "orders", []byte("o1"), func() error {
+		panic("boom")
+	})
+}
+
+func TestResolve(t *testing.T) {
+	tests := []struct {
+		name    string
+		checker TransactionCheckerFunc
+		want    rmq.TransactionResolution
+	}{
+		{name: "commit", checker: func(context.Context, *rmq.MessageView) TransactionState { return TransactionCommit }, want: rmq.COMMIT},
+		{name: "rollback", checker: func(context.Context, *rmq.MessageView) TransactionState { return TransactionRollback }, want: rmq.ROLLBACK},
+		{name: "unknown", checker: func(context.Context, *rmq.MessageView) TransactionState { return TransactionUnknown }, want: rmq.UNKNOWN},
+		{name: "panic", checker: func(context.Context, *rmq.MessageView) TransactionState { panic("db down") }, want: rmq.UNKNOWN},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolve(tt.checker, &rmq.MessageView{}); got != tt.want {
+				t.Errorf("resolve = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishInTransactionTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		sendTimeout time.Duration
+		opts        []PublishOptionFunc
+		want        time.Duration
+	}{
+		{name: "default", want: defaultSendTimeout},
+		{name: "producer config", sendTimeout: time.Minute, want: time.Minute},
+		{name: "option", sendTimeout: time.Minute, opts: []PublishOptionFunc{WithTimeout(time.Hour)}, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeTxProducer{tx: &fakeTransaction{}}
+			p := &TransactionProducer{Producer: &Producer{Producer: fake, sendTimeout: tt.sendTimeout}}
+
+			start := time.Now()
+			if err := p.PublishInTransaction(context.Background(), "orders", []byte("o1"), func() error { return nil }, tt.opts...); err != nil {
+				t.Fatal(err)
+			}
+			if got := fake.deadline.Sub(start); got < tt.want || got > tt.want+time.Second {
+				t.Errorf("send timeout = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
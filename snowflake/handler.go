@@ -0,0 +1,78 @@
+package snowflake
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// DefaultBatchSize is the number of ids served when count is not given
+	DefaultBatchSize = 1
+	// MaxBatchSize caps count, a full millisecond worth of sequence numbers
+	MaxBatchSize = int(maxSequence) + 1
+)
+
+// Batch is the response of Handler. Ids are strings since JavaScript and JSON
+// decoders using float64 cannot hold 63 bit integers
+type Batch struct {
+	IDs  []string `json:"ids"`
+	Node int64    `json:"node"`
+	// Epoch and the bit sizes let clients decode the timestamp of an id:
+	// ms = id>>(SequenceBits+NodeBits) + Epoch
+	Epoch        int64 `json:"epoch"`
+	SequenceBits int   `json:"sequenceBits"`
+	NodeBits     int   `json:"nodeBits"`
+}
+
+// Node returns the random node id of this process, the low bits of every id
+func Node() int64 {
+	return generator.randomNode
+}
+
+// GenerateBatch returns n ids from the process generator
+func GenerateBatch(n int) []int64 {
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = generator.generate()
+	}
+	return ids
+}
+
+// Handler serves batches of ids from the same id space as Generate, for services
+// that cannot link this package. GET ?count=n returns a Batch of n ids, at most
+// MaxBatchSize. Mount it behind the service's own auth, e.g. with rest.WithHandler
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		count := DefaultBatchSize
+		if v := r.URL.Query().Get("count"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > MaxBatchSize {
+				http.Error(w, "count must be between 1 and "+strconv.Itoa(MaxBatchSize), http.StatusBadRequest)
+				return
+			}
+			count = n
+		}
+
+		batch := Batch{
+			IDs:          make([]string, 0, count),
+			Node:         Node(),
+			Epoch:        epoch,
+			SequenceBits: sequenceBits,
+			NodeBits:     randomNodeBits,
+		}
+		for _, id := range GenerateBatch(count) {
+			batch.IDs = append(batch.IDs, strconv.FormatInt(id, 10))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(batch)
+	})
+}
@@ -0,0 +1,67 @@
+package snowflake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		wantStatus int
+		wantIDs    int
+	}{
+		{name: "default", method: http.MethodGet, wantStatus: http.StatusOK, wantIDs: DefaultBatchSize},
+		{name: "batch", method: http.MethodGet, query: "?count=100", wantStatus: http.StatusOK, wantIDs: 100},
+		{name: "max", method: http.MethodGet, query: "?count=" + strconv.Itoa(MaxBatchSize), wantStatus: http.StatusOK, wantIDs: MaxBatchSize},
+		{name: "too many", method: http.MethodGet, query: "?count=" + strconv.Itoa(MaxBatchSize+1), wantStatus: http.StatusBadRequest},
+		{name: "zero", method: http.MethodGet, query: "?count=0", wantStatus: http.StatusBadRequest},
+		{name: "not a number", method: http.MethodGet, query: "?count=ten", wantStatus: http.StatusBadRequest},
+		{name: "post", method: http.MethodPost, wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Handler().ServeHTTP(rec, httptest.NewRequest(tt.method, "/ids"+tt.query, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var batch Batch
+			if err := json.Unmarshal(rec.Body.Bytes(), &batch); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if len(batch.IDs) != tt.wantIDs {
+				t.Fatalf("got %d ids, want %d", len(batch.IDs), tt.wantIDs)
+			}
+			if batch.Node != Node() || batch.Epoch != epoch {
+				t.Errorf("node = %d, epoch = %d", batch.Node, batch.Epoch)
+			}
+
+			var last int64
+			for _, s := range batch.IDs {
+				id, err := strconv.ParseInt(s, 10, 64)
+				if err != nil {
+					t.Fatalf("invalid id %q: %v", s, err)
+				}
+				if id <= last {
+					t.Fatalf("id %d not greater than %d", id, last)
+				}
+				if decodeRandomNode(id) != batch.Node {
+					t.Errorf("id %d carries node %d, want %d", id, decodeRandomNode(id), batch.Node)
+				}
+				last = id
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"gomod.pri/golib/storage/obs"
+	"gomod.pri/golib/storage/oss"
+	"gomod.pri/golib/storage/s3"
+)
+
+// ErrAppendNotSupported is returned by AppendStream for clients without append support
+var ErrAppendNotSupported = errors.New("storage: append not supported")
+
+// Appender is implemented by clients that can append to an existing object:
+// OSS AppendObject, OBS append upload and an S3 multipart compose emulation.
+// The object is created on the first append.
+type Appender interface {
+	AppendStream(ctx context.Context, remote string, stream io.Reader) error
+}
+
+var (
+	_ Appender = (*oss.Client)(nil)
+	_ Appender = (*obs.Client)(nil)
+	_ Appender = (*s3.Client)(nil)
+)
+
+// AppendStream appends stream to remote, e.g. log chunks to a daily object,
+// without rewriting the whole file on providers with native append
+func AppendStream(ctx context.Context, s Storage, remote string, stream io.Reader) error {
+	a, ok := s.(Appender)
+	if !ok {
+		return ErrAppendNotSupported
+	}
+	return a.AppendStream(ctx, remote, stream)
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type appendingStorage struct {
+	memStorage
+}
+
+func (a *appendingStorage) AppendStream(_ context.Context, remote string, stream io.Reader) error {
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return err
+	}
+	a.objects[remote] = append(a.objects[remote], data...)
+	return nil
+}
+
+func TestAppendStream(t *testing.T) {
+	ctx := context.Background()
+
+	s := &appendingStorage{memStorage{objects: map[string][]byte{}}}
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		if err := AppendStream(ctx, s, "logs/2024-01-01.log", strings.NewReader(line)); err != nil {
+			t.Fatalf("AppendStream() error = %v", err)
+		}
+	}
+	if got := string(s.objects["logs/2024-01-01.log"]); got != "a\nb\nc\n" {
+		t.Fatalf("object = %q", got)
+	}
+
+	err := AppendStream(ctx, &memStorage{objects: map[string][]byte{}}, "x", strings.NewReader("a"))
+	if !errors.Is(err, ErrAppendNotSupported) {
+		t.Fatalf("AppendStream() error = %v, want ErrAppendNotSupported", err)
+	}
+}
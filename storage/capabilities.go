@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"gomod.pri/golib/storage/obs"
+	"gomod.pri/golib/storage/oss"
+	"gomod.pri/golib/storage/s3"
+	"gomod.pri/golib/storage/types"
+)
+
+// CapabilityReporter is implemented by clients that describe their features
+// and limits
+type CapabilityReporter interface {
+	Capabilities() types.Capabilities
+}
+
+var (
+	_ CapabilityReporter = (*oss.Client)(nil)
+	_ CapabilityReporter = (*obs.Client)(nil)
+	_ CapabilityReporter = (*s3.Client)(nil)
+)
+
+// Capabilities returns what s supports, e.g. to skip AppendStream or size
+// multipart uploads. Clients without CapabilityReporter are probed for the
+// optional interfaces they implement and report no provider features
+func Capabilities(s Storage) types.Capabilities {
+	if r, ok := s.(CapabilityReporter); ok {
+		return r.Capabilities()
+	}
+	_, appendable := s.(Appender)
+	return types.Capabilities{Append: appendable}
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"testing"
+
+	"gomod.pri/golib/storage/s3"
+	"gomod.pri/golib/storage/types"
+)
+
+func TestCapabilities(t *testing.T) {
+	c, err := s3.NewClient(types.Config{App: "app", Region: "us-east-1", Endpoint: "http://localhost:9000", Bucket: "media"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		s    Storage
+		want types.Capabilities
+	}{
+		{name: "plain", s: &memStorage{}, want: types.Capabilities{}},
+		{name: "appender", s: &appendingStorage{}, want: types.Capabilities{Append: true}},
+		{name: "reporter", s: c, want: c.Capabilities()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Capabilities(tt.s); got != tt.want {
+				t.Errorf("Capabilities() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := Capabilities(c); got.Provider != types.StorageProviderS3 || !got.Append || got.NativeAppend || got.MinPartSize != 5*types.MiB {
+		t.Errorf("s3 Capabilities() = %+v", got)
+	}
+}
@@ -0,0 +1,38 @@
+package storage_test
+
+import (
+	"os"
+	"testing"
+
+	"gomod.pri/golib/storage"
+	"gomod.pri/golib/storage/storagetest"
+	"gomod.pri/golib/storage/types"
+)
+
+// TestProviderContract runs the contract suite against a real bucket, e.g.
+//
+//	STORAGE_TEST_PROVIDER=oss STORAGE_TEST_ENDPOINT=... STORAGE_TEST_REGION=... \
+//	STORAGE_TEST_BUCKET=... STORAGE_TEST_ACCESS_KEY=... STORAGE_TEST_SECRET_KEY=... go test ./storage
+func TestProviderContract(t *testing.T) {
+	provider := os.Getenv("STORAGE_TEST_PROVIDER")
+	if provider == "" {
+		t.Skip("STORAGE_TEST_PROVIDER is not set")
+	}
+	cfg := types.Config{
+		App:       "storagetest",
+		Provider:  provider,
+		Endpoint:  os.Getenv("STORAGE_TEST_ENDPOINT"),
+		Region:    os.Getenv("STORAGE_TEST_REGION"),
+		AccessKey: os.Getenv("STORAGE_TEST_ACCESS_KEY"),
+		SecretKey: os.Getenv("STORAGE_TEST_SECRET_KEY"),
+		Bucket:    types.Bucket(os.Getenv("STORAGE_TEST_BUCKET")),
+	}
+
+	storagetest.RunContract(t, func(t *testing.T) storage.Storage {
+		s, err := storage.NewStorage(cfg.App, cfg, storage.WithValidation(0))
+		if err != nil {
+			t.Fatalf("NewStorage: %v", err)
+		}
+		return s
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 
 	"gomod.pri/golib/storage/obs"
 	"gomod.pri/golib/storage/oss"
@@ -23,17 +24,81 @@ type Storage interface {
 	CopyFile(ctx context.Context, source, target string) error
 }
 
-func NewStorage(appId string, cfg types.Config) (Storage, error) {
+// Validator is implemented by clients that can check credentials and bucket
+// access with a lightweight call (HeadBucket / GetBucketInfo)
+type Validator interface {
+	Validate(ctx context.Context) error
+}
+
+const defaultValidateTimeout = 5 * time.Second
+
+type options struct {
+	validate        bool
+	validateTimeout time.Duration
+}
+
+// Option configures NewStorage
+type Option func(*options)
+
+// WithValidation makes NewStorage check credentials and bucket access before
+// returning, so misconfiguration fails at startup. timeout <= 0 uses 5s
+func WithValidation(timeout time.Duration) Option {
+	return func(o *options) {
+		o.validate = true
+		o.validateTimeout = timeout
+	}
+}
+
+func NewStorage(appId string, cfg types.Config, opts ...Option) (Storage, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	provider := types.StorageProvider(strings.ToLower(cfg.Provider))
 
+	var (
+		s   Storage
+		err error
+	)
 	switch provider {
 	case types.StorageProviderOBS:
-		return obs.NewClient(cfg)
+		s, err = obs.NewClient(cfg)
 	case types.StorageProviderOSS:
-		return oss.NewClient(cfg)
+		s, err = oss.NewClient(cfg)
 	case types.StorageProviderS3:
-		return s3.NewClient(cfg)
+		s, err = s3.NewClient(cfg)
 	default:
 		return nil, fmt.Errorf("Unsupported storage provider: %s", cfg.Provider)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	if o.validate {
+		if err = validate(s, cfg, o.validateTimeout); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+func validate(s Storage, cfg types.Config, timeout time.Duration) error {
+	v, ok := s.(Validator)
+	if !ok {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = defaultValidateTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := v.Validate(ctx); err != nil {
+		return fmt.Errorf("storage: validate %s bucket %q at endpoint %q (region %q) failed, "+
+			"check AccessKey/SecretKey, Bucket, Endpoint and Region: %w",
+			cfg.Provider, cfg.Bucket, cfg.Endpoint, cfg.Region, err)
+	}
+	return nil
 }
@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gomod.pri/golib/storage/types"
+)
+
+type validatingStorage struct {
+	memStorage
+	err error
+}
+
+func (v *validatingStorage) Validate(ctx context.Context) error {
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("missing deadline")
+	}
+	return v.err
+}
+
+func TestValidate(t *testing.T) {
+	cfg := types.Config{Provider: "s3", Bucket: "media", Endpoint: "http://localhost:9000"}
+
+	tests := []struct {
+		name    string
+		s       Storage
+		wantErr string
+	}{
+		{"not a validator", &memStorage{}, ""},
+		{"valid", &validatingStorage{memStorage: memStorage{}}, ""},
+		{"invalid", &validatingStorage{memStorage: memStorage{}, err: errors.New("403 Forbidden")}, `validate s3 bucket "media"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(tt.s, cfg, time.Second)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "403 Forbidden") {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewStorageUnsupported(t *testing.T) {
+	if _, err := NewStorage("app", types.Config{Provider: "ftp"}, WithValidation(0)); err == nil {
+		t.Fatal("expected error")
+	}
+}
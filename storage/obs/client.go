@@ -2,10 +2,14 @@ package obs
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	huaweiObs "github.com/huaweicloud/huaweicloud-sdk-go-obs/obs"
 	"github.com/zeromicro/go-zero/core/logc"
@@ -13,9 +17,10 @@ import (
 )
 
 type Client struct {
-	AppId     string
-	obsClient *huaweiObs.ObsClient
-	bucket    types.Bucket
+	AppId      string
+	obsClient  *huaweiObs.ObsClient
+	bucket     types.Bucket
+	publicBase string // 私有桶为空
 }
 
 func NewClient(cfg types.Config) (*Client, error) {
@@ -24,7 +29,11 @@ func NewClient(cfg types.Config) (*Client, error) {
 		return nil, fmt.Errorf("Create obsClient error, errMsg: %s", err.Error())
 	}
 
-	return &Client{obsClient: obsClient, AppId: cfg.App, bucket: cfg.Bucket}, nil
+	c := &Client{obsClient: obsClient, AppId: cfg.App, bucket: cfg.Bucket}
+	if cfg.IsPublic() {
+		c.publicBase = cfg.PublicBase(false)
+	}
+	return c, nil
 }
 
 // buildKey 构建完整的对象Key，避免双斜杠问题
@@ -101,6 +110,23 @@ func (c *Client) DownloadStream(ctx context.Context, remote string) (io.ReadClos
 }
 
 func (c *Client) SignUrl(ctx context.Context, remote string, expires int) (string, error) {
+	signed, err := c.signedURL(ctx, remote, expires)
+	if err != nil {
+		return "", err
+	}
+	return url.QueryEscape(signed), nil
+}
+
+// ResolveURL 公共读桶返回直链（CDN 域名或桶域名），私有桶返回有效期为 ttl 的签名链接（ttl <= 0 使用 types.DefaultURLTTL）。
+// 与 SignUrl 不同，返回的链接不做 QueryEscape
+func (c *Client) ResolveURL(ctx context.Context, remote string, ttl time.Duration) (string, error) {
+	if c.publicBase != "" {
+		return types.JoinURL(c.publicBase, c.buildKey(remote)), nil
+	}
+	return c.signedURL(ctx, remote, int(types.SignTTL(ttl)/time.Second))
+}
+
+func (c *Client) signedURL(ctx context.Context, remote string, expires int) (string, error) {
 	// 构建Key，避免双斜杠问题
 	key := c.buildKey(remote)
 
@@ -121,7 +147,7 @@ func (c *Client) SignUrl(ctx context.Context, remote string, expires int) (strin
 		return "", fmt.Errorf("Signed url is empty")
 	}
 
-	return url.QueryEscape(output.SignedUrl), nil
+	return output.SignedUrl, nil
 }
 
 func (c *Client) CopyFile(ctx context.Context, source, target string) error {
@@ -141,3 +167,75 @@ func (c *Client) CopyFile(ctx context.Context, source, target string) error {
 
 	return err
 }
+
+// Validate 校验凭证与桶配置，调用 HeadBucket
+func (c *Client) Validate(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := c.obsClient.HeadBucket(string(c.bucket))
+	return err
+}
+
+// AppendStream 追加写入可追加对象，对象不存在时自动创建
+// 注意：通过 UploadStream/UploadFile 上传的普通对象不能追加
+func (c *Client) AppendStream(ctx context.Context, remote string, stream io.Reader) error {
+	key := c.buildKey(remote)
+
+	position, err := c.nextAppendPosition(key)
+	if err != nil {
+		logc.Errorf(ctx, "Append stream error, errMsg: %s", err.Error())
+		return err
+	}
+
+	input := &huaweiObs.AppendObjectInput{}
+	input.Bucket = string(c.bucket)
+	input.Key = key
+	input.Position = position
+	input.Body = stream
+
+	_, err = c.obsClient.AppendObject(input)
+	if err != nil {
+		logc.Errorf(ctx, "Append stream error, errMsg: %s", err.Error())
+	}
+
+	return err
+}
+
+// nextAppendPosition 获取下一次追加的位置，对象不存在时返回 0
+func (c *Client) nextAppendPosition(key string) (int64, error) {
+	input := &huaweiObs.GetObjectMetadataInput{}
+	input.Bucket = string(c.bucket)
+	input.Key = key
+
+	output, err := c.obsClient.GetObjectMetadata(input)
+	if err != nil {
+		var obsErr huaweiObs.ObsError
+		if errors.As(err, &obsErr) && obsErr.StatusCode == http.StatusNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	if output.NextAppendPosition == "" {
+		return 0, fmt.Errorf("object %s is not appendable", key)
+	}
+	return strconv.ParseInt(output.NextAppendPosition, 10, 64)
+}
+
+// Capabilities 返回 OBS 支持的功能与大小限制
+func (c *Client) Capabilities() types.Capabilities {
+	return types.Capabilities{
+		Provider:      types.StorageProviderOBS,
+		Append:        true,
+		NativeAppend:  true,
+		MaxAppendSize: 5 * types.GiB,
+		Tagging:       true,
+		PresignPut:    true,
+		MaxPutSize:    5 * types.GiB,
+		MinPartSize:   100 * types.KiB,
+		MaxPartSize:   5 * types.GiB,
+		MaxParts:      10000,
+		MaxObjectSize: 10000 * 5 * types.GiB,
+	}
+}
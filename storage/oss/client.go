@@ -2,9 +2,12 @@ package oss
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/aliyun/alibabacloud-oss-go-sdk-v2/oss"
@@ -15,9 +18,10 @@ import (
 )
 
 type Client struct {
-	AppId     string
-	ossClient *aliOss.Client
-	bucket    types.Bucket
+	AppId      string
+	ossClient  *aliOss.Client
+	bucket     types.Bucket
+	publicBase string // empty for private buckets
 }
 
 func NewClient(cfg types.Config) (*Client, error) {
@@ -27,7 +31,11 @@ func NewClient(cfg types.Config) (*Client, error) {
 		WithRegion(cfg.Region)
 
 	client := oss.NewClient(config)
-	return &Client{ossClient: client, AppId: cfg.App, bucket: cfg.Bucket}, nil
+	c := &Client{ossClient: client, AppId: cfg.App, bucket: cfg.Bucket}
+	if cfg.IsPublic() {
+		c.publicBase = cfg.PublicBase(false)
+	}
+	return c, nil
 }
 
 func (c *Client) UploadFile(ctx context.Context, remote, local string) error {
@@ -84,10 +92,28 @@ func (c *Client) DownloadStream(ctx context.Context, remote string) (io.ReadClos
 }
 
 func (c *Client) SignUrl(ctx context.Context, remote string, expires int) (string, error) {
+	signed, err := c.presign(ctx, remote, time.Second*time.Duration(expires))
+	if err != nil {
+		return "", err
+	}
+	return url.QueryEscape(signed), nil
+}
+
+// ResolveURL returns the direct URL of remote for public buckets and a URL
+// signed for ttl (<= 0 uses types.DefaultURLTTL) for private ones. Unlike
+// SignUrl the URL is not query escaped
+func (c *Client) ResolveURL(ctx context.Context, remote string, ttl time.Duration) (string, error) {
+	if c.publicBase != "" {
+		return types.JoinURL(c.publicBase, fmt.Sprintf("%s/%s", c.AppId, remote)), nil
+	}
+	return c.presign(ctx, remote, types.SignTTL(ttl))
+}
+
+func (c *Client) presign(ctx context.Context, remote string, expires time.Duration) (string, error) {
 	req, err := c.ossClient.Presign(ctx, &oss.GetObjectRequest{
 		Bucket: oss.Ptr(string(c.bucket)),
 		Key:    oss.Ptr(fmt.Sprintf("%s/%s", c.AppId, remote)),
-	}, oss.PresignExpires(time.Second*time.Duration(expires)))
+	}, oss.PresignExpires(expires))
 	if err != nil {
 		logc.Errorf(ctx, "Sign url error, errMsg: %s", err.Error())
 		return "", err
@@ -96,8 +122,7 @@ func (c *Client) SignUrl(ctx context.Context, remote string, expires int) (strin
 	if req.URL == "" {
 		return "", fmt.Errorf("Signed url is empty")
 	}
-
-	return url.QueryEscape(req.URL), nil
+	return req.URL, nil
 }
 
 func (c *Client) CopyFile(ctx context.Context, source, target string) error {
@@ -113,3 +138,71 @@ func (c *Client) CopyFile(ctx context.Context, source, target string) error {
 
 	return err
 }
+
+// Validate checks credentials and bucket access with GetBucketInfo
+func (c *Client) Validate(ctx context.Context) error {
+	_, err := c.ossClient.GetBucketInfo(ctx, &oss.GetBucketInfoRequest{
+		Bucket: oss.Ptr(string(c.bucket)),
+	})
+	return err
+}
+
+// AppendStream appends stream to an Appendable object, creating it on first use.
+// Objects written by UploadStream/UploadFile are Normal objects and can't be appended.
+func (c *Client) AppendStream(ctx context.Context, remote string, stream io.Reader) error {
+	key := fmt.Sprintf("%s/%s", c.AppId, remote)
+
+	position, err := c.nextAppendPosition(ctx, key)
+	if err != nil {
+		logc.Errorf(ctx, "Append stream error, errMsg: %s", err.Error())
+		return err
+	}
+
+	_, err = c.ossClient.AppendObject(ctx, &oss.AppendObjectRequest{
+		Bucket:   oss.Ptr(string(c.bucket)),
+		Key:      oss.Ptr(key),
+		Position: oss.Ptr(position),
+		Body:     stream,
+	})
+	if err != nil {
+		logc.Errorf(ctx, "Append stream error, errMsg: %s", err.Error())
+	}
+
+	return err
+}
+
+func (c *Client) nextAppendPosition(ctx context.Context, key string) (int64, error) {
+	head, err := c.ossClient.HeadObject(ctx, &oss.HeadObjectRequest{
+		Bucket: oss.Ptr(string(c.bucket)),
+		Key:    oss.Ptr(key),
+	})
+	if err != nil {
+		var serr *oss.ServiceError
+		if errors.As(err, &serr) && serr.StatusCode == http.StatusNotFound {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	if head.NextAppendPosition == nil {
+		return 0, fmt.Errorf("object %s is not appendable (type %s)", key, oss.ToString(head.ObjectType))
+	}
+	return strconv.ParseInt(*head.NextAppendPosition, 10, 64)
+}
+
+// Capabilities reports the features and limits of OSS
+func (c *Client) Capabilities() types.Capabilities {
+	return types.Capabilities{
+		Provider:      types.StorageProviderOSS,
+		Append:        true,
+		NativeAppend:  true,
+		MaxAppendSize: 5 * types.GiB,
+		Tagging:       true,
+		PresignPut:    true,
+		MaxPutSize:    5 * types.GiB,
+		MinPartSize:   100 * types.KiB,
+		MaxPartSize:   5 * types.GiB,
+		MaxParts:      10000,
+		MaxObjectSize: 10000 * 5 * types.GiB,
+	}
+}
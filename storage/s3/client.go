@@ -1,22 +1,27 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"gomod.pri/golib/storage/types"
 )
 
 type Client struct {
-	s3Client *s3.Client
-	bucket   string
-	AppId    string
+	s3Client   *s3.Client
+	bucket     string
+	AppId      string
+	publicBase string // empty for private buckets
 }
 
 func NewClient(cfg types.Config) (*Client, error) {
@@ -40,11 +45,15 @@ func NewClient(cfg types.Config) (*Client, error) {
 		o.UsePathStyle = true // use path style for s3, default is virtual hosted-style
 	})
 
-	return &Client{
+	c := &Client{
 		s3Client: s3Client,
 		bucket:   string(cfg.Bucket),
 		AppId:    cfg.App,
-	}, nil
+	}
+	if cfg.IsPublic() {
+		c.publicBase = cfg.PublicBase(true)
+	}
+	return c, nil
 }
 
 func (c *Client) UploadFile(ctx context.Context, remote, local string) error {
@@ -122,10 +131,14 @@ func (c *Client) SignUrl(ctx context.Context, remote string, expires int) (strin
 
 	presignClient := s3.NewPresignClient(c.s3Client)
 
+	var presignOpts []func(*s3.PresignOptions)
+	if expires > 0 {
+		presignOpts = append(presignOpts, s3.WithPresignExpires(time.Duration(expires)*time.Second))
+	}
 	request, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(c.bucket),
 		Key:    aws.String(key),
-	})
+	}, presignOpts...)
 
 	if err != nil {
 		return "", fmt.Errorf("failed to generate signed URL: %w", err)
@@ -134,6 +147,15 @@ func (c *Client) SignUrl(ctx context.Context, remote string, expires int) (strin
 	return request.URL, nil
 }
 
+// ResolveURL returns the direct URL of remote for public buckets and a URL
+// signed for ttl (<= 0 uses types.DefaultURLTTL) for private ones
+func (c *Client) ResolveURL(ctx context.Context, remote string, ttl time.Duration) (string, error) {
+	if c.publicBase != "" {
+		return types.JoinURL(c.publicBase, fmt.Sprintf("%s/%s", c.AppId, remote)), nil
+	}
+	return c.SignUrl(ctx, remote, int(types.SignTTL(ttl)/time.Second))
+}
+
 func (c *Client) CopyFile(ctx context.Context, source, target string) error {
 	_, err := c.s3Client.CopyObject(ctx, &s3.CopyObjectInput{
 		CopySource: aws.String(fmt.Sprintf("%s/%s", c.bucket, source)),
@@ -147,3 +169,132 @@ func (c *Client) CopyFile(ctx context.Context, source, target string) error {
 
 	return nil
 }
+
+// Validate checks credentials and bucket access with HeadBucket
+func (c *Client) Validate(ctx context.Context) error {
+	_, err := c.s3Client.HeadBucket(ctx, &s3.HeadBucketInput{
+		Bucket: aws.String(c.bucket),
+	})
+	return err
+}
+
+// minPartSize is the smallest non-final part S3 accepts in a multipart upload
+const minPartSize = 5 << 20
+
+// AppendStream emulates an append, S3 has no appendable objects: the object is
+// rewritten from the existing content plus stream. Objects of at least 5 MiB are
+// composed server side with UploadPartCopy, smaller ones are downloaded and re-uploaded.
+// Concurrent appends to the same object are not safe.
+func (c *Client) AppendStream(ctx context.Context, remote string, stream io.Reader) error {
+	key := fmt.Sprintf("%s/%s", c.AppId, remote)
+
+	chunk, err := io.ReadAll(stream)
+	if err != nil {
+		return fmt.Errorf("failed to read append stream: %w", err)
+	}
+
+	head, err := c.s3Client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var notFound *s3types.NotFound
+		if errors.As(err, &notFound) {
+			return c.UploadStream(ctx, remote, bytes.NewReader(chunk))
+		}
+		return fmt.Errorf("failed to head S3 object: %w", err)
+	}
+
+	if aws.ToInt64(head.ContentLength) < minPartSize {
+		existing, err := c.DownloadStream(ctx, remote)
+		if err != nil {
+			return err
+		}
+		defer existing.Close()
+
+		data, err := io.ReadAll(existing)
+		if err != nil {
+			return fmt.Errorf("failed to read S3 object: %w", err)
+		}
+		return c.UploadStream(ctx, remote, bytes.NewReader(append(data, chunk...)))
+	}
+
+	return c.compose(ctx, key, chunk)
+}
+
+// compose rewrites key as a two part multipart upload: a server side copy of
+// the current object followed by chunk
+func (c *Client) compose(ctx context.Context, key string, chunk []byte) error {
+	upload, err := c.s3Client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create multipart upload: %w", err)
+	}
+
+	abort := func(cause error) error {
+		_, _ = c.s3Client.AbortMultipartUpload(context.WithoutCancel(ctx), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(c.bucket),
+			Key:      aws.String(key),
+			UploadId: upload.UploadId,
+		})
+		return cause
+	}
+
+	copied, err := c.s3Client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+		Bucket:     aws.String(c.bucket),
+		Key:        aws.String(key),
+		UploadId:   upload.UploadId,
+		PartNumber: aws.Int32(1),
+		CopySource: aws.String(fmt.Sprintf("%s/%s", c.bucket, key)),
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to copy existing part: %w", err))
+	}
+
+	appended, err := c.s3Client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     aws.String(c.bucket),
+		Key:        aws.String(key),
+		UploadId:   upload.UploadId,
+		PartNumber: aws.Int32(2),
+		Body:       bytes.NewReader(chunk),
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to upload appended part: %w", err))
+	}
+
+	_, err = c.s3Client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:   aws.String(c.bucket),
+		Key:      aws.String(key),
+		UploadId: upload.UploadId,
+		MultipartUpload: &s3types.CompletedMultipartUpload{
+			Parts: []s3types.CompletedPart{
+				{PartNumber: aws.Int32(1), ETag: copied.CopyPartResult.ETag},
+				{PartNumber: aws.Int32(2), ETag: appended.ETag},
+			},
+		},
+	})
+	if err != nil {
+		return abort(fmt.Errorf("failed to complete multipart upload: %w", err))
+	}
+
+	return nil
+}
+
+// Capabilities reports the features and limits of S3. Append is emulated by
+// AppendStream, the existing object is copied as a single part of at most 5 GiB
+func (c *Client) Capabilities() types.Capabilities {
+	return types.Capabilities{
+		Provider:      types.StorageProviderS3,
+		Append:        true,
+		MaxAppendSize: 5 * types.GiB,
+		Tagging:       true,
+		PresignPut:    true,
+		MaxPutSize:    5 * types.GiB,
+		MinPartSize:   minPartSize,
+		MaxPartSize:   5 * types.GiB,
+		MaxParts:      10000,
+		MaxObjectSize: 5 * types.TiB,
+	}
+}
@@ -0,0 +1,215 @@
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gomod.pri/golib/storage"
+)
+
+// Factory returns the storage under test, called once per contract case
+type Factory func(t *testing.T) storage.Storage
+
+// RunContract checks the behavior every storage.Storage is expected to have.
+// Objects are written under a random storagetest/ prefix and are not deleted,
+// so real providers should run it against a bucket with a lifecycle rule.
+// Appender and URLResolver cases are skipped for clients without them
+func RunContract(t *testing.T, factory Factory) {
+	t.Helper()
+	prefix := "storagetest/" + randomID() + "/"
+
+	cases := []struct {
+		name string
+		run  func(t *testing.T, s storage.Storage, key func(string) string)
+	}{
+		{name: "StreamRoundTrip", run: testStreamRoundTrip},
+		{name: "EmptyObject", run: testEmptyObject},
+		{name: "FileRoundTrip", run: testFileRoundTrip},
+		{name: "Overwrite", run: testOverwrite},
+		{name: "DownloadMissing", run: testDownloadMissing},
+		{name: "CopyFile", run: testCopyFile},
+		{name: "CopyMissing", run: testCopyMissing},
+		{name: "SignUrl", run: testSignUrl},
+		{name: "Append", run: testAppend},
+		{name: "ResolveURL", run: testResolveURL},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := factory(t)
+			tc.run(t, s, func(name string) string { return prefix + tc.name + "/" + name })
+		})
+	}
+}
+
+func testStreamRoundTrip(t *testing.T, s storage.Storage, key func(string) string) {
+	ctx := testContext(t)
+	want := []byte("hello storage\n")
+	mustUpload(t, ctx, s, key("hello.txt"), want)
+	if got := mustDownload(t, ctx, s, key("hello.txt")); !bytes.Equal(got, want) {
+		t.Fatalf("downloaded %q, want %q", got, want)
+	}
+}
+
+func testEmptyObject(t *testing.T, s storage.Storage, key func(string) string) {
+	ctx := testContext(t)
+	mustUpload(t, ctx, s, key("empty"), nil)
+	if got := mustDownload(t, ctx, s, key("empty")); len(got) != 0 {
+		t.Fatalf("downloaded %d bytes from an empty object", len(got))
+	}
+}
+
+func testFileRoundTrip(t *testing.T, s storage.Storage, key func(string) string) {
+	ctx := testContext(t)
+	dir := t.TempDir()
+	want := bytes.Repeat([]byte("0123456789"), 1<<12)
+	local := filepath.Join(dir, "upload.bin")
+	if err := os.WriteFile(local, want, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UploadFile(ctx, key("file.bin"), local); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	// the target directory does not exist yet
+	target := filepath.Join(dir, "nested", "download.bin")
+	if err := s.DownloadFile(ctx, key("file.bin"), target); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	got, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("downloaded %d bytes, want %d matching bytes", len(got), len(want))
+	}
+}
+
+func testOverwrite(t *testing.T, s storage.Storage, key func(string) string) {
+	ctx := testContext(t)
+	mustUpload(t, ctx, s, key("doc"), []byte("first version, longer"))
+	mustUpload(t, ctx, s, key("doc"), []byte("second"))
+	if got := mustDownload(t, ctx, s, key("doc")); string(got) != "second" {
+		t.Fatalf("downloaded %q after overwrite, want %q", got, "second")
+	}
+}
+
+func testDownloadMissing(t *testing.T, s storage.Storage, key func(string) string) {
+	ctx := testContext(t)
+	rc, err := s.DownloadStream(ctx, key("missing"))
+	if err == nil {
+		rc.Close()
+		t.Fatal("DownloadStream of a missing object succeeded")
+	}
+	if err := s.DownloadFile(ctx, key("missing"), filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("DownloadFile of a missing object succeeded")
+	}
+}
+
+func testCopyFile(t *testing.T, s storage.Storage, key func(string) string) {
+	ctx := testContext(t)
+	want := []byte("copy me")
+	mustUpload(t, ctx, s, key("source"), want)
+	if err := s.CopyFile(ctx, key("source"), key("target")); err != nil {
+		t.Fatalf("CopyFile: %v", err)
+	}
+	if got := mustDownload(t, ctx, s, key("target")); !bytes.Equal(got, want) {
+		t.Fatalf("copy has %q, want %q", got, want)
+	}
+	if got := mustDownload(t, ctx, s, key("source")); !bytes.Equal(got, want) {
+		t.Fatalf("source has %q after copy, want %q", got, want)
+	}
+}
+
+func testCopyMissing(t *testing.T, s storage.Storage, key func(string) string) {
+	if err := s.CopyFile(testContext(t), key("missing"), key("target")); err == nil {
+		t.Fatal("CopyFile of a missing object succeeded")
+	}
+}
+
+func testSignUrl(t *testing.T, s storage.Storage, key func(string) string) {
+	ctx := testContext(t)
+	mustUpload(t, ctx, s, key("signed.txt"), []byte("signed"))
+	u, err := s.SignUrl(ctx, key("signed.txt"), 60)
+	if err != nil {
+		t.Fatalf("SignUrl: %v", err)
+	}
+	if !strings.Contains(u, "signed.txt") {
+		t.Fatalf("signed URL %q does not reference the object", u)
+	}
+}
+
+func testAppend(t *testing.T, s storage.Storage, key func(string) string) {
+	a, ok := s.(storage.Appender)
+	if !ok {
+		t.Skip("storage does not implement Appender")
+	}
+	ctx := testContext(t)
+	// the first append creates the object
+	for _, chunk := range []string{"line 1\n", "line 2\n", "line 3\n"} {
+		if err := a.AppendStream(ctx, key("log"), strings.NewReader(chunk)); err != nil {
+			t.Fatalf("AppendStream: %v", err)
+		}
+	}
+	if got := mustDownload(t, ctx, s, key("log")); string(got) != "line 1\nline 2\nline 3\n" {
+		t.Fatalf("appended object has %q", got)
+	}
+}
+
+func testResolveURL(t *testing.T, s storage.Storage, key func(string) string) {
+	r, ok := s.(storage.URLResolver)
+	if !ok {
+		t.Skip("storage does not implement URLResolver")
+	}
+	ctx := testContext(t)
+	mustUpload(t, ctx, s, key("resolved.txt"), []byte("resolved"))
+	for _, ttl := range []time.Duration{0, time.Minute} {
+		u, err := r.ResolveURL(ctx, key("resolved.txt"), ttl)
+		if err != nil {
+			t.Fatalf("ResolveURL(%s): %v", ttl, err)
+		}
+		if !strings.Contains(u, "resolved.txt") {
+			t.Fatalf("resolved URL %q does not reference the object", u)
+		}
+	}
+}
+
+func mustUpload(t *testing.T, ctx context.Context, s storage.Storage, remote string, data []byte) {
+	t.Helper()
+	if err := s.UploadStream(ctx, remote, bytes.NewReader(data)); err != nil {
+		t.Fatalf("UploadStream %s: %v", remote, err)
+	}
+}
+
+func mustDownload(t *testing.T, ctx context.Context, s storage.Storage, remote string) []byte {
+	t.Helper()
+	rc, err := s.DownloadStream(ctx, remote)
+	if err != nil {
+		t.Fatalf("DownloadStream %s: %v", remote, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read %s: %v", remote, err)
+	}
+	return data
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func randomID() string {
+	b := make([]byte, 6)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
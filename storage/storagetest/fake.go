@@ -0,0 +1,194 @@
+// Package storagetest provides an in-memory storage.Storage and a contract
+// test suite every provider is expected to pass.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gomod.pri/golib/storage"
+	"gomod.pri/golib/storage/types"
+)
+
+// ErrNotFound is returned by Fake for missing objects
+var ErrNotFound = errors.New("storagetest: object not found")
+
+// Fake is an in-memory storage.Storage, also implementing Appender,
+// URLResolver, Validator and CapabilityReporter. It is safe for concurrent use.
+type Fake struct {
+	mu       sync.Mutex
+	objects  map[string][]byte
+	failures map[string]error
+}
+
+var (
+	_ storage.Storage            = (*Fake)(nil)
+	_ storage.Appender           = (*Fake)(nil)
+	_ storage.URLResolver        = (*Fake)(nil)
+	_ storage.Validator          = (*Fake)(nil)
+	_ storage.CapabilityReporter = (*Fake)(nil)
+)
+
+func NewFake() *Fake {
+	return &Fake{
+		objects:  make(map[string][]byte),
+		failures: make(map[string]error),
+	}
+}
+
+// FailWith makes the method named op, e.g. "UploadStream", return err until
+// reset with a nil err. UploadFile and DownloadFile go through the stream methods
+func (f *Fake) FailWith(op string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err == nil {
+		delete(f.failures, op)
+		return
+	}
+	f.failures[op] = err
+}
+
+// Object returns a copy of the content of remote
+func (f *Fake) Object(remote string) ([]byte, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.objects[remote]
+	return bytes.Clone(data), ok
+}
+
+// Put stores data at remote, e.g. to seed a download test
+func (f *Fake) Put(remote string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[remote] = bytes.Clone(data)
+}
+
+// Keys returns the sorted keys of all objects
+func (f *Fake) Keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *Fake) check(ctx context.Context, op string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.failures[op]
+}
+
+func (f *Fake) UploadFile(ctx context.Context, remote, local string) error {
+	file, err := os.Open(local)
+	if err != nil {
+		return fmt.Errorf("failed to open local file: %w", err)
+	}
+	defer file.Close()
+	return f.UploadStream(ctx, remote, file)
+}
+
+func (f *Fake) UploadStream(ctx context.Context, remote string, stream io.Reader) error {
+	if err := f.check(ctx, "UploadStream"); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return fmt.Errorf("failed to read upload stream: %w", err)
+	}
+	f.Put(remote, data)
+	return nil
+}
+
+func (f *Fake) DownloadFile(ctx context.Context, remote, local string) error {
+	stream, err := f.DownloadStream(ctx, remote)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return fmt.Errorf("failed to create local directory: %w", err)
+	}
+	file, err := os.Create(local)
+	if err != nil {
+		return fmt.Errorf("failed to create local file: %w", err)
+	}
+	defer file.Close()
+	_, err = io.Copy(file, stream)
+	return err
+}
+
+func (f *Fake) DownloadStream(ctx context.Context, remote string) (io.ReadCloser, error) {
+	if err := f.check(ctx, "DownloadStream"); err != nil {
+		return nil, err
+	}
+	data, ok := f.Object(remote)
+	if !ok {
+		return nil, fmt.Errorf("download %s: %w", remote, ErrNotFound)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// SignUrl returns fake://objects/<remote>?expires=<unix>, the object need not exist
+func (f *Fake) SignUrl(ctx context.Context, remote string, expires int) (string, error) {
+	if err := f.check(ctx, "SignUrl"); err != nil {
+		return "", err
+	}
+	u := url.URL{Scheme: "fake", Host: "objects", Path: "/" + remote}
+	u.RawQuery = url.Values{"expires": {fmt.Sprint(time.Now().Add(time.Duration(expires) * time.Second).Unix())}}.Encode()
+	return u.String(), nil
+}
+
+func (f *Fake) ResolveURL(ctx context.Context, remote string, ttl time.Duration) (string, error) {
+	return f.SignUrl(ctx, remote, int(types.SignTTL(ttl)/time.Second))
+}
+
+func (f *Fake) CopyFile(ctx context.Context, source, target string) error {
+	if err := f.check(ctx, "CopyFile"); err != nil {
+		return err
+	}
+	data, ok := f.Object(source)
+	if !ok {
+		return fmt.Errorf("copy %s: %w", source, ErrNotFound)
+	}
+	f.Put(target, data)
+	return nil
+}
+
+func (f *Fake) AppendStream(ctx context.Context, remote string, stream io.Reader) error {
+	if err := f.check(ctx, "AppendStream"); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return fmt.Errorf("failed to read append stream: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[remote] = append(f.objects[remote], data...)
+	return nil
+}
+
+func (f *Fake) Validate(ctx context.Context) error {
+	return f.check(ctx, "Validate")
+}
+
+// Capabilities reports native append and no size limits
+func (f *Fake) Capabilities() types.Capabilities {
+	return types.Capabilities{Append: true, NativeAppend: true}
+}
@@ -0,0 +1,43 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gomod.pri/golib/storage"
+)
+
+func TestFake_Contract(t *testing.T) {
+	RunContract(t, func(t *testing.T) storage.Storage { return NewFake() })
+}
+
+func TestFake_FailWith(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	boom := errors.New("boom")
+
+	f.FailWith("UploadStream", boom)
+	if err := f.UploadStream(ctx, "a", strings.NewReader("x")); !errors.Is(err, boom) {
+		t.Fatalf("UploadStream error = %v, want boom", err)
+	}
+	if _, ok := f.Object("a"); ok {
+		t.Fatal("failed upload stored the object")
+	}
+
+	f.FailWith("UploadStream", nil)
+	if err := f.UploadStream(ctx, "a", strings.NewReader("x")); err != nil {
+		t.Fatalf("UploadStream after reset: %v", err)
+	}
+	if keys := f.Keys(); len(keys) != 1 || keys[0] != "a" {
+		t.Fatalf("Keys() = %v, want [a]", keys)
+	}
+}
+
+func TestFake_NotFound(t *testing.T) {
+	_, err := NewFake().DownloadStream(context.Background(), "missing")
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("DownloadStream error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	defaultTransferChunkSize = 1 << 20 // 1 MiB
+	defaultTransferChunks    = 8
+)
+
+type transferOptions struct {
+	chunkSize int
+	chunks    int
+	progress  func(transferred int64)
+}
+
+// TransferOption configures Transfer
+type TransferOption func(*transferOptions)
+
+// WithTransferBuffer sets the read-ahead buffer as chunks * chunkSize bytes
+func WithTransferBuffer(chunkSize, chunks int) TransferOption {
+	return func(o *transferOptions) {
+		if chunkSize > 0 {
+			o.chunkSize = chunkSize
+		}
+		if chunks > 0 {
+			o.chunks = chunks
+		}
+	}
+}
+
+// WithTransferProgress registers a callback invoked with the total bytes
+// handed to the destination so far
+func WithTransferProgress(fn func(transferred int64)) TransferOption {
+	return func(o *transferOptions) {
+		o.progress = fn
+	}
+}
+
+// Transfer streams srcKey from src into dstKey of dst without staging the object
+// on local disk. Download runs ahead of upload through a bounded buffer, so at
+// most chunks*chunkSize bytes are held in memory. It returns the bytes transferred.
+func Transfer(ctx context.Context, src Storage, srcKey string, dst Storage, dstKey string, opts ...TransferOption) (int64, error) {
+	o := &transferOptions{
+		chunkSize: defaultTransferChunkSize,
+		chunks:    defaultTransferChunks,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := src.DownloadStream(ctx, srcKey)
+	if err != nil {
+		return 0, fmt.Errorf("transfer download %s: %w", srcKey, err)
+	}
+
+	r := newChunkReader(ctx, stream, o.chunkSize, o.chunks, o.progress)
+	uploadErr := dst.UploadStream(ctx, dstKey, r)
+
+	// unblock the download goroutine before waiting for it
+	cancel()
+	stream.Close()
+	readErr := r.wait()
+
+	switch {
+	case uploadErr != nil:
+		if readErr != nil && !errors.Is(readErr, context.Canceled) {
+			uploadErr = errors.Join(uploadErr, readErr)
+		}
+		return r.transferred, fmt.Errorf("transfer upload %s: %w", dstKey, uploadErr)
+	case readErr != nil && !r.eof:
+		return r.transferred, fmt.Errorf("transfer download %s: %w", srcKey, readErr)
+	}
+	return r.transferred, nil
+}
+
+// chunkReader reads src in a background goroutine into a bounded channel of chunks
+type chunkReader struct {
+	chunks      chan []byte
+	chunk       []byte // chunk being consumed, returned to pool when drained
+	current     []byte // unread part of chunk
+	pool        sync.Pool
+	done        chan struct{}
+	readErr     error // written by the producer before chunks is closed
+	eof         bool  // the uploader observed io.EOF
+	transferred int64
+	progress    func(int64)
+}
+
+func newChunkReader(ctx context.Context, src io.Reader, chunkSize, chunks int, progress func(int64)) *chunkReader {
+	r := &chunkReader{
+		chunks:   make(chan []byte, chunks),
+		done:     make(chan struct{}),
+		progress: progress,
+	}
+	r.pool.New = func() any {
+		return make([]byte, chunkSize)
+	}
+
+	go func() {
+		defer close(r.chunks)
+		for {
+			buf := r.pool.Get().([]byte)[:chunkSize]
+			n, err := io.ReadFull(src, buf)
+			if n > 0 {
+				select {
+				case r.chunks <- buf[:n]:
+				case <-r.done:
+					return
+				case <-ctx.Done():
+					r.readErr = ctx.Err()
+					return
+				}
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return
+			}
+			if err != nil {
+				r.readErr = err
+				return
+			}
+		}
+	}()
+	return r
+}
+
+// Read implements io.Reader for the uploader
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.current) == 0 {
+		chunk, ok := <-r.chunks
+		if !ok {
+			// the producer has exited, readErr is safe to read
+			if r.readErr != nil {
+				return 0, r.readErr
+			}
+			r.eof = true
+			return 0, io.EOF
+		}
+		r.chunk, r.current = chunk, chunk
+	}
+
+	n := copy(p, r.current)
+	r.current = r.current[n:]
+	if len(r.current) == 0 {
+		r.pool.Put(r.chunk[:cap(r.chunk)])
+		r.chunk, r.current = nil, nil
+	}
+
+	r.transferred += int64(n)
+	if r.progress != nil {
+		r.progress(r.transferred)
+	}
+	return n, nil
+}
+
+// wait stops the producer, waits for it to exit and returns its error
+func (r *chunkReader) wait() error {
+	close(r.done)
+	for range r.chunks {
+	}
+	return r.readErr
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+type memStorage struct {
+	Storage
+	objects   map[string][]byte
+	uploadErr error
+}
+
+func (m *memStorage) DownloadStream(_ context.Context, remote string) (io.ReadCloser, error) {
+	data, ok := m.objects[remote]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memStorage) UploadStream(_ context.Context, remote string, stream io.Reader) error {
+	if m.uploadErr != nil {
+		return m.uploadErr
+	}
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return err
+	}
+	m.objects[remote] = data
+	return nil
+}
+
+func TestTransfer(t *testing.T) {
+	payload := []byte(strings.Repeat("golib", 10000))
+
+	tests := []struct {
+		name      string
+		srcKey    string
+		uploadErr error
+		wantErr   bool
+	}{
+		{name: "success", srcKey: "a.bin"},
+		{name: "missing source", srcKey: "missing.bin", wantErr: true},
+		{name: "upload failure", srcKey: "a.bin", uploadErr: errors.New("quota exceeded"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &memStorage{objects: map[string][]byte{"a.bin": payload}}
+			dst := &memStorage{objects: map[string][]byte{}, uploadErr: tt.uploadErr}
+
+			var progress int64
+			n, err := Transfer(context.Background(), src, tt.srcKey, dst, "b.bin",
+				WithTransferBuffer(4096, 2),
+				WithTransferProgress(func(transferred int64) { progress = transferred }),
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Transfer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if n != int64(len(payload)) || progress != n {
+				t.Fatalf("Transfer() = %d bytes, progress %d, want %d", n, progress, len(payload))
+			}
+			if !bytes.Equal(dst.objects["b.bin"], payload) {
+				t.Fatal("destination content mismatch")
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// BucketACL is the read access of a bucket
+type BucketACL string
+
+const (
+	// BucketACLPrivate objects are only readable through signed URLs (default)
+	BucketACLPrivate BucketACL = "private"
+	// BucketACLPublicRead objects are readable by anyone at a direct URL
+	BucketACLPublicRead BucketACL = "public-read"
+)
+
+// DefaultURLTTL is the lifetime of signed URLs when ResolveURL gets ttl <= 0
+const DefaultURLTTL = 15 * time.Minute
+
+// IsPublic reports whether objects of the bucket are served by direct URLs
+func (c Config) IsPublic() bool {
+	return BucketACL(strings.ToLower(string(c.ACL))) == BucketACLPublicRead
+}
+
+// PublicBase returns the base URL of public objects: PublicBaseURL if set
+// (e.g. a CDN domain), otherwise the bucket endpoint, path style
+// (https://endpoint/bucket) or virtual hosted (https://bucket.endpoint)
+func (c Config) PublicBase(pathStyle bool) string {
+	if c.PublicBaseURL != "" {
+		return strings.TrimSuffix(c.PublicBaseURL, "/")
+	}
+
+	endpoint := c.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	if pathStyle {
+		return u.Scheme + "://" + u.Host + "/" + string(c.Bucket)
+	}
+	return u.Scheme + "://" + string(c.Bucket) + "." + u.Host
+}
+
+// JoinURL appends the object key to base, escaping each path segment
+func JoinURL(base, key string) string {
+	return base + (&url.URL{Path: "/" + strings.TrimPrefix(key, "/")}).EscapedPath()
+}
+
+// SignTTL returns ttl, or DefaultURLTTL when ttl <= 0
+func SignTTL(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return DefaultURLTTL
+	}
+	return ttl
+}
@@ -0,0 +1,61 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/conf"
+)
+
+func TestPublicBase(t *testing.T) {
+	tests := []struct {
+		name      string
+		cfg       Config
+		pathStyle bool
+		want      string
+	}{
+		{name: "cdn", cfg: Config{PublicBaseURL: "https://cdn.example.com/", Endpoint: "oss-cn-hangzhou.aliyuncs.com"}, want: "https://cdn.example.com"},
+		{name: "virtual hosted", cfg: Config{Bucket: "media", Endpoint: "oss-cn-hangzhou.aliyuncs.com"}, want: "https://media.oss-cn-hangzhou.aliyuncs.com"},
+		{name: "path style", cfg: Config{Bucket: "media", Endpoint: "http://localhost:9000"}, pathStyle: true, want: "http://localhost:9000/media"},
+		{name: "no endpoint", cfg: Config{Bucket: "media"}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cfg.PublicBase(tt.pathStyle); got != tt.want {
+				t.Errorf("PublicBase() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinURL(t *testing.T) {
+	got := JoinURL("https://cdn.example.com", "app/avatars/a b#1.png")
+	if want := "https://cdn.example.com/app/avatars/a%20b%231.png"; got != want {
+		t.Errorf("JoinURL() = %q, want %q", got, want)
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	if (Config{}).IsPublic() || (Config{ACL: BucketACLPrivate}).IsPublic() {
+		t.Error("private bucket reported public")
+	}
+	if !(Config{ACL: "Public-Read"}).IsPublic() {
+		t.Error("public-read bucket reported private")
+	}
+	if SignTTL(0) != DefaultURLTTL || SignTTL(time.Minute) != time.Minute {
+		t.Error("unexpected SignTTL")
+	}
+}
+
+func TestConfig_Load(t *testing.T) {
+	var c struct {
+		Storage Config
+	}
+	content := `{"Storage": {"App": "order", "Provider": "oss", "Endpoint": "oss-cn-hangzhou.aliyuncs.com", "Region": "cn-hangzhou", "AccessKey": "ak", "SecretKey": "sk", "Bucket": "media"}}`
+	if err := conf.LoadFromJsonBytes([]byte(content), &c); err != nil {
+		t.Fatalf("LoadFromJsonBytes() error = %v", err)
+	}
+	if c.Storage.ACL != BucketACLPrivate || c.Storage.PublicBaseURL != "" {
+		t.Errorf("Storage = %+v, want private ACL and no public base URL", c.Storage)
+	}
+}
@@ -0,0 +1,54 @@
+package types
+
+const (
+	KiB int64 = 1 << 10
+	MiB int64 = 1 << 20
+	GiB int64 = 1 << 30
+	TiB int64 = 1 << 40
+)
+
+// Capabilities describes what a storage client and its provider support, so
+// generic code can pick a code path up front instead of failing at runtime.
+// Zero sizes mean the limit is unknown or there is none
+type Capabilities struct {
+	Provider StorageProvider
+
+	// Append is true when AppendStream is available
+	Append bool
+	// NativeAppend is true when the provider appends server side, false when
+	// the client emulates it by rewriting the object (S3)
+	NativeAppend bool
+	// MaxAppendSize is the largest size an appendable object may grow to
+	MaxAppendSize int64
+
+	// Tagging is true when the provider supports object tags
+	Tagging bool
+	// PresignPut is true when the provider accepts uploads to presigned URLs
+	PresignPut bool
+
+	// MaxPutSize is the largest object a single PUT may upload
+	MaxPutSize int64
+	// MinPartSize is the smallest non-final part of a multipart upload
+	MinPartSize int64
+	// MaxPartSize is the largest part of a multipart upload
+	MaxPartSize int64
+	// MaxParts is the most parts a multipart upload may have
+	MaxParts int
+	// MaxObjectSize is the largest object the provider stores
+	MaxObjectSize int64
+}
+
+// PartSize returns the part size to upload size bytes in at most MaxParts
+// parts, never smaller than preferred and MinPartSize. It returns 0 when size
+// does not fit in MaxParts parts of MaxPartSize
+func (c Capabilities) PartSize(size, preferred int64) int64 {
+	part := max(preferred, c.MinPartSize)
+	if c.MaxParts > 0 {
+		n := int64(c.MaxParts)
+		part = max(part, (size+n-1)/n)
+	}
+	if c.MaxPartSize > 0 && part > c.MaxPartSize {
+		return 0
+	}
+	return part
+}
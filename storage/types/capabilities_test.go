@@ -0,0 +1,28 @@
+package types
+
+import "testing"
+
+func TestCapabilitiesPartSize(t *testing.T) {
+	caps := Capabilities{MinPartSize: 5 * MiB, MaxPartSize: 5 * GiB, MaxParts: 10000}
+	tests := []struct {
+		name      string
+		caps      Capabilities
+		size      int64
+		preferred int64
+		want      int64
+	}{
+		{name: "preferred", caps: caps, size: 100 * MiB, preferred: 8 * MiB, want: 8 * MiB},
+		{name: "min part", caps: caps, size: 100 * MiB, preferred: MiB, want: 5 * MiB},
+		{name: "max parts", caps: caps, size: 100000 * MiB, preferred: 8 * MiB, want: 10 * MiB},
+		{name: "rounds up", caps: caps, size: 10000*8*MiB + 1, preferred: 8 * MiB, want: 8*MiB + 1},
+		{name: "too large", caps: caps, size: 6 * TiB * 10, preferred: 8 * MiB, want: 0},
+		{name: "no limits", caps: Capabilities{}, size: TiB, preferred: MiB, want: MiB},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.caps.PartSize(tt.size, tt.preferred); got != tt.want {
+				t.Errorf("PartSize(%d, %d) = %d, want %d", tt.size, tt.preferred, got, tt.want)
+			}
+		})
+	}
+}
@@ -16,6 +16,12 @@ type Config struct {
 	AccessKey string
 	SecretKey string
 	Bucket    Bucket
+	// ACL decides what ResolveURL returns: direct URLs for public-read
+	// buckets, signed URLs otherwise. Defaults to private
+	ACL BucketACL `json:",default=private"`
+	// PublicBaseURL is the CDN domain of a public bucket, e.g.
+	// https://cdn.example.com, empty uses the bucket endpoint
+	PublicBaseURL string `json:",optional"`
 }
 
 type Bucket string
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"gomod.pri/golib/storage/obs"
+	"gomod.pri/golib/storage/oss"
+	"gomod.pri/golib/storage/s3"
+	"gomod.pri/golib/storage/types"
+)
+
+// URLResolver is implemented by clients that know the ACL of their bucket,
+// configured by types.Config.ACL and PublicBaseURL
+type URLResolver interface {
+	ResolveURL(ctx context.Context, remote string, ttl time.Duration) (string, error)
+}
+
+var (
+	_ URLResolver = (*oss.Client)(nil)
+	_ URLResolver = (*obs.Client)(nil)
+	_ URLResolver = (*s3.Client)(nil)
+)
+
+// ResolveURL returns the URL business code hands out for remote: a direct
+// (CDN) URL for public buckets, a URL signed for ttl for private ones.
+// Clients without URLResolver fall back to SignUrl
+func ResolveURL(ctx context.Context, s Storage, remote string, ttl time.Duration) (string, error) {
+	if r, ok := s.(URLResolver); ok {
+		return r.ResolveURL(ctx, remote, ttl)
+	}
+	return s.SignUrl(ctx, remote, int(types.SignTTL(ttl)/time.Second))
+}
@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gomod.pri/golib/storage/s3"
+	"gomod.pri/golib/storage/types"
+)
+
+type signingStorage struct {
+	memStorage
+	expires int
+}
+
+func (s *signingStorage) SignUrl(_ context.Context, remote string, expires int) (string, error) {
+	s.expires = expires
+	return "https://signed/" + remote, nil
+}
+
+func TestResolveURL(t *testing.T) {
+	ctx := context.Background()
+
+	// clients without URLResolver sign with the default ttl
+	s := &signingStorage{}
+	got, err := ResolveURL(ctx, s, "a.png", 0)
+	if err != nil || got != "https://signed/a.png" || s.expires != int(types.DefaultURLTTL/time.Second) {
+		t.Fatalf("ResolveURL() = %q, %v, expires %d", got, err, s.expires)
+	}
+
+	cfg := types.Config{App: "app", Region: "us-east-1", Endpoint: "http://localhost:9000", Bucket: "media", AccessKey: "ak", SecretKey: "sk"}
+
+	public := cfg
+	public.ACL = types.BucketACLPublicRead
+	public.PublicBaseURL = "https://cdn.example.com"
+	c, err := s3.NewClient(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := ResolveURL(ctx, c, "img/a.png", time.Hour); got != "https://cdn.example.com/app/img/a.png" {
+		t.Errorf("public ResolveURL() = %q", got)
+	}
+
+	c, err = s3.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = ResolveURL(ctx, c, "img/a.png", time.Hour)
+	if err != nil || !strings.HasPrefix(got, "http://localhost:9000/media/app/img/a.png?") || !strings.Contains(got, "X-Amz-Expires=3600") {
+		t.Errorf("private ResolveURL() = %q, %v", got, err)
+	}
+}
@@ -12,10 +12,14 @@ import (
 )
 
 type Error struct {
-	code  int    // 错误码
-	msg   string // 用户可读的错误消息
-	cause error  // 原始错误（导致此错误的根本原因）
-	stack string // 可选的调用栈信息
+	code   int     // 错误码
+	msg    string  // 用户可读的错误消息
+	cause  error   // 原始错误（导致此错误的根本原因）
+	stack  string  // 可选的调用栈信息
+	fields []Field // 结构化字段，见 RaiseKV
+
+	logged      bool   // 已由 Raise 系列函数打印过日志，见 IsLogged
+	loggedTrace string // 打印日志时的 trace id
 }
 
 func (e *Error) SetCode(code int) *Error {
@@ -99,26 +103,41 @@ func New(code int, err error, useErrMsg ...bool) *Error {
 	return ce
 }
 
+// RaiseCtx 创建错误并打印日志，args 以 %+v 整体输出，需要可检索的字段时使用 RaiseKV。
+// err 已在同一链路中由 Raise 系列函数打印过时不再重复打印，args 作为字段保留在返回的错误上
 func RaiseCtx(ctx context.Context, code int, err error, args ...interface{}) *Error {
 	ce := New(code, err)
 
-	if err != nil {
-		logx.WithContext(ctx).WithCallerSkip(1).Errorf("%s, args: %+v", ce, args)
+	if err != nil && !ce.dedupe(ctx, err, args) {
+		if ok, suppressed := shouldLog(code, err.Error()); ok {
+			logx.WithContext(ctx).WithCallerSkip(1).Errorf("%s, args: %+v%s", ce, args, suppressedSuffix(suppressed))
+		}
 	}
 
 	return ce
 }
 
+// Raise 创建错误并打印日志，args 以 %+v 整体输出，需要可检索的字段时使用 RaiseKV。
+// 与 RaiseCtx 一样跳过已打印过的错误
 func Raise(code int, err error, args ...interface{}) *Error {
 	ce := New(code, err)
 
-	if err != nil {
-		logx.WithCallerSkip(1).Errorf("%s, args: %+v", ce, args)
+	if err != nil && !ce.dedupe(context.Background(), err, args) {
+		if ok, suppressed := shouldLog(code, err.Error()); ok {
+			logx.WithCallerSkip(1).Errorf("%s, args: %+v%s", ce, args, suppressedSuffix(suppressed))
+		}
 	}
 
 	return ce
 }
 
+func suppressedSuffix(suppressed int64) string {
+	if suppressed == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (sampled, %d similar suppressed)", suppressed)
+}
+
 func NewWithStack(code int, err error) *Error {
 	ce := New(code, err)
 	ce.stack = getStack(3)
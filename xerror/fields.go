@@ -0,0 +1,86 @@
+package xerror
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// badKey 是 kv 参数个数为奇数时最后一个值使用的键，与 slog 一致
+const badKey = "!BADKEY"
+
+// Field 错误附带的结构化字段
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Fields 返回错误附带的结构化字段，用于渲染或上报
+func (e *Error) Fields() []Field {
+	return e.fields
+}
+
+// WithFields 追加 key/value 形式的结构化字段，见 RaiseKV
+func (e *Error) WithFields(kv ...any) *Error {
+	e.fields = append(e.fields, kvFields(kv)...)
+	return e
+}
+
+// Raisef 用格式化消息创建错误并打印日志，支持 %w 包装原始错误。
+// 日志采样按 format 而不是格式化后的消息计数，同一类错误即使参数不同也会被合并。
+// %w 包装的错误已打印过时不再重复打印
+func Raisef(code int, format string, args ...any) *Error {
+	err := fmt.Errorf(format, args...)
+	ce := New(code, err)
+	if ce.dedupe(context.Background(), err, nil) {
+		return ce
+	}
+
+	if ok, suppressed := shouldLog(code, format); ok {
+		logx.WithCallerSkip(1).Errorf("%s%s", ce, suppressedSuffix(suppressed))
+	}
+	return ce
+}
+
+// RaiseKV 创建错误并打印日志，kv 为 key/value 交替的结构化字段，
+// 作为日志字段输出（便于日志平台检索），同时附加到返回的 Error 上；
+// err 已在同一链路中打印过时只附加字段，并继承已打印错误的字段：
+//
+//	xerror.RaiseKV(ctx, xerror.CodeCallFailed, err, "order_id", id, "provider", name)
+func RaiseKV(ctx context.Context, code int, err error, kv ...any) *Error {
+	ce := New(code, err).WithFields(kv...)
+
+	if err != nil && !ce.dedupe(ctx, err, nil) {
+		if ok, suppressed := shouldLog(code, err.Error()); ok {
+			logx.WithContext(ctx).WithCallerSkip(1).WithFields(logFields(ce.fields)...).
+				Errorf("%s%s", ce, suppressedSuffix(suppressed))
+		}
+	}
+	return ce
+}
+
+// kvFields 把 key/value 交替的参数转为字段，非字符串的键用 fmt.Sprint 转换
+func kvFields(kv []any) []Field {
+	fields := make([]Field, 0, (len(kv)+1)/2)
+	for i := 0; i < len(kv); i += 2 {
+		if i+1 == len(kv) {
+			fields = append(fields, Field{Key: badKey, Value: kv[i]})
+			break
+		}
+		key, ok := kv[i].(string)
+		if !ok {
+			key = fmt.Sprint(kv[i])
+		}
+		fields = append(fields, Field{Key: key, Value: kv[i+1]})
+	}
+	return fields
+}
+
+func logFields(fields []Field) []logx.LogField {
+	out := make([]logx.LogField, 0, len(fields))
+	for _, f := range fields {
+		out = append(out, logx.Field(f.Key, f.Value))
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+package xerror
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/zeromicro/go-zero/core/logx/logtest"
+)
+
+func TestKVFields(t *testing.T) {
+	tests := []struct {
+		name string
+		kv   []any
+		want []Field
+	}{
+		{name: "empty", kv: nil, want: []Field{}},
+		{name: "pairs", kv: []any{"order_id", 42, "provider", "stripe"}, want: []Field{{"order_id", 42}, {"provider", "stripe"}}},
+		{name: "non string key", kv: []any{7, true}, want: []Field{{"7", true}}},
+		{name: "odd count", kv: []any{"a", 1, "dangling"}, want: []Field{{"a", 1}, {badKey, "dangling"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := kvFields(tt.kv)
+			if len(got) != len(tt.want) {
+				t.Fatalf("kvFields() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("kvFields() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRaiseKV(t *testing.T) {
+	c := logtest.NewCollector(t)
+
+	cause := errors.New("upstream timeout")
+	ce := RaiseKV(context.Background(), CodeCallFailed, cause, "order_id", "o-1", "attempt", 3)
+
+	if !errors.Is(ce, cause) || ce.Code() != CodeCallFailed {
+		t.Fatalf("RaiseKV() = %v", ce)
+	}
+	if fields := ce.Fields(); len(fields) != 2 || fields[0].Key != "order_id" || fields[1].Value != 3 {
+		t.Fatalf("Fields() = %v", fields)
+	}
+	for _, want := range []string{`"order_id":"o-1"`, `"attempt":3`, "upstream timeout"} {
+		if !strings.Contains(c.String(), want) {
+			t.Errorf("log %q missing %s", c.String(), want)
+		}
+	}
+}
+
+func TestRaisef(t *testing.T) {
+	c := logtest.NewCollector(t)
+
+	cause := errors.New("connection refused")
+	ce := Raisef(CodeUnableConnect, "dial %s: %w", "db-1", cause)
+
+	if !errors.Is(ce, cause) {
+		t.Fatal("Raisef() lost the wrapped error")
+	}
+	if got := ce.Cause().Error(); got != "dial db-1: connection refused" {
+		t.Fatalf("cause = %q", got)
+	}
+	if !strings.Contains(c.String(), "dial db-1: connection refused") {
+		t.Errorf("log %q missing the message", c.String())
+	}
+}
+
+func TestRaisefSamplesByFormat(t *testing.T) {
+	SetLogSampling(SamplingConfig{First: 1, Thereafter: 100})
+	t.Cleanup(func() { SetLogSampling(SamplingConfig{}) })
+	c := logtest.NewCollector(t)
+
+	for i := 0; i < 5; i++ {
+		Raisef(CodeInternalError, "load user %d failed", i)
+	}
+	if n := strings.Count(c.String(), "load user"); n != 1 {
+		t.Errorf("logged %d entries, want 1", n)
+	}
+}
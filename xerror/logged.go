@@ -0,0 +1,68 @@
+package xerror
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// argsKey 跳过重复日志时，RaiseCtx/Raise 的 args 作为字段保留在错误上
+const argsKey = "args"
+
+// IsLogged 判断 err 链上是否有已经由 Raise 系列函数打印过日志的 *Error。
+// 标记按链路区分：err 在另一条链路（trace id 不同）中打印过时返回 false，
+// 打印时或判断时没有链路信息的视为同一条链路。
+// 中间件等统一打印错误日志的地方可以用它跳过已打印的错误
+func IsLogged(ctx context.Context, err error) bool {
+	return loggedError(err, traceID(ctx)) != nil
+}
+
+// loggedError 返回 err 链上第一个在 trace 中打印过日志的 *Error
+func loggedError(err error, trace string) *Error {
+	for err != nil {
+		if e, ok := err.(*Error); ok && e.logged && (e.loggedTrace == "" || trace == "" || e.loggedTrace == trace) {
+			return e
+		}
+		if multi, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, inner := range multi.Unwrap() {
+				if e := loggedError(inner, trace); e != nil {
+					return e
+				}
+			}
+			return nil
+		}
+		err = errors.Unwrap(err)
+	}
+	return nil
+}
+
+// dedupe 在 err 已打印过日志时返回 true，ce 继承已打印错误的字段，
+// 上层调用方的上下文不随日志丢失；并把 ce 标记为已打印
+func (e *Error) dedupe(ctx context.Context, err error, args []any) bool {
+	trace := traceID(ctx)
+	e.logged, e.loggedTrace = true, trace
+
+	inner := loggedError(err, trace)
+	if inner == nil {
+		return false
+	}
+	fields := make([]Field, 0, len(inner.fields)+len(e.fields)+1)
+	fields = append(fields, inner.fields...)
+	fields = append(fields, e.fields...)
+	if len(args) > 0 {
+		fields = append(fields, Field{Key: argsKey, Value: args})
+	}
+	e.fields = fields
+	return true
+}
+
+func traceID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		return sc.TraceID().String()
+	}
+	return ""
+}
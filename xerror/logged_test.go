@@ -0,0 +1,77 @@
+package xerror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/zeromicro/go-zero/core/logx/logtest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func traceCtx(id byte) context.Context {
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{id}, SpanID: trace.SpanID{id}})
+	return trace.ContextWithSpanContext(context.Background(), sc)
+}
+
+func TestRaiseCtxLogsOncePerChain(t *testing.T) {
+	c := logtest.NewCollector(t)
+	ctx := traceCtx(1)
+
+	// dao -> service -> handler 逐层向上抛出
+	dao := RaiseKV(ctx, CodeCallFailed, errors.New("connection reset"), "table", "orders")
+	svc := RaiseCtx(ctx, CodeInternalError, fmt.Errorf("load order: %w", dao), "o-1")
+	handler := RaiseCtx(ctx, CodeInternalError, svc)
+
+	if n := strings.Count(c.String(), "connection reset"); n != 1 {
+		t.Fatalf("logged %d times, want once: %s", n, c.String())
+	}
+	if !errors.Is(handler, dao) || handler.Code() != CodeInternalError {
+		t.Fatalf("handler error = %v", handler)
+	}
+	fields := handler.Fields()
+	if len(fields) != 2 || fields[0].Key != "table" || fields[1].Key != argsKey {
+		t.Fatalf("Fields() = %v, want the dao field and the service args", fields)
+	}
+}
+
+func TestIsLogged(t *testing.T) {
+	ctx := traceCtx(1)
+	logtest.Discard(t)
+	raised := RaiseCtx(ctx, CodeCallFailed, errors.New("timeout"))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "raised", ctx: ctx, err: raised, want: true},
+		{name: "wrapped", ctx: ctx, err: fmt.Errorf("call: %w", raised), want: true},
+		{name: "joined", ctx: ctx, err: errors.Join(errors.New("other"), raised), want: true},
+		{name: "without trace", ctx: context.Background(), err: raised, want: true},
+		{name: "other trace", ctx: traceCtx(2), err: raised, want: false},
+		{name: "not raised", ctx: ctx, err: New(CodeCallFailed, errors.New("timeout")), want: false},
+		{name: "plain error", ctx: ctx, err: errors.New("timeout"), want: false},
+		{name: "nil", ctx: ctx, err: nil, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsLogged(tt.ctx, tt.err); got != tt.want {
+				t.Errorf("IsLogged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRaiseCtxOtherTraceLogsAgain(t *testing.T) {
+	c := logtest.NewCollector(t)
+	shared := RaiseCtx(traceCtx(1), CodeCallFailed, errors.New("quota exceeded"))
+	RaiseCtx(traceCtx(2), CodeCallFailed, shared)
+
+	if n := strings.Count(c.String(), "quota exceeded"); n != 2 {
+		t.Fatalf("logged %d times, want once per trace", n)
+	}
+}
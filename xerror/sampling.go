@@ -0,0 +1,86 @@
+package xerror
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingConfig Raise/RaiseCtx 日志采样配置。
+// 每个 Interval 内，相同错误码+错误消息的前 First 条全部打印，
+// 之后每 Thereafter 条打印 1 条，并附带被跳过的条数
+type SamplingConfig struct {
+	First      int           // 每个周期内全部打印的条数，<=0 关闭采样
+	Thereafter int           // 超过 First 后每 Thereafter 条打印一条，<=0 时超出部分全部丢弃
+	Interval   time.Duration // 统计周期，默认 1s
+}
+
+const defaultSamplingInterval = time.Second
+
+var logSampler atomic.Pointer[sampler]
+
+// SetLogSampling 设置全局日志采样，First <= 0 关闭采样（默认）
+func SetLogSampling(cfg SamplingConfig) {
+	if cfg.First <= 0 {
+		logSampler.Store(nil)
+		return
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultSamplingInterval
+	}
+	logSampler.Store(&sampler{cfg: cfg, counts: make(map[string]*sampleCount), now: time.Now})
+}
+
+type sampler struct {
+	cfg    SamplingConfig
+	mu     sync.Mutex
+	counts map[string]*sampleCount
+	window time.Time
+	now    func() time.Time
+}
+
+type sampleCount struct {
+	total      int64
+	suppressed int64 // 上次打印后被跳过的条数
+}
+
+// shouldLog 判断是否打印日志，返回上次打印后被跳过的条数
+func shouldLog(code int, msg string) (bool, int64) {
+	s := logSampler.Load()
+	if s == nil {
+		return true, 0
+	}
+	return s.allow(strconv.Itoa(code) + "|" + msg)
+}
+
+func (s *sampler) allow(key string) (bool, int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.window) >= s.cfg.Interval {
+		// 新周期，清空计数避免 key 无限增长
+		s.window = now
+		s.counts = make(map[string]*sampleCount)
+	}
+
+	c, ok := s.counts[key]
+	if !ok {
+		c = &sampleCount{}
+		s.counts[key] = c
+	}
+	c.total++
+
+	first := int64(s.cfg.First)
+	if c.total <= first {
+		return true, 0
+	}
+	if s.cfg.Thereafter > 0 && (c.total-first)%int64(s.cfg.Thereafter) == 0 {
+		suppressed := c.suppressed
+		c.suppressed = 0
+		return true, suppressed
+	}
+	c.suppressed++
+	return false, 0
+}
@@ -0,0 +1,58 @@
+package xerror
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := &sampler{
+		cfg:    SamplingConfig{First: 2, Thereafter: 3, Interval: time.Second},
+		counts: make(map[string]*sampleCount),
+		now:    func() time.Time { return now },
+	}
+
+	var logged []int64
+	for i := 0; i < 8; i++ {
+		if ok, suppressed := s.allow("500|db down"); ok {
+			logged = append(logged, suppressed)
+		}
+	}
+	// 1, 2 logged, then every 3rd: 5 (2 suppressed), 8 (2 suppressed)
+	want := []int64{0, 0, 2, 2}
+	if len(logged) != len(want) {
+		t.Fatalf("logged %v, want %v", logged, want)
+	}
+	for i := range want {
+		if logged[i] != want[i] {
+			t.Fatalf("logged %v, want %v", logged, want)
+		}
+	}
+
+	if ok, _ := s.allow("500|other"); !ok {
+		t.Fatal("different message must be counted separately")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := s.allow("500|db down"); !ok {
+		t.Fatal("new interval must reset counts")
+	}
+}
+
+func TestSetLogSampling(t *testing.T) {
+	defer SetLogSampling(SamplingConfig{})
+
+	SetLogSampling(SamplingConfig{First: 1})
+	if ok, _ := shouldLog(1, "x"); !ok {
+		t.Fatal("first occurrence must be logged")
+	}
+	if ok, _ := shouldLog(1, "x"); ok {
+		t.Fatal("repeat must be dropped without Thereafter")
+	}
+
+	SetLogSampling(SamplingConfig{})
+	if ok, _ := shouldLog(1, "x"); !ok {
+		t.Fatal("sampling not disabled")
+	}
+}
@@ -0,0 +1,99 @@
+package xhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gomod.pri/golib/xerror"
+	"gomod.pri/golib/xutils/redact"
+)
+
+// maxErrorBodyLen 错误信息中展示的响应体最大长度
+const maxErrorBodyLen = 512
+
+// HTTPError 非2xx响应返回的结构化错误
+type HTTPError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	Headers    http.Header
+	Body       []byte
+}
+
+// Error 实现 error 接口，URL 去掉查询参数和用户信息，避免签名、token 进入日志
+func (e *HTTPError) Error() string {
+	body := redact.JSON(e.Body)
+	if len(body) > maxErrorBodyLen {
+		body = body[:maxErrorBodyLen]
+	}
+	return fmt.Sprintf("http status %d, %s %s, body: %s", e.StatusCode, e.Method, stripURL(e.URL), body)
+}
+
+// stripURL 去掉 URL 的查询参数、片段和用户信息
+func stripURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		if i := strings.IndexAny(raw, "?#"); i >= 0 {
+			return raw[:i]
+		}
+		return raw
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.ForceQuery = false
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// Code 将HTTP状态码映射为 xerror 错误码
+func (e *HTTPError) Code() int {
+	switch {
+	case e.StatusCode == http.StatusServiceUnavailable || e.StatusCode == http.StatusGatewayTimeout:
+		return xerror.CodeUnableConnect
+	case e.StatusCode >= 500:
+		return xerror.CodeCallFailed
+	default:
+		if _, ok := xerror.ErrMsgs[e.StatusCode]; ok {
+			return e.StatusCode
+		}
+		return xerror.CodeCallFailed
+	}
+}
+
+// XError 转换为 xerror.Error，原始 HTTPError 作为 cause 保留
+func (e *HTTPError) XError() *xerror.Error {
+	return xerror.New(e.Code(), e)
+}
+
+// DecodeBody 将错误响应体解析到v
+func (e *HTTPError) DecodeBody(v any) error {
+	if len(e.Body) == 0 {
+		return errors.New("empty error body")
+	}
+	return json.Unmarshal(e.Body, v)
+}
+
+// Temporary 是否为可重试的错误（429 或 5xx）
+func (e *HTTPError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// AsHTTPError 从错误链中提取 HTTPError
+func AsHTTPError(err error) (*HTTPError, bool) {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he, true
+	}
+	return nil, false
+}
+
+// IsStatus 判断err是否为指定状态码的 HTTPError
+func IsStatus(err error, statusCode int) bool {
+	he, ok := AsHTTPError(err)
+	return ok && he.StatusCode == statusCode
+}
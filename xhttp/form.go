@@ -0,0 +1,163 @@
+package xhttp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// FilePart multipart 请求中的一个文件
+type FilePart struct {
+	Field       string    // 表单字段名
+	FileName    string    // 文件名
+	ContentType string    // 默认 application/octet-stream
+	Reader      io.Reader // 文件内容，发送时流式读取，由调用方关闭
+	// Size 文件大小，所有文件都设置时请求带 Content-Length，否则使用分块传输，
+	// 部分合作方不接受分块上传
+	Size int64
+}
+
+// PostForm 发送 application/x-www-form-urlencoded 表单，日志中的敏感字段按 redact 规则脱敏
+func (c *Client) PostForm(ctx context.Context, url string, header map[string]string, form url.Values) (*http.Response, error) {
+	h := canonicalHeader(header)
+	if _, ok := h["Content-Type"]; !ok {
+		h["Content-Type"] = "application/x-www-form-urlencoded"
+	}
+	return c.Do(ctx, http.MethodPost, url, h, []byte(form.Encode()))
+}
+
+// PostMultipart 发送 multipart/form-data 请求，fields 为普通字段，files 的内容边读边发，
+// 不会整体读入内存。请求体只能读取一次，因此不会重试。日志只记录字段和文件名，不记录文件内容
+func (c *Client) PostMultipart(ctx context.Context, url string, header map[string]string, fields url.Values, files ...FilePart) (*http.Response, error) {
+	for i, f := range files {
+		if f.Field == "" || f.Reader == nil {
+			return nil, fmt.Errorf("multipart file %d: field and reader are required", i)
+		}
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	size := multipartSize(mw.Boundary(), fields, files)
+	go func() {
+		// 请求失败时 Transport 会关闭 pr，这里的写入随即返回错误
+		pw.CloseWithError(writeMultipart(mw, fields, files))
+	}()
+
+	// Content-Type 带 boundary，不能被调用方覆盖
+	h := canonicalHeader(header)
+	h["Content-Type"] = mw.FormDataContentType()
+	return c.send(ctx, request{
+		method:  http.MethodPost,
+		url:     url,
+		header:  h,
+		body:    pr,
+		size:    size,
+		logBody: c.redaction.text(multipartLog(fields, files)),
+	})
+}
+
+// writeMultipart 依次写入字段和文件
+func writeMultipart(mw *multipart.Writer, fields url.Values, files []FilePart) error {
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		for _, v := range fields[name] {
+			if err := mw.WriteField(name, v); err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range files {
+		part, err := mw.CreatePart(filePartHeader(f))
+		if err != nil {
+			return err
+		}
+		if f.Reader != nil {
+			if _, err = io.Copy(part, f.Reader); err != nil {
+				return fmt.Errorf("read multipart file %s: %w", f.FileName, err)
+			}
+		}
+	}
+	return mw.Close()
+}
+
+// multipartSize 计算请求体长度，有文件未设置 Size 时返回 0
+func multipartSize(boundary string, fields url.Values, files []FilePart) int64 {
+	size := int64(0)
+	empty := make([]FilePart, len(files))
+	for i, f := range files {
+		if f.Size <= 0 {
+			return 0
+		}
+		size += f.Size
+		f.Reader = nil
+		empty[i] = f
+	}
+
+	// 用相同的 boundary 写一遍不含文件内容的请求体，得到其余部分的长度
+	var cw countingWriter
+	mw := multipart.NewWriter(&cw)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return 0
+	}
+	if err := writeMultipart(mw, fields, empty); err != nil {
+		return 0
+	}
+	return size + cw.n
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func (f FilePart) contentType() string {
+	if f.ContentType == "" {
+		return "application/octet-stream"
+	}
+	return f.ContentType
+}
+
+func filePartHeader(f FilePart) textproto.MIMEHeader {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(f.Field), quoteEscaper.Replace(f.FileName)))
+	h.Set("Content-Type", f.contentType())
+	return h
+}
+
+// multipartLog 日志中记录的请求体：字段和文件概要
+func multipartLog(fields url.Values, files []FilePart) string {
+	var parts []string
+	if len(fields) > 0 {
+		parts = append(parts, fields.Encode())
+	}
+	for _, f := range files {
+		desc := fmt.Sprintf("%s=@%s(%s", f.Field, f.FileName, f.contentType())
+		if f.Size > 0 {
+			desc += fmt.Sprintf(", %d bytes", f.Size)
+		}
+		parts = append(parts, desc+")")
+	}
+	return strings.Join(parts, "&")
+}
+
+// canonicalHeader 返回规范化请求头名称后的副本
+func canonicalHeader(header map[string]string) map[string]string {
+	h := make(map[string]string, len(header)+1)
+	for k, v := range header {
+		h[http.CanonicalHeaderKey(k)] = v
+	}
+	return h
+}
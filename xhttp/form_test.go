@@ -0,0 +1,139 @@
+package xhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// uploadServer 回显收到的表单字段和文件
+func uploadServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var lines []string
+		lines = append(lines, "content-length="+strconv.FormatInt(r.ContentLength, 10))
+		for k, v := range r.PostForm {
+			lines = append(lines, k+"="+strings.Join(v, ","))
+		}
+		if r.MultipartForm != nil {
+			for field, files := range r.MultipartForm.File {
+				for _, fh := range files {
+					f, _ := fh.Open()
+					data, _ := io.ReadAll(f)
+					f.Close()
+					lines = append(lines, field+"="+fh.Filename+":"+fh.Header.Get("Content-Type")+":"+string(data))
+				}
+			}
+		}
+		w.Write([]byte(strings.Join(lines, "\n")))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient_PostForm(t *testing.T) {
+	srv := uploadServer(t)
+	var logged *RequestResponseLog
+	done := make(chan struct{})
+	c := NewClient(WithLogger(nopLogger{}), WithLogHandler(func(l *RequestResponseLog) {
+		logged = l
+		close(done)
+	}))
+
+	resp, err := c.PostForm(context.Background(), srv.URL, nil, url.Values{"name": {"ann"}, "password": {"hunter2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "name=ann") || !strings.Contains(string(body), "password=hunter2") {
+		t.Errorf("server got %q", body)
+	}
+
+	<-done
+	if strings.Contains(logged.Request, "hunter2") || !strings.Contains(logged.Request, "name=ann") {
+		t.Errorf("logged request = %q, want password redacted", logged.Request)
+	}
+	if logged.Headers["Content-Type"] != "application/x-www-form-urlencoded" {
+		t.Errorf("logged headers = %v", logged.Headers)
+	}
+}
+
+func TestClient_PostMultipart(t *testing.T) {
+	srv := uploadServer(t)
+	c := NewClient(WithLogger(nopLogger{}))
+	ctx := context.Background()
+	fields := url.Values{"user_id": {"42"}}
+
+	tests := []struct {
+		name       string
+		files      []FilePart
+		wantLength bool
+		want       []string
+	}{
+		{
+			name: "sized files send content length",
+			files: []FilePart{
+				{Field: "front", FileName: "id-front.jpg", ContentType: "image/jpeg", Reader: strings.NewReader("jpeg"), Size: 4},
+				{Field: "back", FileName: `b"ack.pdf`, Reader: strings.NewReader("pdf!"), Size: 4},
+			},
+			wantLength: true,
+			want:       []string{"user_id=42", "front=id-front.jpg:image/jpeg:jpeg", `back=b"ack.pdf:application/octet-stream:pdf!`},
+		},
+		{
+			name: "unsized file is chunked",
+			// OneByteReader 模拟慢速的流式读取
+			files: []FilePart{{Field: "doc", FileName: "doc.txt", ContentType: "text/plain", Reader: iotest.OneByteReader(strings.NewReader("hello"))}},
+			want:  []string{"content-length=-1", "doc=doc.txt:text/plain:hello"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := c.PostMultipart(ctx, srv.URL, nil, fields, tt.files...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := io.ReadAll(resp.Body)
+			got := string(data)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("server got %q, want %q", got, want)
+				}
+			}
+			if tt.wantLength && strings.Contains(got, "content-length=-1") {
+				t.Errorf("server got chunked body, want content length")
+			}
+		})
+	}
+}
+
+func TestClient_PostMultipartErrors(t *testing.T) {
+	srv := uploadServer(t)
+	c := NewClient(WithLogger(nopLogger{}))
+	ctx := context.Background()
+
+	if _, err := c.PostMultipart(ctx, srv.URL, nil, nil, FilePart{Field: "doc"}); err == nil {
+		t.Error("PostMultipart() without reader error = nil")
+	}
+
+	readErr := errors.New("disk gone")
+	_, err := c.PostMultipart(ctx, srv.URL, nil, nil, FilePart{Field: "doc", FileName: "doc.txt", Reader: iotest.ErrReader(readErr)})
+	if !errors.Is(err, readErr) {
+		t.Errorf("PostMultipart() with failing reader error = %v, want %v", err, readErr)
+	}
+
+	// 连接失败时写入协程随 Transport 关闭请求体退出
+	_, err = c.PostMultipart(ctx, "http://127.0.0.1:1", nil, nil, FilePart{Field: "doc", Reader: strings.NewReader("x")})
+	if err == nil {
+		t.Error("PostMultipart() to closed port error = nil")
+	}
+}
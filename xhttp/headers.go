@@ -0,0 +1,107 @@
+package xhttp
+
+import (
+	"net/http"
+	"path"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+const libraryPath = "gomod.pri/golib"
+
+// WithDefaultHeaders 设置每个请求都带上的请求头，如鉴权头；可多次调用合并，
+// 单次请求传入的同名请求头优先
+func WithDefaultHeaders(headers map[string]string) ClientOption {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(http.Header, len(headers))
+		}
+		for k, v := range headers {
+			c.headers.Set(k, v)
+		}
+	}
+}
+
+// WithUserAgent 设置调用方服务名和版本，组合为 "service/version golib/x.y.z go1.x"，
+// 未设置时取当前二进制的模块路径和版本
+func WithUserAgent(service, version string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = composeUserAgent(service, version)
+	}
+}
+
+var defaultUserAgent = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return composeUserAgent("", "")
+	}
+	return composeUserAgent(path.Base(info.Main.Path), info.Main.Version)
+})
+
+func composeUserAgent(service, version string) string {
+	var parts []string
+	if service != "" {
+		parts = append(parts, productToken(service, version))
+	}
+	parts = append(parts, productToken("golib", libraryVersion()), runtime.Version())
+	return strings.Join(parts, " ")
+}
+
+// productToken 按 RFC 9110 格式化 name/version，去掉版本中的空白和 "(devel)" 之类的占位
+func productToken(name, version string) string {
+	name = strings.Join(strings.Fields(name), "-")
+	if version == "" || strings.HasPrefix(version, "(") {
+		return name
+	}
+	return name + "/" + strings.Join(strings.Fields(version), "-")
+}
+
+func libraryVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	if info.Main.Path == libraryPath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == libraryPath {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return ""
+}
+
+// applyDefaultHeaders 设置默认请求头和 User-Agent，在透传和单次请求头之前调用
+func (c *Client) applyDefaultHeaders(req *http.Request) {
+	for k, v := range c.headers {
+		req.Header[k] = append([]string(nil), v...)
+	}
+	if req.Header.Get("User-Agent") == "" {
+		ua := c.userAgent
+		if ua == "" {
+			ua = defaultUserAgent()
+		}
+		req.Header.Set("User-Agent", ua)
+	}
+}
+
+// logHeaders 合并默认请求头和单次请求头用于日志
+func (c *Client) logHeaders(header map[string]string) map[string]string {
+	if len(c.headers) == 0 {
+		return header
+	}
+	merged := make(map[string]string, len(c.headers)+len(header))
+	for k := range c.headers {
+		merged[k] = c.headers.Get(k)
+	}
+	for k, v := range header {
+		merged[http.CanonicalHeaderKey(k)] = v
+	}
+	return merged
+}
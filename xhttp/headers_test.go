@@ -0,0 +1,77 @@
+package xhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestDefaultHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		opts   []ClientOption
+		header map[string]string
+		want   map[string]string
+	}{
+		{
+			name: "defaults applied",
+			opts: []ClientOption{WithDefaultHeaders(map[string]string{"Authorization": "Bearer t1", "x-tenant": "th"})},
+			want: map[string]string{"Authorization": "Bearer t1", "X-Tenant": "th"},
+		},
+		{
+			name:   "request header wins",
+			opts:   []ClientOption{WithDefaultHeaders(map[string]string{"Authorization": "Bearer t1"})},
+			header: map[string]string{"Authorization": "Bearer t2"},
+			want:   map[string]string{"Authorization": "Bearer t2"},
+		},
+		{
+			name: "merged across options",
+			opts: []ClientOption{
+				WithDefaultHeaders(map[string]string{"X-Tenant": "th"}),
+				WithDefaultHeaders(map[string]string{"X-Tenant": "vn", "X-Region": "sg"}),
+			},
+			want: map[string]string{"X-Tenant": "vn", "X-Region": "sg"},
+		},
+		{
+			name: "user agent",
+			opts: []ClientOption{WithUserAgent("order-api", "v1.4.2")},
+			want: map[string]string{"User-Agent": "order-api/v1.4.2 golib " + runtime.Version()},
+		},
+		{
+			name:   "request user agent wins",
+			opts:   []ClientOption{WithUserAgent("order-api", "v1.4.2")},
+			header: map[string]string{"User-Agent": "curl/8"},
+			want:   map[string]string{"User-Agent": "curl/8"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(tt.opts...)
+			if _, err := client.Get(context.Background(), srv.URL, tt.header); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			for k, v := range tt.want {
+				if got.Get(k) != v {
+					t.Errorf("%s = %q, want %q", k, got.Get(k), v)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultUserAgent(t *testing.T) {
+	ua := defaultUserAgent()
+	if !strings.Contains(ua, "golib") || !strings.HasSuffix(ua, runtime.Version()) {
+		t.Fatalf("default User-Agent = %q", ua)
+	}
+}
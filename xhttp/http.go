@@ -80,6 +80,11 @@ type Client struct {
 	client     *http.Client
 	logHandler func(log *RequestResponseLog)
 	logger     Logger
+	validators []ResponseValidator
+	headers    http.Header
+	userAgent  string
+	retry      *RetryPolicy // 见 WithRetry
+	redaction  *logRedactor // 见 WithLogRedaction
 }
 
 // NewClient 创建新的HTTP客户端
@@ -120,19 +125,51 @@ func (c *Client) Delete(ctx context.Context, url string, header map[string]strin
 	return c.Do(ctx, http.MethodDelete, url, header, nil)
 }
 
-// Do 执行HTTP请求
+// Do 执行HTTP请求，设置了重试策略时按策略重试（见 WithRetry）
 func (c *Client) Do(ctx context.Context, method string, url string, header map[string]string, body []byte) (*http.Response, error) {
-	var req *http.Request
-	var err error
+	return c.doWithRetry(ctx, method, url, func() (*http.Response, error) {
+		return c.do(ctx, method, url, header, body)
+	})
+}
+
+// do 执行一次HTTP请求
+func (c *Client) do(ctx context.Context, method string, url string, header map[string]string, body []byte) (*http.Response, error) {
+	return c.send(ctx, c.bytesRequest(method, url, header, body))
+}
 
+// request 一次请求的参数
+type request struct {
+	method  string
+	url     string
+	header  map[string]string
+	body    io.Reader // 可以是流式的
+	size    int64     // 大于0时作为 Content-Length，否则流式请求体使用分块传输
+	logBody string    // 日志中记录的请求体，已脱敏
+	// stream 不读取响应体，调用方关闭 resp.Body 时才结束链路和记录日志，见 DoStream
+	stream bool
+}
+
+func (c *Client) bytesRequest(method, url string, header map[string]string, body []byte) request {
+	r := request{method: method, url: url, header: header, logBody: c.redaction.body(body)}
 	if len(body) > 0 {
-		req, err = http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
-	} else {
-		req, err = http.NewRequestWithContext(ctx, method, url, nil)
+		r.body = bytes.NewReader(body)
 	}
+	return r
+}
+
+// send 执行一次HTTP请求
+func (c *Client) send(ctx context.Context, r request) (*http.Response, error) {
+	method, url, header := r.method, r.url, r.header
+	req, err := http.NewRequestWithContext(ctx, method, url, r.body)
 	if err != nil {
+		if closer, ok := r.body.(io.Closer); ok {
+			closer.Close()
+		}
 		return nil, fmt.Errorf("create request failed: %w", err)
 	}
+	if r.size > 0 {
+		req.ContentLength = r.size
+	}
 
 	// 添加链路追踪
 	tracer := trace.TracerFromContext(req.Context())
@@ -151,9 +188,9 @@ func (c *Client) Do(ctx context.Context, method string, url string, header map[s
 		attribute.String("http.host", req.URL.Host),
 		attribute.String("http.path", req.URL.Path),
 	)
-	defer span.End()
 
 	req = req.WithContext(ctx)
+	c.applyDefaultHeaders(req)
 	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	// Extract APP-META from context and set to header
@@ -176,37 +213,54 @@ func (c *Client) Do(ctx context.Context, method string, url string, header map[s
 		}
 	}
 
+	// 透传入站请求头（见 PropagateHeaders），不覆盖上面已设置的值
+	injectPropagatedHeaders(ctx, req)
+
 	// 设置请求头
 	for k, v := range header {
 		req.Header.Set(k, v)
 	}
 
-	// 记录请求信息
+	// 记录请求信息，敏感字段按 redact 全局规则和 WithLogRedaction 脱敏
 	log := &RequestResponseLog{
 		URL:     url,
 		Method:  method,
-		Headers: header,
-		Request: string(body),
+		Headers: c.redaction.headers(c.logHeaders(header)),
+		Request: r.logBody,
 		CTime:   time.Now().UnixMilli(),
 	}
 
 	// 读取响应体并记录日志
 	var (
 		respBody []byte
+		respLog  string // 日志中记录的响应体，已脱敏
 		resp     *http.Response
+		streamed *streamBody // 流式响应体，关闭时记录日志
 	)
 
 	start := time.Now()
-	defer func() {
+	finish := func() {
+		defer span.End()
 		if resp != nil {
 			// 记录响应信息
 			log.Status = resp.StatusCode
-			log.Response = string(respBody)
+			log.Response = respLog
 		} else {
 			log.Status = int(http.StatusRequestTimeout)
 		}
+		if streamed != nil {
+			log.Response = fmt.Sprintf("<stream: %d bytes>", streamed.n)
+			span.SetAttributes(attribute.Int64("http.response_content_length", streamed.n))
+			if streamed.err != nil {
+				err = streamed.err
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+		}
 
-		log.TimeCost = time.Since(start).Milliseconds()
+		elapsed := time.Since(start)
+		log.TimeCost = elapsed.Milliseconds()
+		observeDuration(ctx, method, req.URL.Host, resp, elapsed)
 		if err != nil {
 			if log.Extend == nil {
 				log.Extend = &LogExtend{}
@@ -229,6 +283,11 @@ func (c *Client) Do(ctx context.Context, method string, url string, header map[s
 				c.logHandler(log)
 			}()
 		}
+	}
+	defer func() {
+		if streamed == nil {
+			finish()
+		}
 	}()
 
 	// 执行请求
@@ -243,29 +302,63 @@ func (c *Client) Do(ctx context.Context, method string, url string, header map[s
 	span.SetAttributes(semconv.HTTPAttributesFromHTTPStatusCode(resp.StatusCode)...)
 	span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(resp.StatusCode, oteltrace.SpanKindClient))
 
-	// 读取响应体
-	respBody, err = io.ReadAll(resp.Body)
+	headersJSON, _ := json.Marshal(c.redaction.httpHeader(req.Header))
+	if r.stream && resp.StatusCode < 400 {
+		// 响应体交给调用方读取，关闭时记录读取的字节数
+		c.logger.Infof(
+			"url: %s, method: %s, header: %s, request: %s, response: <stream, status %d>",
+			req.URL.String(),
+			req.Method,
+			string(headersJSON),
+			r.logBody,
+			resp.StatusCode,
+		)
+		streamed = &streamBody{ReadCloser: resp.Body, finish: finish}
+		resp.Body = streamed
+		return resp, nil
+	}
+
+	// 读取响应体，流式请求的错误响应只读取前 maxStreamErrorBody 字节
+	reader := io.Reader(resp.Body)
+	if r.stream {
+		reader = io.LimitReader(resp.Body, maxStreamErrorBody)
+	}
+	respBody, err = io.ReadAll(reader)
+	// 关闭响应体
+	resp.Body.Close()
 	if err != nil {
-		// 关闭响应体
-		resp.Body.Close()
 		return nil, fmt.Errorf("read response body failed: %w", err)
 	}
 
 	// 重新设置响应体，因为已经被读取
 	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	respLog = c.redaction.body(respBody)
 
-	headersJSON, _ := json.Marshal(req.Header)
 	c.logger.Infof(
 		"url: %s, method: %s, header: %s, request: %s, response: %s",
 		req.URL.String(),
 		req.Method,
 		string(headersJSON),
-		string(body),
-		string(respBody),
+		r.logBody,
+		respLog,
 	)
 
 	if resp.StatusCode >= 400 {
-		err = fmt.Errorf("http status %d", resp.StatusCode)
+		err = &HTTPError{
+			StatusCode: resp.StatusCode,
+			Method:     method,
+			URL:        url,
+			Headers:    resp.Header.Clone(),
+			Body:       respBody,
+		}
+	} else if len(c.validators) > 0 {
+		// 合作方接口契约校验，失败时记录到链路上，避免下游出现空指针
+		if err = c.validateResponse(method, url, resp, respBody); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.SetAttributes(attribute.Bool("http.response.invalid", true))
+			c.logger.Errorf("response validation failed: %v", err)
+		}
 	}
 
 	return resp, err
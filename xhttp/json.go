@@ -0,0 +1,82 @@
+package xhttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Doer 发送请求，Client 和 ServiceClient 都实现了该接口
+type Doer interface {
+	Do(ctx context.Context, method, url string, header map[string]string, body []byte) (*http.Response, error)
+}
+
+var (
+	_ Doer = (*Client)(nil)
+	_ Doer = (*ServiceClient)(nil)
+)
+
+// GetJSON 发送GET请求并把 JSON 响应解析为 T，
+// 非2xx响应返回 HTTPError，可用 DecodeBody 解析错误响应体
+func GetJSON[T any](ctx context.Context, c Doer, url string, header map[string]string) (T, error) {
+	return DoJSON[any, T](ctx, c, http.MethodGet, url, header, nil)
+}
+
+// PostJSON 把 req 编码为 JSON 发送POST请求，并把响应解析为 Resp
+func PostJSON[Req, Resp any](ctx context.Context, c Doer, url string, header map[string]string, req Req) (Resp, error) {
+	return DoJSON[Req, Resp](ctx, c, http.MethodPost, url, header, &req)
+}
+
+// PutJSON 把 req 编码为 JSON 发送PUT请求，并把响应解析为 Resp
+func PutJSON[Req, Resp any](ctx context.Context, c Doer, url string, header map[string]string, req Req) (Resp, error) {
+	return DoJSON[Req, Resp](ctx, c, http.MethodPut, url, header, &req)
+}
+
+// DoJSON 发送 JSON 请求，req 为 nil 时不带请求体。header 中的 Content-Type / Accept
+// 优先于默认的 application/json。响应体为空（如 204）时返回 Resp 的零值
+func DoJSON[Req, Resp any](ctx context.Context, c Doer, method, url string, header map[string]string, req *Req) (Resp, error) {
+	var out Resp
+
+	h := map[string]string{"Accept": "application/json"}
+	var body []byte
+	if req != nil {
+		var err error
+		if body, err = json.Marshal(req); err != nil {
+			return out, fmt.Errorf("encode request of %s %s: %w", method, url, err)
+		}
+		h["Content-Type"] = "application/json"
+	}
+	for k, v := range header {
+		h[http.CanonicalHeaderKey(k)] = v
+	}
+
+	resp, err := c.Do(ctx, method, url, h, body)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return out, fmt.Errorf("read response of %s %s: %w", method, url, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Do 只把 >= 400 视为错误，这里 3xx 等也按错误返回
+		return out, &HTTPError{
+			StatusCode: resp.StatusCode,
+			Method:     method,
+			URL:        url,
+			Headers:    resp.Header.Clone(),
+			Body:       data,
+		}
+	}
+	if len(data) == 0 {
+		return out, nil
+	}
+	if err = json.Unmarshal(data, &out); err != nil {
+		return out, fmt.Errorf("decode response of %s %s: %w", method, url, err)
+	}
+	return out, nil
+}
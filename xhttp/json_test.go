@@ -0,0 +1,106 @@
+package xhttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type createOrder struct {
+	SKU string `json:"sku"`
+	Qty int    `json:"qty"`
+}
+
+type order struct {
+	ID  string `json:"id"`
+	SKU string `json:"sku"`
+	Qty int    `json:"qty"`
+}
+
+func newOrderServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/orders/1":
+			w.Write([]byte(`{"id":"1","sku":"apple","qty":2}`))
+		case "/orders":
+			if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Accept") != "application/json" {
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+			var req createOrder
+			body, _ := io.ReadAll(r.Body)
+			if err := json.Unmarshal(body, &req); err != nil || req.SKU == "" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":"invalid_sku","message":"sku is required"}`))
+				return
+			}
+			json.NewEncoder(w).Encode(order{ID: "2", SKU: req.SKU, Qty: req.Qty})
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		case "/moved":
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Write([]byte(`not json`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGetJSON(t *testing.T) {
+	srv := newOrderServer(t)
+	c := NewClient(WithLogger(nopLogger{}))
+	ctx := context.Background()
+
+	got, err := GetJSON[order](ctx, c, srv.URL+"/orders/1", nil)
+	if err != nil || got != (order{ID: "1", SKU: "apple", Qty: 2}) {
+		t.Fatalf("GetJSON() = %+v, %v", got, err)
+	}
+
+	if got, err := GetJSON[*order](ctx, c, srv.URL+"/empty", nil); err != nil || got != nil {
+		t.Errorf("GetJSON() on 204 = %+v, %v", got, err)
+	}
+	if _, err := GetJSON[order](ctx, c, srv.URL+"/moved", nil); !IsStatus(err, http.StatusNotModified) {
+		t.Errorf("GetJSON() on 304 error = %v", err)
+	}
+	if _, err := GetJSON[order](ctx, c, srv.URL+"/text", nil); err == nil || !strings.Contains(err.Error(), "decode response") {
+		t.Errorf("GetJSON() on text error = %v", err)
+	}
+}
+
+func TestPostJSON(t *testing.T) {
+	srv := newOrderServer(t)
+	ctx := context.Background()
+
+	// ServiceClient 使用相对路径
+	sc, err := NewServiceClient(srv.URL, WithClientOptions(WithLogger(nopLogger{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := PostJSON[createOrder, order](ctx, sc, "/orders", nil, createOrder{SKU: "pear", Qty: 3})
+	if err != nil || got != (order{ID: "2", SKU: "pear", Qty: 3}) {
+		t.Fatalf("PostJSON() = %+v, %v", got, err)
+	}
+
+	_, err = PostJSON[createOrder, order](ctx, sc, "/orders", nil, createOrder{})
+	he, ok := AsHTTPError(err)
+	if !ok || he.StatusCode != http.StatusBadRequest {
+		t.Fatalf("PostJSON() error = %v, want 400", err)
+	}
+	var apiErr struct {
+		Code string `json:"code"`
+	}
+	if err := he.DecodeBody(&apiErr); err != nil || apiErr.Code != "invalid_sku" {
+		t.Errorf("DecodeBody() = %+v, %v", apiErr, err)
+	}
+
+	// 调用方的请求头优先
+	_, err = PostJSON[createOrder, order](ctx, sc, "/orders", map[string]string{"content-type": "text/plain"}, createOrder{SKU: "pear"})
+	if !IsStatus(err, http.StatusUnsupportedMediaType) {
+		t.Errorf("PostJSON() with content-type override error = %v", err)
+	}
+}
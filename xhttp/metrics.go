@@ -0,0 +1,31 @@
+package xhttp
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/metric"
+
+	"gomod.pri/golib/xtrace"
+)
+
+// clientDuration 第三方接口耗时，附带 trace_id exemplar，便于从延迟毛刺跳转到链路
+var clientDuration = xtrace.NewHistogramVec(&metric.HistogramVecOpts{
+	Namespace: "xhttp",
+	Subsystem: "client",
+	Name:      "duration_ms",
+	Help:      "Outgoing http request latency in milliseconds, partitioned by method, host and status code.",
+	Labels:    []string{"method", "host", "code"},
+	Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+})
+
+// observeDuration 记录请求耗时，未得到响应时 code 为 error
+func observeDuration(ctx context.Context, method, host string, resp *http.Response, d time.Duration) {
+	code := "error"
+	if resp != nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	clientDuration.Observe(ctx, float64(d.Milliseconds()), method, host, code)
+}
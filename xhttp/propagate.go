@@ -0,0 +1,69 @@
+package xhttp
+
+import (
+	"context"
+	"net/http"
+)
+
+// DefaultPropagatedHeaders 默认透传的入站请求头：请求 ID、应用 ID 和语言
+var DefaultPropagatedHeaders = []string{"X-Request-Id", "APP-ID", "Accept-Language"}
+
+type propagatedHeadersKey struct{}
+
+// PropagateHeaders 返回 go-zero 中间件，把入站请求中 headers（为空时使用 DefaultPropagatedHeaders）
+// 保存到请求 context，之后用该 context 发出的 Client 请求会自动带上这些请求头。
+// W3C traceparent 和 baggage 由 otel propagator 透传，不需要配置在这里
+func PropagateHeaders(headers ...string) func(next http.HandlerFunc) http.HandlerFunc {
+	if len(headers) == 0 {
+		headers = DefaultPropagatedHeaders
+	}
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			h := make(http.Header)
+			for _, name := range headers {
+				if values := r.Header.Values(name); len(values) > 0 {
+					h[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+				}
+			}
+			if len(h) > 0 {
+				r = r.WithContext(ContextWithHeaders(r.Context(), h))
+			}
+			next(w, r)
+		}
+	}
+}
+
+// ContextWithHeaders 返回携带透传请求头的 context，与 ctx 中已有的合并，同名时 h 优先。
+// 用于非 HTTP 入口（如 MQ 消费）手动设置需要透传的请求头
+func ContextWithHeaders(ctx context.Context, h http.Header) context.Context {
+	merged := HeadersFromContext(ctx)
+	if merged == nil {
+		merged = make(http.Header, len(h))
+	}
+	for k, v := range h {
+		merged[http.CanonicalHeaderKey(k)] = append([]string(nil), v...)
+	}
+	return context.WithValue(ctx, propagatedHeadersKey{}, merged)
+}
+
+// HeadersFromContext 返回 ctx 中透传请求头的副本，没有时返回 nil
+func HeadersFromContext(ctx context.Context) http.Header {
+	h, ok := ctx.Value(propagatedHeadersKey{}).(http.Header)
+	if !ok {
+		return nil
+	}
+	return h.Clone()
+}
+
+// injectPropagatedHeaders 把 ctx 中的透传请求头写入出站请求，已设置的请求头不覆盖
+func injectPropagatedHeaders(ctx context.Context, req *http.Request) {
+	h, ok := ctx.Value(propagatedHeadersKey{}).(http.Header)
+	if !ok {
+		return
+	}
+	for k, v := range h {
+		if _, exists := req.Header[k]; !exists {
+			req.Header[k] = append([]string(nil), v...)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package xhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPropagateHeaders(t *testing.T) {
+	var got http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer downstream.Close()
+
+	client := NewClient()
+	handler := PropagateHeaders()(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := client.Get(r.Context(), downstream.URL, map[string]string{"Accept-Language": "en-US"}); err != nil {
+			t.Errorf("Get() error = %v", err)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-Id", "req-1")
+	req.Header.Set("APP-ID", "app-1")
+	req.Header.Set("Accept-Language", "zh-CN")
+	req.Header.Set("Authorization", "Bearer secret")
+	handler(httptest.NewRecorder(), req)
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "X-Request-Id", want: "req-1"},
+		{header: "APP-ID", want: "app-1"},
+		{header: "Accept-Language", want: "en-US"}, // explicit headers win
+		{header: "Authorization", want: ""},        // not configured
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if v := got.Get(tt.header); v != tt.want {
+				t.Errorf("%s = %q, want %q", tt.header, v, tt.want)
+			}
+		})
+	}
+}
+
+func TestContextWithHeaders(t *testing.T) {
+	ctx := ContextWithHeaders(context.Background(), http.Header{"x-request-id": {"a"}, "X-Locale": {"th"}})
+	ctx = ContextWithHeaders(ctx, http.Header{"X-Request-Id": {"b"}})
+
+	h := HeadersFromContext(ctx)
+	if h.Get("X-Request-Id") != "b" || h.Get("X-Locale") != "th" {
+		t.Fatalf("HeadersFromContext() = %v", h)
+	}
+	h.Set("X-Locale", "changed")
+	if HeadersFromContext(ctx).Get("X-Locale") != "th" {
+		t.Fatal("HeadersFromContext() returned a shared header")
+	}
+	if HeadersFromContext(context.Background()) != nil {
+		t.Fatal("empty context has headers")
+	}
+}
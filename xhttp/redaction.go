@@ -0,0 +1,121 @@
+package xhttp
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"gomod.pri/golib/xutils/redact"
+)
+
+// LogRedaction 请求日志的脱敏规则，在写日志和调用 logHandler 之前生效，
+// 在 redact 全局规则（见 redact.SetRules）的基础上叠加
+type LogRedaction struct {
+	// Headers 日志记录的请求头白名单，为空时记录全部请求头。白名单内的敏感请求头仍会脱敏
+	Headers []string
+	// MaskHeaders 额外脱敏的请求头
+	MaskHeaders []string
+	// Fields 额外脱敏的 JSON 字段和表单字段，如合作方接口的证件号、手机号
+	Fields []string
+	// MaxBodySize 日志中请求体和响应体的最大字节数，超出部分截断，0 不限制
+	MaxBodySize int
+}
+
+// WithLogRedaction 设置请求日志的脱敏规则
+func WithLogRedaction(r LogRedaction) ClientOption {
+	return func(c *Client) {
+		c.redaction = newLogRedactor(r)
+	}
+}
+
+// logRedactor 按客户端的 LogRedaction 脱敏日志，nil 时只使用全局规则
+type logRedactor struct {
+	cfg   LogRedaction
+	allow map[string]struct{} // 规范化后的请求头白名单
+	cache atomic.Pointer[mergedRedactor]
+}
+
+// mergedRedactor 全局规则和客户端规则合并后的 Redactor，全局规则变化时重建
+type mergedRedactor struct {
+	base   *redact.Redactor
+	merged *redact.Redactor
+}
+
+func newLogRedactor(cfg LogRedaction) *logRedactor {
+	r := &logRedactor{cfg: cfg}
+	if len(cfg.Headers) > 0 {
+		r.allow = make(map[string]struct{}, len(cfg.Headers))
+		for _, h := range cfg.Headers {
+			r.allow[http.CanonicalHeaderKey(h)] = struct{}{}
+		}
+	}
+	return r
+}
+
+func (r *logRedactor) redactor() *redact.Redactor {
+	base := redact.Default()
+	if r == nil || len(r.cfg.Fields) == 0 && len(r.cfg.MaskHeaders) == 0 {
+		return base
+	}
+	if m := r.cache.Load(); m != nil && m.base == base {
+		return m.merged
+	}
+	rules := base.Rules()
+	rules.Fields = append(slices.Clone(rules.Fields), r.cfg.Fields...)
+	rules.Headers = append(slices.Clone(rules.Headers), r.cfg.MaskHeaders...)
+	merged := redact.New(rules)
+	r.cache.Store(&mergedRedactor{base: base, merged: merged})
+	return merged
+}
+
+// body 返回脱敏并截断后的请求体或响应体
+func (r *logRedactor) body(data []byte) string {
+	return r.truncate(string(r.redactor().JSON(data)))
+}
+
+// text 返回脱敏并截断后的文本，如 multipart 请求的概要
+func (r *logRedactor) text(s string) string {
+	return r.truncate(r.redactor().Text(s))
+}
+
+// headers 返回白名单内的请求头，敏感值已脱敏
+func (r *logRedactor) headers(h map[string]string) map[string]string {
+	if r != nil && r.allow != nil && h != nil {
+		allowed := make(map[string]string, len(r.allow))
+		for k, v := range h {
+			if _, ok := r.allow[http.CanonicalHeaderKey(k)]; ok {
+				allowed[k] = v
+			}
+		}
+		h = allowed
+	}
+	return r.redactor().Headers(h)
+}
+
+// httpHeader 同 headers
+func (r *logRedactor) httpHeader(h http.Header) http.Header {
+	if r != nil && r.allow != nil && h != nil {
+		allowed := make(http.Header, len(r.allow))
+		for k, v := range h {
+			if _, ok := r.allow[http.CanonicalHeaderKey(k)]; ok {
+				allowed[k] = v
+			}
+		}
+		h = allowed
+	}
+	return r.redactor().HTTPHeader(h)
+}
+
+// truncate 按 MaxBodySize 截断，不截断多字节字符
+func (r *logRedactor) truncate(s string) string {
+	if r == nil || r.cfg.MaxBodySize <= 0 || len(s) <= r.cfg.MaxBodySize {
+		return s
+	}
+	cut := r.cfg.MaxBodySize
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...(truncated, %d bytes)", s[:cut], len(s))
+}
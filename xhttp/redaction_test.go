@@ -0,0 +1,79 @@
+package xhttp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gomod.pri/golib/xutils/redact"
+)
+
+// capturingLogger 记录请求日志行
+type capturingLogger struct {
+	lines chan string
+}
+
+func (l capturingLogger) Infof(format string, v ...any) {
+	if strings.HasPrefix(format, "url:") {
+		l.lines <- fmt.Sprintf(format, v...)
+	}
+}
+
+func (capturingLogger) Errorf(string, ...any) {}
+
+func TestClient_LogRedaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id_no":"110101199001011234","name":"` + strings.Repeat("张", 20) + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	logs := make(chan *RequestResponseLog, 1)
+	logger := capturingLogger{lines: make(chan string, 1)}
+	c := NewClient(
+		WithLogger(logger),
+		WithLogHandler(func(l *RequestResponseLog) { logs <- l }),
+		WithDefaultHeaders(map[string]string{"Authorization": "Bearer t0ken", "X-Partner-Sign": "s1gn"}),
+		WithLogRedaction(LogRedaction{
+			Headers:     []string{"authorization", "x-partner-sign", "x-request-id"},
+			MaskHeaders: []string{"X-Partner-Sign"},
+			Fields:      []string{"id_no"},
+			MaxBodySize: 40,
+		}),
+	)
+
+	_, err := c.Post(context.Background(), srv.URL, map[string]string{"X-Request-Id": "r1", "X-Debug": "1"},
+		[]byte(`{"idNo":"110101199001011234","password":"p"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := <-logs
+	wantHeaders := map[string]string{"Authorization": redact.DefaultMask, "X-Partner-Sign": redact.DefaultMask, "X-Request-Id": "r1"}
+	if len(l.Headers) != len(wantHeaders) {
+		t.Errorf("logged headers = %v, want %v", l.Headers, wantHeaders)
+	}
+	for k, v := range wantHeaders {
+		if l.Headers[k] != v {
+			t.Errorf("logged header %s = %q, want %q", k, l.Headers[k], v)
+		}
+	}
+	if want := `{"idNo":"******","password":"******"}`; l.Request != want {
+		t.Errorf("logged request = %s, want %s", l.Request, want)
+	}
+	if !strings.HasPrefix(l.Response, `{"id_no":"******","name":"张`) || !strings.Contains(l.Response, "...(truncated, ") {
+		t.Errorf("logged response = %s, want redacted and truncated", l.Response)
+	}
+	if strings.Contains(l.Response, "�") {
+		t.Errorf("logged response cut a multi-byte character: %s", l.Response)
+	}
+
+	line := <-logger.lines
+	for _, leaked := range []string{"t0ken", "s1gn", "X-Debug", "110101199001011234"} {
+		if strings.Contains(line, leaked) {
+			t.Errorf("log line leaks %q: %s", leaked, line)
+		}
+	}
+}
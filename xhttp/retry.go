@@ -0,0 +1,145 @@
+package xhttp
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryBackoff       = 100 * time.Millisecond
+	defaultRetryMaxBackoff    = 2 * time.Second
+	defaultRetryMaxRetryAfter = 10 * time.Second
+)
+
+// RetryPolicy 请求重试策略，重试连接失败、超时、429 和 5xx，
+// 响应带 Retry-After 时至少等待该时间
+type RetryPolicy struct {
+	MaxAttempts int           // 最多请求次数（含首次），<= 1 不重试
+	Backoff     time.Duration // 首次重试前的等待，之后每次翻倍并加随机抖动，默认100毫秒
+	MaxBackoff  time.Duration // 两次请求间的最大等待，默认2秒
+	// MaxRetryAfter Retry-After 超过该值时不再重试，直接返回响应，默认10秒
+	MaxRetryAfter time.Duration
+	// RetryNonIdempotent 是否重试 POST/PATCH，默认只重试幂等方法，避免重复提交
+	RetryNonIdempotent bool
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.Backoff <= 0 {
+		p.Backoff = defaultRetryBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = defaultRetryMaxBackoff
+	}
+	if p.MaxRetryAfter <= 0 {
+		p.MaxRetryAfter = defaultRetryMaxRetryAfter
+	}
+	return p
+}
+
+// WithRetry 设置客户端的默认重试策略，单次请求可用 ContextWithRetry 覆盖
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		p := policy.withDefaults()
+		c.retry = &p
+	}
+}
+
+type retryPolicyKey struct{}
+
+// ContextWithRetry 返回覆盖客户端重试策略的 context，
+// 如 ContextWithRetry(ctx, RetryPolicy{}) 关闭本次请求的重试
+func ContextWithRetry(ctx context.Context, policy RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy.withDefaults())
+}
+
+// retryPolicy 返回本次请求的重试策略，没有时返回 nil
+func (c *Client) retryPolicy(ctx context.Context) *RetryPolicy {
+	if p, ok := ctx.Value(retryPolicyKey{}).(RetryPolicy); ok {
+		return &p
+	}
+	return c.retry
+}
+
+// doWithRetry 按本次请求的重试策略执行 send，返回最后一次请求的结果
+func (c *Client) doWithRetry(ctx context.Context, method, url string, send func() (*http.Response, error)) (*http.Response, error) {
+	policy := c.retryPolicy(ctx)
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return send()
+	}
+	for attempt := 1; ; attempt++ {
+		resp, err := send()
+		if err == nil || attempt >= policy.MaxAttempts || !policy.retryable(ctx, method, err) {
+			return resp, err
+		}
+		wait, ok := policy.wait(attempt, err)
+		if !ok {
+			return resp, err
+		}
+
+		c.logger.Errorf("%s %s attempt %d failed, retry in %s: %v", method, url, attempt, wait, err)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return resp, err
+		}
+	}
+}
+
+// retryable 判断请求错误是否可以重试
+func (p *RetryPolicy) retryable(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil || !p.RetryNonIdempotent && !isIdempotent(method) {
+		return false
+	}
+	if he, ok := AsHTTPError(err); ok {
+		return he.Temporary()
+	}
+	// 响应校验失败重试也不会改变结果
+	return !errors.Is(err, ErrInvalidResponse)
+}
+
+// wait 返回第 attempt 次请求失败后的等待时间，Retry-After 超过上限时返回 false
+func (p *RetryPolicy) wait(attempt int, err error) (time.Duration, bool) {
+	backoff := p.Backoff
+	for i := 1; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	backoff = min(backoff, p.MaxBackoff)
+	// 抖动：在 [backoff/2, backoff] 内随机，避免多个客户端同时重试
+	backoff = backoff/2 + rand.N(backoff/2+1)
+
+	if he, ok := AsHTTPError(err); ok {
+		if after, ok := retryAfter(he.Headers.Get("Retry-After"), time.Now()); ok {
+			if after > p.MaxRetryAfter {
+				return 0, false
+			}
+			backoff = max(backoff, after)
+		}
+	}
+	return backoff, true
+}
+
+// retryAfter 解析 Retry-After，支持秒数和 HTTP 日期两种格式
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// isIdempotent 判断方法是否幂等，重复请求不会产生副作用
+func isIdempotent(method string) bool {
+	return method != http.MethodPost && method != http.MethodPatch
+}
@@ -0,0 +1,150 @@
+package xhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer 前 failures 次请求返回 status，之后返回 200
+func flakyServer(t *testing.T, status int, failures int32, header http.Header) (*httptest.Server, *atomic.Int32) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestClient_Retry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+	tests := []struct {
+		name     string
+		method   string
+		status   int
+		failures int32
+		header   http.Header
+		policy   RetryPolicy
+		wantHits int32
+		wantErr  bool
+	}{
+		{name: "503 recovers", method: http.MethodGet, status: http.StatusServiceUnavailable, failures: 2, policy: policy, wantHits: 3},
+		{name: "429 recovers", method: http.MethodPut, status: http.StatusTooManyRequests, failures: 1, policy: policy, wantHits: 2},
+		{name: "gives up after max attempts", method: http.MethodGet, status: http.StatusBadGateway, failures: 5, policy: policy, wantHits: 3, wantErr: true},
+		{name: "4xx is not retried", method: http.MethodGet, status: http.StatusNotFound, failures: 1, policy: policy, wantHits: 1, wantErr: true},
+		{name: "post is not retried", method: http.MethodPost, status: http.StatusServiceUnavailable, failures: 1, policy: policy, wantHits: 1, wantErr: true},
+		{name: "post opted in", method: http.MethodPost, status: http.StatusServiceUnavailable, failures: 1,
+			policy: RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond, RetryNonIdempotent: true}, wantHits: 2},
+		{name: "retry-after too long", method: http.MethodGet, status: http.StatusServiceUnavailable, failures: 1,
+			header: http.Header{"Retry-After": {"120"}}, policy: policy, wantHits: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, hits := flakyServer(t, tt.status, tt.failures, tt.header)
+			c := NewClient(WithLogger(nopLogger{}), WithRetry(tt.policy))
+
+			resp, err := c.Do(context.Background(), tt.method, srv.URL, nil, []byte(`{"a":1}`))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && resp.StatusCode != http.StatusOK {
+				t.Errorf("status = %d", resp.StatusCode)
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+func TestClient_RetryConnectionError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	logger := &countingLogger{}
+	c := NewClient(WithLogger(logger), WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}))
+	if _, err := c.Get(context.Background(), srv.URL, nil); err == nil {
+		t.Fatal("Get() on a closed server succeeded")
+	}
+	if n := logger.errors.Load(); n != 1 {
+		t.Errorf("logged %d retries, want 1", n)
+	}
+}
+
+type countingLogger struct {
+	errors atomic.Int32
+}
+
+func (*countingLogger) Infof(string, ...any) {}
+
+func (l *countingLogger) Errorf(string, ...any) { l.errors.Add(1) }
+
+func TestClient_RetryPerRequest(t *testing.T) {
+	srv, hits := flakyServer(t, http.StatusServiceUnavailable, 1, nil)
+	c := NewClient(WithLogger(nopLogger{}), WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}))
+
+	ctx := ContextWithRetry(context.Background(), RetryPolicy{})
+	if _, err := c.Get(ctx, srv.URL, nil); !IsStatus(err, http.StatusServiceUnavailable) {
+		t.Fatalf("Get() with retry disabled error = %v", err)
+	}
+	if hits.Load() != 1 {
+		t.Fatalf("hits = %d, want 1", hits.Load())
+	}
+
+	// 未设置 WithRetry 的客户端也可按请求开启
+	hits.Store(0)
+	ctx = ContextWithRetry(context.Background(), RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond})
+	if _, err := NewClient(WithLogger(nopLogger{})).Get(ctx, srv.URL, nil); err != nil || hits.Load() != 2 {
+		t.Fatalf("Get() error = %v, hits = %d", err, hits.Load())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{value: "", wantOK: false},
+		{value: "3", want: 3 * time.Second, wantOK: true},
+		{value: now.Add(5 * time.Second).Format(http.TimeFormat), want: 5 * time.Second, wantOK: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, wantOK: true},
+		{value: "soon", wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := retryAfter(tt.value, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("retryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Wait(t *testing.T) {
+	p := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}.withDefaults()
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 5: 300 * time.Millisecond} {
+		got, ok := p.wait(attempt, nil)
+		if !ok || got < want/2 || got > want {
+			t.Errorf("wait(%d) = %s, want in [%s, %s]", attempt, got, want/2, want)
+		}
+	}
+
+	err := &HTTPError{StatusCode: http.StatusTooManyRequests, Headers: http.Header{"Retry-After": {"2"}}}
+	if got, ok := p.wait(1, err); !ok || got != 2*time.Second {
+		t.Errorf("wait() with Retry-After = %s, %v, want 2s", got, ok)
+	}
+}
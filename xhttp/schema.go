@@ -0,0 +1,201 @@
+package xhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// jsonSchema JSON Schema 的子集，未知关键字忽略
+type jsonSchema struct {
+	Type                 schemaTypes            `json:"type"`
+	Properties           map[string]*jsonSchema `json:"properties"`
+	Required             []string               `json:"required"`
+	AdditionalProperties *bool                  `json:"additionalProperties"`
+	Items                *jsonSchema            `json:"items"`
+	Enum                 []any                  `json:"enum"`
+	Minimum              *float64               `json:"minimum"`
+	Maximum              *float64               `json:"maximum"`
+	MinLength            *int                   `json:"minLength"`
+	MaxLength            *int                   `json:"maxLength"`
+	Pattern              string                 `json:"pattern"`
+	MinItems             *int                   `json:"minItems"`
+	MaxItems             *int                   `json:"maxItems"`
+
+	pattern *regexp.Regexp
+}
+
+// schemaTypes 兼容 "type": "string" 和 "type": ["string", "null"]
+type schemaTypes []string
+
+func (t *schemaTypes) UnmarshalJSON(data []byte) error {
+	var one string
+	if err := json.Unmarshal(data, &one); err == nil {
+		*t = schemaTypes{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("invalid schema type: %s", data)
+	}
+	*t = many
+	return nil
+}
+
+func parseJSONSchema(data []byte) (*jsonSchema, error) {
+	var s jsonSchema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("xhttp: parse json schema: %w", err)
+	}
+	if err := s.compile(); err != nil {
+		return nil, fmt.Errorf("xhttp: compile json schema: %w", err)
+	}
+	return &s, nil
+}
+
+func (s *jsonSchema) compile() error {
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return err
+		}
+		s.pattern = re
+	}
+	for _, p := range s.Properties {
+		if err := p.compile(); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.compile()
+	}
+	return nil
+}
+
+// validate 校验 v，path 为 JSON Pointer 形式的位置
+func (s *jsonSchema) validate(v any, path string) error {
+	if len(s.Type) > 0 && !s.matchType(v) {
+		return schemaError(path, "expected %s, got %s", strings.Join(s.Type, " or "), jsonType(v))
+	}
+	if len(s.Enum) > 0 && !inEnum(v, s.Enum) {
+		return schemaError(path, "value %v not in enum", v)
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		return s.validateObject(val, path)
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			return schemaError(path, "expected at least %d items, got %d", *s.MinItems, len(val))
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			return schemaError(path, "expected at most %d items, got %d", *s.MaxItems, len(val))
+		}
+		if s.Items != nil {
+			for i, item := range val {
+				if err := s.Items.validate(item, fmt.Sprintf("%s/%d", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(val)
+		if s.MinLength != nil && n < *s.MinLength {
+			return schemaError(path, "expected length >= %d, got %d", *s.MinLength, n)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return schemaError(path, "expected length <= %d, got %d", *s.MaxLength, n)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(val) {
+			return schemaError(path, "%q does not match %s", val, s.Pattern)
+		}
+	case float64:
+		if s.Minimum != nil && val < *s.Minimum {
+			return schemaError(path, "%v is less than %v", val, *s.Minimum)
+		}
+		if s.Maximum != nil && val > *s.Maximum {
+			return schemaError(path, "%v is greater than %v", val, *s.Maximum)
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) validateObject(obj map[string]any, path string) error {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			return schemaError(path, "missing required property %q", name)
+		}
+	}
+	// 按属性名顺序校验，多个属性出错时每次返回同一个错误
+	for _, name := range slices.Sorted(maps.Keys(obj)) {
+		val := obj[name]
+		prop, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return schemaError(path, "unexpected property %q", name)
+			}
+			continue
+		}
+		if err := prop.validate(val, path+"/"+name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *jsonSchema) matchType(v any) bool {
+	actual := jsonType(v)
+	for _, t := range s.Type {
+		if t == actual {
+			return true
+		}
+		if t == "number" && actual == "integer" {
+			return true
+		}
+	}
+	return false
+}
+
+func jsonType(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if val == math.Trunc(val) {
+			return "integer"
+		}
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func inEnum(v any, enum []any) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(v, e) {
+			return true
+		}
+	}
+	return false
+}
+
+func schemaError(path, format string, args ...any) error {
+	if path == "" {
+		path = "/"
+	}
+	return errors.New(path + ": " + fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,279 @@
+package xhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultEndpointCooldown 地址失败后暂停使用的时间
+	defaultEndpointCooldown = 30 * time.Second
+	// healthCheckTimeout 单次健康检查的超时
+	healthCheckTimeout = 3 * time.Second
+)
+
+// ServiceOption 定义服务客户端配置选项
+type ServiceOption func(*ServiceClient)
+
+// WithBaseURLs 追加备用地址，主地址不可用时按顺序故障转移
+func WithBaseURLs(baseURLs ...string) ServiceOption {
+	return func(s *ServiceClient) {
+		s.rawURLs = append(s.rawURLs, baseURLs...)
+	}
+}
+
+// WithClientOptions 设置底层 Client 的选项
+func WithClientOptions(opts ...ClientOption) ServiceOption {
+	return func(s *ServiceClient) {
+		s.clientOpts = append(s.clientOpts, opts...)
+	}
+}
+
+// WithHealthCheck 每隔 interval 对所有地址请求 path，非 2xx 的地址暂停使用直到检查恢复
+func WithHealthCheck(path string, interval time.Duration) ServiceOption {
+	return func(s *ServiceClient) {
+		s.healthPath = path
+		s.healthInterval = interval
+	}
+}
+
+// WithEndpointCooldown 设置请求失败的地址暂停使用的时间，默认30秒
+func WithEndpointCooldown(cooldown time.Duration) ServiceOption {
+	return func(s *ServiceClient) {
+		s.cooldown = cooldown
+	}
+}
+
+// endpoint 一个网关地址
+type endpoint struct {
+	base      string
+	downUntil atomic.Int64 // 暂停使用到的 UnixNano，0 表示可用
+}
+
+func (e *endpoint) available(now time.Time) bool {
+	return now.UnixNano() >= e.downUntil.Load()
+}
+
+// ServiceClient 调用内部服务的客户端，Get/Post 等使用相对路径，
+// 服务发布了多个网关地址时，优先使用第一个可用地址，连接失败或网关错误时转移到下一个
+type ServiceClient struct {
+	client         *Client
+	endpoints      []*endpoint
+	rawURLs        []string
+	clientOpts     []ClientOption
+	cooldown       time.Duration
+	healthPath     string
+	healthInterval time.Duration
+	now            func() time.Time
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewServiceClient 创建服务客户端，baseURL 为主地址，备用地址见 WithBaseURLs。
+// 设置了 WithHealthCheck 时需要调用 Close 停止健康检查，首次检查在后台进行，完成前所有地址都可用
+func NewServiceClient(baseURL string, opts ...ServiceOption) (*ServiceClient, error) {
+	s := &ServiceClient{
+		rawURLs:  []string{baseURL},
+		cooldown: defaultEndpointCooldown,
+		now:      time.Now,
+		stop:     make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	for _, raw := range s.rawURLs {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base url %q: %w", raw, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("invalid base url %q: want http(s)://host[/prefix]", raw)
+		}
+		s.endpoints = append(s.endpoints, &endpoint{base: strings.TrimSuffix(raw, "/")})
+	}
+	s.client = NewClient(s.clientOpts...)
+
+	if s.healthPath != "" && s.healthInterval > 0 {
+		go s.healthLoop()
+	}
+	return s, nil
+}
+
+// Close 停止健康检查
+func (s *ServiceClient) Close() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+}
+
+// Get 发送GET请求，path 为相对路径，可带查询参数
+func (s *ServiceClient) Get(ctx context.Context, path string, header map[string]string) (*http.Response, error) {
+	return s.Do(ctx, http.MethodGet, path, header, nil)
+}
+
+// Post 发送POST请求
+func (s *ServiceClient) Post(ctx context.Context, path string, header map[string]string, body []byte) (*http.Response, error) {
+	return s.Do(ctx, http.MethodPost, path, header, body)
+}
+
+// Put 发送PUT请求
+func (s *ServiceClient) Put(ctx context.Context, path string, header map[string]string, body []byte) (*http.Response, error) {
+	return s.Do(ctx, http.MethodPut, path, header, body)
+}
+
+// Delete 发送DELETE请求
+func (s *ServiceClient) Delete(ctx context.Context, path string, header map[string]string) (*http.Response, error) {
+	return s.Do(ctx, http.MethodDelete, path, header, nil)
+}
+
+// Do 依次在可用地址上执行请求。幂等方法在连接失败、超时或 502/503/504 时转移，
+// POST/PATCH 只在确定未送达（建立连接失败或 503）时转移，避免重复提交。
+// 所有地址都失败时返回最后一个地址的结果
+func (s *ServiceClient) Do(ctx context.Context, method, path string, header map[string]string, body []byte) (*http.Response, error) {
+	candidates := s.candidates()
+	for i, ep := range candidates {
+		resp, err := s.client.Do(ctx, method, joinURL(ep.base, path), header, body)
+		if err == nil || !s.failover(ctx, method, err) {
+			return resp, err
+		}
+		ep.downUntil.Store(s.now().Add(s.cooldown).UnixNano())
+		if i == len(candidates)-1 {
+			return resp, err
+		}
+		s.client.logger.Errorf("endpoint %s unavailable, failover to %s: %v", ep.base, candidates[i+1].base, err)
+		if resp != nil {
+			resp.Body.Close()
+		}
+	}
+	return nil, errors.New("no endpoint")
+}
+
+// Client 返回底层客户端，用于请求完整 URL
+func (s *ServiceClient) Client() *Client {
+	return s.client
+}
+
+// candidates 返回本次请求依次尝试的地址：可用地址按配置顺序在前，
+// 暂停中的地址按恢复时间排在后面，全部不可用时仍会尝试
+func (s *ServiceClient) candidates() []*endpoint {
+	now := s.now()
+	list := make([]*endpoint, 0, len(s.endpoints))
+	var down []*endpoint
+	for _, ep := range s.endpoints {
+		if ep.available(now) {
+			list = append(list, ep)
+		} else {
+			down = append(down, ep)
+		}
+	}
+	for len(down) > 0 {
+		next := 0
+		for i, ep := range down {
+			if ep.downUntil.Load() < down[next].downUntil.Load() {
+				next = i
+			}
+		}
+		list = append(list, down[next])
+		down = append(down[:next], down[next+1:]...)
+	}
+	return list
+}
+
+// failover 判断请求错误后是否换下一个地址
+func (s *ServiceClient) failover(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	idempotent := isIdempotent(method)
+	if he, ok := AsHTTPError(err); ok {
+		switch he.StatusCode {
+		case http.StatusServiceUnavailable:
+			return true
+		case http.StatusBadGateway, http.StatusGatewayTimeout:
+			return idempotent
+		}
+		return false
+	}
+	// 响应校验失败、请求构造失败等不是地址的问题，换地址也不会成功
+	if !isTransportError(err) {
+		return false
+	}
+	if idempotent {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isTransportError 判断是否为连接失败、网络读写错误或超时
+func isTransportError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func (s *ServiceClient) healthLoop() {
+	s.checkHealth()
+	ticker := time.NewTicker(s.healthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.checkHealth()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// checkHealth 检查所有地址，健康检查不经过 Client，避免产生调用日志
+func (s *ServiceClient) checkHealth() {
+	for _, ep := range s.endpoints {
+		if s.probe(ep) {
+			ep.downUntil.Store(0)
+		} else {
+			// 暂停到下一次检查之后
+			ep.downUntil.Store(s.now().Add(s.healthInterval + s.cooldown).UnixNano())
+		}
+	}
+}
+
+func (s *ServiceClient) probe(ep *endpoint) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, joinURL(ep.base, s.healthPath), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := s.client.GetClient().Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// joinURL 拼接地址和相对路径，path 可带查询参数
+func joinURL(base, path string) string {
+	if path == "" {
+		return base
+	}
+	return base + "/" + strings.TrimPrefix(path, "/")
+}
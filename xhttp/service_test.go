@@ -0,0 +1,203 @@
+package xhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gateway 记录请求次数并返回固定状态码
+type gateway struct {
+	*httptest.Server
+	hits   atomic.Int32
+	status atomic.Int32
+	path   atomic.Value
+}
+
+func newGateway(t *testing.T, status int) *gateway {
+	g := &gateway{}
+	g.status.Store(int32(status))
+	g.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			w.WriteHeader(int(g.status.Load()))
+			return
+		}
+		g.hits.Add(1)
+		g.path.Store(r.URL.RequestURI())
+		w.WriteHeader(int(g.status.Load()))
+	}))
+	t.Cleanup(g.Close)
+	return g
+}
+
+func TestServiceClient_Failover(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name          string
+		primaryStatus int
+		primaryDown   bool
+		method        string
+		wantPrimary   int32
+		wantBackup    int32
+	}{
+		{name: "primary ok", primaryStatus: http.StatusOK, method: http.MethodGet, wantPrimary: 1},
+		{name: "503 fails over", primaryStatus: http.StatusServiceUnavailable, method: http.MethodPost, wantPrimary: 1, wantBackup: 1},
+		{name: "502 fails over get", primaryStatus: http.StatusBadGateway, method: http.MethodGet, wantPrimary: 1, wantBackup: 1},
+		{name: "502 keeps post", primaryStatus: http.StatusBadGateway, method: http.MethodPost, wantPrimary: 1},
+		{name: "500 is not a gateway error", primaryStatus: http.StatusInternalServerError, method: http.MethodGet, wantPrimary: 1},
+		{name: "connection refused fails over post", primaryDown: true, method: http.MethodPost, wantBackup: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, backup := newGateway(t, tt.primaryStatus), newGateway(t, http.StatusOK)
+			base := primary.URL
+			if tt.primaryDown {
+				base = closed.URL
+			}
+			s, err := NewServiceClient(base+"/", WithBaseURLs(backup.URL+"/api"), WithClientOptions(WithLogger(nopLogger{})))
+			if err != nil {
+				t.Fatalf("NewServiceClient() error = %v", err)
+			}
+
+			_, _ = s.Do(context.Background(), tt.method, "/orders?id=1", nil, []byte(`{}`))
+			if got := primary.hits.Load(); !tt.primaryDown && got != tt.wantPrimary {
+				t.Errorf("primary hits = %d, want %d", got, tt.wantPrimary)
+			}
+			if got := backup.hits.Load(); got != tt.wantBackup {
+				t.Errorf("backup hits = %d, want %d", got, tt.wantBackup)
+			}
+			if tt.wantBackup > 0 && backup.path.Load() != "/api/orders?id=1" {
+				t.Errorf("backup path = %v", backup.path.Load())
+			}
+		})
+	}
+}
+
+func TestServiceClient_Cooldown(t *testing.T) {
+	primary, backup := newGateway(t, http.StatusServiceUnavailable), newGateway(t, http.StatusOK)
+	s, err := NewServiceClient(primary.URL, WithBaseURLs(backup.URL), WithEndpointCooldown(time.Minute),
+		WithClientOptions(WithLogger(nopLogger{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	for range 3 {
+		if _, err := s.Get(context.Background(), "ping", nil); err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+	}
+	// 失败的地址在冷却期内不再被请求
+	if primary.hits.Load() != 1 || backup.hits.Load() != 3 {
+		t.Fatalf("hits = %d/%d during cooldown", primary.hits.Load(), backup.hits.Load())
+	}
+
+	primary.status.Store(http.StatusOK)
+	now = now.Add(time.Minute)
+	if _, err := s.Get(context.Background(), "ping", nil); err != nil || primary.hits.Load() != 2 {
+		t.Fatalf("primary not used after cooldown: err = %v, hits = %d", err, primary.hits.Load())
+	}
+}
+
+func TestServiceClient_HealthCheck(t *testing.T) {
+	primary, backup := newGateway(t, http.StatusServiceUnavailable), newGateway(t, http.StatusOK)
+	s, err := NewServiceClient(primary.URL, WithBaseURLs(backup.URL), WithHealthCheck("/healthz", 10*time.Millisecond),
+		WithClientOptions(WithLogger(nopLogger{})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// 首次检查在后台进行
+	deadline := time.Now().Add(time.Second)
+	for s.endpoints[0].available(time.Now()) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// 健康检查失败的地址不接收请求
+	if _, err := s.Get(context.Background(), "ping", nil); err != nil || primary.hits.Load() != 0 {
+		t.Fatalf("unhealthy primary used: err = %v, hits = %d", err, primary.hits.Load())
+	}
+
+	primary.status.Store(http.StatusOK)
+	deadline = time.Now().Add(time.Second)
+	for primary.hits.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		_, _ = s.Get(context.Background(), "ping", nil)
+	}
+	if primary.hits.Load() == 0 {
+		t.Fatal("primary not used after the health check recovered")
+	}
+}
+
+func TestServiceClient_NoFailoverOnClientErrors(t *testing.T) {
+	invalid := WithResponseValidator(func(*http.Response, []byte) error {
+		return errors.New("unexpected body")
+	})
+
+	tests := []struct {
+		name        string
+		opts        []ClientOption
+		path        string
+		wantPrimary int32
+	}{
+		{name: "invalid response", opts: []ClientOption{invalid}, path: "orders", wantPrimary: 2},
+		{name: "invalid request", path: "orders/%zz", wantPrimary: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, backup := newGateway(t, http.StatusOK), newGateway(t, http.StatusOK)
+			s, err := NewServiceClient(primary.URL, WithBaseURLs(backup.URL),
+				WithClientOptions(append(tt.opts, WithLogger(nopLogger{}))...))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for range 2 {
+				if _, err := s.Get(context.Background(), tt.path, nil); err == nil {
+					t.Fatal("Get() error = nil")
+				}
+			}
+			// 地址没有问题，不进入冷却
+			if primary.hits.Load() != tt.wantPrimary || backup.hits.Load() != 0 {
+				t.Errorf("hits = %d/%d, want %d/0", primary.hits.Load(), backup.hits.Load(), tt.wantPrimary)
+			}
+			if !s.endpoints[0].available(time.Now()) {
+				t.Error("primary put on cooldown")
+			}
+		})
+	}
+}
+
+func TestNewServiceClient_HealthCheckInBackground(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	t.Cleanup(slow.Close)
+
+	start := time.Now()
+	s, err := NewServiceClient(slow.URL, WithBaseURLs(slow.URL+"/b"), WithHealthCheck("/healthz", time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("NewServiceClient() blocked %s on health checks", elapsed)
+	}
+}
+
+func TestNewServiceClient_InvalidURL(t *testing.T) {
+	for _, base := range []string{"", "gateway:8080", "ftp://gateway", "http://"} {
+		if _, err := NewServiceClient(base); err == nil {
+			t.Errorf("NewServiceClient(%q) expected error", base)
+		}
+	}
+}
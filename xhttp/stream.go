@@ -0,0 +1,48 @@
+package xhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// maxStreamErrorBody 流式请求非2xx响应读取的最大字节数
+const maxStreamErrorBody = 64 << 10
+
+// DoStream 执行HTTP请求并返回未读取的响应体，用于下载大文件等场景，调用方必须关闭 resp.Body。
+// 日志只记录状态码和读取的字节数，链路和日志在关闭 resp.Body 时结束，耗时包含读取响应体的时间。
+// 状态码 >= 400 时读取响应体的前 64KB 返回 HTTPError，不进行响应校验，
+// 设置了重试策略时只在得到响应体之前重试
+func (c *Client) DoStream(ctx context.Context, method string, url string, header map[string]string, body []byte) (*http.Response, error) {
+	return c.doWithRetry(ctx, method, url, func() (*http.Response, error) {
+		r := c.bytesRequest(method, url, header, body)
+		r.stream = true
+		return c.send(ctx, r)
+	})
+}
+
+// streamBody 记录读取的字节数，关闭时结束链路并记录日志
+type streamBody struct {
+	io.ReadCloser
+	finish func()
+	once   sync.Once
+	n      int64
+	err    error // 读取响应体的错误，不含 io.EOF
+}
+
+func (b *streamBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err != nil && !errors.Is(err, io.EOF) && b.err == nil {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *streamBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.finish)
+	return err
+}
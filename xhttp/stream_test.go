@@ -0,0 +1,73 @@
+package xhttp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_DoStream(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789abcdef"), 64<<10) // 1MB
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/file":
+			w.Write(payload)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"` + strings.Repeat("x", maxStreamErrorBody) + `"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	logs := make(chan *RequestResponseLog, 1)
+	c := NewClient(WithLogger(nopLogger{}), WithLogHandler(func(l *RequestResponseLog) { logs <- l }))
+	ctx := context.Background()
+
+	resp, err := c.DoStream(ctx, http.MethodGet, srv.URL+"/file", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-logs:
+		t.Fatal("logged before the body was closed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	got, err := io.ReadAll(resp.Body)
+	if err != nil || !bytes.Equal(got, payload) {
+		t.Fatalf("read %d bytes, %v", len(got), err)
+	}
+	resp.Body.Close()
+	resp.Body.Close()
+
+	l := <-logs
+	if l.Status != http.StatusOK || l.Response != "<stream: 1048576 bytes>" {
+		t.Errorf("log status = %d, response = %q", l.Status, l.Response)
+	}
+
+	_, err = c.DoStream(ctx, http.MethodGet, srv.URL+"/missing", nil, nil)
+	he, ok := AsHTTPError(err)
+	if !ok || he.StatusCode != http.StatusNotFound || len(he.Body) != maxStreamErrorBody {
+		t.Fatalf("DoStream() error = %v, want 404 with a truncated body", err)
+	}
+	<-logs
+}
+
+func TestClient_DoStreamRetry(t *testing.T) {
+	srv, hits := flakyServer(t, http.StatusServiceUnavailable, 1, nil)
+	c := NewClient(WithLogger(nopLogger{}), WithRetry(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}))
+
+	resp, err := c.DoStream(context.Background(), http.MethodGet, srv.URL, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "{}" || hits.Load() != 2 {
+		t.Errorf("body = %q after %d requests", body, hits.Load())
+	}
+}
@@ -0,0 +1,111 @@
+package xhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrInvalidResponse 响应不符合约定（schema / 结构体校验失败）
+var ErrInvalidResponse = errors.New("xhttp: invalid response")
+
+// ResponseValidator 校验 2xx/3xx 响应，body 为已读取的完整响应体
+type ResponseValidator func(resp *http.Response, body []byte) error
+
+// ResponseValidationError 响应校验失败的错误，errors.Is(err, ErrInvalidResponse) 为 true
+type ResponseValidationError struct {
+	StatusCode int
+	Method     string
+	URL        string
+	Err        error
+}
+
+// Error 实现 error 接口
+func (e *ResponseValidationError) Error() string {
+	return fmt.Sprintf("invalid response, status %d, %s %s: %v", e.StatusCode, e.Method, e.URL, e.Err)
+}
+
+// Unwrap 返回校验器的原始错误
+func (e *ResponseValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Is 使 errors.Is(err, ErrInvalidResponse) 成立
+func (e *ResponseValidationError) Is(target error) bool {
+	return target == ErrInvalidResponse
+}
+
+// WithResponseValidator 添加响应校验器，按添加顺序执行，遇到第一个错误即返回。
+// 只校验状态码 < 400 的响应，错误响应仍返回 HTTPError
+func WithResponseValidator(v ResponseValidator) ClientOption {
+	return func(c *Client) {
+		if v != nil {
+			c.validators = append(c.validators, v)
+		}
+	}
+}
+
+// WithResponseSchema 使用 JSON Schema 校验响应体，schema 无效时 panic（在初始化阶段暴露配置错误）
+func WithResponseSchema(schema []byte) ClientOption {
+	v, err := JSONSchemaValidator(schema)
+	if err != nil {
+		panic(err)
+	}
+	return WithResponseValidator(v)
+}
+
+func (c *Client) validateResponse(method, url string, resp *http.Response, body []byte) error {
+	for _, v := range c.validators {
+		if err := v(resp, body); err != nil {
+			return &ResponseValidationError{
+				StatusCode: resp.StatusCode,
+				Method:     method,
+				URL:        url,
+				Err:        err,
+			}
+		}
+	}
+	return nil
+}
+
+var structValidate = validator.New()
+
+// StructValidator 将响应体解析为 prototype 的类型，再按 `validate` 标签校验，
+// 例如 StructValidator(PartnerOrder{})
+func StructValidator(prototype any) ResponseValidator {
+	typ := reflect.TypeOf(prototype)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return func(_ *http.Response, body []byte) error {
+		v := reflect.New(typ).Interface()
+		if err := json.Unmarshal(body, v); err != nil {
+			return fmt.Errorf("decode %s: %w", typ, err)
+		}
+		if typ.Kind() != reflect.Struct {
+			return nil
+		}
+		return structValidate.Struct(v)
+	}
+}
+
+// JSONSchemaValidator 根据 JSON Schema 创建校验器，支持常用关键字子集：
+// type、properties、required、additionalProperties(bool)、items、enum、
+// minimum/maximum、minLength/maxLength、pattern、minItems/maxItems
+func JSONSchemaValidator(schema []byte) (ResponseValidator, error) {
+	s, err := parseJSONSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	return func(_ *http.Response, body []byte) error {
+		var doc any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return fmt.Errorf("response is not json: %w", err)
+		}
+		return s.validate(doc, "")
+	}, nil
+}
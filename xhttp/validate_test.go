@@ -0,0 +1,123 @@
+package xhttp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const orderSchema = `{
+	"type": "object",
+	"required": ["code", "data"],
+	"properties": {
+		"code": {"type": "integer", "enum": [0, 1]},
+		"data": {
+			"type": ["object", "null"],
+			"required": ["id"],
+			"additionalProperties": false,
+			"properties": {
+				"id": {"type": "string", "minLength": 1, "pattern": "^o-"},
+				"amount": {"type": "number", "minimum": 0},
+				"tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+			}
+		}
+	}
+}`
+
+func TestJSONSchemaValidator(t *testing.T) {
+	v, err := JSONSchemaValidator([]byte(orderSchema))
+	if err != nil {
+		t.Fatalf("JSONSchemaValidator() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "valid", body: `{"code": 0, "data": {"id": "o-1", "amount": 9.5, "tags": ["a"]}}`},
+		{name: "null data", body: `{"code": 1, "data": null}`},
+		{name: "missing data", body: `{"code": 0}`, wantErr: `missing required property "data"`},
+		{name: "wrong type", body: `{"code": "0", "data": null}`, wantErr: "/code: expected integer"},
+		{name: "not in enum", body: `{"code": 2, "data": null}`, wantErr: "/code: value 2 not in enum"},
+		{name: "pattern", body: `{"code": 0, "data": {"id": "x-1"}}`, wantErr: "/data/id"},
+		{name: "minimum", body: `{"code": 0, "data": {"id": "o-1", "amount": -1}}`, wantErr: "/data/amount"},
+		{name: "array item", body: `{"code": 0, "data": {"id": "o-1", "tags": [1]}}`, wantErr: "/data/tags/0"},
+		{name: "additional property", body: `{"code": 0, "data": {"id": "o-1", "extra": 1}}`, wantErr: `unexpected property "extra"`},
+		{name: "not json", body: `<html>`, wantErr: "not json"},
+		{name: "first error by property name", body: `{"code": 0, "data": {"tags": [1], "id": "x-1", "extra": 1, "amount": -1}}`, wantErr: "/data/amount"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v(nil, []byte(tt.body))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			// map 遍历顺序随机，错误信息必须稳定
+			for i := 0; i < 20; i++ {
+				if again := v(nil, []byte(tt.body)); again.Error() != err.Error() {
+					t.Fatalf("error not stable: %v != %v", again, err)
+				}
+			}
+		})
+	}
+
+	if _, err := JSONSchemaValidator([]byte(`{"pattern": "("}`)); err == nil {
+		t.Fatal("invalid pattern should fail")
+	}
+}
+
+type partnerOrder struct {
+	ID     string  `json:"id" validate:"required"`
+	Amount float64 `json:"amount" validate:"gte=0"`
+}
+
+func TestClientResponseValidator(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			_, _ = w.Write([]byte(`{"id": "o-1", "amount": 1}`))
+		case "/invalid":
+			_, _ = w.Write([]byte(`{"amount": -1}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(WithLogger(nopLogger{}), WithResponseValidator(StructValidator(&partnerOrder{})))
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, srv.URL+"/ok", nil); err != nil {
+		t.Fatalf("valid response error = %v", err)
+	}
+
+	resp, err := c.Get(ctx, srv.URL+"/invalid", nil)
+	if !errors.Is(err, ErrInvalidResponse) || resp == nil {
+		t.Fatalf("invalid response error = %v", err)
+	}
+	var ve *ResponseValidationError
+	if !errors.As(err, &ve) || ve.StatusCode != http.StatusOK {
+		t.Fatalf("expected ResponseValidationError, got %T", err)
+	}
+
+	// 错误响应不做契约校验
+	if _, err = c.Get(ctx, srv.URL+"/fail", nil); !IsStatus(err, http.StatusInternalServerError) {
+		t.Fatalf("error response = %v", err)
+	}
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Infof(string, ...any)  {}
+func (nopLogger) Errorf(string, ...any) {}
@@ -0,0 +1,193 @@
+package xredis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+// compressMagic marks gzip-compressed values, the leading NUL keeps it from
+// clashing with text and JSON values
+const compressMagic = "\x00xgz1"
+
+// DefaultCompressThreshold is the value size from which CompressionHook compresses
+const DefaultCompressThreshold = 1024
+
+// valueArgs returns the indexes of the value arguments of write commands
+var valueArgs = map[string]func(n int) []int{
+	"SET":    single(2),
+	"SETNX":  single(2),
+	"GETSET": single(2),
+	"SETEX":  single(3),
+	"PSETEX": single(3),
+	"HSETNX": single(3),
+	"MSET":   every(2),
+	"MSETNX": every(2),
+	"HSET":   every(3),
+	"HMSET":  every(3),
+}
+
+func single(i int) func(int) []int {
+	return func(n int) []int {
+		if i < n {
+			return []int{i}
+		}
+		return nil
+	}
+}
+
+// every returns start, start+2, ... for alternating field/value arguments
+func every(start int) func(int) []int {
+	return func(n int) []int {
+		var idx []int
+		for i := start; i < n; i += 2 {
+			idx = append(idx, i)
+		}
+		return idx
+	}
+}
+
+// CompressionHook gzips string values of at least Threshold bytes written by
+// SET, SETEX, MSET, HSET and friends, and transparently decompresses them in the
+// replies of GET, GETSET, GETDEL, GETEX, MGET, HGET, HMGET, HGETALL and HVALS.
+// Uncompressed values are read as is, so it can be enabled on existing data.
+// APPEND, GETRANGE and STRLEN see the compressed bytes and should not be used
+// on keys holding large values
+type CompressionHook struct {
+	Threshold int
+}
+
+var gzipWriters = sync.Pool{New: func() any { return gzip.NewWriter(nil) }}
+
+func (h CompressionHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h CompressionHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		h.compressArgs(cmd)
+		err := next(ctx, cmd)
+		decompressResult(cmd)
+		return err
+	}
+}
+
+func (h CompressionHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			h.compressArgs(cmd)
+		}
+		err := next(ctx, cmds)
+		for _, cmd := range cmds {
+			decompressResult(cmd)
+		}
+		return err
+	}
+}
+
+func (h CompressionHook) threshold() int {
+	if h.Threshold <= 0 {
+		return DefaultCompressThreshold
+	}
+	return h.Threshold
+}
+
+func (h CompressionHook) compressArgs(cmd redis.Cmder) {
+	indexes, ok := valueArgs[strings.ToUpper(cmd.Name())]
+	if !ok {
+		return
+	}
+	args := cmd.Args()
+	for _, i := range indexes(len(args)) {
+		var raw []byte
+		switch v := args[i].(type) {
+		case string:
+			raw = []byte(v)
+		case []byte:
+			raw = v
+		default:
+			continue
+		}
+		if len(raw) < h.threshold() {
+			continue
+		}
+		if compressed, ok := compressValue(raw); ok {
+			args[i] = compressed
+		}
+	}
+}
+
+// compressValue returns the prefixed gzip of raw, ok is false when it doesn't shrink
+func compressValue(raw []byte) (string, bool) {
+	var buf bytes.Buffer
+	buf.WriteString(compressMagic)
+	zw := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(zw)
+	zw.Reset(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return "", false
+	}
+	if err := zw.Close(); err != nil {
+		return "", false
+	}
+	if buf.Len() >= len(raw) {
+		return "", false
+	}
+	return buf.String(), true
+}
+
+// decompressValue returns v unchanged unless it carries compressMagic
+func decompressValue(v string) string {
+	if !strings.HasPrefix(v, compressMagic) {
+		return v
+	}
+	zr, err := gzip.NewReader(strings.NewReader(v[len(compressMagic):]))
+	if err != nil {
+		logx.Errorf("xredis: corrupt compressed value: %v", err)
+		return v
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		logx.Errorf("xredis: corrupt compressed value: %v", err)
+		return v
+	}
+	return string(raw)
+}
+
+func decompressResult(cmd redis.Cmder) {
+	if cmd.Err() != nil {
+		return
+	}
+	switch c := cmd.(type) {
+	case *redis.StringCmd:
+		c.SetVal(decompressValue(c.Val()))
+	case *redis.SliceCmd: // MGET, HMGET
+		vals := c.Val()
+		for i, v := range vals {
+			if s, ok := v.(string); ok {
+				vals[i] = decompressValue(s)
+			}
+		}
+	case *redis.MapStringStringCmd: // HGETALL
+		m := c.Val()
+		for k, v := range m {
+			m[k] = decompressValue(v)
+		}
+	case *redis.StringSliceCmd:
+		if strings.ToUpper(c.Name()) == "HVALS" {
+			vals := c.Val()
+			for i, v := range vals {
+				vals[i] = decompressValue(v)
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package xredis
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressionHook(t *testing.T) {
+	ctx := context.Background()
+	hook := CompressionHook{Threshold: 64}
+	large := strings.Repeat(`{"session":"abcdef"}`, 50)
+
+	tests := []struct {
+		name       string
+		cmd        redis.Cmder
+		compressed []int // value argument indexes expected to be compressed
+	}{
+		{name: "set large", cmd: redis.NewStatusCmd(ctx, "set", "k", large), compressed: []int{2}},
+		{name: "set small", cmd: redis.NewStatusCmd(ctx, "set", "k", "small")},
+		{name: "setex", cmd: redis.NewStatusCmd(ctx, "setex", "k", 60, []byte(large)), compressed: []int{3}},
+		{name: "mset", cmd: redis.NewStatusCmd(ctx, "mset", "a", large, "b", "small"), compressed: []int{2}},
+		{name: "hset", cmd: redis.NewIntCmd(ctx, "hset", "h", "f1", "small", "f2", large), compressed: []int{5}},
+		{name: "non-string value", cmd: redis.NewStatusCmd(ctx, "set", "k", 42)},
+		{name: "incompressible", cmd: redis.NewStatusCmd(ctx, "set", "k", randomish(200))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := append([]interface{}(nil), tt.cmd.Args()...)
+			_ = hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error { return nil })(ctx, tt.cmd)
+			for i, arg := range tt.cmd.Args() {
+				s, isString := arg.(string)
+				wantCompressed := false
+				for _, c := range tt.compressed {
+					wantCompressed = wantCompressed || c == i
+				}
+				if wantCompressed {
+					assert.True(t, isString && strings.HasPrefix(s, compressMagic), "arg %d not compressed", i)
+					assert.Equal(t, large, decompressValue(s))
+				} else {
+					assert.Equal(t, before[i], arg, "arg %d changed", i)
+				}
+			}
+		})
+	}
+}
+
+func TestCompressionHookRead(t *testing.T) {
+	ctx := context.Background()
+	hook := CompressionHook{Threshold: 64}
+	large := strings.Repeat("x", 500)
+	stored, ok := compressValue([]byte(large))
+	assert.True(t, ok)
+
+	get := redis.NewStringCmd(ctx, "get", "k")
+	mget := redis.NewSliceCmd(ctx, "mget", "a", "b", "c")
+	hgetall := redis.NewMapStringStringCmd(ctx, "hgetall", "h")
+	hvals := redis.NewStringSliceCmd(ctx, "hvals", "h")
+
+	err := hook.ProcessPipelineHook(func(ctx context.Context, cmds []redis.Cmder) error {
+		get.SetVal(stored)
+		mget.SetVal([]interface{}{stored, "plain", nil})
+		hgetall.SetVal(map[string]string{"f": stored, "g": "plain"})
+		hvals.SetVal([]string{stored, "plain"})
+		return nil
+	})(ctx, []redis.Cmder{get, mget, hgetall, hvals})
+	assert.NoError(t, err)
+
+	assert.Equal(t, large, get.Val())
+	assert.Equal(t, []interface{}{large, "plain", nil}, mget.Val())
+	assert.Equal(t, map[string]string{"f": large, "g": "plain"}, hgetall.Val())
+	assert.Equal(t, []string{large, "plain"}, hvals.Val())
+
+	// values that only look compressed are returned untouched
+	assert.Equal(t, compressMagic+"garbage", decompressValue(compressMagic+"garbage"))
+}
+
+// randomish returns n bytes that gzip cannot shrink
+func randomish(n int) string {
+	b := make([]byte, n)
+	x := uint32(2463534242)
+	for i := range b {
+		x ^= x << 13
+		x ^= x >> 17
+		x ^= x << 5
+		b[i] = byte(x)
+	}
+	return string(b)
+}
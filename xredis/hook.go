@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel"
@@ -67,7 +68,9 @@ func (th TracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
 		)
 
 		// Process the command with the new context
+		start := time.Now()
 		err := next(spanCtx, cmd)
+		observeCommand(spanCtx, cmd.Name(), err, start)
 
 		// Record any errors and end the span
 		if err != nil && err != redis.Nil {
@@ -99,7 +102,9 @@ func (th TracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.
 		)
 
 		// Process the pipeline with the new context
+		start := time.Now()
 		err := next(spanCtx, cmds)
+		observeCommand(spanCtx, "pipeline", err, start)
 
 		// Record any errors and end the span
 		if err != nil {
@@ -0,0 +1,30 @@
+package xredis
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/zeromicro/go-zero/core/metric"
+
+	"gomod.pri/golib/xtrace"
+)
+
+// commandDuration records redis latency with trace id exemplars, so a latency
+// spike on the dashboard links to example traces
+var commandDuration = xtrace.NewHistogramVec(&metric.HistogramVecOpts{
+	Namespace: "xredis",
+	Subsystem: "client",
+	Name:      "duration_ms",
+	Help:      "Redis command latency in milliseconds, partitioned by command and result.",
+	Labels:    []string{"command", "result"},
+	Buckets:   []float64{0.25, 0.5, 1, 2, 5, 10, 25, 50, 100, 250, 500, 1000},
+})
+
+func observeCommand(ctx context.Context, command string, err error, start time.Time) {
+	result := "ok"
+	if err != nil && err != redis.Nil {
+		result = "error"
+	}
+	commandDuration.Observe(ctx, float64(time.Since(start).Microseconds())/1000, command, result)
+}
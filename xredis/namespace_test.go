@@ -0,0 +1,160 @@
+package xredis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigKeyPrefix(t *testing.T) {
+	t.Setenv("APP_ENV", "staging")
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    string
+		wantErr bool
+	}{
+		{name: "legacy prefix", cfg: Config{Prefix: "order:"}, want: "order:"},
+		{name: "app and env", cfg: Config{IsolateEnv: true, App: "order", Env: "prod"}, want: "order:prod:"},
+		{name: "app from prefix, env from APP_ENV", cfg: Config{IsolateEnv: true, Prefix: "order:"}, want: "order:staging:"},
+		{name: "missing app", cfg: Config{IsolateEnv: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.KeyPrefix()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAppPrefixHookStripOnRead(t *testing.T) {
+	ctx := context.Background()
+	hook := AppPrefixHook{Prefix: "order:prod:", StripOnRead: true}
+
+	scan := redis.NewScanCmd(ctx, nil, "scan", 0, "match", "user:*")
+	err := hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		assert.Equal(t, "order:prod:user:*", cmd.Args()[3])
+		cmd.(*redis.ScanCmd).SetVal([]string{"order:prod:user:1", "order:prod:user:2"}, 7)
+		return nil
+	})(ctx, scan)
+	assert.NoError(t, err)
+	keys, cursor := scan.Val()
+	assert.Equal(t, []string{"user:1", "user:2"}, keys)
+	assert.Equal(t, uint64(7), cursor)
+
+	// members of SSCAN are values, not keys
+	sscan := redis.NewScanCmd(ctx, nil, "sscan", "set", 0)
+	_ = hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		cmd.(*redis.ScanCmd).SetVal([]string{"order:prod:member"}, 0)
+		return nil
+	})(ctx, sscan)
+	members, _ := sscan.Val()
+	assert.Equal(t, []string{"order:prod:member"}, members)
+
+	pipeKeys := redis.NewStringSliceCmd(ctx, "keys", "user:*")
+	_ = hook.ProcessPipelineHook(func(ctx context.Context, cmds []redis.Cmder) error {
+		assert.Equal(t, "order:prod:user:*", cmds[0].Args()[1])
+		cmds[0].(*redis.StringSliceCmd).SetVal([]string{"order:prod:user:1"})
+		return nil
+	})(ctx, []redis.Cmder{pipeKeys})
+	assert.Equal(t, []string{"user:1"}, pipeKeys.Val())
+}
+
+func TestAppPrefixHookKeysOnlyWithStripOnRead(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		hook AppPrefixHook
+		want string
+	}{
+		{name: "strip on read", hook: AppPrefixHook{Prefix: "order:prod:", StripOnRead: true}, want: "order:prod:user:*"},
+		{name: "legacy prefix", hook: AppPrefixHook{Prefix: "order:"}, want: "user:*"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := redis.NewStringSliceCmd(ctx, "keys", "user:*")
+			err := tt.hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+				assert.Equal(t, tt.want, cmd.Args()[1])
+				return nil
+			})(ctx, keys)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestAppPrefixHookScanWithoutMatch(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		hook     AppPrefixHook
+		args     []interface{}
+		wantArgs []interface{}
+		page     []string
+		wantKeys []string
+	}{
+		{
+			name:     "scoped to prefix",
+			hook:     AppPrefixHook{Prefix: "order:prod:", StripOnRead: true},
+			args:     []interface{}{"scan", 0, "count", 100},
+			wantArgs: []interface{}{"scan", 0, "count", 100, "match", "order:prod:*"},
+			page:     []string{"order:prod:user:1"},
+			wantKeys: []string{"user:1"},
+		},
+		{
+			name:     "existing match is prefixed",
+			hook:     AppPrefixHook{Prefix: "order:prod:", StripOnRead: true},
+			args:     []interface{}{"scan", 0, "match", "user:*"},
+			wantArgs: []interface{}{"scan", 0, "match", "order:prod:user:*"},
+			page:     []string{"order:prod:user:1"},
+			wantKeys: []string{"user:1"},
+		},
+		{
+			name:     "legacy prefix scans everything",
+			hook:     AppPrefixHook{Prefix: "order:"},
+			args:     []interface{}{"scan", 0},
+			wantArgs: []interface{}{"scan", 0},
+			page:     []string{"order:user:1", "pay:user:1"},
+			wantKeys: []string{"order:user:1", "pay:user:1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scan := redis.NewScanCmd(ctx, nil, tt.args...)
+			err := tt.hook.ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+				assert.Equal(t, tt.wantArgs, cmd.Args())
+				cmd.(*redis.ScanCmd).SetVal(append([]string(nil), tt.page...), 42)
+				return nil
+			})(ctx, scan)
+			assert.NoError(t, err)
+			keys, cursor := scan.Val()
+			assert.Equal(t, tt.wantKeys, keys)
+			assert.Equal(t, uint64(42), cursor)
+
+			// the caller's command is left as built, so iterators keep working
+			assert.Equal(t, tt.args, scan.Args())
+		})
+	}
+
+	t.Run("pipeline", func(t *testing.T) {
+		hook := AppPrefixHook{Prefix: "order:prod:", StripOnRead: true}
+		get := redis.NewStringCmd(ctx, "get", "user:1")
+		scan := redis.NewScanCmd(ctx, nil, "scan", 0)
+		err := hook.ProcessPipelineHook(func(ctx context.Context, cmds []redis.Cmder) error {
+			assert.Equal(t, []interface{}{"get", "order:prod:user:1"}, cmds[0].Args())
+			assert.Equal(t, []interface{}{"scan", 0, "match", "order:prod:*"}, cmds[1].Args())
+			cmds[1].(*redis.ScanCmd).SetVal([]string{"order:prod:user:1"}, 0)
+			return nil
+		})(ctx, []redis.Cmder{get, scan})
+		assert.NoError(t, err)
+		keys, _ := scan.Val()
+		assert.Equal(t, []string{"user:1"}, keys)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"os"
 	"strings"
 	"time"
 
@@ -19,6 +20,44 @@ type Config struct {
 	Password string
 	Prefix   string
 	UseTLS   bool `json:"UseTLS,optional"`
+
+	// IsolateEnv prefixes every key with "App:Env:" instead of Prefix, so
+	// services of different environments sharing a cluster never collide.
+	// App defaults to Prefix without the trailing ':', Env to APP_ENV / ENV / GO_ENV.
+	// SCAN and KEYS only see keys under the prefix and return them stripped of it
+	IsolateEnv bool   `json:"IsolateEnv,optional"`
+	App        string `json:"App,optional"`
+	Env        string `json:"Env,optional"`
+
+	// Compress gzips values of at least CompressThreshold bytes (default 1KB)
+	// on write and decompresses them on read, see CompressionHook
+	Compress          bool `json:"Compress,optional"`
+	CompressThreshold int  `json:"CompressThreshold,optional"`
+}
+
+var envKeys = []string{"APP_ENV", "ENV", "GO_ENV"}
+
+// KeyPrefix returns the prefix added to every key
+func (c *Config) KeyPrefix() (string, error) {
+	if !c.IsolateEnv {
+		return c.Prefix, nil
+	}
+
+	app := c.App
+	if app == "" {
+		app = strings.TrimSuffix(c.Prefix, ":")
+	}
+	env := c.Env
+	for _, key := range envKeys {
+		if env != "" {
+			break
+		}
+		env = os.Getenv(key)
+	}
+	if app == "" || env == "" {
+		return "", fmt.Errorf("redis IsolateEnv requires App (or Prefix) and Env (or one of %s)", strings.Join(envKeys, "/"))
+	}
+	return app + ":" + env + ":", nil
 }
 
 // var Cli redis.UniversalClient
@@ -40,10 +79,20 @@ func Init(c *Config) redis.UniversalClient {
 		}
 	}
 
+	prefix, err := c.KeyPrefix()
+	if err != nil {
+		logx.Errorf("invalid redis config: %v", err)
+		panic(fmt.Sprintf("invalid redis config: %v", err))
+	}
+
 	Cli := redis.NewUniversalClient(options)
 
 	// because redis use the same, so all keys add the app prefix
-	Cli.AddHook(AppPrefixHook{Prefix: c.Prefix})
+	Cli.AddHook(AppPrefixHook{Prefix: prefix, StripOnRead: c.IsolateEnv})
+
+	if c.Compress {
+		Cli.AddHook(CompressionHook{Threshold: c.CompressThreshold})
+	}
 
 	Cli.AddHook(TracingHook{})
 
@@ -94,6 +143,10 @@ var commandsWithPrefix = []string{
 
 type AppPrefixHook struct {
 	Prefix string
+	// StripOnRead limits SCAN and KEYS to keys under Prefix and removes Prefix
+	// from the keys they return: KEYS patterns are prefixed and SCAN without
+	// MATCH gets MATCH <Prefix>*. Without it both see the whole keyspace
+	StripOnRead bool
 }
 
 func (h AppPrefixHook) DialHook(next redis.DialHook) redis.DialHook {
@@ -104,24 +157,100 @@ func (h AppPrefixHook) DialHook(next redis.DialHook) redis.DialHook {
 
 func (h AppPrefixHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
 	return func(ctx context.Context, cmd redis.Cmder) error {
-		if !shouldSkipPrefix(ctx) {
-			h.addPrefixToArgs(ctx, cmd)
+		if shouldSkipPrefix(ctx) {
+			return next(ctx, cmd)
+		}
+		if scan := h.scopedScan(ctx, cmd); scan != nil {
+			err := next(ctx, scan)
+			h.stripPrefixFromResult(scan)
+			copyScan(cmd, scan)
+			return err
 		}
-		return next(ctx, cmd)
+		h.addPrefixToArgs(ctx, cmd)
+		err := next(ctx, cmd)
+		h.stripPrefixFromResult(cmd)
+		return err
 	}
 }
 
 func (h AppPrefixHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
 	return func(ctx context.Context, cmds []redis.Cmder) error {
-		if !shouldSkipPrefix(ctx) {
-			for _, cmd := range cmds {
-				h.addPrefixToArgs(ctx, cmd)
+		if shouldSkipPrefix(ctx) {
+			return next(ctx, cmds)
+		}
+		sent := make([]redis.Cmder, len(cmds))
+		for i, cmd := range cmds {
+			if scan := h.scopedScan(ctx, cmd); scan != nil {
+				sent[i] = scan
+				continue
+			}
+			h.addPrefixToArgs(ctx, cmd)
+			sent[i] = cmd
+		}
+		err := next(ctx, sent)
+		for i, cmd := range sent {
+			h.stripPrefixFromResult(cmd)
+			if cmd != cmds[i] {
+				copyScan(cmds[i], cmd.(*redis.ScanCmd))
 			}
 		}
-		return next(ctx, cmds)
+		return err
 	}
 }
 
+// scopedScan returns a copy of a SCAN without MATCH limited to keys under the
+// prefix, or nil when cmd needs no rewrite. The args of a command can't grow
+// in place, so the copy is sent instead and its result copied back
+func (h AppPrefixHook) scopedScan(ctx context.Context, cmd redis.Cmder) *redis.ScanCmd {
+	if !h.StripOnRead || h.Prefix == "" || strings.ToUpper(cmd.Name()) != "SCAN" {
+		return nil
+	}
+	if _, ok := cmd.(*redis.ScanCmd); !ok {
+		return nil
+	}
+	args := cmd.Args()
+	for i := 2; i < len(args); i += 2 {
+		if strings.ToUpper(cast.ToString(args[i])) == "MATCH" {
+			return nil
+		}
+	}
+	scoped := append(append([]interface{}{}, args...), "match", h.Prefix+"*")
+	return redis.NewScanCmd(ctx, nil, scoped...)
+}
+
+func copyScan(dst redis.Cmder, src *redis.ScanCmd) {
+	c := dst.(*redis.ScanCmd)
+	c.SetVal(src.Val())
+	c.SetErr(src.Err())
+}
+
+// stripPrefixFromResult removes the prefix from keys returned by SCAN and KEYS,
+// other scans (SSCAN, HSCAN, ZSCAN) return members and are left untouched
+func (h AppPrefixHook) stripPrefixFromResult(cmd redis.Cmder) {
+	if !h.StripOnRead || h.Prefix == "" || cmd.Err() != nil {
+		return
+	}
+
+	switch c := cmd.(type) {
+	case *redis.ScanCmd:
+		if strings.ToUpper(c.Name()) == "SCAN" {
+			keys, cursor := c.Val()
+			c.SetVal(h.trimKeys(keys), cursor)
+		}
+	case *redis.StringSliceCmd:
+		if strings.ToUpper(c.Name()) == "KEYS" {
+			c.SetVal(h.trimKeys(c.Val()))
+		}
+	}
+}
+
+func (h AppPrefixHook) trimKeys(keys []string) []string {
+	for i, k := range keys {
+		keys[i] = strings.TrimPrefix(k, h.Prefix)
+	}
+	return keys
+}
+
 // public prefix processing function
 func (h AppPrefixHook) addPrefixToArgs(ctx context.Context, cmd redis.Cmder) {
 	// directly change the args variable, because the memory address is the same
@@ -168,6 +297,14 @@ func (h AppPrefixHook) addPrefixToArgs(ctx context.Context, cmd redis.Cmder) {
 				}
 			}
 		}
+	case "KEYS":
+		// KEYS results are only stripped with StripOnRead, other clients keep
+		// the unprefixed pattern
+		if h.StripOnRead {
+			args[1] = h.Prefix + cast.ToString(args[1])
+		} else {
+			logx.Errorf("unsupport app prefix command: %s", name)
+		}
 	case "SSCAN", "ZSCAN":
 		if len(args) > 3 {
 			args[1] = h.Prefix + cast.ToString(args[1])
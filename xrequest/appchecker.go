@@ -5,8 +5,22 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 )
 
+// AppProvider is implemented by requests that know their app, e.g. protobuf
+// messages with an app field, so GetApp skips reflection on hot paths
+type AppProvider interface {
+	GetApp() string
+}
+
+// appFields caches the index of the App or AppId field per request type
+var appFields sync.Map // reflect.Type -> appField
+
+type appField struct {
+	index []int // nil when the type has neither field
+}
+
 func GetApp(ctx context.Context, req interface{}) (string, error) {
 	if v := ctx.Value("APP-ID"); v != nil {
 		if str, ok := v.(fmt.Stringer); ok {
@@ -15,7 +29,10 @@ func GetApp(ctx context.Context, req interface{}) (string, error) {
 		return fmt.Sprint(v), nil
 	}
 
-	// Use reflection to check if req has App field
+	if p, ok := req.(AppProvider); ok {
+		return p.GetApp(), nil
+	}
+
 	v := reflect.ValueOf(req)
 
 	// Handle pointer type
@@ -28,19 +45,34 @@ func GetApp(ctx context.Context, req interface{}) (string, error) {
 		return "", errors.New("request struct is not a struct")
 	}
 
-	// Try to get App field first
-	f := v.FieldByName("App")
-	if f.IsValid() {
-		return fmt.Sprint(f.Interface()), nil
+	field := lookupAppField(v.Type())
+	if field.index == nil {
+		return "", errors.New("neither App nor AppId field exists in request struct")
+	}
+	f, err := v.FieldByIndexErr(field.index)
+	if err != nil {
+		// App is promoted from a nil embedded pointer
+		return "", fmt.Errorf("app field is not reachable: %w", err)
 	}
+	return fmt.Sprint(f.Interface()), nil
+}
 
-	// If App field doesn't exist, try to get AppId field
-	f = v.FieldByName("AppId")
-	if f.IsValid() {
-		return fmt.Sprint(f.Interface()), nil
+// lookupAppField resolves the App field, or the AppId field if App doesn't
+// exist, once per type
+func lookupAppField(t reflect.Type) appField {
+	if cached, ok := appFields.Load(t); ok {
+		return cached.(appField)
 	}
 
-	return "", errors.New("neither App nor AppId field exists in request struct")
+	var field appField
+	for _, name := range []string{"App", "AppId"} {
+		if sf, ok := t.FieldByName(name); ok && sf.IsExported() {
+			field.index = sf.Index
+			break
+		}
+	}
+	appFields.Store(t, field)
+	return field
 }
 
 func GetCountry(ctx context.Context, req interface{}) (string, error) {
@@ -75,3 +75,75 @@ func TestGetApp(t *testing.T) {
 		})
 	}
 }
+
+type AppIdRequest struct {
+	AppId string
+}
+
+type BaseRequest struct {
+	App AppEnum
+}
+
+type EmbeddedRequest struct {
+	*BaseRequest
+	ID int
+}
+
+type providerRequest struct {
+	app string
+}
+
+func (r *providerRequest) GetApp() string {
+	return r.app
+}
+
+func TestGetApp_Fields(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     interface{}
+		want    string
+		wantErr bool
+	}{
+		{name: "app provider", req: &providerRequest{app: "provided"}, want: "provided"},
+		{name: "app id field", req: AppIdRequest{AppId: "by-id"}, want: "by-id"},
+		{name: "embedded app", req: &EmbeddedRequest{BaseRequest: &BaseRequest{App: AppEnum1}}, want: "test-app"},
+		{name: "nil embedded app", req: &EmbeddedRequest{}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the second call hits the cached field index
+			for i := 0; i < 2; i++ {
+				got, err := GetApp(context.Background(), tt.req)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("GetApp() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				if got != tt.want {
+					t.Fatalf("GetApp() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetApp(b *testing.B) {
+	ctx := context.Background()
+	benchmarks := []struct {
+		name string
+		req  interface{}
+	}{
+		{name: "reflect", req: &TestRequest{App: AppEnum1}},
+		{name: "app id", req: &AppIdRequest{AppId: "by-id"}},
+		{name: "provider", req: &providerRequest{app: "provided"}},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := GetApp(ctx, bm.req); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package xrequest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Device types reported in ClientInfo.DeviceType
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+)
+
+// ClientInfo describes the caller of a request, see ParseClientInfo
+type ClientInfo struct {
+	IP          string `json:"ip"`
+	UserAgent   string `json:"user_agent"`
+	OS          string `json:"os"`
+	Browser     string `json:"browser"`
+	DeviceType  string `json:"device_type"`
+	DeviceID    string `json:"device_id,omitempty"`
+	Platform    string `json:"platform,omitempty"`
+	AppVersion  string `json:"app_version,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Fingerprint string `json:"fingerprint"` // stable hash of the device fields, not of the IP
+}
+
+type clientInfoOptions struct {
+	trusted          []netip.Prefix
+	deviceIDHeader   string
+	platformHeader   string
+	appVersionHeader string
+}
+
+// ClientInfoOption configures ParseClientInfo and ClientInfoMiddleware
+type ClientInfoOption func(*clientInfoOptions)
+
+// WithTrustedProxies sets the proxies (IPs or CIDRs) whose X-Forwarded-For and
+// X-Real-IP headers are believed. Without it the headers are ignored and the
+// peer address is used, since any client can forge them. Invalid entries are skipped.
+func WithTrustedProxies(proxies ...string) ClientInfoOption {
+	return func(o *clientInfoOptions) {
+		for _, p := range proxies {
+			if prefix, err := netip.ParsePrefix(p); err == nil {
+				o.trusted = append(o.trusted, prefix.Masked())
+			} else if addr, err := netip.ParseAddr(p); err == nil {
+				o.trusted = append(o.trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			}
+		}
+	}
+}
+
+// WithDeviceHeaders overrides the headers carrying the device id, platform and
+// app version, empty names keep the defaults
+func WithDeviceHeaders(deviceID, platform, appVersion string) ClientInfoOption {
+	return func(o *clientInfoOptions) {
+		if deviceID != "" {
+			o.deviceIDHeader = deviceID
+		}
+		if platform != "" {
+			o.platformHeader = platform
+		}
+		if appVersion != "" {
+			o.appVersionHeader = appVersion
+		}
+	}
+}
+
+func newClientInfoOptions(opts []ClientInfoOption) *clientInfoOptions {
+	o := &clientInfoOptions{
+		deviceIDHeader:   "X-Device-Id",
+		platformHeader:   "X-Platform",
+		appVersionHeader: "X-App-Version",
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// ParseClientInfo extracts the client IP, user agent and device fields of r
+func ParseClientInfo(r *http.Request, opts ...ClientInfoOption) *ClientInfo {
+	return parseClientInfo(r, newClientInfoOptions(opts))
+}
+
+func parseClientInfo(r *http.Request, o *clientInfoOptions) *ClientInfo {
+	ua := r.Header.Get("User-Agent")
+	info := &ClientInfo{
+		IP:         clientIP(r, o.trusted),
+		UserAgent:  ua,
+		DeviceID:   r.Header.Get(o.deviceIDHeader),
+		Platform:   r.Header.Get(o.platformHeader),
+		AppVersion: r.Header.Get(o.appVersionHeader),
+		Language:   primaryLanguage(r.Header.Get("Accept-Language")),
+	}
+	info.OS, info.Browser, info.DeviceType = parseUserAgent(ua)
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		info.UserAgent, info.DeviceID, info.Platform, info.Language,
+	}, "\x00")))
+	info.Fingerprint = hex.EncodeToString(sum[:8])
+	return info
+}
+
+// ClientIP returns the real client IP of r, honoring forwarding headers set by trusted proxies only
+func ClientIP(r *http.Request, opts ...ClientInfoOption) string {
+	return clientIP(r, newClientInfoOptions(opts).trusted)
+}
+
+func clientIP(r *http.Request, trusted []netip.Prefix) string {
+	peer := parseIP(r.RemoteAddr)
+	if !peer.IsValid() {
+		return ""
+	}
+	if !isTrusted(peer, trusted) {
+		return peer.String()
+	}
+
+	// walk X-Forwarded-For from the right, the first untrusted hop is the client
+	var hops []string
+	for _, v := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseIP(hops[i])
+		if !ip.IsValid() {
+			break
+		}
+		if !isTrusted(ip, trusted) || i == 0 {
+			return ip.String()
+		}
+	}
+
+	if ip := parseIP(r.Header.Get("X-Real-IP")); ip.IsValid() {
+		return ip.String()
+	}
+	return peer.String()
+}
+
+// parseIP accepts "ip", "ip:port" and "[ip]:port"
+func parseIP(s string) netip.Addr {
+	s = strings.TrimSpace(s)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	ip, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}
+	}
+	return ip.Unmap()
+}
+
+func isTrusted(ip netip.Addr, trusted []netip.Prefix) bool {
+	for _, p := range trusted {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// primaryLanguage returns the first tag of an Accept-Language header
+func primaryLanguage(header string) string {
+	lang, _, _ := strings.Cut(header, ",")
+	lang, _, _ = strings.Cut(lang, ";")
+	return strings.TrimSpace(lang)
+}
+
+// parseUserAgent is a small heuristic covering the clients we serve,
+// not a full user agent database
+func parseUserAgent(ua string) (os, browser, device string) {
+	l := strings.ToLower(ua)
+
+	switch {
+	case l == "":
+		os = "unknown"
+	case strings.Contains(l, "iphone"), strings.Contains(l, "ipad"), strings.Contains(l, "ipod"):
+		os = "iOS"
+	case strings.Contains(l, "android"):
+		os = "Android"
+	case strings.Contains(l, "windows"):
+		os = "Windows"
+	case strings.Contains(l, "mac os"), strings.Contains(l, "macintosh"):
+		os = "macOS"
+	case strings.Contains(l, "linux"):
+		os = "Linux"
+	default:
+		os = "unknown"
+	}
+
+	switch {
+	case strings.Contains(l, "micromessenger"):
+		browser = "WeChat"
+	case strings.Contains(l, "edg/"):
+		browser = "Edge"
+	case strings.Contains(l, "opr/"), strings.Contains(l, "opera"):
+		browser = "Opera"
+	case strings.Contains(l, "firefox/"), strings.Contains(l, "fxios/"):
+		browser = "Firefox"
+	case strings.Contains(l, "chrome/"), strings.Contains(l, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(l, "safari/"):
+		browser = "Safari"
+	case strings.Contains(l, "okhttp"), strings.Contains(l, "cfnetwork"), strings.Contains(l, "dart"):
+		browser = "App"
+	default:
+		browser = "unknown"
+	}
+
+	switch {
+	case strings.Contains(l, "bot"), strings.Contains(l, "spider"), strings.Contains(l, "crawler"), strings.Contains(l, "curl/"):
+		device = DeviceBot
+	case strings.Contains(l, "ipad"), strings.Contains(l, "tablet"),
+		strings.Contains(l, "android") && !strings.Contains(l, "mobile"):
+		device = DeviceTablet
+	case strings.Contains(l, "mobi"), strings.Contains(l, "iphone"), strings.Contains(l, "ipod"):
+		device = DeviceMobile
+	default:
+		device = DeviceDesktop
+	}
+	return os, browser, device
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo returns a copy of ctx carrying info
+func WithClientInfo(ctx context.Context, info *ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+// ClientInfoFromContext returns the ClientInfo stored by ClientInfoMiddleware or WithClientInfo
+func ClientInfoFromContext(ctx context.Context) (*ClientInfo, bool) {
+	info, ok := ctx.Value(clientInfoKey{}).(*ClientInfo)
+	return info, ok && info != nil
+}
+
+// ClientInfoMiddleware returns a go-zero compatible middleware that parses the
+// ClientInfo once and stores it in the request context
+func ClientInfoMiddleware(opts ...ClientInfoOption) func(next http.HandlerFunc) http.HandlerFunc {
+	o := newClientInfoOptions(opts)
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			info := parseClientInfo(r, o)
+			next(w, r.WithContext(WithClientInfo(r.Context(), info)))
+		}
+	}
+}
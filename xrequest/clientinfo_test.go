@@ -0,0 +1,99 @@
+package xrequest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []ClientInfoOption{WithTrustedProxies("10.0.0.0/8", "192.168.1.1")}
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    []string
+		realIP string
+		opts   []ClientInfoOption
+		want   string
+	}{
+		{name: "no proxy", remote: "1.2.3.4:5678", want: "1.2.3.4"},
+		{name: "untrusted peer ignores headers", remote: "1.2.3.4:5678", xff: []string{"9.9.9.9"}, realIP: "8.8.8.8", want: "1.2.3.4"},
+		{name: "no trusted proxies configured", remote: "10.0.0.1:80", xff: []string{"9.9.9.9"}, want: "10.0.0.1"},
+		{name: "trusted peer", remote: "10.0.0.1:80", xff: []string{"9.9.9.9"}, opts: trusted, want: "9.9.9.9"},
+		{name: "skip trusted hops", remote: "10.0.0.1:80", xff: []string{"6.6.6.6, 9.9.9.9, 10.1.1.1", "192.168.1.1"}, opts: trusted, want: "9.9.9.9"},
+		{name: "all hops trusted", remote: "10.0.0.1:80", xff: []string{"10.2.2.2, 10.1.1.1"}, opts: trusted, want: "10.2.2.2"},
+		{name: "x-real-ip", remote: "10.0.0.1:80", realIP: "9.9.9.9", opts: trusted, want: "9.9.9.9"},
+		{name: "garbage xff falls back", remote: "10.0.0.1:80", xff: []string{"unknown"}, opts: trusted, want: "10.0.0.1"},
+		{name: "ipv6 peer", remote: "[2001:db8::1]:443", want: "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := ClientIP(r, tt.opts...); got != tt.want {
+				t.Errorf("ClientIP() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		ua                  string
+		os, browser, device string
+	}{
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Version/17.0 Mobile/15E148 Safari/604.1", "iOS", "Safari", DeviceMobile},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36", "Android", "Chrome", DeviceMobile},
+		{"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 Chrome/120.0 Safari/537.36", "Android", "Chrome", DeviceTablet},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36 Edg/120.0", "Windows", "Edge", DeviceDesktop},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "unknown", "unknown", DeviceBot},
+		{"okhttp/4.12.0", "unknown", "App", DeviceDesktop},
+		{"", "unknown", "unknown", DeviceDesktop},
+	}
+	for _, tt := range tests {
+		os, browser, device := parseUserAgent(tt.ua)
+		if os != tt.os || browser != tt.browser || device != tt.device {
+			t.Errorf("parseUserAgent(%q) = %s/%s/%s, want %s/%s/%s", tt.ua, os, browser, device, tt.os, tt.browser, tt.device)
+		}
+	}
+}
+
+func TestClientInfoMiddleware(t *testing.T) {
+	var got *ClientInfo
+	handler := ClientInfoMiddleware(WithDeviceHeaders("X-Did", "", ""))(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = ClientInfoFromContext(r.Context())
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = "1.2.3.4:1"
+	r.Header.Set("User-Agent", "okhttp/4.12.0")
+	r.Header.Set("X-Did", "dev-1")
+	r.Header.Set("X-Platform", "android")
+	r.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en;q=0.8")
+	handler(httptest.NewRecorder(), r)
+
+	if got == nil {
+		t.Fatal("ClientInfo missing from context")
+	}
+	if got.IP != "1.2.3.4" || got.DeviceID != "dev-1" || got.Platform != "android" || got.Language != "zh-CN" {
+		t.Errorf("unexpected ClientInfo: %+v", got)
+	}
+
+	// the fingerprint doesn't depend on the IP
+	r.RemoteAddr = "5.6.7.8:1"
+	if other := ParseClientInfo(r, WithDeviceHeaders("X-Did", "", "")); other.Fingerprint != got.Fingerprint {
+		t.Errorf("fingerprint changed with IP: %s != %s", other.Fingerprint, got.Fingerprint)
+	}
+
+	if _, ok := ClientInfoFromContext(r.Context()); ok {
+		t.Error("ClientInfoFromContext() should be false without middleware")
+	}
+}
@@ -0,0 +1,168 @@
+package xrequest
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/metric"
+)
+
+const (
+	unknownApp = "unknown"
+	// otherApp labels apps outside the allowlist, see WithApps
+	otherApp = "other"
+)
+
+var (
+	requestDuration = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: "xrequest",
+		Subsystem: "route",
+		Name:      "duration_ms",
+		Help:      "Request latency in milliseconds, partitioned by route and app.",
+		Labels:    []string{"route", "app"},
+		Buckets:   []float64{5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
+	})
+
+	requestTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: "xrequest",
+		Subsystem: "route",
+		Name:      "total",
+		Help:      "How many requests handled, partitioned by route, app and status code.",
+		Labels:    []string{"route", "app", "code"},
+	})
+)
+
+type metricsOptions struct {
+	routeFunc func(r *http.Request) string
+	apps      map[string]struct{}
+	observe   func(route, app string, code int, elapsed time.Duration)
+}
+
+func observeRequest(route, app string, code int, elapsed time.Duration) {
+	requestDuration.Observe(elapsed.Milliseconds(), route, app)
+	requestTotal.Inc(route, app, strconv.Itoa(code))
+}
+
+// MetricsOption configures MetricsMiddleware
+type MetricsOption func(*metricsOptions)
+
+// WithRouteFunc sets how the route label is derived from a request.
+// Use it to map paths with ids (/users/123) to their route pattern (/users/:id)
+// and keep label cardinality bounded. Defaults to the request URL path.
+func WithRouteFunc(fn func(r *http.Request) string) MetricsOption {
+	return func(o *metricsOptions) {
+		o.routeFunc = fn
+	}
+}
+
+// WithApps sets the apps reported in the app label, any other app is labeled
+// "other". The app comes from the client, the allowlist keeps label
+// cardinality bounded. Without it every resolved app is labeled "other"
+func WithApps(apps ...string) MetricsOption {
+	return func(o *metricsOptions) {
+		if o.apps == nil {
+			o.apps = make(map[string]struct{}, len(apps))
+		}
+		for _, app := range apps {
+			o.apps[app] = struct{}{}
+		}
+	}
+}
+
+type metricsAppKey struct{}
+
+// SetMetricsApp records the app of the request for MetricsMiddleware, for
+// middlewares and handlers running inside it that resolve the app themselves,
+// e.g. after authentication
+func SetMetricsApp(ctx context.Context, app string) {
+	if holder, ok := ctx.Value(metricsAppKey{}).(*string); ok {
+		*holder = app
+	}
+}
+
+// MetricsMiddleware returns a go-zero compatible middleware that records
+// request count and latency labeled by route, app and response status code.
+// The app label is resolved after the handler ran: the app recorded with
+// SetMetricsApp, else GetApp on the request context, else the APP-ID header,
+// and is checked against the WithApps allowlist.
+func MetricsMiddleware(opts ...MetricsOption) func(next http.HandlerFunc) http.HandlerFunc {
+	o := &metricsOptions{
+		routeFunc: func(r *http.Request) string {
+			return r.URL.Path
+		},
+		observe: observeRequest,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w, code: http.StatusOK}
+
+			var resolved string
+			next(sw, r.WithContext(context.WithValue(r.Context(), metricsAppKey{}, &resolved)))
+
+			route := o.routeFunc(r)
+			app := o.appLabel(appFromRequest(r, resolved))
+			o.observe(route, app, sw.code, time.Since(start))
+		}
+	}
+}
+
+// appFromRequest returns the app of a handled request, resolved is the app
+// recorded with SetMetricsApp
+func appFromRequest(r *http.Request, resolved string) string {
+	if resolved != "" {
+		return resolved
+	}
+	if app, err := GetApp(r.Context(), nil); err == nil && app != "" {
+		return app
+	}
+	return r.Header.Get("APP-ID")
+}
+
+// appLabel bounds the app label to the allowlist
+func (o *metricsOptions) appLabel(app string) string {
+	if app == "" {
+		return unknownApp
+	}
+	if _, ok := o.apps[app]; ok {
+		return app
+	}
+	return otherApp
+}
+
+// statusWriter captures the status code written by the handler
+type statusWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.code = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,151 @@
+package xrequest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppFromRequest(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      func() *http.Request
+		resolved string
+		want     string
+	}{
+		{
+			name: "app from context",
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+				return r.WithContext(context.WithValue(r.Context(), "APP-ID", "app-ctx"))
+			},
+			want: "app-ctx",
+		},
+		{
+			name: "app from header",
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+				r.Header.Set("APP-ID", "app-header")
+				return r
+			},
+			want: "app-header",
+		},
+		{
+			name: "app recorded by the handler wins",
+			req: func() *http.Request {
+				r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+				r.Header.Set("APP-ID", "app-header")
+				return r
+			},
+			resolved: "app-auth",
+			want:     "app-auth",
+		},
+		{
+			name: "no app",
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/orders", nil)
+			},
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appFromRequest(tt.req(), tt.resolved); got != tt.want {
+				t.Errorf("appFromRequest() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppLabel(t *testing.T) {
+	o := &metricsOptions{}
+	WithApps("loan-app", "wallet")(o)
+
+	tests := []struct {
+		app  string
+		want string
+	}{
+		{app: "loan-app", want: "loan-app"},
+		{app: "forged-" + strings.Repeat("x", 32), want: otherApp},
+		{app: "", want: unknownApp},
+	}
+	for _, tt := range tests {
+		if got := o.appLabel(tt.app); got != tt.want {
+			t.Errorf("appLabel(%q) = %q, want %q", tt.app, got, tt.want)
+		}
+	}
+
+	if got := (&metricsOptions{}).appLabel("loan-app"); got != otherApp {
+		t.Errorf("appLabel() without allowlist = %q, want %q", got, otherApp)
+	}
+}
+
+func TestMetricsMiddleware_App(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		header  string
+		want    string
+	}{
+		{
+			name: "app resolved by an inner middleware",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				r = r.WithContext(context.WithValue(r.Context(), "APP-ID", "wallet"))
+				SetMetricsApp(r.Context(), "wallet")
+			},
+			header: "forged",
+			want:   "wallet",
+		},
+		{name: "allowed header", handler: func(http.ResponseWriter, *http.Request) {}, header: "loan-app", want: "loan-app"},
+		{name: "forged header", handler: func(http.ResponseWriter, *http.Request) {}, header: "app-1234", want: otherApp},
+		{name: "no app", handler: func(http.ResponseWriter, *http.Request) {}, want: unknownApp},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			mw := MetricsMiddleware(WithApps("loan-app", "wallet"), func(o *metricsOptions) {
+				o.observe = func(_, app string, _ int, _ time.Duration) { got = app }
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/orders", nil)
+			if tt.header != "" {
+				r.Header.Set("APP-ID", tt.header)
+			}
+			mw(tt.handler)(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("app label = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// outside MetricsMiddleware SetMetricsApp is a no-op
+	SetMetricsApp(context.Background(), "wallet")
+}
+
+func TestMetricsMiddleware_StatusCode(t *testing.T) {
+	var route string
+	mw := MetricsMiddleware(WithRouteFunc(func(r *http.Request) string {
+		route = "/orders/:id"
+		return route
+	}))
+
+	handler := mw(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = w.Write([]byte("teapot"))
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/orders/1", nil))
+
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusTeapot)
+	}
+	if route != "/orders/:id" {
+		t.Errorf("route func not invoked, got %q", route)
+	}
+}
@@ -0,0 +1,78 @@
+package xtrace
+
+import (
+	"context"
+	"net/http"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/zeromicro/go-zero/core/metric"
+	"github.com/zeromicro/go-zero/core/prometheus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExemplarTraceIDKey is the exemplar label holding the trace id, the name
+// Grafana's "exemplar trace id destination" expects by default
+const ExemplarTraceIDKey = "trace_id"
+
+// HistogramVec is a duration histogram that attaches the trace id of the
+// sampled span in ctx as exemplar, so a dashboard can jump from a latency
+// spike to an example trace. Like go-zero metrics, it only records when the
+// prometheus agent is enabled.
+//
+// Exemplars are only exposed in the OpenMetrics format, serve MetricsHandler
+// instead of the plain promhttp handler for Prometheus to scrape them
+// (requires --enable-feature=exemplar-storage)
+type HistogramVec struct {
+	vec *prom.HistogramVec
+}
+
+// NewHistogramVec creates and registers a histogram, see metric.NewHistogramVec
+func NewHistogramVec(cfg *metric.HistogramVecOpts) *HistogramVec {
+	vec := prom.NewHistogramVec(prom.HistogramOpts{
+		Namespace:   cfg.Namespace,
+		Subsystem:   cfg.Subsystem,
+		Name:        cfg.Name,
+		Help:        cfg.Help,
+		Buckets:     cfg.Buckets,
+		ConstLabels: cfg.ConstLabels,
+	}, cfg.Labels)
+	prom.MustRegister(vec)
+	return &HistogramVec{vec: vec}
+}
+
+// Observe adds v to labels, with the trace id of ctx as exemplar
+func (h *HistogramVec) Observe(ctx context.Context, v float64, labels ...string) {
+	if !prometheus.Enabled() {
+		return
+	}
+	observe(ctx, h.vec.WithLabelValues(labels...), v)
+}
+
+func observe(ctx context.Context, o prom.Observer, v float64) {
+	if exemplar := Exemplar(ctx); exemplar != nil {
+		if eo, ok := o.(prom.ExemplarObserver); ok {
+			eo.ObserveWithExemplar(v, exemplar)
+			return
+		}
+	}
+	o.Observe(v)
+}
+
+// Exemplar returns the exemplar labels for the span in ctx, nil when the span
+// is not sampled: an exemplar pointing to a dropped trace is a dead link
+func Exemplar(ctx context.Context) prom.Labels {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() || !sc.IsSampled() {
+		return nil
+	}
+	return prom.Labels{ExemplarTraceIDKey: sc.TraceID().String()}
+}
+
+// MetricsHandler serves the default registry with OpenMetrics negotiation
+// enabled, which is required to expose exemplars
+func MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(prom.DefaultGatherer, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
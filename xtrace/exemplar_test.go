@@ -0,0 +1,59 @@
+package xtrace
+
+import (
+	"context"
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestObserveExemplar(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	spanCtx := func(flags trace.TraceFlags) context.Context {
+		return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: flags,
+		}))
+	}
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		wantTraceID string
+	}{
+		{name: "sampled", ctx: spanCtx(trace.FlagsSampled), wantTraceID: traceID.String()},
+		{name: "not sampled", ctx: spanCtx(0)},
+		{name: "no span", ctx: context.Background()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := prom.NewHistogram(prom.HistogramOpts{Name: "test_duration_ms", Buckets: []float64{10, 100}})
+			observe(tt.ctx, h, 42)
+
+			var m dto.Metric
+			if err := h.Write(&m); err != nil {
+				t.Fatal(err)
+			}
+			if got := m.GetHistogram().GetSampleCount(); got != 1 {
+				t.Fatalf("sample count = %d, want 1", got)
+			}
+
+			var got string
+			for _, b := range m.GetHistogram().GetBucket() {
+				for _, l := range b.GetExemplar().GetLabel() {
+					if l.GetName() == ExemplarTraceIDKey {
+						got = l.GetValue()
+					}
+				}
+			}
+			if got != tt.wantTraceID {
+				t.Errorf("exemplar trace id = %q, want %q", got, tt.wantTraceID)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package xtrace
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+)
+
+// AttributeFilterConfig controls which span and event attributes are exported.
+// Keys match exactly, or by prefix when ending with "*" (e.g. "http.request.*").
+type AttributeFilterConfig struct {
+	Allow []string `json:",optional"` // when set, only these keys are kept
+	Deny  []string `json:",optional"` // removed
+	Hash  []string `json:",optional"` // value replaced with its sha256
+}
+
+// AttributeFilterForEnv picks the config for env from configs, where env
+// defaults to APP_ENV / ENV / GO_ENV. A missing env falls back to the "default" entry
+func AttributeFilterForEnv(configs map[string]AttributeFilterConfig, env string) AttributeFilterConfig {
+	if env == "" {
+		for _, key := range []string{"APP_ENV", "ENV", "GO_ENV"} {
+			if env = os.Getenv(key); env != "" {
+				break
+			}
+		}
+	}
+	if cfg, ok := configs[env]; ok {
+		return cfg
+	}
+	return configs["default"]
+}
+
+// NewAttributeFilterProcessor wraps next (typically a batch processor) and
+// strips or hashes attributes of ended spans before next sees them
+func NewAttributeFilterProcessor(cfg AttributeFilterConfig, next trace.SpanProcessor) trace.SpanProcessor {
+	return &attributeFilterProcessor{
+		next:  next,
+		allow: newKeyMatcher(cfg.Allow),
+		deny:  newKeyMatcher(cfg.Deny),
+		hash:  newKeyMatcher(cfg.Hash),
+	}
+}
+
+type attributeFilterProcessor struct {
+	next  trace.SpanProcessor
+	allow keyMatcher
+	deny  keyMatcher
+	hash  keyMatcher
+}
+
+func (p *attributeFilterProcessor) OnStart(ctx context.Context, s trace.ReadWriteSpan) {
+	p.next.OnStart(ctx, s)
+}
+
+func (p *attributeFilterProcessor) OnEnd(s trace.ReadOnlySpan) {
+	p.next.OnEnd(&filteredSpan{
+		ReadOnlySpan: s,
+		attrs:        p.filter(s.Attributes()),
+		events:       p.filterEvents(s.Events()),
+	})
+}
+
+func (p *attributeFilterProcessor) Shutdown(ctx context.Context) error {
+	return p.next.Shutdown(ctx)
+}
+
+func (p *attributeFilterProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+func (p *attributeFilterProcessor) filter(attrs []attribute.KeyValue) []attribute.KeyValue {
+	out := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		key := string(attr.Key)
+		if (!p.allow.empty() && !p.allow.match(key)) || p.deny.match(key) {
+			continue
+		}
+		if p.hash.match(key) {
+			attr = attribute.String(key, hashValue(attr.Value.Emit()))
+		}
+		out = append(out, attr)
+	}
+	return out
+}
+
+func (p *attributeFilterProcessor) filterEvents(events []trace.Event) []trace.Event {
+	if len(events) == 0 {
+		return events
+	}
+	out := make([]trace.Event, len(events))
+	for i, e := range events {
+		e.Attributes = p.filter(e.Attributes)
+		out[i] = e
+	}
+	return out
+}
+
+// filteredSpan overrides the attributes of an ended span
+type filteredSpan struct {
+	trace.ReadOnlySpan
+	attrs  []attribute.KeyValue
+	events []trace.Event
+}
+
+func (s *filteredSpan) Attributes() []attribute.KeyValue { return s.attrs }
+func (s *filteredSpan) Events() []trace.Event            { return s.events }
+
+type keyMatcher struct {
+	exact    map[string]struct{}
+	prefixes []string
+}
+
+func newKeyMatcher(keys []string) keyMatcher {
+	m := keyMatcher{exact: make(map[string]struct{}, len(keys))}
+	for _, k := range keys {
+		if prefix, ok := strings.CutSuffix(k, "*"); ok {
+			m.prefixes = append(m.prefixes, prefix)
+			continue
+		}
+		m.exact[k] = struct{}{}
+	}
+	return m
+}
+
+func (m keyMatcher) empty() bool {
+	return len(m.exact) == 0 && len(m.prefixes) == 0
+}
+
+func (m keyMatcher) match(key string) bool {
+	if _, ok := m.exact[key]; ok {
+		return true
+	}
+	for _, prefix := range m.prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func hashValue(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,77 @@
+package xtrace
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestAttributeFilterProcessor(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  AttributeFilterConfig
+		want map[string]string // key -> value, "#" means hashed
+	}{
+		{
+			name: "no rules",
+			cfg:  AttributeFilterConfig{},
+			want: map[string]string{"db.statement": "SELECT 1", "http.request.body": "{}", "http.method": "GET"},
+		},
+		{
+			name: "deny and hash",
+			cfg:  AttributeFilterConfig{Deny: []string{"http.request.*"}, Hash: []string{"db.statement"}},
+			want: map[string]string{"db.statement": "#", "http.method": "GET"},
+		},
+		{
+			name: "allow list",
+			cfg:  AttributeFilterConfig{Allow: []string{"http.method"}},
+			want: map[string]string{"http.method": "GET"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracetest.NewInMemoryExporter()
+			tp := trace.NewTracerProvider(trace.WithSpanProcessor(
+				NewAttributeFilterProcessor(tt.cfg, trace.NewSimpleSpanProcessor(exporter)),
+			))
+
+			_, span := tp.Tracer("test").Start(context.Background(), "query")
+			span.SetAttributes(
+				attribute.String("db.statement", "SELECT 1"),
+				attribute.String("http.request.body", "{}"),
+				attribute.String("http.method", "GET"),
+			)
+			span.End()
+			_ = tp.ForceFlush(context.Background())
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("exported %d spans, want 1", len(spans))
+			}
+
+			got := make(map[string]string)
+			for _, attr := range spans[0].Attributes {
+				got[string(attr.Key)] = attr.Value.AsString()
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("attributes = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if v == "#" {
+					if !strings.HasPrefix(got[k], "sha256:") {
+						t.Fatalf("%s = %q, want hashed", k, got[k])
+					}
+					continue
+				}
+				if got[k] != v {
+					t.Fatalf("%s = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
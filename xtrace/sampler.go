@@ -0,0 +1,116 @@
+package xtrace
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/apolloconfig/agollo/v4/storage"
+	"github.com/zeromicro/go-zero/core/logx"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// RatioSampler samples a ratio of traces by trace id like
+// sdktrace.TraceIDRatioBased, with a ratio that can be changed at runtime
+type RatioSampler struct {
+	sampler atomic.Pointer[sdktrace.Sampler]
+	ratio   atomic.Uint64 // math.Float64bits
+}
+
+// NewRatioSampler creates a sampler with the initial ratio, clamped to [0, 1]
+func NewRatioSampler(ratio float64) *RatioSampler {
+	s := &RatioSampler{}
+	s.SetRatio(ratio)
+	return s
+}
+
+// SetRatio changes the ratio for the traces started from now on
+func (s *RatioSampler) SetRatio(ratio float64) {
+	ratio = min(max(ratio, 0), 1)
+	sampler := sdktrace.TraceIDRatioBased(ratio)
+	s.sampler.Store(&sampler)
+	s.ratio.Store(math.Float64bits(ratio))
+}
+
+// Ratio returns the current ratio
+func (s *RatioSampler) Ratio() float64 {
+	return math.Float64frombits(s.ratio.Load())
+}
+
+func (s *RatioSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	return (*s.sampler.Load()).ShouldSample(p)
+}
+
+func (s *RatioSampler) Description() string {
+	return fmt.Sprintf("RatioSampler{%g}", s.Ratio())
+}
+
+// SamplingSource 采样率的配置来源，*apollo.Client 已实现
+type SamplingSource interface {
+	GetValue(namespace, key string) (string, error)
+	AddChangeListener(listener storage.ChangeListener)
+}
+
+// ApolloSampler returns a sampler whose ratio is read from key of namespace and
+// reloaded when it changes, so sampling can be raised to 1 during an incident
+// without a restart. fallback applies while the key is missing or invalid.
+// Wrap it for the tracer provider so child spans follow their parent:
+//
+//	sdktrace.WithSampler(sdktrace.ParentBased(xtrace.ApolloSampler(client, "application", "trace.sampler", 0.1)))
+func ApolloSampler(source SamplingSource, namespace, key string, fallback float64) *RatioSampler {
+	w := &samplingWatcher{
+		source:    source,
+		namespace: namespace,
+		key:       key,
+		fallback:  fallback,
+		sampler:   NewRatioSampler(fallback),
+	}
+	w.reload()
+	source.AddChangeListener(w)
+	return w.sampler
+}
+
+type samplingWatcher struct {
+	source    SamplingSource
+	namespace string
+	key       string
+	fallback  float64
+	sampler   *RatioSampler
+}
+
+func (w *samplingWatcher) reload() {
+	ratio := w.fallback
+	value, err := w.source.GetValue(w.namespace, w.key)
+	switch {
+	case err != nil:
+		logx.Errorf("xtrace: read sampling ratio %s/%s failed, use %g: %v", w.namespace, w.key, ratio, err)
+	case strings.TrimSpace(value) == "":
+	default:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			logx.Errorf("xtrace: invalid sampling ratio %q in %s/%s, use %g", value, w.namespace, w.key, ratio)
+			break
+		}
+		ratio = parsed
+	}
+
+	if old := w.sampler.Ratio(); old != ratio {
+		logx.Infof("xtrace: sampling ratio changed from %g to %g", old, ratio)
+		w.sampler.SetRatio(ratio)
+	}
+}
+
+// OnChange 实现 storage.ChangeListener
+func (w *samplingWatcher) OnChange(event *storage.ChangeEvent) {
+	if event == nil || event.Namespace != w.namespace {
+		return
+	}
+	if _, ok := event.Changes[w.key]; ok {
+		w.reload()
+	}
+}
+
+// OnNewestChange 实现 storage.ChangeListener
+func (w *samplingWatcher) OnNewestChange(*storage.FullChangeEvent) {}
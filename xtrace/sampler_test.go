@@ -0,0 +1,94 @@
+package xtrace
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/apolloconfig/agollo/v4/storage"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"gomod.pri/golib/apollo"
+)
+
+var _ SamplingSource = (*apollo.Client)(nil)
+
+type fakeSamplingSource struct {
+	value    string
+	err      error
+	listener storage.ChangeListener
+}
+
+func (f *fakeSamplingSource) GetValue(namespace, key string) (string, error) {
+	return f.value, f.err
+}
+
+func (f *fakeSamplingSource) AddChangeListener(listener storage.ChangeListener) {
+	f.listener = listener
+}
+
+func (f *fakeSamplingSource) change(namespace, key, value string) {
+	f.value = value
+	event := &storage.ChangeEvent{Changes: map[string]*storage.ConfigChange{key: {NewValue: value}}}
+	event.Namespace = namespace
+	f.listener.OnChange(event)
+}
+
+func TestApolloSampler(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		err     error
+		want    float64
+	}{
+		{name: "configured", initial: "0.25", want: 0.25},
+		{name: "missing key", initial: "", want: 0.1},
+		{name: "invalid", initial: "lots", want: 0.1},
+		{name: "out of range", initial: "1.5", want: 0.1},
+		{name: "namespace error", err: errors.New("namespace not found"), want: 0.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &fakeSamplingSource{value: tt.initial, err: tt.err}
+			s := ApolloSampler(src, "application", "trace.sampler", 0.1)
+			if got := s.Ratio(); got != tt.want {
+				t.Fatalf("Ratio() = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApolloSampler_HotReload(t *testing.T) {
+	src := &fakeSamplingSource{value: "0"}
+	s := ApolloSampler(src, "application", "trace.sampler", 0.1)
+
+	steps := []struct {
+		namespace, key, value string
+		want                  float64
+	}{
+		{namespace: "application", key: "trace.sampler", value: "1", want: 1},
+		{namespace: "application", key: "other.key", value: "0.5", want: 1},
+		{namespace: "private", key: "trace.sampler", value: "0.5", want: 1},
+		{namespace: "application", key: "trace.sampler", value: "0.01", want: 0.01},
+		{namespace: "application", key: "trace.sampler", value: "", want: 0.1},
+	}
+	for _, step := range steps {
+		src.change(step.namespace, step.key, step.value)
+		if got := s.Ratio(); got != step.want {
+			t.Fatalf("after %s/%s=%q Ratio() = %g, want %g", step.namespace, step.key, step.value, got, step.want)
+		}
+	}
+}
+
+func TestRatioSampler_Decision(t *testing.T) {
+	s := NewRatioSampler(0)
+	params := sdktrace.SamplingParameters{TraceID: trace.TraceID{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}}
+	if got := s.ShouldSample(params).Decision; got != sdktrace.Drop {
+		t.Fatalf("ratio 0 decision = %v, want Drop", got)
+	}
+	s.SetRatio(1)
+	if got := s.ShouldSample(params).Decision; got != sdktrace.RecordAndSample {
+		t.Fatalf("ratio 1 decision = %v, want RecordAndSample", got)
+	}
+}
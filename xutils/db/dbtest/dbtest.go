@@ -0,0 +1,189 @@
+// Package dbtest provides a fast, isolated database for model-layer tests: a
+// private in-memory sqlite database per test, or a throwaway MySQL database
+// when DBTEST_MYSQL_DSN is set, with the schema applied and tracing enabled.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/zeromicro/go-zero/core/stores/sqlx"
+	semconv "go.opentelemetry.io/otel/semconv/v1.30.0"
+	_ "modernc.org/sqlite"
+
+	"gomod.pri/golib/xutils/db"
+)
+
+// MySQLDSNEnv names the environment variable that switches NewTestConn to a
+// MySQL server, e.g. root:root@tcp(127.0.0.1:3306)/ in CI
+const MySQLDSNEnv = "DBTEST_MYSQL_DSN"
+
+var seq atomic.Int64
+
+type options struct {
+	mysqlDSN string
+	dbOpts   []db.Option
+}
+
+// Option configures NewTestConn
+type Option func(*options)
+
+// WithMySQL runs the test on the MySQL server at dsn instead of sqlite,
+// overriding DBTEST_MYSQL_DSN. Each test gets its own database, dropped on cleanup
+func WithMySQL(dsn string) Option {
+	return func(o *options) {
+		o.mysqlDSN = dsn
+	}
+}
+
+// WithDBOptions wraps the connection like db.GetDB, e.g. db.WithStatementTimeout
+func WithDBOptions(opts ...db.Option) Option {
+	return func(o *options) {
+		o.dbOpts = append(o.dbOpts, opts...)
+	}
+}
+
+// NewTestConn returns a traced connection to a database private to t with the
+// *.sql files at the root of schema applied in name order, nil schema applies
+// nothing. On sqlite the MySQL DDL of goctl models is translated, see SQLiteDDL.
+// The database is removed when the test ends
+func NewTestConn(t testing.TB, schema fs.FS, opts ...Option) sqlx.SqlConn {
+	t.Helper()
+	o := &options{mysqlDSN: os.Getenv(MySQLDSNEnv)}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	stmts, err := loadSchema(schema)
+	if err != nil {
+		t.Fatalf("dbtest: load schema: %v", err)
+	}
+
+	var conn sqlx.SqlConn
+	if o.mysqlDSN != "" {
+		conn = newMySQL(t, o)
+	} else {
+		conn = newSQLite(t, o)
+		for i, stmt := range stmts {
+			stmts[i] = SQLiteDDL(stmt)
+		}
+	}
+
+	ctx := context.Background()
+	for _, stmt := range stmts {
+		if stmt == "" {
+			continue
+		}
+		if _, err := conn.ExecCtx(ctx, stmt); err != nil {
+			t.Fatalf("dbtest: apply schema: %v\n%s", err, stmt)
+		}
+	}
+	return conn
+}
+
+func newSQLite(t testing.TB, o *options) sqlx.SqlConn {
+	t.Helper()
+	// a named shared cache keeps one database across the pool, private to the test
+	dsn := fmt.Sprintf("file:%s_%d?mode=memory&cache=shared&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)",
+		dbName(t), seq.Add(1))
+
+	// the database lives as long as a connection to it is open, the pool may close idle ones
+	keeper, err := sql.Open("sqlite", dsn)
+	if err == nil {
+		err = keeper.Ping()
+	}
+	if err != nil {
+		t.Fatalf("dbtest: open sqlite: %v", err)
+	}
+	t.Cleanup(func() { keeper.Close() })
+
+	conn, err := db.NewConn("sqlite", dsn, semconv.DBSystemNameSqlite, o.dbOpts...)
+	if err != nil {
+		t.Fatalf("dbtest: %v", err)
+	}
+	closeOnCleanup(t, conn)
+	return conn
+}
+
+func newMySQL(t testing.TB, o *options) sqlx.SqlConn {
+	t.Helper()
+	cfg, err := mysql.ParseDSN(o.mysqlDSN)
+	if err != nil {
+		t.Fatalf("dbtest: parse %s: %v", MySQLDSNEnv, err)
+	}
+	cfg.DBName = ""
+	admin, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		t.Fatalf("dbtest: open mysql: %v", err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	name := fmt.Sprintf("dbtest_%s_%d_%d", dbName(t), time.Now().UnixNano()%1e6, seq.Add(1))
+	if len(name) > 64 {
+		name = name[len(name)-64:]
+	}
+	if _, err = admin.Exec("CREATE DATABASE `" + name + "`"); err != nil {
+		t.Fatalf("dbtest: create database: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP DATABASE IF EXISTS `" + name + "`"); err != nil {
+			t.Logf("dbtest: drop database %s: %v", name, err)
+		}
+	})
+
+	cfg.DBName = name
+	cfg.ParseTime = true
+	conn, err := db.NewConn("mysql", cfg.FormatDSN(), semconv.DBSystemNameMySQL, o.dbOpts...)
+	if err != nil {
+		t.Fatalf("dbtest: %v", err)
+	}
+	closeOnCleanup(t, conn)
+	return conn
+}
+
+// closeOnCleanup closes the pool, go-zero caches it by dsn which is unique per test
+func closeOnCleanup(t testing.TB, conn sqlx.SqlConn) {
+	t.Cleanup(func() {
+		if raw, err := conn.RawDB(); err == nil {
+			raw.Close()
+		}
+	})
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+func dbName(t testing.TB) string {
+	return strings.ToLower(unsafeName.ReplaceAllString(t.Name(), "_"))
+}
+
+// loadSchema returns the statements of the *.sql files at the root of schema
+func loadSchema(schema fs.FS) ([]string, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	files, err := fs.Glob(schema, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var stmts []string
+	for _, file := range files {
+		data, err := fs.ReadFile(schema, file)
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, SplitStatements(string(data))...)
+	}
+	return stmts, nil
+}
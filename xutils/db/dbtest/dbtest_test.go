@@ -0,0 +1,128 @@
+package dbtest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// goctl style MySQL schema
+const userSchema = "SET NAMES utf8mb4;\n" +
+	"-- users of the shop\n" +
+	"CREATE TABLE `user` (\n" +
+	"  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n" +
+	"  `name` varchar(64) NOT NULL DEFAULT '' COMMENT 'display name; shown in orders',\n" +
+	"  `email` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL,\n" +
+	"  `status` enum('active','banned') NOT NULL DEFAULT 'active',\n" +
+	"  `create_time` datetime(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),\n" +
+	"  `update_time` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n" +
+	"  PRIMARY KEY (`id`),\n" +
+	"  UNIQUE KEY `uk_email` (`email`),\n" +
+	"  KEY `idx_name` (`name`)\n" +
+	") ENGINE=InnoDB AUTO_INCREMENT=7 DEFAULT CHARSET=utf8mb4 COMMENT='users';\n"
+
+type user struct {
+	ID     int64  `db:"id"`
+	Name   string `db:"name"`
+	Email  string `db:"email"`
+	Status string `db:"status"`
+}
+
+func TestNewTestConn(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	schema := fstest.MapFS{
+		"001_user.sql": {Data: []byte(userSchema)},
+		"002_seed.sql": {Data: []byte("INSERT INTO `user` (`name`, `email`) VALUES ('ann', 'ann@example.com');")},
+		"README.md":    {Data: []byte("not applied")},
+	}
+	conn := NewTestConn(t, schema, WithMySQL(""))
+	ctx := context.Background()
+
+	res, err := conn.ExecCtx(ctx, "INSERT INTO `user` (`name`, `email`) VALUES (?, ?)", "bob", "bob@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := res.LastInsertId(); id != 2 {
+		t.Errorf("LastInsertId() = %d, want 2", id)
+	}
+	if _, err = conn.ExecCtx(ctx, "INSERT INTO `user` (`name`, `email`) VALUES (?, ?)", "bob", "bob@example.com"); err == nil {
+		t.Error("duplicate email was accepted")
+	}
+
+	var users []user
+	if err = conn.QueryRowsCtx(ctx, &users, "SELECT `id`, `name`, `email`, `status` FROM `user` ORDER BY `id`"); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0] != (user{ID: 1, Name: "ann", Email: "ann@example.com", Status: "active"}) {
+		t.Fatalf("users = %+v", users)
+	}
+
+	traced := false
+	for _, s := range recorder.Ended() {
+		for _, kv := range s.Attributes() {
+			if kv.Key == "db.system.name" && kv.Value.AsString() == "sqlite" {
+				traced = true
+			}
+		}
+	}
+	if !traced {
+		t.Error("no sqlite spans recorded")
+	}
+}
+
+func TestNewTestConnIsolated(t *testing.T) {
+	schema := fstest.MapFS{"schema.sql": {Data: []byte("CREATE TABLE kv (k TEXT PRIMARY KEY, v TEXT);")}}
+	for _, name := range []string{"a", "b"} {
+		t.Run(name, func(t *testing.T) {
+			conn := NewTestConn(t, schema, WithMySQL(""))
+			if _, err := conn.Exec("INSERT INTO kv VALUES ('k', ?)", name); err != nil {
+				t.Fatalf("insert into a fresh database: %v", err)
+			}
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	got := SplitStatements("/* header; */ SELECT 'a;b';\n-- note; here\nSELECT \"c\\\";\" # trailing;\n;;SELECT 1")
+	want := []string{"SELECT 'a;b'", "SELECT \"c\\\";\"", "SELECT 1"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("SplitStatements() = %q, want %q", got, want)
+	}
+}
+
+func TestSQLiteDDL(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt string
+		want string
+	}{
+		{name: "session", stmt: "SET FOREIGN_KEY_CHECKS = 0", want: ""},
+		{name: "insert", stmt: "INSERT INTO t VALUES (1)", want: "INSERT INTO t VALUES (1)"},
+		{
+			name: "composite key keeps the auto increment column plain",
+			stmt: "CREATE TABLE t (`id` bigint NOT NULL AUTO_INCREMENT, `tenant` int NOT NULL, PRIMARY KEY (`tenant`, `id`)) ENGINE=InnoDB",
+			want: "CREATE TABLE t (\n  `id` bigint NOT NULL,\n  `tenant` int NOT NULL,\n  PRIMARY KEY (`tenant`, `id`)\n)",
+		},
+		{
+			name: "unique key",
+			stmt: "CREATE TABLE IF NOT EXISTS t (a int, b int, UNIQUE INDEX uk (a, b), FULLTEXT KEY ft (b))",
+			want: "CREATE TABLE IF NOT EXISTS t (\n  a int,\n  b int,\n  UNIQUE (a, b)\n)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SQLiteDDL(tt.stmt); got != tt.want {
+				t.Errorf("SQLiteDDL() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package dbtest
+
+import (
+	"regexp"
+	"strings"
+)
+
+// SplitStatements splits a SQL script on the semicolons outside quotes and
+// comments, dropping comments and empty statements
+func SplitStatements(script string) []string {
+	var (
+		stmts []string
+		cur   strings.Builder
+		quote byte
+	)
+	flush := func() {
+		if stmt := strings.TrimSpace(cur.String()); stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+		cur.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case quote != 0:
+			cur.WriteByte(c)
+			if c == '\\' && quote != '`' && i+1 < len(script) {
+				i++
+				cur.WriteByte(script[i])
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+			cur.WriteByte(c)
+		case c == '-' && strings.HasPrefix(script[i:], "-- "), c == '#':
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+				cur.WriteByte('\n')
+			} else {
+				i = len(script)
+			}
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			if end := strings.Index(script[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(script)
+			}
+		case c == ';':
+			flush()
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	flush()
+	return stmts
+}
+
+var (
+	// MySQL session statements without a sqlite counterpart
+	sessionStmt     = regexp.MustCompile(`(?i)^(SET|LOCK\s+TABLES|UNLOCK\s+TABLES|USE)\b`)
+	createTable     = regexp.MustCompile(`(?is)^CREATE\s+TABLE\b`)
+	columnComment   = regexp.MustCompile(`(?i)\s+COMMENT\s+'(?:[^'\\]|\\.|'')*'`)
+	onUpdate        = regexp.MustCompile(`(?i)\s+ON\s+UPDATE\s+CURRENT_TIMESTAMP(\(\d*\))?`)
+	timestampPrec   = regexp.MustCompile(`(?i)CURRENT_TIMESTAMP\(\d*\)`)
+	charset         = regexp.MustCompile(`(?i)\s+(CHARACTER\s+SET|CHARSET|COLLATE)\s+\w+`)
+	enumType        = regexp.MustCompile(`(?i)\b(ENUM|SET)\s*\((?:[^()'\\]|'(?:[^'\\]|\\.|'')*')*\)`)
+	autoIncrement   = regexp.MustCompile(`(?i)\bAUTO_INCREMENT\b`)
+	uniqueKey       = regexp.MustCompile(`(?is)^UNIQUE\s+(?:KEY|INDEX)\s+\S+\s*(\(.*\))`)
+	plainKey        = regexp.MustCompile(`(?i)^(KEY|INDEX|FULLTEXT|SPATIAL)\b`)
+	primaryKeyCols  = regexp.MustCompile(`(?is)^PRIMARY\s+KEY\s*\((.*)\)`)
+	identifierQuote = strings.NewReplacer("`", "", `"`, "", " ", "")
+)
+
+// SQLiteDDL translates the MySQL DDL goctl models are generated from into
+// sqlite: table options, comments, charsets and ON UPDATE clauses are dropped,
+// ENUM/SET columns become TEXT, secondary indexes are dropped, UNIQUE KEY
+// becomes a UNIQUE constraint and the AUTO_INCREMENT column becomes
+// INTEGER PRIMARY KEY AUTOINCREMENT. Session statements such as SET NAMES
+// translate to "", other statements are returned as is
+func SQLiteDDL(stmt string) string {
+	stmt = strings.TrimSpace(stmt)
+	if sessionStmt.MatchString(stmt) {
+		return ""
+	}
+	if !createTable.MatchString(stmt) {
+		return stmt
+	}
+	open, end := strings.IndexByte(stmt, '('), strings.LastIndexByte(stmt, ')')
+	if open < 0 || end < open {
+		return stmt
+	}
+
+	var (
+		defs    []string
+		autoCol string
+		autoIdx = -1
+		pkIdx   = -1
+	)
+	for _, def := range splitDefinitions(stmt[open+1 : end]) {
+		def = strings.TrimSpace(def)
+		switch {
+		case def == "":
+			continue
+		case plainKey.MatchString(def):
+			continue
+		case uniqueKey.MatchString(def):
+			def = "UNIQUE " + uniqueKey.FindStringSubmatch(def)[1]
+		case primaryKeyCols.MatchString(def):
+			pkIdx = len(defs)
+		default:
+			def = columnComment.ReplaceAllString(def, "")
+			def = onUpdate.ReplaceAllString(def, "")
+			def = timestampPrec.ReplaceAllString(def, "CURRENT_TIMESTAMP")
+			def = charset.ReplaceAllString(def, "")
+			def = enumType.ReplaceAllString(def, "TEXT")
+			if autoIncrement.MatchString(def) {
+				autoCol, autoIdx = strings.Fields(def)[0], len(defs)
+				def = strings.Join(strings.Fields(autoIncrement.ReplaceAllString(def, "")), " ")
+			}
+		}
+		defs = append(defs, def)
+	}
+
+	// sqlite only auto increments an INTEGER PRIMARY KEY column
+	if autoIdx >= 0 {
+		var pkCols string
+		if pkIdx >= 0 {
+			pkCols = identifierQuote.Replace(primaryKeyCols.FindStringSubmatch(defs[pkIdx])[1])
+		}
+		if pkIdx < 0 || pkCols == identifierQuote.Replace(autoCol) {
+			defs[autoIdx] = autoCol + " INTEGER PRIMARY KEY AUTOINCREMENT"
+			if pkIdx >= 0 {
+				defs = append(defs[:pkIdx], defs[pkIdx+1:]...)
+			}
+		}
+	}
+	return strings.TrimSpace(stmt[:open]) + " (\n  " + strings.Join(defs, ",\n  ") + "\n)"
+}
+
+// splitDefinitions splits the body of CREATE TABLE on the commas outside
+// parentheses and quotes
+func splitDefinitions(body string) []string {
+	var (
+		defs  []string
+		depth int
+		quote byte
+		start int
+	)
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			defs = append(defs, body[start:i])
+			start = i + 1
+		}
+	}
+	return append(defs, body[start:])
+}
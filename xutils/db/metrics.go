@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/metric"
+	"github.com/zeromicro/go-zero/core/stores/sqlx"
+
+	"gomod.pri/golib/xtrace"
+)
+
+// statementDuration records statement latency with trace id exemplars, so a
+// latency spike on the dashboard links to example traces
+var statementDuration = xtrace.NewHistogramVec(&metric.HistogramVecOpts{
+	Namespace: "db",
+	Subsystem: "client",
+	Name:      "duration_ms",
+	Help:      "SQL statement latency in milliseconds, partitioned by command and result.",
+	Labels:    []string{"command", "result"},
+	Buckets:   []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
+})
+
+// metricsConn records the duration of every statement of a sqlx.SqlConn
+type metricsConn struct {
+	metricsSession
+	conn sqlx.SqlConn
+}
+
+func newMetricsConn(conn sqlx.SqlConn) sqlx.SqlConn {
+	return &metricsConn{metricsSession: metricsSession{session: conn}, conn: conn}
+}
+
+func (c *metricsConn) RawDB() (*sql.DB, error) {
+	return c.conn.RawDB()
+}
+
+func (c *metricsConn) Transact(fn func(sqlx.Session) error) error {
+	return c.conn.Transact(func(session sqlx.Session) error {
+		return fn(&metricsSession{session: session})
+	})
+}
+
+func (c *metricsConn) TransactCtx(ctx context.Context, fn func(context.Context, sqlx.Session) error) error {
+	return c.conn.TransactCtx(ctx, func(ctx context.Context, session sqlx.Session) error {
+		return fn(ctx, &metricsSession{session: session})
+	})
+}
+
+// metricsSession observes each statement
+type metricsSession struct {
+	session sqlx.Session
+}
+
+func (s *metricsSession) Exec(query string, args ...any) (sql.Result, error) {
+	return s.ExecCtx(context.Background(), query, args...)
+}
+
+func (s *metricsSession) ExecCtx(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	start := time.Now()
+	res, err := s.session.ExecCtx(ctx, query, args...)
+	observeStatement(ctx, query, err, start)
+	return res, err
+}
+
+func (s *metricsSession) Prepare(query string) (sqlx.StmtSession, error) {
+	return s.session.Prepare(query)
+}
+
+func (s *metricsSession) PrepareCtx(ctx context.Context, query string) (sqlx.StmtSession, error) {
+	return s.session.PrepareCtx(ctx, query)
+}
+
+func (s *metricsSession) QueryRow(v any, query string, args ...any) error {
+	return s.QueryRowCtx(context.Background(), v, query, args...)
+}
+
+func (s *metricsSession) QueryRowCtx(ctx context.Context, v any, query string, args ...any) error {
+	start := time.Now()
+	err := s.session.QueryRowCtx(ctx, v, query, args...)
+	observeStatement(ctx, query, err, start)
+	return err
+}
+
+func (s *metricsSession) QueryRowPartial(v any, query string, args ...any) error {
+	return s.QueryRowPartialCtx(context.Background(), v, query, args...)
+}
+
+func (s *metricsSession) QueryRowPartialCtx(ctx context.Context, v any, query string, args ...any) error {
+	start := time.Now()
+	err := s.session.QueryRowPartialCtx(ctx, v, query, args...)
+	observeStatement(ctx, query, err, start)
+	return err
+}
+
+func (s *metricsSession) QueryRows(v any, query string, args ...any) error {
+	return s.QueryRowsCtx(context.Background(), v, query, args...)
+}
+
+func (s *metricsSession) QueryRowsCtx(ctx context.Context, v any, query string, args ...any) error {
+	start := time.Now()
+	err := s.session.QueryRowsCtx(ctx, v, query, args...)
+	observeStatement(ctx, query, err, start)
+	return err
+}
+
+func (s *metricsSession) QueryRowsPartial(v any, query string, args ...any) error {
+	return s.QueryRowsPartialCtx(context.Background(), v, query, args...)
+}
+
+func (s *metricsSession) QueryRowsPartialCtx(ctx context.Context, v any, query string, args ...any) error {
+	start := time.Now()
+	err := s.session.QueryRowsPartialCtx(ctx, v, query, args...)
+	observeStatement(ctx, query, err, start)
+	return err
+}
+
+func observeStatement(ctx context.Context, query string, err error, start time.Time) {
+	result := "ok"
+	if err != nil && !errors.Is(err, sqlx.ErrNotFound) {
+		result = "error"
+	}
+	statementDuration.Observe(ctx, float64(time.Since(start).Microseconds())/1000, sqlCommand(query), result)
+}
+
+// sqlCommand returns the lowercased leading keyword of query, bounded to the
+// common commands to keep the label cardinality low
+func sqlCommand(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	switch cmd := strings.ToLower(fields[0]); cmd {
+	case "select", "insert", "update", "delete", "replace", "with":
+		return cmd
+	}
+	return "other"
+}
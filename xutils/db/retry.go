@@ -0,0 +1,213 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/zeromicro/go-zero/core/logc"
+	"github.com/zeromicro/go-zero/core/metric"
+	"github.com/zeromicro/go-zero/core/stores/sqlx"
+)
+
+const (
+	defaultRetryBackoff = 100 * time.Millisecond
+	maxRetryBackoff     = 2 * time.Second
+)
+
+// retry reasons, also used as metric labels
+const (
+	reasonBadConn  = "bad_conn"
+	reasonTimeout  = "timeout"
+	reasonFailover = "failover"
+	reasonNetwork  = "network"
+)
+
+var retryTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+	Namespace: "db",
+	Subsystem: "retry",
+	Name:      "total",
+	Help:      "How many reads were retried on transient errors, partitioned by reason and result (retry, recovered, exhausted).",
+	Labels:    []string{"reason", "result"},
+})
+
+// WithRetry retries reads (QueryRow*, QueryRows*) failing with a transient
+// error: bad connection, network timeout, or a failover (read-only / shutting
+// down server), up to maxAttempts in total. The wait starts at backoff
+// (default 100ms) and doubles per attempt, capped at 2s and at the ctx deadline.
+// Exec, Prepare and transactions are never retried, they may not be idempotent
+func WithRetry(maxAttempts int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.retryAttempts = maxAttempts
+		o.retryBackoff = backoff
+	}
+}
+
+// retryConn retries idempotent reads on a sqlx.SqlConn
+type retryConn struct {
+	sqlx.SqlConn
+	attempts int
+	backoff  time.Duration
+}
+
+func newRetryConn(conn sqlx.SqlConn, o *options) sqlx.SqlConn {
+	backoff := o.retryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+	return &retryConn{SqlConn: conn, attempts: o.retryAttempts, backoff: backoff}
+}
+
+func (c *retryConn) QueryRow(v any, query string, args ...any) error {
+	return c.QueryRowCtx(context.Background(), v, query, args...)
+}
+
+func (c *retryConn) QueryRowCtx(ctx context.Context, v any, query string, args ...any) error {
+	return c.do(ctx, func() error {
+		return c.SqlConn.QueryRowCtx(ctx, v, query, args...)
+	})
+}
+
+func (c *retryConn) QueryRowPartial(v any, query string, args ...any) error {
+	return c.QueryRowPartialCtx(context.Background(), v, query, args...)
+}
+
+func (c *retryConn) QueryRowPartialCtx(ctx context.Context, v any, query string, args ...any) error {
+	return c.do(ctx, func() error {
+		return c.SqlConn.QueryRowPartialCtx(ctx, v, query, args...)
+	})
+}
+
+func (c *retryConn) QueryRows(v any, query string, args ...any) error {
+	return c.QueryRowsCtx(context.Background(), v, query, args...)
+}
+
+func (c *retryConn) QueryRowsCtx(ctx context.Context, v any, query string, args ...any) error {
+	reset := rowsReset(v)
+	return c.do(ctx, func() error {
+		reset()
+		return c.SqlConn.QueryRowsCtx(ctx, v, query, args...)
+	})
+}
+
+func (c *retryConn) QueryRowsPartial(v any, query string, args ...any) error {
+	return c.QueryRowsPartialCtx(context.Background(), v, query, args...)
+}
+
+func (c *retryConn) QueryRowsPartialCtx(ctx context.Context, v any, query string, args ...any) error {
+	reset := rowsReset(v)
+	return c.do(ctx, func() error {
+		reset()
+		return c.SqlConn.QueryRowsPartialCtx(ctx, v, query, args...)
+	})
+}
+
+// rowsReset returns a func restoring the slice v points to as it was before
+// the first attempt. sqlx appends scanned rows to it, so a retry after the
+// connection dropped mid-stream would return the first rows twice
+func rowsReset(v any) func() {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return func() {}
+	}
+	dst := rv.Elem()
+	orig := reflect.New(dst.Type()).Elem()
+	orig.Set(dst)
+	return func() {
+		dst.Set(orig)
+	}
+}
+
+func (c *retryConn) do(ctx context.Context, fn func() error) error {
+	var reason string
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			if reason != "" {
+				retryTotal.Inc(reason, "recovered")
+			}
+			return nil
+		}
+
+		r, ok := retryReason(err)
+		if !ok {
+			return err
+		}
+		reason = r
+		if attempt >= c.attempts {
+			retryTotal.Inc(reason, "exhausted")
+			return err
+		}
+
+		wait := c.wait(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			retryTotal.Inc(reason, "exhausted")
+			return err
+		}
+		logc.Infof(ctx, "db: retrying read after %s error (attempt %d/%d): %v", reason, attempt, c.attempts, err)
+		retryTotal.Inc(reason, "retry")
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// wait returns the backoff before the next attempt with up to 20% jitter,
+// so clients don't reconnect to the new primary in lockstep
+func (c *retryConn) wait(attempt int) time.Duration {
+	d := c.backoff << (attempt - 1)
+	if d <= 0 || d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	return d - time.Duration(rand.Int64N(int64(d)/5+1))
+}
+
+// retryReason reports whether err is transient and safe to retry for a read
+func retryReason(err error) (string, bool) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// the caller's (or statement) deadline, not the connection
+		return "", false
+	}
+
+	var myErr *mysql.MySQLError
+	if errors.As(err, &myErr) {
+		switch myErr.Number {
+		case 1290, // ER_OPTION_PREVENTS_STATEMENT, --read-only on the demoted primary
+			1792, // ER_CANT_EXECUTE_IN_READ_ONLY_TRANSACTION
+			1836, // ER_READ_ONLY_MODE
+			1053, // ER_SERVER_SHUTDOWN
+			1927: // ER_CONNECTION_KILLED
+			return reasonFailover, true
+		case 2006, 2013: // CR_SERVER_GONE_ERROR, CR_SERVER_LOST
+			return reasonBadConn, true
+		}
+		return "", false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return reasonBadConn, true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return reasonTimeout, true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) {
+		return reasonNetwork, true
+	}
+	return "", false
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/zeromicro/go-zero/core/stores/sqlx"
+)
+
+type flakyConn struct {
+	sqlx.SqlConn
+	errs  []error // returned in order, nil once exhausted
+	calls int
+}
+
+func (c *flakyConn) QueryRowCtx(ctx context.Context, v any, query string, args ...any) error {
+	c.calls++
+	if c.calls <= len(c.errs) {
+		return c.errs[c.calls-1]
+	}
+	return nil
+}
+
+// QueryRowsCtx appends rows to v like sqlx does, failing after the first
+// row while errs are left
+func (c *flakyConn) QueryRowsCtx(ctx context.Context, v any, query string, args ...any) error {
+	c.calls++
+	rows := v.(*[]int)
+	for i := 1; i <= 3; i++ {
+		*rows = append(*rows, i)
+		if c.calls <= len(c.errs) {
+			return c.errs[c.calls-1]
+		}
+	}
+	return nil
+}
+
+type timeoutErr struct{}
+
+func (timeoutErr) Error() string   { return "i/o timeout" }
+func (timeoutErr) Timeout() bool   { return true }
+func (timeoutErr) Temporary() bool { return true }
+
+func TestRetryReason(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		reason string
+	}{
+		{name: "bad conn", err: driver.ErrBadConn, reason: reasonBadConn},
+		{name: "invalid conn", err: fmt.Errorf("query: %w", mysql.ErrInvalidConn), reason: reasonBadConn},
+		{name: "unexpected eof", err: io.ErrUnexpectedEOF, reason: reasonBadConn},
+		{name: "read only", err: &mysql.MySQLError{Number: 1290}, reason: reasonFailover},
+		{name: "server shutdown", err: &mysql.MySQLError{Number: 1053}, reason: reasonFailover},
+		{name: "net timeout", err: &net.OpError{Op: "read", Err: timeoutErr{}}, reason: reasonTimeout},
+		{name: "conn refused", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, reason: reasonNetwork},
+		{name: "duplicate key", err: &mysql.MySQLError{Number: 1062}},
+		{name: "not found", err: sqlx.ErrNotFound},
+		{name: "ctx deadline", err: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ok := retryReason(tt.err)
+			if ok != (tt.reason != "") || reason != tt.reason {
+				t.Errorf("retryReason(%v) = %q, %v, want %q", tt.err, reason, ok, tt.reason)
+			}
+		})
+	}
+}
+
+func TestRetryConn(t *testing.T) {
+	failover := &mysql.MySQLError{Number: 1836, Message: "read only"}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		attempts  int
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "success", attempts: 3, wantCalls: 1},
+		{name: "recovers", errs: []error{driver.ErrBadConn, failover}, attempts: 3, wantCalls: 3},
+		{name: "exhausted", errs: []error{failover, failover, failover}, attempts: 2, wantErr: failover, wantCalls: 2},
+		{name: "not retryable", errs: []error{sqlx.ErrNotFound}, attempts: 3, wantErr: sqlx.ErrNotFound, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &flakyConn{errs: tt.errs}
+			conn := newRetryConn(fake, &options{retryAttempts: tt.attempts, retryBackoff: time.Millisecond})
+
+			err := conn.QueryRow(nil, "SELECT 1")
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if fake.calls != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", fake.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryConn_Deadline(t *testing.T) {
+	fake := &flakyConn{errs: []error{driver.ErrBadConn, driver.ErrBadConn}}
+	conn := newRetryConn(fake, &options{retryAttempts: 3, retryBackoff: time.Second})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := conn.QueryRowCtx(ctx, nil, "SELECT 1"); !errors.Is(err, driver.ErrBadConn) {
+		t.Fatalf("err = %v, want %v", err, driver.ErrBadConn)
+	}
+	if fake.calls != 1 {
+		t.Errorf("calls = %d, want 1", fake.calls)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("waited %s for a backoff that can't fit the deadline", elapsed)
+	}
+}
+
+func TestRetryConn_RowsFailMidStream(t *testing.T) {
+	tests := []struct {
+		name string
+		dst  []int
+		want []int
+	}{
+		{name: "nil slice", want: []int{1, 2, 3}},
+		{name: "rows kept", dst: make([]int, 1, 8), want: []int{0, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &flakyConn{errs: []error{io.ErrUnexpectedEOF, mysql.ErrInvalidConn}}
+			conn := newRetryConn(fake, &options{retryAttempts: 3, retryBackoff: time.Millisecond})
+
+			rows := tt.dst
+			if err := conn.QueryRows(&rows, "SELECT id FROM orders"); err != nil {
+				t.Fatalf("QueryRows() error = %v", err)
+			}
+			if fmt.Sprint(rows) != fmt.Sprint(tt.want) || fake.calls != 3 {
+				t.Errorf("rows = %v after %d calls, want %v after 3", rows, fake.calls, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/sqlx"
+)
+
+// Option customizes the connection returned by GetDB
+type Option func(*options)
+
+type options struct {
+	maxStatementTime time.Duration
+	mysqlHint        bool
+	retryAttempts    int
+	retryBackoff     time.Duration
+}
+
+// WithStatementTimeout bounds every statement by the ctx deadline capped at max,
+// so a statement without a ctx deadline still can't run longer than max.
+// When the deadline expires the context is canceled and the driver aborts the statement.
+func WithStatementTimeout(max time.Duration) Option {
+	return func(o *options) {
+		o.maxStatementTime = max
+	}
+}
+
+// WithMaxExecutionTimeHint adds a MySQL /*+ MAX_EXECUTION_TIME(ms) */ hint to
+// SELECT statements, so the server also stops the query if the client goes away
+func WithMaxExecutionTimeHint() Option {
+	return func(o *options) {
+		o.mysqlHint = true
+	}
+}
+
+// timeoutConn enforces per-statement deadlines on a sqlx.SqlConn
+type timeoutConn struct {
+	timeoutSession
+	conn sqlx.SqlConn
+}
+
+func newTimeoutConn(conn sqlx.SqlConn, o *options) sqlx.SqlConn {
+	return &timeoutConn{
+		timeoutSession: timeoutSession{session: conn, opts: o},
+		conn:           conn,
+	}
+}
+
+func (c *timeoutConn) RawDB() (*sql.DB, error) {
+	return c.conn.RawDB()
+}
+
+func (c *timeoutConn) Transact(fn func(sqlx.Session) error) error {
+	return c.conn.Transact(func(session sqlx.Session) error {
+		return fn(&timeoutSession{session: session, opts: c.opts})
+	})
+}
+
+func (c *timeoutConn) TransactCtx(ctx context.Context, fn func(context.Context, sqlx.Session) error) error {
+	return c.conn.TransactCtx(ctx, func(ctx context.Context, session sqlx.Session) error {
+		return fn(ctx, &timeoutSession{session: session, opts: c.opts})
+	})
+}
+
+// timeoutSession applies the deadline and hint to each statement
+type timeoutSession struct {
+	session sqlx.Session
+	opts    *options
+}
+
+func (s *timeoutSession) Exec(query string, args ...any) (sql.Result, error) {
+	return s.ExecCtx(context.Background(), query, args...)
+}
+
+func (s *timeoutSession) ExecCtx(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, query, cancel := s.prepare(ctx, query)
+	defer cancel()
+	return s.session.ExecCtx(ctx, query, args...)
+}
+
+func (s *timeoutSession) Prepare(query string) (sqlx.StmtSession, error) {
+	return s.session.Prepare(query)
+}
+
+func (s *timeoutSession) PrepareCtx(ctx context.Context, query string) (sqlx.StmtSession, error) {
+	return s.session.PrepareCtx(ctx, query)
+}
+
+func (s *timeoutSession) QueryRow(v any, query string, args ...any) error {
+	return s.QueryRowCtx(context.Background(), v, query, args...)
+}
+
+func (s *timeoutSession) QueryRowCtx(ctx context.Context, v any, query string, args ...any) error {
+	ctx, query, cancel := s.prepare(ctx, query)
+	defer cancel()
+	return s.session.QueryRowCtx(ctx, v, query, args...)
+}
+
+func (s *timeoutSession) QueryRowPartial(v any, query string, args ...any) error {
+	return s.QueryRowPartialCtx(context.Background(), v, query, args...)
+}
+
+func (s *timeoutSession) QueryRowPartialCtx(ctx context.Context, v any, query string, args ...any) error {
+	ctx, query, cancel := s.prepare(ctx, query)
+	defer cancel()
+	return s.session.QueryRowPartialCtx(ctx, v, query, args...)
+}
+
+func (s *timeoutSession) QueryRows(v any, query string, args ...any) error {
+	return s.QueryRowsCtx(context.Background(), v, query, args...)
+}
+
+func (s *timeoutSession) QueryRowsCtx(ctx context.Context, v any, query string, args ...any) error {
+	ctx, query, cancel := s.prepare(ctx, query)
+	defer cancel()
+	return s.session.QueryRowsCtx(ctx, v, query, args...)
+}
+
+func (s *timeoutSession) QueryRowsPartial(v any, query string, args ...any) error {
+	return s.QueryRowsPartialCtx(context.Background(), v, query, args...)
+}
+
+func (s *timeoutSession) QueryRowsPartialCtx(ctx context.Context, v any, query string, args ...any) error {
+	ctx, query, cancel := s.prepare(ctx, query)
+	defer cancel()
+	return s.session.QueryRowsPartialCtx(ctx, v, query, args...)
+}
+
+// prepare derives the statement deadline from ctx and adds the hint if enabled
+func (s *timeoutSession) prepare(ctx context.Context, query string) (context.Context, string, context.CancelFunc) {
+	timeout := s.opts.maxStatementTime
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); timeout <= 0 || left < timeout {
+			timeout = left
+		}
+	}
+	if timeout <= 0 && s.opts.maxStatementTime <= 0 {
+		return ctx, query, func() {}
+	}
+
+	if s.opts.mysqlHint {
+		query = addMaxExecutionTime(query, timeout)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, query, cancel
+}
+
+// addMaxExecutionTime inserts a MAX_EXECUTION_TIME optimizer hint after the
+// leading SELECT keyword; other statements are returned unchanged
+func addMaxExecutionTime(query string, timeout time.Duration) string {
+	trimmed := strings.TrimLeft(query, " \t\r\n")
+	if len(trimmed) < len("SELECT") || !strings.EqualFold(trimmed[:len("SELECT")], "SELECT") {
+		return query
+	}
+	if strings.Contains(strings.ToUpper(query), "MAX_EXECUTION_TIME") {
+		return query
+	}
+
+	ms := timeout.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	offset := len(query) - len(trimmed) + len("SELECT")
+	return query[:offset] + fmt.Sprintf(" /*+ MAX_EXECUTION_TIME(%d) */", ms) + query[offset:]
+}
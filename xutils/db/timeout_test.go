@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/sqlx"
+)
+
+type recordSession struct {
+	sqlx.Session
+	query    string
+	deadline time.Duration
+}
+
+func (s *recordSession) QueryRowsCtx(ctx context.Context, v any, query string, args ...any) error {
+	s.query = query
+	if deadline, ok := ctx.Deadline(); ok {
+		s.deadline = time.Until(deadline)
+	}
+	return nil
+}
+
+func TestTimeoutSession(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        options
+		ctxTimeout  time.Duration
+		query       string
+		wantQuery   string
+		maxDeadline time.Duration // 0 means no deadline expected
+	}{
+		{
+			name:      "disabled",
+			query:     "SELECT * FROM users",
+			wantQuery: "SELECT * FROM users",
+		},
+		{
+			name:        "default max applies",
+			opts:        options{maxStatementTime: time.Second},
+			query:       "SELECT * FROM users",
+			wantQuery:   "SELECT * FROM users",
+			maxDeadline: time.Second,
+		},
+		{
+			name:        "shorter ctx deadline wins",
+			opts:        options{maxStatementTime: time.Minute},
+			ctxTimeout:  200 * time.Millisecond,
+			query:       "SELECT id FROM users",
+			wantQuery:   "SELECT id FROM users",
+			maxDeadline: 200 * time.Millisecond,
+		},
+		{
+			name:        "hint only for select",
+			opts:        options{maxStatementTime: time.Second, mysqlHint: true},
+			query:       "UPDATE users SET name = ?",
+			wantQuery:   "UPDATE users SET name = ?",
+			maxDeadline: time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordSession{}
+			s := &timeoutSession{session: rec, opts: &tt.opts}
+
+			ctx := context.Background()
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+
+			var v []string
+			if err := s.QueryRowsCtx(ctx, &v, tt.query); err != nil {
+				t.Fatalf("QueryRowsCtx() error = %v", err)
+			}
+
+			if tt.maxDeadline == 0 {
+				if rec.deadline != 0 {
+					t.Fatalf("unexpected deadline %s", rec.deadline)
+				}
+			} else if rec.deadline <= 0 || rec.deadline > tt.maxDeadline {
+				t.Fatalf("deadline = %s, want (0, %s]", rec.deadline, tt.maxDeadline)
+			}
+
+			if rec.query != tt.wantQuery {
+				t.Fatalf("query = %q, want %q", rec.query, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestAddMaxExecutionTime(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT * FROM users", "SELECT /*+ MAX_EXECUTION_TIME(1500) */ * FROM users"},
+		{"  select id FROM users", "  select /*+ MAX_EXECUTION_TIME(1500) */ id FROM users"},
+		{"SELECT /*+ MAX_EXECUTION_TIME(10) */ 1", "SELECT /*+ MAX_EXECUTION_TIME(10) */ 1"},
+		{"DELETE FROM users", "DELETE FROM users"},
+		{"SEL", "SEL"},
+	}
+
+	for _, tt := range tests {
+		if got := addMaxExecutionTime(tt.query, 1500*time.Millisecond); got != tt.expected {
+			t.Errorf("addMaxExecutionTime(%q) = %q, want %q", tt.query, got, tt.expected)
+		}
+	}
+}
@@ -20,52 +20,114 @@ var (
 	driverName string
 	once       sync.Once
 	dbCache    sync.Map // dsn -> sqlx.SqlConn
+
+	tracedDrivers sync.Map // driver -> otelsql driver name, see NewConn
+	registerMu    sync.Mutex
 )
 
 // Initialize OTel driver
 func initDriver() {
 	once.Do(func() {
 		var err error
-		driverName, err = otelsql.Register(
-			"mysql",
-			// Mark database type
-			otelsql.WithAttributes(semconv.DBSystemNameMySQL),
-			// Ensure SQL text is written to span
-			otelsql.WithSpanOptions(otelsql.SpanOptions{
-				DisableQuery:   false, // Ensure SQL query statements are recorded
-				DisableErrSkip: true,
-			}),
-			// Record SQL statements and parameters
-			otelsql.WithAttributesGetter(func(ctx context.Context, method otelsql.Method, query string, args []driver.NamedValue) []attribute.KeyValue {
-				// Build complete SQL statement
-				completeSQL := buildCompleteSQL(query, args)
-
-				attrs := []attribute.KeyValue{
-					// Record complete SQL statement
-					attribute.String("db.statement", completeSQL),
-					// Record SQL method (SELECT, INSERT, UPDATE, DELETE, etc.)
-					attribute.String("db.sql.method", string(method)),
-				}
-
-				return attrs
-			}),
-		)
+		driverName, err = registerDriver("mysql", semconv.DBSystemNameMySQL)
 		if err != nil {
 			panic(err)
 		}
 	})
 }
 
-// GetDB returns sqlx.SqlConn with tracing enabled and caches the connection
-func GetDB(dsn string) sqlx.SqlConn {
+// registerDriver wraps the base driver with otelsql, system marks the database type
+func registerDriver(base string, system attribute.KeyValue) (string, error) {
+	return otelsql.Register(
+		base,
+		// Mark database type
+		otelsql.WithAttributes(system),
+		// Ensure SQL text is written to span
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableQuery:   false, // Ensure SQL query statements are recorded
+			DisableErrSkip: true,
+		}),
+		// Record SQL statements and parameters
+		otelsql.WithAttributesGetter(func(ctx context.Context, method otelsql.Method, query string, args []driver.NamedValue) []attribute.KeyValue {
+			// Build complete SQL statement
+			completeSQL := buildCompleteSQL(query, args)
+
+			attrs := []attribute.KeyValue{
+				// Record complete SQL statement
+				attribute.String("db.statement", completeSQL),
+				// Record SQL method (SELECT, INSERT, UPDATE, DELETE, etc.)
+				attribute.String("db.sql.method", string(method)),
+			}
+
+			return attrs
+		}),
+	)
+}
+
+// GetDB returns sqlx.SqlConn with tracing and duration metrics enabled and
+// caches the connection.
+// Options wrap the cached connection, see WithStatementTimeout and WithRetry
+func GetDB(dsn string, opts ...Option) sqlx.SqlConn {
 	initDriver()
 
+	var conn sqlx.SqlConn
 	if val, ok := dbCache.Load(dsn); ok {
-		return val.(sqlx.SqlConn)
+		conn = val.(sqlx.SqlConn)
+	} else {
+		conn = newMetricsConn(sqlx.NewSqlConn(driverName, dsn))
+		dbCache.Store(dsn, conn)
 	}
+	return wrap(conn, opts)
+}
 
-	conn := sqlx.NewSqlConn(driverName, dsn)
-	dbCache.Store(dsn, conn)
+// NewConn returns an uncached sqlx.SqlConn on any registered database/sql
+// driver, traced and measured like GetDB, e.g. a sqlite database in tests.
+// system names the database in spans, e.g. semconv.DBSystemNameSqlite
+func NewConn(driver, dsn string, system attribute.KeyValue, opts ...Option) (sqlx.SqlConn, error) {
+	name, err := tracedDriver(driver, system)
+	if err != nil {
+		return nil, err
+	}
+	return wrap(newMetricsConn(sqlx.NewSqlConn(name, dsn)), opts), nil
+}
+
+// tracedDriver registers the otelsql wrapper of driver once
+func tracedDriver(driver string, system attribute.KeyValue) (string, error) {
+	if driver == "mysql" {
+		initDriver()
+		return driverName, nil
+	}
+	if name, ok := tracedDrivers.Load(driver); ok {
+		return name.(string), nil
+	}
+
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	if name, ok := tracedDrivers.Load(driver); ok {
+		return name.(string), nil
+	}
+	name, err := registerDriver(driver, system)
+	if err != nil {
+		return "", fmt.Errorf("register traced %s driver: %w", driver, err)
+	}
+	tracedDrivers.Store(driver, name)
+	return name, nil
+}
+
+// wrap applies opts to conn
+func wrap(conn sqlx.SqlConn, opts []Option) sqlx.SqlConn {
+	if len(opts) == 0 {
+		return conn
+	}
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	conn = newTimeoutConn(conn, o)
+	if o.retryAttempts > 1 {
+		// outermost, so each attempt gets its own statement deadline
+		conn = newRetryConn(conn, o)
+	}
 	return conn
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/zeromicro/go-zero/core/logx"
 	"gomod.pri/golib/notify"
+	"gomod.pri/golib/xutils/redact"
 )
 
 const (
@@ -20,6 +22,8 @@ const (
 	defaultIntervalSec  = 60
 	runtimePathSegment  = "/runtime/"
 	maxNotifyContentLen = 20000
+	defaultCloseTimeout = 5 * time.Second
+	defaultAlertTitle   = "Error Alert"
 )
 
 var (
@@ -33,16 +37,22 @@ var (
 		"microloan",
 		"gomod.pri",
 	}
+
+	serviceNameEnvKeys = []string{"SERVICE_NAME", "APP_NAME"}
+	envEnvKeys         = []string{"APP_ENV", "ENV", "GO_ENV"}
+	podNameEnvKeys     = []string{"POD_NAME", "HOSTNAME"}
 )
 
 type HookWriter struct {
 	w        io.Writer
 	msgChan  chan errorEvent
 	quit     chan struct{}
+	done     chan struct{}
 	records  map[string]*errorRecord
 	order    []string
 	mu       sync.Mutex
 	once     sync.Once
+	closeErr error
 	interval time.Duration
 	limit    int
 	config   Config
@@ -73,11 +83,13 @@ func NewHookWriter(w io.Writer, config Config) *HookWriter {
 	if intervalSec <= 0 {
 		intervalSec = defaultIntervalSec
 	}
+	config = resolveMetadata(config)
 
 	hw := &HookWriter{
 		w:        w,
 		msgChan:  make(chan errorEvent, 1000),
 		quit:     make(chan struct{}),
+		done:     make(chan struct{}),
 		records:  make(map[string]*errorRecord),
 		order:    make([]string, 0),
 		interval: time.Duration(intervalSec) * time.Second,
@@ -107,13 +119,52 @@ func (h *HookWriter) Write(p []byte) (n int, err error) {
 	return h.w.Write(p)
 }
 
+// Close flushes pending errors within the default deadline, see Shutdown
 func (h *HookWriter) Close() {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+	_ = h.Shutdown(ctx)
+}
+
+// Shutdown stops the notifier, drains queued error events and flushes them
+// synchronously until ctx is done. The underlying writer is closed afterwards
+// if it implements io.Closer, os.Stdout and os.Stderr are left open.
+// Subsequent calls return the first result.
+func (h *HookWriter) Shutdown(ctx context.Context) error {
 	h.once.Do(func() {
 		close(h.quit)
+
+		select {
+		case <-h.done:
+		case <-ctx.Done():
+			h.closeErr = fmt.Errorf("logutil: flush on close: %w", ctx.Err())
+		}
+
+		if err := closeWriter(ctx, h.w); err != nil && h.closeErr == nil {
+			h.closeErr = fmt.Errorf("logutil: close underlying writer: %w", err)
+		}
 	})
+	return h.closeErr
+}
+
+// closeWriter shuts down a wrapped HookWriter or closes an io.Closer. The
+// process std streams are shared with the rest of the program and never closed
+func closeWriter(ctx context.Context, w io.Writer) error {
+	if w == io.Writer(os.Stdout) || w == io.Writer(os.Stderr) {
+		return nil
+	}
+	switch c := w.(type) {
+	case interface{ Shutdown(context.Context) error }:
+		return c.Shutdown(ctx)
+	case io.Closer:
+		return c.Close()
+	}
+	return nil
 }
 
 func (h *HookWriter) runNotifier() {
+	defer close(h.done)
+
 	ticker := time.NewTicker(h.interval)
 	defer ticker.Stop()
 
@@ -124,12 +175,25 @@ func (h *HookWriter) runNotifier() {
 		case <-ticker.C:
 			h.flush()
 		case <-h.quit:
+			h.drain()
 			h.flush()
 			return
 		}
 	}
 }
 
+// drain consumes events still buffered in msgChan without blocking
+func (h *HookWriter) drain() {
+	for {
+		select {
+		case event := <-h.msgChan:
+			h.handleEvent(event)
+		default:
+			return
+		}
+	}
+}
+
 func (h *HookWriter) handleEvent(event errorEvent) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -159,7 +223,7 @@ func (h *HookWriter) flush() {
 	}
 
 	summaries := h.buildSummaries()
-	sendNotifyMarkdown(h.config.NotifyChannel, h.config.NotifyWebhook, h.config.NotifySecret, summaries)
+	sendNotifyMarkdown(h.config, summaries)
 
 	h.records = make(map[string]*errorRecord)
 	h.order = make([]string, 0)
@@ -198,7 +262,7 @@ func (h *HookWriter) buildSummaries() []summaryItem {
 			Line:         record.Line,
 			FuncNameFull: funcFull,
 			FuncName:     simplifyFuncName(funcFull),
-			Message:      stripANSI(record.LastMessage),
+			Message:      redact.Text(stripANSI(record.LastMessage)),
 		})
 	}
 
@@ -218,17 +282,17 @@ func (h *HookWriter) newErrorEvent(msg string) errorEvent {
 	}
 }
 
-func sendNotifyMarkdown(channel, webhook, secret string, items []summaryItem) {
+func sendNotifyMarkdown(config Config, items []summaryItem) {
 	if len(items) == 0 {
 		return
 	}
 
-	notifyChannel := parseNotifyChannel(channel)
+	notifyChannel := parseNotifyChannel(config.NotifyChannel)
 	robot, err := notify.NewNotification(notify.NotificationConfig{
 		Type: notifyChannel,
 		Config: notify.Config{
-			Webhook: webhook,
-			Secret:  secret,
+			Webhook: config.NotifyWebhook,
+			Secret:  config.NotifySecret,
 		},
 	})
 	if err != nil {
@@ -236,9 +300,9 @@ func sendNotifyMarkdown(channel, webhook, secret string, items []summaryItem) {
 		return
 	}
 
-	content := buildMarkdownCard(items)
+	content := buildMarkdownCard(config, items)
 	content = truncateContent(content)
-	if err := robot.SendCard(context.Background(), "Error Alert", content); err != nil {
+	if err := robot.SendCard(context.Background(), buildAlertTitle(config), content); err != nil {
 		logx.Errorf("[sendNotify] failed to send markdown card: %v", err)
 	}
 }
@@ -254,8 +318,64 @@ func parseNotifyChannel(channel string) notify.NotificationType {
 	}
 }
 
-func buildMarkdownCard(items []summaryItem) string {
+// resolveMetadata fills empty metadata fields from well-known env vars
+func resolveMetadata(config Config) Config {
+	if config.ServiceName == "" {
+		config.ServiceName = firstEnv(serviceNameEnvKeys)
+	}
+	if config.Env == "" {
+		config.Env = firstEnv(envEnvKeys)
+	}
+	if config.PodName == "" {
+		config.PodName = firstEnv(podNameEnvKeys)
+	}
+	return config
+}
+
+func firstEnv(keys []string) string {
+	for _, key := range keys {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// buildAlertTitle prefixes the alert title with env and service, e.g. "[prod] order-api Error Alert"
+func buildAlertTitle(config Config) string {
 	var sb strings.Builder
+	if config.Env != "" {
+		sb.WriteString("[")
+		sb.WriteString(config.Env)
+		sb.WriteString("] ")
+	}
+	if config.ServiceName != "" {
+		sb.WriteString(config.ServiceName)
+		sb.WriteString(" ")
+	}
+	sb.WriteString(defaultAlertTitle)
+	return sb.String()
+}
+
+func buildMarkdownCard(config Config, items []summaryItem) string {
+	var sb strings.Builder
+
+	hasMeta := false
+	if config.ServiceName != "" {
+		writeKVLine(&sb, "service", config.ServiceName)
+		hasMeta = true
+	}
+	if config.Env != "" {
+		writeKVLine(&sb, "env", config.Env)
+		hasMeta = true
+	}
+	if config.PodName != "" {
+		writeKVLine(&sb, "pod", config.PodName)
+		hasMeta = true
+	}
+	if hasMeta {
+		sb.WriteString("\n")
+	}
 
 	if len(items) > 0 {
 		if host := extractHostname(items[0].Message); host != "" {
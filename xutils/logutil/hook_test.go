@@ -2,6 +2,11 @@ package logutil
 
 import (
 	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"strings"
 	"sync"
 	"testing"
@@ -168,3 +173,113 @@ func TestFrameFilter_CaptureCaller(t *testing.T) {
 		t.Fatalf("expected funcName to contain test function name, got %q", funcName)
 	}
 }
+
+// TestResolveMetadata_FromEnv verifies metadata is auto-detected from env vars
+// and explicit config values win over them.
+func TestResolveMetadata_FromEnv(t *testing.T) {
+	t.Setenv("SERVICE_NAME", "order-api")
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("POD_NAME", "order-api-7d9f")
+
+	got := resolveMetadata(Config{Env: "prod"})
+	if got.ServiceName != "order-api" {
+		t.Fatalf("ServiceName = %q, want %q", got.ServiceName, "order-api")
+	}
+	if got.Env != "prod" {
+		t.Fatalf("Env = %q, want explicit value %q", got.Env, "prod")
+	}
+	if got.PodName != "order-api-7d9f" {
+		t.Fatalf("PodName = %q, want %q", got.PodName, "order-api-7d9f")
+	}
+}
+
+// TestBuildMarkdownCard_Metadata checks metadata is rendered into the alert.
+func TestBuildMarkdownCard_Metadata(t *testing.T) {
+	cfg := Config{ServiceName: "order-api", Env: "prod", PodName: "order-api-7d9f"}
+	items := []summaryItem{{Count: 2, File: "main.go", Line: 10, Message: "boom"}}
+
+	content := buildMarkdownCard(cfg, items)
+	for _, want := range []string{"**service:** order-api", "**env:** prod", "**pod:** order-api-7d9f"} {
+		if !strings.Contains(content, want) {
+			t.Fatalf("expected card to contain %q, got:\n%s", want, content)
+		}
+	}
+
+	if got, want := buildAlertTitle(cfg), "[prod] order-api Error Alert"; got != want {
+		t.Fatalf("buildAlertTitle() = %q, want %q", got, want)
+	}
+}
+
+type closeRecorder struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+// alertServer records the alert cards posted to a DingTalk-style webhook
+func alertServer(t *testing.T) (*httptest.Server, *testNotifier) {
+	received := &testNotifier{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received.add(string(body))
+		w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, received
+}
+
+// TestHookWriter_ShutdownFlushesQueuedAlerts verifies errors written just
+// before shutdown are sent although the flush interval has not elapsed,
+// and the wrapped writer is closed.
+func TestHookWriter_ShutdownFlushesQueuedAlerts(t *testing.T) {
+	srv, received := alertServer(t)
+	out := &closeRecorder{}
+	h := NewHookWriter(out, Config{IntervalSec: 3600, NotifyWebhook: srv.URL + "/robot/send?access_token=x"})
+
+	for _, msg := range []string{"payment failed", "refund failed"} {
+		if _, err := h.Write([]byte("2025-01-01T00:00:00.000+08:00\terror\t" + msg + "\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+
+	received.mu.Lock()
+	cards := strings.Join(received.messages, "\n")
+	received.mu.Unlock()
+	// both errors come from the same caller and are grouped into one record
+	for _, want := range []string{"**count:** 2", "refund failed"} {
+		if !strings.Contains(cards, want) {
+			t.Errorf("queued alerts not flushed, want %q in %q", want, cards)
+		}
+	}
+	if !out.closed {
+		t.Fatal("expected underlying writer to be closed")
+	}
+	if len(h.msgChan) != 0 {
+		t.Fatalf("expected msgChan to be drained, got %d pending", len(h.msgChan))
+	}
+
+	// Close after Shutdown is a no-op
+	h.Close()
+}
+
+// TestHookWriter_CloseKeepsStdStreams verifies the process std streams are
+// not closed with the hook.
+func TestHookWriter_CloseKeepsStdStreams(t *testing.T) {
+	for _, std := range []*os.File{os.Stdout, os.Stderr} {
+		h := NewHookWriter(std, Config{IntervalSec: 60})
+		h.Close()
+		if _, err := std.Stat(); err != nil {
+			t.Fatalf("%s closed by HookWriter: %v", std.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package logutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Level is the severity of a log entry, ordered from least to most severe
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelSlow
+	LevelError
+	LevelSevere
+)
+
+// FilteredWriter receives the entries that pass its filters. Zero filters
+// accept everything
+type FilteredWriter struct {
+	Writer   io.Writer
+	MinLevel Level          // entries below are skipped, entries without a level count as info
+	Include  *regexp.Regexp // if set, only matching entries are written
+	Exclude  *regexp.Regexp // matching entries are skipped
+}
+
+func (f FilteredWriter) accept(level Level, entry string) bool {
+	if level < f.MinLevel {
+		return false
+	}
+	if f.Include != nil && !f.Include.MatchString(entry) {
+		return false
+	}
+	if f.Exclude != nil && f.Exclude.MatchString(entry) {
+		return false
+	}
+	return true
+}
+
+// TeeWriter writes every log entry to the writers whose filters accept it,
+// e.g. everything to stdout, errors to a local file and the alert hook:
+//
+//	logx.SetWriter(logx.NewWriter(logutil.NewTeeWriter(
+//		logutil.FilteredWriter{Writer: os.Stdout},
+//		logutil.FilteredWriter{Writer: errFile, MinLevel: logutil.LevelError},
+//		logutil.FilteredWriter{Writer: logutil.NewHookWriter(io.Discard, cfg), MinLevel: logutil.LevelError},
+//	)))
+type TeeWriter struct {
+	mu      sync.Mutex
+	writers []FilteredWriter
+}
+
+func NewTeeWriter(writers ...FilteredWriter) *TeeWriter {
+	return &TeeWriter{writers: append([]FilteredWriter(nil), writers...)}
+}
+
+// Write passes p to every accepting writer. A failing writer doesn't stop the
+// others, the errors are joined
+func (t *TeeWriter) Write(p []byte) (int, error) {
+	entry := string(p)
+	level := parseLevel(entry)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var errs []error
+	for i, w := range t.writers {
+		if w.Writer == nil || !w.accept(level, entry) {
+			continue
+		}
+		if _, err := w.Writer.Write(p); err != nil {
+			errs = append(errs, fmt.Errorf("logutil: tee writer %d: %w", i, err))
+		}
+	}
+	return len(p), errors.Join(errs...)
+}
+
+// Close flushes the HookWriters and closes the writers implementing
+// io.Closer within the default deadline. os.Stdout and os.Stderr are left open
+func (t *TeeWriter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultCloseTimeout)
+	defer cancel()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var errs []error
+	for i, w := range t.writers {
+		if w.Writer == nil {
+			continue
+		}
+		if err := closeWriter(ctx, w.Writer); err != nil {
+			errs = append(errs, fmt.Errorf("logutil: close tee writer %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseLevel detects the level of go-zero plain (tab separated), json and
+// key=value entries, defaulting to info
+func parseLevel(entry string) Level {
+	clean := stripANSI(entry)
+
+	if parts := strings.Split(clean, "\t"); len(parts) >= 2 {
+		if level, ok := levelByName(strings.Trim(strings.TrimSpace(parts[1]), "[]")); ok {
+			return level
+		}
+	}
+
+	lower := strings.ToLower(clean)
+	for _, marker := range []string{`"level":"`, " level="} {
+		if idx := strings.Index(lower, marker); idx >= 0 {
+			name := lower[idx+len(marker):]
+			if end := strings.IndexAny(name, "\" \t\n"); end >= 0 {
+				name = name[:end]
+			}
+			if level, ok := levelByName(name); ok {
+				return level
+			}
+		}
+	}
+	return LevelInfo
+}
+
+func levelByName(name string) (Level, bool) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return LevelDebug, true
+	case "info", "stat":
+		return LevelInfo, true
+	case "slow":
+		return LevelSlow, true
+	case "error", "err", "erro":
+		return LevelError, true
+	case "severe", "fatal", "panic":
+		return LevelSevere, true
+	}
+	return 0, false
+}
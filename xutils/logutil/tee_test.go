@@ -0,0 +1,138 @@
+package logutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+type closingWriter struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (w *closingWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := []struct {
+		name string
+		msg  string
+		want Level
+	}{
+		{name: "plain error", msg: "2025-01-01T00:00:00.000+08:00\terror\tboom", want: LevelError},
+		{name: "plain slow", msg: "2025-01-01T00:00:00.000+08:00\tslow\tslowcall", want: LevelSlow},
+		{name: "plain stat", msg: "2025-01-01T00:00:00.000+08:00\tstat\tCPU: 1m", want: LevelInfo},
+		{name: "ansi severe", msg: "2025-01-01\t\x1b[31msevere\x1b[0m\tpanic", want: LevelSevere},
+		{name: "json debug", msg: `{"@timestamp":"2025-01-01","level":"debug","content":"x"}`, want: LevelDebug},
+		{name: "kv error", msg: `time=2025-01-01 level=error msg="failed"`, want: LevelError},
+		{name: "unknown", msg: "just text", want: LevelInfo},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLevel(tt.msg); got != tt.want {
+				t.Errorf("parseLevel(%q) = %d, want %d", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTeeWriter(t *testing.T) {
+	var all, errs, payments bytes.Buffer
+	closer := &closingWriter{}
+	tee := NewTeeWriter(
+		FilteredWriter{Writer: &all, Exclude: regexp.MustCompile(`healthz`)},
+		FilteredWriter{Writer: &errs, MinLevel: LevelError},
+		FilteredWriter{Writer: &payments, Include: regexp.MustCompile(`payment`)},
+		FilteredWriter{Writer: closer, MinLevel: LevelSevere},
+	)
+
+	lines := []string{
+		"t\tinfo\tGET /healthz\n",
+		"t\tinfo\tpayment created\n",
+		"t\terror\tpayment failed\n",
+		"t\tsevere\tout of memory\n",
+	}
+	for _, line := range lines {
+		if n, err := tee.Write([]byte(line)); err != nil || n != len(line) {
+			t.Fatalf("Write() = %d, %v", n, err)
+		}
+	}
+
+	want := map[string]string{
+		"all":      lines[1] + lines[2] + lines[3],
+		"errors":   lines[2] + lines[3],
+		"payments": lines[1] + lines[2],
+		"closer":   lines[3],
+	}
+	got := map[string]string{
+		"all":      all.String(),
+		"errors":   errs.String(),
+		"payments": payments.String(),
+		"closer":   closer.String(),
+	}
+	for name, w := range want {
+		if got[name] != w {
+			t.Errorf("%s got %q, want %q", name, got[name], w)
+		}
+	}
+
+	if err := tee.Close(); err != nil || !closer.closed {
+		t.Errorf("Close() = %v, closed = %v", err, closer.closed)
+	}
+}
+
+func TestTeeWriter_ErrorDoesNotStopOthers(t *testing.T) {
+	var out bytes.Buffer
+	tee := NewTeeWriter(FilteredWriter{Writer: failingWriter{}}, FilteredWriter{Writer: &out})
+
+	line := []byte("t\terror\tboom\n")
+	n, err := tee.Write(line)
+	if err == nil || n != len(line) {
+		t.Fatalf("Write() = %d, %v, want the failing writer's error", n, err)
+	}
+	if out.String() != string(line) {
+		t.Errorf("second writer got %q", out.String())
+	}
+}
+
+func TestTeeWriter_Close(t *testing.T) {
+	srv, received := alertServer(t)
+	hook := NewHookWriter(io.Discard, Config{IntervalSec: 3600, NotifyWebhook: srv.URL + "/robot/send?access_token=x"})
+	file := &closingWriter{}
+	tee := NewTeeWriter(
+		FilteredWriter{Writer: os.Stdout, MinLevel: LevelSevere},
+		FilteredWriter{Writer: file},
+		FilteredWriter{Writer: hook, MinLevel: LevelError},
+	)
+
+	if _, err := tee.Write([]byte("t\terror\tpayment failed\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := tee.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if _, err := os.Stdout.Stat(); err != nil {
+		t.Fatalf("stdout closed by TeeWriter: %v", err)
+	}
+	if !file.closed {
+		t.Error("file writer not closed")
+	}
+	received.mu.Lock()
+	defer received.mu.Unlock()
+	if len(received.messages) != 1 || !strings.Contains(received.messages[0], "payment failed") {
+		t.Errorf("hook alerts = %q, want the queued error flushed", received.messages)
+	}
+}
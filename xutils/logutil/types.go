@@ -7,4 +7,9 @@ type Config struct {
 	NotifyChannel  string `json:"NotifyChannel,optional"`
 	NotifyWebhook  string `json:"NotifyWebhook"`
 	NotifySecret   string `json:"NotifySecret"`
+
+	// metadata rendered into every alert, auto-detected from env vars when empty
+	ServiceName string `json:"ServiceName,optional"`
+	Env         string `json:"Env,optional"`
+	PodName     string `json:"PodName,optional"`
 }
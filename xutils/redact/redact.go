@@ -0,0 +1,248 @@
+// Package redact masks sensitive fields in logs and alerts. Rules are
+// registered once with SetRules and shared by xhttp (outbound request logs)
+// and logutil (error alerts). Error messages returned to clients, such as
+// xrequest.Response.ErrMsg, are not redacted
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultMask replaces redacted values
+const DefaultMask = "******"
+
+// Rules describes what to redact. Field and header names are matched
+// case-insensitively, ignoring '_' and '-', so "access_token" also covers
+// "accessToken" and "Access-Token"
+type Rules struct {
+	Fields  []string `json:",optional"` // JSON keys / key=value keys
+	Headers []string `json:",optional"` // HTTP header names
+	Mask    string   `json:",optional"` // default DefaultMask
+}
+
+// DefaultRules are active until SetRules is called
+var DefaultRules = Rules{
+	Fields: []string{
+		"password", "passwd", "secret", "token", "access_token", "refresh_token",
+		"api_key", "private_key", "id_card", "card_no", "cvv",
+	},
+	Headers: []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"},
+}
+
+// Redactor applies one set of rules. The package functions use the global
+// Redactor, build another with New for rules that only apply to one caller
+type Redactor struct {
+	rules   Rules
+	fields  map[string]struct{}
+	headers map[string]struct{}
+	text    *regexp.Regexp
+}
+
+var current atomic.Pointer[Redactor]
+
+func init() {
+	SetRules(DefaultRules)
+}
+
+// New compiles rules into a Redactor
+func New(rules Rules) *Redactor {
+	if rules.Mask == "" {
+		rules.Mask = DefaultMask
+	}
+
+	r := &Redactor{
+		rules:   rules,
+		fields:  make(map[string]struct{}, len(rules.Fields)),
+		headers: make(map[string]struct{}, len(rules.Headers)),
+	}
+	alts := make([]string, 0, len(rules.Fields))
+	for _, f := range rules.Fields {
+		r.fields[normalize(f)] = struct{}{}
+		alts = append(alts, fieldPattern(f))
+	}
+	for _, h := range rules.Headers {
+		r.headers[normalize(h)] = struct{}{}
+	}
+	if len(alts) > 0 {
+		// "key": "value" / key=value / key: value
+		r.text = regexp.MustCompile(`(?i)("?\b(?:` + strings.Join(alts, "|") + `)"?\s*[:=]\s*)("[^"]*"|[^\s,&;}]+)`)
+	}
+	return r
+}
+
+// SetRules replaces the global redaction rules
+func SetRules(rules Rules) {
+	current.Store(New(rules))
+}
+
+// Default returns the global Redactor, it changes on every SetRules
+func Default() *Redactor {
+	return current.Load()
+}
+
+// GetRules returns the active rules
+func GetRules() Rules {
+	return current.Load().rules
+}
+
+// IsSensitiveField reports whether a JSON key or form field is redacted
+func IsSensitiveField(name string) bool {
+	return current.Load().IsSensitiveField(name)
+}
+
+// IsSensitiveHeader reports whether an HTTP header is redacted
+func IsSensitiveHeader(name string) bool {
+	return current.Load().IsSensitiveHeader(name)
+}
+
+// JSON masks sensitive values in a JSON document at any depth.
+// Input that is not valid JSON is redacted with Text
+func JSON(data []byte) []byte {
+	return current.Load().JSON(data)
+}
+
+// Text masks "key": "value", key=value and key: value pairs in free text
+func Text(s string) string {
+	return current.Load().Text(s)
+}
+
+// Headers returns a copy of headers with sensitive values masked
+func Headers(headers map[string]string) map[string]string {
+	return current.Load().Headers(headers)
+}
+
+// HTTPHeader returns a copy of h with sensitive values masked
+func HTTPHeader(h http.Header) http.Header {
+	return current.Load().HTTPHeader(h)
+}
+
+// Rules returns the rules r was built from
+func (r *Redactor) Rules() Rules {
+	return r.rules
+}
+
+// IsSensitiveField reports whether a JSON key or form field is redacted
+func (r *Redactor) IsSensitiveField(name string) bool {
+	_, ok := r.fields[normalize(name)]
+	return ok
+}
+
+// IsSensitiveHeader reports whether an HTTP header is redacted
+func (r *Redactor) IsSensitiveHeader(name string) bool {
+	_, ok := r.headers[normalize(name)]
+	return ok
+}
+
+// JSON masks sensitive values in a JSON document, see the package JSON
+func (r *Redactor) JSON(data []byte) []byte {
+	if len(data) == 0 {
+		return data
+	}
+
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return []byte(r.Text(string(data)))
+	}
+
+	if !r.redactValue(&v) {
+		return data
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+	return out
+}
+
+// Text masks key/value pairs in free text, see the package Text
+func (r *Redactor) Text(s string) string {
+	if r.text == nil || s == "" {
+		return s
+	}
+	return r.text.ReplaceAllStringFunc(s, func(m string) string {
+		sub := r.text.FindStringSubmatch(m)
+		if strings.HasPrefix(sub[2], `"`) {
+			return sub[1] + `"` + r.rules.Mask + `"`
+		}
+		return sub[1] + r.rules.Mask
+	})
+}
+
+// Headers returns a copy of headers with sensitive values masked
+func (r *Redactor) Headers(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	out := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if r.IsSensitiveHeader(k) {
+			v = r.rules.Mask
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// HTTPHeader returns a copy of h with sensitive values masked
+func (r *Redactor) HTTPHeader(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	out := h.Clone()
+	for k := range out {
+		if r.IsSensitiveHeader(k) {
+			out[k] = []string{r.rules.Mask}
+		}
+	}
+	return out
+}
+
+// redactValue masks sensitive keys in place and reports whether anything changed
+func (r *Redactor) redactValue(v *any) bool {
+	changed := false
+	switch t := (*v).(type) {
+	case map[string]any:
+		for k, child := range t {
+			if r.IsSensitiveField(k) {
+				t[k] = r.rules.Mask
+				changed = true
+				continue
+			}
+			if r.redactValue(&child) {
+				t[k] = child
+				changed = true
+			}
+		}
+	case []any:
+		for i := range t {
+			if r.redactValue(&t[i]) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func normalize(name string) string {
+	name = strings.ToLower(name)
+	return strings.NewReplacer("_", "", "-", "").Replace(name)
+}
+
+// fieldPattern matches name with optional '_' / '-' between its letters
+func fieldPattern(name string) string {
+	var sb strings.Builder
+	for i, r := range normalize(name) {
+		if i > 0 {
+			sb.WriteString(`[_-]?`)
+		}
+		sb.WriteString(regexp.QuoteMeta(string(r)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,79 @@
+package redact
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"flat", `{"user":"bob","password":"p@ss"}`, `{"password":"******","user":"bob"}`},
+		{"nested and camel", `{"data":[{"accessToken":"x","n":1}]}`, `{"data":[{"accessToken":"******","n":1}]}`},
+		{"untouched", `{"b":1,"a":2}`, `{"b":1,"a":2}`},
+		{"not json", `password=p@ss&user=bob`, `password=******&user=bob`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(JSON([]byte(tt.in))); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`login failed {"password": "p@ss", "user": "bob"}`, `login failed {"password": "******", "user": "bob"}`},
+		{`refresh-token: abc123, user=bob`, `refresh-token: ******, user=bob`},
+		{`nothing sensitive`, `nothing sensitive`},
+	}
+	for _, tt := range tests {
+		if got := Text(tt.in); got != tt.want {
+			t.Fatalf("got %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSetRules(t *testing.T) {
+	defer SetRules(DefaultRules)
+
+	SetRules(Rules{Fields: []string{"phone"}, Headers: []string{"X-Sign"}, Mask: "[redacted]"})
+
+	if IsSensitiveField("password") || !IsSensitiveField("Phone") {
+		t.Fatal("rules not replaced")
+	}
+	if got := string(JSON([]byte(`{"phone":"138"}`))); got != `{"phone":"[redacted]"}` {
+		t.Fatalf("got %s", got)
+	}
+
+	h := HTTPHeader(http.Header{"X-Sign": {"abc"}, "Accept": {"*/*"}})
+	if h.Get("X-Sign") != "[redacted]" || h.Get("Accept") != "*/*" {
+		t.Fatalf("unexpected headers %v", h)
+	}
+	m := Headers(map[string]string{"x-sign": "abc"})
+	if m["x-sign"] != "[redacted]" {
+		t.Fatalf("unexpected headers %v", m)
+	}
+}
+
+func TestNew(t *testing.T) {
+	r := New(Rules{Fields: []string{"id_no"}, Headers: []string{"X-Sign"}})
+
+	if got := string(r.JSON([]byte(`{"idNo":"110","password":"p"}`))); got != `{"idNo":"******","password":"p"}` {
+		t.Fatalf("got %s", got)
+	}
+	if got := r.Headers(map[string]string{"x-sign": "abc"}); got["x-sign"] != DefaultMask {
+		t.Fatalf("got %v", got)
+	}
+	// the global rules are untouched
+	if IsSensitiveField("id_no") || Default().IsSensitiveHeader("X-Sign") {
+		t.Fatal("New changed the global rules")
+	}
+}
@@ -0,0 +1,56 @@
+package watermark
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/color"
+	"time"
+)
+
+// renderVersion changes whenever the rendering changes, so cache keys of
+// older outputs stop matching
+const renderVersion = 1
+
+// ErrNotDeterministic is returned by CacheKey without WithDeterministic
+var ErrNotDeterministic = errors.New("watermark: cache key requires WithDeterministic")
+
+// CacheKey returns a hex SHA-256 identifying the output RenderBytes produces
+// for body, text and opts, without rendering it. Identical keys mean
+// byte-identical outputs of the same build (cgo/libvips or pure Go), so a CDN
+// can look up a deduplicated asset before rendering and store it under the
+// key, Result.Hash identifies the output after rendering
+func CacheKey(body []byte, text string, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	if !o.deterministic {
+		return "", ErrNotDeterministic
+	}
+
+	h := sha256.New()
+	bodySum := sha256.Sum256(body)
+	fmt.Fprintf(h, "v%d|%s|%x|%q|%s", renderVersion, renderer, bodySum, text, o.fingerprint())
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fingerprint lists every option that affects the output, keep it in sync
+// with options. Colors are normalized: a pointer color such as &color.RGBA{}
+// would otherwise print as its address
+func (o *options) fingerprint() string {
+	st := o.style
+	return fmt.Sprintf("q%d|a%d|r%g,%t|w%d|s%g|f%g,%g|j%g|l%d|%s|e%d|%q|%s|%q|%q|c%s|o%s,%g|sh%s,%g,%g|ac%t|x%v",
+		o.quality, o.alpha, o.angle, o.angleSet, o.maxWidth, o.tileSpacing, o.fontSize, o.fontScale, o.jitter,
+		o.level, o.format, o.exif,
+		o.vars, o.now.UTC().Format(time.RFC3339Nano), o.timeFormat, o.rowTemplates,
+		colorKey(st.Color), colorKey(st.OutlineColor), st.OutlineWidth,
+		colorKey(st.ShadowColor), st.ShadowDX, st.ShadowDY, st.AutoContrast, o.exclusions)
+}
+
+// colorKey prints c as non-premultiplied RGBA hex, "-" for nil
+func colorKey(c color.Color) string {
+	if c == nil {
+		return "-"
+	}
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("%02x%02x%02x%02x", n.R, n.G, n.B, n.A)
+}
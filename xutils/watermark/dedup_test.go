@@ -0,0 +1,83 @@
+package watermark
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+func TestCacheKey(t *testing.T) {
+	body := testPNG(t, 64, 64)
+	at := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	key := func(t *testing.T, body []byte, text string, opts ...Option) string {
+		t.Helper()
+		k, err := CacheKey(body, text, append([]Option{WithDeterministic()}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+	base := key(t, body, "{user}", WithVars(map[string]string{"user": "alice"}), WithTime(at))
+
+	tests := []struct {
+		name string
+		body []byte
+		text string
+		opts []Option
+		same bool
+	}{
+		{name: "same inputs", body: body, text: "{user}", opts: []Option{WithVars(map[string]string{"user": "alice"}), WithTime(at)}, same: true},
+		{name: "same time in another zone", body: body, text: "{user}", opts: []Option{WithVars(map[string]string{"user": "alice"}), WithTime(at.In(time.FixedZone("CST", 8*3600)))}, same: true},
+		{name: "other body", body: testPNG(t, 65, 64), text: "{user}", opts: []Option{WithVars(map[string]string{"user": "alice"}), WithTime(at)}},
+		{name: "other text", body: body, text: "{user}!", opts: []Option{WithVars(map[string]string{"user": "alice"}), WithTime(at)}},
+		{name: "other vars", body: body, text: "{user}", opts: []Option{WithVars(map[string]string{"user": "bob"}), WithTime(at)}},
+		{name: "other time", body: body, text: "{user}", opts: []Option{WithVars(map[string]string{"user": "alice"}), WithTime(at.Add(time.Second))}},
+		{name: "other style", body: body, text: "{user}", opts: []Option{WithVars(map[string]string{"user": "alice"}), WithTime(at), WithColor(color.Black)}},
+		{name: "other exclusions", body: body, text: "{user}", opts: []Option{WithVars(map[string]string{"user": "alice"}), WithTime(at), WithExclusions(image.Rect(0, 0, 8, 8))}},
+		{name: "other quality", body: body, text: "{user}", opts: []Option{WithVars(map[string]string{"user": "alice"}), WithTime(at), WithQuality(70)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := key(t, tt.body, tt.text, tt.opts...); (got == base) != tt.same {
+				t.Errorf("key equal = %v, want %v", got == base, tt.same)
+			}
+		})
+	}
+
+	if _, err := CacheKey(body, "x"); !errors.Is(err, ErrNotDeterministic) {
+		t.Errorf("CacheKey() without WithDeterministic error = %v", err)
+	}
+}
+
+func TestCacheKey_Colors(t *testing.T) {
+	body := testPNG(t, 32, 32)
+	key := func(c color.Color) string {
+		k, err := CacheKey(body, "wm", WithDeterministic(), WithColor(c), WithOutline(c, 1), WithShadow(c, 1, 1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return k
+	}
+
+	red := key(&color.RGBA{R: 255, A: 255})
+	tests := []struct {
+		name string
+		c    color.Color
+		same bool
+	}{
+		{name: "same pointer color", c: &color.RGBA{R: 255, A: 255}, same: true},
+		{name: "value color", c: color.RGBA{R: 255, A: 255}, same: true},
+		{name: "other model", c: color.NRGBA{R: 255, A: 255}, same: true},
+		{name: "other pointer color", c: &color.RGBA{G: 255, A: 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := key(tt.c); (got == red) != tt.same {
+				t.Errorf("key equal = %v, want %v", got == red, tt.same)
+			}
+		})
+	}
+}
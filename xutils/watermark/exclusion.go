@@ -0,0 +1,49 @@
+package watermark
+
+import (
+	"image"
+	"math"
+)
+
+// WithExclusions skips the tiles overlapping any of rects, e.g. detected faces
+// or signatures that verification must still be able to read. The rectangles
+// are in pixels of the upright input image, after the EXIF rotation and
+// before WithMaxWidth downscaling
+func WithExclusions(rects ...image.Rectangle) Option {
+	return func(o *options) {
+		for _, r := range rects {
+			if r = r.Canon(); !r.Empty() {
+				o.exclusions = append(o.exclusions, r)
+			}
+		}
+	}
+}
+
+// exclusionSet holds the exclusion rectangles in pixels of the rendered image
+type exclusionSet []image.Rectangle
+
+// newExclusionSet scales rects from an input width of inWidth to outWidth pixels
+func newExclusionSet(rects []image.Rectangle, inWidth, outWidth int) exclusionSet {
+	if len(rects) == 0 || inWidth <= 0 {
+		return nil
+	}
+	scale := float64(outWidth) / float64(inWidth)
+	set := make(exclusionSet, 0, len(rects))
+	for _, r := range rects {
+		set = append(set, image.Rect(
+			int(math.Floor(float64(r.Min.X)*scale)), int(math.Floor(float64(r.Min.Y)*scale)),
+			int(math.Ceil(float64(r.Max.X)*scale)), int(math.Ceil(float64(r.Max.Y)*scale)),
+		))
+	}
+	return set
+}
+
+// blocks reports whether a tile with bounds r overlaps an exclusion
+func (s exclusionSet) blocks(r image.Rectangle) bool {
+	for _, ex := range s {
+		if ex.Overlaps(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package watermark
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestExclusionSet(t *testing.T) {
+	set := newExclusionSet([]image.Rectangle{image.Rect(100, 100, 201, 200)}, 1000, 500)
+	if want := image.Rect(50, 50, 101, 100); set[0] != want {
+		t.Fatalf("scaled = %v, want %v", set[0], want)
+	}
+
+	tests := []struct {
+		tile image.Rectangle
+		want bool
+	}{
+		{tile: image.Rect(0, 0, 50, 50), want: false},
+		{tile: image.Rect(0, 0, 51, 51), want: true},
+		{tile: image.Rect(90, 90, 120, 120), want: true},
+		{tile: image.Rect(101, 0, 200, 200), want: false},
+	}
+	for _, tt := range tests {
+		if got := set.blocks(tt.tile); got != tt.want {
+			t.Errorf("blocks(%v) = %v, want %v", tt.tile, got, tt.want)
+		}
+	}
+	if newExclusionSet(nil, 100, 100).blocks(image.Rect(0, 0, 10, 10)) {
+		t.Error("empty set blocks")
+	}
+}
+
+func TestRenderExclusions(t *testing.T) {
+	white := solidPNG(t, 640, 480, color.White)
+	face := image.Rect(200, 120, 440, 360)
+
+	res, err := RenderBytes(context.Background(), white, "CONFIDENTIAL",
+		WithFormat(FormatPNG), WithColor(color.Black), WithAlpha(255), WithMaxWidth(320),
+		WithExclusions(face, image.Rect(5, 5, 5, 50)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(res.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the face is at half size after WithMaxWidth(320)
+	scaled := image.Rect(face.Min.X/2, face.Min.Y/2, face.Max.X/2, face.Max.Y/2)
+	var inside, outside int
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r < 0x8000 {
+				if image.Pt(x, y).In(scaled) {
+					inside++
+				} else {
+					outside++
+				}
+			}
+		}
+	}
+	if inside > 0 {
+		t.Errorf("%d watermark pixels inside the excluded region", inside)
+	}
+	if outside == 0 {
+		t.Error("no watermark outside the excluded region")
+	}
+}
+
+func TestRenderAllExcluded(t *testing.T) {
+	body := testPNG(t, 200, 100)
+	if _, err := RenderBytes(context.Background(), body, "x", WithExclusions(image.Rect(-1000, -1000, 1000, 1000))); err != nil {
+		t.Fatalf("RenderBytes() with everything excluded error = %v", err)
+	}
+}
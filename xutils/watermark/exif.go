@@ -0,0 +1,188 @@
+package watermark
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+
+	"github.com/disintegration/imaging"
+)
+
+// EXIF tags handled by the pure Go path
+const (
+	tagOrientation = 0x0112
+	tagGPSIFD      = 0x8825
+)
+
+var (
+	exifHeader = []byte("Exif\x00\x00")
+	pngMagic   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// readEXIF returns the raw TIFF structure of the EXIF block of a JPEG (APP1)
+// or PNG (eXIf chunk), nil if there is none
+func readEXIF(data []byte) []byte {
+	if bytes.HasPrefix(data, pngMagic) {
+		for p := len(pngMagic); p+8 <= len(data); {
+			n := int(binary.BigEndian.Uint32(data[p:]))
+			typ := string(data[p+4 : p+8])
+			if p+12+n > len(data) || typ == "IDAT" {
+				return nil
+			}
+			if typ == "eXIf" {
+				return data[p+8 : p+8+n]
+			}
+			p += 12 + n
+		}
+		return nil
+	}
+
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil
+	}
+	for p := 2; p+4 <= len(data) && data[p] == 0xFF; {
+		marker := data[p+1]
+		if marker == 0xDA || marker == 0xD9 { // start of scan, end of image
+			return nil
+		}
+		n := int(binary.BigEndian.Uint16(data[p+2:]))
+		if n < 2 || p+2+n > len(data) {
+			return nil
+		}
+		seg := data[p+4 : p+2+n]
+		if marker == 0xE1 && bytes.HasPrefix(seg, exifHeader) {
+			return seg[len(exifHeader):]
+		}
+		p += 2 + n
+	}
+	return nil
+}
+
+// ifd0 returns the byte order and the offset of the first IFD of a TIFF block
+func ifd0(tiff []byte) (binary.ByteOrder, int, bool) {
+	if len(tiff) < 8 {
+		return nil, 0, false
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil, 0, false
+	}
+	off := int(order.Uint32(tiff[4:]))
+	if off < 8 || off+2 > len(tiff) {
+		return nil, 0, false
+	}
+	if off+2+12*int(order.Uint16(tiff[off:])) > len(tiff) {
+		return nil, 0, false
+	}
+	return order, off, true
+}
+
+// exifOrientation returns the EXIF orientation (1-8), 1 when absent or invalid
+func exifOrientation(tiff []byte) int {
+	order, off, ok := ifd0(tiff)
+	if !ok {
+		return 1
+	}
+	n := int(order.Uint16(tiff[off:]))
+	for i := 0; i < n; i++ {
+		e := tiff[off+2+12*i:]
+		if order.Uint16(e) == tagOrientation {
+			if v := int(order.Uint16(e[8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// normalizeEXIF returns a copy of tiff with the orientation reset to 1 and,
+// when dropGPS is set, the GPS IFD unlinked. Other offsets stay valid since
+// no data is moved.
+func normalizeEXIF(tiff []byte, dropGPS bool) []byte {
+	order, off, ok := ifd0(tiff)
+	if !ok {
+		return nil
+	}
+	out := bytes.Clone(tiff)
+	n := int(order.Uint16(out[off:]))
+	for i := 0; i < n; i++ {
+		e := out[off+2+12*i:]
+		switch order.Uint16(e) {
+		case tagOrientation:
+			order.PutUint16(e[8:], 1)
+		case tagGPSIFD:
+			if dropGPS {
+				// shift the following entries and the next-IFD offset down
+				copy(e, out[off+2+12*(i+1):off+2+12*n+4])
+				n--
+				i--
+				order.PutUint16(out[off:], uint16(n))
+			}
+		}
+	}
+	return out
+}
+
+// embedEXIF inserts tiff into an encoded JPEG or PNG, data is returned
+// unchanged for other formats or when the block is too large for a JPEG segment
+func embedEXIF(data []byte, format Format, tiff []byte) []byte {
+	if len(tiff) == 0 {
+		return data
+	}
+	switch format {
+	case FormatJPEG:
+		n := 2 + len(exifHeader) + len(tiff)
+		if n > 0xFFFF || len(data) < 2 {
+			return data
+		}
+		out := make([]byte, 0, len(data)+2+n)
+		out = append(out, data[:2]...) // SOI
+		out = append(out, 0xFF, 0xE1)
+		out = binary.BigEndian.AppendUint16(out, uint16(n))
+		out = append(out, exifHeader...)
+		out = append(out, tiff...)
+		return append(out, data[2:]...)
+	case FormatPNG:
+		// the eXIf chunk goes right after IHDR
+		ihdrEnd := len(pngMagic) + 8 + 13 + 4
+		if len(data) < ihdrEnd {
+			return data
+		}
+		out := make([]byte, 0, len(data)+12+len(tiff))
+		out = append(out, data[:ihdrEnd]...)
+		out = binary.BigEndian.AppendUint32(out, uint32(len(tiff)))
+		chunk := append([]byte("eXIf"), tiff...)
+		out = append(out, chunk...)
+		out = binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(chunk))
+		return append(out, data[ihdrEnd:]...)
+	}
+	return data
+}
+
+// applyOrientation rotates/flips img so that it displays upright for orientation 1
+func applyOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	}
+	return img
+}
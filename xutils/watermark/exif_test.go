@@ -0,0 +1,160 @@
+package watermark
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"testing"
+)
+
+// testTIFF builds a little-endian EXIF block with an orientation and an empty GPS IFD
+func testTIFF(orientation uint16) []byte {
+	b := []byte("II*\x00")
+	b = binary.LittleEndian.AppendUint32(b, 8)
+	b = binary.LittleEndian.AppendUint16(b, 2)
+	// orientation, SHORT
+	b = binary.LittleEndian.AppendUint16(b, tagOrientation)
+	b = binary.LittleEndian.AppendUint16(b, 3)
+	b = binary.LittleEndian.AppendUint32(b, 1)
+	b = binary.LittleEndian.AppendUint16(b, orientation)
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	// GPS IFD pointer, LONG
+	b = binary.LittleEndian.AppendUint16(b, tagGPSIFD)
+	b = binary.LittleEndian.AppendUint16(b, 4)
+	b = binary.LittleEndian.AppendUint32(b, 1)
+	b = binary.LittleEndian.AppendUint32(b, 38)
+	b = binary.LittleEndian.AppendUint32(b, 0) // next IFD
+	// empty GPS IFD
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	return binary.LittleEndian.AppendUint32(b, 0)
+}
+
+func hasTag(tiff []byte, tag uint16) bool {
+	order, off, ok := ifd0(tiff)
+	if !ok {
+		return false
+	}
+	for i := 0; i < int(order.Uint16(tiff[off:])); i++ {
+		if order.Uint16(tiff[off+2+12*i:]) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEXIFRoundTrip(t *testing.T) {
+	tiff := testTIFF(6)
+	for _, tt := range []struct {
+		format Format
+		body   []byte
+	}{
+		{FormatJPEG, testJPEG(t, 8, 4)},
+		{FormatPNG, testPNG(t, 8, 4)},
+	} {
+		t.Run(string(tt.format), func(t *testing.T) {
+			data := embedEXIF(tt.body, tt.format, tiff)
+			if got := readEXIF(data); !bytes.Equal(got, tiff) {
+				t.Fatalf("readEXIF() = %x, want %x", got, tiff)
+			}
+			if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+				t.Fatalf("embedded image no longer decodes: %v", err)
+			}
+		})
+	}
+
+	if readEXIF(testJPEG(t, 8, 4)) != nil {
+		t.Error("plain JPEG should have no EXIF")
+	}
+}
+
+func TestNormalizeEXIF(t *testing.T) {
+	tiff := testTIFF(6)
+	if got := exifOrientation(tiff); got != 6 {
+		t.Fatalf("exifOrientation() = %d, want 6", got)
+	}
+
+	kept := normalizeEXIF(tiff, false)
+	if exifOrientation(kept) != 1 || !hasTag(kept, tagGPSIFD) {
+		t.Errorf("keep: orientation = %d, gps = %v", exifOrientation(kept), hasTag(kept, tagGPSIFD))
+	}
+
+	noGPS := normalizeEXIF(tiff, true)
+	if exifOrientation(noGPS) != 1 || hasTag(noGPS, tagGPSIFD) {
+		t.Errorf("no gps: orientation = %d, gps = %v", exifOrientation(noGPS), hasTag(noGPS, tagGPSIFD))
+	}
+	if exifOrientation(tiff) != 6 {
+		t.Error("normalizeEXIF must not modify its input")
+	}
+}
+
+func TestRenderFormatAndEXIF(t *testing.T) {
+	ctx := context.Background()
+	body := embedEXIF(testJPEG(t, 120, 80), FormatJPEG, testTIFF(6))
+
+	tests := []struct {
+		name   string
+		opts   []Option
+		format Format
+		exif   bool
+		gps    bool
+	}{
+		{name: "strip by default", format: FormatJPEG},
+		{name: "keep", opts: []Option{WithEXIF(EXIFKeep)}, format: FormatJPEG, exif: true, gps: true},
+		{name: "keep without gps", opts: []Option{WithEXIF(EXIFKeepNoGPS)}, format: FormatJPEG, exif: true},
+		{name: "deterministic strips", opts: []Option{WithEXIF(EXIFKeep), WithDeterministic()}, format: FormatJPEG},
+		{name: "png output", opts: []Option{WithFormat(FormatPNG), WithEXIF(EXIFKeep)}, format: FormatPNG, exif: true, gps: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := RenderBytes(ctx, body, "wm", tt.opts...)
+			if err != nil {
+				t.Fatalf("RenderBytes() error = %v", err)
+			}
+			if res.Format != tt.format {
+				t.Errorf("Format = %s, want %s", res.Format, tt.format)
+			}
+
+			// orientation 6 is applied to the pixels: 120x80 becomes 80x120
+			cfg, _, err := image.DecodeConfig(bytes.NewReader(res.Data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Width != 80 || cfg.Height != 120 {
+				t.Errorf("size = %dx%d, want 80x120", cfg.Width, cfg.Height)
+			}
+
+			tiff := readEXIF(res.Data)
+			if (tiff != nil) != tt.exif {
+				t.Fatalf("exif present = %v, want %v", tiff != nil, tt.exif)
+			}
+			if tiff == nil {
+				return
+			}
+			if got := exifOrientation(tiff); got != 1 {
+				t.Errorf("orientation = %d, want 1", got)
+			}
+			if hasTag(tiff, tagGPSIFD) != tt.gps {
+				t.Errorf("gps present = %v, want %v", !tt.gps, tt.gps)
+			}
+		})
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	tests := []struct {
+		want, original, def, expect Format
+	}{
+		{"", FormatPNG, FormatJPEG, FormatJPEG},
+		{"", FormatPNG, FormatPNG, FormatPNG},
+		{FormatOriginal, FormatPNG, FormatJPEG, FormatPNG},
+		{FormatOriginal, FormatWebP, FormatJPEG, FormatWebP},
+		{FormatOriginal, "gif", FormatPNG, FormatJPEG},
+		{FormatWebP, FormatJPEG, FormatJPEG, FormatWebP},
+	}
+	for _, tt := range tests {
+		if got := outputFormat(tt.want, tt.original, tt.def); got != tt.expect {
+			t.Errorf("outputFormat(%q, %q, %q) = %q, want %q", tt.want, tt.original, tt.def, got, tt.expect)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+//go:build !cgo
+
+package watermark
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRenderUnsupportedInput(t *testing.T) {
+	_, err := RenderBytes(context.Background(), ftyp("heic", "mif1", "heic"), "wm")
+	if !errors.Is(err, ErrUnsupportedFormat) {
+		t.Fatalf("RenderBytes() error = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestFallbackFormat(t *testing.T) {
+	body := testPNG(t, 16, 16)
+	tests := []struct {
+		want   Format
+		expect Format
+	}{
+		{FormatWebP, FormatPNG},
+		{FormatAVIF, FormatJPEG},
+		{FormatHEIC, FormatJPEG},
+		{FormatJPEG, FormatJPEG},
+	}
+	for _, tt := range tests {
+		res, err := RenderBytes(context.Background(), body, "wm", WithFormat(tt.want))
+		if err != nil {
+			t.Fatalf("RenderBytes(%s) error = %v", tt.want, err)
+		}
+		if res.Format != tt.expect || sniffFormat(res.Data) != tt.expect {
+			t.Errorf("WithFormat(%s) wrote %s (%s), want %s", tt.want, res.Format, sniffFormat(res.Data), tt.expect)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package watermark
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// testWebP is a 1x1 lossless WebP
+var testWebP = []byte{
+	0x52, 0x49, 0x46, 0x46, 0x1a, 0x00, 0x00, 0x00, 0x57, 0x45, 0x42, 0x50,
+	0x56, 0x50, 0x38, 0x4c, 0x0d, 0x00, 0x00, 0x00, 0x2f, 0x00, 0x00, 0x00,
+	0x10, 0x07, 0x10, 0x11, 0x11, 0x88, 0x88, 0xfe, 0x07, 0x00,
+}
+
+func ftyp(major string, compatible ...string) []byte {
+	b := []byte{0, 0, 0, byte(16 + 4*len(compatible))}
+	b = append(b, "ftyp"+major+"\x00\x00\x00\x00"...)
+	for _, c := range compatible {
+		b = append(b, c...)
+	}
+	return append(b, "meta"...)
+}
+
+func TestSniffFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want Format
+	}{
+		{"jpeg", testJPEG(t, 4, 4), FormatJPEG},
+		{"png", testPNG(t, 4, 4), FormatPNG},
+		{"webp", testWebP, FormatWebP},
+		{"heic", ftyp("heic", "mif1", "heic"), FormatHEIC},
+		{"avif", ftyp("avif", "mif1", "avif"), FormatAVIF},
+		{"avif with mif1 major brand", ftyp("mif1", "mif1", "avif"), FormatAVIF},
+		{"mp4", ftyp("isom", "isom", "mp41"), ""},
+		{"garbage", []byte("hello"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sniffFormat(tt.data); got != tt.want {
+				t.Errorf("sniffFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderWebPInput(t *testing.T) {
+	if _, err := webp.Decode(bytes.NewReader(testWebP)); err != nil {
+		t.Fatalf("invalid test fixture: %v", err)
+	}
+
+	res, err := RenderBytes(context.Background(), testWebP, "wm", WithFormat(FormatOriginal))
+	if err != nil {
+		t.Fatalf("RenderBytes() error = %v", err)
+	}
+	if sniffFormat(res.Data) != res.Format {
+		t.Errorf("Format = %s but data is %s", res.Format, sniffFormat(res.Data))
+	}
+	if _, _, err := image.Decode(bytes.NewReader(res.Data)); err != nil {
+		t.Errorf("output doesn't decode: %v", err)
+	}
+}
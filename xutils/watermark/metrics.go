@@ -0,0 +1,101 @@
+package watermark
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/metric"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"gomod.pri/golib/xtrace"
+)
+
+const tracerName = "watermark"
+
+// rendering phases, traced as spans and timed by phaseDuration
+const (
+	phaseDecode    = "decode"
+	phaseResize    = "resize"
+	phaseComposite = "composite"
+	phaseEncode    = "encode"
+)
+
+var (
+	renderDuration = xtrace.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: "watermark",
+		Name:      "render_duration_ms",
+		Help:      "Watermark rendering latency in milliseconds, partitioned by renderer, output format and result.",
+		Labels:    []string{"renderer", "format", "result"},
+		Buckets:   []float64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000},
+	})
+	phaseDuration = xtrace.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: "watermark",
+		Name:      "phase_duration_ms",
+		Help:      "Watermark rendering phase latency in milliseconds, partitioned by renderer and phase.",
+		Labels:    []string{"renderer", "phase"},
+		Buckets:   []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000},
+	})
+	outputSize = metric.NewHistogramVec(&metric.HistogramVecOpts{
+		Namespace: "watermark",
+		Name:      "output_bytes",
+		Help:      "Size of watermarked images in bytes, partitioned by output format.",
+		Labels:    []string{"format"},
+		Buckets:   []float64{16 << 10, 64 << 10, 256 << 10, 512 << 10, 1 << 20, 2 << 20, 5 << 20, 10 << 20},
+	})
+)
+
+// encodeObserved runs encode in a watermark.Render span and records the
+// latency and output size
+func encodeObserved(ctx context.Context, in input, text string, o *options, w io.Writer) (Format, error) {
+	source := "body"
+	if in.path != "" {
+		source = "path"
+	}
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "watermark.Render",
+		trace.WithAttributes(
+			attribute.String("watermark.renderer", renderer),
+			attribute.String("watermark.source", source),
+			attribute.Int("watermark.input_bytes", len(in.body)),
+		),
+	)
+	defer span.End()
+
+	cw := &countingWriter{w: w}
+	start := time.Now()
+	format, err := encode(ctx, in, text, o, cw)
+	elapsed := time.Since(start)
+
+	result := "ok"
+	if err != nil {
+		result = "error"
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else {
+		span.SetAttributes(
+			attribute.String("watermark.format", string(format)),
+			attribute.Int64("watermark.output_bytes", cw.n),
+		)
+		outputSize.Observe(cw.n, string(format))
+	}
+	renderDuration.Observe(ctx, float64(elapsed.Milliseconds()), renderer, string(format), result)
+	return format, err
+}
+
+// startPhase starts the span of a rendering phase, the returned func ends it
+// with the phase error and records its duration
+func startPhase(ctx context.Context, phase string) (context.Context, func(error)) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "watermark."+phase)
+	start := time.Now()
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+		phaseDuration.Observe(ctx, float64(time.Since(start).Milliseconds()), renderer, phase)
+	}
+}
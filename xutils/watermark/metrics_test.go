@@ -0,0 +1,62 @@
+package watermark
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestRenderSpans(t *testing.T) {
+	recorder := recordSpans(t)
+	if _, err := RenderBytes(context.Background(), testPNG(t, 320, 240), "CONFIDENTIAL", WithMaxWidth(160)); err != nil {
+		t.Fatal(err)
+	}
+
+	byName := map[string]sdktrace.ReadOnlySpan{}
+	for _, s := range recorder.Ended() {
+		byName[s.Name()] = s
+	}
+	root, ok := byName["watermark.Render"]
+	if !ok {
+		t.Fatal("no watermark.Render span")
+	}
+	for _, phase := range []string{phaseDecode, phaseResize, phaseComposite, phaseEncode} {
+		s, ok := byName["watermark."+phase]
+		if !ok {
+			t.Errorf("missing %s span", phase)
+			continue
+		}
+		if s.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("%s span is not a child of watermark.Render", phase)
+		}
+	}
+}
+
+func TestRenderSpanError(t *testing.T) {
+	recorder := recordSpans(t)
+	if _, err := RenderBytes(context.Background(), []byte("not an image"), "x"); err == nil {
+		t.Fatal("rendering garbage succeeded")
+	}
+
+	for _, s := range recorder.Ended() {
+		if s.Name() == "watermark.Render" && s.Status().Code != codes.Error {
+			t.Errorf("failed render status = %v", s.Status())
+		}
+		if s.Name() == "watermark."+phaseEncode {
+			t.Error("encode phase ran after a decode error")
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package watermark
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logc"
+)
+
+// options left at zero use the defaults of the active build
+// (cgo/libvips or pure Go), which render slightly differently.
+// Fields that change the output must be added to fingerprint
+type options struct {
+	deterministic bool
+	quality       int     // JPEG quality 1-100
+	alpha         int     // text opacity 1-255
+	angle         float64 // text rotation in degrees, counter-clockwise
+	angleSet      bool
+	maxWidth      int     // downscale wider images
+	tileSpacing   float64 // tile step as a multiple of the text size
+	fontSize      float64 // 0 derives the size from the image
+	fontScale     float64 // multiplies the derived font size, set by the security level
+	jitter        float64 // max per-tile rotation offset in degrees
+	level         SecurityLevel
+	format        Format
+	exif          EXIFPolicy
+
+	maxDownloadBytes int64 // 0 default, < 0 unlimited
+	maxPixels        int64 // 0 default, < 0 unlimited
+
+	vars         map[string]string // template placeholders, see WithVars
+	now          time.Time
+	timeFormat   string
+	rowTemplates []string
+
+	style      TextStyle
+	exclusions []image.Rectangle // see WithExclusions
+}
+
+// Format is the encoding of the watermarked image
+type Format string
+
+// WebP, AVIF and HEIC are encoded by libvips only (HEIC/AVIF need libheif).
+// The pure Go build decodes WebP but not HEIC/AVIF, and writes PNG instead of
+// WebP and JPEG instead of AVIF/HEIC.
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatWebP Format = "webp"
+	FormatAVIF Format = "avif"
+	FormatHEIC Format = "heic"
+	// FormatOriginal keeps the input format, except HEIC which browsers can't
+	// display and is written as JPEG
+	FormatOriginal Format = "original"
+)
+
+// ErrUnsupportedFormat is returned for inputs the active build can't decode
+var ErrUnsupportedFormat = errors.New("watermark: unsupported image format")
+
+// EXIFPolicy controls which EXIF metadata is copied to the output.
+// The orientation is always applied to the pixels and reset to 1.
+type EXIFPolicy int
+
+const (
+	EXIFStrip EXIFPolicy = iota
+	EXIFKeep
+	// EXIFKeepNoGPS keeps EXIF but drops the GPS location
+	EXIFKeepNoGPS
+)
+
+// WithFormat sets the output format. Without it the cgo build writes JPEG
+// and the pure Go build keeps the input format
+func WithFormat(format Format) Option {
+	return func(o *options) {
+		o.format = format
+	}
+}
+
+// WithEXIF copies EXIF metadata to the output, ignored with WithDeterministic
+func WithEXIF(policy EXIFPolicy) Option {
+	return func(o *options) {
+		o.exif = policy
+	}
+}
+
+// Option configures Add / AddFromBytes / Render
+type Option func(*options)
+
+// WithQuality sets the JPEG/WebP/AVIF/HEIC quality (1-100)
+func WithQuality(quality int) Option {
+	return func(o *options) {
+		o.quality = clamp(quality, 1, 100)
+	}
+}
+
+// WithAlpha sets the text opacity (1-255)
+func WithAlpha(alpha int) Option {
+	return func(o *options) {
+		o.alpha = clamp(alpha, 1, 255)
+	}
+}
+
+// WithAngle sets the counter-clockwise text rotation in degrees, 0 is horizontal
+func WithAngle(degrees float64) Option {
+	return func(o *options) {
+		o.angle = degrees
+		o.angleSet = true
+	}
+}
+
+// WithMaxWidth downscales images wider than width before watermarking
+func WithMaxWidth(width int) Option {
+	return func(o *options) {
+		o.maxWidth = width
+	}
+}
+
+// WithTileSpacing sets the distance between tiles as a multiple of the text size
+func WithTileSpacing(factor float64) Option {
+	return func(o *options) {
+		if factor > 0 {
+			o.tileSpacing = factor
+		}
+	}
+}
+
+// WithFontSize sets a fixed font size in points instead of one derived from the image
+func WithFontSize(size float64) Option {
+	return func(o *options) {
+		if size > 0 {
+			o.fontSize = size
+		}
+	}
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// withDefault returns v, or def when v is zero
+func withDefault[T int | float64 | string](v, def T) T {
+	var zero T
+	if v == zero {
+		return def
+	}
+	return v
+}
+
+// WithDeterministic makes the output byte-for-byte reproducible for the same
+// input, text and options: metadata is stripped, encoder settings are pinned
+// and nothing time dependent is rendered ({time} needs WithTime). Use it for
+// golden-file tests and for caching watermarked assets by Result.Hash
+func WithDeterministic() Option {
+	return func(o *options) {
+		o.deterministic = true
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.deterministic {
+		o.exif = EXIFStrip
+	}
+	o.applySecurityLevel()
+	return o
+}
+
+// outputFormat resolves the requested format for an input decoded as original,
+// def is used when no format was requested
+func outputFormat(want, original, def Format) Format {
+	switch want {
+	case FormatJPEG, FormatPNG, FormatWebP, FormatAVIF, FormatHEIC:
+		return want
+	case FormatOriginal:
+		switch original {
+		case FormatPNG, FormatWebP, FormatAVIF:
+			return original
+		}
+		return FormatJPEG
+	}
+	return def
+}
+
+// sniffFormat detects the image format from its magic bytes, "" if unknown
+func sniffFormat(data []byte) Format {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPEG
+	case bytes.HasPrefix(data, pngMagic):
+		return FormatPNG
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return FormatWebP
+	case len(data) >= 12 && string(data[4:8]) == "ftyp":
+		// ISO BMFF: major brand, minor version, then compatible brands up to the box size
+		end := min(int(binary.BigEndian.Uint32(data)), len(data))
+		var heic bool
+		for i := 8; i+4 <= end; i += 4 {
+			if i == 12 {
+				continue
+			}
+			switch string(data[i : i+4]) {
+			case "avif", "avis":
+				return FormatAVIF
+			case "heic", "heix", "heim", "heis", "hevc", "hevx", "mif1", "msf1":
+				heic = true
+			}
+		}
+		if heic {
+			return FormatHEIC
+		}
+	}
+	return ""
+}
+
+// Result is a watermarked image
+type Result struct {
+	Data   []byte // nil for RenderTo / RenderUpload
+	Format Format
+	Hash   string // hex SHA-256 of the image
+	Size   int64
+}
+
+// Reader returns the image data as a ReadCloser
+func (r *Result) Reader() io.ReadCloser {
+	return io.NopCloser(bytes.NewReader(r.Data))
+}
+
+func newResult(data []byte, format Format) *Result {
+	sum := sha256.Sum256(data)
+	return &Result{Data: data, Format: format, Hash: hex.EncodeToString(sum[:]), Size: int64(len(data))}
+}
+
+// input is either an image body or a local path / http(s) URL
+type input struct {
+	body []byte
+	path string
+}
+
+// Render watermarks the image at path (local file or http(s) URL)
+func Render(ctx context.Context, path string, text string, opts ...Option) (*Result, error) {
+	return renderLogged(ctx, input{path: path}, text, opts)
+}
+
+// RenderBytes watermarks an image body
+func RenderBytes(ctx context.Context, body []byte, text string, opts ...Option) (*Result, error) {
+	return renderLogged(ctx, input{body: body}, text, opts)
+}
+
+func AddFromBytes(ctx context.Context, body []byte, text string, opts ...Option) (io.ReadCloser, error) {
+	res, err := RenderBytes(ctx, body, text, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return res.Reader(), nil
+}
+
+func Add(ctx context.Context, path string, text string, opts ...Option) (io.ReadCloser, error) {
+	res, err := Render(ctx, path, text, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return res.Reader(), nil
+}
+
+// RenderTo watermarks the image at path and writes it to w without keeping
+// a copy, the returned Result has no Data
+func RenderTo(ctx context.Context, w io.Writer, path string, text string, opts ...Option) (*Result, error) {
+	h := sha256.New()
+	cw := &countingWriter{w: io.MultiWriter(w, h)}
+	format, err := encodeObserved(ctx, input{path: path}, text, newOptions(opts), cw)
+	if err != nil {
+		logc.Errorf(ctx, "watermark render error: %v", err)
+		return nil, err
+	}
+	return &Result{Format: format, Hash: hex.EncodeToString(h.Sum(nil)), Size: cw.n}, nil
+}
+
+// Uploader is the subset of storage.Storage used by RenderUpload
+type Uploader interface {
+	UploadStream(ctx context.Context, remote string, stream io.Reader) error
+}
+
+// RenderUpload watermarks the image at path and streams it to remote through
+// u, e.g. a storage.Storage, without buffering the output a second time
+func RenderUpload(ctx context.Context, u Uploader, remote, path, text string, opts ...Option) (*Result, error) {
+	pr, pw := io.Pipe()
+
+	var (
+		res       *Result
+		renderErr error
+		done      = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		res, renderErr = RenderTo(ctx, pw, path, text, opts...)
+		pw.CloseWithError(renderErr)
+	}()
+
+	uploadErr := u.UploadStream(ctx, remote, pr)
+	// unblock the renderer if the upload stopped reading early
+	pr.CloseWithError(io.ErrClosedPipe)
+	<-done
+
+	if renderErr != nil {
+		return nil, renderErr
+	}
+	if uploadErr != nil {
+		return nil, fmt.Errorf("upload %s failed: %w", remote, uploadErr)
+	}
+	return res, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func render(ctx context.Context, in input, text string, o *options) (*Result, error) {
+	var buf bytes.Buffer
+	format, err := encodeObserved(ctx, in, text, o, &buf)
+	if err != nil {
+		return nil, err
+	}
+	return newResult(buf.Bytes(), format), nil
+}
+
+func renderLogged(ctx context.Context, in input, text string, opts []Option) (*Result, error) {
+	res, err := render(ctx, in, text, newOptions(opts))
+	if err != nil {
+		logc.Errorf(ctx, "watermark render error: %v", err)
+		return nil, err
+	}
+	return res, nil
+}
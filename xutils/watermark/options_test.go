@@ -0,0 +1,150 @@
+package watermark
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func testJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(testPNG(t, w, h)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRenderDeterministic(t *testing.T) {
+	body := testPNG(t, 320, 240)
+	ctx := context.Background()
+
+	a, err := RenderBytes(ctx, body, "CONFIDENTIAL", WithDeterministic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := RenderBytes(ctx, body, "CONFIDENTIAL", WithDeterministic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Hash != b.Hash || !bytes.Equal(a.Data, b.Data) {
+		t.Fatal("deterministic output differs between runs")
+	}
+	sum := sha256.Sum256(a.Data)
+	if a.Hash != hex.EncodeToString(sum[:]) {
+		t.Fatal("hash does not match data")
+	}
+
+	c, err := RenderBytes(ctx, body, "OTHER", WithDeterministic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Hash == a.Hash {
+		t.Fatal("different text produced the same hash")
+	}
+}
+
+func TestAddFromBytes(t *testing.T) {
+	rc, err := AddFromBytes(context.Background(), testPNG(t, 64, 64), "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	if _, _, err := image.Decode(rc); err != nil {
+		t.Fatalf("output is not an image: %v", err)
+	}
+
+	if _, err := AddFromBytes(context.Background(), []byte("not an image"), "x"); err == nil {
+		t.Fatal("expected decode error")
+	}
+}
+
+func TestRenderOptions(t *testing.T) {
+	body := testPNG(t, 400, 200)
+	ctx := context.Background()
+
+	base, err := RenderBytes(ctx, body, "WM", WithDeterministic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{"alpha", WithAlpha(200)},
+		{"angle", WithAngle(0)},
+		{"tile spacing", WithTileSpacing(3)},
+		{"font size", WithFontSize(12)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := RenderBytes(ctx, body, "WM", WithDeterministic(), tt.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Hash == base.Hash {
+				t.Fatal("option had no effect on the output")
+			}
+		})
+	}
+
+	// quality only applies to JPEG output
+	jpg, err := RenderBytes(ctx, testJPEG(t, 400, 200), "WM", WithDeterministic())
+	if err != nil {
+		t.Fatal(err)
+	}
+	low, err := RenderBytes(ctx, testJPEG(t, 400, 200), "WM", WithDeterministic(), WithQuality(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(low.Data) >= len(jpg.Data) {
+		t.Fatal("lower quality did not shrink the output")
+	}
+
+	res, err := RenderBytes(ctx, body, "WM", WithMaxWidth(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(res.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 100 {
+		t.Fatalf("width %d, want 100", cfg.Width)
+	}
+}
+
+func TestClampOptions(t *testing.T) {
+	o := newOptions([]Option{WithQuality(500), WithAlpha(-1), WithFontSize(-3)})
+	if o.quality != 100 || o.alpha != 1 || o.fontSize != 0 {
+		t.Fatalf("unexpected options %+v", o)
+	}
+}
@@ -0,0 +1,25 @@
+package watermark
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+)
+
+// Overlay renders the tiled watermark on a transparent width x height PNG,
+// for compositing onto media this package can't decode, e.g. video frames
+func Overlay(ctx context.Context, width, height int, text string, opts ...Option) (*Result, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("watermark: invalid overlay size %dx%d", width, height)
+	}
+
+	var base bytes.Buffer
+	if err := png.Encode(&base, image.NewNRGBA(image.Rect(0, 0, width, height))); err != nil {
+		return nil, fmt.Errorf("encode overlay base failed: %w", err)
+	}
+
+	opts = append(append([]Option(nil), opts...), WithFormat(FormatPNG), WithMaxWidth(width))
+	return RenderBytes(ctx, base.Bytes(), text, opts...)
+}
@@ -0,0 +1,202 @@
+package watermark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const defaultItemTimeout = 30 * time.Second
+
+// Job is one image of a batch, either Body or Path (local file or http(s) URL)
+type Job struct {
+	ID      string
+	Path    string
+	Body    []byte
+	Text    string
+	Options []Option // appended to the Processor options
+}
+
+// JobResult is the outcome of a Job, Result is nil when Err is set
+type JobResult struct {
+	Job      Job
+	Result   *Result
+	Err      error
+	Duration time.Duration
+}
+
+// BatchStats aggregates the outcome of a batch
+type BatchStats struct {
+	Succeeded int
+	Failed    int
+	Duration  time.Duration
+}
+
+// BatchResult holds the results of Process in input order
+type BatchResult struct {
+	BatchStats
+	Results []JobResult
+}
+
+// Err joins the errors of the failed jobs, nil if all succeeded
+func (b *BatchResult) Err() error {
+	var errs []error
+	for _, r := range b.Results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("job %s: %w", r.Job.ID, r.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ProcessorOption configures a Processor
+type ProcessorOption func(*Processor)
+
+// WithWorkers bounds the number of images rendered at once, default runtime.NumCPU()
+func WithWorkers(n int) ProcessorOption {
+	return func(p *Processor) {
+		if n > 0 {
+			p.workers = n
+		}
+	}
+}
+
+// WithItemTimeout bounds each job, including the download of remote inputs.
+// Default 30s, <= 0 disables it
+func WithItemTimeout(d time.Duration) ProcessorOption {
+	return func(p *Processor) {
+		p.itemTimeout = d
+	}
+}
+
+// WithDefaultOptions applies opts to every job
+func WithDefaultOptions(opts ...Option) ProcessorOption {
+	return func(p *Processor) {
+		p.opts = append(p.opts, opts...)
+	}
+}
+
+// Processor watermarks batches of images with a bounded worker pool, so large
+// backfills neither spawn unbounded goroutines nor hold more than workers
+// decoded images (and their libvips buffers) in memory at once
+type Processor struct {
+	workers     int
+	itemTimeout time.Duration
+	opts        []Option
+	render      func(ctx context.Context, in input, text string, o *options) (*Result, error)
+}
+
+func NewProcessor(opts ...ProcessorOption) *Processor {
+	p := &Processor{
+		workers:     runtime.NumCPU(),
+		itemTimeout: defaultItemTimeout,
+		render:      render,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Process renders jobs and returns all results in input order. Results keep
+// the image data in memory, use Run for batches too large for that
+func (p *Processor) Process(ctx context.Context, jobs []Job) *BatchResult {
+	res := &BatchResult{Results: make([]JobResult, len(jobs))}
+
+	in := make(chan indexedJob)
+	go func() {
+		defer close(in)
+		for i, job := range jobs {
+			in <- indexedJob{Job: job, index: i}
+		}
+	}()
+
+	res.BatchStats = p.run(ctx, in, func(i int, r JobResult) {
+		res.Results[i] = r
+	})
+	return res
+}
+
+// Run renders jobs until the channel is closed, handing each result to fn as
+// soon as it is ready. fn is called concurrently from the workers. Jobs
+// received after ctx is done fail with ctx.Err() without being rendered
+func (p *Processor) Run(ctx context.Context, jobs <-chan Job, fn func(JobResult)) BatchStats {
+	in := make(chan indexedJob)
+	go func() {
+		defer close(in)
+		for job := range jobs {
+			in <- indexedJob{Job: job}
+		}
+	}()
+
+	return p.run(ctx, in, func(_ int, r JobResult) {
+		if fn != nil {
+			fn(r)
+		}
+	})
+}
+
+type indexedJob struct {
+	Job
+	index int
+}
+
+func (p *Processor) run(ctx context.Context, jobs <-chan indexedJob, fn func(int, JobResult)) BatchStats {
+	start := time.Now()
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		stats BatchStats
+	)
+
+	for w := 0; w < p.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				r := p.do(ctx, job.Job)
+
+				mu.Lock()
+				if r.Err != nil {
+					stats.Failed++
+				} else {
+					stats.Succeeded++
+				}
+				mu.Unlock()
+
+				fn(job.index, r)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats.Duration = time.Since(start)
+	return stats
+}
+
+func (p *Processor) do(ctx context.Context, job Job) JobResult {
+	start := time.Now()
+	r := JobResult{Job: job}
+	if err := ctx.Err(); err != nil {
+		r.Err = err
+		return r
+	}
+
+	if p.itemTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.itemTimeout)
+		defer cancel()
+	}
+
+	opts := append(append([]Option(nil), p.opts...), job.Options...)
+	r.Result, r.Err = p.render(ctx, input{body: job.Body, path: job.Path}, job.Text, newOptions(opts))
+	if r.Err == nil && ctx.Err() != nil {
+		// libvips can't be interrupted, report the overrun instead of a late result
+		r.Result, r.Err = nil, ctx.Err()
+	}
+	r.Duration = time.Since(start)
+	return r
+}
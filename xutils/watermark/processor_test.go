@@ -0,0 +1,97 @@
+package watermark
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProcessorProcess(t *testing.T) {
+	body := testPNG(t, 64, 48)
+	jobs := make([]Job, 10)
+	for i := range jobs {
+		jobs[i] = Job{ID: strconv.Itoa(i), Body: body, Text: "wm " + strconv.Itoa(i)}
+	}
+	jobs[3].Body = []byte("not an image")
+
+	res := NewProcessor(WithWorkers(3), WithDefaultOptions(WithDeterministic())).Process(context.Background(), jobs)
+	if res.Succeeded != 9 || res.Failed != 1 {
+		t.Fatalf("stats = %+v", res.BatchStats)
+	}
+	for i, r := range res.Results {
+		if r.Job.ID != strconv.Itoa(i) {
+			t.Fatalf("result %d belongs to job %s", i, r.Job.ID)
+		}
+		if (r.Err != nil) != (i == 3) || (r.Result == nil) != (i == 3) {
+			t.Errorf("job %d: result = %v, err = %v", i, r.Result != nil, r.Err)
+		}
+	}
+	if err := res.Err(); err == nil {
+		t.Fatal("Err() should report the failed job")
+	}
+}
+
+func TestProcessorBoundsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	p := NewProcessor(WithWorkers(4))
+	p.render = func(ctx context.Context, in input, text string, o *options) (*Result, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			old := peak.Load()
+			if n <= old || peak.CompareAndSwap(old, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		return newResult([]byte(text), FormatPNG), nil
+	}
+
+	jobs := make(chan Job)
+	go func() {
+		defer close(jobs)
+		for i := 0; i < 100; i++ {
+			jobs <- Job{ID: strconv.Itoa(i), Text: "x"}
+		}
+	}()
+
+	var mu sync.Mutex
+	seen := 0
+	stats := p.Run(context.Background(), jobs, func(r JobResult) {
+		mu.Lock()
+		seen++
+		mu.Unlock()
+	})
+	if stats.Succeeded != 100 || seen != 100 {
+		t.Fatalf("stats = %+v, seen = %d", stats, seen)
+	}
+	if peak.Load() > 4 {
+		t.Fatalf("peak concurrency = %d, want <= 4", peak.Load())
+	}
+}
+
+func TestProcessorTimeoutAndCancel(t *testing.T) {
+	p := NewProcessor(WithWorkers(2), WithItemTimeout(10*time.Millisecond))
+	p.render = func(ctx context.Context, in input, text string, o *options) (*Result, error) {
+		if text == "slow" {
+			time.Sleep(30 * time.Millisecond)
+		}
+		return newResult([]byte(text), FormatPNG), nil
+	}
+
+	res := p.Process(context.Background(), []Job{{ID: "a", Text: "fast"}, {ID: "b", Text: "slow"}})
+	if res.Results[0].Err != nil || !errors.Is(res.Results[1].Err, context.DeadlineExceeded) {
+		t.Fatalf("results = %+v", res.Results)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	res = p.Process(ctx, []Job{{ID: "a", Text: "fast"}})
+	if !errors.Is(res.Results[0].Err, context.Canceled) || res.Failed != 1 {
+		t.Fatalf("canceled batch results = %+v", res.Results)
+	}
+}
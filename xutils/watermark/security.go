@@ -0,0 +1,95 @@
+package watermark
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// SecurityLevel is a preset of alpha, font size, tile spacing and rotation
+// jitter, from a light watermark that barely covers the image to a dense one
+// that is hard to crop or retouch away
+type SecurityLevel int
+
+const (
+	SecurityLow SecurityLevel = iota + 1
+	SecurityMedium
+	SecurityHigh
+	SecurityParanoid
+)
+
+func (l SecurityLevel) String() string {
+	switch l {
+	case SecurityLow:
+		return "low"
+	case SecurityMedium:
+		return "medium"
+	case SecurityHigh:
+		return "high"
+	case SecurityParanoid:
+		return "paranoid"
+	}
+	return fmt.Sprintf("SecurityLevel(%d)", int(l))
+}
+
+// securityPreset holds the values a level sets
+type securityPreset struct {
+	alpha       int
+	fontScale   float64 // multiplies the default font size
+	tileSpacing float64
+	jitter      float64 // degrees
+}
+
+var securityPresets = map[SecurityLevel]securityPreset{
+	SecurityLow:      {alpha: 40, fontScale: 1.2, tileSpacing: 2.0},
+	SecurityMedium:   {alpha: 60, fontScale: 1.0, tileSpacing: 1.4, jitter: 5},
+	SecurityHigh:     {alpha: 90, fontScale: 0.85, tileSpacing: 1.1, jitter: 10},
+	SecurityParanoid: {alpha: 120, fontScale: 0.7, tileSpacing: 0.9, jitter: 15},
+}
+
+// WithSecurityLevel applies the preset of level. Options set explicitly, such
+// as WithAlpha or WithFontSize, take precedence whatever their order
+func WithSecurityLevel(level SecurityLevel) Option {
+	return func(o *options) {
+		o.level = level
+	}
+}
+
+// WithRotationJitter rotates each tile by up to ±degrees around the base angle,
+// so the tiles don't line up for pattern based removal. The offsets depend only
+// on the tile position and keep the output reproducible
+func WithRotationJitter(degrees float64) Option {
+	return func(o *options) {
+		if degrees > 0 {
+			o.jitter = degrees
+		}
+	}
+}
+
+// applySecurityLevel fills the options left unset from the level preset
+func (o *options) applySecurityLevel() {
+	p, ok := securityPresets[o.level]
+	if !ok {
+		return
+	}
+	o.alpha = withDefault(o.alpha, p.alpha)
+	o.tileSpacing = withDefault(o.tileSpacing, p.tileSpacing)
+	o.jitter = withDefault(o.jitter, p.jitter)
+	if o.fontSize == 0 {
+		o.fontScale = p.fontScale
+	}
+}
+
+// jitterSteps quantizes the jitter, the libvips build renders one tile image per step
+const jitterSteps = 5
+
+// tileJitter returns the rotation offset in degrees of the tile at row, col,
+// one of jitterSteps values evenly spread over [-jitter, jitter]
+func tileJitter(jitter float64, row, col int) float64 {
+	if jitter <= 0 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%d:%d", row, col)
+	step := int(h.Sum32() % jitterSteps)
+	return jitter * (2*float64(step)/(jitterSteps-1) - 1)
+}
@@ -0,0 +1,110 @@
+package watermark
+
+import (
+	"bytes"
+	"context"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestSecurityLevelOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want options
+	}{
+		{name: "none", want: options{}},
+		{
+			name: "high preset",
+			opts: []Option{WithSecurityLevel(SecurityHigh)},
+			want: options{alpha: 90, fontScale: 0.85, tileSpacing: 1.1, jitter: 10},
+		},
+		{
+			name: "explicit options win before the level",
+			opts: []Option{WithAlpha(200), WithFontSize(30), WithSecurityLevel(SecurityParanoid)},
+			want: options{alpha: 200, fontSize: 30, tileSpacing: 0.9, jitter: 15},
+		},
+		{
+			name: "explicit options win after the level",
+			opts: []Option{WithSecurityLevel(SecurityLow), WithTileSpacing(3), WithRotationJitter(2)},
+			want: options{alpha: 40, fontScale: 1.2, tileSpacing: 3, jitter: 2},
+		},
+		{name: "unknown level", opts: []Option{WithSecurityLevel(SecurityLevel(9))}, want: options{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOptions(tt.opts)
+			o.level = 0
+			if o.fingerprint() != tt.want.fingerprint() {
+				t.Errorf("got %+v, want %+v", *o, tt.want)
+			}
+		})
+	}
+}
+
+func TestTileJitter(t *testing.T) {
+	if j := tileJitter(0, 3, 4); j != 0 {
+		t.Fatalf("jitter without WithRotationJitter = %v", j)
+	}
+	seen := map[float64]bool{}
+	for row := 0; row < 10; row++ {
+		for col := 0; col < 10; col++ {
+			j := tileJitter(10, row, col)
+			if j < -10 || j > 10 {
+				t.Fatalf("jitter %v out of range", j)
+			}
+			if j != tileJitter(10, row, col) {
+				t.Fatal("jitter is not reproducible")
+			}
+			seen[j] = true
+		}
+	}
+	if len(seen) < 2 || len(seen) > jitterSteps {
+		t.Errorf("got %d distinct offsets, want 2..%d", len(seen), jitterSteps)
+	}
+}
+
+func TestRenderSecurityLevels(t *testing.T) {
+	white := solidPNG(t, 400, 300, color.White)
+	coverage := map[SecurityLevel]int{}
+	for _, level := range []SecurityLevel{SecurityLow, SecurityParanoid} {
+		res, err := RenderBytes(context.Background(), white, "CONFIDENTIAL",
+			WithSecurityLevel(level), WithFormat(FormatPNG), WithColor(color.Black), WithDeterministic())
+		if err != nil {
+			t.Fatalf("%s: %v", level, err)
+		}
+		again, err := RenderBytes(context.Background(), white, "CONFIDENTIAL",
+			WithSecurityLevel(level), WithFormat(FormatPNG), WithColor(color.Black), WithDeterministic())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Hash != again.Hash {
+			t.Errorf("%s: output with jitter is not reproducible", level)
+		}
+		coverage[level] = markedPixels(t, res.Data)
+	}
+	if coverage[SecurityParanoid] <= coverage[SecurityLow] {
+		t.Errorf("paranoid marks %d pixels, low %d", coverage[SecurityParanoid], coverage[SecurityLow])
+	}
+}
+
+// markedPixels counts the pixels darkened by the watermark
+func markedPixels(t *testing.T, data []byte) int {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r < 0xf000 {
+				n++
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,142 @@
+package watermark
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// default safeguards against oversized inputs, see WithMaxDownloadBytes and WithMaxPixels
+const (
+	defaultMaxDownloadBytes = 50 << 20
+	defaultMaxPixels        = 100_000_000
+)
+
+// ErrTooLarge is returned for inputs exceeding the download or pixel limits
+var ErrTooLarge = errors.New("watermark: image too large")
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// WithMaxDownloadBytes caps the size of remote and local inputs, default 50 MiB,
+// n <= 0 disables the cap
+func WithMaxDownloadBytes(n int64) Option {
+	return func(o *options) {
+		o.maxDownloadBytes = n
+		if n <= 0 {
+			o.maxDownloadBytes = -1
+		}
+	}
+}
+
+// WithMaxPixels rejects images with more than n pixels before they are fully
+// decoded, default 100 megapixels, n <= 0 disables the check
+func WithMaxPixels(n int64) Option {
+	return func(o *options) {
+		o.maxPixels = n
+		if n <= 0 {
+			o.maxPixels = -1
+		}
+	}
+}
+
+// limit returns v, def when unset, or 0 (no limit) when disabled
+func limit(v, def int64) int64 {
+	switch {
+	case v == 0:
+		return def
+	case v < 0:
+		return 0
+	}
+	return v
+}
+
+func (o *options) downloadLimit() int64 { return limit(o.maxDownloadBytes, defaultMaxDownloadBytes) }
+func (o *options) pixelLimit() int64    { return limit(o.maxPixels, defaultMaxPixels) }
+
+// checkPixels enforces the pixel limit on the image dimensions
+func checkPixels(width, height int, maxPixels int64) error {
+	if maxPixels > 0 && int64(width)*int64(height) > maxPixels {
+		return fmt.Errorf("%w: %dx%d exceeds %d pixels", ErrTooLarge, width, height, maxPixels)
+	}
+	return nil
+}
+
+// isRemote reports whether path is an http(s) URL
+func isRemote(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// loadInput returns the image bytes of in and their content type, if known
+func loadInput(ctx context.Context, in input, maxBytes int64) ([]byte, string, error) {
+	if len(in.body) > 0 {
+		return in.body, "", nil
+	}
+	if isRemote(in.path) {
+		return fetchRemote(ctx, in.path, maxBytes)
+	}
+
+	if err := checkFileSize(in.path, maxBytes); err != nil {
+		return nil, "", err
+	}
+	data, err := os.ReadFile(in.path)
+	if err != nil {
+		return nil, "", fmt.Errorf("load local image failed: %w", err)
+	}
+	return data, mime.TypeByExtension(filepath.Ext(in.path)), nil
+}
+
+func checkFileSize(path string, maxBytes int64) error {
+	if maxBytes <= 0 {
+		return nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("load local image failed: %w", err)
+	}
+	if fi.Size() > maxBytes {
+		return fmt.Errorf("%w: %s is %d bytes, limit %d", ErrTooLarge, path, fi.Size(), maxBytes)
+	}
+	return nil
+}
+
+// fetchRemote downloads url, reading at most maxBytes (0 means unlimited)
+func fetchRemote(ctx context.Context, url string, maxBytes int64) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetchRemote error: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("fetchRemote error: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("fetchRemote error: status code %d", resp.StatusCode)
+	}
+
+	var body io.Reader = resp.Body
+	if maxBytes > 0 {
+		if resp.ContentLength > maxBytes {
+			return nil, "", fmt.Errorf("%w: %s is %d bytes, limit %d", ErrTooLarge, url, resp.ContentLength, maxBytes)
+		}
+		body = io.LimitReader(resp.Body, maxBytes+1)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("readAll error: %w", err)
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return nil, "", fmt.Errorf("%w: %s exceeds %d bytes", ErrTooLarge, url, maxBytes)
+	}
+
+	return data, resp.Header.Get("Content-Type"), nil
+}
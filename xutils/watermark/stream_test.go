@@ -0,0 +1,112 @@
+package watermark
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type memUploader struct {
+	data []byte
+	err  error
+}
+
+func (m *memUploader) UploadStream(_ context.Context, _ string, stream io.Reader) error {
+	if m.err != nil {
+		return m.err
+	}
+	var err error
+	m.data, err = io.ReadAll(stream)
+	return err
+}
+
+func writeTemp(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRenderTo(t *testing.T) {
+	ctx := context.Background()
+	path := writeTemp(t, "in.png", testPNG(t, 160, 120))
+
+	want, err := Render(ctx, path, "wm", WithDeterministic())
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	got, err := RenderTo(ctx, &buf, path, "wm", WithDeterministic())
+	if err != nil {
+		t.Fatalf("RenderTo() error = %v", err)
+	}
+	if got.Data != nil || got.Hash != want.Hash || got.Size != want.Size || !bytes.Equal(buf.Bytes(), want.Data) {
+		t.Fatalf("RenderTo() = %+v, want hash %s size %d", got, want.Hash, want.Size)
+	}
+
+	up := &memUploader{}
+	res, err := RenderUpload(ctx, up, "out.png", path, "wm", WithDeterministic())
+	if err != nil {
+		t.Fatalf("RenderUpload() error = %v", err)
+	}
+	if res.Hash != want.Hash || !bytes.Equal(up.data, want.Data) {
+		t.Fatal("RenderUpload() uploaded different bytes")
+	}
+
+	up = &memUploader{err: errors.New("quota exceeded")}
+	if _, err := RenderUpload(ctx, up, "out.png", path, "wm"); err == nil {
+		t.Fatal("RenderUpload() should report the upload error")
+	}
+	if _, err := RenderUpload(ctx, &memUploader{}, "out.png", path+".missing", "wm"); err == nil {
+		t.Fatal("RenderUpload() should report the render error")
+	}
+}
+
+func TestSizeLimits(t *testing.T) {
+	ctx := context.Background()
+	body := testPNG(t, 200, 100)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("chunked") {
+			// no Content-Length, the cap is enforced while reading
+			w.(http.Flusher).Flush()
+		}
+		_, _ = w.Write(body)
+	}))
+	defer srv.Close()
+	path := writeTemp(t, "in.png", body)
+
+	tests := []struct {
+		name   string
+		render func(opts ...Option) (*Result, error)
+		opts   []Option
+		tooBig bool
+	}{
+		{name: "pixels", render: func(opts ...Option) (*Result, error) { return RenderBytes(ctx, body, "wm", opts...) }, opts: []Option{WithMaxPixels(199 * 100)}, tooBig: true},
+		{name: "pixels disabled", render: func(opts ...Option) (*Result, error) { return RenderBytes(ctx, body, "wm", opts...) }, opts: []Option{WithMaxPixels(0)}},
+		{name: "remote content length", render: func(opts ...Option) (*Result, error) { return Render(ctx, srv.URL, "wm", opts...) }, opts: []Option{WithMaxDownloadBytes(100)}, tooBig: true},
+		{name: "remote chunked", render: func(opts ...Option) (*Result, error) { return Render(ctx, srv.URL+"?chunked=1", "wm", opts...) }, opts: []Option{WithMaxDownloadBytes(100)}, tooBig: true},
+		{name: "remote within limit", render: func(opts ...Option) (*Result, error) { return Render(ctx, srv.URL, "wm", opts...) }, opts: []Option{WithMaxDownloadBytes(int64(len(body)))}},
+		{name: "local file", render: func(opts ...Option) (*Result, error) { return Render(ctx, path, "wm", opts...) }, opts: []Option{WithMaxDownloadBytes(100)}, tooBig: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.render(tt.opts...)
+			if tt.tooBig != errors.Is(err, ErrTooLarge) {
+				t.Fatalf("error = %v, want ErrTooLarge = %v", err, tt.tooBig)
+			}
+			if !tt.tooBig && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package watermark
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// TextStyle sets how the watermark text is painted. The zero value paints
+// white text without outline or shadow, the opacity comes from WithAlpha
+type TextStyle struct {
+	Color        color.Color // nil is white
+	OutlineColor color.Color // nil disables the outline
+	OutlineWidth float64     // in pixels
+	ShadowColor  color.Color // nil disables the shadow
+	ShadowDX     float64     // shadow offset in pixels, along the text direction
+	ShadowDY     float64
+	// AutoContrast samples the image under each tile and paints dark text on
+	// light regions and light text on dark ones, the outline takes the
+	// opposite color. Color and OutlineColor are ignored
+	AutoContrast bool
+}
+
+var (
+	lightText = color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	darkText  = color.NRGBA{R: 32, G: 32, B: 32, A: 255}
+)
+
+// WithColor sets the text color, its opacity is set by WithAlpha
+func WithColor(c color.Color) Option {
+	return func(o *options) {
+		o.style.Color = c
+	}
+}
+
+// WithOutline strokes the text with an outline of width pixels, which keeps
+// it readable on busy backgrounds
+func WithOutline(c color.Color, width float64) Option {
+	return func(o *options) {
+		if width > 0 {
+			o.style.OutlineColor = c
+			o.style.OutlineWidth = width
+		}
+	}
+}
+
+// WithShadow draws a drop shadow offset by dx, dy pixels
+func WithShadow(c color.Color, dx, dy float64) Option {
+	return func(o *options) {
+		o.style.ShadowColor = c
+		o.style.ShadowDX = dx
+		o.style.ShadowDY = dy
+	}
+}
+
+// WithAutoContrast picks dark or light text per tile from the brightness of
+// the image underneath, see TextStyle.AutoContrast
+func WithAutoContrast() Option {
+	return func(o *options) {
+		o.style.AutoContrast = true
+	}
+}
+
+// textPaint is a TextStyle resolved for one tile. The colors are opaque,
+// the text is drawn on its own layer which is faded to the alpha as a whole,
+// so the overlapping outline and shadow strokes don't add up
+type textPaint struct {
+	fill         color.NRGBA
+	outline      color.NRGBA
+	outlineWidth float64
+	shadow       color.NRGBA
+	shadowDX     float64
+	shadowDY     float64
+}
+
+// paint resolves the style for a tile, light tells whether the tile lies on
+// a light region (only used with AutoContrast)
+func (s TextStyle) paint(light bool) textPaint {
+	p := textPaint{fill: lightText}
+	if s.Color != nil {
+		p.fill = opaque(s.Color)
+	}
+	if s.OutlineColor != nil && s.OutlineWidth > 0 {
+		p.outline = opaque(s.OutlineColor)
+		p.outlineWidth = s.OutlineWidth
+	}
+	if s.AutoContrast {
+		p.fill, p.outline = lightText, darkText
+		if light {
+			p.fill, p.outline = darkText, lightText
+		}
+	}
+	if s.ShadowColor != nil && (s.ShadowDX != 0 || s.ShadowDY != 0) {
+		p.shadow = opaque(s.ShadowColor)
+		p.shadowDX, p.shadowDY = s.ShadowDX, s.ShadowDY
+	}
+	return p
+}
+
+// margin is the extra space the outline and shadow need around the text
+func (p textPaint) margin() int {
+	return int(math.Ceil(p.outlineWidth + max(math.Abs(p.shadowDX), math.Abs(p.shadowDY))))
+}
+
+// hasShadow reports whether a shadow is drawn
+func (p textPaint) hasShadow() bool {
+	return p.shadowDX != 0 || p.shadowDY != 0
+}
+
+// outlineOffsets returns the positions the text is drawn at, in outline
+// color, to stroke it
+func (p textPaint) outlineOffsets() [][2]float64 {
+	if p.outlineWidth <= 0 {
+		return nil
+	}
+	const directions = 16
+	var offsets [][2]float64
+	rings := math.Ceil(p.outlineWidth)
+	for ring := 1.0; ring <= rings; ring++ {
+		r := p.outlineWidth * ring / rings
+		for i := 0; i < directions; i++ {
+			a := 2 * math.Pi * float64(i) / directions
+			offsets = append(offsets, [2]float64{r * math.Cos(a), r * math.Sin(a)})
+		}
+	}
+	return offsets
+}
+
+// opaque converts c to non-premultiplied RGB at full opacity
+func opaque(c color.Color) color.NRGBA {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n.A = 255
+	return n
+}
+
+// fade scales the opacity of img by alpha/255
+func fade(img *image.NRGBA, alpha uint8) {
+	if alpha == 255 {
+		return
+	}
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = uint8(uint16(img.Pix[i]) * uint16(alpha) / 255)
+	}
+}
+
+// sampleWidth is the width of the thumbnail AutoContrast samples from
+const sampleWidth = 64
+
+// lumaSampler tells the brightness of image regions from a thumbnail
+type lumaSampler struct {
+	img   image.Image
+	scale float64 // thumbnail pixels per image pixel
+}
+
+func newLumaSampler(thumb image.Image, imageWidth int) *lumaSampler {
+	if imageWidth <= 0 || thumb.Bounds().Empty() {
+		return nil
+	}
+	return &lumaSampler{img: thumb, scale: float64(thumb.Bounds().Dx()) / float64(imageWidth)}
+}
+
+// light reports whether the region r (in image pixels) is bright enough for
+// dark text, regions outside the image count as dark
+func (s *lumaSampler) light(r image.Rectangle) bool {
+	if s == nil {
+		return false
+	}
+	b := s.img.Bounds()
+	scaled := image.Rect(
+		b.Min.X+int(float64(r.Min.X)*s.scale), b.Min.Y+int(float64(r.Min.Y)*s.scale),
+		b.Min.X+int(math.Ceil(float64(r.Max.X)*s.scale)), b.Min.Y+int(math.Ceil(float64(r.Max.Y)*s.scale)),
+	).Intersect(b)
+	if scaled.Empty() {
+		return false
+	}
+
+	var sum float64
+	for y := scaled.Min.Y; y < scaled.Max.Y; y++ {
+		for x := scaled.Min.X; x < scaled.Max.X; x++ {
+			cr, cg, cb, _ := s.img.At(x, y).RGBA()
+			sum += 0.299*float64(cr) + 0.587*float64(cg) + 0.114*float64(cb)
+		}
+	}
+	return sum/float64(scaled.Dx()*scaled.Dy())/0xffff > 0.55
+}
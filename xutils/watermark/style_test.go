@@ -0,0 +1,138 @@
+package watermark
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestTextStylePaint(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	black := color.Black
+
+	tests := []struct {
+		name    string
+		style   TextStyle
+		light   bool
+		fill    color.NRGBA
+		outline float64
+		shadow  bool
+	}{
+		{name: "default white", fill: lightText},
+		{name: "color", style: TextStyle{Color: red}, fill: color.NRGBA{R: 255, A: 255}},
+		{name: "translucent color is opaque", style: TextStyle{Color: color.NRGBA{G: 255, A: 10}}, fill: color.NRGBA{G: 255, A: 255}},
+		{name: "outline", style: TextStyle{OutlineColor: black, OutlineWidth: 2}, fill: lightText, outline: 2},
+		{name: "outline without width", style: TextStyle{OutlineColor: black}, fill: lightText},
+		{name: "shadow", style: TextStyle{ShadowColor: black, ShadowDX: 2, ShadowDY: 2}, fill: lightText, shadow: true},
+		{name: "shadow without offset", style: TextStyle{ShadowColor: black}, fill: lightText},
+		{name: "auto contrast dark region", style: TextStyle{AutoContrast: true, Color: red}, fill: lightText},
+		{name: "auto contrast light region", style: TextStyle{AutoContrast: true, Color: red}, light: true, fill: darkText},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := tt.style.paint(tt.light)
+			if p.fill != tt.fill {
+				t.Errorf("fill = %v, want %v", p.fill, tt.fill)
+			}
+			if p.outlineWidth != tt.outline {
+				t.Errorf("outline width = %v, want %v", p.outlineWidth, tt.outline)
+			}
+			if p.hasShadow() != tt.shadow {
+				t.Errorf("hasShadow() = %v, want %v", p.hasShadow(), tt.shadow)
+			}
+			if tt.outline > 0 && len(p.outlineOffsets()) == 0 {
+				t.Error("outline has no offsets")
+			}
+		})
+	}
+}
+
+func TestLumaSampler(t *testing.T) {
+	// left half black, right half white
+	thumb := image.NewGray(image.Rect(0, 0, 10, 10))
+	for y := 0; y < 10; y++ {
+		for x := 5; x < 10; x++ {
+			thumb.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	s := newLumaSampler(thumb, 100)
+
+	if s.light(image.Rect(0, 0, 40, 100)) {
+		t.Error("black region reported light")
+	}
+	if !s.light(image.Rect(60, 0, 100, 100)) {
+		t.Error("white region reported dark")
+	}
+	if s.light(image.Rect(200, 200, 300, 300)) {
+		t.Error("region outside the image reported light")
+	}
+	var nilSampler *lumaSampler
+	if nilSampler.light(image.Rect(0, 0, 10, 10)) {
+		t.Error("nil sampler reported light")
+	}
+}
+
+func TestRenderStyle(t *testing.T) {
+	ctx := context.Background()
+	white := solidPNG(t, 320, 240, color.White)
+
+	// the default white text is invisible on a white image, auto contrast
+	// and a dark outline are not
+	tests := []struct {
+		name string
+		opts []Option
+		dark bool
+	}{
+		{name: "default", dark: false},
+		{name: "auto contrast", opts: []Option{WithAutoContrast()}, dark: true},
+		{name: "outline", opts: []Option{WithOutline(color.Black, 2)}, dark: true},
+		{name: "color", opts: []Option{WithColor(color.Black)}, dark: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithFormat(FormatPNG), WithAlpha(200)}, tt.opts...)
+			res, err := RenderBytes(ctx, white, "CONFIDENTIAL", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hasDarkPixel(t, res.Data); got != tt.dark {
+				t.Errorf("dark pixels = %v, want %v", got, tt.dark)
+			}
+		})
+	}
+}
+
+func solidPNG(t *testing.T, w, h int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func hasDarkPixel(t *testing.T, data []byte) bool {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if r, _, _, _ := img.At(x, y).RGBA(); r < 0x8000 {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package watermark
+
+import (
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultTimeFormat = "2006-01-02 15:04:05"
+
+// WithVars sets values for the {name} placeholders of the watermark text,
+// e.g. WithVars(map[string]string{"user": uid, "ip": ip, "page": "3"}).
+// Built in placeholders are {time}, {date} (render time, see WithTime) and
+// {row} (1-based tile row). Unknown placeholders are rendered as is
+func WithVars(vars map[string]string) Option {
+	return func(o *options) {
+		if o.vars == nil {
+			o.vars = make(map[string]string, len(vars))
+		}
+		maps.Copy(o.vars, vars)
+	}
+}
+
+// WithTime pins the time used by {time} and {date}. Without it the render
+// time is used, except with WithDeterministic where they render empty
+func WithTime(t time.Time) Option {
+	return func(o *options) {
+		o.now = t
+	}
+}
+
+// WithTimeFormat sets the layout of {time}, default "2006-01-02 15:04:05"
+func WithTimeFormat(layout string) Option {
+	return func(o *options) {
+		o.timeFormat = layout
+	}
+}
+
+// WithRowTemplates varies the text per tile row: row i renders
+// templates[i % len(templates)] instead of the text argument, e.g.
+// WithRowTemplates("{user} {ip}", "{time}") alternates two lines
+func WithRowTemplates(templates ...string) Option {
+	return func(o *options) {
+		o.rowTemplates = append([]string(nil), templates...)
+	}
+}
+
+// rowTexts resolves the placeholders of text once per render and returns the
+// text of each tile row. Rows repeat, so results are memoized by template
+type rowTexts struct {
+	templates []string
+	vars      map[string]string
+	perRow    bool // some template uses {row}
+	resolved  map[string]string
+}
+
+func newRowTexts(text string, o *options) *rowTexts {
+	r := &rowTexts{
+		templates: o.rowTemplates,
+		vars:      make(map[string]string, len(o.vars)+2),
+		resolved:  make(map[string]string),
+	}
+	if len(r.templates) == 0 {
+		r.templates = []string{text}
+	}
+
+	now := o.now
+	if now.IsZero() && !o.deterministic {
+		now = time.Now()
+	}
+	if !now.IsZero() {
+		r.vars["time"] = now.Format(withDefault(o.timeFormat, defaultTimeFormat))
+		r.vars["date"] = now.Format(time.DateOnly)
+	} else {
+		r.vars["time"], r.vars["date"] = "", ""
+	}
+	maps.Copy(r.vars, o.vars)
+
+	for _, t := range r.templates {
+		r.perRow = r.perRow || strings.Contains(t, "{row}")
+	}
+	return r
+}
+
+// text returns the text of tile row (0-based)
+func (r *rowTexts) text(row int) string {
+	tmpl := r.templates[row%len(r.templates)]
+	if r.perRow {
+		r.vars["row"] = strconv.Itoa(row + 1)
+		return expandTemplate(tmpl, r.vars)
+	}
+	if s, ok := r.resolved[tmpl]; ok {
+		return s
+	}
+	s := expandTemplate(tmpl, r.vars)
+	r.resolved[tmpl] = s
+	return s
+}
+
+// expandTemplate replaces {name} with vars[name], leaving unknown names as is
+func expandTemplate(tmpl string, vars map[string]string) string {
+	if !strings.Contains(tmpl, "{") {
+		return tmpl
+	}
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(tmpl, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(tmpl[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(tmpl[:start])
+		if v, ok := vars[tmpl[start+1:end]]; ok {
+			b.WriteString(v)
+		} else {
+			b.WriteString(tmpl[start : end+1])
+		}
+		tmpl = tmpl[end+1:]
+	}
+	b.WriteString(tmpl)
+	return b.String()
+}
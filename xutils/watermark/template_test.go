@@ -0,0 +1,106 @@
+package watermark
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	vars := map[string]string{"user": "u1001", "ip": "10.0.0.1", "empty": ""}
+
+	tests := []struct {
+		tmpl string
+		want string
+	}{
+		{tmpl: "CONFIDENTIAL", want: "CONFIDENTIAL"},
+		{tmpl: "{user} {ip}", want: "u1001 10.0.0.1"},
+		{tmpl: "[{user}]{empty}", want: "[u1001]"},
+		{tmpl: "{unknown} {user}", want: "{unknown} u1001"},
+		{tmpl: "open {user", want: "open {user"},
+		{tmpl: "{}", want: "{}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tmpl, func(t *testing.T) {
+			if got := expandTemplate(tt.tmpl, vars); got != tt.want {
+				t.Errorf("expandTemplate(%q) = %q, want %q", tt.tmpl, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRowTexts(t *testing.T) {
+	at := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		text string
+		opts []Option
+		want []string // rows 0, 1, 2
+	}{
+		{
+			name: "vars and time",
+			text: "{user} {time}",
+			opts: []Option{WithVars(map[string]string{"user": "alice"}), WithTime(at)},
+			want: []string{"alice 2025-03-01 09:30:00", "alice 2025-03-01 09:30:00", "alice 2025-03-01 09:30:00"},
+		},
+		{
+			name: "time format",
+			text: "{date} {time}",
+			opts: []Option{WithTime(at), WithTimeFormat("15:04")},
+			want: []string{"2025-03-01 09:30", "2025-03-01 09:30", "2025-03-01 09:30"},
+		},
+		{
+			name: "deterministic without time",
+			text: "{user}{time}",
+			opts: []Option{WithDeterministic(), WithVars(map[string]string{"user": "bob"})},
+			want: []string{"bob", "bob", "bob"},
+		},
+		{
+			name: "row templates",
+			text: "ignored",
+			opts: []Option{WithRowTemplates("{user}", "page {page}"), WithVars(map[string]string{"user": "carol", "page": "2"})},
+			want: []string{"carol", "page 2", "carol"},
+		},
+		{
+			name: "row number",
+			text: "{user}#{row}",
+			opts: []Option{WithVars(map[string]string{"user": "dan"})},
+			want: []string{"dan#1", "dan#2", "dan#3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			texts := newRowTexts(tt.text, newOptions(tt.opts))
+			for row, want := range tt.want {
+				if got := texts.text(row); got != want {
+					t.Errorf("row %d = %q, want %q", row, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	body := testPNG(t, 320, 240)
+	ctx := context.Background()
+	at := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	render := func(user string) string {
+		t.Helper()
+		res, err := RenderBytes(ctx, body, "{user} {time}",
+			WithDeterministic(), WithTime(at), WithVars(map[string]string{"user": user}),
+			WithRowTemplates("{user} {time}", "{user} #{row}"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.Hash
+	}
+
+	if render("alice") != render("alice") {
+		t.Error("same vars rendered different images")
+	}
+	if render("alice") == render("bob") {
+		t.Error("different vars rendered the same image")
+	}
+}
@@ -0,0 +1,336 @@
+// Package video burns the tiled watermark of package watermark into videos
+// by running ffmpeg. The watermark is rendered once as a transparent overlay
+// at the display size of the video, so it looks the same as on images.
+package video
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gomod.pri/golib/xutils/watermark"
+)
+
+// ErrFFmpegNotFound is returned when the ffmpeg or ffprobe binary is missing
+var ErrFFmpegNotFound = errors.New("video: ffmpeg not found")
+
+// maxStderr bounds the ffmpeg output kept for error messages
+const maxStderr = 4 << 10
+
+// Progress reports how much of the input has been encoded
+type Progress struct {
+	Processed time.Duration
+	Duration  time.Duration // 0 if ffprobe couldn't tell
+	Done      bool
+}
+
+// Percent returns the progress in [0, 100], 0 when the duration is unknown
+func (p Progress) Percent() float64 {
+	if p.Done {
+		return 100
+	}
+	if p.Duration <= 0 {
+		return 0
+	}
+	return min(float64(p.Processed)/float64(p.Duration)*100, 100)
+}
+
+// Option configures Render
+type Option func(*options)
+
+type options struct {
+	ffmpeg       string
+	ffprobe      string
+	progress     func(Progress)
+	imageOpts    []watermark.Option
+	logo         string
+	crf          int
+	preset       string
+	keepMetadata bool
+}
+
+// WithFFmpeg sets the ffmpeg and ffprobe binaries, default looked up in PATH
+func WithFFmpeg(ffmpeg, ffprobe string) Option {
+	return func(o *options) {
+		o.ffmpeg = ffmpeg
+		o.ffprobe = ffprobe
+	}
+}
+
+// WithProgress calls fn as ffmpeg reports progress, from a single goroutine
+func WithProgress(fn func(Progress)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// WithWatermarkOptions styles the tiled text like the image watermark,
+// e.g. WithWatermarkOptions(watermark.WithAlpha(80), watermark.WithVars(vars))
+func WithWatermarkOptions(opts ...watermark.Option) Option {
+	return func(o *options) {
+		o.imageOpts = append(o.imageOpts, opts...)
+	}
+}
+
+// WithLogo overlays the image at path (e.g. a PNG with transparency) in the
+// bottom right corner, on top of the tiled text
+func WithLogo(path string) Option {
+	return func(o *options) {
+		o.logo = path
+	}
+}
+
+// WithQuality sets the x264 CRF (0-51, lower is better, default 23) and
+// preset (default "veryfast")
+func WithQuality(crf int, preset string) Option {
+	return func(o *options) {
+		o.crf = crf
+		o.preset = preset
+	}
+}
+
+// WithKeepMetadata keeps the container metadata, which is stripped by default
+// because phone recordings carry the GPS location
+func WithKeepMetadata() Option {
+	return func(o *options) {
+		o.keepMetadata = true
+	}
+}
+
+// Render watermarks the video at in (MP4, MOV or anything ffmpeg reads) and
+// writes it to out as H.264, the container follows the extension of out.
+// Audio is copied unchanged
+func Render(ctx context.Context, in, out, text string, opts ...Option) error {
+	o := &options{crf: 23, preset: "veryfast"}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := o.resolveBinaries(); err != nil {
+		return err
+	}
+
+	info, err := probe(ctx, o.ffprobe, in)
+	if err != nil {
+		return err
+	}
+
+	overlay, err := watermark.Overlay(ctx, info.Width, info.Height, text, o.imageOpts...)
+	if err != nil {
+		return fmt.Errorf("video: render overlay: %w", err)
+	}
+	overlayPath, err := writeTemp(overlay.Data)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(overlayPath)
+
+	return o.run(ctx, buildArgs(in, overlayPath, out, o), info.Duration)
+}
+
+func (o *options) resolveBinaries() error {
+	var err error
+	if o.ffmpeg, err = lookPath(o.ffmpeg, "ffmpeg"); err != nil {
+		return err
+	}
+	o.ffprobe, err = lookPath(o.ffprobe, "ffprobe")
+	return err
+}
+
+func lookPath(name, def string) (string, error) {
+	if name == "" {
+		name = def
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrFFmpegNotFound, err)
+	}
+	return path, nil
+}
+
+func writeTemp(data []byte) (string, error) {
+	f, err := os.CreateTemp("", "watermark-*.png")
+	if err != nil {
+		return "", fmt.Errorf("video: create overlay file: %w", err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", fmt.Errorf("video: write overlay file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("video: write overlay file: %w", err)
+	}
+	return f.Name(), nil
+}
+
+func buildArgs(in, overlay, out string, o *options) []string {
+	args := []string{"-hide_banner", "-nostdin", "-y", "-i", in, "-i", overlay}
+
+	// ffmpeg applies the rotation of phone videos before the filters, the
+	// overlay is rendered at that display size
+	filter := "[0:v][1:v]overlay=0:0:format=auto[v]"
+	if o.logo != "" {
+		args = append(args, "-i", o.logo)
+		filter = "[0:v][1:v]overlay=0:0:format=auto[tiled];[tiled][2:v]overlay=W-w-16:H-h-16:format=auto[v]"
+	}
+
+	args = append(args,
+		"-filter_complex", filter,
+		"-map", "[v]", "-map", "0:a?",
+		"-c:v", "libx264", "-preset", o.preset, "-crf", strconv.Itoa(o.crf), "-pix_fmt", "yuv420p",
+		"-c:a", "copy",
+	)
+	if !o.keepMetadata {
+		args = append(args, "-map_metadata", "-1")
+	}
+	if ext := strings.ToLower(filepath.Ext(out)); ext == ".mp4" || ext == ".mov" {
+		args = append(args, "-movflags", "+faststart")
+	}
+	return append(args, "-progress", "pipe:1", "-nostats", out)
+}
+
+func (o *options) run(ctx context.Context, args []string, duration time.Duration) error {
+	cmd := exec.CommandContext(ctx, o.ffmpeg, args...)
+	stderr := &tailBuffer{max: maxStderr}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("video: ffmpeg stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("video: start ffmpeg: %w", err)
+	}
+
+	readProgress(stdout, duration, o.progress)
+
+	if err := cmd.Wait(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return fmt.Errorf("video: ffmpeg failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// readProgress parses the key=value blocks of ffmpeg -progress until EOF
+func readProgress(r io.Reader, duration time.Duration, fn func(Progress)) {
+	p := Progress{Duration: duration}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "out_time_us", "out_time_ms": // both are microseconds
+			if us, err := strconv.ParseInt(value, 10, 64); err == nil && us >= 0 {
+				p.Processed = time.Duration(us) * time.Microsecond
+			}
+		case "progress":
+			p.Done = value == "end"
+			if fn != nil {
+				fn(p)
+			}
+		}
+	}
+	// drain so ffmpeg never blocks on a full pipe
+	_, _ = io.Copy(io.Discard, r)
+}
+
+type videoInfo struct {
+	Width    int
+	Height   int
+	Duration time.Duration
+}
+
+// probe reads the display size and duration of the first video stream
+func probe(ctx context.Context, ffprobe, in string) (videoInfo, error) {
+	out, err := exec.CommandContext(ctx, ffprobe,
+		"-v", "error", "-select_streams", "v:0", "-show_streams", "-show_format", "-of", "json", in,
+	).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return videoInfo{}, fmt.Errorf("video: ffprobe %s: %w: %s", in, err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return videoInfo{}, fmt.Errorf("video: ffprobe %s: %w", in, err)
+	}
+	return parseProbe(out)
+}
+
+type probeOutput struct {
+	Streams []struct {
+		Width        int               `json:"width"`
+		Height       int               `json:"height"`
+		Tags         map[string]string `json:"tags"`
+		SideDataList []struct {
+			Rotation float64 `json:"rotation"`
+		} `json:"side_data_list"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+func parseProbe(data []byte) (videoInfo, error) {
+	var po probeOutput
+	if err := json.Unmarshal(data, &po); err != nil {
+		return videoInfo{}, fmt.Errorf("video: parse ffprobe output: %w", err)
+	}
+	if len(po.Streams) == 0 || po.Streams[0].Width <= 0 || po.Streams[0].Height <= 0 {
+		return videoInfo{}, errors.New("video: no video stream")
+	}
+
+	s := po.Streams[0]
+	info := videoInfo{Width: s.Width, Height: s.Height}
+
+	// rotate tag (older muxers) or display matrix side data
+	rotation, _ := strconv.Atoi(s.Tags["rotate"])
+	for _, sd := range s.SideDataList {
+		if sd.Rotation != 0 {
+			rotation = int(sd.Rotation)
+		}
+	}
+	if r := ((rotation % 180) + 180) % 180; r == 90 {
+		info.Width, info.Height = info.Height, info.Width
+	}
+
+	if sec, err := strconv.ParseFloat(po.Format.Duration, 64); err == nil && sec > 0 {
+		info.Duration = time.Duration(sec * float64(time.Second))
+	}
+	return info, nil
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.max; over > 0 {
+		t.buf = append(t.buf[:0], t.buf[over:]...)
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
@@ -0,0 +1,143 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gomod.pri/golib/xutils/watermark"
+)
+
+func TestParseProbe(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want videoInfo
+	}{
+		{
+			name: "landscape",
+			json: `{"streams":[{"width":1920,"height":1080}],"format":{"duration":"12.500000"}}`,
+			want: videoInfo{Width: 1920, Height: 1080, Duration: 12500 * time.Millisecond},
+		},
+		{
+			name: "rotate tag",
+			json: `{"streams":[{"width":1920,"height":1080,"tags":{"rotate":"90"}}],"format":{"duration":"3"}}`,
+			want: videoInfo{Width: 1080, Height: 1920, Duration: 3 * time.Second},
+		},
+		{
+			name: "display matrix",
+			json: `{"streams":[{"width":1280,"height":720,"side_data_list":[{"rotation":-90}]}],"format":{}}`,
+			want: videoInfo{Width: 720, Height: 1280},
+		},
+		{
+			name: "upside down",
+			json: `{"streams":[{"width":1280,"height":720,"side_data_list":[{"rotation":180}]}],"format":{"duration":"N/A"}}`,
+			want: videoInfo{Width: 1280, Height: 720},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProbe([]byte(tt.json))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("parseProbe() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseProbe([]byte(`{"streams":[],"format":{}}`)); err == nil {
+		t.Error("parseProbe() accepted a file without video stream")
+	}
+}
+
+func TestReadProgress(t *testing.T) {
+	out := strings.Join([]string{
+		"frame=10", "out_time_us=1000000", "progress=continue",
+		"frame=20", "out_time_ms=2000000", "progress=continue",
+		"out_time_us=4000000", "progress=end",
+	}, "\n")
+
+	var got []Progress
+	readProgress(strings.NewReader(out), 4*time.Second, func(p Progress) {
+		got = append(got, p)
+	})
+
+	if len(got) != 3 {
+		t.Fatalf("got %d progress reports, want 3", len(got))
+	}
+	if got[0].Percent() != 25 || got[1].Percent() != 50 || !got[2].Done || got[2].Percent() != 100 {
+		t.Errorf("progress = %+v", got)
+	}
+	if (Progress{Processed: time.Second}).Percent() != 0 {
+		t.Error("Percent() without duration should be 0")
+	}
+}
+
+func TestBuildArgs(t *testing.T) {
+	o := &options{crf: 23, preset: "veryfast"}
+	args := buildArgs("in.mov", "wm.png", "out.mp4", o)
+	for _, want := range []string{"-map_metadata", "+faststart", "pipe:1"} {
+		if !slices.Contains(args, want) {
+			t.Errorf("args %v missing %q", args, want)
+		}
+	}
+	if args[len(args)-1] != "out.mp4" {
+		t.Errorf("output must be the last argument, got %v", args)
+	}
+
+	o.logo, o.keepMetadata = "logo.png", true
+	args = buildArgs("in.mov", "wm.png", "out.mkv", o)
+	if !slices.Contains(args, "logo.png") || slices.Contains(args, "-map_metadata") || slices.Contains(args, "+faststart") {
+		t.Errorf("unexpected args %v", args)
+	}
+}
+
+func TestOverlaySize(t *testing.T) {
+	res, err := watermark.Overlay(context.Background(), 640, 360, "KYC {user}",
+		watermark.WithVars(map[string]string{"user": "u1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(res.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 640 || b.Dy() != 360 {
+		t.Errorf("overlay size = %v, want 640x360", b)
+	}
+}
+
+func TestRender(t *testing.T) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		t.Skip("ffmpeg not installed")
+	}
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.mp4")
+	gen := exec.Command(ffmpeg, "-hide_banner", "-v", "error", "-f", "lavfi", "-i", "testsrc=size=320x240:rate=10",
+		"-t", "1", "-pix_fmt", "yuv420p", in)
+	if out, err := gen.CombinedOutput(); err != nil {
+		t.Skipf("ffmpeg can't generate a test video: %v: %s", err, out)
+	}
+
+	out := filepath.Join(dir, "out.mp4")
+	var last Progress
+	if err := Render(context.Background(), in, out, "CONFIDENTIAL", WithProgress(func(p Progress) { last = p })); err != nil {
+		t.Fatal(err)
+	}
+	if st, err := os.Stat(out); err != nil || st.Size() == 0 {
+		t.Fatalf("output missing: %v", err)
+	}
+	if !last.Done {
+		t.Errorf("last progress = %+v, want done", last)
+	}
+}
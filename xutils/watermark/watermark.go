@@ -9,20 +9,22 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"image/png"
 	"io"
-	"net/http"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/davidbyttow/govips/v2/vips"
 	"github.com/disintegration/imaging"
 	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
-	"github.com/zeromicro/go-zero/core/logc"
 	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
 )
 
+// renderer identifies the build in cache keys, outputs differ between builds
+const renderer = "libvips"
+
 type Config struct {
 	ImageBody         []byte
 	InputPath         string
@@ -32,121 +34,277 @@ type Config struct {
 	TileSpacingFactor float64
 	MinTileStep       int
 	Alpha             int
+	Angle             float64 // degrees, counter-clockwise
+	FontSize          float64 // 0 derives the size from the image
+	FontScale         float64 // multiplies the derived font size, 0 means 1
+	RotationJitter    float64 // max per-tile rotation offset in degrees, see WithRotationJitter
+	Deterministic     bool    // pin encoder settings, see WithDeterministic
+	Format            Format  // empty writes JPEG
+	EXIF              EXIFPolicy
+	MaxDownloadBytes  int64 // 0 means unlimited
+	MaxPixels         int64 // 0 means unlimited
+	// TextForRow returns the text of a tile row (0-based), nil renders
+	// WatermarkText on every row
+	TextForRow func(row int) string
+	Style      TextStyle
+	// Exclusions are rectangles of the upright input image where no tile is
+	// placed, see WithExclusions
+	Exclusions []image.Rectangle
+}
+
+func (cfg Config) rowText(row int) string {
+	if cfg.TextForRow == nil {
+		return cfg.WatermarkText
+	}
+	return cfg.TextForRow(row)
 }
 
 var (
 	fontCache     *truetype.Font
 	fontCacheOnce sync.Once
-	httpClient    = &http.Client{Timeout: 15 * time.Second}
 	vipsInitOnce  sync.Once
 	wmLRU         = newWatermarkLRU(128)
 )
 
-func AddFromBytes(ctx context.Context, body []byte, text string) (io.ReadCloser, error) {
+func encode(ctx context.Context, in input, text string, o *options, w io.Writer) (Format, error) {
 	cfg := Config{
-		ImageBody:         body,
+		ImageBody:         in.body,
+		InputPath:         in.path,
 		WatermarkText:     text,
-		MaxWidth:          2000,
-		Quality:           85,
-		TileSpacingFactor: 1.4,
+		MaxWidth:          withDefault(o.maxWidth, 2000),
+		Quality:           withDefault(o.quality, 85),
+		TileSpacingFactor: withDefault(o.tileSpacing, 1.4),
 		MinTileStep:       140,
-		Alpha:             50,
+		Alpha:             withDefault(o.alpha, 50),
+		Angle:             30,
+		FontSize:          o.fontSize,
+		FontScale:         o.fontScale,
+		RotationJitter:    o.jitter,
+		Deterministic:     o.deterministic,
+		Format:            o.format,
+		EXIF:              o.exif,
+		MaxDownloadBytes:  o.downloadLimit(),
+		MaxPixels:         o.pixelLimit(),
+		Style:             o.style,
+		Exclusions:        o.exclusions,
+	}
+	if o.angleSet {
+		cfg.Angle = o.angle
+	}
+	texts := newRowTexts(text, o)
+	cfg.WatermarkText = texts.text(0)
+	cfg.TextForRow = texts.text
+
+	outputBytes, format, err := applyWatermark(ctx, cfg)
+	if err != nil {
+		return "", fmt.Errorf("applyWatermark error: %w", err)
 	}
 
-	outputBytes, err := applyWatermark(cfg)
+	if _, err := w.Write(outputBytes); err != nil {
+		return "", fmt.Errorf("write output error: %w", err)
+	}
+	return format, nil
+}
+
+func applyWatermark(ctx context.Context, cfg Config) ([]byte, Format, error) {
+	initVIPS()
+
+	// the pixels are decoded lazily, so the decode span mostly covers loading
+	// the input and the later spans include the decoding they trigger
+	decodeCtx, endDecode := startPhase(ctx, phaseDecode)
+	baseRef, err := loadBaseImage(decodeCtx, cfg)
 	if err != nil {
-		logc.Errorf(ctx, "applyWatermark error: %v", err)
-		return nil, err
+		endDecode(err)
+		return nil, "", err
 	}
+	defer baseRef.Close()
 
-	return io.NopCloser(bytes.NewReader(outputBytes)), nil
-}
+	// libvips decodes lazily, so this runs before the pixels are loaded
+	if err := checkPixels(baseRef.Width(), baseRef.Height(), cfg.MaxPixels); err != nil {
+		endDecode(err)
+		return nil, "", err
+	}
 
-func Add(ctx context.Context, path string, text string) (io.ReadCloser, error) {
-	cfg := Config{
-		InputPath:         path,
-		WatermarkText:     text,
-		MaxWidth:          2000,
-		Quality:           85,
-		TileSpacingFactor: 1.4,
-		MinTileStep:       140,
-		Alpha:             50,
+	_ = baseRef.AutoRotate()
+	endDecode(nil)
+
+	uprightWidth := baseRef.Width()
+	if err := resizeBase(ctx, baseRef, cfg); err != nil {
+		return nil, "", err
 	}
 
-	outputBytes, err := applyWatermark(cfg)
-	if err != nil {
-		logc.Errorf(ctx, "applyWatermark error: %v", err)
-		return nil, err
+	tiles := &tileSet{
+		base:     baseRef,
+		cfg:      cfg,
+		fontSize: determineFontSize(baseRef, cfg),
+		excluded: newExclusionSet(cfg.Exclusions, uprightWidth, baseRef.Width()),
+		refs:     make(map[tileKey]*vips.ImageRef),
 	}
+	defer tiles.close()
 
-	return io.NopCloser(bytes.NewReader(outputBytes)), nil
-}
+	_, endComposite := startPhase(ctx, phaseComposite)
+	if err := composite(baseRef, tiles, cfg); err != nil {
+		endComposite(err)
+		return nil, "", err
+	}
+	endComposite(nil)
 
-func applyWatermark(cfg Config) ([]byte, error) {
-	initVIPS()
+	_, endEncode := startPhase(ctx, phaseEncode)
+	format := outputFormat(cfg.Format, vipsFormat(baseRef.Format()), FormatJPEG)
+	if err := applyEXIFPolicy(baseRef, cfg.EXIF); err != nil {
+		endEncode(err)
+		return nil, "", fmt.Errorf("applyEXIFPolicy error: %w", err)
+	}
 
-	baseRef, err := loadBaseImage(cfg)
+	outputBytes, err := export(baseRef, format, cfg)
+	endEncode(err)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
-	defer baseRef.Close()
 
-	_ = baseRef.AutoRotate()
+	return outputBytes, format, nil
+}
+
+// resizeBase downscales images wider than MaxWidth and converts them to sRGB
+// with alpha for compositing
+func resizeBase(ctx context.Context, baseRef *vips.ImageRef, cfg Config) (err error) {
+	_, end := startPhase(ctx, phaseResize)
+	defer func() { end(err) }()
 
 	if cfg.MaxWidth > 0 && baseRef.Width() > cfg.MaxWidth {
 		scale := float64(cfg.MaxWidth) / float64(baseRef.Width())
 		if err := baseRef.Resize(scale, vips.KernelAuto); err != nil {
-			return nil, fmt.Errorf("resize error: %w", err)
+			return fmt.Errorf("resize error: %w", err)
 		}
 	}
 
 	if err := ensureRGBA(baseRef); err != nil {
-		return nil, fmt.Errorf("ensureRGBA error: %w", err)
+		return fmt.Errorf("ensureRGBA error: %w", err)
 	}
+	return nil
+}
 
-	fontSize := determineFontSize(baseRef, cfg)
-
-	watermarkPNG, err := createTextWatermarkPNG(cfg.WatermarkText, cfg.Alpha, fontSize)
-	if err != nil {
-		return nil, fmt.Errorf("createTextWatermarkPNG error: %w", err)
+// composite renders the tiles and blends them over the base image
+func composite(baseRef *vips.ImageRef, tiles *tileSet, cfg Config) error {
+	if cfg.Style.AutoContrast {
+		sampler, err := newVipsSampler(baseRef)
+		if err != nil {
+			return err
+		}
+		tiles.sampler = sampler
 	}
 
-	wmRef, err := vips.NewImageFromBuffer(watermarkPNG)
+	compositeItems, err := buildCompositeGrid(baseRef, tiles, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("newImageFromBuffer error: %w", err)
+		return err
 	}
-	defer wmRef.Close()
-
-	if err := ensureRGBA(wmRef); err != nil {
-		return nil, fmt.Errorf("ensureRGBA error: %w", err)
+	if len(compositeItems) == 0 {
+		if len(tiles.excluded) > 0 {
+			// every tile overlaps an exclusion
+			return nil
+		}
+		return fmt.Errorf("no composite items")
 	}
 
-	if wmRef.Interpretation() != baseRef.Interpretation() {
-		if err := wmRef.ToColorSpace(baseRef.Interpretation()); err != nil {
-			return nil, fmt.Errorf("toColorSpace error: %w", err)
-		}
+	if err := baseRef.CompositeMulti(compositeItems); err != nil {
+		return fmt.Errorf("compositeMulti error: %w", err)
 	}
+	return nil
+}
 
-	if wmRef.BandFormat() != baseRef.BandFormat() {
-		if err := wmRef.Cast(baseRef.BandFormat()); err != nil {
-			return nil, fmt.Errorf("cast error: %w", err)
+// vipsFormat maps the decoded image type to a Format, other types count as JPEG
+func vipsFormat(t vips.ImageType) Format {
+	switch t {
+	case vips.ImageTypePNG:
+		return FormatPNG
+	case vips.ImageTypeWEBP:
+		return FormatWebP
+	case vips.ImageTypeAVIF:
+		return FormatAVIF
+	case vips.ImageTypeHEIF:
+		return FormatHEIC
+	}
+	return FormatJPEG
+}
+
+// applyEXIFPolicy drops the metadata fields the policy doesn't keep, AutoRotate
+// already reset the orientation
+func applyEXIFPolicy(img *vips.ImageRef, policy EXIFPolicy) error {
+	if policy != EXIFKeepNoGPS {
+		return nil
+	}
+	var keep []string
+	for _, field := range img.ImageFields() {
+		// libvips 把 GPS IFD 解析为 exif-ifd3-*
+		if !strings.HasPrefix(field, "exif-ifd3-") {
+			keep = append(keep, field)
 		}
 	}
+	return img.RemoveMetadata(keep...)
+}
 
-	compositeItems := buildCompositeGrid(baseRef, wmRef, cfg)
-	if len(compositeItems) == 0 {
-		return nil, fmt.Errorf("no composite items")
-	}
+func export(img *vips.ImageRef, format Format, cfg Config) ([]byte, error) {
+	strip := cfg.EXIF == EXIFStrip
 
-	if err := baseRef.CompositeMulti(compositeItems); err != nil {
-		return nil, fmt.Errorf("compositeMulti error: %w", err)
+	switch format {
+	case FormatPNG:
+		ep := vips.NewPngExportParams()
+		ep.StripMetadata = strip
+		out, _, err := img.ExportPng(ep)
+		if err != nil {
+			return nil, fmt.Errorf("exportPng error: %w", err)
+		}
+		return out, nil
+	case FormatWebP:
+		ep := vips.NewWebpExportParams()
+		ep.Quality = cfg.Quality
+		ep.StripMetadata = strip
+		out, _, err := img.ExportWebp(ep)
+		if err != nil {
+			return nil, fmt.Errorf("exportWebp error: %w", err)
+		}
+		return out, nil
+	case FormatAVIF:
+		ep := vips.NewAvifExportParams()
+		ep.Quality = cfg.Quality
+		ep.StripMetadata = strip
+		out, _, err := img.ExportAvif(ep)
+		if err != nil {
+			return nil, fmt.Errorf("exportAvif error: %w", err)
+		}
+		return out, nil
+	case FormatHEIC:
+		// HeifExportParams has no StripMetadata in govips v2.16.0 and heifsave
+		// keeps EXIF/GPS, so drop the metadata on the image itself
+		if strip {
+			if err := img.RemoveMetadata(); err != nil {
+				return nil, fmt.Errorf("removeMetadata error: %w", err)
+			}
+		}
+		ep := vips.NewHeifExportParams()
+		ep.Quality = cfg.Quality
+		out, _, err := img.ExportHeif(ep)
+		if err != nil {
+			return nil, fmt.Errorf("exportHeif error: %w", err)
+		}
+		return out, nil
 	}
 
 	ep := vips.NewJpegExportParams()
 	ep.Quality = cfg.Quality
-	ep.StripMetadata = true
-
-	outputBytes, _, err := baseRef.ExportJpeg(ep)
+	ep.StripMetadata = strip
+	if cfg.Deterministic {
+		// baseline encoding with fixed tables, independent of libvips defaults
+		ep.Interlace = false
+		ep.OptimizeCoding = false
+		ep.OptimizeScans = false
+		ep.TrellisQuant = false
+		ep.OvershootDeringing = false
+		ep.SubsampleMode = vips.VipsForeignSubsampleOn
+		ep.QuantTable = 0
+	}
+
+	outputBytes, _, err := img.ExportJpeg(ep)
 	if err != nil {
 		return nil, fmt.Errorf("exportJpeg error: %w", err)
 	}
@@ -154,43 +312,34 @@ func applyWatermark(cfg Config) ([]byte, error) {
 	return outputBytes, nil
 }
 
-func loadBaseImage(cfg Config) (*vips.ImageRef, error) {
+func loadBaseImage(ctx context.Context, cfg Config) (*vips.ImageRef, error) {
 	if len(cfg.ImageBody) > 0 {
 		return vips.NewImageFromBuffer(cfg.ImageBody)
 	}
 
-	if strings.HasPrefix(cfg.InputPath, "http://") || strings.HasPrefix(cfg.InputPath, "https://") {
-		data, err := fetchRemote(cfg.InputPath)
+	if isRemote(cfg.InputPath) {
+		data, _, err := fetchRemote(ctx, cfg.InputPath, cfg.MaxDownloadBytes)
 		if err != nil {
 			return nil, err
 		}
 		return vips.NewImageFromBuffer(data)
 	}
-	return vips.NewImageFromFile(cfg.InputPath)
-}
-
-func fetchRemote(url string) ([]byte, error) {
-	resp, err := httpClient.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("fetchRemote error: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("fetchRemote error: status code %d", resp.StatusCode)
-	}
 
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("readAll error: %w", err)
+	if err := checkFileSize(cfg.InputPath, cfg.MaxDownloadBytes); err != nil {
+		return nil, err
 	}
-
-	return data, nil
+	return vips.NewImageFromFile(cfg.InputPath)
 }
 
 func determineFontSize(img *vips.ImageRef, cfg Config) float64 {
+	if cfg.FontSize > 0 {
+		return cfg.FontSize
+	}
 	diagonalLen := float64(img.Width() + img.Height())
 	size := diagonalLen * 0.03
+	if cfg.FontScale > 0 {
+		size *= cfg.FontScale
+	}
 	if size < 24 {
 		size = 24
 	}
@@ -200,27 +349,134 @@ func determineFontSize(img *vips.ImageRef, cfg Config) float64 {
 	return size
 }
 
-func buildCompositeGrid(baseRef, wmRef *vips.ImageRef, cfg Config) []*vips.ImageComposite {
-	wmWidth := wmRef.Width()
-	wmHeight := wmRef.Height()
+// newVipsSampler renders a small copy of img for AutoContrast
+func newVipsSampler(img *vips.ImageRef) (*lumaSampler, error) {
+	thumb, err := img.Copy()
+	if err != nil {
+		return nil, fmt.Errorf("copy error: %w", err)
+	}
+	defer thumb.Close()
 
-	xStep := int(float64(wmWidth) * cfg.TileSpacingFactor)
-	if xStep < cfg.MinTileStep {
-		xStep = cfg.MinTileStep
+	if thumb.Width() > sampleWidth {
+		if err := thumb.Resize(float64(sampleWidth)/float64(thumb.Width()), vips.KernelLinear); err != nil {
+			return nil, fmt.Errorf("resize error: %w", err)
+		}
+	}
+	data, _, err := thumb.ExportPng(vips.NewPngExportParams())
+	if err != nil {
+		return nil, fmt.Errorf("exportPng error: %w", err)
+	}
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode sample error: %w", err)
+	}
+	return newLumaSampler(decoded, img.Width()), nil
+}
+
+type tileKey struct {
+	text   string
+	light  bool
+	jitter float64
+}
+
+// tileSet loads one watermark image per distinct row text (and background
+// brightness with AutoContrast, and rotation jitter step), matching the color space and band format of
+// the base image
+type tileSet struct {
+	base     *vips.ImageRef
+	cfg      Config
+	fontSize float64
+	sampler  *lumaSampler
+	excluded exclusionSet
+	refs     map[tileKey]*vips.ImageRef
+}
+
+// light reports whether the tile at r lies on a light region, always false
+// without AutoContrast
+func (t *tileSet) light(r image.Rectangle) bool {
+	return t.sampler.light(r)
+}
+
+func (t *tileSet) forRow(row int, light bool) (*vips.ImageRef, error) {
+	return t.forTile(row, light, 0)
+}
+
+// forTile returns the tile of row rotated by jitter degrees more than the base angle
+func (t *tileSet) forTile(row int, light bool, jitter float64) (*vips.ImageRef, error) {
+	key := tileKey{text: t.cfg.rowText(row), light: light, jitter: jitter}
+	if ref, ok := t.refs[key]; ok {
+		return ref, nil
+	}
+
+	paint := t.cfg.Style.paint(light)
+	watermarkPNG, err := createTextWatermarkPNG(key.text, t.cfg.Alpha, t.fontSize, t.cfg.Angle+jitter, paint)
+	if err != nil {
+		return nil, fmt.Errorf("createTextWatermarkPNG error: %w", err)
+	}
+
+	wmRef, err := vips.NewImageFromBuffer(watermarkPNG)
+	if err != nil {
+		return nil, fmt.Errorf("newImageFromBuffer error: %w", err)
 	}
-	yStep := int(float64(wmHeight) * cfg.TileSpacingFactor)
+	t.refs[key] = wmRef
+
+	if err := ensureRGBA(wmRef); err != nil {
+		return nil, fmt.Errorf("ensureRGBA error: %w", err)
+	}
+
+	if wmRef.Interpretation() != t.base.Interpretation() {
+		if err := wmRef.ToColorSpace(t.base.Interpretation()); err != nil {
+			return nil, fmt.Errorf("toColorSpace error: %w", err)
+		}
+	}
+
+	if wmRef.BandFormat() != t.base.BandFormat() {
+		if err := wmRef.Cast(t.base.BandFormat()); err != nil {
+			return nil, fmt.Errorf("cast error: %w", err)
+		}
+	}
+	return wmRef, nil
+}
+
+func (t *tileSet) close() {
+	for _, ref := range t.refs {
+		ref.Close()
+	}
+}
+
+// buildCompositeGrid tiles the watermarks over the base image, the row step
+// follows the first row and the column step the width of each row's text
+func buildCompositeGrid(baseRef *vips.ImageRef, tiles *tileSet, cfg Config) ([]*vips.ImageComposite, error) {
+	first, err := tiles.forRow(0, false)
+	if err != nil {
+		return nil, err
+	}
+	yStep := int(float64(first.Height()) * cfg.TileSpacingFactor)
 	if yStep < cfg.MinTileStep {
 		yStep = cfg.MinTileStep
 	}
 
 	var items []*vips.ImageComposite
 	row := 0
-	for y := -wmHeight; y < baseRef.Height()+wmHeight; y += yStep {
+	for y := -first.Height(); y < baseRef.Height()+first.Height(); y += yStep {
+		// the light and dark variants of a row have the same size
+		rowRef, err := tiles.forRow(row, false)
+		if err != nil {
+			return nil, err
+		}
+		wmWidth := rowRef.Width()
+		wmHeight := rowRef.Height()
+
+		xStep := int(float64(wmWidth) * cfg.TileSpacingFactor)
+		if xStep < cfg.MinTileStep {
+			xStep = cfg.MinTileStep
+		}
+
 		rowOffset := 0
 		if row%2 != 0 {
 			rowOffset = xStep / 2
 		}
-		for x := -wmWidth; x < baseRef.Width()+wmWidth; x += xStep {
+		for col, x := 0, -wmWidth; x < baseRef.Width()+wmWidth; col, x = col+1, x+xStep {
 			finalX := x + rowOffset
 			finalY := y
 
@@ -230,18 +486,27 @@ func buildCompositeGrid(baseRef, wmRef *vips.ImageRef, cfg Config) []*vips.Image
 			if finalY+wmHeight <= 0 || finalY >= baseRef.Height() {
 				continue
 			}
+			if tiles.excluded.blocks(image.Rect(finalX, finalY, finalX+wmWidth, finalY+wmHeight)) {
+				continue
+			}
 
+			light := tiles.light(image.Rect(finalX, finalY, finalX+wmWidth, finalY+wmHeight))
+			wmRef, err := tiles.forTile(row, light, tileJitter(cfg.RotationJitter, row, col))
+			if err != nil {
+				return nil, err
+			}
+			// a jittered tile has a different bounding box, keep it centered on the grid
 			items = append(items, &vips.ImageComposite{
 				Image:     wmRef,
 				BlendMode: vips.BlendModeOver,
-				X:         finalX,
-				Y:         finalY,
+				X:         finalX + (wmWidth-wmRef.Width())/2,
+				Y:         finalY + (wmHeight-wmRef.Height())/2,
 			})
 		}
 		row++
 	}
 
-	return items
+	return items, nil
 }
 
 func ensureRGBA(img *vips.ImageRef) error {
@@ -260,9 +525,9 @@ func ensureRGBA(img *vips.ImageRef) error {
 	return nil
 }
 
-func createTextWatermarkPNG(text string, alpha int, fontSize float64) ([]byte, error) {
-	// 使用 LRU 缓存，key 包含文字、透明度和字号（保留一位小数）
-	cacheKey := fmt.Sprintf("%s_%d_%.1f", text, alpha, fontSize)
+func createTextWatermarkPNG(text string, alpha int, fontSize, angle float64, paint textPaint) ([]byte, error) {
+	// 使用 LRU 缓存，key 包含文字、透明度、字号、角度（保留一位小数）和颜色样式
+	cacheKey := fmt.Sprintf("%s_%d_%.1f_%.1f_%v", text, alpha, fontSize, angle, paint)
 	if data, ok := wmLRU.Get(cacheKey); ok {
 		return data, nil
 	}
@@ -290,7 +555,7 @@ func createTextWatermarkPNG(text string, alpha int, fontSize float64) ([]byte, e
 	textWidth := int((textBounds.X - ptStart.X) >> 6)
 	textHeight := int(fontSize * 1.2)
 
-	padding := 10
+	padding := 10 + paint.margin()
 	width := textWidth + padding*2
 	height := textHeight + padding*2
 
@@ -298,14 +563,29 @@ func createTextWatermarkPNG(text string, alpha int, fontSize float64) ([]byte, e
 
 	c.SetClip(img.Bounds())
 	c.SetDst(img)
-	c.SetSrc(image.NewUniform(color.RGBA{255, 255, 255, uint8(alpha)})) // more obvious semi-transparent white
 
 	pt := freetype.Pt(padding, padding+int(c.PointToFixed(fontSize)>>6))
-	if _, err := c.DrawString(text, pt); err != nil {
+	drawAt := func(col color.NRGBA, dx, dy float64) error {
+		c.SetSrc(image.NewUniform(col))
+		_, err := c.DrawString(text, fixed.Point26_6{X: pt.X + fixed.Int26_6(dx*64), Y: pt.Y + fixed.Int26_6(dy*64)})
+		return err
+	}
+	if paint.hasShadow() {
+		if err := drawAt(paint.shadow, paint.shadowDX, paint.shadowDY); err != nil {
+			return nil, err
+		}
+	}
+	for _, off := range paint.outlineOffsets() {
+		if err := drawAt(paint.outline, off[0], off[1]); err != nil {
+			return nil, err
+		}
+	}
+	if err := drawAt(paint.fill, 0, 0); err != nil {
 		return nil, err
 	}
+	fade(img, uint8(alpha))
 
-	rotatedImg := imaging.Rotate(img, 30, color.Transparent)
+	rotatedImg := imaging.Rotate(img, angle, color.Transparent)
 
 	var pngBuf bytes.Buffer
 	if err := imaging.Encode(&pngBuf, rotatedImg, imaging.PNG); err != nil {
@@ -6,21 +6,28 @@ package watermark
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"image"
+	"image/color"
+	imagedraw "image/draw"
 	"image/jpeg"
 	"image/png"
 	"io"
-	"net/http"
+	"math"
 	"strings"
 
+	"github.com/disintegration/imaging"
 	"github.com/fogleman/gg"
 	"github.com/golang/freetype/truetype"
-	"github.com/zeromicro/go-zero/core/logc"
 	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/webp"
 )
 
+// renderer 区分构建方式，写入缓存 key，两种构建的输出不同
+const renderer = "go"
+
 // smartDecode 解决 image.Decode 对 OSS URL 格式识别失败的问题
-func smartDecode(r io.Reader, contentType string) (image.Image, string, error) {
+func smartDecode(r io.Reader, contentType string) (image.Image, Format, error) {
 	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, "", err
@@ -31,125 +38,232 @@ func smartDecode(r io.Reader, contentType string) (image.Image, string, error) {
 	ct := strings.ToLower(contentType)
 	if strings.Contains(ct, "jpeg") || strings.Contains(ct, "jpg") {
 		img, err := jpeg.Decode(buf)
-		return img, "jpeg", err
+		return img, FormatJPEG, err
 	}
 
 	if strings.Contains(ct, "png") {
 		img, err := png.Decode(buf)
-		return img, "png", err
+		return img, FormatPNG, err
+	}
+
+	if strings.Contains(ct, "webp") {
+		img, err := webp.Decode(buf)
+		return img, FormatWebP, err
 	}
 
-	// 2. fallback 自动探测 PNG → JPEG
+	// 2. fallback 自动探测 PNG → JPEG → WebP
 	img, err := png.Decode(bytes.NewBuffer(data))
 	if err == nil {
-		return img, "png", nil
+		return img, FormatPNG, nil
 	}
 
 	img, err = jpeg.Decode(bytes.NewBuffer(data))
 	if err == nil {
-		return img, "jpeg", nil
+		return img, FormatJPEG, nil
+	}
+
+	if img, werr := webp.Decode(bytes.NewBuffer(data)); werr == nil {
+		return img, FormatWebP, nil
 	}
 
 	return nil, "", err
 }
 
-func AddFromBytes(ctx context.Context, body []byte, text string) (io.ReadCloser, error) {
-	smarIm, format, err := smartDecode(bytes.NewBuffer(body), "")
+func encode(ctx context.Context, in input, text string, o *options, w io.Writer) (Format, error) {
+	im, format, tiff, err := decode(ctx, in, o)
 	if err != nil {
-		logc.Errorf(ctx, "AddWatermark decode image failed, err: %v", err)
-		return nil, err
+		return "", err
 	}
-	return draw(ctx, smarIm, format, text)
-}
 
-func Add(ctx context.Context, uri string, watermarkText string) (io.ReadCloser, error) {
-	const fontSize = 48
+	if o.exif == EXIFStrip || tiff == nil {
+		return draw(ctx, im, format, text, o, w)
+	}
 
-	var (
-		im     image.Image
-		format string
-	)
+	// EXIF 需要插入到编码结果中，先写入缓冲
+	var buf bytes.Buffer
+	if format, err = draw(ctx, im, format, text, o, &buf); err != nil {
+		return "", err
+	}
+	out := embedEXIF(buf.Bytes(), format, normalizeEXIF(tiff, o.exif == EXIFKeepNoGPS))
+	if _, err := w.Write(out); err != nil {
+		return "", fmt.Errorf("write output failed: %w", err)
+	}
+	return format, nil
+}
 
-	// ---------- 1. 加载图片 ----------
-	if strings.HasPrefix(uri, "http://") || strings.HasPrefix(uri, "https://") {
-		resp, err := http.Get(uri)
-		if err != nil {
-			logc.Errorf(ctx, "AddWatermark load http image failed, err: %v", err)
-			return nil, err
-		}
-		defer resp.Body.Close()
+// decode 读取并解码输入，按 EXIF 方向旋转，返回原始 EXIF（TIFF 结构）
+func decode(ctx context.Context, in input, o *options) (_ image.Image, _ Format, _ []byte, err error) {
+	ctx, end := startPhase(ctx, phaseDecode)
+	defer func() { end(err) }()
 
-		im, format, err = smartDecode(resp.Body, resp.Header.Get("Content-Type"))
-		if err != nil {
-			logc.Errorf(ctx, "AddWatermark decode http image failed, err: %v", err)
-			return nil, err
-		}
+	data, contentType, err := loadInput(ctx, in, o.downloadLimit())
+	if err != nil {
+		return nil, "", nil, err
+	}
 
-	} else {
-		// 本地文件
-		raw, err := gg.LoadImage(uri)
-		if err != nil {
-			logc.Errorf(ctx, "AddWatermark load local image failed, err: %v", err)
-			return nil, err
-		}
-		im = raw
+	// HEIC/AVIF 需要 libheif，纯 Go 无法解码
+	if f := sniffFormat(data); f == FormatHEIC || f == FormatAVIF {
+		return nil, "", nil, fmt.Errorf("%w: %s input requires the libvips (cgo) build", ErrUnsupportedFormat, f)
+	}
 
-		if strings.HasSuffix(strings.ToLower(uri), ".png") {
-			format = "png"
-		} else {
-			format = "jpeg"
+	// 只解析头部，超过像素上限时不做完整解码
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		if err := checkPixels(cfg.Width, cfg.Height, o.pixelLimit()); err != nil {
+			return nil, "", nil, err
 		}
 	}
 
-	return draw(ctx, im, format, watermarkText)
+	im, format, err := smartDecode(bytes.NewReader(data), contentType)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("decode image failed: %w", err)
+	}
+
+	// 与 libvips 的 AutoRotate 保持一致
+	tiff := readEXIF(data)
+	return applyOrientation(im, exifOrientation(tiff)), format, tiff, nil
 }
 
-func draw(ctx context.Context, im image.Image, format string, watermarkText string) (io.ReadCloser, error) {
-	const fontSize = 48
+// fallbackFormat 纯 Go 没有 WebP/AVIF/HEIC 编码器：WebP 用无损的 PNG 代替（保留透明度），AVIF/HEIC 用 JPEG 代替
+func fallbackFormat(f Format) Format {
+	switch f {
+	case FormatWebP:
+		return FormatPNG
+	case FormatAVIF, FormatHEIC:
+		return FormatJPEG
+	}
+	return f
+}
+
+func draw(ctx context.Context, im image.Image, format Format, watermarkText string, o *options, output io.Writer) (Format, error) {
+	fontSize := withDefault(o.fontSize, 48*withDefault(o.fontScale, 1))
+	alpha := uint8(withDefault(o.alpha, 64))
+	angle := 30.0
+	if o.angleSet {
+		angle = o.angle
+	}
 
 	var (
 		err error
 	)
 
 	// ---------- 2. 绘制水印 ----------
+	uprightWidth := im.Bounds().Dx()
+	if o.maxWidth > 0 && im.Bounds().Dx() > o.maxWidth {
+		_, endResize := startPhase(ctx, phaseResize)
+		im = imaging.Resize(im, o.maxWidth, 0, imaging.Lanczos)
+		endResize(nil)
+	}
 	w := im.Bounds().Dx()
 	h := im.Bounds().Dy()
-	dc := gg.NewContextForImage(im)
 
+	_, endComposite := startPhase(ctx, phaseComposite)
 	font, err := truetype.Parse(goregular.TTF)
 	if err != nil {
-		logc.Errorf(ctx, "AddWatermark parse font failed, err: %v", err)
-		return nil, err
+		endComposite(err)
+		return "", fmt.Errorf("parse font failed: %w", err)
 	}
 
+	// 水印以不透明颜色画在单独的图层上，再整体按 alpha 叠加，描边和阴影的重叠处不会变深
+	dc := gg.NewContext(w, h)
 	dc.SetFontFace(truetype.NewFace(font, &truetype.Options{Size: fontSize}))
-	dc.SetRGBA(1, 1, 1, 0.25)
-	dc.RotateAbout(gg.Radians(-30), float64(w)/2, float64(h)/2)
+	dc.RotateAbout(gg.Radians(-angle), float64(w)/2, float64(h)/2)
 
-	textWidth, textHeight := dc.MeasureString(watermarkText)
-	xStep := textWidth * 2
+	// 排除区域（人脸、签名等）按缩放后的尺寸换算
+	excluded := newExclusionSet(o.exclusions, uprightWidth, w)
+	margin := float64(o.style.paint(false).margin())
+
+	// 自动对比度：按缩略图判断每个水印下方的亮度
+	var sampler *lumaSampler
+	if o.style.AutoContrast {
+		sampler = newLumaSampler(imaging.Resize(im, min(sampleWidth, w), 0, imaging.Box), w)
+	}
+
+	// 每行文字可能不同（模板 / WithRowTemplates），行距按第一行计算，列距按当前行计算
+	texts := newRowTexts(watermarkText, o)
+	_, textHeight := dc.MeasureString(texts.text(0))
 	yStep := textHeight * 3
+	if o.tileSpacing > 0 {
+		yStep = textHeight * 2 * o.tileSpacing
+	}
+	// keep the loops finite for empty or tiny text
+	yStep = max(yStep, 1)
+
+	row := 0
+	for y := -h; y < 2*h; y += int(yStep) {
+		text := texts.text(row)
+		textWidth, _ := dc.MeasureString(text)
+		xStep := textWidth * 2
+		if o.tileSpacing > 0 {
+			xStep = textWidth * o.tileSpacing
+		}
+		xStep = max(xStep, 1)
 
-	for x := -w; x < 2*w; x += int(xStep) {
-		for y := -h; y < 2*h; y += int(yStep) {
-			dc.DrawStringAnchored(watermarkText, float64(x), float64(y), 0.5, 0.5)
+		for col, x := 0, -w; x < 2*w; col, x = col+1, x+int(xStep) {
+			// 抖动角度绕文字中心旋转，外接矩形按旋转后计算
+			dc.Push()
+			dc.RotateAbout(gg.Radians(-tileJitter(o.jitter, row, col)), float64(x), float64(y))
+			bounds := tileBounds(dc, float64(x), float64(y), textWidth+2*margin, textHeight+2*margin)
+			if !excluded.blocks(bounds) {
+				light := false
+				if sampler != nil {
+					light = sampler.light(bounds)
+				}
+				drawText(dc, text, float64(x), float64(y), o.style.paint(light))
+			}
+			dc.Pop()
 		}
+		row++
 	}
 
+	out := imaging.Clone(im)
+	imagedraw.DrawMask(out, out.Bounds(), dc.Image(), image.Point{}, image.NewUniform(color.Alpha{A: alpha}), image.Point{}, imagedraw.Over)
+	endComposite(nil)
+
 	// ---------- 3. 保存 ----------
-	var output bytes.Buffer
+	_, endEncode := startPhase(ctx, phaseEncode)
+	format = fallbackFormat(outputFormat(o.format, format, format))
 
 	switch format {
-	case "png":
-		err = png.Encode(&output, dc.Image())
+	case FormatPNG:
+		err = png.Encode(output, out)
 	default:
-		err = jpeg.Encode(&output, dc.Image(), &jpeg.Options{Quality: 95})
+		err = jpeg.Encode(output, out, &jpeg.Options{Quality: withDefault(o.quality, 95)})
 	}
+	endEncode(err)
 
 	if err != nil {
-		logc.Errorf(ctx, "AddWatermark encode image failed, err: %v", err)
-		return nil, err
+		return "", fmt.Errorf("encode image failed: %w", err)
+	}
+
+	// Go encoders are deterministic and write no metadata, so the output is
+	// reproducible with or without WithDeterministic
+	return format, nil
+}
+
+// tileBounds 返回以 (x, y) 为中心的文字旋转后在图片上的外接矩形
+func tileBounds(dc *gg.Context, x, y, textWidth, textHeight float64) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, corner := range [4][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+		px, py := dc.TransformPoint(x+corner[0]*textWidth/2, y+corner[1]*textHeight/2)
+		minX, minY = min(minX, px), min(minY, py)
+		maxX, maxY = max(maxX, px), max(maxY, py)
 	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
 
-	return io.NopCloser(&output), nil
+// drawText 依次绘制阴影、描边和文字
+func drawText(dc *gg.Context, text string, x, y float64, p textPaint) {
+	if p.hasShadow() {
+		dc.SetColor(p.shadow)
+		dc.DrawStringAnchored(text, x+p.shadowDX, y+p.shadowDY, 0.5, 0.5)
+	}
+	if offsets := p.outlineOffsets(); len(offsets) > 0 {
+		dc.SetColor(p.outline)
+		for _, off := range offsets {
+			dc.DrawStringAnchored(text, x+off[0], y+off[1], 0.5, 0.5)
+		}
+	}
+	dc.SetColor(p.fill)
+	dc.DrawStringAnchored(text, x, y, 0.5, 0.5)
 }